@@ -0,0 +1,216 @@
+package dagger
+
+import (
+	"errors"
+	"fmt"
+	"github.com/autom8ter/dagger/primitive"
+	"math"
+	"sync"
+	"time"
+)
+
+// Metric computes a value from the current state of the graph
+type Metric func() float64
+
+// NodeCountMetric returns the number of nodes of the given type
+func (g *Graph) NodeCountMetric(typ primitive.Type) Metric {
+	return func() float64 {
+		count := 0
+		g.graph.RangeNodeTypes(typ, func(n primitive.Node) bool {
+			count++
+			return true
+		})
+		return float64(count)
+	}
+}
+
+// NodeCountMetric calls Graph.NodeCountMetric on the default graph
+func NodeCountMetric(typ primitive.Type) Metric {
+	return defaultGraph.NodeCountMetric(typ)
+}
+
+// MaxInDegreeMetric returns the highest number of edges of the given type pointing to a single node
+func (g *Graph) MaxInDegreeMetric(edgeType primitive.Type) Metric {
+	return func() float64 {
+		max := 0
+		g.graph.RangeNodes(func(n primitive.Node) bool {
+			degree := 0
+			g.graph.EdgesTo(edgeType, n, func(e *primitive.Edge) bool {
+				degree++
+				return true
+			})
+			if degree > max {
+				max = degree
+			}
+			return true
+		})
+		return float64(max)
+	}
+}
+
+// MaxInDegreeMetric calls Graph.MaxInDegreeMetric on the default graph
+func MaxInDegreeMetric(edgeType primitive.Type) Metric {
+	return defaultGraph.MaxInDegreeMetric(edgeType)
+}
+
+// ComponentCountMetric returns the number of weakly connected components in the graph
+func (g *Graph) ComponentCountMetric() Metric {
+	return func() float64 {
+		return float64(g.graph.ComponentCount())
+	}
+}
+
+// ComponentCountMetric calls Graph.ComponentCountMetric on the default graph
+func ComponentCountMetric() Metric {
+	return defaultGraph.ComponentCountMetric()
+}
+
+// DriftRateMetric returns the relative change in the total number of nodes & edges since the metric was last computed.
+// The first computation always returns 0.
+func (g *Graph) DriftRateMetric() Metric {
+	var (
+		mu   sync.Mutex
+		last = -1
+	)
+	return func() float64 {
+		mu.Lock()
+		defer mu.Unlock()
+		current := g.NodeCount() + g.EdgeCount()
+		previous := last
+		last = current
+		if previous <= 0 {
+			return 0
+		}
+		return math.Abs(float64(current-previous)) / float64(previous)
+	}
+}
+
+// DriftRateMetric calls Graph.DriftRateMetric on the default graph
+func DriftRateMetric() Metric {
+	return defaultGraph.DriftRateMetric()
+}
+
+// AlertRule fires an alert when its metric crosses the threshold
+type AlertRule struct {
+	// Name identifies the rule in fired alerts
+	Name string
+	// Metric is the value the rule observes
+	Metric Metric
+	// Threshold is the value the metric is compared against
+	Threshold float64
+	// Below fires the rule when the metric drops below the threshold instead of rising above it
+	Below bool
+}
+
+func (r *AlertRule) violated(value float64) bool {
+	if r.Below {
+		return value < r.Threshold
+	}
+	return value > r.Threshold
+}
+
+// Alert is fired when an AlertRule's threshold is crossed
+type Alert = primitive.Alert
+
+// Alerter evaluates alert rules against the graph, notifying when a rule's threshold is crossed. A rule only fires again
+// after its metric has recovered.
+type Alerter struct {
+	mu      sync.Mutex
+	graph   *Graph
+	rules   []*AlertRule
+	firing  map[*AlertRule]bool
+	notify  func(a Alert)
+	stop    chan struct{}
+	once    sync.Once
+	started bool
+}
+
+// NewAlerter creates an Alerter that passes fired alerts to notify & publishes them to the graph's subscribers as
+// primitive.ChangeAlert changes. It returns an error if notify is nil.
+func (g *Graph) NewAlerter(notify func(a Alert)) (*Alerter, error) {
+	if notify == nil {
+		return nil, errors.New("missing alert notify func")
+	}
+	return &Alerter{
+		graph:  g,
+		firing: map[*AlertRule]bool{},
+		notify: notify,
+		stop:   make(chan struct{}),
+	}, nil
+}
+
+// NewAlerter calls Graph.NewAlerter on the default graph
+func NewAlerter(notify func(a Alert)) (*Alerter, error) {
+	return defaultGraph.NewAlerter(notify)
+}
+
+// AddRule registers the rule with the Alerter. Rules are tracked individually, so rules sharing a name fire
+// independently.
+func (a *Alerter) AddRule(rule AlertRule) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.rules = append(a.rules, &rule)
+}
+
+// Evaluate computes every rule's metric once, notifying and returning the alerts that fired
+func (a *Alerter) Evaluate() []Alert {
+	a.mu.Lock()
+	var alerts []Alert
+	for _, rule := range a.rules {
+		value := rule.Metric()
+		if !rule.violated(value) {
+			a.firing[rule] = false
+			continue
+		}
+		if a.firing[rule] {
+			continue
+		}
+		a.firing[rule] = true
+		alerts = append(alerts, Alert{
+			Rule:      rule.Name,
+			Value:     value,
+			Threshold: rule.Threshold,
+			Time:      time.Now(),
+		})
+	}
+	a.mu.Unlock()
+	for _, alert := range alerts {
+		a.notify(alert)
+		a.graph.graph.PublishAlert(alert)
+	}
+	return alerts
+}
+
+// Start evaluates the rules on the given interval in the background until Stop is called. Calling Start again has no
+// effect. It returns an error if the interval is not positive.
+func (a *Alerter) Start(interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("dagger: alert interval must be positive, got %v", interval)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.started {
+		return nil
+	}
+	a.started = true
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-a.stop:
+				return
+			case <-ticker.C:
+				a.Evaluate()
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops background evaluation
+func (a *Alerter) Stop() {
+	a.once.Do(func() {
+		close(a.stop)
+	})
+}
@@ -0,0 +1,154 @@
+package dagger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	snapshotPrefix = "snapshot-"
+	snapshotSuffix = ".json.gz"
+)
+
+// EnableAutoSnapshot writes a gzip compressed json snapshot of the graph into dir every interval, in the background,
+// until the returned stop function is called(it is safe to call more than once, and returns once any snapshot in
+// progress is written). Only the newest keep snapshots are kept. Each snapshot is written to a temporary file &
+// renamed, so a crash never leaves a partial snapshot behind. Errors are passed to onError if it is not nil. Call
+// RestoreSnapshot at startup to load the newest snapshot.
+func (g *Graph) EnableAutoSnapshot(dir string, interval time.Duration, keep int, onError func(err error)) (stop func(), err error) {
+	if keep < 1 {
+		return nil, fmt.Errorf("dagger: auto snapshot must keep at least 1 snapshot, got %v", keep)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if _, err := g.WriteSnapshot(dir, keep); err != nil && onError != nil {
+					onError(fmt.Errorf("dagger: auto snapshot: %w", err))
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+		<-exited
+	}, nil
+}
+
+// EnableAutoSnapshot calls Graph.EnableAutoSnapshot on the default graph
+func EnableAutoSnapshot(dir string, interval time.Duration, keep int, onError func(err error)) (stop func(), err error) {
+	return defaultGraph.EnableAutoSnapshot(dir, interval, keep, onError)
+}
+
+// WriteSnapshot writes a gzip compressed json snapshot of the graph into dir, then removes all but the newest keep
+// snapshots, returning the path of the new snapshot. Health reports the age of the newest snapshot written. If the
+// graph logs its changes to a write-ahead log(see Recover), the log is checkpointed(see CheckpointWAL) once the snapshot
+// is written.
+func (g *Graph) WriteSnapshot(dir string, keep int) (string, error) {
+	tmp, err := os.CreateTemp(dir, "."+snapshotPrefix+"*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if err := g.ExportJSONGzip(tmp); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	// zero padded timestamps sort lexically in the order the snapshots were taken
+	now := time.Now()
+	path := filepath.Join(dir, fmt.Sprintf("%s%020d%s", snapshotPrefix, now.UnixNano(), snapshotSuffix))
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	g.graph.Snapshotted(now)
+	if err := g.graph.CheckpointWAL(); err != nil && !errors.Is(err, ErrNoWAL) {
+		return "", err
+	}
+	snapshots, err := listSnapshots(dir)
+	if err != nil {
+		return "", err
+	}
+	for len(snapshots) > keep {
+		if err := os.Remove(snapshots[0]); err != nil {
+			return "", err
+		}
+		snapshots = snapshots[1:]
+	}
+	return path, nil
+}
+
+// WriteSnapshot calls Graph.WriteSnapshot on the default graph
+func WriteSnapshot(dir string, keep int) (string, error) {
+	return defaultGraph.WriteSnapshot(dir, keep)
+}
+
+// RestoreSnapshot imports the newest snapshot in dir written by WriteSnapshot or EnableAutoSnapshot into the graph,
+// returning false if there is none
+func (g *Graph) RestoreSnapshot(dir string) (bool, error) {
+	snapshots, err := listSnapshots(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if len(snapshots) == 0 {
+		return false, nil
+	}
+	f, err := os.Open(snapshots[len(snapshots)-1])
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	if err := g.ImportJSON(f); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RestoreSnapshot calls Graph.RestoreSnapshot on the default graph
+func RestoreSnapshot(dir string) (bool, error) {
+	return defaultGraph.RestoreSnapshot(dir)
+}
+
+// listSnapshots returns the paths of the snapshots in dir from oldest to newest
+func listSnapshots(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []string
+	for _, entry := range entries {
+		if name := entry.Name(); strings.HasPrefix(name, snapshotPrefix) && strings.HasSuffix(name, snapshotSuffix) {
+			snapshots = append(snapshots, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(snapshots)
+	return snapshots, nil
+}
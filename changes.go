@@ -0,0 +1,91 @@
+package dagger
+
+import (
+	"encoding/json"
+	"github.com/autom8ter/dagger/encoding"
+	"github.com/autom8ter/dagger/primitive"
+	"io"
+)
+
+// Checkpoint is a position in the sequence of changes made to a graph
+type Checkpoint = primitive.Checkpoint
+
+// ErrChangesNotTracked is returned when exporting the changes made to a graph created without TrackChanges
+var ErrChangesNotTracked = primitive.ErrChangesNotTracked
+
+// TrackChanges records the sequence of changes made to the graph, so ExportSince can export only the changes since a
+// checkpoint. A tombstone is kept for every deleted node & edge until it is pruned with PruneChanges.
+func TrackChanges() Option {
+	return func(g *Graph) {
+		g.options = append(g.options, primitive.TrackChanges())
+	}
+}
+
+// ErrCheckpointPruned is returned when exporting the changes since a checkpoint whose deletions were forgotten by
+// PruneChanges
+var ErrCheckpointPruned = primitive.ErrCheckpointPruned
+
+// CurrentCheckpoint returns the checkpoint of the graph's latest change
+func (g *Graph) CurrentCheckpoint() Checkpoint {
+	return g.graph.Checkpoint()
+}
+
+// CurrentCheckpoint calls Graph.CurrentCheckpoint on the default graph
+func CurrentCheckpoint() Checkpoint {
+	return defaultGraph.CurrentCheckpoint()
+}
+
+// ExportSince exports only the nodes & edges added, patched or deleted after the checkpoint as a json blob into the io
+// Writer, returning the checkpoint to pass to the next call. The zero checkpoint exports the whole graph. Apply the
+// exports in order with ImportChanges to restore the graph from a full backup & its incremental backups. The graph
+// must be created with TrackChanges. The transforms are applied to a copy of every exported node & edge, as in
+// ExportJSON.
+func (g *Graph) ExportSince(checkpoint Checkpoint, w io.Writer, transforms ...primitive.Transform) (Checkpoint, error) {
+	defer g.trace("ExportSince", nil)()
+	changes, err := g.graph.ChangesSince(checkpoint)
+	if err != nil {
+		return checkpoint, err
+	}
+	if len(transforms) > 0 {
+		changes = changes.Transform(transforms...)
+	}
+	if err := json.NewEncoder(w).Encode(changes); err != nil {
+		return checkpoint, err
+	}
+	return changes.Checkpoint, nil
+}
+
+// ExportSince calls Graph.ExportSince on the default graph
+func ExportSince(checkpoint Checkpoint, w io.Writer, transforms ...primitive.Transform) (Checkpoint, error) {
+	return defaultGraph.ExportSince(checkpoint, w, transforms...)
+}
+
+// ImportChanges applies an export written by ExportSince to the graph. Gzip compressed exports are decompressed.
+func (g *Graph) ImportChanges(r io.Reader) error {
+	defer g.trace("ImportChanges", nil)()
+	r, err := encoding.Decompress(r)
+	if err != nil {
+		return err
+	}
+	changes := &primitive.Changes{}
+	if err := json.NewDecoder(r).Decode(changes); err != nil {
+		return err
+	}
+	return g.graph.ApplyChanges(changes)
+}
+
+// ImportChanges calls Graph.ImportChanges on the default graph
+func ImportChanges(r io.Reader) error {
+	return defaultGraph.ImportChanges(r)
+}
+
+// PruneChanges forgets the deletions made up to the checkpoint to free memory. Call it once every export up to the
+// checkpoint has been taken: ExportSince returns ErrCheckpointPruned for earlier checkpoints afterwards.
+func (g *Graph) PruneChanges(checkpoint Checkpoint) {
+	g.graph.PruneChanges(checkpoint)
+}
+
+// PruneChanges calls Graph.PruneChanges on the default graph
+func PruneChanges(checkpoint Checkpoint) {
+	defaultGraph.PruneChanges(checkpoint)
+}
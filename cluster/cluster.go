@@ -0,0 +1,250 @@
+// Package cluster replicates a dagger graph across instances with Raft(github.com/hashicorp/raft). One instance, the
+// leader, accepts writes: each write is committed to a quorum of the cluster's raft logs, then applied to the graph of
+// every instance, so followers serve reads of the same graph. If the leader fails, the remaining instances elect a new
+// one.
+//
+// The package is a separate module, so programs that do not replicate their graph do not depend on raft.
+package cluster
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/autom8ter/dagger"
+	"github.com/autom8ter/dagger/primitive"
+	"github.com/hashicorp/raft"
+	"io"
+	"time"
+)
+
+// ErrNotLeader is returned when writing to an instance that is not the cluster's leader. Send the write to the instance
+// at Leader instead.
+var ErrNotLeader = errors.New("dagger: cluster instance is not the leader")
+
+// Cluster is an instance of a replicated graph. Write to it through its methods, never directly to its graph, or the
+// write will not be replicated.
+type Cluster struct {
+	graph   *dagger.Graph
+	raft    *raft.Raft
+	timeout time.Duration
+}
+
+// Option configures a Cluster
+type Option func(c *Cluster)
+
+// WithApplyTimeout sets how long a write waits to be committed before it fails(the default is 10s)
+func WithApplyTimeout(timeout time.Duration) Option {
+	return func(c *Cluster) {
+		c.timeout = timeout
+	}
+}
+
+// New starts a raft instance that replicates the graph. The arguments are passed to raft.NewRaft: pick durable log,
+// stable & snapshot stores(ex: raft-boltdb & raft.NewFileSnapshotStore) and a network transport(ex:
+// raft.NewTCPTransport) for production. The graph should start empty: it is rebuilt from the cluster's snapshots & log
+// as the instance catches up. Call Bootstrap on one instance of a new cluster.
+func New(g *dagger.Graph, conf *raft.Config, logs raft.LogStore, stable raft.StableStore, snaps raft.SnapshotStore, trans raft.Transport, opts ...Option) (*Cluster, error) {
+	c := &Cluster{
+		graph:   g,
+		timeout: 10 * time.Second,
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	r, err := raft.NewRaft(conf, NewFSM(g), logs, stable, snaps, trans)
+	if err != nil {
+		return nil, err
+	}
+	c.raft = r
+	return c, nil
+}
+
+// Bootstrap makes the servers the initial members of a new cluster. Call it on a single instance, listing every
+// instance(including itself), the first time the cluster is started.
+func (c *Cluster) Bootstrap(servers ...raft.Server) error {
+	return c.raft.BootstrapCluster(raft.Configuration{Servers: servers}).Error()
+}
+
+// Graph returns the instance's replica of the graph. It may be read on any instance, but it lags the leader until the
+// instance applies the latest writes. Use Barrier on the leader for reads that must observe every committed write.
+func (c *Cluster) Graph() *dagger.ReadOnlyGraph {
+	return c.graph.View()
+}
+
+// Raft returns the instance's raft node, for operations(ex: stats & leadership transfer) the cluster does not wrap
+func (c *Cluster) Raft() *raft.Raft {
+	return c.raft
+}
+
+// IsLeader returns true if the instance is the cluster's leader & accepts writes
+func (c *Cluster) IsLeader() bool {
+	return c.raft.State() == raft.Leader
+}
+
+// Leader returns the address & id of the cluster's leader, or empty strings if there is no leader
+func (c *Cluster) Leader() (raft.ServerAddress, raft.ServerID) {
+	return c.raft.LeaderWithID()
+}
+
+// Join adds a voting instance to the cluster. It must be called on the leader.
+func (c *Cluster) Join(id raft.ServerID, addr raft.ServerAddress) error {
+	return c.wrap(c.raft.AddVoter(id, addr, 0, c.timeout).Error())
+}
+
+// Leave removes an instance from the cluster. It must be called on the leader.
+func (c *Cluster) Leave(id raft.ServerID) error {
+	return c.wrap(c.raft.RemoveServer(id, 0, c.timeout).Error())
+}
+
+// Barrier blocks until every write committed before it has been applied to the leader's graph
+func (c *Cluster) Barrier() error {
+	return c.wrap(c.raft.Barrier(c.timeout).Error())
+}
+
+// Shutdown stops the instance. Its graph is left as it was.
+func (c *Cluster) Shutdown() error {
+	return c.raft.Shutdown().Error()
+}
+
+// Apply commits the changes to the cluster & applies them to every instance's graph. Nodes & edges without an id are
+// given one first, so every instance applies the same change. It must be called on the leader.
+func (c *Cluster) Apply(changes *primitive.Changes) error {
+	for _, n := range changes.Nodes {
+		if !n.HasID() {
+			n.SetID(primitive.UUID())
+		}
+	}
+	for _, e := range changes.Edges {
+		if !e.HasID() {
+			e.SetID(primitive.UUID())
+		}
+	}
+	bits, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	future := c.raft.Apply(bits, c.timeout)
+	if err := future.Error(); err != nil {
+		return c.wrap(err)
+	}
+	if err, ok := future.Response().(error); ok && err != nil {
+		return err
+	}
+	return nil
+}
+
+// SetNode adds the node, or replaces the node with the same type & id, on every instance, returning its id
+func (c *Cluster) SetNode(attributes map[string]interface{}) (primitive.TypedID, error) {
+	n := primitive.Node(attributes).Copy()
+	if n.Type() == "" {
+		n.SetType(primitive.DefaultType)
+	}
+	if err := c.Apply(&primitive.Changes{Nodes: []primitive.Node{n}}); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// DelNode deletes the node & its edges on every instance
+func (c *Cluster) DelNode(id primitive.TypedID) error {
+	return c.Apply(&primitive.Changes{DeletedNodes: []primitive.Node{typedID(id)}})
+}
+
+// Connect adds an edge of the relationship type between the nodes on every instance, returning its id
+func (c *Cluster) Connect(from, to primitive.TypedID, relationship string, attributes map[string]interface{}) (primitive.TypedID, error) {
+	n := primitive.Node(attributes).Copy()
+	n.SetType(relationship)
+	e := &primitive.Edge{Node: n, From: typedID(from), To: typedID(to)}
+	if err := c.Apply(&primitive.Changes{Edges: []*primitive.Edge{e}}); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// DelEdge deletes the edge on every instance
+func (c *Cluster) DelEdge(id primitive.TypedID) error {
+	return c.Apply(&primitive.Changes{DeletedEdges: []primitive.Node{typedID(id)}})
+}
+
+// wrap replaces raft's leadership errors with ErrNotLeader
+func (c *Cluster) wrap(err error) error {
+	if errors.Is(err, raft.ErrNotLeader) || errors.Is(err, raft.ErrLeadershipLost) {
+		addr, _ := c.raft.LeaderWithID()
+		return fmt.Errorf("%w: the leader is %q", ErrNotLeader, addr)
+	}
+	return err
+}
+
+func typedID(id primitive.TypedID) primitive.Node {
+	return primitive.Node{primitive.TYPE_KEY: id.Type(), primitive.ID_KEY: id.ID()}
+}
+
+// FSM applies committed raft log entries to a graph. Each entry is a json encoded primitive.Changes. Cluster uses it,
+// but it can also be passed to raft.NewRaft directly.
+type FSM struct {
+	graph *dagger.Graph
+}
+
+// NewFSM returns a raft.FSM that applies committed changes to the graph
+func NewFSM(g *dagger.Graph) *FSM {
+	return &FSM{graph: g}
+}
+
+// Apply applies a committed entry to the graph, returning the error(if any) applying it
+func (f *FSM) Apply(log *raft.Log) interface{} {
+	if log.Type != raft.LogCommand {
+		return nil
+	}
+	return f.graph.ImportChanges(bytes.NewReader(log.Data))
+}
+
+// Snapshot copies the graph, so it may be persisted while later entries are applied
+func (f *FSM) Snapshot() (raft.FSMSnapshot, error) {
+	return &snapshot{export: f.graph.Export()}, nil
+}
+
+// Restore replaces the graph with a snapshot
+func (f *FSM) Restore(rc io.ReadCloser) error {
+	defer rc.Close()
+	restored := dagger.NewGraph()
+	if err := restored.ImportJSON(rc); err != nil {
+		return err
+	}
+	// the graph is patched into the snapshot's state, so readers holding it observe the restore
+	changes := &primitive.Changes{}
+	f.graph.RangeNodes(func(n *dagger.Node) bool {
+		if !restored.HasNode(n) {
+			changes.DeletedNodes = append(changes.DeletedNodes, typedID(n))
+		}
+		return true
+	})
+	f.graph.RangeEdges(func(e *dagger.Edge) bool {
+		if !restored.HasEdge(e) {
+			changes.DeletedEdges = append(changes.DeletedEdges, typedID(e))
+		}
+		return true
+	})
+	exp := restored.Export()
+	changes.Nodes = exp.Nodes
+	changes.Edges = exp.Edges
+	bits, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	return f.graph.ImportChanges(bytes.NewReader(bits))
+}
+
+type snapshot struct {
+	export *primitive.Export
+}
+
+func (s *snapshot) Persist(sink raft.SnapshotSink) error {
+	if err := json.NewEncoder(sink).Encode(s.export); err != nil {
+		sink.Cancel()
+		return err
+	}
+	return sink.Close()
+}
+
+func (s *snapshot) Release() {}
@@ -0,0 +1,165 @@
+package cluster_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/autom8ter/dagger"
+	"github.com/autom8ter/dagger/cluster"
+	"github.com/hashicorp/raft"
+	"io"
+	"testing"
+	"time"
+)
+
+func newCluster(t *testing.T, size int) []*cluster.Cluster {
+	var (
+		instances  []*cluster.Cluster
+		transports []*raft.InmemTransport
+		servers    []raft.Server
+	)
+	for i := 0; i < size; i++ {
+		id := raft.ServerID(fmt.Sprintf("dagger-%v", i))
+		addr, transport := raft.NewInmemTransport(raft.ServerAddress(id))
+		transports = append(transports, transport)
+		servers = append(servers, raft.Server{ID: id, Address: addr})
+		conf := raft.DefaultConfig()
+		conf.LocalID = id
+		conf.HeartbeatTimeout = 50 * time.Millisecond
+		conf.ElectionTimeout = 50 * time.Millisecond
+		conf.LeaderLeaseTimeout = 50 * time.Millisecond
+		conf.CommitTimeout = 5 * time.Millisecond
+		conf.SnapshotThreshold = 4
+		conf.TrailingLogs = 1
+		conf.LogOutput = io.Discard
+		store := raft.NewInmemStore()
+		c, err := cluster.New(dagger.NewGraph(), conf, store, store, raft.NewInmemSnapshotStore(), transport)
+		if err != nil {
+			t.Fatal(err)
+		}
+		instances = append(instances, c)
+	}
+	for _, a := range transports {
+		for _, b := range transports {
+			a.Connect(b.LocalAddr(), b)
+		}
+	}
+	if err := instances[0].Bootstrap(servers...); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		for _, c := range instances {
+			c.Shutdown()
+		}
+	})
+	return instances
+}
+
+func leader(t *testing.T, instances []*cluster.Cluster) *cluster.Cluster {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, c := range instances {
+			if c.IsLeader() {
+				return c
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for a leader")
+	return nil
+}
+
+func converge(t *testing.T, instances []*cluster.Cluster, hash string) {
+	deadline := time.Now().Add(5 * time.Second)
+	for _, c := range instances {
+		for c.Graph().Hash() != hash {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out waiting for the instances to converge")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+func TestCluster(t *testing.T) {
+	instances := newCluster(t, 3)
+	l := leader(t, instances)
+	coleman, err := l.SetNode(map[string]interface{}{"_type": "user", "_id": "coleman", "name": "coleman"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tyler, err := l.SetNode(map[string]interface{}{"_type": "user", "name": "tyler"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tyler.ID() == "" {
+		t.Fatal("expected nodes without an id to be given one")
+	}
+	friend, err := l.Connect(coleman, tyler, "friend", map[string]interface{}{"since": 2020})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Barrier(); err != nil {
+		t.Fatal(err)
+	}
+	converge(t, instances, l.Graph().Hash())
+	for _, c := range instances {
+		e, ok := c.Graph().GetEdge(friend)
+		if !ok || e.GetInt("since") != 2020 || e.From().ID() != "coleman" {
+			t.Fatal("expected every instance to have the edge")
+		}
+	}
+	for _, c := range instances {
+		if c == l {
+			continue
+		}
+		if _, err := c.SetNode(map[string]interface{}{"_type": "user"}); !errors.Is(err, cluster.ErrNotLeader) {
+			t.Fatalf("expected a follower to reject writes, got %v", err)
+		}
+	}
+	if err := l.DelEdge(friend); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.DelNode(tyler); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Raft().Snapshot().Error(); err != nil {
+		t.Fatal(err)
+	}
+	converge(t, instances, l.Graph().Hash())
+	if l.Graph().NodeCount() != 1 || l.Graph().EdgeCount() != 0 {
+		t.Fatalf("expected 1 node & no edges, got %v %v", l.Graph().NodeCount(), l.Graph().EdgeCount())
+	}
+}
+
+func TestFSMRestore(t *testing.T) {
+	source := dagger.NewGraph()
+	coleman := source.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman"})
+	tyler := source.NewNode(map[string]interface{}{"_type": "user", "_id": "tyler"})
+	if _, err := coleman.Connect(tyler, "friend", false); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := cluster.NewFSM(source).Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	snaps := raft.NewInmemSnapshotStore()
+	sink, err := snaps.Create(raft.SnapshotVersionMax, 1, 1, raft.Configuration{}, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := snapshot.Persist(sink); err != nil {
+		t.Fatal(err)
+	}
+	dest := dagger.NewGraph()
+	dest.NewNode(map[string]interface{}{"_type": "user", "_id": "stale"})
+	_, rc, err := snaps.Open(sink.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cluster.NewFSM(dest).Restore(rc); err != nil {
+		t.Fatal(err)
+	}
+	if dest.Hash() != source.Hash() {
+		t.Fatal("expected the restored graph to replace the stale graph")
+	}
+}
@@ -0,0 +1,432 @@
+// Command dagger inspects & mutates a graph stored in a file, so operators can work with a graph without writing Go
+// programs, ex:
+//
+//	dagger -graph graph.json stats
+//	dagger -graph graph.json get user.coleman
+//	dagger -graph graph.json connect user.coleman friend user.tyler
+//	dagger -graph graph.json path -type friend user.coleman user.sarah
+//	dagger -graph graph.json export -format dot > graph.dot
+//	dagger -graph graph.json query 'MATCH (a:user)-[:friend]->(b) RETURN a.name, b.name'
+//
+// The graph is read from the file's format, chosen by its extension(.json, .json.gz, .ndjson, .pb, .nt, .graphml,
+// .gexf, .gob or .msgpack), or from the newest snapshot if it is a directory written by EnableAutoSnapshot. Commands
+// that mutate the graph write it back in the same format. Nodes are named by their type & id separated by a dot.
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/autom8ter/dagger"
+	"github.com/autom8ter/dagger/encoding"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+const usage = `usage: dagger [-graph path] <command> [flags] [args]
+
+commands:
+  stats                                   print node & edge counts by type
+  get [-edges] <type.id>                  print a node, and optionally its edges
+  connect [-mutual] [-data json] <type.id> <relationship> <type.id>
+                                          connect two nodes & save the graph
+  path [-type edgeType] <type.id> <type.id>
+                                          print the shortest path between two nodes
+  export [-format format] [-o path]       export the graph as dot, mermaid, cypher, json, ndjson, graphml, gexf,
+                                          ntriples or proto(default json)
+  query <query>                           run a cypher-like query(see Graph.Query)
+
+The graph path defaults to $DAGGER_GRAPH.
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "dagger:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("dagger", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+	}
+	path := flags.String("graph", os.Getenv("DAGGER_GRAPH"), "path of the graph file or snapshot directory")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("missing command")
+	}
+	if *path == "" {
+		return errors.New("missing -graph path")
+	}
+	store := &store{path: *path}
+	g, err := store.load()
+	if err != nil {
+		return err
+	}
+	command, args := flags.Arg(0), flags.Args()[1:]
+	switch command {
+	case "stats":
+		return stats(g, stdout)
+	case "get":
+		return get(g, args, stdout)
+	case "connect":
+		return connect(g, store, args, stdout)
+	case "path":
+		return shortestPath(g, args, stdout)
+	case "export":
+		return export(g, args, stdout)
+	case "query":
+		return query(g, args, stdout)
+	}
+	flags.Usage()
+	return fmt.Errorf("unknown command: %s", command)
+}
+
+// store reads & writes the graph at path
+type store struct {
+	path string
+}
+
+// codec returns the codec of the file's extension, or nil for json & ndjson
+func (s *store) codec() (encoding.Codec, error) {
+	name := strings.TrimSuffix(strings.ToLower(s.path), ".gz")
+	switch filepath.Ext(name) {
+	case ".json", ".ndjson", "":
+		return nil, nil
+	case ".pb", ".proto":
+		return encoding.Protobuf{}, nil
+	case ".nt":
+		return encoding.NTriples{}, nil
+	case ".graphml":
+		return encoding.GraphML{}, nil
+	case ".gexf":
+		return encoding.GEXF{}, nil
+	case ".gob":
+		return encoding.Gob{}, nil
+	case ".msgpack", ".mp":
+		return encoding.MsgPack{}, nil
+	}
+	return nil, fmt.Errorf("unknown graph format: %s", s.path)
+}
+
+func (s *store) isDir() bool {
+	info, err := os.Stat(s.path)
+	return err == nil && info.IsDir()
+}
+
+func (s *store) load() (*dagger.Graph, error) {
+	g := dagger.NewGraph()
+	if s.isDir() {
+		ok, err := g.RestoreSnapshot(s.path)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("no snapshots in %s", s.path)
+		}
+		return g, nil
+	}
+	codec, err := s.codec()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	switch {
+	case codec != nil:
+		err = g.Decode(f, codec)
+	case strings.HasSuffix(strings.TrimSuffix(s.path, ".gz"), ".ndjson"):
+		err = g.ImportNDJSON(f)
+	default:
+		err = g.ImportJSON(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.path, err)
+	}
+	return g, nil
+}
+
+// save writes the graph back to the file through a temporary file, or as a new snapshot if the path is a directory
+func (s *store) save(g *dagger.Graph) error {
+	if s.isDir() {
+		_, err := g.WriteSnapshot(s.path, math.MaxInt32)
+		return err
+	}
+	codec, err := s.codec()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".dagger-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	gz := strings.HasSuffix(s.path, ".gz")
+	switch {
+	case codec != nil && gz:
+		err = g.Encode(tmp, encoding.Gzip(codec))
+	case codec != nil:
+		err = g.Encode(tmp, codec)
+	case strings.HasSuffix(strings.TrimSuffix(s.path, ".gz"), ".ndjson"):
+		if !gz {
+			err = g.ExportNDJSON(tmp)
+			break
+		}
+		zw := gzip.NewWriter(tmp)
+		if err = g.ExportNDJSON(zw); err == nil {
+			err = zw.Close()
+		}
+	case gz:
+		err = g.ExportJSONGzip(tmp)
+	default:
+		err = g.ExportJSON(tmp)
+	}
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// parseID parses a type.id node name
+func parseID(name string) (*dagger.ForeignKey, error) {
+	i := strings.Index(name, ".")
+	if i <= 0 || i == len(name)-1 {
+		return nil, fmt.Errorf("invalid node %q: expected type.id", name)
+	}
+	return &dagger.ForeignKey{XType: name[:i], XID: name[i+1:]}, nil
+}
+
+func getNode(g *dagger.Graph, name string) (*dagger.Node, error) {
+	id, err := parseID(name)
+	if err != nil {
+		return nil, err
+	}
+	n, ok := g.GetNode(id)
+	if !ok {
+		return nil, fmt.Errorf("node %s does not exist", name)
+	}
+	return n, nil
+}
+
+func nodeName(n *dagger.Node) string {
+	return n.Type() + "." + n.ID()
+}
+
+func stats(g *dagger.Graph, stdout io.Writer) error {
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	health := g.Health()
+	fmt.Fprintf(w, "nodes\t%v\n", health.Nodes)
+	fmt.Fprintf(w, "edges\t%v\n", health.Edges)
+	fmt.Fprintf(w, "dangling edges\t%v\n", health.DanglingEdges)
+	fmt.Fprintf(w, "status\t%v\n", health.Status)
+	for _, typ := range g.NodeTypes() {
+		count := 0
+		g.RangeNodeTypes(dagger.StringType(typ), func(n *dagger.Node) bool {
+			count++
+			return true
+		})
+		fmt.Fprintf(w, "node type %s\t%v\n", typ, count)
+	}
+	edges := map[string]int{}
+	g.RangeEdges(func(e *dagger.Edge) bool {
+		edges[e.Type()]++
+		return true
+	})
+	for _, typ := range g.EdgeTypes() {
+		fmt.Fprintf(w, "edge type %s\t%v\n", typ, edges[typ])
+	}
+	return w.Flush()
+}
+
+func get(g *dagger.Graph, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("get", flag.ContinueOnError)
+	withEdges := flags.Bool("edges", false, "print the node's edges")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: dagger get [-edges] <type.id>")
+	}
+	n, err := getNode(g, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(n.Raw()); err != nil {
+		return err
+	}
+	if *withEdges {
+		n.EdgesFrom(dagger.AnyType(), func(e *dagger.Edge) bool {
+			fmt.Fprintf(stdout, "-[%s]-> %s\n", e.Type(), nodeName(e.To()))
+			return true
+		})
+		n.EdgesTo(dagger.AnyType(), func(e *dagger.Edge) bool {
+			fmt.Fprintf(stdout, "<-[%s]- %s\n", e.Type(), nodeName(e.From()))
+			return true
+		})
+	}
+	return nil
+}
+
+func connect(g *dagger.Graph, s *store, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("connect", flag.ContinueOnError)
+	mutual := flags.Bool("mutual", false, "also connect the second node to the first")
+	data := flags.String("data", "", "json object of attributes to set on the edge")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 3 {
+		return errors.New("usage: dagger connect [-mutual] [-data json] <type.id> <relationship> <type.id>")
+	}
+	var attrs map[string]interface{}
+	if *data != "" {
+		if err := json.Unmarshal([]byte(*data), &attrs); err != nil {
+			return fmt.Errorf("invalid -data: %w", err)
+		}
+	}
+	from, err := getNode(g, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	to, err := getNode(g, flags.Arg(2))
+	if err != nil {
+		return err
+	}
+	e, err := from.Connect(to, flags.Arg(1), *mutual)
+	if err != nil {
+		return err
+	}
+	if len(attrs) > 0 {
+		var opts []dagger.PatchOption
+		if *mutual {
+			opts = append(opts, dagger.PatchReverse())
+		}
+		if err := e.Patch(attrs, opts...); err != nil {
+			return err
+		}
+	}
+	if err := s.save(g); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "%s -[%s]-> %s (%s.%s)\n", nodeName(from), e.Type(), nodeName(to), e.Type(), e.ID())
+	return nil
+}
+
+func shortestPath(g *dagger.Graph, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("path", flag.ContinueOnError)
+	edgeType := flags.String("type", "*", "the type of edges to follow")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return errors.New("usage: dagger path [-type edgeType] <type.id> <type.id>")
+	}
+	from, err := parseID(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	to, err := parseID(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	edges, err := g.ShortestPath(from, to, *edgeType)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, flags.Arg(0))
+	for _, e := range edges {
+		fmt.Fprintf(stdout, "-[%s]-> %s\n", e.Type(), nodeName(e.To()))
+	}
+	return nil
+}
+
+func export(g *dagger.Graph, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", "json", "dot, mermaid, cypher, json, ndjson, graphml, gexf, ntriples or proto")
+	out := flags.String("o", "", "write to the path instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *out == "" {
+		return exportTo(g, *format, stdout)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := exportTo(g, *format, f); err != nil {
+		f.Close()
+		return err
+	}
+	// a failed flush to disk is only reported by Close
+	return f.Close()
+}
+
+func exportTo(g *dagger.Graph, format string, w io.Writer) error {
+	switch format {
+	case "dot":
+		return g.ExportDOT(w)
+	case "mermaid":
+		return g.ExportMermaid(w)
+	case "cypher":
+		return g.ExportCypher(w)
+	case "json":
+		return g.ExportJSON(w)
+	case "ndjson":
+		return g.ExportNDJSON(w)
+	case "graphml":
+		return g.Encode(w, encoding.GraphML{})
+	case "gexf":
+		return g.Encode(w, encoding.GEXF{})
+	case "ntriples":
+		return g.ExportNTriples(w)
+	case "proto":
+		return g.ExportProto(w)
+	}
+	return fmt.Errorf("unknown export format: %s", format)
+}
+
+func query(g *dagger.Graph, args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("usage: dagger query <query>")
+	}
+	res, err := g.Query(strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(res.Columns, "\t"))
+	for _, row := range res.Rows {
+		var values []string
+		for _, v := range row {
+			switch v := v.(type) {
+			case *dagger.Node:
+				values = append(values, nodeName(v))
+			case *dagger.Edge:
+				values = append(values, v.Type()+"."+v.ID())
+			default:
+				values = append(values, fmt.Sprint(v))
+			}
+		}
+		fmt.Fprintln(w, strings.Join(values, "\t"))
+	}
+	return w.Flush()
+}
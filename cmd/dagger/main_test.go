@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"github.com/autom8ter/dagger"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeGraph writes a graph of coleman -friend-> tyler -friend-> sarah to a file in a new temporary directory
+func writeGraph(t *testing.T, name string) string {
+	g := dagger.NewGraph()
+	var previous *dagger.Node
+	for _, id := range []string{"coleman", "tyler", "sarah"} {
+		n := g.NewNode(map[string]interface{}{"_type": "user", "_id": id, "name": id})
+		if previous != nil {
+			if _, err := previous.Connect(n, "friend", false); err != nil {
+				t.Fatal(err)
+			}
+		}
+		previous = n
+	}
+	path := filepath.Join(t.TempDir(), name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := g.ExportJSON(f); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		args []string
+		// want are substrings the output must contain
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "stats",
+			args: []string{"stats"},
+			want: []string{"nodes", "3", "node type user", "edge type friend", "status"},
+		},
+		{
+			name: "get",
+			args: []string{"get", "user.coleman"},
+			want: []string{`"name": "coleman"`},
+		},
+		{
+			name: "get edges",
+			args: []string{"get", "-edges", "user.tyler"},
+			want: []string{"-[friend]-> user.sarah", "<-[friend]- user.coleman"},
+		},
+		{
+			name:    "get missing node",
+			args:    []string{"get", "user.lacee"},
+			wantErr: "node user.lacee does not exist",
+		},
+		{
+			name:    "get invalid node",
+			args:    []string{"get", "coleman"},
+			wantErr: "expected type.id",
+		},
+		{
+			name: "connect",
+			args: []string{"connect", "-mutual", "-data", `{"since":2020}`, "user.sarah", "friend", "user.coleman"},
+			want: []string{"user.sarah -[friend]-> user.coleman"},
+		},
+		{
+			name:    "connect invalid data",
+			args:    []string{"connect", "-data", "{", "user.sarah", "friend", "user.coleman"},
+			wantErr: "invalid -data",
+		},
+		{
+			name: "path",
+			args: []string{"path", "-type", "friend", "user.coleman", "user.sarah"},
+			want: []string{"user.coleman\n-[friend]-> user.tyler\n-[friend]-> user.sarah\n"},
+		},
+		{
+			name:    "no path",
+			args:    []string{"path", "user.sarah", "user.coleman"},
+			wantErr: "no path between nodes",
+		},
+		{
+			name: "export dot",
+			args: []string{"export", "-format", "dot"},
+			want: []string{"digraph", "friend"},
+		},
+		{
+			name: "export mermaid",
+			args: []string{"export", "-format", "mermaid"},
+			want: []string{"graph TD", `-->|"friend"|`},
+		},
+		{
+			name: "export cypher",
+			args: []string{"export", "-format", "cypher"},
+			want: []string{"CREATE", "[:`friend`"},
+		},
+		{
+			name: "export json",
+			args: []string{"export"},
+			want: []string{`"coleman"`},
+		},
+		{
+			name:    "export unknown format",
+			args:    []string{"export", "-format", "xlsx"},
+			wantErr: "unknown export format: xlsx",
+		},
+		{
+			name: "query",
+			args: []string{"query", "MATCH (a:user)-[:friend]->(b) RETURN a.name, b.name"},
+			want: []string{"a.name", "coleman", "tyler", "sarah"},
+		},
+		{
+			name:    "unknown command",
+			args:    []string{"drop"},
+			wantErr: "unknown command: drop",
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			path := writeGraph(t, "graph.json")
+			stdout := bytes.NewBuffer(nil)
+			err := run(append([]string{"-graph", path}, c.args...), stdout)
+			if c.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", c.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range c.want {
+				if !strings.Contains(stdout.String(), want) {
+					t.Fatalf("expected the output to contain %q, got:\n%s", want, stdout.String())
+				}
+			}
+		})
+	}
+}
+
+func TestConnectSaves(t *testing.T) {
+	for _, name := range []string{"graph.json", "graph.json.gz", "graph.ndjson", "graph.graphml"} {
+		t.Run(name, func(t *testing.T) {
+			source := writeGraph(t, "graph.json")
+			path := filepath.Join(filepath.Dir(source), name)
+			if name != "graph.json" {
+				// convert the graph to the format under test
+				s := &store{path: source}
+				g, err := s.load()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := (&store{path: path}).save(g); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := run([]string{"-graph", path, "connect", "user.sarah", "follows", "user.coleman"}, bytes.NewBuffer(nil)); err != nil {
+				t.Fatal(err)
+			}
+			stdout := bytes.NewBuffer(nil)
+			if err := run([]string{"-graph", path, "get", "-edges", "user.sarah"}, stdout); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(stdout.String(), "-[follows]-> user.coleman") {
+				t.Fatalf("expected the new edge to be saved, got:\n%s", stdout.String())
+			}
+		})
+	}
+}
+
+func TestExportFile(t *testing.T) {
+	path := writeGraph(t, "graph.json")
+	out := filepath.Join(filepath.Dir(path), "graph.dot")
+	if err := run([]string{"-graph", path, "export", "-format", "dot", "-o", out}, bytes.NewBuffer(nil)); err != nil {
+		t.Fatal(err)
+	}
+	bits, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(bits), "digraph") {
+		t.Fatalf("expected a dot file, got:\n%s", bits)
+	}
+	missing := filepath.Join(filepath.Dir(path), "missing", "graph.dot")
+	if err := run([]string{"-graph", path, "export", "-o", missing}, bytes.NewBuffer(nil)); err == nil {
+		t.Fatal("expected an error exporting to a missing directory")
+	}
+}
@@ -0,0 +1,111 @@
+package dagger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/autom8ter/dagger/primitive"
+	"net/http"
+	"strings"
+)
+
+type (
+	// Replica is a conflict-free replicated copy of a graph(see primitive.Replica)
+	Replica = primitive.Replica
+	// CRDTState is the replicated state of a Replica
+	CRDTState = primitive.CRDTState
+	// ReplicaPeer is a replica that can be synced with
+	ReplicaPeer = primitive.ReplicaPeer
+)
+
+// NewReplica returns a conflict-free replica of the graph, for deployments where several processes mutate their own
+// copy of the graph & converge by periodically exchanging state instead of agreeing on every write. The replica id must
+// be unique across the replicas that will be merged. The graph should start empty, and should only be written through
+// the replica.
+func (g *Graph) NewReplica(id string) *Replica {
+	return g.graph.NewReplica(id)
+}
+
+// NewReplica calls Graph.NewReplica on the default graph
+func NewReplica(id string) *Replica {
+	return defaultGraph.NewReplica(id)
+}
+
+// ReplicaHandler returns an http handler that serves the replica's state(GET /state) and merges the states of other
+// replicas into it(POST /merge), so remote replicas can sync with it with an HTTPReplicaPeer
+func ReplicaHandler(r *Replica) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/state", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(r.State())
+	})
+	mux.HandleFunc("/merge", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			http.Error(w, "merge requires a POST", http.StatusMethodNotAllowed)
+			return
+		}
+		state := &CRDTState{}
+		if err := json.NewDecoder(req.Body).Decode(state); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := r.Merge(state); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
+
+// HTTPReplicaPeer is a ReplicaPeer served by a remote ReplicaHandler
+type HTTPReplicaPeer struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewHTTPReplicaPeer creates a peer for the ReplicaHandler mounted at the base url. If client is nil,
+// http.DefaultClient is used.
+func NewHTTPReplicaPeer(baseURL string, client *http.Client) *HTTPReplicaPeer {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPReplicaPeer{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  client,
+	}
+}
+
+// State fetches the remote replica's state
+func (p *HTTPReplicaPeer) State() (*CRDTState, error) {
+	resp, err := p.client.Get(p.baseURL + "/state")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("dagger: replica peer responded %s", resp.Status)
+	}
+	state := &CRDTState{}
+	if err := json.NewDecoder(resp.Body).Decode(state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Merge sends the state to the remote replica to merge
+func (p *HTTPReplicaPeer) Merge(state *CRDTState) error {
+	bits, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Post(p.baseURL+"/merge", "application/json", bytes.NewReader(bits))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("dagger: replica peer responded %s", resp.Status)
+	}
+	return nil
+}
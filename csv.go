@@ -0,0 +1,273 @@
+package dagger
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/autom8ter/dagger/primitive"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSVMapping configures how ImportNodesCSV & ImportEdgesCSV read a CSV file. Headers follow the Neo4j bulk import
+// conventions: a column named name:type holds the attribute name converted to the type(int, long, float, double,
+// boolean or string; suffixed with [] for arrays), :ID holds the node's id, :LABEL its type, :TYPE an edge's type, and
+// :START_ID & :END_ID the ids of an edge's endpoints. An id column may name an id space(ex: :ID(user) or
+// :START_ID(user)), which is used as the node type. :IGNORE columns are skipped.
+type CSVMapping struct {
+	// Type is the node or edge type of rows without a :LABEL or :TYPE
+	Type string
+	// StartType & EndType are the node types of an edge's endpoints when the :START_ID & :END_ID columns do not name
+	// an id space. If neither is known, the endpoint is looked up by id across every node type.
+	StartType string
+	EndType   string
+	// Delimiter separates fields(default ',')
+	Delimiter rune
+	// ArrayDelimiter separates the elements of array fields & multiple labels(default ';')
+	ArrayDelimiter string
+}
+
+// csvColumn is a parsed CSV header field
+type csvColumn struct {
+	name string
+	// kind is the attribute type, or the special column(ex: ID, LABEL, START_ID) without its leading colon
+	kind    string
+	array   bool
+	idSpace string
+}
+
+func parseCSVHeader(header []string) []csvColumn {
+	columns := make([]csvColumn, len(header))
+	for i, field := range header {
+		col := csvColumn{name: strings.TrimSpace(field), kind: "string"}
+		if idx := strings.LastIndex(col.name, ":"); idx >= 0 {
+			col.kind = col.name[idx+1:]
+			col.name = col.name[:idx]
+		}
+		if open := strings.Index(col.kind, "("); open >= 0 && strings.HasSuffix(col.kind, ")") {
+			col.idSpace = col.kind[open+1 : len(col.kind)-1]
+			col.kind = col.kind[:open]
+		}
+		if strings.HasSuffix(col.kind, "[]") {
+			col.array = true
+			col.kind = strings.TrimSuffix(col.kind, "[]")
+		}
+		switch strings.ToUpper(col.kind) {
+		case "ID", "LABEL", "TYPE", "START_ID", "END_ID", "IGNORE":
+			col.kind = strings.ToUpper(col.kind)
+		default:
+			col.kind = strings.ToLower(col.kind)
+		}
+		columns[i] = col
+	}
+	return columns
+}
+
+func (m CSVMapping) arrayDelimiter() string {
+	if m.ArrayDelimiter == "" {
+		return ";"
+	}
+	return m.ArrayDelimiter
+}
+
+// convert converts the CSV field to the column's attribute type
+func (m CSVMapping) convert(col csvColumn, field string) (interface{}, error) {
+	scalar := func(s string) (interface{}, error) {
+		switch col.kind {
+		case "int", "long", "short", "byte":
+			return strconv.Atoi(s)
+		case "float", "double":
+			return strconv.ParseFloat(s, 64)
+		case "boolean":
+			return strconv.ParseBool(s)
+		default:
+			return s, nil
+		}
+	}
+	if !col.array {
+		return scalar(field)
+	}
+	var values []interface{}
+	for _, s := range strings.Split(field, m.arrayDelimiter()) {
+		v, err := scalar(s)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// readCSV reads the rows of the CSV, passing each row's fields keyed by column to fn
+func (m CSVMapping) readCSV(r io.Reader, fn func(line int, columns []csvColumn, row []string) error) error {
+	reader := csv.NewReader(r)
+	if m.Delimiter != 0 {
+		reader.Comma = m.Delimiter
+	}
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("dagger: csv header: %w", err)
+	}
+	columns := parseCSVHeader(header)
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(line, columns, row); err != nil {
+			return fmt.Errorf("dagger: csv line %v: %w", line, err)
+		}
+	}
+}
+
+// attributes sets the attribute columns of the row on the node, returning the special columns by kind
+func (m CSVMapping) attributes(n primitive.Node, columns []csvColumn, row []string) (map[string]string, map[string]csvColumn, error) {
+	special := map[string]string{}
+	specialColumns := map[string]csvColumn{}
+	for i, col := range columns {
+		if i >= len(row) || col.kind == "IGNORE" {
+			continue
+		}
+		field := row[i]
+		switch col.kind {
+		case "ID", "LABEL", "TYPE", "START_ID", "END_ID":
+			special[col.kind] = field
+			specialColumns[col.kind] = col
+			// a named id column(ex: email:ID) is also stored as an attribute
+			if col.kind == "ID" && col.name != "" {
+				n[col.name] = field
+			}
+			continue
+		}
+		if field == "" {
+			continue
+		}
+		v, err := m.convert(col, field)
+		if err != nil {
+			return nil, nil, fmt.Errorf("column %s: %w", col.name, err)
+		}
+		n[col.name] = v
+	}
+	return special, specialColumns, nil
+}
+
+// ImportNodesCSV imports a CSV file of nodes with Neo4j bulk import headers(see CSVMapping). A node's type is its first
+// :LABEL, else the mapping's Type, else the :ID column's id space.
+func (g *Graph) ImportNodesCSV(r io.Reader, mapping CSVMapping) error {
+	defer g.trace("ImportNodesCSV", nil)()
+	var nodes []primitive.Node
+	err := mapping.readCSV(r, func(line int, columns []csvColumn, row []string) error {
+		n := primitive.Node{}
+		special, specialColumns, err := mapping.attributes(n, columns, row)
+		if err != nil {
+			return err
+		}
+		typ := mapping.Type
+		if labels := strings.Split(special["LABEL"], mapping.arrayDelimiter()); labels[0] != "" {
+			typ = labels[0]
+		}
+		if typ == "" {
+			typ = specialColumns["ID"].idSpace
+		}
+		if typ == "" {
+			typ = primitive.DefaultType
+		}
+		n.SetType(typ)
+		if id := special["ID"]; id != "" {
+			n.SetID(id)
+		} else {
+			n.SetID(primitive.UUID())
+		}
+		nodes = append(nodes, n)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return g.graph.BulkLoad(nodes, nil)
+}
+
+// ImportNodesCSV calls Graph.ImportNodesCSV on the default graph
+func ImportNodesCSV(r io.Reader, mapping CSVMapping) error {
+	return defaultGraph.ImportNodesCSV(r, mapping)
+}
+
+// ImportEdgesCSV imports a CSV file of edges with Neo4j bulk import headers(see CSVMapping). An edge's type is its :TYPE,
+// else the mapping's Type. Every endpoint must already exist in the graph.
+func (g *Graph) ImportEdgesCSV(r io.Reader, mapping CSVMapping) error {
+	defer g.trace("ImportEdgesCSV", nil)()
+	var edges []*primitive.Edge
+	err := mapping.readCSV(r, func(line int, columns []csvColumn, row []string) error {
+		n := primitive.Node{}
+		special, specialColumns, err := mapping.attributes(n, columns, row)
+		if err != nil {
+			return err
+		}
+		typ := special["TYPE"]
+		if typ == "" {
+			typ = mapping.Type
+		}
+		if typ == "" {
+			return fmt.Errorf("edge has no :TYPE & the mapping has no Type")
+		}
+		n.SetType(typ)
+		if id := special["ID"]; id != "" {
+			n.SetID(id)
+		} else {
+			n.SetID(primitive.UUID())
+		}
+		from, err := g.csvEndpoint(special["START_ID"], specialColumns["START_ID"].idSpace, mapping.StartType)
+		if err != nil {
+			return err
+		}
+		to, err := g.csvEndpoint(special["END_ID"], specialColumns["END_ID"].idSpace, mapping.EndType)
+		if err != nil {
+			return err
+		}
+		edges = append(edges, &primitive.Edge{Node: n, From: from, To: to})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return g.graph.BulkLoad(nil, edges)
+}
+
+// ImportEdgesCSV calls Graph.ImportEdgesCSV on the default graph
+func ImportEdgesCSV(r io.Reader, mapping CSVMapping) error {
+	return defaultGraph.ImportEdgesCSV(r, mapping)
+}
+
+// csvEndpoint finds the node with the id in the id space, else of the fallback type, else of any type
+func (g *Graph) csvEndpoint(id, idSpace, fallback string) (primitive.Node, error) {
+	if id == "" {
+		return nil, fmt.Errorf("edge is missing an endpoint id")
+	}
+	typ := idSpace
+	if typ == "" {
+		typ = fallback
+	}
+	if typ != "" {
+		n, ok := g.graph.GetNode(primitive.Node{primitive.TYPE_KEY: typ, primitive.ID_KEY: id})
+		if !ok {
+			return nil, fmt.Errorf("node %s.%s does not exist", typ, id)
+		}
+		return n, nil
+	}
+	var found primitive.Node
+	for _, t := range g.graph.NodeTypes() {
+		if n, ok := g.graph.GetNode(primitive.Node{primitive.TYPE_KEY: t, primitive.ID_KEY: id}); ok {
+			if found != nil {
+				return nil, fmt.Errorf("id %s is ambiguous: it is held by a %s & a %s", id, found.Type(), t)
+			}
+			found = n
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("node with id %s does not exist", id)
+	}
+	return found, nil
+}
@@ -0,0 +1,89 @@
+package dagger
+
+import (
+	"context"
+	"github.com/autom8ter/dagger/primitive"
+)
+
+// RangeNodesCtx is like RangeNodes, but stops iterating & returns the context's error once it is done
+func (g *Graph) RangeNodesCtx(ctx context.Context, fn func(n *Node) bool) error {
+	ctx, end := g.startSpan(ctx, "RangeNodes", nil)
+	defer end()
+	return g.graph.RangeNodesCtx(ctx, func(n primitive.Node) bool {
+		return fn(g.node(n))
+	})
+}
+
+// RangeNodesCtx calls Graph.RangeNodesCtx on the default graph
+func RangeNodesCtx(ctx context.Context, fn func(n *Node) bool) error {
+	return defaultGraph.RangeNodesCtx(ctx, fn)
+}
+
+// RangeNodeTypesCtx is like RangeNodeTypes, but stops iterating & returns the context's error once it is done
+func (g *Graph) RangeNodeTypesCtx(ctx context.Context, typ primitive.Type, fn func(n *Node) bool) error {
+	ctx, end := g.startSpan(ctx, "RangeNodeTypes", typeAttribute(AttributeNodeType, typ))
+	defer end()
+	return g.graph.RangeNodeTypesCtx(ctx, typ, func(n primitive.Node) bool {
+		return fn(g.node(n))
+	})
+}
+
+// RangeNodeTypesCtx calls Graph.RangeNodeTypesCtx on the default graph
+func RangeNodeTypesCtx(ctx context.Context, typ primitive.Type, fn func(n *Node) bool) error {
+	return defaultGraph.RangeNodeTypesCtx(ctx, typ, fn)
+}
+
+// RangeEdgesCtx is like RangeEdges, but stops iterating & returns the context's error once it is done
+func (g *Graph) RangeEdgesCtx(ctx context.Context, fn func(e *Edge) bool) error {
+	ctx, end := g.startSpan(ctx, "RangeEdges", nil)
+	defer end()
+	return g.graph.RangeEdgesCtx(ctx, func(e *primitive.Edge) bool {
+		return fn(g.edge(e))
+	})
+}
+
+// RangeEdgesCtx calls Graph.RangeEdgesCtx on the default graph
+func RangeEdgesCtx(ctx context.Context, fn func(e *Edge) bool) error {
+	return defaultGraph.RangeEdgesCtx(ctx, fn)
+}
+
+// RangeEdgeTypesCtx is like RangeEdgeTypes, but stops iterating & returns the context's error once it is done
+func (g *Graph) RangeEdgeTypesCtx(ctx context.Context, edgeType primitive.Type, fn func(e *Edge) bool) error {
+	ctx, end := g.startSpan(ctx, "RangeEdgeTypes", typeAttribute(AttributeEdgeType, edgeType))
+	defer end()
+	return g.graph.RangeEdgeTypesCtx(ctx, edgeType, func(e *primitive.Edge) bool {
+		return fn(g.edge(e))
+	})
+}
+
+// RangeEdgeTypesCtx calls Graph.RangeEdgeTypesCtx on the default graph
+func RangeEdgeTypesCtx(ctx context.Context, edgeType primitive.Type, fn func(e *Edge) bool) error {
+	return defaultGraph.RangeEdgeTypesCtx(ctx, edgeType, fn)
+}
+
+// EdgesFromCtx is like EdgesFrom, but stops iterating & returns the context's error once it is done
+func (n *Node) EdgesFromCtx(ctx context.Context, edgeType primitive.Type, fn func(e *Edge) bool) error {
+	return n.owner().graph.EdgesFromCtx(ctx, edgeType, n, func(e *primitive.Edge) bool {
+		return fn(n.owner().edge(e))
+	})
+}
+
+// EdgesToCtx is like EdgesTo, but stops iterating & returns the context's error once it is done
+func (n *Node) EdgesToCtx(ctx context.Context, edgeType primitive.Type, fn func(e *Edge) bool) error {
+	return n.owner().graph.EdgesToCtx(ctx, edgeType, n, func(e *primitive.Edge) bool {
+		return fn(n.owner().edge(e))
+	})
+}
+
+// BFSCtx is like BFS, but stops the walk & returns the context's error once it is done
+func (n *Node) BFSCtx(ctx context.Context, depth int, fn func(n *Node) bool) error {
+	ctx, end := n.owner().startSpan(ctx, "BFS", typeAttribute(AttributeNodeType, n))
+	defer end()
+	_, err := n.owner().graph.BFSCtx(ctx, n, AnyType(), primitive.TraversalOptions{MaxDepth: depth}, func(node primitive.Node, d int) bool {
+		if d == 0 {
+			return true
+		}
+		return fn(n.owner().node(node))
+	})
+	return err
+}
@@ -0,0 +1,512 @@
+package dagger
+
+import (
+	"fmt"
+	"github.com/autom8ter/dagger/primitive"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ResultSet holds the rows returned by Graph.Query. Each row has one value per column: a *Node or *Edge for a returned
+// variable, or the attribute's value for a returned property.
+type ResultSet struct {
+	Columns []string
+	Rows    [][]interface{}
+	// Truncated is true if the query exhausted its traversal options, so the rows are partial
+	Truncated bool
+}
+
+// Query runs a query written in a small Cypher-inspired language against the graph, ex:
+//
+//	MATCH (a:user)-[:friend]->(b:user) WHERE a.name = "coleman" RETURN b
+//
+// The supported subset is a single MATCH path of nodes `(var:type)` joined by relationships `-[var:type]->` or
+// `<-[var:type]-`(variables & types are optional, and types include declared subtypes), an optional WHERE of
+// `var.attribute <op> literal` comparisons joined by AND(ops are =, <>, !=, <, <=, >, >=), a RETURN list of variables
+// or `var.attribute` properties, and an optional LIMIT. Use Default().Query to query the default graph.
+//
+// The MATCH is bounded by the traversal options if they are given: every node it binds counts as a visit, and a path
+// with more relationships than the max depth is not followed.
+func (g *Graph) Query(q string, opts ...primitive.TraversalOptions) (*ResultSet, error) {
+	defer g.trace("Query", nil)()
+	tokens, err := tokenizeQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{tokens: tokens}
+	stmt, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	res := &ResultSet{}
+	for _, r := range stmt.returns {
+		res.Columns = append(res.Columns, r.String())
+	}
+	var matchErr error
+	b := primitive.NewTraversalBudget(traversalOptions(opts))
+	defer func() {
+		res.Truncated = b.Result().Truncated
+	}()
+	g.matchPattern(stmt, b, func(bindings map[string]interface{}) bool {
+		for _, c := range stmt.where {
+			ok, err := compare(c.prop.value(bindings), c.op, c.value)
+			if err != nil {
+				matchErr = err
+				return false
+			}
+			if !ok {
+				return true
+			}
+		}
+		var row []interface{}
+		for _, r := range stmt.returns {
+			row = append(row, r.value(bindings))
+		}
+		res.Rows = append(res.Rows, row)
+		return stmt.limit <= 0 || len(res.Rows) < stmt.limit
+	})
+	if matchErr != nil {
+		return nil, matchErr
+	}
+	return res, nil
+}
+
+type nodePattern struct {
+	variable string
+	typ      string
+}
+
+type relPattern struct {
+	variable string
+	typ      string
+	incoming bool
+}
+
+// queryProperty is a returned or compared variable, or one of its attributes if attribute is set
+type queryProperty struct {
+	variable  string
+	attribute string
+}
+
+func (p queryProperty) String() string {
+	if p.attribute == "" {
+		return p.variable
+	}
+	return p.variable + "." + p.attribute
+}
+
+func (p queryProperty) value(bindings map[string]interface{}) interface{} {
+	bound := bindings[p.variable]
+	if p.attribute == "" {
+		return bound
+	}
+	switch v := bound.(type) {
+	case *Node:
+		return v.Get(p.attribute)
+	case *Edge:
+		return v.Get(p.attribute)
+	}
+	return nil
+}
+
+type queryCondition struct {
+	prop  queryProperty
+	op    Operator
+	value interface{}
+}
+
+type queryStatement struct {
+	nodes   []nodePattern
+	rels    []relPattern
+	where   []queryCondition
+	returns []queryProperty
+	limit   int
+}
+
+// matchPattern passes the variable bindings of every match of the statement's path to fn until fn returns false or the
+// budget is exhausted
+func (g *Graph) matchPattern(stmt *queryStatement, b *primitive.TraversalBudget, fn func(bindings map[string]interface{}) bool) {
+	bindings := map[string]interface{}{}
+	stopped := false
+	var walk func(i int, n *Node) bool
+	bind := func(variable string, value interface{}, key string, next func() bool) bool {
+		if variable == "" {
+			return next()
+		}
+		if bound, ok := bindings[variable]; ok {
+			if boundKey(bound) != key {
+				return true
+			}
+			return next()
+		}
+		bindings[variable] = value
+		defer delete(bindings, variable)
+		return next()
+	}
+	walk = func(i int, n *Node) bool {
+		np := stmt.nodes[i]
+		if np.typ != "" && !g.graph.IsNodeType(n.Type(), np.typ) {
+			return true
+		}
+		if !b.Visit() {
+			return false
+		}
+		return bind(np.variable, n, "node:"+n.Type()+"."+n.ID(), func() bool {
+			if i == len(stmt.rels) {
+				return fn(bindings)
+			}
+			if !b.Expands(i) {
+				// every path is as long as the pattern, so none fit within the max depth
+				b.Truncate()
+				return false
+			}
+			rel := stmt.rels[i]
+			edgeType := AnyType()
+			if rel.typ != "" {
+				edgeType = StringType(rel.typ)
+			}
+			keepGoing := true
+			visit := func(e *Edge) bool {
+				next := e.To()
+				if rel.incoming {
+					next = e.From()
+				}
+				keepGoing = bind(rel.variable, e, "edge:"+e.Type()+"."+e.ID(), func() bool {
+					return walk(i+1, next)
+				})
+				return keepGoing
+			}
+			if rel.incoming {
+				n.EdgesTo(edgeType, visit)
+			} else {
+				n.EdgesFrom(edgeType, visit)
+			}
+			return keepGoing
+		})
+	}
+	start := func(n *Node) bool {
+		if stopped {
+			return false
+		}
+		stopped = !walk(0, n)
+		return !stopped
+	}
+	if typ := stmt.nodes[0].typ; typ != "" {
+		g.RangeNodeTypes(StringType(typ), start)
+	} else {
+		g.RangeNodes(start)
+	}
+}
+
+func boundKey(v interface{}) string {
+	switch v := v.(type) {
+	case *Node:
+		return "node:" + v.Type() + "." + v.ID()
+	case *Edge:
+		return "edge:" + v.Type() + "." + v.ID()
+	}
+	return ""
+}
+
+type queryToken struct {
+	text   string
+	quoted bool
+}
+
+func tokenizeQuery(q string) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(q)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				if runes[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("dagger: unterminated string in query")
+			}
+			text := strings.NewReplacer(`\`+string(r), string(r), `\\`, `\`).Replace(string(runes[i+1 : end]))
+			tokens = append(tokens, queryToken{text: text, quoted: true})
+			i = end + 1
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			end := i
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_' ||
+				(runes[end] == '.' && unicode.IsDigit(r))) {
+				end++
+			}
+			tokens = append(tokens, queryToken{text: string(runes[i:end])})
+			i = end
+		default:
+			if i+1 < len(runes) {
+				switch pair := string(runes[i : i+2]); pair {
+				case "<=", ">=", "!=", "<>":
+					tokens = append(tokens, queryToken{text: pair})
+					i += 2
+					continue
+				}
+			}
+			if !strings.ContainsRune("()[]:-<>=,.", r) {
+				return nil, fmt.Errorf("dagger: unexpected %q in query", r)
+			}
+			tokens = append(tokens, queryToken{text: string(r)})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+func (p *queryParser) peek() string {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].quoted {
+		return ""
+	}
+	return p.tokens[p.pos].text
+}
+
+func (p *queryParser) next() (queryToken, error) {
+	if p.pos >= len(p.tokens) {
+		return queryToken{}, fmt.Errorf("dagger: unexpected end of query")
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+	return t, nil
+}
+
+func (p *queryParser) expect(text string) error {
+	t, err := p.next()
+	if err != nil {
+		return err
+	}
+	if t.quoted || !strings.EqualFold(t.text, text) {
+		return fmt.Errorf("dagger: expected %q in query, found %q", text, t.text)
+	}
+	return nil
+}
+
+func (p *queryParser) keyword(text string) bool {
+	if strings.EqualFold(p.peek(), text) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) identifier() (string, error) {
+	t, err := p.next()
+	if err != nil {
+		return "", err
+	}
+	if t.quoted || t.text == "" || !(unicode.IsLetter([]rune(t.text)[0]) || t.text[0] == '_') {
+		return "", fmt.Errorf("dagger: expected a name in query, found %q", t.text)
+	}
+	return t.text, nil
+}
+
+func (p *queryParser) parse() (*queryStatement, error) {
+	stmt := &queryStatement{}
+	if err := p.expect("MATCH"); err != nil {
+		return nil, err
+	}
+	n, err := p.parseNode()
+	if err != nil {
+		return nil, err
+	}
+	stmt.nodes = append(stmt.nodes, n)
+	for p.peek() == "-" || p.peek() == "<" {
+		rel, err := p.parseRel()
+		if err != nil {
+			return nil, err
+		}
+		n, err := p.parseNode()
+		if err != nil {
+			return nil, err
+		}
+		stmt.rels = append(stmt.rels, rel)
+		stmt.nodes = append(stmt.nodes, n)
+	}
+	if p.keyword("WHERE") {
+		for {
+			c, err := p.parseCondition()
+			if err != nil {
+				return nil, err
+			}
+			stmt.where = append(stmt.where, c)
+			if !p.keyword("AND") {
+				break
+			}
+		}
+	}
+	if err := p.expect("RETURN"); err != nil {
+		return nil, err
+	}
+	for {
+		prop, err := p.parseProperty()
+		if err != nil {
+			return nil, err
+		}
+		stmt.returns = append(stmt.returns, prop)
+		if p.peek() != "," {
+			break
+		}
+		p.pos++
+	}
+	if p.keyword("LIMIT") {
+		t, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		limit, err := strconv.Atoi(t.text)
+		if err != nil || t.quoted {
+			return nil, fmt.Errorf("dagger: expected a number after LIMIT, found %q", t.text)
+		}
+		stmt.limit = limit
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("dagger: unexpected %q at the end of query", p.tokens[p.pos].text)
+	}
+	vars := map[string]bool{}
+	for _, n := range stmt.nodes {
+		vars[n.variable] = true
+	}
+	for _, r := range stmt.rels {
+		vars[r.variable] = true
+	}
+	for _, c := range stmt.where {
+		if !vars[c.prop.variable] {
+			return nil, fmt.Errorf("dagger: %s is not defined in the MATCH pattern", c.prop.variable)
+		}
+	}
+	for _, r := range stmt.returns {
+		if !vars[r.variable] {
+			return nil, fmt.Errorf("dagger: %s is not defined in the MATCH pattern", r.variable)
+		}
+	}
+	return stmt, nil
+}
+
+// parseNode parses (variable:type), where the variable & type are optional
+func (p *queryParser) parseNode() (nodePattern, error) {
+	n := nodePattern{}
+	if err := p.expect("("); err != nil {
+		return n, err
+	}
+	var err error
+	if p.peek() != ":" && p.peek() != ")" {
+		if n.variable, err = p.identifier(); err != nil {
+			return n, err
+		}
+	}
+	if p.keyword(":") {
+		if n.typ, err = p.identifier(); err != nil {
+			return n, err
+		}
+	}
+	return n, p.expect(")")
+}
+
+// parseRel parses -[variable:type]-> or <-[variable:type]-, where the brackets, variable & type are optional
+func (p *queryParser) parseRel() (relPattern, error) {
+	rel := relPattern{}
+	if p.keyword("<") {
+		rel.incoming = true
+	}
+	if err := p.expect("-"); err != nil {
+		return rel, err
+	}
+	if p.keyword("[") {
+		var err error
+		if p.peek() != ":" && p.peek() != "]" {
+			if rel.variable, err = p.identifier(); err != nil {
+				return rel, err
+			}
+		}
+		if p.keyword(":") {
+			if rel.typ, err = p.identifier(); err != nil {
+				return rel, err
+			}
+		}
+		if err := p.expect("]"); err != nil {
+			return rel, err
+		}
+	}
+	if err := p.expect("-"); err != nil {
+		return rel, err
+	}
+	if !rel.incoming {
+		if err := p.expect(">"); err != nil {
+			return rel, err
+		}
+	}
+	return rel, nil
+}
+
+func (p *queryParser) parseProperty() (queryProperty, error) {
+	prop := queryProperty{}
+	var err error
+	if prop.variable, err = p.identifier(); err != nil {
+		return prop, err
+	}
+	if p.keyword(".") {
+		if prop.attribute, err = p.identifier(); err != nil {
+			return prop, err
+		}
+	}
+	return prop, nil
+}
+
+func (p *queryParser) parseCondition() (queryCondition, error) {
+	c := queryCondition{}
+	var err error
+	if c.prop, err = p.parseProperty(); err != nil {
+		return c, err
+	}
+	op, err := p.next()
+	if err != nil {
+		return c, err
+	}
+	switch op.text {
+	case "=":
+		c.op = Eq
+	case "!=", "<>":
+		c.op = Neq
+	case ">", ">=", "<", "<=":
+		c.op = Operator(op.text)
+	default:
+		return c, fmt.Errorf("dagger: unsupported operator %q in query", op.text)
+	}
+	negative := p.keyword("-")
+	t, err := p.next()
+	if err != nil {
+		return c, err
+	}
+	switch {
+	case t.quoted && !negative:
+		c.value = t.text
+	case strings.EqualFold(t.text, "true") && !negative:
+		c.value = true
+	case strings.EqualFold(t.text, "false") && !negative:
+		c.value = false
+	default:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil || t.quoted {
+			return c, fmt.Errorf("dagger: expected a string, number or boolean in query, found %q", t.text)
+		}
+		if negative {
+			f = -f
+		}
+		c.value = f
+	}
+	return c, nil
+}
@@ -0,0 +1,112 @@
+package dagger
+
+import (
+	"encoding/json"
+	"github.com/autom8ter/dagger/primitive"
+	"io"
+	"sort"
+)
+
+type cytoscapeConfig struct {
+	layered    primitive.Type
+	transforms []primitive.Transform
+}
+
+// CytoscapeOption configures ExportCytoscape
+type CytoscapeOption func(c *cytoscapeConfig)
+
+// CytoscapeTransforms applies the transforms(ex: primitive.HashAttributes) to a copy of every exported node & edge, as in
+// ExportJSON
+func CytoscapeTransforms(transforms ...primitive.Transform) CytoscapeOption {
+	return func(c *cytoscapeConfig) {
+		c.transforms = append(c.transforms, transforms...)
+	}
+}
+
+// CytoscapeLayeredLayout positions each node at its place in the graph's LayeredLayout over edges of the given type, and
+// sets the preset layout so Cytoscape draws the positions as computed
+func CytoscapeLayeredLayout(edgeType primitive.Type) CytoscapeOption {
+	return func(c *cytoscapeConfig) {
+		c.layered = edgeType
+	}
+}
+
+// cytoscapeSpacing is the distance in pixels between neighboring positions of a layered layout
+const cytoscapeSpacing = 100
+
+// cytoscapeGraph is the elements JSON format read by Cytoscape.js(cy.json) & the Cytoscape desktop app
+type cytoscapeGraph struct {
+	Elements cytoscapeElements `json:"elements"`
+	Layout   *cytoscapeLayout  `json:"layout,omitempty"`
+}
+
+type cytoscapeElements struct {
+	Nodes []cytoscapeElement `json:"nodes"`
+	Edges []cytoscapeElement `json:"edges"`
+}
+
+type cytoscapeElement struct {
+	Data     map[string]interface{} `json:"data"`
+	Position *cytoscapePosition     `json:"position,omitempty"`
+}
+
+type cytoscapePosition struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type cytoscapeLayout struct {
+	Name string `json:"name"`
+}
+
+// ExportCytoscape writes the graph in the Cytoscape.js elements JSON format. Each element's data holds its attributes,
+// with its type.id as the element id; edges also carry the type.id of their source & target.
+func (g *Graph) ExportCytoscape(w io.Writer, opts ...CytoscapeOption) error {
+	defer g.trace("ExportCytoscape", nil)()
+	c := &cytoscapeConfig{}
+	for _, o := range opts {
+		o(c)
+	}
+	export := g.export(c.transforms)
+	sort.Slice(export.Nodes, func(i, j int) bool {
+		return dotID(export.Nodes[i]) < dotID(export.Nodes[j])
+	})
+	sort.Slice(export.Edges, func(i, j int) bool {
+		return dotID(export.Edges[i]) < dotID(export.Edges[j])
+	})
+	graph := cytoscapeGraph{
+		Elements: cytoscapeElements{
+			Nodes: []cytoscapeElement{},
+			Edges: []cytoscapeElement{},
+		},
+	}
+	var layout *primitive.Layout
+	if c.layered != nil {
+		layout = g.graph.LayeredLayout(c.layered)
+		graph.Layout = &cytoscapeLayout{Name: "preset"}
+	}
+	for _, n := range export.Nodes {
+		element := cytoscapeElement{Data: n.Copy()}
+		element.Data["id"] = dotID(n)
+		if layout != nil {
+			if p, ok := layout.Position(n); ok {
+				// cytoscape's y axis points down, so the first rank is drawn at the top
+				element.Position = &cytoscapePosition{X: p.X * cytoscapeSpacing, Y: p.Y * cytoscapeSpacing}
+			}
+		}
+		graph.Elements.Nodes = append(graph.Elements.Nodes, element)
+	}
+	for _, e := range export.Edges {
+		element := cytoscapeElement{Data: e.Node.Copy()}
+		element.Data["id"] = dotID(e)
+		element.Data["source"] = dotID(e.From)
+		element.Data["target"] = dotID(e.To)
+		graph.Elements.Edges = append(graph.Elements.Edges, element)
+	}
+	return json.NewEncoder(w).Encode(graph)
+}
+
+// ExportCytoscape calls Graph.ExportCytoscape on the default graph
+func ExportCytoscape(w io.Writer, opts ...CytoscapeOption) error {
+	return defaultGraph.ExportCytoscape(w, opts...)
+}
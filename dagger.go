@@ -1,88 +1,138 @@
 package dagger
 
 import (
+	"compress/gzip"
 	"encoding/json"
+	"github.com/autom8ter/dagger/encoding"
+	"github.com/autom8ter/dagger/migrate"
 	"github.com/autom8ter/dagger/primitive"
 	"io"
 	"sort"
 )
 
-var globalGraph = primitive.NewGraph()
+// NodeCount returns the total number of nodes in the graph from the count the graph maintains as it changes
+func (g *Graph) NodeCount() int {
+	return g.graph.NodeCount()
+}
 
-// NodeCount returns the total number of nodes in the graph
+// NodeCount calls Graph.NodeCount on the default graph
 func NodeCount() int {
-	i := 0
-	globalGraph.RangeNodes(func(n primitive.Node) bool {
-		if n != nil {
-			i++
-		}
-		return true
-	})
-	return i
+	return defaultGraph.NodeCount()
+}
+
+// EdgeCount returns the total number of edges in the graph from the count the graph maintains as it changes
+func (g *Graph) EdgeCount() int {
+	return g.graph.EdgeCount()
 }
 
-// EdgeCount returns the total number of edges in the graph
+// EdgeCount calls Graph.EdgeCount on the default graph
 func EdgeCount() int {
-	i := 0
-	globalGraph.RangeEdges(func(n *primitive.Edge) bool {
-		if n != nil {
-			i++
-		}
-		return true
-	})
-	return i
+	return defaultGraph.EdgeCount()
+}
+
+// NodeTypeCounts returns the number of nodes of each type from the counts the graph maintains as it changes
+func (g *Graph) NodeTypeCounts() map[string]int {
+	return g.graph.NodeTypeCounts()
+}
+
+// NodeTypeCounts calls Graph.NodeTypeCounts on the default graph
+func NodeTypeCounts() map[string]int {
+	return defaultGraph.NodeTypeCounts()
+}
+
+// EdgeTypeCounts returns the number of edges of each type from the counts the graph maintains as it changes
+func (g *Graph) EdgeTypeCounts() map[string]int {
+	return g.graph.EdgeTypeCounts()
+}
+
+// EdgeTypeCounts calls Graph.EdgeTypeCounts on the default graph
+func EdgeTypeCounts() map[string]int {
+	return defaultGraph.EdgeTypeCounts()
 }
 
 // EdgeTypes returns the types of relationships/edges/connections in the graph
-func EdgeTypes() []string {
-	edgeTypes := globalGraph.EdgeTypes()
+func (g *Graph) EdgeTypes() []string {
+	edgeTypes := g.graph.EdgeTypes()
 	sort.Strings(edgeTypes)
 	return edgeTypes
 }
 
+// EdgeTypes calls Graph.EdgeTypes on the default graph
+func EdgeTypes() []string {
+	return defaultGraph.EdgeTypes()
+}
+
 // NodeTypes returns the types of nodes in the graph
-func NodeTypes() []string {
-	nodeTypes := globalGraph.NodeTypes()
+func (g *Graph) NodeTypes() []string {
+	nodeTypes := g.graph.NodeTypes()
 	sort.Strings(nodeTypes)
 	return nodeTypes
 }
 
+// NodeTypes calls Graph.NodeTypes on the default graph
+func NodeTypes() []string {
+	return defaultGraph.NodeTypes()
+}
+
 // GetNode gets a node from the graph if it exists
-func GetNode(id primitive.TypedID) (*Node, bool) {
-	n, ok := globalGraph.GetNode(id)
+func (g *Graph) GetNode(id primitive.TypedID) (*Node, bool) {
+	n, ok := g.graph.GetNode(id)
 	if !ok {
 		return nil, false
 	}
-	return &Node{n}, true
+	return g.node(n), true
+}
+
+// GetNode calls Graph.GetNode on the default graph
+func GetNode(id primitive.TypedID) (*Node, bool) {
+	return defaultGraph.GetNode(id)
 }
 
 // GetEdge gets an edge from the graph if it exists
-func GetEdge(id primitive.TypedID) (*Edge, bool) {
-	n, ok := globalGraph.GetEdge(id)
+func (g *Graph) GetEdge(id primitive.TypedID) (*Edge, bool) {
+	n, ok := g.graph.GetEdge(id)
 	if !ok {
 		return nil, false
 	}
-	return &Edge{n}, true
+	return g.edge(n), true
+}
+
+// GetEdge calls Graph.GetEdge on the default graph
+func GetEdge(id primitive.TypedID) (*Edge, bool) {
+	return defaultGraph.GetEdge(id)
 }
 
 // RangeNodeTypes iterates over nodes of a given type until the iterator returns false
-func RangeNodeTypes(typ primitive.Type, fn func(n *Node) bool) {
-	globalGraph.RangeNodeTypes(typ, func(n primitive.Node) bool {
-		return fn(&Node{n})
+func (g *Graph) RangeNodeTypes(typ primitive.Type, fn func(n *Node) bool) {
+	defer g.trace("RangeNodeTypes", typeAttribute(AttributeNodeType, typ))()
+	g.graph.RangeNodeTypes(typ, func(n primitive.Node) bool {
+		return fn(g.node(n))
 	})
 }
 
+// RangeNodeTypes calls Graph.RangeNodeTypes on the default graph
+func RangeNodeTypes(typ primitive.Type, fn func(n *Node) bool) {
+	defaultGraph.RangeNodeTypes(typ, fn)
+}
+
 // RangeNodes iterates over all nodes until the iterator returns false
-func RangeNodes(fn func(n *Node) bool) {
-	globalGraph.RangeNodes(func(n primitive.Node) bool {
-		return fn(&Node{n})
+func (g *Graph) RangeNodes(fn func(n *Node) bool) {
+	defer g.trace("RangeNodes", nil)()
+	g.graph.RangeNodes(func(n primitive.Node) bool {
+		return fn(g.node(n))
 	})
 }
 
+// RangeNodes calls Graph.RangeNodes on the default graph
+func RangeNodes(fn func(n *Node) bool) {
+	defaultGraph.RangeNodes(fn)
+}
+
 // RangeEdges iterates over all edges/connections until the iterator returns false
-func RangeEdges(fn func(e *Edge) bool) {
-	globalGraph.RangeEdges(func(e *primitive.Edge) bool {
-		this, err := edgeFrom(e)
+func (g *Graph) RangeEdges(fn func(e *Edge) bool) {
+	defer g.trace("RangeEdges", nil)()
+	g.graph.RangeEdges(func(e *primitive.Edge) bool {
+		this, err := g.edgeFrom(e)
 		if err != nil {
 			return true
 		}
@@ -90,10 +140,16 @@ func RangeEdges(fn func(e *Edge) bool) {
 	})
 }
 
+// RangeEdges calls Graph.RangeEdges on the default graph
+func RangeEdges(fn func(e *Edge) bool) {
+	defaultGraph.RangeEdges(fn)
+}
+
 // RangeEdgeTypes iterates over edges/connections of a given type until the iterator returns false
-func RangeEdgeTypes(edgeType primitive.Type, fn func(e *Edge) bool) {
-	globalGraph.RangeEdgeTypes(edgeType, func(e *primitive.Edge) bool {
-		this, err := edgeFrom(e)
+func (g *Graph) RangeEdgeTypes(edgeType primitive.Type, fn func(e *Edge) bool) {
+	defer g.trace("RangeEdgeTypes", typeAttribute(AttributeEdgeType, edgeType))()
+	g.graph.RangeEdgeTypes(edgeType, func(e *primitive.Edge) bool {
+		this, err := g.edgeFrom(e)
 		if err != nil {
 			return true
 		}
@@ -101,45 +157,691 @@ func RangeEdgeTypes(edgeType primitive.Type, fn func(e *Edge) bool) {
 	})
 }
 
+// RangeEdgeTypes calls Graph.RangeEdgeTypes on the default graph
+func RangeEdgeTypes(edgeType primitive.Type, fn func(e *Edge) bool) {
+	defaultGraph.RangeEdgeTypes(edgeType, fn)
+}
+
 // HasNode returns true if a node with the typed ID exists in the graph
+func (g *Graph) HasNode(id primitive.TypedID) bool {
+	return g.graph.HasNode(id)
+}
+
+// HasNode calls Graph.HasNode on the default graph
 func HasNode(id primitive.TypedID) bool {
-	return globalGraph.HasNode(id)
+	return defaultGraph.HasNode(id)
 }
 
-// DelNode deletes a node from the graph
-func DelNode(id primitive.TypedID) {
-	globalGraph.DelNode(id)
+// DelNode deletes a node & its edges from the graph, applying the delete policy of each edge's type
+func (g *Graph) DelNode(id primitive.TypedID) error {
+	defer g.trace("DelNode", typeAttribute(AttributeNodeType, id))()
+	return g.graph.DelNode(id)
+}
+
+// DelNode calls Graph.DelNode on the default graph
+func DelNode(id primitive.TypedID) error {
+	return defaultGraph.DelNode(id)
 }
 
 // DelEdge deletes an edge from the graph
-func DelEdge(id primitive.TypedID) {
-	globalGraph.DelEdge(id)
+func (g *Graph) DelEdge(id primitive.TypedID) error {
+	defer g.trace("DelEdge", typeAttribute(AttributeEdgeType, id))()
+	return g.graph.DelEdge(id)
+}
+
+// DelEdge calls Graph.DelEdge on the default graph
+func DelEdge(id primitive.TypedID) error {
+	return defaultGraph.DelEdge(id)
 }
 
 // HasEdge returns true if an edge with the typed ID exists in the graph
+func (g *Graph) HasEdge(id primitive.TypedID) bool {
+	return g.graph.HasEdge(id)
+}
+
+// HasEdge calls Graph.HasEdge on the default graph
 func HasEdge(id primitive.TypedID) bool {
-	return globalGraph.HasEdge(id)
+	return defaultGraph.HasEdge(id)
+}
+
+// ErrReadOnly is returned by mutations while the graph is read-only
+var ErrReadOnly = primitive.ErrReadOnly
+
+// SetReadOnly freezes(or unfreezes) the graph. While frozen, every mutation returns ErrReadOnly.
+func (g *Graph) SetReadOnly(readOnly bool) {
+	g.graph.SetReadOnly(readOnly)
+}
+
+// SetReadOnly calls Graph.SetReadOnly on the default graph
+func SetReadOnly(readOnly bool) {
+	defaultGraph.SetReadOnly(readOnly)
+}
+
+// DeclareEdgeType declares each of the subtypes a kind of the parent edge type(ex: friend & spouse are kinds of knows),
+// so EdgesFrom, EdgesTo & RangeEdgeTypes over the parent type include edges of every subtype
+func (g *Graph) DeclareEdgeType(parent string, subtypes ...string) error {
+	return g.graph.DeclareEdgeType(parent, subtypes...)
+}
+
+// DeclareEdgeType calls Graph.DeclareEdgeType on the default graph
+func DeclareEdgeType(parent string, subtypes ...string) error {
+	return defaultGraph.DeclareEdgeType(parent, subtypes...)
+}
+
+// DeclareNodeType declares each of the subtypes a kind of the abstract parent node type(ex: user & employee are kinds
+// of person), so RangeNodeTypes over the parent type includes nodes of every subtype
+func (g *Graph) DeclareNodeType(parent string, subtypes ...string) error {
+	return g.graph.DeclareNodeType(parent, subtypes...)
+}
+
+// DeclareNodeType calls Graph.DeclareNodeType on the default graph
+func DeclareNodeType(parent string, subtypes ...string) error {
+	return defaultGraph.DeclareNodeType(parent, subtypes...)
+}
+
+// ClearDirty marks the given nodes clean, or every node if none are given. Persistent backends call it once dirty
+// fields have been flushed.
+func (g *Graph) ClearDirty(ids ...primitive.TypedID) {
+	g.graph.ClearDirty(ids...)
 }
 
-// Close closes the global graph instance
+// ClearDirty calls Graph.ClearDirty on the default graph
+func ClearDirty(ids ...primitive.TypedID) {
+	defaultGraph.ClearDirty(ids...)
+}
+
+// Hash returns a deterministic digest of the graph's nodes, edges & attributes that is independent of insertion order
+func (g *Graph) Hash() string {
+	return g.graph.Hash()
+}
+
+// Hash calls Graph.Hash on the default graph
+func Hash() string {
+	return defaultGraph.Hash()
+}
+
+// GCUnreachable removes(or with primitive.GCDryRun, reports) every node that cannot be reached from the roots by
+// following outgoing edges
+func (g *Graph) GCUnreachable(roots []primitive.TypedID, opts ...primitive.GCOption) ([]*Node, error) {
+	removed, err := g.graph.GCUnreachable(roots, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var nodes []*Node
+	for _, n := range removed {
+		nodes = append(nodes, g.node(n))
+	}
+	return nodes, nil
+}
+
+// GCUnreachable calls Graph.GCUnreachable on the default graph
+func GCUnreachable(roots []primitive.TypedID, opts ...primitive.GCOption) ([]*Node, error) {
+	return defaultGraph.GCUnreachable(roots, opts...)
+}
+
+// Close closes the graph instance
+func (g *Graph) Close() {
+	g.graph.Close()
+}
+
+// Close calls Graph.Close on the default graph
 func Close() {
-	globalGraph.Close()
+	defaultGraph.Close()
 }
 
-// ExportJSON exports the graph as a json blob into the io Writer
-func ExportJSON(w io.Writer) error {
-	export := globalGraph.Export()
-	return json.NewEncoder(w).Encode(&export)
+// ExportJSON exports the graph as a json blob into the io Writer.
+// The transforms(ex: primitive.HashAttributes) are applied to a copy of every exported node & edge to redact sensitive attributes.
+func (g *Graph) ExportJSON(w io.Writer, transforms ...primitive.Transform) error {
+	defer g.trace("ExportJSON", nil)()
+	return json.NewEncoder(w).Encode(g.export(transforms))
 }
 
-// ImportJSON imports the json blob into the graph from the io Reader
-func ImportJSON(r io.Reader) error {
+// export is the export every serialization of the graph writes: a consistent copy of the graph with the transforms
+// applied
+func (g *Graph) export(transforms []primitive.Transform) *primitive.Export {
+	export := g.graph.Export()
+	if len(transforms) > 0 {
+		export = export.Transform(transforms...)
+	}
+	return export
+}
+
+// ExportJSON calls Graph.ExportJSON on the default graph
+func ExportJSON(w io.Writer, transforms ...primitive.Transform) error {
+	return defaultGraph.ExportJSON(w, transforms...)
+}
+
+// ExportJSONGzip exports the graph as a gzip compressed json blob into the io Writer. ImportJSON reads it back.
+func (g *Graph) ExportJSONGzip(w io.Writer, transforms ...primitive.Transform) error {
+	zw := gzip.NewWriter(w)
+	if err := g.ExportJSON(zw, transforms...); err != nil {
+		zw.Close()
+		return err
+	}
+	return zw.Close()
+}
+
+// ExportJSONGzip calls Graph.ExportJSONGzip on the default graph
+func ExportJSONGzip(w io.Writer, transforms ...primitive.Transform) error {
+	return defaultGraph.ExportJSONGzip(w, transforms...)
+}
+
+// ImportJSON imports the json blob into the graph from the io Reader. Gzip compressed blobs are decompressed.
+func (g *Graph) ImportJSON(r io.Reader) error {
+	defer g.trace("ImportJSON", nil)()
+	r, err := encoding.Decompress(r)
+	if err != nil {
+		return err
+	}
 	export := &primitive.Export{}
 	if err := json.NewDecoder(r).Decode(&export); err != nil {
 		return err
 	}
-	if err := globalGraph.Import(export); err != nil {
+	if err := g.graph.Import(export); err != nil {
 		return err
 	}
 	return nil
 }
+
+// ImportJSON calls Graph.ImportJSON on the default graph
+func ImportJSON(r io.Reader) error {
+	return defaultGraph.ImportJSON(r)
+}
+
+// SampleNeighborhoods samples layered neighborhoods around the seed nodes for mini-batch training.
+// fanouts[i] is the max number of outgoing neighbors sampled per node on hop i, and features are the node attributes
+// collected into the sample's feature matrix.
+func (g *Graph) SampleNeighborhoods(seeds []primitive.TypedID, fanouts []int, features ...string) *primitive.NeighborhoodSample {
+	return g.graph.SampleNeighborhoods(seeds, fanouts, features...)
+}
+
+// SampleNeighborhoods calls Graph.SampleNeighborhoods on the default graph
+func SampleNeighborhoods(seeds []primitive.TypedID, fanouts []int, features ...string) *primitive.NeighborhoodSample {
+	return defaultGraph.SampleNeighborhoods(seeds, fanouts, features...)
+}
+
+// SampleNodes returns a uniform random sample of up to n nodes of the given type in a single pass
+func (g *Graph) SampleNodes(typ primitive.Type, n int) []*Node {
+	var nodes []*Node
+	for _, node := range g.graph.SampleNodes(typ, n) {
+		nodes = append(nodes, g.node(node))
+	}
+	return nodes
+}
+
+// SampleNodes calls Graph.SampleNodes on the default graph
+func SampleNodes(typ primitive.Type, n int) []*Node {
+	return defaultGraph.SampleNodes(typ, n)
+}
+
+// SampleEdges samples up to n edges uniformly in a single pass(use primitive.SampleEdgeType to sample a single type). If withNegatives is true, a negative(non-existent)
+// edge with matching edge and endpoint types is generated for every sampled edge.
+func (g *Graph) SampleEdges(n int, withNegatives bool, opts ...primitive.EdgeSampleOption) *primitive.EdgeSample {
+	return g.graph.SampleEdges(n, withNegatives, opts...)
+}
+
+// SampleEdges calls Graph.SampleEdges on the default graph
+func SampleEdges(n int, withNegatives bool, opts ...primitive.EdgeSampleOption) *primitive.EdgeSample {
+	return defaultGraph.SampleEdges(n, withNegatives, opts...)
+}
+
+// LayeredLayout computes a hierarchical layout(ranks & coordinates) of the graph over edges of the given type,
+// minimizing edge crossings so dependency graphs stay readable when drawn. DOTLayeredLayout, MermaidLayeredLayout &
+// CytoscapeLayeredLayout draw exports with it; the layout itself marshals to JSON.
+func (g *Graph) LayeredLayout(edgeType primitive.Type) *primitive.Layout {
+	return g.graph.LayeredLayout(edgeType)
+}
+
+// LayeredLayout calls Graph.LayeredLayout on the default graph
+func LayeredLayout(edgeType primitive.Type) *primitive.Layout {
+	return defaultGraph.LayeredLayout(edgeType)
+}
+
+// PatchWhere applies the changes to every node of the given type that passes the predicate in one atomic pass,
+// returning the number of nodes patched. The predicate must not mutate the graph.
+func (g *Graph) PatchWhere(typ primitive.Type, pred func(n *Node) bool, changes map[string]interface{}) (int, error) {
+	return g.graph.PatchWhere(typ, func(n primitive.Node) bool {
+		return pred(g.node(n))
+	}, changes)
+}
+
+// PatchWhere calls Graph.PatchWhere on the default graph
+func PatchWhere(typ primitive.Type, pred func(n *Node) bool, changes map[string]interface{}) (int, error) {
+	return defaultGraph.PatchWhere(typ, pred, changes)
+}
+
+// MoveEdges atomically re-points every edge from & to the node onto the target node, preserving edge ids and attributes
+func (g *Graph) MoveEdges(from primitive.TypedID, to primitive.TypedID) error {
+	return g.graph.MoveEdges(from, to)
+}
+
+// MoveEdges calls Graph.MoveEdges on the default graph
+func MoveEdges(from primitive.TypedID, to primitive.TypedID) error {
+	return defaultGraph.MoveEdges(from, to)
+}
+
+// MergeNodes atomically merges the duplicate nodes into the survivor, combining attributes with the strategy,
+// re-pointing edges onto the survivor and removing the duplicates
+func (g *Graph) MergeNodes(survivor primitive.TypedID, strategy primitive.MergeStrategy, duplicates ...primitive.TypedID) error {
+	return g.graph.MergeNodes(survivor, strategy, duplicates...)
+}
+
+// MergeNodes calls Graph.MergeNodes on the default graph
+func MergeNodes(survivor primitive.TypedID, strategy primitive.MergeStrategy, duplicates ...primitive.TypedID) error {
+	return defaultGraph.MergeNodes(survivor, strategy, duplicates...)
+}
+
+// FindDuplicates groups nodes of the given type whose average score across the match rules meets the threshold into
+// merge proposals, only comparing nodes that share a blocking key(ex: primitive.SoundexKey) unless the key is nil
+func (g *Graph) FindDuplicates(typ primitive.Type, threshold float64, key primitive.BlockKey, rules ...primitive.MatchRule) []*primitive.MergeProposal {
+	return g.graph.FindDuplicates(typ, threshold, key, rules...)
+}
+
+// FindDuplicates calls Graph.FindDuplicates on the default graph
+func FindDuplicates(typ primitive.Type, threshold float64, key primitive.BlockKey, rules ...primitive.MatchRule) []*primitive.MergeProposal {
+	return defaultGraph.FindDuplicates(typ, threshold, key, rules...)
+}
+
+// ApplyProposal merges the proposal's duplicates into its survivor
+func (g *Graph) ApplyProposal(proposal *primitive.MergeProposal, strategy primitive.MergeStrategy) error {
+	return g.graph.ApplyProposal(proposal, strategy)
+}
+
+// ApplyProposal calls Graph.ApplyProposal on the default graph
+func ApplyProposal(proposal *primitive.MergeProposal, strategy primitive.MergeStrategy) error {
+	return defaultGraph.ApplyProposal(proposal, strategy)
+}
+
+// InferSchema scans the graph and describes the attributes of every node & edge type, and the endpoint types and
+// cardinalities of every edge type
+func (g *Graph) InferSchema() *primitive.InferredSchema {
+	return g.graph.InferSchema()
+}
+
+// InferSchema calls Graph.InferSchema on the default graph
+func InferSchema() *primitive.InferredSchema {
+	return defaultGraph.InferSchema()
+}
+
+// Migrate applies the migrator's pending migrations to the graph. It should be called after the graph is loaded(ex: ImportJSON)
+func (g *Graph) Migrate(m *migrate.Migrator) ([]migrate.Migration, error) {
+	return m.Up(g.graph)
+}
+
+// Migrate calls Graph.Migrate on the default graph
+func Migrate(m *migrate.Migrator) ([]migrate.Migration, error) {
+	return defaultGraph.Migrate(m)
+}
+
+// Apply converges the nodes & edges selected by the scope to the desired state, creating, replacing and deleting
+// elements as needed. The applied changes are returned as a plan.
+func (g *Graph) Apply(desired *primitive.Export, scope primitive.Filter) (*primitive.Plan, error) {
+	return g.graph.Apply(desired, scope)
+}
+
+// Apply calls Graph.Apply on the default graph
+func Apply(desired *primitive.Export, scope primitive.Filter) (*primitive.Plan, error) {
+	return defaultGraph.Apply(desired, scope)
+}
+
+// ImportJSONParallel imports the json blob into the graph from the io Reader, decoding nodes & edges across the given
+// number of workers. Every node & edge that fails to import is reported in the returned primitive.ImportErrors. Gzip
+// compressed blobs are decompressed.
+func (g *Graph) ImportJSONParallel(r io.Reader, workers int) error {
+	defer g.trace("ImportJSONParallel", nil)()
+	r, err := encoding.Decompress(r)
+	if err != nil {
+		return err
+	}
+	return g.graph.ImportJSONParallel(r, workers)
+}
+
+// ImportJSONParallel calls Graph.ImportJSONParallel on the default graph
+func ImportJSONParallel(r io.Reader, workers int) error {
+	return defaultGraph.ImportJSONParallel(r, workers)
+}
+
+// ExportNDJSON streams the graph into the io Writer as newline delimited json, one node or edge per line, with
+// bounded memory. The transforms are applied to a copy of every exported node & edge, as in ExportJSON.
+func (g *Graph) ExportNDJSON(w io.Writer, transforms ...primitive.Transform) error {
+	defer g.trace("ExportNDJSON", nil)()
+	return g.graph.ExportNDJSON(w, transforms...)
+}
+
+// ExportNDJSON calls Graph.ExportNDJSON on the default graph
+func ExportNDJSON(w io.Writer, transforms ...primitive.Transform) error {
+	return defaultGraph.ExportNDJSON(w, transforms...)
+}
+
+// ImportNDJSON streams newline delimited json written by ExportNDJSON into the graph from the io Reader. Gzip
+// compressed streams are decompressed.
+func (g *Graph) ImportNDJSON(r io.Reader) error {
+	defer g.trace("ImportNDJSON", nil)()
+	r, err := encoding.Decompress(r)
+	if err != nil {
+		return err
+	}
+	return g.graph.ImportNDJSON(r)
+}
+
+// ImportNDJSON calls Graph.ImportNDJSON on the default graph
+func ImportNDJSON(r io.Reader) error {
+	return defaultGraph.ImportNDJSON(r)
+}
+
+// BulkLoad adds the nodes & edges in one pass, validating edge endpoints & indexing edges once at the end. Use it
+// instead of AddNode/AddEdge to import large graphs.
+func (g *Graph) BulkLoad(nodes []primitive.Node, edges []*primitive.Edge) error {
+	return g.graph.BulkLoad(nodes, edges)
+}
+
+// BulkLoad calls Graph.BulkLoad on the default graph
+func BulkLoad(nodes []primitive.Node, edges []*primitive.Edge) error {
+	return defaultGraph.BulkLoad(nodes, edges)
+}
+
+// ErrNoIndex is returned when looking up an attribute that has not been indexed with CreateIndex
+var ErrNoIndex = primitive.ErrNoIndex
+
+// CreateIndex indexes the attribute of nodes of the given type so FindByIndex can look them up by value without
+// scanning every node. The index is kept up to date as nodes change.
+func (g *Graph) CreateIndex(nodeType, attribute string) error {
+	return g.graph.CreateIndex(nodeType, attribute)
+}
+
+// CreateIndex calls Graph.CreateIndex on the default graph
+func CreateIndex(nodeType, attribute string) error {
+	return defaultGraph.CreateIndex(nodeType, attribute)
+}
+
+// FindByIndex returns the nodes of the given type whose indexed attribute equals the value
+func (g *Graph) FindByIndex(nodeType, attribute string, value interface{}) ([]*Node, error) {
+	found, err := g.graph.FindByIndex(nodeType, attribute, value)
+	if err != nil {
+		return nil, err
+	}
+	var nodes []*Node
+	for _, n := range found {
+		nodes = append(nodes, g.node(n))
+	}
+	return nodes, nil
+}
+
+// FindByIndex calls Graph.FindByIndex on the default graph
+func FindByIndex(nodeType, attribute string, value interface{}) ([]*Node, error) {
+	return defaultGraph.FindByIndex(nodeType, attribute, value)
+}
+
+// ErrDuplicateEdge is returned when adding an edge that the UniqueEdges option does not allow
+var ErrDuplicateEdge = primitive.ErrDuplicateEdge
+
+// GetEdgesBetween returns the edges of the given type(use "*" for any type) that point from one node to the other
+func (g *Graph) GetEdgesBetween(from, to primitive.TypedID, edgeType string) []*Edge {
+	var edges []*Edge
+	for _, e := range g.graph.GetEdgesBetween(from, to, StringType(edgeType)) {
+		edges = append(edges, g.edge(e))
+	}
+	return edges
+}
+
+// GetEdgesBetween calls Graph.GetEdgesBetween on the default graph
+func GetEdgesBetween(from, to primitive.TypedID, edgeType string) []*Edge {
+	return defaultGraph.GetEdgesBetween(from, to, edgeType)
+}
+
+// EdgesFrom iterates over the edges of the given type that stem from the node until the iterator returns false
+func (g *Graph) EdgesFrom(id primitive.TypedID, edgeType primitive.Type, fn func(e *Edge) bool) {
+	g.node(id).EdgesFrom(edgeType, fn)
+}
+
+// EdgesFrom calls Graph.EdgesFrom on the default graph
+func EdgesFrom(id primitive.TypedID, edgeType primitive.Type, fn func(e *Edge) bool) {
+	defaultGraph.EdgesFrom(id, edgeType, fn)
+}
+
+// EdgesTo iterates over the edges of the given type that point toward the node until the iterator returns false
+func (g *Graph) EdgesTo(id primitive.TypedID, edgeType primitive.Type, fn func(e *Edge) bool) {
+	g.node(id).EdgesTo(edgeType, fn)
+}
+
+// EdgesTo calls Graph.EdgesTo on the default graph
+func EdgesTo(id primitive.TypedID, edgeType primitive.Type, fn func(e *Edge) bool) {
+	defaultGraph.EdgesTo(id, edgeType, fn)
+}
+
+// Export returns a point-in-time copy of the graph's nodes & edges
+func (g *Graph) Export() *primitive.Export {
+	defer g.trace("Export", nil)()
+	return g.graph.Export()
+}
+
+// Export calls Graph.Export on the default graph
+func Export() *primitive.Export {
+	return defaultGraph.Export()
+}
+
+// ConnectedComponents returns the groups of nodes joined to each other by edges in either direction(ex: to find
+// orphaned subgraphs after deletes)
+func (g *Graph) ConnectedComponents() [][]*Node {
+	return g.components(g.graph.ConnectedComponents())
+}
+
+// ConnectedComponents calls Graph.ConnectedComponents on the default graph
+func ConnectedComponents() [][]*Node {
+	return defaultGraph.ConnectedComponents()
+}
+
+// StronglyConnectedComponents returns the groups of nodes that can each reach every other node in the group over
+// outgoing edges
+func (g *Graph) StronglyConnectedComponents() [][]*Node {
+	return g.components(g.graph.StronglyConnectedComponents())
+}
+
+// StronglyConnectedComponents calls Graph.StronglyConnectedComponents on the default graph
+func StronglyConnectedComponents() [][]*Node {
+	return defaultGraph.StronglyConnectedComponents()
+}
+
+func (g *Graph) components(components [][]primitive.Node) [][]*Node {
+	var result [][]*Node
+	for _, c := range components {
+		var nodes []*Node
+		for _, n := range c {
+			nodes = append(nodes, g.node(n))
+		}
+		result = append(result, nodes)
+	}
+	return result
+}
+
+// Reachable returns true if the to node can be reached from the from node over outgoing edges
+func (g *Graph) Reachable(from, to primitive.TypedID) bool {
+	return g.graph.Reachable(from, to)
+}
+
+// Reachable calls Graph.Reachable on the default graph
+func Reachable(from, to primitive.TypedID) bool {
+	return defaultGraph.Reachable(from, to)
+}
+
+// Diff returns the changes that turn export a into export b(ex: to sync graphs between environments without a full
+// re-import)
+func Diff(a, b *primitive.Export) *primitive.GraphDiff {
+	return primitive.Diff(a, b)
+}
+
+// ApplyDiff atomically applies the changes in the diff to the graph
+func (g *Graph) ApplyDiff(d *primitive.GraphDiff) error {
+	return g.graph.ApplyDiff(d)
+}
+
+// ApplyDiff calls Graph.ApplyDiff on the default graph
+func ApplyDiff(d *primitive.GraphDiff) error {
+	return defaultGraph.ApplyDiff(d)
+}
+
+// ConflictFn resolves a conflict between two versions of the same node(or edge attributes) by returning the attributes
+// to keep
+type ConflictFn = primitive.ConflictFn
+
+// Merge unions the nodes & edges of the other graph into the graph(ex: to combine per-region graphs into a global view).
+// When the same typed id exists in both graphs with differing attributes, resolve picks the attributes to keep(a nil
+// resolver keeps the other graph's).
+func (g *Graph) Merge(other *Graph, resolve ConflictFn) error {
+	return g.graph.Merge(other.graph, resolve)
+}
+
+// Merge calls Graph.Merge on the default graph
+func Merge(other *Graph, resolve ConflictFn) error {
+	return defaultGraph.Merge(other, resolve)
+}
+
+// Schema declares the attributes & outgoing edge types allowed on nodes of a type
+type Schema = primitive.Schema
+
+// ErrSchemaViolation is returned when a mutation would leave a node or edge that does not conform to a registered schema
+var ErrSchemaViolation = primitive.ErrSchemaViolation
+
+// RegisterSchema registers the schema for nodes of the given type(and its declared subtypes). Adding or patching a node
+// that violates it, or connecting it with an edge type it does not allow, returns ErrSchemaViolation. Existing nodes &
+// edges are not checked.
+func (g *Graph) RegisterSchema(nodeType string, schema Schema) {
+	g.graph.RegisterSchema(nodeType, schema)
+}
+
+// RegisterSchema calls Graph.RegisterSchema on the default graph
+func RegisterSchema(nodeType string, schema Schema) {
+	defaultGraph.RegisterSchema(nodeType, schema)
+}
+
+// Schemas returns the registered schemas by node type
+func (g *Graph) Schemas() map[string]Schema {
+	return g.graph.Schemas()
+}
+
+// Schemas calls Graph.Schemas on the default graph
+func Schemas() map[string]Schema {
+	return defaultGraph.Schemas()
+}
+
+// ConstraintOption configures an edge constraint
+type ConstraintOption = primitive.ConstraintOption
+
+// From restricts the node types an edge may stem from
+func From(nodeTypes ...string) ConstraintOption {
+	return primitive.From(nodeTypes...)
+}
+
+// To restricts the node types an edge may point to
+func To(nodeTypes ...string) ConstraintOption {
+	return primitive.To(nodeTypes...)
+}
+
+// ConstrainEdge restricts the node types edges of the given type(and its subtypes) may connect(ex: owner edges may only
+// point from a dog to a user). Adding an edge that violates it returns ErrSchemaViolation.
+func (g *Graph) ConstrainEdge(edgeType string, opts ...ConstraintOption) {
+	g.graph.ConstrainEdge(edgeType, opts...)
+}
+
+// ConstrainEdge calls Graph.ConstrainEdge on the default graph
+func ConstrainEdge(edgeType string, opts ...ConstraintOption) {
+	defaultGraph.ConstrainEdge(edgeType, opts...)
+}
+
+// EdgeConstraints returns the registered edge constraints by edge type
+func (g *Graph) EdgeConstraints() map[string]primitive.EdgeConstraint {
+	return g.graph.EdgeConstraints()
+}
+
+// EdgeConstraints calls Graph.EdgeConstraints on the default graph
+func EdgeConstraints() map[string]primitive.EdgeConstraint {
+	return defaultGraph.EdgeConstraints()
+}
+
+// DeletePolicy determines what DelNode does with the edges of a type that are connected to the deleted node
+type DeletePolicy = primitive.DeletePolicy
+
+const (
+	// Detach removes the edges, leaving the other endpoints in place. It is the default policy.
+	Detach = primitive.Detach
+	// Cascade removes the edges & also deletes the other endpoints
+	Cascade = primitive.Cascade
+	// Restrict refuses to delete the node while the edges exist, returning ErrRestricted
+	Restrict = primitive.Restrict
+)
+
+// ErrMissingAttribute is panicked with by the MustGet family when a node has no value for an attribute
+var ErrMissingAttribute = primitive.ErrMissingAttribute
+
+// ErrAttributeType is panicked with by the MustGet family when an attribute holds a value of another type
+var ErrAttributeType = primitive.ErrAttributeType
+
+// ErrInvalidPath is returned when an attribute path is malformed or runs through a value that is not a map or slice
+var ErrInvalidPath = primitive.ErrInvalidPath
+
+// ErrRestricted is returned when deleting a node that has edges whose type has the Restrict delete policy
+var ErrRestricted = primitive.ErrRestricted
+
+// SetDeletePolicy sets the delete policy of edges of the given type(and its subtypes)
+func (g *Graph) SetDeletePolicy(edgeType string, policy DeletePolicy) {
+	g.graph.SetDeletePolicy(edgeType, policy)
+}
+
+// SetDeletePolicy calls Graph.SetDeletePolicy on the default graph
+func SetDeletePolicy(edgeType string, policy DeletePolicy) {
+	defaultGraph.SetDeletePolicy(edgeType, policy)
+}
+
+// CheckIntegrity scans the graph for dangling edges, stale or missing edge index entries and nodes stored under the
+// wrong type namespace
+func (g *Graph) CheckIntegrity() []primitive.IntegrityError {
+	return g.graph.CheckIntegrity()
+}
+
+// CheckIntegrity calls Graph.CheckIntegrity on the default graph
+func CheckIntegrity() []primitive.IntegrityError {
+	return defaultGraph.CheckIntegrity()
+}
+
+// Repair fixes the problems CheckIntegrity finds, returning them
+func (g *Graph) Repair() ([]primitive.IntegrityError, error) {
+	return g.graph.Repair()
+}
+
+// Repair calls Graph.Repair on the default graph
+func Repair() ([]primitive.IntegrityError, error) {
+	return defaultGraph.Repair()
+}
+
+// NodesPage returns up to limit nodes of the given type in id order, starting after the cursor, along with the cursor
+// of the next page(ex: for paginated HTTP APIs). Pass an empty cursor for the first page; the next cursor is empty once
+// there are no more nodes.
+func (g *Graph) NodesPage(nodeType string, cursor string, limit int) ([]*Node, string) {
+	page, next := g.graph.NodesPage(nodeType, cursor, limit)
+	nodes := make([]*Node, 0, len(page))
+	for _, n := range page {
+		nodes = append(nodes, g.node(n))
+	}
+	return nodes, next
+}
+
+// NodesPage calls Graph.NodesPage on the default graph
+func NodesPage(nodeType string, cursor string, limit int) ([]*Node, string) {
+	return defaultGraph.NodesPage(nodeType, cursor, limit)
+}
+
+// EdgesPage returns up to limit edges of the given type in id order, starting after the cursor, along with the cursor
+// of the next page. Pass an empty cursor for the first page; the next cursor is empty once there are no more edges.
+func (g *Graph) EdgesPage(edgeType string, cursor string, limit int) ([]*Edge, string) {
+	page, next := g.graph.EdgesPage(edgeType, cursor, limit)
+	edges := make([]*Edge, 0, len(page))
+	for _, e := range page {
+		edges = append(edges, g.edge(e))
+	}
+	return edges, next
+}
+
+// EdgesPage calls Graph.EdgesPage on the default graph
+func EdgesPage(edgeType string, cursor string, limit int) ([]*Edge, string) {
+	return defaultGraph.EdgesPage(edgeType, cursor, limit)
+}
@@ -1,9 +1,34 @@
 package dagger_test
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/autom8ter/dagger"
+	"github.com/autom8ter/dagger/encoding"
+	"github.com/autom8ter/dagger/loadtest"
+	"github.com/autom8ter/dagger/migrate"
+	"github.com/autom8ter/dagger/primitive"
+	"github.com/autom8ter/dagger/traversal"
+	"github.com/autom8ter/dagger/ui"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 var (
@@ -195,6 +220,144 @@ func Benchmark(t *testing.B) {
 	t.Logf("visited: %v edgesTo", edgesTo)
 }
 
+func BenchmarkStorageParallelSet(b *testing.B) {
+	storage := primitive.NewMemoryStorage()
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			i++
+			storage.Set("user", fmt.Sprint(i%1024), i)
+		}
+	})
+}
+
+func BenchmarkStorageParallelGet(b *testing.B) {
+	storage := primitive.NewMemoryStorage()
+	for i := 0; i < 1024; i++ {
+		storage.Set("user", fmt.Sprint(i), i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			i++
+			storage.Get("user", fmt.Sprint(i%1024))
+		}
+	})
+}
+
+// BenchmarkParallelAddNode measures writers adding different nodes, which only lock the stripes of the nodes they add,
+// so they scale with cores. Run it with -cpu 1,4,8 to compare.
+func BenchmarkParallelAddNode(b *testing.B) {
+	g := dagger.NewGraph()
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			g.NewNode(map[string]interface{}{"_type": "user", "name": "coleman"})
+		}
+	})
+}
+
+// BenchmarkParallelIncrementNode measures writers patching nodes of their own. Run it with -cpu 1,4,8 to compare.
+func BenchmarkParallelIncrementNode(b *testing.B) {
+	g := primitive.NewGraph()
+	var next int64
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		n := primitive.NewNode(map[string]interface{}{"_type": "user", "_id": fmt.Sprint(atomic.AddInt64(&next, 1))})
+		if err := g.AddNode(n); err != nil {
+			b.Fatal(err)
+		}
+		for pb.Next() {
+			if _, err := g.IncrementNode(n, "requests", 1); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkParallelGetNodeWhileWriting measures reads while a writer mutates the graph. Reads only lock a storage
+// shard, so they do not wait for the writer's mutations to complete. Run it with -cpu 1,4,8 to compare.
+func BenchmarkParallelGetNodeWhileWriting(b *testing.B) {
+	g := dagger.NewGraph()
+	var ids []primitive.TypedID
+	for i := 0; i < 1024; i++ {
+		ids = append(ids, g.NewNode(map[string]interface{}{"_type": "user", "_id": fmt.Sprint(i)}))
+	}
+	done := make(chan struct{})
+	writing := make(chan struct{})
+	go func() {
+		defer close(writing)
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+				g.NewNode(map[string]interface{}{"_type": "user", "_id": fmt.Sprint(i % 1024), "writes": i})
+			}
+		}
+	}()
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			i++
+			g.GetNode(ids[i%1024])
+		}
+	})
+	b.StopTimer()
+	close(done)
+	<-writing
+}
+
+func BenchmarkFindDuplicates(b *testing.B) {
+	g := primitive.NewGraph()
+	first := []string{"robert", "rupert", "lacee", "tyler", "coleman", "sarah", "charlie", "ava"}
+	for i := 0; i < 1000; i++ {
+		g.AddNode(primitive.NewNode(map[string]interface{}{
+			"_type": "user",
+			"name":  fmt.Sprintf("%s %s", first[i%len(first)], primitive.UUID()[:6]),
+		}))
+	}
+	for name, key := range map[string]primitive.BlockKey{"all pairs": nil, "soundex": primitive.SoundexKey("name")} {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				g.FindDuplicates(dagger.StringType("user"), 1, key, primitive.PhoneticMatch("name"))
+			}
+		})
+	}
+}
+
+func BenchmarkEdgesFrom(b *testing.B) {
+	g := primitive.NewGraph()
+	hub := primitive.NewNode(map[string]interface{}{"_type": "user", "_id": "hub"})
+	g.AddNode(hub)
+	for i := 0; i < 100; i++ {
+		friend := primitive.NewNode(map[string]interface{}{"_type": "user", "_id": fmt.Sprint(i)})
+		g.AddNode(friend)
+		g.AddEdge(&primitive.Edge{Node: primitive.NewNode(map[string]interface{}{"_type": "friend"}), From: hub, To: friend})
+	}
+	friend := dagger.StringType("friend")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		count := 0
+		g.EdgesFrom(friend, hub, func(e *primitive.Edge) bool {
+			count++
+			return true
+		})
+		if count != 100 {
+			b.Fatalf("expected 100 edges, got %v", count)
+		}
+	}
+}
+
 func TestExportJSON(t *testing.T) {
 	os.Remove("testing.json")
 	_ = dagger.NewNode(map[string]interface{}{
@@ -235,3 +398,4965 @@ func TestExportJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestSampleNeighborhoods(t *testing.T) {
+	g := primitive.NewGraph()
+	seed := primitive.NewNode(map[string]interface{}{
+		"_type": "user",
+		"age":   30,
+	})
+	g.AddNode(seed)
+	for i := 0; i < 5; i++ {
+		friend := primitive.NewNode(map[string]interface{}{
+			"_type": "user",
+			"age":   i,
+		})
+		g.AddNode(friend)
+		if err := g.AddEdge(&primitive.Edge{
+			Node: primitive.NewNode(map[string]interface{}{"_type": "friend"}),
+			From: seed,
+			To:   friend,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sample := g.SampleNeighborhoods([]primitive.TypedID{seed}, []int{3, 2}, "age")
+	if len(sample.Layers) != 2 {
+		t.Fatalf("expected 2 layers, got %v", len(sample.Layers))
+	}
+	if len(sample.Layers[0].Src) != 3 {
+		t.Fatalf("expected 3 sampled neighbors, got %v", len(sample.Layers[0].Src))
+	}
+	if len(sample.Nodes) != 4 || len(sample.Features) != 4 {
+		t.Fatalf("expected 4 nodes, got %v", len(sample.Nodes))
+	}
+	if sample.Features[0][0] != 30 {
+		t.Fatalf("expected seed feature to be 30, got %v", sample.Features[0][0])
+	}
+	all := g.SampleNeighborhoods([]primitive.TypedID{seed}, []int{-1}, "age")
+	if len(all.Layers[0].Src) != 5 {
+		t.Fatalf("expected a negative fanout to sample every neighbor, got %v", len(all.Layers[0].Src))
+	}
+	all.Nodes[0].Set("age", 31)
+	if n, _ := g.GetNode(seed); n.GetInt("age") != 30 {
+		t.Fatal("expected sampled nodes to be copies")
+	}
+}
+
+func TestSampleEdges(t *testing.T) {
+	g := primitive.NewGraph()
+	var users []primitive.Node
+	for i := 0; i < 10; i++ {
+		user := primitive.NewNode(map[string]interface{}{
+			"_type": "user",
+		})
+		g.AddNode(user)
+		users = append(users, user)
+	}
+	for i := 1; i < len(users); i++ {
+		if err := g.AddEdge(&primitive.Edge{
+			Node: primitive.NewNode(map[string]interface{}{"_type": "friend"}),
+			From: users[0],
+			To:   users[i],
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sample := g.SampleEdges(3, true, primitive.SampleEdgeType(dagger.StringType("friend")))
+	if len(sample.Positives) != 3 {
+		t.Fatalf("expected 3 positive edges, got %v", len(sample.Positives))
+	}
+	sample.Positives[0].Set("weight", 2)
+	if e, _ := g.GetEdge(sample.Positives[0]); e.Get("weight") != nil {
+		t.Fatal("expected sampled edges to be copies")
+	}
+	ratios := map[string]float64{"train": 0.5, "test": 0.5}
+	train := g.SampleEdges(100, false, primitive.SampleSplit("train", ratios))
+	test := g.SampleEdges(100, false, primitive.SampleSplit("test", ratios))
+	if len(train.Positives)+len(test.Positives) != len(users)-1 {
+		t.Fatalf("expected splits to partition edges, got %v train %v test", len(train.Positives), len(test.Positives))
+	}
+	for _, e := range train.Positives {
+		for _, other := range test.Positives {
+			if e.ID() == other.ID() {
+				t.Fatalf("edge %s leaked across splits", e.ID())
+			}
+		}
+	}
+	// negatives are never edges of the graph, including edges of a subtype of their type
+	if err := g.DeclareEdgeType("friend", "best_friend"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 2; i < len(users); i++ {
+		if err := g.AddEdge(&primitive.Edge{
+			Node: primitive.NewNode(map[string]interface{}{"_type": "best_friend"}),
+			From: users[1],
+			To:   users[i],
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sample = g.SampleEdges(100, true, primitive.SampleEdgeType(dagger.StringType("friend")))
+	if len(sample.Positives) != 2*(len(users)-1)-1 {
+		t.Fatalf("expected edges of the subtype to be sampled, got %v", len(sample.Positives))
+	}
+	if len(sample.Negatives) == 0 {
+		t.Fatal("expected negative edges")
+	}
+	for _, negative := range sample.Negatives {
+		g.EdgesFrom(dagger.StringType("friend"), negative.From, func(e *primitive.Edge) bool {
+			if e.To.ID() == negative.To.ID() {
+				t.Fatalf("expected negative %v -> %v not to be an edge", negative.From.ID(), negative.To.ID())
+			}
+			return true
+		})
+		if negative.From.ID() == negative.To.ID() {
+			t.Fatalf("expected negative %v not to be a self loop", negative.From.ID())
+		}
+	}
+}
+
+func TestAlerter(t *testing.T) {
+	if _, err := dagger.NewAlerter(nil); err == nil {
+		t.Fatal("expected an error creating an alerter without a notify func")
+	}
+	var (
+		mu    sync.Mutex
+		fired []dagger.Alert
+	)
+	g := dagger.NewGraph()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes, err := g.Subscribe(ctx, primitive.ChangeFilter{Ops: []primitive.ChangeOp{primitive.ChangeAlert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	alerter, err := g.NewAlerter(func(a dagger.Alert) {
+		mu.Lock()
+		defer mu.Unlock()
+		fired = append(fired, a)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// rules sharing a name fire independently
+	for i := 0; i < 2; i++ {
+		alerter.AddRule(dagger.AlertRule{
+			Name:      "no_admins",
+			Metric:    g.NodeCountMetric(dagger.StringType("alert_admin")),
+			Threshold: 1,
+			Below:     true,
+		})
+	}
+	alerter.Evaluate()
+	alerter.Evaluate()
+	if len(fired) != 2 {
+		t.Fatalf("expected each rule to fire once, fired %v times", len(fired))
+	}
+	if fired[0].Rule != "no_admins" || fired[0].Value != 0 {
+		t.Fatalf("unexpected alert: %v", fired[0])
+	}
+	for i := 0; i < 2; i++ {
+		if c := <-changes; c.Alert == nil || c.Alert.Rule != "no_admins" {
+			t.Fatalf("expected the alert to be published to subscribers, got %+v", c)
+		}
+	}
+	if err := alerter.Start(0); err == nil {
+		t.Fatal("expected an error starting an alerter without a positive interval")
+	}
+	// starting twice must not evaluate on two goroutines: each tick evaluates the metric once
+	var evaluations int64
+	alerter.AddRule(dagger.AlertRule{
+		Name: "evaluations",
+		Metric: func() float64 {
+			atomic.AddInt64(&evaluations, 1)
+			return 0
+		},
+	})
+	if err := alerter.Start(10 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := alerter.Start(10 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(55 * time.Millisecond)
+	alerter.Stop()
+	alerter.Stop()
+	if n := atomic.LoadInt64(&evaluations); n > 5 {
+		t.Fatalf("expected at most 5 evaluations in 5 ticks, got %v", n)
+	}
+}
+
+func TestLayeredLayout(t *testing.T) {
+	g := primitive.NewGraph()
+	var tasks []primitive.Node
+	for i := 0; i < 4; i++ {
+		task := primitive.NewNode(map[string]interface{}{
+			"_type": "task",
+		})
+		g.AddNode(task)
+		tasks = append(tasks, task)
+	}
+	for _, pair := range [][2]int{{0, 1}, {1, 2}, {0, 2}, {2, 3}} {
+		if err := g.AddEdge(&primitive.Edge{
+			Node: primitive.NewNode(map[string]interface{}{"_type": "depends_on"}),
+			From: tasks[pair[0]],
+			To:   tasks[pair[1]],
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	layout := g.LayeredLayout(dagger.AnyType())
+	if len(layout.Positions) != len(tasks) {
+		t.Fatalf("expected %v positions, got %v", len(tasks), len(layout.Positions))
+	}
+	for i, task := range tasks {
+		p, ok := layout.Position(task)
+		if !ok || p.Rank != i {
+			t.Fatalf("expected task %v to be ranked %v, got %v", task.ID(), i, p.Rank)
+		}
+	}
+	// close a cycle - every node must still be placed
+	if err := g.AddEdge(&primitive.Edge{
+		Node: primitive.NewNode(map[string]interface{}{"_type": "depends_on"}),
+		From: tasks[3],
+		To:   tasks[0],
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if layout := g.LayeredLayout(dagger.AnyType()); len(layout.Positions) != len(tasks) {
+		t.Fatalf("expected %v positions, got %v", len(tasks), len(layout.Positions))
+	}
+}
+
+func TestPatchWhere(t *testing.T) {
+	g := primitive.NewGraph()
+	for i := 0; i < 10; i++ {
+		g.AddNode(primitive.NewNode(map[string]interface{}{
+			"_type": "user",
+			"age":   i,
+		}))
+	}
+	patched, err := g.PatchWhere(dagger.StringType("user"), func(n primitive.Node) bool {
+		return n.GetInt("age") >= 5
+	}, map[string]interface{}{
+		"adult": true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patched != 5 {
+		t.Fatalf("expected 5 patched nodes, got %v", patched)
+	}
+	g.RangeNodes(func(n primitive.Node) bool {
+		if n.GetBool("adult") != (n.GetInt("age") >= 5) {
+			t.Fatalf("unexpected patch result: %v", n)
+		}
+		return true
+	})
+	if _, err := g.PatchWhere(dagger.AnyType(), func(n primitive.Node) bool {
+		return true
+	}, map[string]interface{}{
+		"_type": "admin",
+	}); err == nil {
+		t.Fatal("expected error patching node type")
+	}
+}
+
+func TestMoveEdges(t *testing.T) {
+	g := primitive.NewGraph()
+	var users []primitive.Node
+	for i := 0; i < 3; i++ {
+		user := primitive.NewNode(map[string]interface{}{
+			"_type": "user",
+		})
+		g.AddNode(user)
+		users = append(users, user)
+	}
+	edge := &primitive.Edge{
+		Node: primitive.NewNode(map[string]interface{}{"_type": "friend", "since": 2020}),
+		From: users[0],
+		To:   users[1],
+	}
+	if err := g.AddEdge(edge); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.MoveEdges(users[1], users[2]); err != nil {
+		t.Fatal(err)
+	}
+	moved, ok := g.GetEdge(edge)
+	if !ok {
+		t.Fatal("expected edge to keep its id")
+	}
+	if moved.To.ID() != users[2].ID() || moved.GetInt("since") != 2020 {
+		t.Fatalf("unexpected moved edge: %v", moved)
+	}
+	g.EdgesTo(dagger.AnyType(), users[1], func(e *primitive.Edge) bool {
+		t.Fatal("expected no edges to the replaced node")
+		return true
+	})
+
+	unique := primitive.NewGraph(primitive.UniqueEdges(true))
+	unique.AddNodes(users[0], users[1], users[2])
+	for _, to := range users[1:] {
+		if err := unique.AddEdge(&primitive.Edge{
+			Node: primitive.NewNode(map[string]interface{}{"_type": "friend"}),
+			From: users[0],
+			To:   to,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := unique.MoveEdges(users[1], users[2]); !errors.Is(err, primitive.ErrDuplicateEdge) {
+		t.Fatalf("expected a duplicate edge error, got %v", err)
+	}
+	if len(unique.GetEdgesBetween(users[0], users[1], dagger.AnyType())) != 1 {
+		t.Fatal("expected a rejected move to leave the edge in place")
+	}
+}
+
+func TestMergeNodes(t *testing.T) {
+	g := primitive.NewGraph()
+	survivor := primitive.NewNode(map[string]interface{}{
+		"_type": "user",
+		"name":  "coleman",
+	})
+	duplicate := primitive.NewNode(map[string]interface{}{
+		"_type": "user",
+		"name":  "Coleman",
+		"email": "coleman@example.com",
+	})
+	friend := primitive.NewNode(map[string]interface{}{
+		"_type": "user",
+	})
+	g.AddNodes(survivor, duplicate, friend)
+	if err := g.AddEdge(&primitive.Edge{
+		Node: primitive.NewNode(map[string]interface{}{"_type": "friend"}),
+		From: friend,
+		To:   duplicate,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.MergeNodes(survivor, primitive.KeepSurvivor, duplicate); err != nil {
+		t.Fatal(err)
+	}
+	if g.HasNode(duplicate) {
+		t.Fatal("expected duplicate to be removed")
+	}
+	merged, _ := g.GetNode(survivor)
+	if merged.GetString("name") != "coleman" || merged.GetString("email") != "coleman@example.com" {
+		t.Fatalf("unexpected merged attributes: %v", merged)
+	}
+	if provenance, _ := merged.Get(primitive.MERGED_KEY).([]interface{}); len(provenance) != 1 {
+		t.Fatalf("expected provenance to be recorded, got %v", merged.Get(primitive.MERGED_KEY))
+	}
+	edges := 0
+	g.EdgesTo(dagger.AnyType(), survivor, func(e *primitive.Edge) bool {
+		edges++
+		return true
+	})
+	if edges != 1 {
+		t.Fatalf("expected 1 edge to the survivor, got %v", edges)
+	}
+
+	unique := primitive.NewGraph(primitive.UniqueEdges(true))
+	survivor = primitive.NewNode(map[string]interface{}{"_type": "user", "name": "coleman"})
+	duplicate = primitive.NewNode(map[string]interface{}{"_type": "user", "name": "Coleman"})
+	friend = primitive.NewNode(map[string]interface{}{"_type": "user"})
+	unique.AddNodes(survivor, duplicate, friend)
+	for _, from := range []primitive.Node{survivor, duplicate} {
+		if err := unique.AddEdge(&primitive.Edge{
+			Node: primitive.NewNode(map[string]interface{}{"_type": "friend"}),
+			From: from,
+			To:   friend,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := unique.MergeNodes(survivor, primitive.KeepDuplicate, duplicate); !errors.Is(err, primitive.ErrDuplicateEdge) {
+		t.Fatalf("expected a duplicate edge error, got %v", err)
+	}
+	if !unique.HasNode(duplicate) || len(unique.GetEdgesBetween(duplicate, friend, dagger.AnyType())) != 1 {
+		t.Fatal("expected a rejected merge to leave the duplicate & its edge in place")
+	}
+	if n, _ := unique.GetNode(survivor); n.GetString("name") != "coleman" || n.Get(primitive.MERGED_KEY) != nil {
+		t.Fatalf("expected a rejected merge to leave the survivor unchanged, got %v", n)
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	g := primitive.NewGraph()
+	for _, name := range []string{"Robert Smith", "robert smith", "Rupert Smyth", "Lacee"} {
+		g.AddNode(primitive.NewNode(map[string]interface{}{
+			"_type": "user",
+			"name":  name,
+		}))
+	}
+	proposals := g.FindDuplicates(dagger.StringType("user"), 1, nil, primitive.NormalizedMatch("name"))
+	if len(proposals) != 1 || len(proposals[0].Duplicates) != 1 {
+		t.Fatalf("expected 1 proposal with 1 duplicate, got %v", proposals)
+	}
+	proposals = g.FindDuplicates(dagger.StringType("user"), 1, primitive.PrefixKey("name", 3), primitive.NormalizedMatch("name"))
+	if len(proposals) != 1 || len(proposals[0].Duplicates) != 1 {
+		t.Fatalf("expected blocking on a prefix to find the same duplicate, got %v", proposals)
+	}
+	proposals = g.FindDuplicates(dagger.StringType("user"), 1, primitive.SoundexKey("name"), primitive.PhoneticMatch("name"))
+	if len(proposals) != 1 || len(proposals[0].Duplicates) != 2 {
+		t.Fatalf("expected 1 proposal with 2 duplicates, got %v", proposals)
+	}
+	if err := g.ApplyProposal(proposals[0], primitive.KeepSurvivor); err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	g.RangeNodes(func(n primitive.Node) bool {
+		count++
+		return true
+	})
+	if count != 2 {
+		t.Fatalf("expected 2 nodes after merge, got %v", count)
+	}
+}
+
+func TestExportTransform(t *testing.T) {
+	g := primitive.NewGraph()
+	user := primitive.NewNode(map[string]interface{}{
+		"_type": "user",
+		"email": "coleman@example.com",
+		"ssn":   "123-45-6789",
+		"phone": "555-5555",
+		"age":   37,
+	})
+	g.AddNode(user)
+	export := g.Export().Transform(
+		primitive.HashAttributes("salt", "email"),
+		primitive.StripAttributes("ssn"),
+		primitive.MaskAttributes("phone"),
+		primitive.Bucketize("age", 10),
+	)
+	redacted := export.Nodes[0]
+	if redacted.GetString("email") == "coleman@example.com" || redacted.GetString("email") == "" {
+		t.Fatalf("expected email to be hashed, got %v", redacted.GetString("email"))
+	}
+	if redacted.Exists("ssn") {
+		t.Fatal("expected ssn to be stripped")
+	}
+	if redacted.GetString("phone") != "***" {
+		t.Fatalf("expected phone to be masked, got %v", redacted.GetString("phone"))
+	}
+	if redacted.GetString("age") != "30-39" {
+		t.Fatalf("expected age to be bucketized, got %v", redacted.GetString("age"))
+	}
+	if original, _ := g.GetNode(user); original.GetString("ssn") != "123-45-6789" {
+		t.Fatal("expected the graph to be left untouched")
+	}
+}
+
+func TestExportTransformsEveryFormat(t *testing.T) {
+	g := dagger.NewGraph(dagger.TrackChanges())
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman", "ssn": "123-45-6789"})
+	tyler := g.NewNode(map[string]interface{}{"_type": "user", "_id": "tyler", "ssn": "987-65-4321"})
+	if _, err := coleman.Connect(tyler, "friend", false); err != nil {
+		t.Fatal(err)
+	}
+	strip := primitive.StripAttributes("ssn")
+	exports := map[string]func(w io.Writer) error{
+		"json":   func(w io.Writer) error { return g.ExportJSON(w, strip) },
+		"ndjson": func(w io.Writer) error { return g.ExportNDJSON(w, strip) },
+		"since": func(w io.Writer) error {
+			_, err := g.ExportSince(0, w, strip)
+			return err
+		},
+		"proto":    func(w io.Writer) error { return g.ExportProto(w, strip) },
+		"graphml":  func(w io.Writer) error { return g.Encode(w, encoding.GraphML{}, strip) },
+		"ntriples": func(w io.Writer) error { return g.ExportNTriples(w, strip) },
+		"dot": func(w io.Writer) error {
+			return g.ExportDOT(w, dagger.DOTNodeLabel("ssn"), dagger.DOTTransforms(strip))
+		},
+		"mermaid": func(w io.Writer) error {
+			return g.ExportMermaid(w, dagger.MermaidNodeLabel("ssn"), dagger.MermaidTransforms(strip))
+		},
+		"cypher":    func(w io.Writer) error { return g.ExportCypher(w, dagger.CypherTransforms(strip)) },
+		"cytoscape": func(w io.Writer) error { return g.ExportCytoscape(w, dagger.CytoscapeTransforms(strip)) },
+	}
+	for name, export := range exports {
+		buf := bytes.NewBuffer(nil)
+		if err := export(buf); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if buf.Len() == 0 || bytes.Contains(buf.Bytes(), []byte("123-45-6789")) {
+			t.Fatalf("expected the %s export to be redacted, got %s", name, buf.String())
+		}
+	}
+	mem := &sqlDB{tables: map[string]*sqlTable{}}
+	db := sql.OpenDB(mem)
+	defer db.Close()
+	if err := g.ExportSQL(db, dagger.SQLTransforms(strip)); err != nil {
+		t.Fatal(err)
+	}
+	if users := mem.tables["node_user"]; users == nil || len(users.rows) != 2 || users.rows[0]["ssn"] != nil {
+		t.Fatalf("expected the sql export to be redacted, got %+v", users)
+	}
+	if n, _ := g.GetNode(coleman); n.GetString("ssn") != "123-45-6789" {
+		t.Fatal("expected the graph to be left untouched")
+	}
+}
+
+func TestInferSchema(t *testing.T) {
+	g := primitive.NewGraph()
+	owner := primitive.NewNode(map[string]interface{}{
+		"_type": "user",
+		"name":  "coleman",
+	})
+	g.AddNode(owner)
+	for i := 0; i < 2; i++ {
+		dog := primitive.NewNode(map[string]interface{}{
+			"_type":  "dog",
+			"name":   "charlie",
+			"weight": 19.5,
+		})
+		g.AddNode(dog)
+		if err := g.AddEdge(&primitive.Edge{
+			Node: primitive.NewNode(map[string]interface{}{"_type": "pet"}),
+			From: owner,
+			To:   dog,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	schema := g.InferSchema()
+	dog := schema.Nodes["dog"]
+	if dog == nil || dog.Count != 2 || !dog.Attributes["weight"].Required || dog.Attributes["weight"].Kinds[0] != "float" {
+		t.Fatalf("unexpected dog schema: %v", dog)
+	}
+	pet := schema.Edges["pet"]
+	if pet == nil || len(pet.Endpoints) != 1 || pet.Endpoints[0].Cardinality != "one-to-many" {
+		t.Fatalf("unexpected pet schema: %v", pet)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	g := primitive.NewGraph()
+	user := primitive.NewNode(map[string]interface{}{
+		"_type":    "usr",
+		"fullname": "coleman word",
+	})
+	dog := primitive.NewNode(map[string]interface{}{
+		"_type": "dog",
+	})
+	g.AddNodes(user, dog)
+	if err := g.AddEdge(&primitive.Edge{
+		Node: primitive.NewNode(map[string]interface{}{"_type": "pet"}),
+		From: user,
+		To:   dog,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	m := migrate.New(
+		migrate.Migration{Version: 2, Name: "retype users", Up: migrate.RetypeNodes("usr", "user")},
+		migrate.Migration{Version: 1, Name: "rename fullname", Up: migrate.RenameAttribute("usr", "fullname", "name")},
+	)
+	applied, err := m.Up(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 2 || migrate.Version(g) != 2 {
+		t.Fatalf("expected 2 migrations to be applied, got %v(version %v)", len(applied), migrate.Version(g))
+	}
+	migrated, ok := g.GetNode(&dagger.ForeignKey{XID: user.ID(), XType: "user"})
+	if !ok || migrated.GetString("name") != "coleman word" {
+		t.Fatalf("unexpected migrated user: %v", migrated)
+	}
+	pets := 0
+	g.EdgesFrom(dagger.AnyType(), migrated, func(e *primitive.Edge) bool {
+		pets++
+		return true
+	})
+	if pets != 1 {
+		t.Fatalf("expected migrated user to keep its edges, got %v", pets)
+	}
+	if applied, _ := m.Up(g); len(applied) != 0 {
+		t.Fatal("expected no pending migrations")
+	}
+}
+
+func TestLoadSeed(t *testing.T) {
+	seed := `
+# seeded users & pets
+nodes:
+  seed_owner:
+    _type: user
+    _id: seed_owner
+    name: "coleman # not a comment"
+    tags: [admin, ops]
+  seed_dog:
+    _type: dog
+    _id: seed_dog
+    weight: 25
+edges:
+  - type: pet
+    from: seed_owner
+    to: seed_dog
+    attributes:
+      since: 2019
+`
+	if err := dagger.LoadSeed(strings.NewReader(seed)); err != nil {
+		t.Fatal(err)
+	}
+	owner, ok := dagger.GetNode(&dagger.ForeignKey{XID: "seed_owner", XType: "user"})
+	if !ok {
+		t.Fatal("expected seeded owner to exist")
+	}
+	if owner.GetString("name") != "coleman # not a comment" {
+		t.Fatalf("unexpected name: %v", owner.GetString("name"))
+	}
+	if tags, _ := owner.Get("tags").([]interface{}); len(tags) != 2 {
+		t.Fatalf("unexpected tags: %v", owner.Get("tags"))
+	}
+	pets := owner.FilterEdgesFrom(dagger.StringType("pet"), func(e *dagger.Edge) bool {
+		return e.GetInt("since") == 2019 && e.To().GetInt("weight") == 25
+	})
+	if len(pets) != 1 {
+		t.Fatalf("expected 1 seeded pet edge, got %v", len(pets))
+	}
+	dagger.DelNode(owner)
+	dagger.DelNode(&dagger.ForeignKey{XID: "seed_dog", XType: "dog"})
+	if err := dagger.LoadSeed(strings.NewReader("edges:\n  - type: pet\n    from: missing\n    to: missing\n")); err == nil {
+		t.Fatal("expected error for unknown node reference")
+	}
+
+	g := dagger.NewGraph()
+	g.ConstrainEdge("pet", dagger.From("user"), dagger.To("dog"))
+	anchored := `
+defaults: &user
+  _type: user
+  roles: [member]
+nodes:
+  alice:
+    <<: *user
+    _id: alice
+    name: alice
+  bob:
+    _id: bob
+    name: bob
+    <<: *user
+    roles: [admin]
+  rex:
+    _type: dog
+    _id: rex
+edges:
+  - &pet
+    type: pet
+    from: alice
+    to: rex
+  - type: pet
+    from: bob
+    to: rex
+`
+	if err := g.LoadSeed(strings.NewReader(anchored)); err != nil {
+		t.Fatal(err)
+	}
+	alice, ok := g.GetNode(&dagger.ForeignKey{XID: "alice", XType: "user"})
+	if !ok {
+		t.Fatal("expected alias merged node to have the anchored type")
+	}
+	bob, _ := g.GetNode(&dagger.ForeignKey{XID: "bob", XType: "user"})
+	if roles, _ := bob.Get("roles").([]interface{}); len(roles) != 1 || roles[0] != "admin" {
+		t.Fatalf("expected keys to override merged keys, got %v", bob.Get("roles"))
+	}
+	if roles, _ := alice.Get("roles").([]interface{}); len(roles) != 1 || roles[0] != "member" {
+		t.Fatalf("expected merged roles, got %v", alice.Get("roles"))
+	}
+	if g.EdgeCount() != 2 {
+		t.Fatalf("expected 2 seeded edges, got %v", g.EdgeCount())
+	}
+	// pets must be dogs, so the seed is rejected & neither node is loaded
+	broken := `
+nodes:
+  carol:
+    _type: user
+    _id: carol
+  tom:
+    _type: cat
+    _id: tom
+edges:
+  - type: pet
+    from: carol
+    to: carol
+  - type: pet
+    from: carol
+    to: tom
+`
+	if err := g.LoadSeed(strings.NewReader(broken)); err == nil {
+		t.Fatal("expected the edge constraint to reject the seed")
+	}
+	if g.HasNode(&dagger.ForeignKey{XID: "carol", XType: "user"}) || g.EdgeCount() != 2 {
+		t.Fatal("expected a rejected seed to change nothing")
+	}
+	if err := g.LoadSeed(strings.NewReader("nodes:\n  x: *missing\n")); err == nil {
+		t.Fatal("expected error for unknown alias")
+	}
+}
+
+func TestApply(t *testing.T) {
+	g := primitive.NewGraph()
+	stale := primitive.NewNode(map[string]interface{}{
+		"_type": "host",
+		"_id":   "stale",
+	})
+	web := primitive.NewNode(map[string]interface{}{
+		"_type": "host",
+		"_id":   "web",
+		"os":    "linux",
+	})
+	untouched := primitive.NewNode(map[string]interface{}{
+		"_type": "user",
+		"_id":   "coleman",
+	})
+	g.AddNodes(stale, web, untouched)
+	desired := &primitive.Export{
+		Nodes: []primitive.Node{
+			{"_type": "host", "_id": "web", "os": "linux", "cpus": 4},
+			{"_type": "host", "_id": "db"},
+		},
+		Edges: []*primitive.Edge{
+			{
+				Node: primitive.Node{"_type": "connects"},
+				From: primitive.Node{"_type": "host", "_id": "web"},
+				To:   primitive.Node{"_type": "host", "_id": "db"},
+			},
+		},
+	}
+	scope := primitive.Filter{NodeTypes: []string{"host"}, EdgeTypes: []string{"connects"}}
+	plan, err := g.Apply(desired, scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.CreateNodes) != 1 || len(plan.PatchNodes) != 1 || len(plan.DeleteNodes) != 1 || len(plan.CreateEdges) != 1 {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+	if g.HasNode(stale) || !g.HasNode(untouched) {
+		t.Fatal("expected only the stale host to be deleted")
+	}
+	if n, _ := g.GetNode(web); n.GetInt("cpus") != 4 {
+		t.Fatalf("expected web to be patched, got %v", n)
+	}
+	if plan := g.Plan(desired, scope); !plan.Empty() {
+		t.Fatalf("expected apply to converge, got %+v", plan)
+	}
+	// a plan the graph rejects changes nothing
+	broken := &primitive.Export{
+		Nodes: []primitive.Node{
+			{"_type": "host", "_id": "web", "os": "windows"},
+		},
+		Edges: []*primitive.Edge{
+			{
+				Node: primitive.Node{"_type": "connects"},
+				From: primitive.Node{"_type": "host", "_id": "web"},
+				To:   primitive.Node{"_type": "host", "_id": "ghost"},
+			},
+		},
+	}
+	if _, err := g.Apply(broken, scope); err == nil {
+		t.Fatal("expected an edge to a missing node to fail the apply")
+	}
+	if n, _ := g.GetNode(web); n.GetString("os") != "linux" || !g.HasNode(primitive.Node{"_type": "host", "_id": "db"}) {
+		t.Fatalf("expected a failed apply to change nothing, got %v", n)
+	}
+	if plan := g.Plan(desired, scope); !plan.Empty() {
+		t.Fatalf("expected a failed apply to change nothing, got %+v", plan)
+	}
+}
+
+func TestTraversalBudget(t *testing.T) {
+	g := primitive.NewGraph()
+	var chain []primitive.Node
+	for i := 0; i < 10; i++ {
+		n := primitive.NewNode(map[string]interface{}{
+			"_type": "hop",
+		})
+		g.AddNode(n)
+		if i > 0 {
+			if err := g.AddEdge(&primitive.Edge{
+				Node: primitive.NewNode(map[string]interface{}{"_type": "next"}),
+				From: chain[i-1],
+				To:   n,
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		chain = append(chain, n)
+	}
+	visit := func(n primitive.Node, depth int) bool {
+		return true
+	}
+	if result := g.BFS(chain[0], dagger.AnyType(), primitive.TraversalOptions{}, visit); result.Visited != 10 || result.Truncated {
+		t.Fatalf("unexpected unbounded result: %+v", result)
+	}
+	if result := g.BFS(chain[0], dagger.AnyType(), primitive.TraversalOptions{MaxDepth: 3}, visit); result.Visited != 4 || !result.Truncated {
+		t.Fatalf("unexpected max depth result: %+v", result)
+	}
+	if result := g.BFS(chain[0], dagger.AnyType(), primitive.TraversalOptions{MaxVisited: 5}, visit); result.Visited != 5 || !result.Truncated {
+		t.Fatalf("unexpected max visited result: %+v", result)
+	}
+	if result := g.BFS(chain[0], dagger.AnyType(), primitive.TraversalOptions{Deadline: time.Now().Add(-time.Second)}, visit); result.Visited != 0 || !result.Truncated {
+		t.Fatalf("unexpected deadline result: %+v", result)
+	}
+
+	// every traversal entry point honors the same budget
+	ctx := context.Background()
+	traversals := map[string]func(opts primitive.TraversalOptions) primitive.TraversalResult{
+		"BFSCtx": func(opts primitive.TraversalOptions) primitive.TraversalResult {
+			result, _ := g.BFSCtx(ctx, chain[0], dagger.AnyType(), opts, visit)
+			return result
+		},
+		"DFS": func(opts primitive.TraversalOptions) primitive.TraversalResult {
+			return g.DFS(chain[0], opts, visit)
+		},
+		"DFSPostOrder": func(opts primitive.TraversalOptions) primitive.TraversalResult {
+			return g.DFSPostOrder(chain[0], opts, visit)
+		},
+		"DFSCtx": func(opts primitive.TraversalOptions) primitive.TraversalResult {
+			result, _ := g.DFSCtx(ctx, chain[0], opts, visit)
+			return result
+		},
+		"DFSPostOrderCtx": func(opts primitive.TraversalOptions) primitive.TraversalResult {
+			result, _ := g.DFSPostOrderCtx(ctx, chain[0], opts, visit)
+			return result
+		},
+	}
+	for name, traverse := range traversals {
+		if result := traverse(primitive.TraversalOptions{}); result.Visited != 10 || result.Truncated {
+			t.Fatalf("%s: unexpected unbounded result: %+v", name, result)
+		}
+		if result := traverse(primitive.TraversalOptions{MaxDepth: 3}); result.Visited != 4 || !result.Truncated {
+			t.Fatalf("%s: unexpected max depth result: %+v", name, result)
+		}
+		if result := traverse(primitive.TraversalOptions{MaxVisited: 5}); result.Visited != 5 || !result.Truncated {
+			t.Fatalf("%s: unexpected max visited result: %+v", name, result)
+		}
+		if result := traverse(primitive.TraversalOptions{Deadline: time.Now().Add(-time.Second)}); result.Visited != 0 || !result.Truncated {
+			t.Fatalf("%s: unexpected deadline result: %+v", name, result)
+		}
+	}
+	paths := map[string]func(opts primitive.TraversalOptions) ([]*primitive.Edge, error){
+		"ShortestPath": func(opts primitive.TraversalOptions) ([]*primitive.Edge, error) {
+			return g.ShortestPath(chain[0], chain[9], dagger.AnyType(), opts)
+		},
+		"ShortestWeightedPath": func(opts primitive.TraversalOptions) ([]*primitive.Edge, error) {
+			path, _, err := g.ShortestWeightedPath(chain[0], chain[9], dagger.AnyType(), "", opts)
+			return path, err
+		},
+	}
+	for name, find := range paths {
+		if path, err := find(primitive.TraversalOptions{}); err != nil || len(path) != 9 {
+			t.Fatalf("%s: expected a path of 9 edges, got %v %v", name, len(path), err)
+		}
+		if path, err := find(primitive.TraversalOptions{MaxDepth: 9, MaxVisited: 10}); err != nil || len(path) != 9 {
+			t.Fatalf("%s: expected the path to fit the budget, got %v %v", name, len(path), err)
+		}
+		for _, opts := range []primitive.TraversalOptions{{MaxDepth: 8}, {MaxVisited: 9}, {Deadline: time.Now().Add(-time.Second)}} {
+			if _, err := find(opts); !errors.Is(err, primitive.ErrBudgetExhausted) {
+				t.Fatalf("%s: expected %+v to be exhausted, got %v", name, opts, err)
+			}
+		}
+	}
+	if _, err := g.ShortestPath(chain[9], chain[0], dagger.AnyType(), primitive.TraversalOptions{MaxDepth: 3}); !errors.Is(err, primitive.ErrNoPath) {
+		t.Fatalf("expected no path when the budget was not the limit, got %v", err)
+	}
+}
+
+func TestQueryBudget(t *testing.T) {
+	g := dagger.NewGraph()
+	var chain []*dagger.Node
+	for i := 0; i < 10; i++ {
+		n := g.NewNode(map[string]interface{}{"_type": "hop", "_id": fmt.Sprint(i)})
+		if i > 0 {
+			if _, err := chain[i-1].Connect(n, "next", false); err != nil {
+				t.Fatal(err)
+			}
+		}
+		chain = append(chain, n)
+	}
+	build := func(opts primitive.TraversalOptions) *dagger.QueryResult {
+		res, err := g.NewQuery().Nodes("hop").Out("next").Out("next").Budget(opts).Execute()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	if res := build(primitive.TraversalOptions{}); len(res.Nodes) != 8 || res.Truncated {
+		t.Fatalf("expected 8 unbounded results, got %v %v", len(res.Nodes), res.Truncated)
+	}
+	if res := build(primitive.TraversalOptions{MaxDepth: 1}); len(res.Nodes) != 0 || !res.Truncated {
+		t.Fatalf("expected the second hop to be cut off, got %v %v", len(res.Nodes), res.Truncated)
+	}
+	if res := build(primitive.TraversalOptions{MaxVisited: 20}); len(res.Nodes) >= 8 || !res.Truncated {
+		t.Fatalf("expected partial results, got %v %v", len(res.Nodes), res.Truncated)
+	}
+	if res := build(primitive.TraversalOptions{Deadline: time.Now().Add(-time.Second)}); len(res.Nodes) != 0 || !res.Truncated {
+		t.Fatalf("expected no results after the deadline, got %v %v", len(res.Nodes), res.Truncated)
+	}
+
+	match := func(opts primitive.TraversalOptions) *dagger.ResultSet {
+		res, err := g.Query(`MATCH (a:hop)-[:next]->(b:hop)-[:next]->(c:hop) RETURN c`, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	if res := match(primitive.TraversalOptions{}); len(res.Rows) != 8 || res.Truncated {
+		t.Fatalf("expected 8 unbounded matches, got %v %v", len(res.Rows), res.Truncated)
+	}
+	if res := match(primitive.TraversalOptions{MaxDepth: 2}); len(res.Rows) != 8 || res.Truncated {
+		t.Fatalf("expected the pattern to fit the max depth, got %v %v", len(res.Rows), res.Truncated)
+	}
+	if res := match(primitive.TraversalOptions{MaxDepth: 1}); len(res.Rows) != 0 || !res.Truncated {
+		t.Fatalf("expected the pattern to exceed the max depth, got %v %v", len(res.Rows), res.Truncated)
+	}
+	if res := match(primitive.TraversalOptions{MaxVisited: 6}); len(res.Rows) != 2 || !res.Truncated {
+		t.Fatalf("expected 2 matches within 6 visits, got %v %v", len(res.Rows), res.Truncated)
+	}
+	if res := match(primitive.TraversalOptions{Deadline: time.Now().Add(-time.Second)}); len(res.Rows) != 0 || !res.Truncated {
+		t.Fatalf("expected no matches after the deadline, got %v %v", len(res.Rows), res.Truncated)
+	}
+	if path, err := g.ShortestPath(chain[0], chain[9], "next", primitive.TraversalOptions{MaxDepth: 5}); !errors.Is(err, dagger.ErrBudgetExhausted) {
+		t.Fatalf("expected the path to be out of reach, got %v %v", len(path), err)
+	}
+}
+
+func TestRandomNeighbor(t *testing.T) {
+	g := primitive.NewGraph()
+	from := primitive.NewNode(map[string]interface{}{"_type": "server"})
+	heavy := primitive.NewNode(map[string]interface{}{"_type": "server", "name": "heavy"})
+	never := primitive.NewNode(map[string]interface{}{"_type": "server", "name": "never"})
+	g.AddNodes(from, heavy, never)
+	for to, weight := range map[*primitive.Node]float64{&heavy: 10, &never: 0} {
+		if err := g.AddEdge(&primitive.Edge{
+			Node: primitive.NewNode(map[string]interface{}{"_type": "route", "weight": weight}),
+			From: from,
+			To:   *to,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 20; i++ {
+		n, ok := g.RandomNeighbor(from, dagger.AnyType(), "weight")
+		if !ok || n.GetString("name") != "heavy" {
+			t.Fatalf("expected only the weighted neighbor to be picked, got %v", n)
+		}
+	}
+	if _, ok := g.RandomNeighbor(heavy, dagger.AnyType(), "weight"); ok {
+		t.Fatal("expected no neighbor for a node without edges")
+	}
+}
+
+func TestSampleNodes(t *testing.T) {
+	g := primitive.NewGraph()
+	for i := 0; i < 100; i++ {
+		g.AddNode(primitive.NewNode(map[string]interface{}{
+			"_type": "user",
+		}))
+	}
+	g.AddNode(primitive.NewNode(map[string]interface{}{
+		"_type": "dog",
+	}))
+	sample := g.SampleNodes(dagger.StringType("user"), 10)
+	if len(sample) != 10 {
+		t.Fatalf("expected 10 sampled nodes, got %v", len(sample))
+	}
+	seen := map[string]bool{}
+	for _, n := range sample {
+		if n.Type() != "user" || seen[n.ID()] {
+			t.Fatalf("unexpected sampled node: %v", n)
+		}
+		seen[n.ID()] = true
+	}
+	if sample := g.SampleNodes(dagger.StringType("dog"), 10); len(sample) != 1 {
+		t.Fatalf("expected 1 sampled dog, got %v", len(sample))
+	}
+}
+
+func TestSampleUniform(t *testing.T) {
+	rand.Seed(42)
+	g := primitive.NewGraph()
+	var users []primitive.Node
+	for i := 0; i < 10; i++ {
+		user := primitive.NewNode(map[string]interface{}{
+			"_type": "user",
+		})
+		g.AddNode(user)
+		users = append(users, user)
+	}
+	for i := 0; i < len(users); i++ {
+		if err := g.AddEdge(&primitive.Edge{
+			Node: primitive.NewNode(map[string]interface{}{"_type": "follows"}),
+			From: users[i],
+			To:   users[(i+1)%len(users)],
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// every element is drawn 3 in 10 times, so each of 3000 draws of 3 should pick it about 900 times
+	const trials = 3000
+	nodes, edges := map[string]int{}, map[string]int{}
+	for i := 0; i < trials; i++ {
+		for _, n := range g.SampleNodes(dagger.StringType("user"), 3) {
+			nodes[n.ID()]++
+		}
+		for _, e := range g.SampleEdges(3, false).Positives {
+			edges[e.ID()]++
+		}
+	}
+	for name, counts := range map[string]map[string]int{"node": nodes, "edge": edges} {
+		if len(counts) != 10 {
+			t.Fatalf("expected every %s to be sampled, got %v", name, len(counts))
+		}
+		for id, count := range counts {
+			if count < 800 || count > 1000 {
+				t.Fatalf("expected %s %s to be sampled about 900 times, got %v", name, id, count)
+			}
+		}
+	}
+	if sample := g.SampleNodes(dagger.StringType("user"), 100); len(sample) != len(users) {
+		t.Fatalf("expected every node when sampling more than there are, got %v", len(sample))
+	}
+	if sample := g.SampleEdges(100, false); len(sample.Positives) != len(users) {
+		t.Fatalf("expected every edge when sampling more than there are, got %v", len(sample.Positives))
+	}
+	if sample := g.SampleNodes(dagger.StringType("user"), 0); len(sample) != 0 {
+		t.Fatalf("expected no nodes when sampling 0, got %v", len(sample))
+	}
+	if sample := g.SampleEdges(0, true); len(sample.Positives) != 0 || len(sample.Negatives) != 0 {
+		t.Fatalf("expected no edges when sampling 0, got %+v", sample)
+	}
+}
+
+func TestStatsD(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	sink, err := dagger.NewStatsD(server.LocalAddr().String(), "dagger", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	g := primitive.NewGraph()
+	g.SetMetricsSink(sink)
+	g.AddNode(primitive.NewNode(map[string]interface{}{
+		"_type": "user",
+	}))
+	buf := make([]byte, 1024)
+	server.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := server.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(buf[:n]); got != "dagger.nodes.added:1|c|#type:user" {
+		t.Fatalf("unexpected metric: %s", got)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	g := primitive.NewGraph()
+	user := primitive.NewNode(map[string]interface{}{
+		"_type": "user",
+	})
+	if err := g.AddNode(user); err != nil {
+		t.Fatal(err)
+	}
+	g.SetReadOnly(true)
+	if err := g.AddNode(primitive.NewNode(map[string]interface{}{"_type": "user"})); err != primitive.ErrReadOnly {
+		t.Fatalf("expected ErrReadOnly, got %v", err)
+	}
+	if err := g.PatchNode(user, map[string]interface{}{"name": "coleman"}); err != primitive.ErrReadOnly {
+		t.Fatalf("expected ErrReadOnly, got %v", err)
+	}
+	if err := g.DelNode(user); err != primitive.ErrReadOnly {
+		t.Fatalf("expected ErrReadOnly, got %v", err)
+	}
+	g.SetReadOnly(false)
+	if err := g.DelNode(user); err != nil {
+		t.Fatal(err)
+	}
+	if g.HasNode(user) {
+		t.Fatal("expected node to be deleted once unfrozen")
+	}
+}
+
+func TestExportConsistency(t *testing.T) {
+	g := primitive.NewGraph()
+	root := primitive.NewNode(map[string]interface{}{
+		"_type": "user",
+	})
+	g.AddNode(root)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			n := primitive.NewNode(map[string]interface{}{"_type": "user"})
+			g.AddNode(n)
+			g.AddEdge(&primitive.Edge{
+				Node: primitive.NewNode(map[string]interface{}{"_type": "friend"}),
+				From: root,
+				To:   n,
+			})
+			g.DelNode(n)
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		export := g.Export()
+		nodes := map[string]bool{}
+		for _, n := range export.Nodes {
+			nodes[n.ID()] = true
+		}
+		for _, e := range export.Edges {
+			if !nodes[e.From.ID()] || !nodes[e.To.ID()] {
+				t.Fatalf("export contains dangling edge: %v", e.ID())
+			}
+		}
+	}
+	<-done
+
+	// a slow consumer of a streaming export must not block writers
+	writing := make(chan struct{})
+	written := make(chan struct{})
+	export := make(chan error, 1)
+	go func() {
+		var once sync.Once
+		export <- g.ExportNDJSON(writerFunc(func(p []byte) (int, error) {
+			once.Do(func() { close(writing) })
+			<-written
+			return len(p), nil
+		}))
+	}()
+	go func() {
+		<-writing
+		g.AddNode(primitive.NewNode(map[string]interface{}{"_type": "user"}))
+		close(written)
+	}()
+	select {
+	case err := <-export:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected writers to proceed while an export is being written")
+	}
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+func TestImportJSONParallel(t *testing.T) {
+	source := primitive.NewGraph()
+	var users []primitive.Node
+	for i := 0; i < 50; i++ {
+		user := primitive.NewNode(map[string]interface{}{"_type": "user"})
+		source.AddNode(user)
+		users = append(users, user)
+		if i > 0 {
+			if err := source.AddEdge(&primitive.Edge{
+				Node: primitive.NewNode(map[string]interface{}{"_type": "friend"}),
+				From: users[i-1],
+				To:   user,
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	export := source.Export()
+	export.Edges = append(export.Edges, &primitive.Edge{
+		Node: primitive.Node{"_type": "friend"},
+		From: primitive.Node{"_type": "user", "_id": "missing"},
+		To:   users[0],
+	})
+	buf := bytes.NewBuffer(nil)
+	if err := json.NewEncoder(buf).Encode(export); err != nil {
+		t.Fatal(err)
+	}
+	g := primitive.NewGraph()
+	err := g.ImportJSONParallel(buf, 4)
+	importErrs, ok := err.(primitive.ImportErrors)
+	if !ok || len(importErrs) != 1 || importErrs[0].Index != len(export.Edges)-1 || !importErrs[0].Edge {
+		t.Fatalf("expected a single error for the dangling edge, got %v", err)
+	}
+	health := g.Health()
+	if health.Nodes != 50 || health.Edges != 49 {
+		t.Fatalf("expected 50 nodes & 49 edges, got %v & %v", health.Nodes, health.Edges)
+	}
+}
+
+func TestLoadTest(t *testing.T) {
+	g := primitive.NewGraph()
+	target, err := loadtest.NewGraphTarget(g, "user", "friend", 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := loadtest.Run(target, loadtest.Config{
+		Workers:    4,
+		Operations: 1000,
+		Mix:        loadtest.Mix{Reads: 8, Writes: 1, Traversals: 1},
+		Seed:       1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Operations != 1000 {
+		t.Fatalf("expected 1000 operations, got %v", report.Operations)
+	}
+	for _, op := range []string{loadtest.OpRead, loadtest.OpWrite, loadtest.OpTraverse} {
+		stats, ok := report.Stats[op]
+		if !ok || stats.Count == 0 {
+			t.Fatalf("expected %s operations", op)
+		}
+		if stats.Errors != 0 {
+			t.Fatalf("expected no %s errors, got %v", op, stats.Errors)
+		}
+		if stats.P50 > stats.P99 || stats.P99 > stats.Max {
+			t.Fatalf("expected ordered %s percentiles: %v", op, report)
+		}
+	}
+	if g.Health().Nodes != 10+report.Stats[loadtest.OpWrite].Count {
+		t.Fatalf("expected every write to add a node")
+	}
+	if _, err := loadtest.Run(target, loadtest.Config{Mix: loadtest.Mix{Reads: 1}}); err == nil {
+		t.Fatal("expected an error without operations or duration")
+	}
+}
+
+func TestDeclareEdgeType(t *testing.T) {
+	g := primitive.NewGraph()
+	coleman := primitive.Node{"_type": "user", "_id": "coleman"}
+	lacee := primitive.Node{"_type": "user", "_id": "lacee"}
+	charlie := primitive.Node{"_type": "dog", "_id": "charlie"}
+	if err := g.AddNodes(coleman, lacee, charlie); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.DeclareEdgeType("knows", "friend", "spouse"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.DeclareEdgeType("spouse", "wife"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.DeclareEdgeType("wife", "knows"); err == nil {
+		t.Fatal("expected a cycle to be rejected")
+	}
+	for _, e := range []*primitive.Edge{
+		{Node: primitive.Node{"_type": "friend"}, From: coleman, To: lacee},
+		{Node: primitive.Node{"_type": "wife"}, From: coleman, To: lacee},
+		{Node: primitive.Node{"_type": "owner"}, From: coleman, To: charlie},
+	} {
+		if err := g.AddEdge(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var types []string
+	g.EdgesFrom(dagger.StringType("knows"), coleman, func(e *primitive.Edge) bool {
+		types = append(types, e.Type())
+		return true
+	})
+	sort.Strings(types)
+	if strings.Join(types, ",") != "friend,wife" {
+		t.Fatalf("expected friend & wife edges, got %v", types)
+	}
+	count := 0
+	g.RangeEdgeTypes(dagger.StringType("spouse"), func(e *primitive.Edge) bool {
+		count++
+		return true
+	})
+	if count != 1 {
+		t.Fatalf("expected 1 spouse edge, got %v", count)
+	}
+	if !g.IsEdgeType("wife", "knows") || g.IsEdgeType("owner", "knows") {
+		t.Fatal("unexpected edge type hierarchy")
+	}
+}
+
+func TestDeclareNodeType(t *testing.T) {
+	g := primitive.NewGraph()
+	if err := g.AddNodes(
+		primitive.Node{"_type": "user", "_id": "coleman"},
+		primitive.Node{"_type": "employee", "_id": "lacee"},
+		primitive.Node{"_type": "dog", "_id": "charlie"},
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.DeclareNodeType("person", "user", "employee"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.DeclareNodeType("user", "person"); err == nil {
+		t.Fatal("expected a cycle to be rejected")
+	}
+	var ids []string
+	g.RangeNodeTypes(dagger.StringType("person"), func(n primitive.Node) bool {
+		ids = append(ids, n.ID())
+		return true
+	})
+	sort.Strings(ids)
+	if strings.Join(ids, ",") != "coleman,lacee" {
+		t.Fatalf("expected coleman & lacee, got %v", ids)
+	}
+	patched, err := g.PatchWhere(dagger.StringType("person"), func(n primitive.Node) bool {
+		return true
+	}, map[string]interface{}{"human": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patched != 2 {
+		t.Fatalf("expected 2 patched persons, got %v", patched)
+	}
+	if !g.IsNodeType("employee", "person") || g.IsNodeType("dog", "person") {
+		t.Fatal("unexpected node type hierarchy")
+	}
+}
+
+func TestDirtyFields(t *testing.T) {
+	g := primitive.NewGraph()
+	coleman := primitive.Node{"_type": "user", "_id": "coleman", "name": "coleman", "age": 30}
+	if err := g.AddNode(coleman); err != nil {
+		t.Fatal(err)
+	}
+	if fields := g.DirtyFields(coleman); strings.Join(fields, ",") != "_id,_type,age,name" {
+		t.Fatalf("expected every field of a new node to be dirty, got %v", fields)
+	}
+	g.ClearDirty()
+	if err := g.PatchNode(coleman, map[string]interface{}{"age": 31, "name": "coleman"}); err != nil {
+		t.Fatal(err)
+	}
+	if fields := g.DirtyFields(coleman); strings.Join(fields, ",") != "age" {
+		t.Fatalf("expected only age to be dirty, got %v", fields)
+	}
+	if err := g.AddNode(primitive.Node{"_type": "user", "_id": "coleman", "age": 31}); err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	g.RangeDirty(func(n primitive.Node, fields []string) bool {
+		count++
+		if strings.Join(fields, ",") != "age,name" {
+			t.Fatalf("expected age & deleted name to be dirty, got %v", fields)
+		}
+		return true
+	})
+	if count != 1 {
+		t.Fatalf("expected 1 dirty node, got %v", count)
+	}
+	g.ClearDirty(coleman)
+	if fields := g.DirtyFields(coleman); len(fields) != 0 {
+		t.Fatalf("expected no dirty fields, got %v", fields)
+	}
+}
+
+func TestHash(t *testing.T) {
+	build := func(reverse bool) *primitive.Graph {
+		g := primitive.NewGraph()
+		nodes := []primitive.Node{
+			{"_type": "user", "_id": "coleman", "age": 30},
+			{"_type": "user", "_id": "lacee", "age": 28.0},
+			{"_type": "dog", "_id": "charlie"},
+		}
+		if reverse {
+			nodes[0], nodes[2] = nodes[2], nodes[0]
+		}
+		for _, n := range nodes {
+			if err := g.AddNode(n); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := g.AddEdge(&primitive.Edge{
+			Node: primitive.Node{"_type": "pet", "_id": "1"},
+			From: primitive.Node{"_type": "user", "_id": "coleman"},
+			To:   primitive.Node{"_type": "dog", "_id": "charlie"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return g
+	}
+	a, b := build(false), build(true)
+	if a.Hash() != b.Hash() {
+		t.Fatal("expected graphs with the same contents to have the same hash")
+	}
+	if err := b.PatchNode(primitive.Node{"_type": "dog", "_id": "charlie"}, map[string]interface{}{"weight": 25}); err != nil {
+		t.Fatal(err)
+	}
+	if a.Hash() == b.Hash() {
+		t.Fatal("expected graphs with different contents to have different hashes")
+	}
+	users := primitive.Filter{NodeTypes: []string{"user"}, EdgeTypes: []string{"none"}}
+	if a.HashFilter(users) != b.HashFilter(users) {
+		t.Fatal("expected unchanged user subgraphs to have the same hash")
+	}
+}
+
+func TestSyncFrom(t *testing.T) {
+	source := primitive.NewGraph()
+	for i := 0; i < 100; i++ {
+		if err := source.AddNode(primitive.Node{"_type": "user", "_id": fmt.Sprint(i), "rank": i}); err != nil {
+			t.Fatal(err)
+		}
+		if i > 0 {
+			if err := source.AddEdge(&primitive.Edge{
+				Node: primitive.Node{"_type": "friend", "_id": fmt.Sprint(i)},
+				From: primitive.Node{"_type": "user", "_id": fmt.Sprint(i - 1)},
+				To:   primitive.Node{"_type": "user", "_id": fmt.Sprint(i)},
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	replica := primitive.NewGraph()
+	result, err := replica.SyncFrom(primitive.LocalPeer(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Nodes != 100 || result.Edges != 99 || replica.Hash() != source.Hash() {
+		t.Fatalf("expected a full sync, got %+v", result)
+	}
+	if err := source.PatchNode(primitive.Node{"_type": "user", "_id": "42"}, map[string]interface{}{"rank": 0}); err != nil {
+		t.Fatal(err)
+	}
+	if err := replica.AddNode(primitive.Node{"_type": "user", "_id": "stray"}); err != nil {
+		t.Fatal(err)
+	}
+	result, err = replica.SyncFrom(primitive.LocalPeer(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Buckets) > 2 || result.Nodes >= 10 {
+		t.Fatalf("expected only the differing buckets to be transferred, got %+v", result)
+	}
+	if replica.Hash() != source.Hash() {
+		t.Fatal("expected the replica to converge to the source")
+	}
+	server := httptest.NewServer(dagger.SyncHandler())
+	defer server.Close()
+	remote := primitive.NewGraph()
+	if _, err := remote.SyncFrom(dagger.NewHTTPPeer(server.URL, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if remote.Hash() != dagger.Hash() {
+		t.Fatal("expected the remote to converge to the global graph")
+	}
+}
+
+func TestGCUnreachable(t *testing.T) {
+	g := primitive.NewGraph()
+	root := primitive.Node{"_type": "user", "_id": "root"}
+	for _, id := range []string{"root", "a", "b", "c", "debris"} {
+		if err := g.AddNode(primitive.Node{"_type": "user", "_id": id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range [][3]string{{"friend", "root", "a"}, {"friend", "a", "b"}, {"owner", "root", "c"}, {"friend", "debris", "a"}} {
+		if err := g.AddEdge(&primitive.Edge{
+			Node: primitive.Node{"_type": e[0]},
+			From: primitive.Node{"_type": "user", "_id": e[1]},
+			To:   primitive.Node{"_type": "user", "_id": e[2]},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ids := func(nodes []primitive.Node) string {
+		var ids []string
+		for _, n := range nodes {
+			ids = append(ids, n.ID())
+		}
+		sort.Strings(ids)
+		return strings.Join(ids, ",")
+	}
+	report, err := g.GCUnreachable([]primitive.TypedID{root}, primitive.GCEdgeTypes("friend"), primitive.GCDryRun())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids(report) != "c,debris" {
+		t.Fatalf("expected c & debris to be unreachable over friend edges, got %v", ids(report))
+	}
+	if g.Health().Nodes != 5 {
+		t.Fatal("expected a dry run to leave the graph untouched")
+	}
+	removed, err := g.GCUnreachable([]primitive.TypedID{root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids(removed) != "debris" {
+		t.Fatalf("expected debris to be removed, got %v", ids(removed))
+	}
+	if health := g.Health(); health.Nodes != 4 || health.Edges != 3 {
+		t.Fatalf("expected 4 nodes & 3 edges, got %v & %v", health.Nodes, health.Edges)
+	}
+}
+
+func TestNodeBFS(t *testing.T) {
+	var users []*dagger.Node
+	for i := 0; i < 4; i++ {
+		users = append(users, dagger.NewNode(map[string]interface{}{"_type": "user", "name": fmt.Sprint(i)}))
+	}
+	defer func() {
+		for _, u := range users {
+			u.Remove()
+		}
+	}()
+	for i := 1; i < len(users); i++ {
+		if _, err := users[i-1].Connect(users[i], "friend", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var names []string
+	users[0].BFS(2, func(n *dagger.Node) bool {
+		names = append(names, n.GetString("name"))
+		return true
+	})
+	if strings.Join(names, ",") != "1,2" {
+		t.Fatalf("expected friends & friends of friends, got %v", names)
+	}
+	names = nil
+	users[0].BFS(0, func(n *dagger.Node) bool {
+		names = append(names, n.GetString("name"))
+		return false
+	})
+	if len(names) != 1 {
+		t.Fatalf("expected the walk to stop early, got %v", names)
+	}
+}
+
+func TestDFS(t *testing.T) {
+	g := primitive.NewGraph()
+	for _, id := range []string{"a", "b", "c", "d"} {
+		if err := g.AddNode(primitive.Node{"_type": "user", "_id": id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range [][2]string{{"a", "c"}, {"a", "b"}, {"b", "d"}, {"d", "a"}} {
+		if err := g.AddEdge(&primitive.Edge{
+			Node: primitive.Node{"_type": "friend"},
+			From: primitive.Node{"_type": "user", "_id": e[0]},
+			To:   primitive.Node{"_type": "user", "_id": e[1]},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := primitive.Node{"_type": "user", "_id": "a"}
+	var pre, post []string
+	g.DFS(start, primitive.TraversalOptions{}, func(n primitive.Node, depth int) bool {
+		pre = append(pre, fmt.Sprintf("%s%d", n.ID(), depth))
+		return true
+	})
+	g.DFSPostOrder(start, primitive.TraversalOptions{}, func(n primitive.Node, depth int) bool {
+		post = append(post, n.ID())
+		return true
+	})
+	if strings.Join(pre, ",") != "a0,b1,d2,c1" {
+		t.Fatalf("unexpected pre-order: %v", pre)
+	}
+	if strings.Join(post, ",") != "d,b,c,a" {
+		t.Fatalf("unexpected post-order: %v", post)
+	}
+	visited := 0
+	g.DFS(start, primitive.TraversalOptions{}, func(n primitive.Node, depth int) bool {
+		visited++
+		return visited < 2
+	})
+	if visited != 2 {
+		t.Fatalf("expected the traversal to stop early, visited %v", visited)
+	}
+}
+
+func TestShortestPath(t *testing.T) {
+	var users []*dagger.Node
+	for i := 0; i < 4; i++ {
+		users = append(users, dagger.NewNode(map[string]interface{}{"_type": "user", "name": fmt.Sprint(i)}))
+	}
+	defer func() {
+		for _, u := range users {
+			u.Remove()
+		}
+	}()
+	connect := func(from, to int, weight float64) {
+		e, err := users[from].Connect(users[to], "friend", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := e.Patch(map[string]interface{}{"weight": weight}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	connect(0, 1, 1)
+	connect(1, 2, 1)
+	connect(2, 3, 1)
+	connect(0, 3, 10)
+	path, err := dagger.ShortestPath(users[0], users[3], "friend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(path) != 1 {
+		t.Fatalf("expected a single hop, got %v", len(path))
+	}
+	path, weight, err := dagger.ShortestWeightedPath(users[0], users[3], "friend", "weight")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(path) != 3 || weight != 3 {
+		t.Fatalf("expected 3 hops weighing 3, got %v hops weighing %v", len(path), weight)
+	}
+	if _, err := dagger.ShortestPath(users[3], users[0], "friend"); err != dagger.ErrNoPath {
+		t.Fatalf("expected no path, got %v", err)
+	}
+}
+
+func TestNewGraph(t *testing.T) {
+	tenant := dagger.NewGraph()
+	other := dagger.NewGraph(dagger.WithReadOnly())
+	coleman := tenant.NewNode(map[string]interface{}{"_type": "user", "name": "coleman"})
+	lacee := tenant.NewNode(map[string]interface{}{"_type": "user", "name": "lacee"})
+	if _, err := coleman.Connect(lacee, "friend", false); err != nil {
+		t.Fatal(err)
+	}
+	if tenant.NodeCount() != 2 || tenant.EdgeCount() != 1 {
+		t.Fatalf("expected 2 nodes & 1 edge, got %v & %v", tenant.NodeCount(), tenant.EdgeCount())
+	}
+	if dagger.HasNode(coleman) || other.HasNode(coleman) {
+		t.Fatal("expected graphs to be independent")
+	}
+	coleman.EdgesFrom(dagger.AnyType(), func(e *dagger.Edge) bool {
+		if e.To().GetString("name") != "lacee" {
+			t.Fatalf("expected edge to lacee, got %v", e.To().GetString("name"))
+		}
+		return true
+	})
+	if err := other.Primitive().AddNode(primitive.Node{"_type": "user", "_id": "coleman"}); err != dagger.ErrReadOnly {
+		t.Fatalf("expected read-only graph, got %v", err)
+	}
+}
+
+func TestDiskStorage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "graph.db")
+	storage, err := primitive.OpenDiskStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := dagger.NewGraph(dagger.WithStorage(storage))
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "name": "coleman", "age": 30})
+	charlie := g.NewNode(map[string]interface{}{"_type": "dog", "name": "charlie"})
+	if _, err := coleman.Connect(charlie, "pet", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := coleman.Patch(map[string]interface{}{"age": 31}); err != nil {
+		t.Fatal(err)
+	}
+	if err := coleman.Del("name"); err != nil {
+		t.Fatal(err)
+	}
+	stray := g.NewNode(map[string]interface{}{"_type": "user", "name": "stray"})
+	if err := stray.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	hash := g.Hash()
+	g.Close()
+	if err := storage.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// simulate a torn write at the end of the file
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"ns":"nodes/user","key":"torn"`)
+	f.Close()
+
+	storage, err = primitive.OpenDiskStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	// options passed before WithStorage must not be lost
+	reopened := dagger.NewGraph(dagger.UniqueEdges(false), dagger.WithStorage(storage))
+	defer reopened.Close()
+	if reopened.Hash() != hash {
+		t.Fatal("expected the reopened graph to match the closed graph")
+	}
+	if reopened.NodeCount() != 2 || reopened.EdgeCount() != 1 {
+		t.Fatalf("expected 2 nodes & 1 edge, got %v & %v", reopened.NodeCount(), reopened.EdgeCount())
+	}
+	n, ok := reopened.GetNode(coleman)
+	if !ok || n.GetInt("age") != 31 || n.Get("name") != nil {
+		t.Fatalf("expected patched node, got %v", n)
+	}
+	pets := n.FilterEdgesFrom(dagger.StringType("pet"), func(e *dagger.Edge) bool {
+		return true
+	})
+	if len(pets) != 1 || pets[0].To().GetString("name") != "charlie" {
+		t.Fatal("expected coleman's pet edge to survive a restart")
+	}
+	if _, err := n.Connect(pets[0].To(), "friend", false); !errors.Is(err, dagger.ErrDuplicateEdge) {
+		t.Fatalf("expected a duplicate edge error, got %v", err)
+	}
+}
+
+func TestCachedStorage(t *testing.T) {
+	for _, policy := range []primitive.WritePolicy{primitive.WriteThrough, primitive.WriteBehind} {
+		path := filepath.Join(t.TempDir(), "graph.db")
+		disk, err := primitive.OpenDiskStorage(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cached := primitive.NewCachedStorage(disk, 4, policy)
+		g := dagger.NewGraph(dagger.WithStorage(cached))
+		var users []*dagger.Node
+		for i := 0; i < 10; i++ {
+			users = append(users, g.NewNode(map[string]interface{}{"_type": "user", "_id": fmt.Sprint(i), "age": i}))
+		}
+		for i := 1; i < len(users); i++ {
+			if _, err := users[0].Connect(users[i], "friend", false); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := users[9].Patch(map[string]interface{}{"age": 99}); err != nil {
+			t.Fatal(err)
+		}
+		if err := users[8].Remove(); err != nil {
+			t.Fatal(err)
+		}
+		if stats := cached.Stats(); stats.Len > 4 || stats.Evictions == 0 {
+			t.Fatalf("expected the cache to stay within its capacity, got %+v", stats)
+		}
+		before := cached.Stats()
+		for i := 0; i < 3; i++ {
+			if users[9].GetInt("age") != 99 {
+				t.Fatal("expected the patched age")
+			}
+		}
+		if stats := cached.Stats(); stats.Hits <= before.Hits {
+			t.Fatalf("expected repeated reads to hit the cache, got %+v", stats)
+		}
+		_, written := disk.Get("nodes/user", "9")
+		if policy == primitive.WriteThrough && !written {
+			t.Fatal("expected writes to reach the disk before returning")
+		}
+		if policy == primitive.WriteBehind && cached.Stats().Dirty == 0 {
+			t.Fatal("expected recent writes to wait in the cache")
+		}
+		hash := g.Hash()
+		g.Close()
+		if err := disk.Err(); err != nil {
+			t.Fatal(err)
+		}
+		disk, err = primitive.OpenDiskStorage(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reopened := dagger.NewGraph(dagger.WithStorage(disk))
+		if reopened.Hash() != hash || reopened.NodeCount() != 9 || reopened.EdgeCount() != 8 {
+			t.Fatalf("expected the closed graph to be written to disk, got %v nodes & %v edges", reopened.NodeCount(), reopened.EdgeCount())
+		}
+		if n, ok := reopened.GetNode(users[9]); !ok || n.GetInt("age") != 99 {
+			t.Fatal("expected the patched node on disk")
+		}
+		reopened.Close()
+	}
+}
+
+func TestRecover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "graph.wal")
+	g := dagger.NewGraph()
+	if err := g.Recover(path); err != nil {
+		t.Fatal(err)
+	}
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "name": "coleman"})
+	lacee := g.NewNode(map[string]interface{}{"_type": "user", "name": "lacee"})
+	stray := g.NewNode(map[string]interface{}{"_type": "user", "name": "stray"})
+	if _, err := coleman.Connect(lacee, "fiance", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := coleman.Connect(stray, "friend", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := coleman.Patch(map[string]interface{}{"age": 30}); err != nil {
+		t.Fatal(err)
+	}
+	if err := stray.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	hash := g.Hash()
+	// crash without closing the graph
+	recovered := dagger.NewGraph()
+	if err := recovered.Recover(path); err != nil {
+		t.Fatal(err)
+	}
+	defer recovered.Close()
+	if recovered.Hash() != hash {
+		t.Fatal("expected the recovered graph to match the crashed graph")
+	}
+	if n, ok := recovered.GetNode(coleman); !ok || n.GetInt("age") != 30 {
+		t.Fatal("expected patches to be recovered")
+	}
+}
+
+func TestRecoverTornWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "graph.wal")
+	g := dagger.NewGraph()
+	if err := g.Recover(path); err != nil {
+		t.Fatal(err)
+	}
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "name": "coleman"})
+	g.Close()
+	// crash partway through writing an entry
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"op":"set_node","no`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	restarted := dagger.NewGraph()
+	if err := restarted.Recover(path); err != nil {
+		t.Fatal(err)
+	}
+	lacee := restarted.NewNode(map[string]interface{}{"_type": "user", "name": "lacee"})
+	restarted.Close()
+	recovered := dagger.NewGraph()
+	if err := recovered.Recover(path); err != nil {
+		t.Fatal(err)
+	}
+	defer recovered.Close()
+	if !recovered.HasNode(coleman) || !recovered.HasNode(lacee) {
+		t.Fatal("expected entries written after a torn write to be recovered")
+	}
+}
+
+func TestWALFailure(t *testing.T) {
+	dir := t.TempDir()
+	wal, err := primitive.OpenWAL(filepath.Join(dir, "graph.wal"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := primitive.NewGraph()
+	p.SetWAL(wal)
+	g := dagger.NewGraph(dagger.WithPrimitive(p))
+	coleman, err := g.AddNode(map[string]interface{}{"_type": "user", "_id": "coleman"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// writes to the closed log file fail
+	wal.Close()
+	if err := coleman.Patch(map[string]interface{}{"age": 30}); !errors.Is(err, dagger.ErrWALFailed) {
+		t.Fatalf("expected the patch that could not be logged to fail, got %v", err)
+	}
+	if !p.ReadOnly() {
+		t.Fatal("expected the graph to become read only")
+	}
+	if _, err := g.AddNode(map[string]interface{}{"_type": "user", "_id": "tyler"}); !errors.Is(err, primitive.ErrReadOnly) {
+		t.Fatalf("expected later changes to be rejected, got %v", err)
+	}
+	if health := g.Health(); health.Status != primitive.HealthFailing {
+		t.Fatalf("expected the graph to be failing, got %+v", health)
+	}
+	// a new log brings the graph back
+	replacement, err := primitive.OpenWAL(filepath.Join(dir, "replacement.wal"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replacement.Close()
+	p.SetWAL(replacement)
+	p.SetReadOnly(false)
+	if _, err := g.AddNode(map[string]interface{}{"_type": "user", "_id": "tyler"}); err != nil {
+		t.Fatal(err)
+	}
+	if health := g.Health(); !health.Healthy() || health.WAL.Entries != 1 || health.WAL.Unsynced != 0 {
+		t.Fatalf("expected the graph to be healthy again, got %+v", health)
+	}
+}
+
+func TestCheckpointWAL(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "graph.wal")
+	g := dagger.NewGraph()
+	if err := g.CheckpointWAL(); !errors.Is(err, dagger.ErrNoWAL) {
+		t.Fatalf("expected ErrNoWAL, got %v", err)
+	}
+	if err := g.Recover(path); err != nil {
+		t.Fatal(err)
+	}
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman"})
+	tyler := g.NewNode(map[string]interface{}{"_type": "user", "_id": "tyler"})
+	if _, err := coleman.Connect(tyler, "friend", false); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if err := coleman.Patch(map[string]interface{}{"visits": i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before := time.Now()
+	if health := g.Health(); health.WAL.Entries != 13 {
+		t.Fatalf("expected 13 entries before the checkpoint, got %+v", health.WAL)
+	}
+	if _, err := g.WriteSnapshot(dir, 1); err != nil {
+		t.Fatal(err)
+	}
+	// the checkpoint & one entry per node & edge
+	if health := g.Health(); health.WAL.Entries != 4 {
+		t.Fatalf("expected the snapshot to compact the log to 4 entries, got %+v", health.WAL)
+	}
+	lacee := g.NewNode(map[string]interface{}{"_type": "user", "_id": "lacee"})
+	if _, err := tyler.Connect(lacee, "friend", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := coleman.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	hash := g.Hash()
+	// the compacted log alone recovers the graph
+	recovered := dagger.NewGraph()
+	if err := recovered.Recover(path); err != nil {
+		t.Fatal(err)
+	}
+	defer recovered.Close()
+	if recovered.Hash() != hash || recovered.NodeCount() != 2 || recovered.EdgeCount() != 1 {
+		t.Fatalf("expected the recovered graph to match, got %v nodes & %v edges", recovered.NodeCount(), recovered.EdgeCount())
+	}
+	if _, err := g.AsOf(before); err == nil {
+		t.Fatal("expected the graph not to be reconstructed from before the checkpoint")
+	}
+	if past, err := g.AsOf(time.Now()); err != nil || past.NodeCount() != 2 {
+		t.Fatalf("expected the graph to be reconstructed after the checkpoint, got %v", err)
+	}
+}
+
+func TestNDJSON(t *testing.T) {
+	source := dagger.NewGraph()
+	coleman := source.NewNode(map[string]interface{}{"_type": "user", "name": "coleman"})
+	charlie := source.NewNode(map[string]interface{}{"_type": "dog", "name": "charlie"})
+	if _, err := coleman.Connect(charlie, "pet", false); err != nil {
+		t.Fatal(err)
+	}
+	buf := bytes.NewBuffer(nil)
+	if err := source.ExportNDJSON(buf); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 3 {
+		t.Fatalf("expected 3 lines, got %v", lines)
+	}
+	dest := dagger.NewGraph()
+	if err := dest.ImportNDJSON(buf); err != nil {
+		t.Fatal(err)
+	}
+	if dest.Hash() != source.Hash() {
+		t.Fatal("expected the imported graph to match the exported graph")
+	}
+	if err := dest.ImportNDJSON(strings.NewReader(`{"edge":{"node":{"_type":"pet","_id":"x"},"from":{"_type":"user","_id":"missing"},"to":{"_type":"dog","_id":"missing"}}}`)); err == nil {
+		t.Fatal("expected an error importing an edge between missing nodes")
+	}
+}
+
+func TestExportDOT(t *testing.T) {
+	g := dagger.NewGraph()
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman", "name": `coleman "cole"`})
+	charlie := g.NewNode(map[string]interface{}{"_type": "dog", "_id": "charlie"})
+	if _, err := coleman.Connect(charlie, "pet", false); err != nil {
+		t.Fatal(err)
+	}
+	buf := bytes.NewBuffer(nil)
+	if err := g.ExportDOT(buf, dagger.DOTRankDir("LR"), dagger.DOTClusterByType()); err != nil {
+		t.Fatal(err)
+	}
+	dot := buf.String()
+	for _, want := range []string{
+		"digraph dagger {",
+		"rankdir=LR;",
+		`label="dog";`,
+		`"user.coleman" [label="coleman \"cole\""];`,
+		`"dog.charlie" [label="dog.charlie"];`,
+		`"user.coleman" -> "dog.charlie" [label="pet"];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Fatalf("expected %s in:\n%s", want, dot)
+		}
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	source := dagger.NewGraph()
+	coleman := source.NewNode(map[string]interface{}{"_type": "user", "name": "coleman", "age": 30, "admin": true})
+	charlie := source.NewNode(map[string]interface{}{"_type": "dog", "name": "charlie", "weight": 25.5})
+	if _, err := coleman.Connect(charlie, "pet", false); err != nil {
+		t.Fatal(err)
+	}
+	for name, codec := range map[string]encoding.Codec{
+		"graphml":  encoding.GraphML{},
+		"gexf":     encoding.GEXF{},
+		"protobuf": encoding.Protobuf{},
+		"json":     encoding.JSON{},
+		"gob":      encoding.Gob{},
+		"msgpack":  encoding.MsgPack{},
+		"ntriples": encoding.NTriples{},
+	} {
+		buf := bytes.NewBuffer(nil)
+		if err := source.Encode(buf, codec); err != nil {
+			t.Fatal(err)
+		}
+		dest := dagger.NewGraph()
+		if err := dest.Decode(buf, codec); err != nil {
+			t.Fatal(err)
+		}
+		if dest.Hash() != source.Hash() {
+			t.Fatalf("%s: expected the decoded graph to match the encoded graph", name)
+		}
+	}
+	gexf := `<gexf xmlns="http://gexf.net/1.3" version="1.3"><graph defaultedgetype="directed">
+<nodes><node id="a" label="Alice"/><node id="b" label="Bob"/></nodes>
+<edges><edge id="0" source="a" target="b" label="knows"/></edges>
+</graph></gexf>`
+	exp, err := encoding.GEXF{}.Decode(strings.NewReader(gexf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exp.Nodes) != 2 || exp.Nodes[0].GetString("name") != "Alice" || exp.Edges[0].Type() != "knows" {
+		t.Fatalf("unexpected import of a foreign document: %v", exp)
+	}
+}
+
+func TestHooks(t *testing.T) {
+	g := dagger.NewGraph()
+	var added, patched, deleted, edgesAdded, edgesDeleted []string
+	g.OnNodeAdded(func(n *dagger.Node) {
+		added = append(added, n.ID())
+	})
+	g.OnNodePatched(func(n *dagger.Node) {
+		patched = append(patched, n.GetString("name"))
+	})
+	g.OnNodeDeleted(func(n primitive.Node) {
+		deleted = append(deleted, n.GetString("name"))
+	})
+	g.OnEdgeAdded(func(e *dagger.Edge) {
+		edgesAdded = append(edgesAdded, e.Type())
+		// hooks run after the graph is unlocked so they may mutate it
+		if err := e.Patch(map[string]interface{}{"seen": true}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	g.OnEdgeDeleted(func(e *primitive.Edge) {
+		edgesDeleted = append(edgesDeleted, e.From.ID())
+	})
+	async := make(chan string, 1)
+	g.OnNodeAdded(func(n *dagger.Node) {
+		select {
+		case async <- n.ID():
+		default:
+		}
+	}, dagger.HookAsync())
+
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman", "name": "coleman"})
+	charlie := g.NewNode(map[string]interface{}{"_type": "dog", "_id": "charlie", "name": "charlie"})
+	edge, err := coleman.Connect(charlie, "pet", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := coleman.Patch(map[string]interface{}{"name": "colemanword"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := coleman.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(added, ",") != "coleman,charlie" {
+		t.Fatalf("unexpected added nodes: %v", added)
+	}
+	if strings.Join(patched, ",") != "colemanword" {
+		t.Fatalf("unexpected patched nodes: %v", patched)
+	}
+	if strings.Join(deleted, ",") != "colemanword" {
+		t.Fatalf("unexpected deleted nodes: %v", deleted)
+	}
+	if strings.Join(edgesAdded, ",") != "pet" || strings.Join(edgesDeleted, ",") != "coleman" {
+		t.Fatalf("unexpected edge hooks: %v %v", edgesAdded, edgesDeleted)
+	}
+	if _, ok := g.GetEdge(edge); ok {
+		t.Fatal("expected the edge to be deleted with its node")
+	}
+	select {
+	case <-async:
+	case <-time.After(time.Second):
+		t.Fatal("expected the async hook to run")
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	g := dagger.NewGraph()
+	ctx, cancel := context.WithCancel(context.Background())
+	changes, err := g.Subscribe(ctx, primitive.ChangeFilter{Types: []string{"user", "pet"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman"})
+	charlie := g.NewNode(map[string]interface{}{"_type": "dog", "_id": "charlie"})
+	edge, err := coleman.Connect(charlie, "pet", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := edge.Patch(map[string]interface{}{"since": 2019}); err != nil {
+		t.Fatal(err)
+	}
+	if err := charlie.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if err := coleman.Patch(map[string]interface{}{"name": "coleman"}); err != nil {
+		t.Fatal(err)
+	}
+	var ops []string
+	for i := 0; i < 5; i++ {
+		c := <-changes
+		ops = append(ops, string(c.Op))
+	}
+	if strings.Join(ops, ",") != "node_added,edge_added,edge_patched,edge_deleted,node_patched" {
+		t.Fatalf("unexpected changes: %v", ops)
+	}
+	cancel()
+	for range changes {
+	}
+	if _, err := g.Subscribe(ctx, primitive.ChangeFilter{}); err == nil {
+		t.Fatal("expected an error subscribing with a done context")
+	}
+}
+
+func TestBulkLoad(t *testing.T) {
+	g := dagger.NewGraph()
+	var nodes []primitive.Node
+	var edges []*primitive.Edge
+	for i := 0; i < 100; i++ {
+		nodes = append(nodes, primitive.NewNode(map[string]interface{}{"_type": "user", "_id": fmt.Sprint(i)}))
+	}
+	for i := 1; i < 100; i++ {
+		edges = append(edges, &primitive.Edge{
+			Node: primitive.NewNode(map[string]interface{}{"_type": "friend"}),
+			From: nodes[0],
+			To:   nodes[i],
+		})
+	}
+	if err := g.BulkLoad(nodes, edges); err != nil {
+		t.Fatal(err)
+	}
+	root, ok := g.GetNode(&dagger.ForeignKey{XType: "user", XID: "0"})
+	if !ok {
+		t.Fatal("expected the bulk loaded node to exist")
+	}
+	if got := len(root.FilterEdgesFrom(dagger.StringType("friend"), func(e *dagger.Edge) bool { return true })); got != 99 {
+		t.Fatalf("expected 99 edges from the root, got %d", got)
+	}
+	last, _ := g.GetNode(&dagger.ForeignKey{XType: "user", XID: "99"})
+	if got := len(last.FilterEdgesTo(dagger.StringType("friend"), func(e *dagger.Edge) bool { return true })); got != 1 {
+		t.Fatalf("expected 1 edge to the last node, got %d", got)
+	}
+	missing := &primitive.Edge{
+		Node: primitive.NewNode(map[string]interface{}{"_type": "friend"}),
+		From: nodes[0],
+		To:   primitive.NewNode(map[string]interface{}{"_type": "user", "_id": "missing"}),
+	}
+	if err := g.BulkLoad(nil, []*primitive.Edge{missing}); err == nil {
+		t.Fatal("expected an error loading an edge to a missing node")
+	}
+	if g.HasEdge(missing) {
+		t.Fatal("expected the invalid edge not to be added")
+	}
+}
+
+func TestIndex(t *testing.T) {
+	g := dagger.NewGraph()
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman", "email": "coleman@example.com", "age": 30})
+	g.NewNode(map[string]interface{}{"_type": "user", "_id": "tyler", "email": "tyler@example.com", "age": 30})
+	if _, err := g.FindByIndex("user", "email", "coleman@example.com"); err != dagger.ErrNoIndex {
+		t.Fatalf("expected ErrNoIndex, got %v", err)
+	}
+	if err := g.CreateIndex("user", "email"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.CreateIndex("user", "age"); err != nil {
+		t.Fatal(err)
+	}
+	found, err := g.FindByIndex("user", "email", "coleman@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].ID() != "coleman" {
+		t.Fatalf("unexpected index lookup: %v", found)
+	}
+	if found, _ := g.FindByIndex("user", "age", 30.0); len(found) != 2 {
+		t.Fatalf("expected 2 users aged 30, got %d", len(found))
+	}
+	if err := coleman.Patch(map[string]interface{}{"email": "coleman@dagger.dev"}); err != nil {
+		t.Fatal(err)
+	}
+	if found, _ := g.FindByIndex("user", "email", "coleman@example.com"); len(found) != 0 {
+		t.Fatal("expected the old value to be removed from the index")
+	}
+	if found, _ := g.FindByIndex("user", "email", "coleman@dagger.dev"); len(found) != 1 {
+		t.Fatal("expected the patched value to be indexed")
+	}
+	if err := coleman.Del("age"); err != nil {
+		t.Fatal(err)
+	}
+	g.NewNode(map[string]interface{}{"_type": "user", "_id": "lacee", "age": 30})
+	if found, _ := g.FindByIndex("user", "age", 30); len(found) != 2 || found[0].ID() != "lacee" {
+		t.Fatalf("unexpected index lookup after delete & add: %v", found)
+	}
+	if err := coleman.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if found, _ := g.FindByIndex("user", "email", "coleman@dagger.dev"); len(found) != 0 {
+		t.Fatal("expected the removed node to be removed from the index")
+	}
+}
+
+func TestQueryBuilder(t *testing.T) {
+	g := dagger.NewGraph()
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman", "age": 30})
+	tyler := g.NewNode(map[string]interface{}{"_type": "user", "_id": "tyler", "age": 18})
+	lacee := g.NewNode(map[string]interface{}{"_type": "user", "_id": "lacee", "age": 28})
+	charlie := g.NewNode(map[string]interface{}{"_type": "dog", "_id": "charlie", "age": 3})
+	for _, to := range []*dagger.Node{tyler, lacee} {
+		if _, err := coleman.Connect(to, "friend", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := tyler.Connect(lacee, "friend", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := coleman.Connect(charlie, "pet", false); err != nil {
+		t.Fatal(err)
+	}
+	res, err := g.NewQuery().Nodes("user").Where("age", dagger.Gt, 21).OutE("friend").InV().Dedup().Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, n := range res.Nodes {
+		ids = append(ids, n.ID())
+	}
+	sort.Strings(ids)
+	if strings.Join(ids, ",") != "lacee,tyler" {
+		t.Fatalf("unexpected friends of users over 21: %v", ids)
+	}
+	res, err = g.NewQuery().Nodes("user").Out("friend").Where("age", dagger.Gte, 28.0).Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Nodes) != 2 {
+		t.Fatalf("expected lacee twice, got %d nodes", len(res.Nodes))
+	}
+	res, err = g.NewQuery().Nodes("dog").In("pet").Limit(1).Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Nodes) != 1 || res.Nodes[0].ID() != "coleman" {
+		t.Fatalf("unexpected pet owners: %v", res.Nodes)
+	}
+	res, err = g.NewQuery().Edges("friend").Where("_type", dagger.Eq, "friend").Limit(2).Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Edges) != 2 {
+		t.Fatalf("expected 2 edges, got %d", len(res.Edges))
+	}
+	if _, err := g.NewQuery().Nodes("user").InV().Execute(); err == nil {
+		t.Fatal("expected an error moving to a vertex from nodes")
+	}
+}
+
+func TestTraversal(t *testing.T) {
+	g := primitive.NewGraph()
+	for _, id := range []string{"coleman", "tyler", "lacee", "sarah"} {
+		if err := g.AddNode(primitive.Node{"_type": "user", "_id": id, "name": id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	connect := func(from, to string) {
+		if err := g.AddEdge(&primitive.Edge{
+			Node: primitive.Node{"_type": "friend"},
+			From: primitive.Node{"_type": "user", "_id": from},
+			To:   primitive.Node{"_type": "user", "_id": to},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	connect("coleman", "tyler")
+	connect("coleman", "lacee")
+	connect("tyler", "sarah")
+	connect("lacee", "sarah")
+	src := traversal.New(g)
+	friendsOfFriends := src.V(&dagger.ForeignKey{XType: "user", XID: "coleman"}).Out("friend").Out("friend")
+	if got := friendsOfFriends.ToList(); len(got) != 2 {
+		t.Fatalf("expected sarah twice, got %v", got)
+	}
+	if got := src.V(&dagger.ForeignKey{XType: "user", XID: "coleman"}).Out("friend").Out("friend").Dedup().ToList(); len(got) != 1 || got[0].GetString("name") != "sarah" {
+		t.Fatalf("unexpected deduped friends of friends: %v", got)
+	}
+	paths := src.V(&dagger.ForeignKey{XType: "user", XID: "sarah"}).In("friend").In().Path().Paths()
+	if len(paths) != 2 || len(paths[0]) != 3 || paths[0][0].ID() != "sarah" || paths[0][1].ID() != "lacee" || paths[0][2].ID() != "coleman" {
+		t.Fatalf("unexpected paths: %v", paths)
+	}
+	if got := src.V().Has("name", "tyler").Both().ToList(); len(got) != 2 {
+		t.Fatalf("expected tyler's 2 neighbors, got %v", got)
+	}
+	if got := src.V().Both("friend").Limit(3).ToList(); len(got) != 3 {
+		t.Fatalf("expected the traversal to stop at 3 nodes, got %d", len(got))
+	}
+}
+
+func TestCypherQuery(t *testing.T) {
+	g := dagger.NewGraph()
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman", "name": "coleman", "age": 30})
+	tyler := g.NewNode(map[string]interface{}{"_type": "user", "_id": "tyler", "name": "tyler", "age": 18})
+	lacee := g.NewNode(map[string]interface{}{"_type": "user", "_id": "lacee", "name": "lacee", "age": 28})
+	charlie := g.NewNode(map[string]interface{}{"_type": "dog", "_id": "charlie", "name": "charlie"})
+	for _, to := range []*dagger.Node{tyler, lacee} {
+		if _, err := coleman.Connect(to, "friend", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := lacee.Connect(charlie, "pet", false); err != nil {
+		t.Fatal(err)
+	}
+	res, err := g.Query(`MATCH (a:user)-[:friend]->(b:user) WHERE a.name = "coleman" AND b.age >= 21 RETURN b, b.name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(res.Columns, ",") != "b,b.name" || len(res.Rows) != 1 || res.Rows[0][1] != "lacee" {
+		t.Fatalf("unexpected result: %v %v", res.Columns, res.Rows)
+	}
+	if n, ok := res.Rows[0][0].(*dagger.Node); !ok || n.ID() != "lacee" {
+		t.Fatalf("expected the node to be returned, got %v", res.Rows[0][0])
+	}
+	res, err = g.Query(`match (d:dog)<-[p:pet]-(owner)<-[:friend]-(f) return f.name, p LIMIT 5`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Rows) != 1 || res.Rows[0][0] != "coleman" {
+		t.Fatalf("unexpected result: %v", res.Rows)
+	}
+	if e, ok := res.Rows[0][1].(*dagger.Edge); !ok || e.Type() != "pet" {
+		t.Fatalf("expected the edge to be returned, got %v", res.Rows[0][1])
+	}
+	res, err = g.Query(`MATCH (u:user) RETURN u LIMIT 2`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(res.Rows))
+	}
+	for _, bad := range []string{
+		`MATCH (a:user) RETURN b`,
+		`MATCH (a:user RETURN a`,
+		`MATCH (a:user) WHERE a.name ~ "x" RETURN a`,
+	} {
+		if _, err := g.Query(bad); err == nil {
+			t.Fatalf("expected an error for %s", bad)
+		}
+	}
+}
+
+func TestCtx(t *testing.T) {
+	g := dagger.NewGraph()
+	root := g.NewNode(map[string]interface{}{"_type": "user", "_id": "root"})
+	for i := 0; i < 10; i++ {
+		n := g.NewNode(map[string]interface{}{"_type": "user", "_id": fmt.Sprint(i)})
+		if _, err := root.Connect(n, "friend", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := g.RangeNodesCtx(ctx, func(n *dagger.Node) bool { return true }); err != nil {
+		t.Fatal(err)
+	}
+	visited := 0
+	err := g.RangeNodesCtx(ctx, func(n *dagger.Node) bool {
+		visited++
+		if visited == 3 {
+			cancel()
+		}
+		return true
+	})
+	if err != context.Canceled || visited != 3 {
+		t.Fatalf("expected the scan to stop after 3 nodes with context.Canceled, got %v after %d", err, visited)
+	}
+	if err := root.EdgesFromCtx(ctx, dagger.AnyType(), func(e *dagger.Edge) bool {
+		t.Fatal("expected no edges to be visited with a cancelled context")
+		return true
+	}); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if err := root.BFSCtx(ctx, 0, func(n *dagger.Node) bool { return true }); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if _, err := g.Primitive().DFSCtx(ctx, root, primitive.TraversalOptions{}, func(n primitive.Node, depth int) bool { return true }); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if err := traversal.New(g.Primitive()).V().Out().IterateCtx(ctx, func(tr *traversal.Traverser) bool { return true }); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+type typedUser struct {
+	Name  string   `json:"name"`
+	Age   int      `json:"age"`
+	Email string   `json:"email,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+type typedDog struct {
+	Name string `json:"name"`
+}
+
+func TestTypedNode(t *testing.T) {
+	g := dagger.NewGraph()
+	coleman, err := dagger.NewTypedNode(g, "user", typedUser{Name: "coleman", Age: 30, Email: "coleman@example.com", Tags: []string{"admin"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	charlie, err := dagger.NewTypedNode(g, "dog", typedDog{Name: "charlie"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if coleman.Type() != "user" || coleman.ID() == "" || coleman.GetString("name") != "coleman" {
+		t.Fatalf("unexpected typed node: %v", coleman.Raw())
+	}
+	data, err := coleman.GetData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Name != "coleman" || data.Age != 30 || len(data.Tags) != 1 {
+		t.Fatalf("unexpected data: %+v", data)
+	}
+	if err := coleman.SetData(typedUser{Name: "coleman", Age: 31}); err != nil {
+		t.Fatal(err)
+	}
+	if coleman.Get("email") != nil || coleman.GetInt("age") != 31 {
+		t.Fatalf("expected the data to be replaced: %v", coleman.Raw())
+	}
+	edge, err := dagger.ConnectTyped(coleman, charlie, "pet", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dog, err := dagger.AsTyped[typedDog](edge.To()).GetData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dog.Name != "charlie" {
+		t.Fatalf("unexpected dog: %+v", dog)
+	}
+}
+
+func TestEdgeWeight(t *testing.T) {
+	g := dagger.NewGraph()
+	a := g.NewNode(map[string]interface{}{"_type": "user", "_id": "a"})
+	b := g.NewNode(map[string]interface{}{"_type": "user", "_id": "b"})
+	c := g.NewNode(map[string]interface{}{"_type": "user", "_id": "c"})
+	direct, err := a.Connect(c, "friend", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if direct.Weight() != 1 {
+		t.Fatalf("expected an unweighted edge to weigh 1, got %v", direct.Weight())
+	}
+	if err := direct.SetWeight(5); err != nil {
+		t.Fatal(err)
+	}
+	if direct.Weight() != 5 {
+		t.Fatalf("expected weight 5, got %v", direct.Weight())
+	}
+	if _, err := a.Connect(b, "friend", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Connect(c, "friend", false); err != nil {
+		t.Fatal(err)
+	}
+	path, weight, err := g.ShortestWeightedPath(a, c, "friend", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(path) != 2 || weight != 2 {
+		t.Fatalf("expected 2 unweighted hops, got %v hops weighing %v", len(path), weight)
+	}
+}
+
+func TestUniqueEdges(t *testing.T) {
+	multi := dagger.NewGraph()
+	a := multi.NewNode(map[string]interface{}{"_type": "user", "_id": "a"})
+	b := multi.NewNode(map[string]interface{}{"_type": "user", "_id": "b"})
+	for i := 0; i < 2; i++ {
+		if _, err := a.Connect(b, "friend", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := len(multi.GetEdgesBetween(a, b, "friend")); got != 2 {
+		t.Fatalf("expected 2 parallel edges by default, got %d", got)
+	}
+	perType := dagger.NewGraph(dagger.UniqueEdges(true))
+	a = perType.NewNode(map[string]interface{}{"_type": "user", "_id": "a"})
+	b = perType.NewNode(map[string]interface{}{"_type": "user", "_id": "b"})
+	if _, err := a.Connect(b, "friend", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Connect(b, "friend", false); !errors.Is(err, dagger.ErrDuplicateEdge) {
+		t.Fatalf("expected ErrDuplicateEdge, got %v", err)
+	}
+	if _, err := a.Connect(b, "wife", false); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(perType.GetEdgesBetween(a, b, "*")); got != 2 {
+		t.Fatalf("expected a friend & wife edge, got %d", got)
+	}
+	if got := len(perType.GetEdgesBetween(b, a, "friend")); got != 1 {
+		t.Fatalf("expected the mutual edge, got %d", got)
+	}
+	single := dagger.NewGraph(dagger.UniqueEdges(false))
+	a = single.NewNode(map[string]interface{}{"_type": "user", "_id": "a"})
+	b = single.NewNode(map[string]interface{}{"_type": "user", "_id": "b"})
+	edge, err := a.Connect(b, "friend", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := edge.Patch(map[string]interface{}{"since": 2019}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Connect(b, "wife", false); !errors.Is(err, dagger.ErrDuplicateEdge) {
+		t.Fatalf("expected ErrDuplicateEdge, got %v", err)
+	}
+}
+
+func TestUpsertNode(t *testing.T) {
+	g := dagger.NewGraph()
+	n, created, err := g.UpsertNode(map[string]interface{}{"_type": "user", "_id": "coleman", "name": "coleman", "age": 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created {
+		t.Fatal("expected the node to be created")
+	}
+	n, created, err = g.UpsertNode(map[string]interface{}{"_type": "user", "_id": "coleman", "age": 31})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created {
+		t.Fatal("expected the existing node to be updated")
+	}
+	if n.GetString("name") != "coleman" || n.GetInt("age") != 31 {
+		t.Fatalf("expected the attributes to be merged: %v", n.Raw())
+	}
+	if g.NodeCount() != 1 {
+		t.Fatalf("expected 1 node, got %d", g.NodeCount())
+	}
+	if _, created, _ := g.UpsertNode(map[string]interface{}{"_type": "user"}); !created {
+		t.Fatal("expected a node without an id to be created")
+	}
+	g.SetReadOnly(true)
+	if _, _, err := g.UpsertNode(map[string]interface{}{"_type": "user", "_id": "coleman"}); err != primitive.ErrReadOnly {
+		t.Fatalf("expected ErrReadOnly, got %v", err)
+	}
+}
+
+func TestPatchIf(t *testing.T) {
+	g := dagger.NewGraph()
+	lock := g.NewNode(map[string]interface{}{"_type": "user", "_id": "lock"})
+	won := make(chan bool)
+	for w := 0; w < 8; w++ {
+		w := w
+		go func() {
+			ok, err := lock.PatchIf(func(attributes map[string]interface{}) bool {
+				_, held := attributes["owner"]
+				return !held
+			}, map[string]interface{}{"owner": w})
+			if err != nil {
+				t.Error(err)
+			}
+			won <- ok
+		}()
+	}
+	winners := 0
+	for w := 0; w < 8; w++ {
+		if <-won {
+			winners++
+		}
+	}
+	if winners != 1 {
+		t.Fatalf("expected exactly one goroutine to acquire the lock, got %d", winners)
+	}
+	ok, err := lock.PatchIf(func(attributes map[string]interface{}) bool {
+		return attributes["owner"] == -1
+	}, map[string]interface{}{"owner": 0})
+	if err != nil || ok {
+		t.Fatalf("expected the patch to be skipped, got %v %v", ok, err)
+	}
+}
+
+func TestIncrement(t *testing.T) {
+	g := dagger.NewGraph()
+	a := g.NewNode(map[string]interface{}{"_type": "user", "_id": "a"})
+	b := g.NewNode(map[string]interface{}{"_type": "user", "_id": "b"})
+	edge, err := a.Connect(b, "friend", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	for w := 0; w < 8; w++ {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for i := 0; i < 100; i++ {
+				if _, err := a.Increment("requests", 1); err != nil {
+					t.Error(err)
+				}
+				if _, err := edge.Increment("messages", 2); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	for w := 0; w < 8; w++ {
+		<-done
+	}
+	if got, err := a.Increment("requests", 0); err != nil || got != 800 {
+		t.Fatalf("expected 800 requests, got %v %v", got, err)
+	}
+	if got, err := edge.Increment("messages", -600); err != nil || got != 1000 {
+		t.Fatalf("expected 1000 messages, got %v %v", got, err)
+	}
+	if err := edge.Patch(map[string]interface{}{"_type": "enemy"}); err == nil {
+		t.Fatal("expected an error patching the edge's type")
+	}
+	// the edge no longer passes validation, so patches to it must be rejected without changing it
+	g.RegisterSchema("user", dagger.Schema{EdgeTypes: []string{"follows"}})
+	if _, err := edge.Increment("messages", 1); !errors.Is(err, dagger.ErrSchemaViolation) {
+		t.Fatalf("expected a schema violation, got %v", err)
+	}
+	if err := edge.Patch(map[string]interface{}{"muted": true}); !errors.Is(err, dagger.ErrSchemaViolation) {
+		t.Fatalf("expected a schema violation, got %v", err)
+	}
+	if edge.GetInt("messages") != 1000 || edge.Get("muted") != nil {
+		t.Fatal("expected rejected patches to leave the edge unchanged")
+	}
+}
+
+// TestParallelWriters must pass under -race: writers changing different nodes & edges share the graph's lock, so the
+// changes they make must not be lost & their hooks must each run once
+func TestParallelWriters(t *testing.T) {
+	g := primitive.NewGraph()
+	var added, connected int64
+	g.OnNodeAdded(func(n primitive.Node) {
+		atomic.AddInt64(&added, 1)
+	})
+	g.OnEdgeAdded(func(e *primitive.Edge) {
+		atomic.AddInt64(&connected, 1)
+	})
+	hub := primitive.NewNode(map[string]interface{}{"_type": "user", "_id": "hub"})
+	if err := g.AddNode(hub); err != nil {
+		t.Fatal(err)
+	}
+	const writers, each = 8, 50
+	stop := make(chan struct{})
+	reading := make(chan struct{})
+	go func() {
+		defer close(reading)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				// readers that need a consistent view wait for the writers
+				if errs := g.CheckIntegrity(); len(errs) > 0 {
+					t.Error(errs[0])
+					return
+				}
+				g.Hash()
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < each; i++ {
+				n := primitive.NewNode(map[string]interface{}{"_type": "user", "_id": fmt.Sprintf("%v-%v", w, i)})
+				if err := g.AddNode(n); err != nil {
+					t.Error(err)
+					return
+				}
+				// every writer connects to the hub, so they contend for its stripe
+				if err := g.AddEdge(&primitive.Edge{Node: primitive.Node{"_type": "follows"}, From: n, To: hub}); err != nil {
+					t.Error(err)
+					return
+				}
+				if _, err := g.IncrementNode(hub, "followers", 1); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(stop)
+	<-reading
+	health := g.Health()
+	if health.Nodes != writers*each+1 || health.Edges != writers*each {
+		t.Fatalf("expected %v nodes & %v edges, got %v & %v", writers*each+1, writers*each, health.Nodes, health.Edges)
+	}
+	if n, _ := g.GetNode(hub); n.GetInt("followers") != writers*each {
+		t.Fatalf("expected %v followers, got %v", writers*each, n.GetInt("followers"))
+	}
+	if len(g.Neighbors(hub, dagger.AnyType(), primitive.Incoming)) != writers*each {
+		t.Fatal("expected every edge in the hub's index")
+	}
+	if atomic.LoadInt64(&added) != writers*each+1 || atomic.LoadInt64(&connected) != writers*each {
+		t.Fatalf("expected each hook to run once per change, got %v & %v", added, connected)
+	}
+}
+
+// TestIncrementWhileReading must pass under -race: patches replace nodes & edges rather than modifying the maps readers
+// hold without the lock
+func TestIncrementWhileReading(t *testing.T) {
+	g := dagger.NewGraph()
+	a := g.NewNode(map[string]interface{}{"_type": "user", "_id": "a"})
+	b := g.NewNode(map[string]interface{}{"_type": "user", "_id": "b"})
+	edge, err := a.Connect(b, "friend", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := make(chan struct{})
+	var readers sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			last := 0
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				n, ok := g.GetNode(a)
+				if !ok {
+					t.Error("expected the node to exist")
+					return
+				}
+				got := n.GetInt("requests")
+				if got < last {
+					t.Errorf("expected requests to only grow, got %v after %v", got, last)
+					return
+				}
+				last = got
+				edge.GetInt("messages")
+				g.RangeNodes(func(n *dagger.Node) bool {
+					n.Get("requests")
+					return true
+				})
+			}
+		}()
+	}
+	for i := 0; i < 200; i++ {
+		if _, err := a.Increment("requests", 1); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := edge.Increment("messages", 1); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Patch(map[string]interface{}{"seen": i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(stop)
+	readers.Wait()
+	if a.GetInt("requests") != 200 || edge.GetInt("messages") != 200 {
+		t.Fatalf("expected 200 requests & messages, got %v %v", a.GetInt("requests"), edge.GetInt("messages"))
+	}
+}
+
+func TestTTL(t *testing.T) {
+	g := dagger.NewGraph()
+	session := g.NewNode(map[string]interface{}{"_type": "session", "_id": "s1"})
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman"})
+	lacee := g.NewNode(map[string]interface{}{"_type": "user", "_id": "lacee"})
+	owns, err := coleman.Connect(session, "owns", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	friend, err := coleman.Connect(lacee, "friend", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := session.SetTTL(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := friend.SetTTL(-time.Second); err != nil {
+		t.Fatal(err)
+	}
+	nodes, edges, err := g.Expire()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nodes != 0 || edges != 1 || g.HasEdge(friend) {
+		t.Fatalf("expected only the expired edge to be removed, got %d nodes & %d edges", nodes, edges)
+	}
+	if err := session.SetTTL(10 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	stop := g.StartJanitor(5*time.Millisecond, func(err error) { t.Error(err) })
+	defer stop()
+	deadline := time.Now().Add(time.Second)
+	for g.HasNode(session) {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the janitor to remove the expired session")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if g.HasEdge(owns) {
+		t.Fatal("expected the edge to the expired session to be removed")
+	}
+	if !g.HasNode(coleman) {
+		t.Fatal("expected nodes without a ttl to remain")
+	}
+}
+
+func TestExpiryCallbacks(t *testing.T) {
+	g := dagger.NewGraph()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes, err := g.Subscribe(ctx, primitive.ChangeFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expired []string
+	g.OnNodeExpired(func(n primitive.Node) {
+		expired = append(expired, n.ID())
+	})
+	g.OnEdgeExpired(func(e *primitive.Edge) {
+		expired = append(expired, e.Type())
+	})
+	// active sessions get another hour
+	g.OnNodeExpiring(func(n *dagger.Node) time.Duration {
+		if n.GetBool("active") {
+			return time.Hour
+		}
+		return 0
+	})
+	active := g.NewNode(map[string]interface{}{"_type": "session", "_id": "active", "active": true})
+	idle := g.NewNode(map[string]interface{}{"_type": "session", "_id": "idle"})
+	follows, err := active.Connect(idle, "follows", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ttl := range []interface{ SetTTL(time.Duration) error }{active, idle, follows} {
+		if err := ttl.SetTTL(-time.Second); err != nil {
+			t.Fatal(err)
+		}
+	}
+	nodes, edges, err := g.Expire()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nodes != 1 || edges != 1 || !g.HasNode(active) || g.HasNode(idle) {
+		t.Fatalf("expected only the idle session & the edge to expire, got %d nodes & %d edges", nodes, edges)
+	}
+	if expires, ok := primitive.Node(active.Raw()).ExpiresAt(); !ok || time.Until(expires) < 59*time.Minute {
+		t.Fatalf("expected the active session's ttl to be extended by an hour, got %v", expires)
+	}
+	if len(expired) != 2 || expired[0] != "follows" || expired[1] != "idle" {
+		t.Fatalf("expected the edge & idle session to be passed to the expired hooks, got %v", expired)
+	}
+	// the stream publishes an expired change ahead of each deleted change
+	var ops []primitive.ChangeOp
+	for len(ops) < 4 {
+		select {
+		case c := <-changes:
+			if c.Op == primitive.ChangeNodeExpired || c.Op == primitive.ChangeEdgeExpired || c.Op == primitive.ChangeNodeDeleted || c.Op == primitive.ChangeEdgeDeleted {
+				ops = append(ops, c.Op)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected 4 expiry changes, got %v", ops)
+		}
+	}
+	want := []primitive.ChangeOp{primitive.ChangeEdgeExpired, primitive.ChangeEdgeDeleted, primitive.ChangeNodeExpired, primitive.ChangeNodeDeleted}
+	if fmt.Sprint(ops) != fmt.Sprint(want) {
+		t.Fatalf("expected %v, got %v", want, ops)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	g := dagger.NewGraph()
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman", "name": "coleman"})
+	lacee := g.NewNode(map[string]interface{}{"_type": "user", "_id": "lacee", "name": "lacee"})
+	if _, err := coleman.Connect(lacee, "friend", false); err != nil {
+		t.Fatal(err)
+	}
+	snapshot := g.Snapshot()
+	hash := snapshot.Hash()
+	if hash != g.Hash() {
+		t.Fatal("expected the snapshot to match the graph")
+	}
+	if err := coleman.Patch(map[string]interface{}{"name": "colemanword"}); err != nil {
+		t.Fatal(err)
+	}
+	g.NewNode(map[string]interface{}{"_type": "user", "_id": "tyler"})
+	if snapshot.NodeCount() != 2 || snapshot.Hash() != hash {
+		t.Fatal("expected the snapshot not to observe later writes")
+	}
+	n, ok := snapshot.GetNode(coleman)
+	if !ok || n.GetString("name") != "coleman" {
+		t.Fatalf("unexpected snapshot node: %v", n)
+	}
+	edges := 0
+	snapshot.EdgesFrom(coleman, dagger.AnyType(), func(e *dagger.EdgeView) bool {
+		edges++
+		return true
+	})
+	if edges != 1 {
+		t.Fatalf("expected 1 edge in the snapshot, got %d", edges)
+	}
+}
+
+func TestView(t *testing.T) {
+	g := dagger.NewGraph()
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman", "name": "coleman"})
+	lacee := g.NewNode(map[string]interface{}{"_type": "user", "_id": "lacee"})
+	count := func(r dagger.Reader) int {
+		return r.NodeCount()
+	}
+	view := g.View()
+	if count(view) != 2 {
+		t.Fatal("expected the view to be a reader of 2 nodes")
+	}
+	g.NewNode(map[string]interface{}{"_type": "user", "_id": "tyler"})
+	if count(view) != 3 {
+		t.Fatal("expected the view to observe writes to the graph")
+	}
+	n, ok := view.GetNode(coleman)
+	if !ok {
+		t.Fatal("expected the node to exist")
+	}
+	// views have no mutation methods, so writes through them do not compile
+	var node interface{} = n
+	if _, ok := node.(interface {
+		Patch(data map[string]interface{}) error
+	}); ok {
+		t.Fatal("expected a node view not to have a Patch method")
+	}
+	if _, ok := node.(interface {
+		Connect(nodeID primitive.TypedID, relationship string, mutual bool) (*dagger.Edge, error)
+	}); ok {
+		t.Fatal("expected a node view not to have a Connect method")
+	}
+	if _, ok := node.(interface{ Remove() error }); ok {
+		t.Fatal("expected a node view not to have a Remove method")
+	}
+	if neighbors := n.Neighbors("*"); len(neighbors) != 0 || lacee.Degree(dagger.Both, "*") != 0 {
+		t.Fatal("expected the node to have no neighbors")
+	}
+	if coleman.GetString("name") != "coleman" || g.EdgeCount() != 0 {
+		t.Fatal("expected the graph to be unchanged")
+	}
+	if err := coleman.Patch(map[string]interface{}{"name": "colemanword"}); err != nil {
+		t.Fatal(err)
+	}
+	if n.GetString("name") != "colemanword" {
+		t.Fatal("expected the view's node to observe the patch")
+	}
+}
+
+func TestComponents(t *testing.T) {
+	g := dagger.NewGraph()
+	nodes := map[string]*dagger.Node{}
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		nodes[id] = g.NewNode(map[string]interface{}{"_type": "user", "_id": id})
+	}
+	connect := func(from, to string) {
+		if _, err := nodes[from].Connect(nodes[to], "friend", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	connect("a", "b")
+	connect("b", "c")
+	connect("c", "a")
+	connect("c", "d")
+	ids := func(components [][]*dagger.Node) string {
+		var groups []string
+		for _, c := range components {
+			var group []string
+			for _, n := range c {
+				group = append(group, n.ID())
+			}
+			groups = append(groups, strings.Join(group, ","))
+		}
+		return strings.Join(groups, "|")
+	}
+	if got := ids(g.ConnectedComponents()); got != "a,b,c,d|e" {
+		t.Fatalf("unexpected weak components: %s", got)
+	}
+	if got := ids(g.StronglyConnectedComponents()); got != "a,b,c|d|e" {
+		t.Fatalf("unexpected strong components: %s", got)
+	}
+	if !g.Reachable(nodes["a"], nodes["d"]) {
+		t.Fatal("expected d to be reachable from a")
+	}
+	if g.Reachable(nodes["d"], nodes["a"]) || g.Reachable(nodes["a"], nodes["e"]) {
+		t.Fatal("expected unreachable nodes")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	g := dagger.NewGraph()
+	nodes := map[string]*dagger.Node{}
+	for _, id := range []string{"coleman", "tyler", "lacee"} {
+		nodes[id] = g.NewNode(map[string]interface{}{"_type": "user", "_id": id, "admin": id == "coleman"})
+	}
+	nodes["charlie"] = g.NewNode(map[string]interface{}{"_type": "dog", "_id": "charlie"})
+	connect := func(from, to, typ string) {
+		if _, err := nodes[from].Connect(nodes[to], typ, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	connect("coleman", "tyler", "friend")
+	connect("tyler", "lacee", "friend")
+	connect("coleman", "lacee", "friend")
+	connect("lacee", "charlie", "pet")
+	matches := g.Match(&dagger.Pattern{
+		Nodes: []dagger.PatternNode{
+			{Var: "a", Type: "user", Where: func(n primitive.Node) bool { return n.GetBool("admin") }},
+			{Var: "b", Type: "user"},
+			{Var: "c", Type: "user"},
+		},
+		Edges: []dagger.PatternEdge{
+			{From: "a", To: "b", Type: "friend"},
+			{From: "b", To: "c", Type: "friend"},
+			{Var: "shortcut", From: "a", To: "c", Type: "friend"},
+		},
+	})
+	if len(matches) != 1 {
+		t.Fatalf("expected 1 triangle, got %d", len(matches))
+	}
+	m := matches[0]
+	if m.Nodes["a"].ID() != "coleman" || m.Nodes["b"].ID() != "tyler" || m.Nodes["c"].ID() != "lacee" {
+		t.Fatalf("unexpected bindings: %v", m.Nodes)
+	}
+	if m.Edges["shortcut"].From().ID() != "coleman" || m.Edges["shortcut"].To().ID() != "lacee" {
+		t.Fatal("unexpected edge binding")
+	}
+	owners := g.Match(&dagger.Pattern{
+		Nodes: []dagger.PatternNode{{Var: "pet", Type: "dog"}},
+		Edges: []dagger.PatternEdge{{From: "owner", To: "pet", Type: "pet"}, {From: "friend", To: "owner", Type: "friend"}},
+	})
+	var friends []string
+	for _, m := range owners {
+		friends = append(friends, m.Nodes["friend"].ID())
+	}
+	sort.Strings(friends)
+	if strings.Join(friends, ",") != "coleman,tyler" {
+		t.Fatalf("unexpected friends of pet owners: %v", friends)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := dagger.NewGraph()
+	coleman := a.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman", "name": "coleman"})
+	tyler := a.NewNode(map[string]interface{}{"_type": "user", "_id": "tyler"})
+	a.NewNode(map[string]interface{}{"_type": "user", "_id": "lacee"})
+	if _, err := coleman.Connect(tyler, "friend", false); err != nil {
+		t.Fatal(err)
+	}
+	before := a.Export()
+
+	b := dagger.NewGraph()
+	if err := b.ApplyDiff(dagger.Diff(&primitive.Export{}, before)); err != nil {
+		t.Fatal(err)
+	}
+	if !dagger.Diff(before, b.Export()).Empty() {
+		t.Fatal("expected applying a diff from an empty export to copy the graph")
+	}
+
+	if err := coleman.Patch(map[string]interface{}{"name": "colemanword"}); err != nil {
+		t.Fatal(err)
+	}
+	lacee, _ := a.GetNode(&dagger.ForeignKey{XType: "user", XID: "lacee"})
+	if err := lacee.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	charlie := a.NewNode(map[string]interface{}{"_type": "dog", "_id": "charlie"})
+	if _, err := coleman.Connect(charlie, "pet", false); err != nil {
+		t.Fatal(err)
+	}
+	d := dagger.Diff(before, a.Export())
+	if len(d.AddedNodes) != 1 || d.AddedNodes[0].ID() != "charlie" {
+		t.Fatalf("unexpected added nodes: %v", d.AddedNodes)
+	}
+	if len(d.RemovedNodes) != 1 || d.RemovedNodes[0].ID() != "lacee" {
+		t.Fatalf("unexpected removed nodes: %v", d.RemovedNodes)
+	}
+	if len(d.ModifiedNodes) != 1 || d.ModifiedNodes[0].GetString("name") != "colemanword" {
+		t.Fatalf("unexpected modified nodes: %v", d.ModifiedNodes)
+	}
+	if len(d.AddedEdges) != 1 || len(d.RemovedEdges) != 0 || len(d.ModifiedEdges) != 0 {
+		t.Fatalf("unexpected edge changes: %v", d)
+	}
+	if err := b.ApplyDiff(d); err != nil {
+		t.Fatal(err)
+	}
+	if !dagger.Diff(a.Export(), b.Export()).Empty() {
+		t.Fatal("expected graphs to converge after applying the diff")
+	}
+	b.SetReadOnly(true)
+	if err := b.ApplyDiff(d); !errors.Is(err, dagger.ErrReadOnly) {
+		t.Fatalf("expected read only error, got %v", err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	us := dagger.NewGraph()
+	eu := dagger.NewGraph()
+	coleman := us.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman", "region": "us", "logins": 3})
+	tyler := us.NewNode(map[string]interface{}{"_type": "user", "_id": "tyler"})
+	if _, err := coleman.Connect(tyler, "friend", false); err != nil {
+		t.Fatal(err)
+	}
+	eu.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman", "region": "eu", "logins": 5})
+	lacee := eu.NewNode(map[string]interface{}{"_type": "user", "_id": "lacee"})
+	if _, err := lacee.Connect(&dagger.ForeignKey{XType: "user", XID: "coleman"}, "friend", false); err != nil {
+		t.Fatal(err)
+	}
+	var conflicts int
+	if err := us.Merge(eu, func(ours, theirs primitive.Node) primitive.Node {
+		conflicts++
+		ours.Set("logins", ours.GetInt("logins")+theirs.GetInt("logins"))
+		ours.Set("region", "global")
+		return ours
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if conflicts != 1 {
+		t.Fatalf("expected 1 conflict, got %d", conflicts)
+	}
+	if coleman.GetInt("logins") != 8 || coleman.GetString("region") != "global" {
+		t.Fatalf("unexpected resolved attributes: %v", coleman.Raw())
+	}
+	if _, ok := us.GetNode(lacee); !ok {
+		t.Fatal("expected lacee to be merged")
+	}
+	if len(coleman.FilterEdgesTo(dagger.AnyType(), func(e *dagger.Edge) bool { return true })) != 1 {
+		t.Fatal("expected lacee's edge to be merged")
+	}
+	if err := eu.Merge(us, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !dagger.Diff(us.Export(), eu.Export()).Empty() {
+		t.Fatal("expected graphs to converge")
+	}
+}
+
+func TestClone(t *testing.T) {
+	g := dagger.NewGraph()
+	coleman := g.NewNode(map[string]interface{}{
+		"_type":   "user",
+		"_id":     "coleman",
+		"address": map[string]interface{}{"city": "denver"},
+		"tags":    []string{"admin"},
+	})
+	tyler := g.NewNode(map[string]interface{}{"_type": "user", "_id": "tyler"})
+	if _, err := coleman.Connect(tyler, "friend", false); err != nil {
+		t.Fatal(err)
+	}
+	copied := coleman.Copy()
+	copied["address"].(map[string]interface{})["city"] = "boulder"
+	copied["tags"].([]string)[0] = "guest"
+	if coleman.Get("address").(map[string]interface{})["city"] != "denver" || coleman.Get("tags").([]string)[0] != "admin" {
+		t.Fatal("expected copy mutations not to leak into the node")
+	}
+
+	clone := g.Clone()
+	cloned, ok := clone.GetNode(coleman)
+	if !ok {
+		t.Fatal("expected node to be cloned")
+	}
+	cloned.Raw()["address"].(map[string]interface{})["city"] = "boulder"
+	if err := cloned.Patch(map[string]interface{}{"name": "coleman"}); err != nil {
+		t.Fatal(err)
+	}
+	if coleman.Get("address").(map[string]interface{})["city"] != "denver" || coleman.Get("name") != nil {
+		t.Fatal("expected clone mutations not to leak into the graph")
+	}
+	if len(cloned.FilterEdgesFrom(dagger.AnyType(), func(e *dagger.Edge) bool { return true })) != 1 {
+		t.Fatal("expected edges to be cloned")
+	}
+
+	exp := g.Export()
+	imported := primitive.NewGraph()
+	if err := imported.Import(exp); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range exp.Nodes {
+		if n.ID() == "coleman" {
+			n["address"].(map[string]interface{})["city"] = "boulder"
+		}
+	}
+	n, _ := imported.GetNode(coleman)
+	if n["address"].(map[string]interface{})["city"] != "denver" {
+		t.Fatal("expected export mutations not to leak into the imported graph")
+	}
+}
+
+func TestBulkWritesValidate(t *testing.T) {
+	dir := t.TempDir()
+	wal, err := primitive.OpenWAL(filepath.Join(dir, "graph.wal"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the source graph has no schema, so it holds a user without the name the target graph requires
+	source := primitive.NewGraph()
+	source.SetWAL(wal)
+	nameless := primitive.Node{"_type": "user", "_id": "nameless"}
+	named := primitive.Node{"_type": "user", "_id": "coleman", "name": "coleman"}
+	if err := source.AddNodes(nameless, named); err != nil {
+		t.Fatal(err)
+	}
+	if err := source.AddEdge(&primitive.Edge{Node: primitive.Node{"_type": "knows"}, From: named, To: nameless}); err != nil {
+		t.Fatal(err)
+	}
+	wal.Close()
+	var ndjson bytes.Buffer
+	if err := source.ExportNDJSON(&ndjson); err != nil {
+		t.Fatal(err)
+	}
+	target := func() *primitive.Graph {
+		g := primitive.NewGraph()
+		g.RegisterSchema("user", primitive.Schema{Required: []string{"name"}})
+		return g
+	}
+	writes := map[string]func(g *primitive.Graph) error{
+		"Import": func(g *primitive.Graph) error {
+			return g.Import(source.Export())
+		},
+		"Merge": func(g *primitive.Graph) error {
+			return g.Merge(source, nil)
+		},
+		"Apply": func(g *primitive.Graph) error {
+			_, err := g.Apply(source.Export(), primitive.Filter{})
+			return err
+		},
+		"ApplyDiff": func(g *primitive.Graph) error {
+			return g.ApplyDiff(primitive.Diff(g.Export(), source.Export()))
+		},
+		"BulkLoad": func(g *primitive.Graph) error {
+			exp := source.Export()
+			return g.BulkLoad(exp.Nodes, exp.Edges)
+		},
+		"ImportNDJSON": func(g *primitive.Graph) error {
+			return g.ImportNDJSON(bytes.NewReader(ndjson.Bytes()))
+		},
+		"Recover": func(g *primitive.Graph) error {
+			f, err := os.Open(filepath.Join(dir, "graph.wal"))
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return g.Recover(f)
+		},
+	}
+	for name, write := range writes {
+		g := target()
+		if err := write(g); !errors.Is(err, primitive.ErrSchemaViolation) {
+			t.Fatalf("%s: expected a schema violation, got %v", name, err)
+		}
+		if g.HasNode(nameless) {
+			t.Fatalf("%s: expected the invalid node not to be written", name)
+		}
+	}
+	// an edge to a node that does not exist is reported rather than skipped
+	dangling := &primitive.Export{
+		Nodes: []primitive.Node{named},
+		Edges: []*primitive.Edge{{Node: primitive.Node{"_type": "knows", "_id": "ghost"}, From: named, To: primitive.Node{"_type": "user", "_id": "ghost"}}},
+	}
+	if err := target().Import(dangling); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Fatalf("expected the dangling edge to fail the import, got %v", err)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	g := dagger.NewGraph()
+	g.RegisterSchema("user", dagger.Schema{
+		Required:   []string{"name"},
+		Attributes: map[string]string{"name": "string", "age": "int", "score": "float"},
+		Strict:     true,
+		EdgeTypes:  []string{"knows"},
+	})
+	if err := g.DeclareEdgeType("knows", "friend"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.AddNode(map[string]interface{}{"_type": "user", "_id": "nobody"}); !errors.Is(err, dagger.ErrSchemaViolation) {
+		t.Fatalf("expected missing attribute violation, got %v", err)
+	}
+	if _, err := g.AddNode(map[string]interface{}{"_type": "user", "name": "coleman", "age": "old"}); !errors.Is(err, dagger.ErrSchemaViolation) {
+		t.Fatalf("expected kind violation, got %v", err)
+	}
+	if _, err := g.AddNode(map[string]interface{}{"_type": "user", "name": "coleman", "nickname": "cole"}); !errors.Is(err, dagger.ErrSchemaViolation) {
+		t.Fatalf("expected undeclared attribute violation, got %v", err)
+	}
+	coleman, err := g.AddNode(map[string]interface{}{"_type": "user", "_id": "coleman", "name": "coleman", "age": 30, "score": 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tyler, err := g.AddNode(map[string]interface{}{"_type": "user", "_id": "tyler", "name": "tyler"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := coleman.Patch(map[string]interface{}{"age": "thirty"}); !errors.Is(err, dagger.ErrSchemaViolation) {
+		t.Fatalf("expected patch violation, got %v", err)
+	}
+	if coleman.GetInt("age") != 30 {
+		t.Fatal("expected rejected patch not to be applied")
+	}
+	if _, err := coleman.Connect(tyler, "friend", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := coleman.Connect(tyler, "blocks", false); !errors.Is(err, dagger.ErrSchemaViolation) {
+		t.Fatalf("expected edge type violation, got %v", err)
+	}
+	if len(g.Schemas()) != 1 {
+		t.Fatal("expected 1 registered schema")
+	}
+}
+
+func TestConstrainEdge(t *testing.T) {
+	g := dagger.NewGraph()
+	g.ConstrainEdge("owner", dagger.From("dog"), dagger.To("person"))
+	if err := g.DeclareNodeType("person", "user"); err != nil {
+		t.Fatal(err)
+	}
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman"})
+	tyler := g.NewNode(map[string]interface{}{"_type": "user", "_id": "tyler"})
+	charlie := g.NewNode(map[string]interface{}{"_type": "dog", "_id": "charlie"})
+	if _, err := charlie.Connect(coleman, "owner", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tyler.Connect(coleman, "owner", false); !errors.Is(err, dagger.ErrSchemaViolation) {
+		t.Fatalf("expected source type violation, got %v", err)
+	}
+	if _, err := charlie.Connect(charlie, "owner", false); !errors.Is(err, dagger.ErrSchemaViolation) {
+		t.Fatalf("expected target type violation, got %v", err)
+	}
+	if _, err := tyler.Connect(coleman, "friend", false); err != nil {
+		t.Fatal(err)
+	}
+	if c := g.EdgeConstraints()["owner"]; len(c.From) != 1 || c.To[0] != "person" {
+		t.Fatalf("unexpected constraints: %v", c)
+	}
+}
+
+func TestDeletePolicy(t *testing.T) {
+	g := dagger.NewGraph()
+	g.SetDeletePolicy("owns", dagger.Cascade)
+	g.SetDeletePolicy("employs", dagger.Restrict)
+	nodes := map[string]*dagger.Node{}
+	for _, id := range []string{"coleman", "tyler", "charlie", "acme"} {
+		nodes[id] = g.NewNode(map[string]interface{}{"_type": "user", "_id": id})
+	}
+	connect := func(from, to, typ string) *dagger.Edge {
+		e, err := nodes[from].Connect(nodes[to], typ, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e
+	}
+	friend := connect("tyler", "coleman", "friend")
+	connect("coleman", "charlie", "owns")
+	connect("charlie", "tyler", "friend")
+	employs := connect("acme", "tyler", "employs")
+
+	if err := nodes["tyler"].Remove(); !errors.Is(err, dagger.ErrRestricted) {
+		t.Fatalf("expected restricted error, got %v", err)
+	}
+	if g.NodeCount() != 4 {
+		t.Fatal("expected a restricted delete to change nothing")
+	}
+	if err := g.DelEdge(employs); err != nil {
+		t.Fatal(err)
+	}
+	if err := nodes["charlie"].Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if g.HasNode(nodes["coleman"]) || !g.HasNode(nodes["tyler"]) {
+		t.Fatal("expected incoming cascade edges to delete the owner but detach friends")
+	}
+	if g.HasEdge(friend) || g.EdgeCount() != 0 {
+		t.Fatalf("expected no orphaned edges, got %d", g.EdgeCount())
+	}
+	if n := len(nodes["tyler"].FilterEdgesFrom(dagger.AnyType(), func(e *dagger.Edge) bool { return true })); n != 0 {
+		t.Fatalf("expected no dangling edge index entries, got %d", n)
+	}
+}
+
+func TestCheckIntegrity(t *testing.T) {
+	storage := primitive.NewMemoryStorage()
+	g := dagger.NewGraph(dagger.WithStorage(storage))
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman"})
+	tyler := g.NewNode(map[string]interface{}{"_type": "user", "_id": "tyler"})
+	lacee := g.NewNode(map[string]interface{}{"_type": "user", "_id": "lacee"})
+	friend, err := coleman.Connect(tyler, "friend", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tyler.Connect(lacee, "friend", false); err != nil {
+		t.Fatal(err)
+	}
+	if errs := g.CheckIntegrity(); len(errs) != 0 {
+		t.Fatalf("expected a consistent graph, got %v", errs)
+	}
+	// corrupt the storage behind the graph's back
+	storage.Delete("nodes/user", "lacee")
+	storage.Delete("from/user", "coleman")
+	storage.Set("nodes/person", "tyler", primitive.Node(tyler.Raw()))
+	storage.Delete("nodes/user", "tyler")
+	var kinds []string
+	for _, err := range g.CheckIntegrity() {
+		kinds = append(kinds, err.Kind)
+	}
+	if strings.Join(kinds, ",") != "dangling_edge,misplaced_node,missing_index" {
+		t.Fatalf("unexpected integrity errors: %v", kinds)
+	}
+	repaired, err := g.Repair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repaired) != 3 {
+		t.Fatalf("expected 3 repairs, got %v", repaired)
+	}
+	if errs := g.CheckIntegrity(); len(errs) != 0 {
+		t.Fatalf("expected a consistent graph after repair, got %v", errs)
+	}
+	if g.EdgeCount() != 1 || !g.HasNode(tyler) {
+		t.Fatal("expected the dangling edge to be deleted & the misplaced node to be restored")
+	}
+	if edges := coleman.FilterEdgesFrom(dagger.AnyType(), func(e *dagger.Edge) bool { return true }); len(edges) != 1 || edges[0].ID() != friend.ID() {
+		t.Fatal("expected the missing index entry to be restored")
+	}
+}
+
+func TestHealthHandlers(t *testing.T) {
+	storage := primitive.NewMemoryStorage()
+	g := dagger.NewGraph(dagger.WithStorage(storage))
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman"})
+	tyler := g.NewNode(map[string]interface{}{"_type": "user", "_id": "tyler"})
+	lacee := g.NewNode(map[string]interface{}{"_type": "user", "_id": "lacee"})
+	if _, err := coleman.Connect(tyler, "friend", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tyler.Connect(lacee, "friend", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.DelNode(coleman); err != nil {
+		t.Fatal(err)
+	}
+	probe := func(handler http.Handler) (int, *primitive.Health) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		var health primitive.Health
+		if err := json.NewDecoder(w.Body).Decode(&health); err != nil {
+			t.Fatal(err)
+		}
+		return w.Code, &health
+	}
+	code, health := probe(g.ReadyHandler())
+	if code != http.StatusOK || health.Status != primitive.HealthOK || health.Nodes != 2 || health.Edges != 1 {
+		t.Fatalf("expected a healthy graph of 2 nodes & 1 edge, got %v %+v", code, health)
+	}
+	if nodes, edges := g.NodeTypeCounts(), g.EdgeTypeCounts(); len(nodes) != 1 || nodes["user"] != 2 || len(edges) != 1 || edges["friend"] != 1 {
+		t.Fatalf("expected 2 users & 1 friend edge, got %v & %v", nodes, edges)
+	}
+	// a graph opened on the storage picks up its counts
+	reopened := dagger.NewGraph(dagger.WithStorage(storage))
+	if health := reopened.Health(); health.Nodes != 2 || health.Edges != 1 || reopened.NodeTypeCounts()["user"] != 2 {
+		t.Fatalf("expected the reopened graph to count 2 nodes & 1 edge, got %+v", health)
+	}
+
+	// dangling edges left by storage changed behind the graph's back are reported once a scan finds them
+	storage.Delete("nodes/user", "lacee")
+	if errs := g.CheckIntegrity(); len(errs) != 1 {
+		t.Fatalf("expected a dangling edge, got %v", errs)
+	}
+	if code, health := probe(g.ReadyHandler()); code != http.StatusServiceUnavailable || health.Status != primitive.HealthDegraded || health.DanglingEdges != 1 {
+		t.Fatalf("expected the graph not to be ready, got %v %+v", code, health)
+	}
+	if code, health := probe(g.HealthHandler()); code != http.StatusOK || health.Nodes != 1 {
+		t.Fatalf("expected the health handler to report the degraded graph, got %v %+v", code, health)
+	}
+	if _, err := g.Repair(); err != nil {
+		t.Fatal(err)
+	}
+	if code, health := probe(g.ReadyHandler()); code != http.StatusOK || health.Edges != 0 || health.DanglingEdges != 0 {
+		t.Fatalf("expected the repaired graph to be ready, got %v %+v", code, health)
+	}
+	if nodes, edges := g.NodeTypeCounts(), g.EdgeTypeCounts(); nodes["user"] != 1 || len(edges) != 0 {
+		t.Fatalf("expected the repaired graph to be recounted by type, got %v & %v", nodes, edges)
+	}
+	if health := g.Health(); health.Storage != "memory" || health.IntegrityErrors != 0 || health.IntegrityCheckedAt == nil || health.LastSnapshot != nil || health.WAL != nil {
+		t.Fatalf("expected the last integrity check but no snapshot or wal to be reported, got %+v", health)
+	}
+	if g.NodeCount() != 1 || g.EdgeCount() != 0 {
+		t.Fatalf("expected the counts to match the repaired graph, got %v nodes & %v edges", g.NodeCount(), g.EdgeCount())
+	}
+
+	dir := t.TempDir()
+	if _, err := g.WriteSnapshot(dir, 1); err != nil {
+		t.Fatal(err)
+	}
+	if health := g.Health(); health.LastSnapshot == nil || health.SnapshotAge < 0 || health.SnapshotAge > time.Minute {
+		t.Fatalf("expected the snapshot's age to be reported, got %+v", health)
+	}
+	wal, err := primitive.OpenWAL(filepath.Join(dir, "wal.log"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := primitive.NewGraph()
+	p.SetWAL(wal)
+	logged := dagger.NewGraph(dagger.WithPrimitive(p))
+	logged.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman"})
+	if code, health := probe(logged.ReadyHandler()); code != http.StatusOK || health.WAL == nil || health.WAL.Entries != 1 || health.WAL.Unsynced != 1 {
+		t.Fatalf("expected the wal's entries to be reported, got %v %+v", code, health)
+	}
+	// a wal that can no longer be appended to fails readiness: changes are no longer being persisted
+	if err := wal.Close(); err != nil {
+		t.Fatal(err)
+	}
+	logged.AddNode(map[string]interface{}{"_type": "user", "_id": "tyler"})
+	if code, health := probe(logged.ReadyHandler()); code != http.StatusServiceUnavailable || health.Status != primitive.HealthFailing || health.WAL.Error == "" {
+		t.Fatalf("expected the graph with a failed wal not to be ready, got %v %+v", code, health)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	g := dagger.NewGraph(dagger.WithHistory(3))
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman", "status": "new"})
+	for _, status := range []string{"active", "suspended", "active"} {
+		if err := coleman.Patch(map[string]interface{}{"status": status}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	history := coleman.History()
+	if len(history) != 3 {
+		t.Fatalf("expected 3 retained revisions, got %d", len(history))
+	}
+	var statuses []string
+	for _, r := range history {
+		statuses = append(statuses, fmt.Sprintf("%d:%s", r.Revision, r.Node.GetString("status")))
+	}
+	if strings.Join(statuses, ",") != "2:active,3:suspended,4:active" {
+		t.Fatalf("unexpected history: %v", statuses)
+	}
+	if _, ok := coleman.AtRevision(1); ok {
+		t.Fatal("expected the oldest revision to be dropped")
+	}
+	past, ok := coleman.AtRevision(3)
+	if !ok || past.GetString("status") != "suspended" {
+		t.Fatalf("unexpected revision: %v", past)
+	}
+	past.Set("status", "deleted")
+	if again, _ := coleman.AtRevision(3); again.GetString("status") != "suspended" {
+		t.Fatal("expected revisions to be immutable")
+	}
+	if len(dagger.NewGraph().NewNode(map[string]interface{}{"_type": "user"}).History()) != 0 {
+		t.Fatal("expected history to be disabled by default")
+	}
+}
+
+func TestAsOf(t *testing.T) {
+	g := dagger.NewGraph()
+	if _, err := g.AsOf(time.Now()); !errors.Is(err, dagger.ErrNoWAL) {
+		t.Fatalf("expected ErrNoWAL, got %v", err)
+	}
+	if err := g.Recover(filepath.Join(t.TempDir(), "graph.wal")); err != nil {
+		t.Fatal(err)
+	}
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman", "status": "active"})
+	tyler := g.NewNode(map[string]interface{}{"_type": "user", "_id": "tyler"})
+	if _, err := coleman.Connect(tyler, "friend", false); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	before := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	if err := coleman.Patch(map[string]interface{}{"status": "suspended"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tyler.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	past, err := g.AsOf(before)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if past.NodeCount() != 2 || past.EdgeCount() != 1 {
+		t.Fatalf("expected 2 nodes & 1 edge, got %d & %d", past.NodeCount(), past.EdgeCount())
+	}
+	n, ok := past.GetNode(coleman)
+	if !ok || n.GetString("status") != "active" {
+		t.Fatal("expected the node's past attributes")
+	}
+	now, err := g.AsOf(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if now.NodeCount() != 1 || now.EdgeCount() != 0 {
+		t.Fatalf("expected the current state, got %d nodes & %d edges", now.NodeCount(), now.EdgeCount())
+	}
+}
+
+type recordingTracer struct {
+	spans []string
+}
+
+func (r *recordingTracer) Tracer(name string) dagger.Tracer {
+	return r
+}
+
+type spanKey struct{}
+
+func (r *recordingTracer) Start(ctx context.Context, spanName string, attributes map[string]string) (context.Context, dagger.Span) {
+	var pairs []string
+	for k, v := range attributes {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	span := spanName
+	if parent, ok := ctx.Value(spanKey{}).(string); ok {
+		span = parent + ">" + span
+	}
+	if len(pairs) > 0 {
+		span += "(" + strings.Join(pairs, ",") + ")"
+	}
+	r.spans = append(r.spans, span)
+	return context.WithValue(ctx, spanKey{}, spanName), &recordedSpan{}
+}
+
+type recordedSpan struct{}
+
+func (s *recordedSpan) End() {}
+
+func TestTracing(t *testing.T) {
+	tracer := &recordingTracer{}
+	g := dagger.NewGraph(dagger.WithTracerProvider(tracer))
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman"})
+	charlie := g.NewNode(map[string]interface{}{"_type": "dog", "_id": "charlie"})
+	if _, err := coleman.Connect(charlie, "pet", false); err != nil {
+		t.Fatal(err)
+	}
+	g.RangeNodeTypes(dagger.StringType("dog"), func(n *dagger.Node) bool { return true })
+	coleman.BFS(1, func(n *dagger.Node) bool { return true })
+	ctx := context.WithValue(context.Background(), spanKey{}, "request")
+	if err := g.RangeEdgesCtx(ctx, func(e *dagger.Edge) bool { return true }); err != nil {
+		t.Fatal(err)
+	}
+	if err := charlie.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"dagger.AddEdge(dagger.edge.type=pet,dagger.from.type=user,dagger.to.type=dog)",
+		"dagger.RangeNodeTypes(dagger.node.type=dog)",
+		"dagger.BFS(dagger.node.type=user)",
+		"request>dagger.RangeEdges",
+	}
+	if strings.Join(tracer.spans[:4], "|") != strings.Join(want, "|") {
+		t.Fatalf("unexpected spans: %v", tracer.spans)
+	}
+	if tracer.spans[len(tracer.spans)-1] != "dagger.DelNode(dagger.node.type=dog)" {
+		t.Fatalf("expected a DelNode span, got %v", tracer.spans)
+	}
+	tracer.spans = nil
+	if _, err := g.Query("MATCH (u:user) RETURN u"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.NewQuery().Nodes("user").Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ExportProto(io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{
+		"dagger.Query",
+		"dagger.RangeNodeTypes(dagger.node.type=user)",
+		"dagger.ExecuteQuery",
+		"dagger.RangeNodeTypes(dagger.node.type=user)",
+		"dagger.Encode(dagger.codec=encoding.Protobuf)",
+	}
+	if strings.Join(tracer.spans, "|") != strings.Join(want, "|") {
+		t.Fatalf("unexpected spans: %v", tracer.spans)
+	}
+}
+
+func TestPagination(t *testing.T) {
+	g := dagger.NewGraph()
+	var previous *dagger.Node
+	for i := 0; i < 7; i++ {
+		n := g.NewNode(map[string]interface{}{"_type": "user", "_id": fmt.Sprintf("user-%d", i)})
+		if previous != nil {
+			if _, err := previous.Connect(n, "next", false); err != nil {
+				t.Fatal(err)
+			}
+		}
+		previous = n
+	}
+	g.NewNode(map[string]interface{}{"_type": "dog", "_id": "charlie"})
+	var pages []string
+	cursor := ""
+	for {
+		nodes, next := g.NodesPage("user", cursor, 3)
+		var ids []string
+		for _, n := range nodes {
+			ids = append(ids, n.ID())
+		}
+		pages = append(pages, strings.Join(ids, ","))
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if strings.Join(pages, "|") != "user-0,user-1,user-2|user-3,user-4,user-5|user-6" {
+		t.Fatalf("unexpected pages: %v", pages)
+	}
+	var edges int
+	cursor = ""
+	for {
+		page, next := g.EdgesPage("next", cursor, 4)
+		edges += len(page)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if edges != 6 {
+		t.Fatalf("expected 6 paginated edges, got %d", edges)
+	}
+	if all, next := g.NodesPage("user", "", 0); len(all) != 7 || next != "" {
+		t.Fatal("expected a non-positive limit to return every node")
+	}
+}
+
+func TestOrder(t *testing.T) {
+	ids := []string{"tyler", "coleman", "lacee", "adam"}
+	build := func(order dagger.Order) *dagger.Graph {
+		g := dagger.NewGraph(dagger.WithOrder(order))
+		hub := g.NewNode(map[string]interface{}{"_type": "hub", "_id": "hub"})
+		for _, id := range ids {
+			n := g.NewNode(map[string]interface{}{"_type": "user", "_id": id})
+			if _, err := hub.Connect(n, "member", false); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return g
+	}
+	users := func(g *dagger.Graph) string {
+		var got []string
+		g.RangeNodeTypes(dagger.StringType("user"), func(n *dagger.Node) bool {
+			got = append(got, n.ID())
+			return true
+		})
+		return strings.Join(got, ",")
+	}
+	members := func(g *dagger.Graph) string {
+		var got []string
+		g.EdgesFrom(&dagger.ForeignKey{XType: "hub", XID: "hub"}, dagger.AnyType(), func(e *dagger.Edge) bool {
+			got = append(got, e.To().ID())
+			return true
+		})
+		return strings.Join(got, ",")
+	}
+	inserted := build(dagger.InsertionOrder)
+	if got := users(inserted); got != "tyler,coleman,lacee,adam" {
+		t.Fatalf("unexpected insertion order: %s", got)
+	}
+	if got := members(inserted); got != "tyler,coleman,lacee,adam" {
+		t.Fatalf("unexpected edge insertion order: %s", got)
+	}
+	lacee, _ := inserted.GetNode(&dagger.ForeignKey{XType: "user", XID: "lacee"})
+	if err := lacee.Patch(map[string]interface{}{"name": "lacee"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := users(inserted); got != "tyler,coleman,lacee,adam" {
+		t.Fatalf("expected patches to keep their position: %s", got)
+	}
+	sorted := build(dagger.IDOrder)
+	if got := users(sorted); got != "adam,coleman,lacee,tyler" {
+		t.Fatalf("unexpected id order: %s", got)
+	}
+	var types []string
+	sorted.RangeNodes(func(n *dagger.Node) bool {
+		types = append(types, n.Type())
+		return true
+	})
+	if types[0] != "hub" || types[1] != "user" {
+		t.Fatalf("expected nodes sorted by type first: %v", types)
+	}
+	first := bytes.NewBuffer(nil)
+	second := bytes.NewBuffer(nil)
+	if err := sorted.ExportJSON(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := sorted.ExportJSON(second); err != nil {
+		t.Fatal(err)
+	}
+	if first.String() != second.String() {
+		t.Fatal("expected exports to be deterministic")
+	}
+}
+
+func TestCache(t *testing.T) {
+	cache := primitive.NewCache[int]()
+	for i := 0; i < 100; i++ {
+		cache.Set("even", fmt.Sprint(i*2), i*2)
+	}
+	cache.Set("odd", "1", 1)
+	if cache.Len("even") != 100 {
+		t.Fatalf("expected 100 even keys, got %v", cache.Len("even"))
+	}
+	if value, ok := cache.Get("even", "42"); !ok || value != 42 {
+		t.Fatalf("expected 42, got %v", value)
+	}
+	if _, ok := cache.Get("odd", "42"); ok {
+		t.Fatal("expected keys to be namespaced")
+	}
+	sum := 0
+	cache.Range("even", func(key string, value int) bool {
+		sum += value
+		cache.Delete("even", key)
+		return true
+	})
+	if sum != 9900 || cache.Len("even") != 0 {
+		t.Fatalf("expected to range & delete every even key, got sum %v & %v keys", sum, cache.Len("even"))
+	}
+	count := 0
+	cache.Range(primitive.AnyType, func(key string, value int) bool {
+		count++
+		return true
+	})
+	if count != 1 {
+		t.Fatalf("expected 1 key in every namespace, got %v", count)
+	}
+}
+
+func TestReverseEdge(t *testing.T) {
+	g := dagger.NewGraph()
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "name": "coleman"})
+	tyler := g.NewNode(map[string]interface{}{"_type": "user", "name": "tyler"})
+	friend, err := coleman.Connect(tyler, "friend", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.EdgeCount() != 2 {
+		t.Fatalf("expected mutual edges to be distinct, got %v edges", g.EdgeCount())
+	}
+	reverse, ok := friend.Reverse()
+	if !ok {
+		t.Fatal("expected a reverse edge")
+	}
+	if reverse.From().ID() != tyler.ID() || reverse.To().ID() != coleman.ID() {
+		t.Fatal("expected the reverse edge to point from tyler to coleman")
+	}
+	if back, ok := reverse.Reverse(); !ok || back.ID() != friend.ID() {
+		t.Fatal("expected the reverse of the reverse edge to be the edge")
+	}
+	if err := friend.Patch(map[string]interface{}{"since": "2019"}); err != nil {
+		t.Fatal(err)
+	}
+	if reverse.GetString("since") != "" {
+		t.Fatal("expected a plain patch to leave the reverse edge untouched")
+	}
+	if err := friend.Patch(map[string]interface{}{"close": true}, dagger.PatchReverse()); err != nil {
+		t.Fatal(err)
+	}
+	if !reverse.GetBool("close") || !friend.GetBool("close") {
+		t.Fatal("expected the patch to propagate to the reverse edge")
+	}
+	follows, err := coleman.Connect(tyler, "follows", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := follows.Reverse(); ok {
+		t.Fatal("expected a one way edge to have no reverse edge")
+	}
+	admin := g.NewNode(map[string]interface{}{"_type": "admin"})
+	managed, err := coleman.Connect(admin, "friend", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// only the reverse edge violates the schema, so neither edge may be patched
+	g.RegisterSchema("admin", dagger.Schema{EdgeTypes: []string{"follows"}})
+	if err := managed.Patch(map[string]interface{}{"close": true}, dagger.PatchReverse()); !errors.Is(err, dagger.ErrSchemaViolation) {
+		t.Fatalf("expected a schema violation, got %v", err)
+	}
+	if managed.Get("close") != nil {
+		t.Fatal("expected a rejected patch to leave the edge unchanged")
+	}
+}
+
+func TestNeighbors(t *testing.T) {
+	g := dagger.NewGraph()
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman"})
+	tyler := g.NewNode(map[string]interface{}{"_type": "user", "_id": "tyler"})
+	lacee := g.NewNode(map[string]interface{}{"_type": "user", "_id": "lacee"})
+	for _, c := range []struct {
+		from, to *dagger.Node
+		typ      string
+	}{
+		{coleman, tyler, "friend"},
+		{coleman, lacee, "friend"},
+		{coleman, lacee, "fiance"},
+		{tyler, coleman, "friend"},
+	} {
+		if _, err := c.from.Connect(c.to, c.typ, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ids := func(nodes []*dagger.Node) string {
+		var ids []string
+		for _, n := range nodes {
+			ids = append(ids, n.ID())
+		}
+		return strings.Join(ids, ",")
+	}
+	if got := ids(coleman.Neighbors("friend")); got != "lacee,tyler" {
+		t.Fatalf("expected lacee,tyler got %s", got)
+	}
+	if got := ids(coleman.Neighbors("*")); got != "lacee,tyler" {
+		t.Fatalf("expected neighbors over any edge type to be deduplicated, got %s", got)
+	}
+	if got := ids(lacee.InNeighbors("fiance")); got != "coleman" {
+		t.Fatalf("expected coleman got %s", got)
+	}
+	if got := ids(lacee.Neighbors("friend")); got != "" {
+		t.Fatalf("expected no neighbors got %s", got)
+	}
+	if d := coleman.Degree(dagger.Outgoing, "*"); d != 3 {
+		t.Fatalf("expected an out degree of 3 got %v", d)
+	}
+	if d := coleman.Degree(dagger.Incoming, "friend"); d != 1 {
+		t.Fatalf("expected an in degree of 1 got %v", d)
+	}
+	if d := coleman.Degree(dagger.Both, "friend"); d != 3 {
+		t.Fatalf("expected a degree of 3 got %v", d)
+	}
+}
+
+func TestFindOrCreate(t *testing.T) {
+	for _, indexed := range []bool{false, true} {
+		g := dagger.NewGraph()
+		if indexed {
+			if err := g.CreateIndex("user", "email"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		g.NewNode(map[string]interface{}{"_type": "user", "email": "tyler@example.com"})
+		var (
+			wg      sync.WaitGroup
+			created int32
+			ids     = make([]string, 10)
+		)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				n, ok, err := g.FindOrCreate("user", map[string]interface{}{"email": "coleman@example.com"}, map[string]interface{}{"name": "coleman"})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if ok {
+					atomic.AddInt32(&created, 1)
+				}
+				ids[i] = n.ID()
+			}(i)
+		}
+		wg.Wait()
+		if created != 1 {
+			t.Fatalf("expected the node to be created once, got %v", created)
+		}
+		for _, id := range ids {
+			if id != ids[0] {
+				t.Fatal("expected every caller to get the same node")
+			}
+		}
+		n, ok, err := g.FindOrCreate("user", map[string]interface{}{"email": "coleman@example.com"}, nil)
+		if err != nil || ok {
+			t.Fatalf("expected to find the node, got %v %v", ok, err)
+		}
+		if n.GetString("name") != "coleman" {
+			t.Fatalf("expected the created node to have the create attributes, got %v", n.Raw())
+		}
+		if g.NodeCount() != 2 {
+			t.Fatalf("expected 2 nodes, got %v", g.NodeCount())
+		}
+	}
+}
+
+func TestTypedAccessors(t *testing.T) {
+	g := dagger.NewGraph()
+	joined := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	n := g.NewNode(map[string]interface{}{
+		"_type":  "user",
+		"name":   "coleman",
+		"age":    float64(29),
+		"score":  4.5,
+		"admin":  false,
+		"joined": joined.Format(time.RFC3339),
+		"tags":   []interface{}{"go", "graphs"},
+	})
+	if name, ok := n.GetStringOK("name"); !ok || name != "coleman" {
+		t.Fatalf("expected coleman got %v", name)
+	}
+	if _, ok := n.GetStringOK("age"); ok {
+		t.Fatal("expected a number not to be a string")
+	}
+	if age, ok := n.GetIntOK("age"); !ok || age != 29 {
+		t.Fatalf("expected 29 got %v", age)
+	}
+	if _, ok := n.GetIntOK("score"); ok {
+		t.Fatal("expected a fractional number not to be an int")
+	}
+	if score, ok := n.GetFloatOK("score"); !ok || score != 4.5 {
+		t.Fatalf("expected 4.5 got %v", score)
+	}
+	if admin, ok := n.GetBoolOK("admin"); !ok || admin {
+		t.Fatal("expected admin to be present & false")
+	}
+	if _, ok := n.GetBoolOK("missing"); ok {
+		t.Fatal("expected a missing attribute not to be ok")
+	}
+	if !n.GetTime("joined").Equal(joined) {
+		t.Fatalf("expected %v got %v", joined, n.GetTime("joined"))
+	}
+	if tags := n.GetStringSlice("tags"); strings.Join(tags, ",") != "go,graphs" {
+		t.Fatalf("expected go,graphs got %v", tags)
+	}
+	if n.MustGetInt("age") != 29 {
+		t.Fatal("expected MustGetInt to return 29")
+	}
+	for key, want := range map[string]error{"missing": dagger.ErrMissingAttribute, "name": dagger.ErrAttributeType} {
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, want) {
+					t.Fatalf("expected MustGetBool(%q) to panic with %v, got %v", key, want, err)
+				}
+			}()
+			n.MustGetBool(key)
+		}()
+	}
+}
+
+func TestAttributePaths(t *testing.T) {
+	g := dagger.NewGraph()
+	n := g.NewNode(map[string]interface{}{
+		"_type": "user",
+		"address": map[string]interface{}{
+			"city": "Austin",
+		},
+		"phones":  []interface{}{"555-0100", map[string]interface{}{"kind": "work"}},
+		"a/b~c.d": "escaped",
+	})
+	if city, ok := n.GetPath("address.city"); !ok || city != "Austin" {
+		t.Fatalf("expected Austin got %v", city)
+	}
+	if kind, ok := n.GetPath("phones.1.kind"); !ok || kind != "work" {
+		t.Fatalf("expected work got %v", kind)
+	}
+	if _, ok := n.GetPath("address.zip"); ok {
+		t.Fatal("expected a missing path not to be ok")
+	}
+	if val, ok := n.GetPointer("/a~1b~0c.d"); !ok || val != "escaped" {
+		t.Fatalf("expected escaped got %v", val)
+	}
+	before := n.Copy()
+	if err := n.PatchPath("address.city", "Denver"); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.PatchPointer("/address/geo/lat", 39.7); err != nil {
+		t.Fatal(err)
+	}
+	if city, _ := n.GetPath("address.city"); city != "Denver" {
+		t.Fatalf("expected Denver got %v", city)
+	}
+	if lat, _ := n.GetPath("address.geo.lat"); lat != 39.7 {
+		t.Fatalf("expected missing maps to be created, got %v", lat)
+	}
+	if city, _ := before.GetPath("address.city"); city != "Austin" {
+		t.Fatal("expected the patch not to modify copies of the node")
+	}
+	if err := n.PatchPath("address.city.name", "Denver"); !errors.Is(err, dagger.ErrInvalidPath) {
+		t.Fatalf("expected ErrInvalidPath got %v", err)
+	}
+	if err := n.PatchPath("phones.5", "555-0101"); !errors.Is(err, dagger.ErrInvalidPath) {
+		t.Fatalf("expected ErrInvalidPath got %v", err)
+	}
+	if err := n.PatchPointer("address/city", "Denver"); !errors.Is(err, dagger.ErrInvalidPath) {
+		t.Fatalf("expected ErrInvalidPath got %v", err)
+	}
+}
+
+func TestExportProto(t *testing.T) {
+	source := dagger.NewGraph()
+	coleman := source.NewNode(map[string]interface{}{
+		"_type":   "user",
+		"name":    "coleman",
+		"age":     -30,
+		"score":   4.5,
+		"admin":   true,
+		"address": map[string]interface{}{"city": "Denver"},
+		"tags":    []interface{}{"go"},
+	})
+	for i := 0; i < 100; i++ {
+		friend := source.NewNode(map[string]interface{}{"_type": "user", "name": fmt.Sprintf("friend%v", i)})
+		if _, err := coleman.Connect(friend, "friend", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	proto := bytes.NewBuffer(nil)
+	if err := source.ExportProto(proto); err != nil {
+		t.Fatal(err)
+	}
+	js := bytes.NewBuffer(nil)
+	if err := source.ExportJSON(js); err != nil {
+		t.Fatal(err)
+	}
+	if proto.Len() >= js.Len() {
+		t.Fatalf("expected the protobuf export(%v bytes) to be smaller than the json export(%v bytes)", proto.Len(), js.Len())
+	}
+	dest := dagger.NewGraph()
+	if err := dest.ImportProto(proto); err != nil {
+		t.Fatal(err)
+	}
+	if dest.Hash() != source.Hash() {
+		t.Fatal("expected the imported graph to match the exported graph")
+	}
+	n, ok := dest.GetNode(coleman)
+	if !ok {
+		t.Fatal("expected coleman to be imported")
+	}
+	if city, _ := n.GetPath("address.city"); city != "Denver" || n.GetInt("age") != -30 {
+		t.Fatalf("unexpected attributes: %v", n.Raw())
+	}
+	if err := dest.ImportProto(bytes.NewReader([]byte{0x0a, 0x05, 0x01})); err == nil {
+		t.Fatal("expected a truncated message to fail")
+	}
+}
+
+func TestSerializationCodecs(t *testing.T) {
+	source := dagger.NewGraph()
+	coleman := source.NewNode(map[string]interface{}{
+		"_type":   "user",
+		"name":    strings.Repeat("c", 300),
+		"age":     -70000,
+		"big":     uint64(1 << 40),
+		"score":   4.5,
+		"admin":   true,
+		"nothing": nil,
+		"address": map[string]interface{}{"city": "Denver", "zip": 80202},
+		"tags":    []interface{}{"go", 1, false},
+	})
+	for i := 0; i < 20; i++ {
+		friend := source.NewNode(map[string]interface{}{"_type": "user", "name": fmt.Sprintf("friend%v", i)})
+		if _, err := coleman.Connect(friend, "friend", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, codec := range map[string]encoding.Codec{"gob": encoding.Gob{}, "msgpack": encoding.MsgPack{}} {
+		buf := bytes.NewBuffer(nil)
+		if err := source.Encode(buf, codec); err != nil {
+			t.Fatal(err)
+		}
+		dest := dagger.NewGraph()
+		if err := dest.Decode(buf, codec); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if dest.Hash() != source.Hash() {
+			t.Fatalf("%s: expected the decoded graph to match the encoded graph", name)
+		}
+		n, _ := dest.GetNode(coleman)
+		if zip, _ := n.GetPath("address.zip"); fmt.Sprint(zip) != "80202" || n.GetInt("age") != -70000 {
+			t.Fatalf("%s: unexpected attributes: %v", name, n.Raw())
+		}
+	}
+}
+
+func TestCompression(t *testing.T) {
+	source := dagger.NewGraph()
+	coleman := source.NewNode(map[string]interface{}{"_type": "user", "name": "coleman"})
+	for i := 0; i < 100; i++ {
+		friend := source.NewNode(map[string]interface{}{"_type": "user", "name": "friend"})
+		if _, err := coleman.Connect(friend, "friend", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	plain := bytes.NewBuffer(nil)
+	if err := source.ExportJSON(plain); err != nil {
+		t.Fatal(err)
+	}
+	compressed := bytes.NewBuffer(nil)
+	if err := source.ExportJSONGzip(compressed); err != nil {
+		t.Fatal(err)
+	}
+	if compressed.Len()*3 > plain.Len() {
+		t.Fatalf("expected the gzip export(%v bytes) to be much smaller than the json export(%v bytes)", compressed.Len(), plain.Len())
+	}
+	for name, r := range map[string]io.Reader{"gzip": compressed, "plain": plain} {
+		dest := dagger.NewGraph()
+		if err := dest.ImportJSON(r); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if dest.Hash() != source.Hash() {
+			t.Fatalf("%s: expected the imported graph to match the exported graph", name)
+		}
+	}
+	buf := bytes.NewBuffer(nil)
+	if err := source.Encode(buf, encoding.Gzip(encoding.MsgPack{})); err != nil {
+		t.Fatal(err)
+	}
+	dest := dagger.NewGraph()
+	if err := dest.Decode(buf, encoding.MsgPack{}); err != nil {
+		t.Fatal(err)
+	}
+	if dest.Hash() != source.Hash() {
+		t.Fatal("expected the decoded graph to match the encoded graph")
+	}
+	zstd := bytes.NewReader([]byte{0x28, 0xb5, 0x2f, 0xfd, 0x00})
+	if err := dagger.NewGraph().ImportJSON(zstd); !errors.Is(err, encoding.ErrUnsupportedCompression) {
+		t.Fatalf("expected ErrUnsupportedCompression got %v", err)
+	}
+}
+
+func TestExportSince(t *testing.T) {
+	if _, err := dagger.NewGraph().ExportSince(0, io.Discard); !errors.Is(err, dagger.ErrChangesNotTracked) {
+		t.Fatalf("expected ErrChangesNotTracked, got %v", err)
+	}
+	source := dagger.NewGraph(dagger.TrackChanges())
+	coleman := source.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman", "name": "coleman"})
+	tyler := source.NewNode(map[string]interface{}{"_type": "user", "_id": "tyler", "name": "tyler"})
+	if _, err := coleman.Connect(tyler, "friend", false); err != nil {
+		t.Fatal(err)
+	}
+	full := bytes.NewBuffer(nil)
+	checkpoint, err := source.ExportSince(0, full)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint != source.CurrentCheckpoint() {
+		t.Fatalf("expected the returned checkpoint to be the current checkpoint")
+	}
+
+	lacee := source.NewNode(map[string]interface{}{"_type": "user", "_id": "lacee", "name": "lacee"})
+	if err := coleman.Patch(map[string]interface{}{"name": "colemanword"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := coleman.Connect(lacee, "fiance", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := tyler.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	incremental := bytes.NewBuffer(nil)
+	next, err := source.ExportSince(checkpoint, incremental)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var changes primitive.Changes
+	if err := json.Unmarshal(incremental.Bytes(), &changes); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes.Nodes) != 2 || len(changes.Edges) != 1 || len(changes.DeletedNodes) != 1 || len(changes.DeletedEdges) != 1 {
+		t.Fatalf("expected only the changes since the checkpoint, got %v nodes, %v edges, %v deleted nodes & %v deleted edges",
+			len(changes.Nodes), len(changes.Edges), len(changes.DeletedNodes), len(changes.DeletedEdges))
+	}
+	if changes.Nodes[0].ID() != "lacee" || changes.Nodes[1].ID() != "coleman" {
+		t.Fatal("expected the changes in the order they were made")
+	}
+
+	restored := dagger.NewGraph()
+	for _, export := range []*bytes.Buffer{full, incremental} {
+		if err := restored.ImportChanges(bytes.NewReader(export.Bytes())); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if restored.Hash() != source.Hash() {
+		t.Fatal("expected the full & incremental exports to restore the graph")
+	}
+	invalid := `{"deleted_nodes":[{"_type":"user","_id":"coleman"}],"edges":[{"node":{"_type":"friend","_id":"x"},"from":{"_type":"user","_id":"lacee"},"to":{"_type":"user","_id":"missing"}}]}`
+	if err := restored.ImportChanges(strings.NewReader(invalid)); err == nil {
+		t.Fatal("expected an error importing an edge to a missing node")
+	}
+	if restored.Hash() != source.Hash() {
+		t.Fatal("expected rejected changes to leave the graph untouched")
+	}
+
+	empty := bytes.NewBuffer(nil)
+	if last, err := source.ExportSince(next, empty); err != nil || last != next {
+		t.Fatalf("expected no changes since the last checkpoint, got %v %v", last, err)
+	}
+	source.PruneChanges(next)
+	if _, err := source.ExportSince(checkpoint, io.Discard); !errors.Is(err, dagger.ErrCheckpointPruned) {
+		t.Fatalf("expected ErrCheckpointPruned got %v", err)
+	}
+}
+
+func TestAutoSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	g := dagger.NewGraph()
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "name": "coleman"})
+	if _, err := g.EnableAutoSnapshot(dir, time.Second, 0, nil); err == nil {
+		t.Fatal("expected keeping no snapshots to fail")
+	}
+	errs := make(chan error, 1)
+	stop, err := g.EnableAutoSnapshot(dir, 10*time.Millisecond, 2, func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		matches, _ := filepath.Glob(filepath.Join(dir, "snapshot-*.json.gz"))
+		if len(matches) == 2 {
+			break
+		}
+		if len(matches) > 2 {
+			t.Fatalf("expected at most 2 snapshots to be kept, got %v", len(matches))
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for snapshots")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	stop()
+	stop()
+	select {
+	case err := <-errs:
+		t.Fatal(err)
+	default:
+	}
+	if err := coleman.Patch(map[string]interface{}{"name": "colemanword"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.WriteSnapshot(dir, 2); err != nil {
+		t.Fatal(err)
+	}
+	restored := dagger.NewGraph()
+	ok, err := restored.RestoreSnapshot(dir)
+	if err != nil || !ok {
+		t.Fatalf("expected a snapshot to be restored, got %v %v", ok, err)
+	}
+	if restored.Hash() != g.Hash() {
+		t.Fatal("expected the newest snapshot to be restored")
+	}
+	if ok, err := dagger.NewGraph().RestoreSnapshot(filepath.Join(dir, "missing")); ok || err != nil {
+		t.Fatalf("expected no snapshot to restore, got %v %v", ok, err)
+	}
+}
+
+func TestCSVImport(t *testing.T) {
+	g := dagger.NewGraph()
+	nodes := strings.Join([]string{
+		"userId:ID(user),name,age:int,score:float,admin:boolean,tags:string[],:LABEL,notes:IGNORE",
+		"u1,coleman,30,1.5,true,a;b,user,skip",
+		"u2,tyler,,2,false,,,",
+	}, "\n")
+	if err := g.ImportNodesCSV(strings.NewReader(nodes), dagger.CSVMapping{}); err != nil {
+		t.Fatal(err)
+	}
+	coleman, ok := g.GetNode(&dagger.ForeignKey{XType: "user", XID: "u1"})
+	if !ok {
+		t.Fatal("expected node user.u1")
+	}
+	if coleman.GetString("name") != "coleman" || coleman.GetInt("age") != 30 || coleman.MustGetFloat("score") != 1.5 ||
+		!coleman.GetBool("admin") || coleman.GetString("userId") != "u1" {
+		t.Fatalf("unexpected attributes: %v", coleman.Raw())
+	}
+	if tags := coleman.GetStringSlice("tags"); len(tags) != 2 || tags[1] != "b" {
+		t.Fatalf("unexpected tags: %v", tags)
+	}
+	if coleman.Get("notes") != nil {
+		t.Fatal("expected ignored column to be skipped")
+	}
+	tyler, ok := g.GetNode(&dagger.ForeignKey{XType: "user", XID: "u2"})
+	if !ok {
+		t.Fatal("expected the id space to be used as the type of unlabeled nodes")
+	}
+	if tyler.Get("age") != nil {
+		t.Fatal("expected empty fields to be skipped")
+	}
+	if err := g.ImportNodesCSV(strings.NewReader("petId:ID|name\np1|fido"), dagger.CSVMapping{Type: "dog", Delimiter: '|'}); err != nil {
+		t.Fatal(err)
+	}
+	edges := strings.Join([]string{
+		":START_ID(user),:END_ID,:TYPE,since:int",
+		"u1,p1,owns,2020",
+		"u1,u2,,2021",
+	}, "\n")
+	if err := g.ImportEdgesCSV(strings.NewReader(edges), dagger.CSVMapping{Type: "knows"}); err != nil {
+		t.Fatal(err)
+	}
+	if between := g.GetEdgesBetween(coleman, &dagger.ForeignKey{XType: "dog", XID: "p1"}, "owns"); len(between) != 1 || between[0].GetInt("since") != 2020 {
+		t.Fatalf("expected 1 owns edge, got %v", len(between))
+	}
+	if between := g.GetEdgesBetween(coleman, tyler, "knows"); len(between) != 1 {
+		t.Fatalf("expected edges without a :TYPE to use the mapping's type, got %v", len(between))
+	}
+	if err := g.ImportEdgesCSV(strings.NewReader(":START_ID,:END_ID,:TYPE\nu1,missing,owns"), dagger.CSVMapping{}); err == nil {
+		t.Fatal("expected an edge to a missing node to fail")
+	}
+	if err := g.ImportNodesCSV(strings.NewReader(":ID,age:int\nu3,old"), dagger.CSVMapping{}); err == nil {
+		t.Fatal("expected an invalid int to fail")
+	}
+}
+
+// sqlDB is an in-memory database/sql driver that understands the statements ExportSQL runs
+type sqlDB struct {
+	mu     sync.Mutex
+	tables map[string]*sqlTable
+}
+
+type sqlTable struct {
+	columns []string
+	types   map[string]string
+	rows    []map[string]driver.Value
+}
+
+var (
+	sqlCreate = regexp.MustCompile(`^CREATE TABLE IF NOT EXISTS "([^"]+)" \((.*)\)$`)
+	sqlSelect = regexp.MustCompile(`^SELECT \* FROM "([^"]+)" WHERE 1 = 0$`)
+	sqlAlter  = regexp.MustCompile(`^ALTER TABLE "([^"]+)" ADD COLUMN "([^"]+)" (\w+)$`)
+	sqlDelete = regexp.MustCompile(`^DELETE FROM "([^"]+)"$`)
+	sqlInsert = regexp.MustCompile(`^INSERT INTO "([^"]+)" \((.*)\) VALUES`)
+	sqlColumn = regexp.MustCompile(`"([^"]+)"`)
+	sqlDef    = regexp.MustCompile(`^"([^"]+)" (\w+)`)
+)
+
+func (d *sqlDB) Connect(ctx context.Context) (driver.Conn, error) { return d, nil }
+func (d *sqlDB) Driver() driver.Driver                            { return d }
+func (d *sqlDB) Open(name string) (driver.Conn, error)            { return d, nil }
+func (d *sqlDB) Prepare(query string) (driver.Stmt, error)        { return &sqlStmt{d, query}, nil }
+func (d *sqlDB) Close() error                                     { return nil }
+func (d *sqlDB) Begin() (driver.Tx, error)                        { return d, nil }
+func (d *sqlDB) Commit() error                                    { return nil }
+func (d *sqlDB) Rollback() error                                  { return nil }
+
+type sqlStmt struct {
+	db    *sqlDB
+	query string
+}
+
+func (s *sqlStmt) Close() error  { return nil }
+func (s *sqlStmt) NumInput() int { return -1 }
+
+func (s *sqlStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	if m := sqlCreate.FindStringSubmatch(s.query); m != nil {
+		if s.db.tables[m[1]] == nil {
+			table := &sqlTable{types: map[string]string{}}
+			for _, def := range strings.Split(m[2], ", ") {
+				column := sqlDef.FindStringSubmatch(def)
+				table.columns = append(table.columns, column[1])
+				table.types[column[1]] = column[2]
+			}
+			s.db.tables[m[1]] = table
+		}
+		return driver.RowsAffected(0), nil
+	}
+	if m := sqlAlter.FindStringSubmatch(s.query); m != nil {
+		s.db.tables[m[1]].columns = append(s.db.tables[m[1]].columns, m[2])
+		s.db.tables[m[1]].types[m[2]] = m[3]
+		return driver.RowsAffected(0), nil
+	}
+	if m := sqlDelete.FindStringSubmatch(s.query); m != nil {
+		s.db.tables[m[1]].rows = nil
+		return driver.RowsAffected(0), nil
+	}
+	if m := sqlInsert.FindStringSubmatch(s.query); m != nil {
+		table := s.db.tables[m[1]]
+		row := map[string]driver.Value{}
+		for i, column := range sqlColumn.FindAllStringSubmatch(m[2], -1) {
+			row[column[1]] = args[i]
+		}
+		for _, column := range sqlColumn.FindAllStringSubmatch(m[2], -1) {
+			found := false
+			for _, c := range table.columns {
+				found = found || c == column[1]
+			}
+			if !found {
+				return nil, fmt.Errorf("no such column: %s", column[1])
+			}
+		}
+		for _, existing := range table.rows {
+			if existing["_id"] == row["_id"] {
+				return nil, fmt.Errorf("duplicate primary key: %v", row["_id"])
+			}
+		}
+		table.rows = append(table.rows, row)
+		return driver.RowsAffected(1), nil
+	}
+	return nil, fmt.Errorf("unsupported statement: %s", s.query)
+}
+
+func (s *sqlStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	m := sqlSelect.FindStringSubmatch(s.query)
+	if m == nil || s.db.tables[m[1]] == nil {
+		return nil, fmt.Errorf("unsupported query: %s", s.query)
+	}
+	return &sqlRows{columns: append([]string{}, s.db.tables[m[1]].columns...)}, nil
+}
+
+type sqlRows struct {
+	columns []string
+}
+
+func (r *sqlRows) Columns() []string              { return r.columns }
+func (r *sqlRows) Close() error                   { return nil }
+func (r *sqlRows) Next(dest []driver.Value) error { return io.EOF }
+
+func TestExportSQL(t *testing.T) {
+	g := dagger.NewGraph()
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman", "name": "coleman", "age": 30})
+	tyler := g.NewNode(map[string]interface{}{"_type": "user", "_id": "tyler", "name": "tyler"})
+	if _, err := coleman.Connect(tyler, "friend", false); err != nil {
+		t.Fatal(err)
+	}
+	mem := &sqlDB{tables: map[string]*sqlTable{}}
+	db := sql.OpenDB(mem)
+	defer db.Close()
+	if err := g.ExportSQL(db, dagger.SQLTablePrefix("graph_")); err != nil {
+		t.Fatal(err)
+	}
+	users, friends := mem.tables["graph_node_user"], mem.tables["graph_edge_friend"]
+	if users == nil || len(users.rows) != 2 || friends == nil || len(friends.rows) != 1 {
+		t.Fatalf("expected a table per type, got %v", mem.tables)
+	}
+	if friends.rows[0]["_from_id"] != "coleman" || friends.rows[0]["_to_id"] != "tyler" {
+		t.Fatalf("expected the edge's endpoints, got %v", friends.rows[0])
+	}
+	for _, row := range users.rows {
+		if row["_id"] == "coleman" && row["age"] != int64(30) {
+			t.Fatalf("expected an integer age, got %v", row)
+		}
+	}
+
+	// exporting again replaces the rows instead of failing on the primary key, & adds columns for new attributes
+	if err := tyler.Patch(map[string]interface{}{"email": "tyler@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	g.NewNode(map[string]interface{}{"_type": "user", "_id": "sarah"})
+	if err := g.ExportSQL(db, dagger.SQLTablePrefix("graph_")); err != nil {
+		t.Fatal(err)
+	}
+	if len(users.rows) != 3 || len(friends.rows) != 1 {
+		t.Fatalf("expected the tables to match the graph, got %v users & %v friends", len(users.rows), len(friends.rows))
+	}
+	for _, row := range users.rows {
+		if row["_id"] == "tyler" && row["email"] != "tyler@example.com" {
+			t.Fatalf("expected the new attribute to be exported, got %v", row)
+		}
+	}
+
+	// the columns of a type with a schema are typed by it, even when no node has the attribute or its values vary
+	g.RegisterSchema("account", dagger.Schema{Attributes: map[string]string{"balance": "float", "owner": "string"}})
+	g.NewNode(map[string]interface{}{"_type": "account", "_id": "checking", "balance": 10})
+	g.NewNode(map[string]interface{}{"_type": "account", "_id": "savings", "balance": 2.5})
+	if err := g.ExportSQL(db); err != nil {
+		t.Fatal(err)
+	}
+	accounts := mem.tables["node_account"]
+	if accounts == nil || accounts.types["balance"] != "DOUBLE" || accounts.types["owner"] != "TEXT" || len(accounts.rows) != 2 {
+		t.Fatalf("expected the account columns to follow the schema, got %+v", accounts)
+	}
+}
+
+func TestExportCypher(t *testing.T) {
+	g := dagger.NewGraph()
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman", "name": `coleman "cole"`, "age": 30, "tags": []string{"a", "b"}})
+	charlie := g.NewNode(map[string]interface{}{"_type": "dog", "_id": "charlie", "weight": 25.0, "meta": map[string]interface{}{"breed": "lab"}})
+	edge, err := coleman.Connect(charlie, "pet", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := bytes.NewBuffer(nil)
+	if err := g.ExportCypher(buf); err != nil {
+		t.Fatal(err)
+	}
+	cypher := buf.String()
+	for _, want := range []string{
+		"CREATE (:`user` {`_id`: \"coleman\", `age`: 30, `name`: \"coleman \\\"cole\\\"\", `tags`: [\"a\", \"b\"]});",
+		"CREATE (:`dog` {`_id`: \"charlie\", `meta`: \"{\\\"breed\\\":\\\"lab\\\"}\", `weight`: 25.0});",
+		fmt.Sprintf("MATCH (a:`user` {`_id`: \"coleman\"}), (b:`dog` {`_id`: \"charlie\"}) CREATE (a)-[:`pet` {`_id`: %q}]->(b);", edge.ID()),
+	} {
+		if !strings.Contains(cypher, want) {
+			t.Fatalf("expected %s in:\n%s", want, cypher)
+		}
+	}
+	buf.Reset()
+	if err := g.ExportCypher(buf, dagger.CypherMerge(), dagger.CypherIndexes()); err != nil {
+		t.Fatal(err)
+	}
+	cypher = buf.String()
+	for _, want := range []string{
+		"CREATE INDEX IF NOT EXISTS FOR (n:`dog`) ON (n.`_id`);",
+		"MERGE (n:`user` {`_id`: \"coleman\"}) SET n += {",
+		fmt.Sprintf("MERGE (a)-[r:`pet` {`_id`: %q}]->(b) SET r += {", edge.ID()),
+	} {
+		if !strings.Contains(cypher, want) {
+			t.Fatalf("expected %s in:\n%s", want, cypher)
+		}
+	}
+}
+
+func TestNTriples(t *testing.T) {
+	g := dagger.NewGraph()
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman smith", "name": "coleman \"cole\"\n", "tags": []interface{}{"a", "b"}})
+	charlie := g.NewNode(map[string]interface{}{"_type": "dog", "_id": "charlie", "weight": 25.5})
+	pet, err := coleman.Connect(charlie, "pet", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pet.Patch(map[string]interface{}{"since": 2020}); err != nil {
+		t.Fatal(err)
+	}
+	buf := bytes.NewBuffer(nil)
+	if err := g.ExportNTriples(buf); err != nil {
+		t.Fatal(err)
+	}
+	triples := buf.String()
+	for _, want := range []string{
+		`<urn:dagger:node/user/coleman%20smith> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <urn:dagger:type/user> .`,
+		`<urn:dagger:node/user/coleman%20smith> <urn:dagger:attr/name> "coleman \"cole\"\n" .`,
+		`<urn:dagger:node/dog/charlie> <urn:dagger:attr/weight> "25.5"^^<http://www.w3.org/2001/XMLSchema#double> .`,
+		`<urn:dagger:node/user/coleman%20smith> <urn:dagger:rel/pet> <urn:dagger:node/dog/charlie> .`,
+	} {
+		if !strings.Contains(triples, want) {
+			t.Fatalf("expected %s in:\n%s", want, triples)
+		}
+	}
+	restored := dagger.NewGraph()
+	if err := restored.ImportNTriples(strings.NewReader(triples)); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Hash() != g.Hash() {
+		t.Fatalf("expected the imported graph to match the exported graph:\n%s", triples)
+	}
+	foreign := `# people
+<http://example.org/alice> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://xmlns.com/foaf/0.1/Person> .
+<http://example.org/alice> <http://xmlns.com/foaf/0.1/name> "Alice"@en .
+<http://example.org/alice> <http://xmlns.com/foaf/0.1/age> "30"^^<http://www.w3.org/2001/XMLSchema#integer> .
+<http://example.org/alice> <http://xmlns.com/foaf/0.1/knows> _:bob .
+_:bob <http://xmlns.com/foaf/0.1/name> "Bob\u00e9" .
+`
+	exp, err := encoding.NTriples{}.Decode(strings.NewReader(foreign))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exp.Nodes) != 2 || len(exp.Edges) != 1 {
+		t.Fatalf("expected 2 nodes & 1 edge, got %v %v", len(exp.Nodes), len(exp.Edges))
+	}
+	alice := exp.Edges[0].From
+	if alice.Type() != "Person" || alice.GetString("name") != "Alice" || alice.GetInt("age") != 30 || exp.Edges[0].Type() != "knows" {
+		t.Fatalf("unexpected import of a foreign document: %v", exp.Edges[0])
+	}
+	if exp.Edges[0].To.GetString("name") != "Bob\u00e9" {
+		t.Fatalf("expected unicode escapes to be decoded, got %v", exp.Edges[0].To)
+	}
+	if _, err := (encoding.NTriples{}).Decode(strings.NewReader(`<a> <b> "c"`)); err == nil {
+		t.Fatal("expected a triple without a terminating . to fail")
+	}
+}
+
+func TestExportMermaid(t *testing.T) {
+	g := dagger.NewGraph()
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman", "name": `coleman "cole"`})
+	charlie := g.NewNode(map[string]interface{}{"_type": "dog", "_id": "charlie"})
+	if _, err := coleman.Connect(charlie, "pet", false); err != nil {
+		t.Fatal(err)
+	}
+	buf := bytes.NewBuffer(nil)
+	if err := g.ExportMermaid(buf, dagger.MermaidDirection("LR"), dagger.MermaidTypeStyle("user", "fill:#f9f")); err != nil {
+		t.Fatal(err)
+	}
+	mermaid := buf.String()
+	for _, want := range []string{
+		"graph LR\n",
+		`n0["dog.charlie"]`,
+		`n1["coleman #quot;cole#quot;"]`,
+		`n1 -->|"pet"| n0`,
+		"classDef t0 fill:#dbeafe,stroke:#1d4ed8\n    class n0 t0",
+		"classDef t1 fill:#f9f\n    class n1 t1",
+	} {
+		if !strings.Contains(mermaid, want) {
+			t.Fatalf("expected %s in:\n%s", want, mermaid)
+		}
+	}
+	buf.Reset()
+	if err := g.ExportMermaid(buf, dagger.MermaidUnstyled()); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "classDef") {
+		t.Fatalf("expected no styles in:\n%s", buf.String())
+	}
+}
+
+func TestExportLayered(t *testing.T) {
+	g := dagger.NewGraph()
+	var tasks []*dagger.Node
+	for _, id := range []string{"build", "test", "lint", "release"} {
+		tasks = append(tasks, g.NewNode(map[string]interface{}{"_type": "task", "_id": id}))
+	}
+	for _, pair := range [][2]int{{3, 1}, {3, 2}, {1, 0}, {2, 0}} {
+		if _, err := tasks[pair[0]].Connect(tasks[pair[1]], "depends_on", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	layout := g.LayeredLayout(dagger.StringType("depends_on"))
+	buf := bytes.NewBuffer(nil)
+	if err := g.ExportDOT(buf, dagger.DOTLayeredLayout(dagger.StringType("depends_on"))); err != nil {
+		t.Fatal(err)
+	}
+	dot := buf.String()
+	for _, want := range []string{
+		`{rank=same; "task.release";}`,
+		`{rank=same; "task.lint"; "task.test";}`,
+		`{rank=same; "task.build";}`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Fatalf("expected %s in:\n%s", want, dot)
+		}
+	}
+	for _, task := range tasks {
+		p, _ := layout.Position(task)
+		if want := fmt.Sprintf(`%q [label=%q, pos="%g,%g!"];`, "task."+task.ID(), "task."+task.ID(), p.X*100, -p.Y*100); !strings.Contains(dot, want) {
+			t.Fatalf("expected %s in:\n%s", want, dot)
+		}
+	}
+	buf.Reset()
+	if err := g.ExportMermaid(buf, dagger.MermaidLayeredLayout(dagger.StringType("depends_on"))); err != nil {
+		t.Fatal(err)
+	}
+	mermaid := buf.String()
+	// nodes are numbered in the order they are declared: rank by rank
+	for _, want := range []string{`n0["task.release"]`, `n3["task.build"]`} {
+		if !strings.Contains(mermaid, want) {
+			t.Fatalf("expected %s in:\n%s", want, mermaid)
+		}
+	}
+	buf.Reset()
+	if err := g.ExportCytoscape(buf, dagger.CytoscapeLayeredLayout(dagger.StringType("depends_on"))); err != nil {
+		t.Fatal(err)
+	}
+	var cy struct {
+		Elements struct {
+			Nodes []struct {
+				Data     map[string]interface{} `json:"data"`
+				Position *primitive.Position    `json:"position"`
+			} `json:"nodes"`
+			Edges []struct {
+				Data map[string]interface{} `json:"data"`
+			} `json:"edges"`
+		} `json:"elements"`
+		Layout struct {
+			Name string `json:"name"`
+		} `json:"layout"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &cy); err != nil {
+		t.Fatal(err)
+	}
+	if len(cy.Elements.Nodes) != len(tasks) || len(cy.Elements.Edges) != 4 || cy.Layout.Name != "preset" {
+		t.Fatalf("unexpected cytoscape export: %s", buf.String())
+	}
+	for _, n := range cy.Elements.Nodes {
+		p, _ := layout.Position(primitive.Node{"_type": "task", "_id": n.Data["_id"]})
+		if n.Data["id"] != "task."+n.Data["_id"].(string) || n.Position == nil || n.Position.X != p.X*100 || n.Position.Y != p.Y*100 {
+			t.Fatalf("expected %v to be positioned at %v", n.Data, p)
+		}
+	}
+	if e := cy.Elements.Edges[0].Data; e["source"] == nil || e["target"] == nil {
+		t.Fatalf("expected edges to have a source & target, got %v", e)
+	}
+}
+
+func TestUI(t *testing.T) {
+	g := dagger.NewGraph()
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman", "name": "coleman"})
+	tyler := g.NewNode(map[string]interface{}{"_type": "user", "_id": "tyler"})
+	charlie := g.NewNode(map[string]interface{}{"_type": "dog", "_id": "charlie"})
+	if _, err := coleman.Connect(charlie, "pet", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := coleman.Connect(tyler, "friend", false); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(ui.Handler(g, ui.WithLimit(2)))
+	defer server.Close()
+	res, err := server.Client().Get(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if !strings.Contains(string(page), "app.js") {
+		t.Fatalf("expected the embedded page, got:\n%s", page)
+	}
+	get := func(path string) *ui.Graph {
+		res, err := server.Client().Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		doc := &ui.Graph{}
+		if err := json.NewDecoder(res.Body).Decode(doc); err != nil {
+			t.Fatal(err)
+		}
+		return doc
+	}
+	doc := get("/graph")
+	if len(doc.Nodes) != 2 || !doc.Truncated || len(doc.NodeTypes) != 2 || len(doc.EdgeTypes) != 2 {
+		t.Fatalf("expected 2 of 3 nodes to be served, got %+v", doc)
+	}
+	doc = get("/graph?limit=0&nodeType=user")
+	if len(doc.Nodes) != 2 || len(doc.Edges) != 1 || doc.Edges[0].Type != "friend" || doc.Truncated {
+		t.Fatalf("expected the users & the edge between them, got %+v", doc)
+	}
+	if doc.Nodes[0].Label != "coleman" || doc.Edges[0].From != "user.coleman" {
+		t.Fatalf("unexpected node labels or edge endpoints: %+v", doc)
+	}
+	doc = get("/graph?limit=0&edgeType=pet")
+	if len(doc.Nodes) != 3 || len(doc.Edges) != 1 || doc.Edges[0].To != "dog.charlie" {
+		t.Fatalf("expected only pet edges, got %+v", doc)
+	}
+	// following the cursors pages through every node once, with each edge on the page of its from node
+	var keys, edges []string
+	for cursor, pages := "", 0; ; pages++ {
+		doc = get("/graph?limit=1&cursor=" + cursor)
+		for _, n := range doc.Nodes {
+			keys = append(keys, n.Key)
+		}
+		for _, e := range doc.Edges {
+			edges = append(edges, e.From+"-"+e.Type+"->"+e.To)
+		}
+		if !doc.Truncated {
+			if pages != 2 || doc.Next != "" {
+				t.Fatalf("expected 3 pages, got %v %+v", pages+1, doc)
+			}
+			break
+		}
+		cursor = doc.Next
+	}
+	sort.Strings(edges)
+	if strings.Join(keys, ",") != "dog.charlie,user.coleman,user.tyler" || strings.Join(edges, ",") != "user.coleman-friend->user.tyler,user.coleman-pet->dog.charlie" {
+		t.Fatalf("unexpected pages: %v %v", keys, edges)
+	}
+	if doc := get("/graph?limit=1&cursor=user.coleman"); len(doc.Nodes) != 1 || doc.Nodes[0].Key != "user.tyler" || doc.Truncated {
+		t.Fatalf("expected the last page, got %+v", doc)
+	}
+}
+
+func TestCRDT(t *testing.T) {
+	a, b, c := dagger.NewGraph(), dagger.NewGraph(), dagger.NewGraph()
+	ra, rb, rc := a.NewReplica("a"), b.NewReplica("b"), c.NewReplica("c")
+	coleman, err := ra.AddNode(map[string]interface{}{"_type": "user", "_id": "coleman", "name": "coleman", "age": 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ra.Sync(rb.Peer()); err != nil {
+		t.Fatal(err)
+	}
+	// concurrent writes to the same attribute: the later write wins, with ties broken by replica id
+	if err := ra.PatchNode(coleman, map[string]interface{}{"name": "cole", "age": nil}); err != nil {
+		t.Fatal(err)
+	}
+	if err := rb.PatchNode(coleman, map[string]interface{}{"name": "colemanword"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ra.Sync(rb.Peer()); err != nil {
+		t.Fatal(err)
+	}
+	if a.Hash() != b.Hash() {
+		t.Fatal("expected the replicas to converge")
+	}
+	n, _ := a.GetNode(coleman)
+	if n.GetString("name") != "colemanword" || n.Get("age") != nil {
+		t.Fatalf("unexpected attributes: %v", n.Raw())
+	}
+	// a concurrent add wins over a delete
+	tyler, err := rb.AddNode(map[string]interface{}{"_type": "user", "_id": "tyler"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ra.DelNode(coleman); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rb.AddNode(map[string]interface{}{"_type": "user", "_id": "coleman", "active": true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := rb.Sync(ra.Peer()); err != nil {
+		t.Fatal(err)
+	}
+	if !a.HasNode(coleman) || !a.HasNode(tyler) || a.Hash() != b.Hash() {
+		t.Fatal("expected the concurrent add to win")
+	}
+	// deleting an edge removes it even if it was patched concurrently
+	friend, err := rb.Connect(coleman, tyler, "friend", map[string]interface{}{"since": 2020})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rb.Sync(ra.Peer()); err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := a.GetEdge(friend); !ok || e.GetInt("since") != 2020 {
+		t.Fatal("expected the edge to be replicated")
+	}
+	if err := ra.DelEdge(friend); err != nil {
+		t.Fatal(err)
+	}
+	if err := rb.PatchEdge(friend, map[string]interface{}{"close": true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ra.Sync(rb.Peer()); err != nil {
+		t.Fatal(err)
+	}
+	if a.HasEdge(friend) || b.HasEdge(friend) {
+		t.Fatal("expected the edge to be deleted")
+	}
+	server := httptest.NewServer(dagger.ReplicaHandler(rc))
+	defer server.Close()
+	peer := dagger.NewHTTPReplicaPeer(server.URL, server.Client())
+	if err := ra.Sync(peer); err != nil {
+		t.Fatal(err)
+	}
+	if c.Hash() != a.Hash() {
+		t.Fatal("expected the remote replica to converge")
+	}
+	hash := c.Hash()
+	if err := ra.Sync(peer); err != nil || c.Hash() != hash {
+		t.Fatalf("expected syncing again to change nothing, got %v", err)
+	}
+	if _, err := rc.AddNode(map[string]interface{}{"_type": "dog", "_id": "charlie"}); err != nil {
+		t.Fatal(err)
+	}
+	stop := rb.StartExchange(10*time.Millisecond, []dagger.ReplicaPeer{peer}, func(err error) {
+		t.Error(err)
+	})
+	defer stop()
+	deadline := time.Now().Add(5 * time.Second)
+	for !b.HasNode(&dagger.ForeignKey{XType: "dog", XID: "charlie"}) {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the exchange")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,174 @@
+package dagger
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/autom8ter/dagger/primitive"
+	"io"
+	"sort"
+	"strings"
+)
+
+type dotConfig struct {
+	labels     []string
+	rankDir    string
+	clusterize bool
+	layered    primitive.Type
+	transforms []primitive.Transform
+}
+
+// DOTOption configures ExportDOT
+type DOTOption func(c *dotConfig)
+
+// DOTTransforms applies the transforms(ex: primitive.HashAttributes) to a copy of every exported node & edge, as in
+// ExportJSON
+func DOTTransforms(transforms ...primitive.Transform) DOTOption {
+	return func(c *dotConfig) {
+		c.transforms = append(c.transforms, transforms...)
+	}
+}
+
+// DOTNodeLabel labels each node with the first of the attributes that it has(the default is name).
+// Nodes without any of the attributes are labelled with their type & id.
+func DOTNodeLabel(attributes ...string) DOTOption {
+	return func(c *dotConfig) {
+		c.labels = attributes
+	}
+}
+
+// DOTRankDir sets the direction of the layout: TB(the default), LR, BT or RL
+func DOTRankDir(dir string) DOTOption {
+	return func(c *dotConfig) {
+		c.rankDir = dir
+	}
+}
+
+// DOTClusterByType groups the nodes of each type into a labelled cluster
+func DOTClusterByType() DOTOption {
+	return func(c *dotConfig) {
+		c.clusterize = true
+	}
+}
+
+// DOTLayeredLayout places the nodes by the graph's LayeredLayout over edges of the given type: the nodes of each rank
+// are kept on the same rank, and each node is pinned to its position so renderers that honor positions(neato -n) draw
+// the layout as computed.
+func DOTLayeredLayout(edgeType primitive.Type) DOTOption {
+	return func(c *dotConfig) {
+		c.layered = edgeType
+	}
+}
+
+// dotSpacing is the distance in points between neighboring positions of a layered layout
+const dotSpacing = 100
+
+// ExportDOT writes the graph in GraphViz DOT format(render it with dot -Tpng). Nodes are labelled from their attributes
+// and edges with their type.
+func (g *Graph) ExportDOT(w io.Writer, opts ...DOTOption) error {
+	defer g.trace("ExportDOT", nil)()
+	c := &dotConfig{
+		labels: []string{"name"},
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	export := g.export(c.transforms)
+	sort.Slice(export.Nodes, func(i, j int) bool {
+		return dotID(export.Nodes[i]) < dotID(export.Nodes[j])
+	})
+	sort.Slice(export.Edges, func(i, j int) bool {
+		a, b := export.Edges[i], export.Edges[j]
+		if dotID(a.From) != dotID(b.From) {
+			return dotID(a.From) < dotID(b.From)
+		}
+		if dotID(a.To) != dotID(b.To) {
+			return dotID(a.To) < dotID(b.To)
+		}
+		return a.Type() < b.Type()
+	})
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph dagger {")
+	if c.rankDir != "" {
+		fmt.Fprintf(bw, "\trankdir=%s;\n", c.rankDir)
+	}
+	var layout *primitive.Layout
+	if c.layered != nil {
+		layout = g.graph.LayeredLayout(c.layered)
+	}
+	writeNode := func(indent string, n primitive.Node) {
+		if layout != nil {
+			if p, ok := layout.Position(n); ok {
+				// dot's y axis points up, so the first rank is drawn at the top
+				fmt.Fprintf(bw, "%s%s [label=%s, pos=\"%g,%g!\"];\n", indent, dotQuote(dotID(n)), dotQuote(c.label(n)), p.X*dotSpacing, -p.Y*dotSpacing)
+				return
+			}
+		}
+		fmt.Fprintf(bw, "%s%s [label=%s];\n", indent, dotQuote(dotID(n)), dotQuote(c.label(n)))
+	}
+	if c.clusterize {
+		byType := map[string][]primitive.Node{}
+		var types []string
+		for _, n := range export.Nodes {
+			if _, ok := byType[n.Type()]; !ok {
+				types = append(types, n.Type())
+			}
+			byType[n.Type()] = append(byType[n.Type()], n)
+		}
+		sort.Strings(types)
+		for i, typ := range types {
+			fmt.Fprintf(bw, "\tsubgraph cluster_%v {\n\t\tlabel=%s;\n", i, dotQuote(typ))
+			for _, n := range byType[typ] {
+				writeNode("\t\t", n)
+			}
+			fmt.Fprintln(bw, "\t}")
+		}
+	} else {
+		for _, n := range export.Nodes {
+			writeNode("\t", n)
+		}
+	}
+	if layout != nil {
+		exported := map[string]bool{}
+		for _, n := range export.Nodes {
+			exported[dotID(n)] = true
+		}
+		for _, rank := range layout.Ranks {
+			var ids []string
+			for _, id := range rank {
+				if exported[id] {
+					ids = append(ids, dotQuote(id))
+				}
+			}
+			if len(ids) > 0 {
+				fmt.Fprintf(bw, "\t{rank=same; %s;}\n", strings.Join(ids, "; "))
+			}
+		}
+	}
+	for _, e := range export.Edges {
+		fmt.Fprintf(bw, "\t%s -> %s [label=%s];\n", dotQuote(dotID(e.From)), dotQuote(dotID(e.To)), dotQuote(e.Type()))
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// ExportDOT calls Graph.ExportDOT on the default graph
+func ExportDOT(w io.Writer, opts ...DOTOption) error {
+	return defaultGraph.ExportDOT(w, opts...)
+}
+
+func (c *dotConfig) label(n primitive.Node) string {
+	for _, attr := range c.labels {
+		if n.Exists(attr) {
+			return fmt.Sprint(n.Get(attr))
+		}
+	}
+	return dotID(n)
+}
+
+func dotID(id primitive.TypedID) string {
+	return fmt.Sprintf("%s.%s", id.Type(), id.ID())
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
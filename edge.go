@@ -7,25 +7,33 @@ import (
 // Edge is an edge in the directed graph. It represents a relationship between two nodes.
 type Edge struct {
 	primitive.TypedID
+	graph *Graph
 }
 
-// NewEdge creates a new edge node in the global, in-memory graph.
+// NewEdge creates a new edge node in the graph that the from node belongs to.
 func NewEdge(relationship string, from, to *Node, mutual bool) (*Edge, error) {
 	return from.Connect(to, relationship, mutual)
 }
 
-func edgeFrom(edge *primitive.Edge) (*Edge, error) {
-	if !globalGraph.HasEdge(edge) || !edge.HasID() {
-		if err := globalGraph.AddEdge(edge); err != nil {
+func (g *Graph) edgeFrom(edge *primitive.Edge) (*Edge, error) {
+	if !g.graph.HasEdge(edge) || !edge.HasID() {
+		if err := g.graph.AddEdge(edge); err != nil {
 			return nil, err
 		}
-		return &Edge{edge}, nil
 	}
-	return &Edge{edge}, nil
+	return g.edge(edge), nil
+}
+
+// owner returns the graph the edge belongs to. Edges created without a graph belong to the default graph.
+func (e *Edge) owner() *Graph {
+	if e.graph == nil {
+		return defaultGraph
+	}
+	return e.graph
 }
 
 func (e *Edge) load() *primitive.Edge {
-	edge, ok := globalGraph.GetEdge(e)
+	edge, ok := e.owner().graph.GetEdge(e)
 	if !ok {
 		return &primitive.Edge{
 			Node: primitive.Node{},
@@ -38,19 +46,69 @@ func (e *Edge) load() *primitive.Edge {
 
 // From returns the node that points to the node returned by To()
 func (e *Edge) From() *Node {
-	return nodeFrom(e.load().From)
+	return e.owner().nodeFrom(e.load().From)
 }
 
 // To returns the node that is being pointed to by From()
 func (e *Edge) To() *Node {
-	return nodeFrom(e.load().To)
+	return e.owner().nodeFrom(e.load().To)
+}
+
+// PatchOption configures Edge.Patch
+type PatchOption func(o *patchOptions)
+
+type patchOptions struct {
+	reverse bool
+}
+
+// PatchReverse applies the patch to the edge's mutual counterpart(see Edge.Reverse) too, keeping both in sync
+func PatchReverse() PatchOption {
+	return func(o *patchOptions) {
+		o.reverse = true
+	}
 }
 
 // Patch patches the edge attributes with the given data
-func (e *Edge) Patch(data map[string]interface{}) {
-	edge := e.load()
-	edge.SetAll(data)
-	globalGraph.AddEdge(edge)
+func (e *Edge) Patch(data map[string]interface{}, opts ...PatchOption) error {
+	if err := e.owner().writable(); err != nil {
+		return err
+	}
+	var options patchOptions
+	for _, o := range opts {
+		o(&options)
+	}
+	if options.reverse {
+		return e.owner().graph.PatchEdgeAndReverse(e, data)
+	}
+	return e.owner().graph.PatchEdge(e, data)
+}
+
+// Reverse returns the edge's mutual counterpart: the edge Connect created in the opposite direction when mutual is true
+func (e *Edge) Reverse() (*Edge, bool) {
+	id, ok := e.load().Reverse()
+	if !ok {
+		return nil, false
+	}
+	return e.owner().GetEdge(id)
+}
+
+// Weight returns the weight set with SetWeight, or 1 if the edge has no weight
+func (e *Edge) Weight() float64 {
+	return e.load().Weight()
+}
+
+// SetWeight sets the weight path algorithms read from the edge by default
+func (e *Edge) SetWeight(weight float64) error {
+	return e.Patch(map[string]interface{}{primitive.WEIGHT_KEY: weight})
+}
+
+// Increment atomically adds delta to the edge's integer attribute, returning the new value. It is safe for concurrent
+// use without external locking.
+func (e *Edge) Increment(key string, delta int64) (int64, error) {
+	if err := e.owner().writable(); err != nil {
+		return 0, err
+	}
+	return e.owner().graph.IncrementEdge(e, key, delta)
 }
 
 // Range iterates over the edges attributes until the iterator returns false
@@ -61,7 +119,7 @@ func (e *Edge) Range(fn func(key string, value interface{}) bool) {
 
 func (e *Edge) Node() *Node {
 	edge := e.load()
-	return nodeFrom(edge.Node)
+	return e.owner().nodeFrom(edge.Node)
 }
 
 // GetString gets a string value from the edges attributes(if it exists)
@@ -89,9 +147,14 @@ func (e *Edge) Get(key string) interface{} {
 }
 
 // Del deletes the entry from the edge by key
-func (e *Edge) Del(key string) {
+func (e *Edge) Del(key string) error {
+	if e.owner().view || e.owner().graph.ReadOnly() {
+		return primitive.ErrReadOnly
+	}
 	edge := e.load()
 	edge.Del(key)
+	// store the edge again so storage backends persist the deletion
+	return e.owner().graph.AddEdge(edge)
 }
 
 // JSON returns the edge as JSON bytes
@@ -0,0 +1,82 @@
+package dagger
+
+import (
+	"github.com/autom8ter/dagger/encoding"
+	"github.com/autom8ter/dagger/primitive"
+	"io"
+)
+
+// Encode writes the graph into the io Writer in an interchange format(ex: encoding.GraphML, encoding.GEXF) or a
+// serialization codec(ex: encoding.Gob, encoding.MsgPack). The transforms are applied to a copy of every exported node
+// & edge, as in ExportJSON.
+func (g *Graph) Encode(w io.Writer, enc encoding.Encoder, transforms ...primitive.Transform) error {
+	defer g.trace("Encode", codecAttribute(enc))()
+	return enc.Encode(w, g.export(transforms))
+}
+
+// Encode calls Graph.Encode on the default graph
+func Encode(w io.Writer, enc encoding.Encoder, transforms ...primitive.Transform) error {
+	return defaultGraph.Encode(w, enc, transforms...)
+}
+
+// Decode imports a graph from the io Reader in an interchange format(ex: encoding.GraphML, encoding.GEXF) or a
+// serialization codec(ex: encoding.Gob, encoding.MsgPack). Gzip compressed streams are decompressed.
+func (g *Graph) Decode(r io.Reader, dec encoding.Decoder) error {
+	defer g.trace("Decode", codecAttribute(dec))()
+	r, err := encoding.Decompress(r)
+	if err != nil {
+		return err
+	}
+	exp, err := dec.Decode(r)
+	if err != nil {
+		return err
+	}
+	return g.graph.Import(exp)
+}
+
+// Decode calls Graph.Decode on the default graph
+func Decode(r io.Reader, dec encoding.Decoder) error {
+	return defaultGraph.Decode(r, dec)
+}
+
+// ExportProto writes the graph into the io Writer in the compact, binary protocol buffers format described by
+// encoding/dagger.proto. The transforms are applied as in ExportJSON.
+func (g *Graph) ExportProto(w io.Writer, transforms ...primitive.Transform) error {
+	return g.Encode(w, encoding.Protobuf{}, transforms...)
+}
+
+// ExportProto calls Graph.ExportProto on the default graph
+func ExportProto(w io.Writer, transforms ...primitive.Transform) error {
+	return defaultGraph.ExportProto(w, transforms...)
+}
+
+// ImportProto imports a graph written by ExportProto from the io Reader
+func (g *Graph) ImportProto(r io.Reader) error {
+	return g.Decode(r, encoding.Protobuf{})
+}
+
+// ImportProto calls Graph.ImportProto on the default graph
+func ImportProto(r io.Reader) error {
+	return defaultGraph.ImportProto(r)
+}
+
+// ExportNTriples writes the graph into the io Writer as RDF N-Triples(see encoding.NTriples). The transforms are
+// applied as in ExportJSON.
+func (g *Graph) ExportNTriples(w io.Writer, transforms ...primitive.Transform) error {
+	return g.Encode(w, encoding.NTriples{}, transforms...)
+}
+
+// ExportNTriples calls Graph.ExportNTriples on the default graph
+func ExportNTriples(w io.Writer, transforms ...primitive.Transform) error {
+	return defaultGraph.ExportNTriples(w, transforms...)
+}
+
+// ImportNTriples imports a graph from RDF N-Triples in the io Reader(see encoding.NTriples)
+func (g *Graph) ImportNTriples(r io.Reader) error {
+	return g.Decode(r, encoding.NTriples{})
+}
+
+// ImportNTriples calls Graph.ImportNTriples on the default graph
+func ImportNTriples(r io.Reader) error {
+	return defaultGraph.ImportNTriples(r)
+}
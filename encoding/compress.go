@@ -0,0 +1,64 @@
+package encoding
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"github.com/autom8ter/dagger/primitive"
+	"io"
+)
+
+// ErrUnsupportedCompression is returned when decompressing a stream compressed with an unsupported algorithm(ex: zstd)
+var ErrUnsupportedCompression = errors.New("encoding: unsupported compression")
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// Decompress returns a reader of the decompressed stream if r is gzip compressed, or of r itself if it is not
+// compressed. It returns ErrUnsupportedCompression for zstd streams.
+func Decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		return gzip.NewReader(br)
+	case bytes.HasPrefix(head, zstdMagic):
+		return nil, ErrUnsupportedCompression
+	default:
+		return br, nil
+	}
+}
+
+// Gzip wraps the codec so exports are gzip compressed. Decoding accepts compressed & uncompressed streams.
+func Gzip(codec Codec) Codec {
+	return gzipCodec{codec}
+}
+
+type gzipCodec struct {
+	codec Codec
+}
+
+// Encode writes the export with the codec, gzip compressed
+func (g gzipCodec) Encode(w io.Writer, exp *primitive.Export) error {
+	zw := gzip.NewWriter(w)
+	if err := g.codec.Encode(zw, exp); err != nil {
+		zw.Close()
+		return err
+	}
+	return zw.Close()
+}
+
+// Decode reads an export with the codec, decompressing it if it is gzip compressed
+func (g gzipCodec) Decode(r io.Reader) (*primitive.Export, error) {
+	r, err := Decompress(r)
+	if err != nil {
+		return nil, err
+	}
+	return g.codec.Decode(r)
+}
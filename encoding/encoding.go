@@ -0,0 +1,165 @@
+// Package encoding converts graph exports to & from interchange formats read by other graph tools(ex: Gephi, yEd)
+package encoding
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/autom8ter/dagger/primitive"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// Encoder writes a graph export in an interchange format
+type Encoder interface {
+	Encode(w io.Writer, exp *primitive.Export) error
+}
+
+// Decoder reads a graph export from an interchange format
+type Decoder interface {
+	Decode(r io.Reader) (*primitive.Export, error)
+}
+
+// Codec is both an Encoder & a Decoder
+type Codec interface {
+	Encoder
+	Decoder
+}
+
+const (
+	kindString  = "string"
+	kindLong    = "long"
+	kindDouble  = "double"
+	kindBoolean = "boolean"
+)
+
+// attribute is a declared attribute column of a node or edge
+type attribute struct {
+	id   string
+	name string
+	kind string
+}
+
+// collectAttributes declares an attribute for every key of the elements, sorted by name. An attribute whose values
+// are of different kinds is declared a string.
+func collectAttributes(elements []primitive.Node) []attribute {
+	kinds := map[string]string{}
+	for _, n := range elements {
+		for k, v := range n {
+			if v == nil {
+				continue
+			}
+			kind := kindOf(v)
+			if current, ok := kinds[k]; ok && current != kind {
+				kind = kindString
+			}
+			kinds[k] = kind
+		}
+	}
+	var attributes []attribute
+	for name, kind := range kinds {
+		attributes = append(attributes, attribute{name: name, kind: kind})
+	}
+	sort.Slice(attributes, func(i, j int) bool {
+		return attributes[i].name < attributes[j].name
+	})
+	for i := range attributes {
+		attributes[i].id = strconv.Itoa(i)
+	}
+	return attributes
+}
+
+func kindOf(v interface{}) string {
+	switch v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return kindLong
+	case float32, float64:
+		return kindDouble
+	case bool:
+		return kindBoolean
+	default:
+		return kindString
+	}
+}
+
+// formatValue formats the value as text. Values that are not scalars are formatted as json.
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, bool:
+		return fmt.Sprint(v)
+	default:
+		bits, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(bits)
+	}
+}
+
+// parseValue parses text formatted as the kind, falling back to the text itself if it cannot be parsed
+func parseValue(kind, text string) interface{} {
+	switch kind {
+	case kindLong, "int", "integer":
+		if i, err := strconv.Atoi(text); err == nil {
+			return i
+		}
+	case kindDouble, "float":
+		if f, err := strconv.ParseFloat(text, 64); err == nil {
+			return f
+		}
+	case kindBoolean:
+		if b, err := strconv.ParseBool(text); err == nil {
+			return b
+		}
+	}
+	return text
+}
+
+// elementID is the id of a node or edge within a document
+func elementID(id primitive.TypedID) string {
+	return id.Type() + "." + id.ID()
+}
+
+// decodedElement completes a decoded node or edge: elements without an id use their document id, and elements
+// without a type are given the default type
+func decodedElement(n primitive.Node, documentID string) primitive.Node {
+	if n.Type() == "" {
+		n.SetType(primitive.DefaultType)
+	}
+	if n.ID() == "" {
+		n.SetID(documentID)
+	}
+	return n
+}
+
+// edgeNodes returns the edges' attributes
+func edgeNodes(edges []*primitive.Edge) []primitive.Node {
+	var nodes []primitive.Node
+	for _, e := range edges {
+		nodes = append(nodes, e.Node)
+	}
+	return nodes
+}
+
+// resolveEdge builds an edge between the decoded nodes with the given document ids
+func resolveEdge(attrs primitive.Node, documentID, source, target string, nodes map[string]primitive.Node) (*primitive.Edge, error) {
+	from, ok := nodes[source]
+	if !ok {
+		return nil, fmt.Errorf("encoding: edge %s refers to unknown source node %s", documentID, source)
+	}
+	to, ok := nodes[target]
+	if !ok {
+		return nil, fmt.Errorf("encoding: edge %s refers to unknown target node %s", documentID, target)
+	}
+	return &primitive.Edge{
+		Node: decodedElement(attrs, documentID),
+		From: from,
+		To:   to,
+	}, nil
+}
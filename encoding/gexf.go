@@ -0,0 +1,157 @@
+package encoding
+
+import (
+	"encoding/xml"
+	"github.com/autom8ter/dagger/primitive"
+	"io"
+)
+
+// GEXF encodes & decodes GEXF 1.3 documents(ex: Gephi). Node & edge attributes are stored as attribute values,
+// including _type & _id. Nodes are labelled with their name attribute if they have one, and edges with their type.
+type GEXF struct{}
+
+type gexfDocument struct {
+	XMLName xml.Name  `xml:"gexf"`
+	XMLNS   string    `xml:"xmlns,attr"`
+	Version string    `xml:"version,attr"`
+	Graph   gexfGraph `xml:"graph"`
+}
+
+type gexfGraph struct {
+	DefaultEdgeType string           `xml:"defaultedgetype,attr"`
+	Attributes      []gexfAttributes `xml:"attributes"`
+	Nodes           []gexfElement    `xml:"nodes>node"`
+	Edges           []gexfElement    `xml:"edges>edge"`
+}
+
+type gexfAttributes struct {
+	Class      string          `xml:"class,attr"`
+	Attributes []gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfElement struct {
+	ID        string         `xml:"id,attr"`
+	Label     string         `xml:"label,attr,omitempty"`
+	Source    string         `xml:"source,attr,omitempty"`
+	Target    string         `xml:"target,attr,omitempty"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfAttValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// Encode writes the export as a GEXF document
+func (GEXF) Encode(w io.Writer, exp *primitive.Export) error {
+	doc := gexfDocument{
+		XMLNS:   "http://gexf.net/1.3",
+		Version: "1.3",
+		Graph:   gexfGraph{DefaultEdgeType: "directed"},
+	}
+	nodeAttrs := collectAttributes(exp.Nodes)
+	edgeAttrs := collectAttributes(edgeNodes(exp.Edges))
+	declare := func(class string, attributes []attribute) {
+		decl := gexfAttributes{Class: class}
+		for _, a := range attributes {
+			decl.Attributes = append(decl.Attributes, gexfAttribute{ID: a.id, Title: a.name, Type: a.kind})
+		}
+		doc.Graph.Attributes = append(doc.Graph.Attributes, decl)
+	}
+	declare("node", nodeAttrs)
+	declare("edge", edgeAttrs)
+	values := func(n primitive.Node, attributes []attribute) []gexfAttValue {
+		var values []gexfAttValue
+		for _, a := range attributes {
+			if v, ok := n[a.name]; ok && v != nil {
+				values = append(values, gexfAttValue{For: a.id, Value: formatValue(v)})
+			}
+		}
+		return values
+	}
+	for _, n := range exp.Nodes {
+		label := n.GetString("name")
+		if label == "" {
+			label = elementID(n)
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, gexfElement{ID: elementID(n), Label: label, AttValues: values(n, nodeAttrs)})
+	}
+	for _, e := range exp.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, gexfElement{
+			ID:        elementID(e),
+			Label:     e.Type(),
+			Source:    elementID(e.From),
+			Target:    elementID(e.To),
+			AttValues: values(e.Node, edgeAttrs),
+		})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(doc)
+}
+
+// Decode reads a GEXF document. Elements without _type & _id attribute values are given the default type(or for
+// edges, their label) & their document id, and nodes without an _id keep their label as their name.
+func (GEXF) Decode(r io.Reader) (*primitive.Export, error) {
+	var doc gexfDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	declared := map[string]map[string]gexfAttribute{}
+	for _, decl := range doc.Graph.Attributes {
+		if declared[decl.Class] == nil {
+			declared[decl.Class] = map[string]gexfAttribute{}
+		}
+		for _, a := range decl.Attributes {
+			declared[decl.Class][a.ID] = a
+		}
+	}
+	attributes := func(class string, values []gexfAttValue) primitive.Node {
+		n := primitive.Node{}
+		for _, v := range values {
+			a, ok := declared[class][v.For]
+			if !ok {
+				continue
+			}
+			name := a.Title
+			if name == "" {
+				name = a.ID
+			}
+			n[name] = parseValue(a.Type, v.Value)
+		}
+		return n
+	}
+	exp := &primitive.Export{}
+	nodes := map[string]primitive.Node{}
+	for _, el := range doc.Graph.Nodes {
+		attrs := attributes("node", el.AttValues)
+		if _, ok := attrs[primitive.ID_KEY]; !ok && el.Label != "" {
+			// a document not written by dagger: keep the node's label
+			attrs["name"] = el.Label
+		}
+		n := decodedElement(attrs, el.ID)
+		nodes[el.ID] = n
+		exp.Nodes = append(exp.Nodes, n)
+	}
+	for _, el := range doc.Graph.Edges {
+		attrs := attributes("edge", el.AttValues)
+		if attrs.Type() == "" && el.Label != "" {
+			attrs.SetType(el.Label)
+		}
+		e, err := resolveEdge(attrs, el.ID, el.Source, el.Target, nodes)
+		if err != nil {
+			return nil, err
+		}
+		exp.Edges = append(exp.Edges, e)
+	}
+	return exp, nil
+}
@@ -0,0 +1,36 @@
+package encoding
+
+import (
+	"encoding/gob"
+	"github.com/autom8ter/dagger/primitive"
+	"io"
+	"time"
+)
+
+func init() {
+	// attribute values are held in interface{}, so gob must know their concrete types
+	gob.Register(primitive.Node{})
+	gob.Register(map[string]interface{}{})
+	gob.Register([]interface{}{})
+	gob.Register([]string{})
+	gob.Register(time.Time{})
+}
+
+// Gob encodes & decodes exports with encoding/gob. It is the fastest format for Go programs exchanging graphs. Attribute
+// values of types other than scalars, primitive.Node, map[string]interface{}, []interface{}, []string & time.Time must
+// be registered with gob.Register.
+type Gob struct{}
+
+// Encode writes the export as a gob stream
+func (Gob) Encode(w io.Writer, exp *primitive.Export) error {
+	return gob.NewEncoder(w).Encode(exp)
+}
+
+// Decode reads an export from a gob stream
+func (Gob) Decode(r io.Reader) (*primitive.Export, error) {
+	exp := &primitive.Export{}
+	if err := gob.NewDecoder(r).Decode(exp); err != nil {
+		return nil, err
+	}
+	return exp, nil
+}
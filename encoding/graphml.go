@@ -0,0 +1,132 @@
+package encoding
+
+import (
+	"encoding/xml"
+	"github.com/autom8ter/dagger/primitive"
+	"io"
+)
+
+// GraphML encodes & decodes GraphML documents(ex: yEd). Node & edge attributes are stored as data elements, including
+// _type & _id. Attributes that are not scalars are encoded as json strings.
+type GraphML struct{}
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string           `xml:"id,attr"`
+	EdgeDefault string           `xml:"edgedefault,attr"`
+	Nodes       []graphMLElement `xml:"node"`
+	Edges       []graphMLElement `xml:"edge"`
+}
+
+type graphMLElement struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr,omitempty"`
+	Target string        `xml:"target,attr,omitempty"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// Encode writes the export as a GraphML document
+func (GraphML) Encode(w io.Writer, exp *primitive.Export) error {
+	doc := graphMLDocument{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Graph: graphMLGraph{ID: "G", EdgeDefault: "directed"},
+	}
+	nodeAttrs := collectAttributes(exp.Nodes)
+	edgeAttrs := collectAttributes(edgeNodes(exp.Edges))
+	keys := func(attributes []attribute, kind, prefix string) map[string]string {
+		ids := map[string]string{}
+		for _, a := range attributes {
+			ids[a.name] = prefix + a.id
+			doc.Keys = append(doc.Keys, graphMLKey{ID: prefix + a.id, For: kind, Name: a.name, Type: a.kind})
+		}
+		return ids
+	}
+	nodeKeys := keys(nodeAttrs, "node", "n")
+	edgeKeys := keys(edgeAttrs, "edge", "e")
+	data := func(n primitive.Node, ids map[string]string, attributes []attribute) []graphMLData {
+		var data []graphMLData
+		for _, a := range attributes {
+			if v, ok := n[a.name]; ok && v != nil {
+				data = append(data, graphMLData{Key: ids[a.name], Value: formatValue(v)})
+			}
+		}
+		return data
+	}
+	for _, n := range exp.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLElement{ID: elementID(n), Data: data(n, nodeKeys, nodeAttrs)})
+	}
+	for _, e := range exp.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLElement{
+			ID:     elementID(e),
+			Source: elementID(e.From),
+			Target: elementID(e.To),
+			Data:   data(e.Node, edgeKeys, edgeAttrs),
+		})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(doc)
+}
+
+// Decode reads a GraphML document. Elements without _type & _id data are given the default type & their document id.
+func (GraphML) Decode(r io.Reader) (*primitive.Export, error) {
+	var doc graphMLDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	keys := map[string]graphMLKey{}
+	for _, k := range doc.Keys {
+		keys[k.ID] = k
+	}
+	attributes := func(data []graphMLData) primitive.Node {
+		n := primitive.Node{}
+		for _, d := range data {
+			k, ok := keys[d.Key]
+			if !ok {
+				continue
+			}
+			name := k.Name
+			if name == "" {
+				name = k.ID
+			}
+			n[name] = parseValue(k.Type, d.Value)
+		}
+		return n
+	}
+	exp := &primitive.Export{}
+	nodes := map[string]primitive.Node{}
+	for _, el := range doc.Graph.Nodes {
+		n := decodedElement(attributes(el.Data), el.ID)
+		nodes[el.ID] = n
+		exp.Nodes = append(exp.Nodes, n)
+	}
+	for _, el := range doc.Graph.Edges {
+		e, err := resolveEdge(attributes(el.Data), el.ID, el.Source, el.Target, nodes)
+		if err != nil {
+			return nil, err
+		}
+		exp.Edges = append(exp.Edges, e)
+	}
+	return exp, nil
+}
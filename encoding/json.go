@@ -0,0 +1,24 @@
+package encoding
+
+import (
+	"encoding/json"
+	"github.com/autom8ter/dagger/primitive"
+	"io"
+)
+
+// JSON encodes & decodes exports as a json document, the same format as Graph.ExportJSON
+type JSON struct{}
+
+// Encode writes the export as json
+func (JSON) Encode(w io.Writer, exp *primitive.Export) error {
+	return json.NewEncoder(w).Encode(exp)
+}
+
+// Decode reads a json export
+func (JSON) Decode(r io.Reader) (*primitive.Export, error) {
+	exp := &primitive.Export{}
+	if err := json.NewDecoder(r).Decode(exp); err != nil {
+		return nil, err
+	}
+	return exp, nil
+}
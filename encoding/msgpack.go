@@ -0,0 +1,410 @@
+package encoding
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/autom8ter/dagger/primitive"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// MsgPack encodes & decodes exports as MessagePack(https://msgpack.org), a compact binary json. The export is a map of
+// "nodes" & "edges" like the json export, but edge endpoints only hold their _type & _id. Times are written as RFC 3339
+// strings & values that are not scalars, maps or slices are written as they would be encoded to json.
+type MsgPack struct{}
+
+// msgpackWriter writes MessagePack values
+type msgpackWriter struct {
+	w   *bufio.Writer
+	buf [9]byte
+}
+
+func (m *msgpackWriter) head(b byte, size int, n uint64) {
+	m.buf[0] = b
+	switch size {
+	case 1:
+		m.buf[1] = byte(n)
+	case 2:
+		binary.BigEndian.PutUint16(m.buf[1:], uint16(n))
+	case 4:
+		binary.BigEndian.PutUint32(m.buf[1:], uint32(n))
+	case 8:
+		binary.BigEndian.PutUint64(m.buf[1:], n)
+	}
+	m.w.Write(m.buf[:1+size])
+}
+
+// length writes the header of a string, binary, array or map of the length. fix is the header of the fixed size
+// family(or 0 if there is none) & fixMax its largest length, followed by the 8, 16 & 32 bit headers(0 if absent).
+func (m *msgpackWriter) length(n int, fix byte, fixMax int, b8, b16, b32 byte) {
+	switch {
+	case fix != 0 && n <= fixMax:
+		m.w.WriteByte(fix | byte(n))
+	case b8 != 0 && n <= math.MaxUint8:
+		m.head(b8, 1, uint64(n))
+	case n <= math.MaxUint16:
+		m.head(b16, 2, uint64(n))
+	default:
+		m.head(b32, 4, uint64(n))
+	}
+}
+
+func (m *msgpackWriter) int(i int64) {
+	switch {
+	case i >= 0:
+		m.uint(uint64(i))
+	case i >= -32:
+		m.w.WriteByte(byte(i))
+	case i >= math.MinInt8:
+		m.head(0xd0, 1, uint64(i))
+	case i >= math.MinInt16:
+		m.head(0xd1, 2, uint64(i))
+	case i >= math.MinInt32:
+		m.head(0xd2, 4, uint64(i))
+	default:
+		m.head(0xd3, 8, uint64(i))
+	}
+}
+
+func (m *msgpackWriter) uint(u uint64) {
+	switch {
+	case u <= 0x7f:
+		m.w.WriteByte(byte(u))
+	case u <= math.MaxUint8:
+		m.head(0xcc, 1, u)
+	case u <= math.MaxUint16:
+		m.head(0xcd, 2, u)
+	case u <= math.MaxUint32:
+		m.head(0xce, 4, u)
+	default:
+		m.head(0xcf, 8, u)
+	}
+}
+
+func (m *msgpackWriter) string(s string) {
+	m.length(len(s), 0xa0, 31, 0xd9, 0xda, 0xdb)
+	m.w.WriteString(s)
+}
+
+func (m *msgpackWriter) mapOf(n map[string]interface{}) error {
+	keys := make([]string, 0, len(n))
+	for k := range n {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	m.length(len(keys), 0x80, 15, 0, 0xde, 0xdf)
+	for _, k := range keys {
+		m.string(k)
+		if err := m.value(n[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *msgpackWriter) value(v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		m.w.WriteByte(0xc0)
+	case bool:
+		if v {
+			m.w.WriteByte(0xc3)
+		} else {
+			m.w.WriteByte(0xc2)
+		}
+	case int:
+		m.int(int64(v))
+	case int8:
+		m.int(int64(v))
+	case int16:
+		m.int(int64(v))
+	case int32:
+		m.int(int64(v))
+	case int64:
+		m.int(v)
+	case uint:
+		m.uint(uint64(v))
+	case uint8:
+		m.uint(uint64(v))
+	case uint16:
+		m.uint(uint64(v))
+	case uint32:
+		m.uint(uint64(v))
+	case uint64:
+		m.uint(v)
+	case float32:
+		m.head(0xca, 4, uint64(math.Float32bits(v)))
+	case float64:
+		m.head(0xcb, 8, math.Float64bits(v))
+	case string:
+		m.string(v)
+	case []byte:
+		m.length(len(v), 0, 0, 0xc4, 0xc5, 0xc6)
+		m.w.Write(v)
+	case time.Time:
+		m.string(v.Format(time.RFC3339Nano))
+	case primitive.Node:
+		return m.mapOf(v)
+	case map[string]interface{}:
+		return m.mapOf(v)
+	case []interface{}:
+		m.length(len(v), 0x90, 15, 0, 0xdc, 0xdd)
+		for _, elem := range v {
+			if err := m.value(elem); err != nil {
+				return err
+			}
+		}
+	default:
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice {
+			m.length(rv.Len(), 0x90, 15, 0, 0xdc, 0xdd)
+			for i := 0; i < rv.Len(); i++ {
+				if err := m.value(rv.Index(i).Interface()); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		// encode other values(ex: structs & typed maps) as they would be encoded to json
+		bits, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		var generic interface{}
+		if err := json.Unmarshal(bits, &generic); err != nil {
+			return err
+		}
+		return m.value(generic)
+	}
+	return nil
+}
+
+// Encode writes the export as MessagePack
+func (MsgPack) Encode(w io.Writer, exp *primitive.Export) error {
+	m := &msgpackWriter{w: bufio.NewWriter(w)}
+	m.length(2, 0x80, 15, 0, 0xde, 0xdf)
+	m.string("nodes")
+	m.length(len(exp.Nodes), 0x90, 15, 0, 0xdc, 0xdd)
+	for _, n := range exp.Nodes {
+		if err := m.mapOf(n); err != nil {
+			return fmt.Errorf("encoding: node %s: %w", elementID(n), err)
+		}
+	}
+	m.string("edges")
+	m.length(len(exp.Edges), 0x90, 15, 0, 0xdc, 0xdd)
+	for _, e := range exp.Edges {
+		m.length(3, 0x80, 15, 0, 0xde, 0xdf)
+		m.string("node")
+		if err := m.mapOf(e.Node); err != nil {
+			return fmt.Errorf("encoding: edge %s: %w", elementID(e), err)
+		}
+		for _, endpoint := range []struct {
+			key string
+			id  primitive.TypedID
+		}{{"from", e.From}, {"to", e.To}} {
+			m.string(endpoint.key)
+			m.mapOf(map[string]interface{}{primitive.TYPE_KEY: endpoint.id.Type(), primitive.ID_KEY: endpoint.id.ID()})
+		}
+	}
+	return m.w.Flush()
+}
+
+// msgpackReader reads MessagePack values
+type msgpackReader struct {
+	r *bufio.Reader
+}
+
+func (m *msgpackReader) uint(size int) (uint64, error) {
+	var buf [8]byte
+	if _, err := io.ReadFull(m.r, buf[:size]); err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(buf[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(buf[:])), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(buf[:])), nil
+	default:
+		return binary.BigEndian.Uint64(buf[:]), nil
+	}
+}
+
+func (m *msgpackReader) bytes(n uint64) ([]byte, error) {
+	buf := make([]byte, n)
+	_, err := io.ReadFull(m.r, buf)
+	return buf, err
+}
+
+func (m *msgpackReader) array(n uint64) ([]interface{}, error) {
+	arr := make([]interface{}, 0, n)
+	for i := uint64(0); i < n; i++ {
+		v, err := m.value()
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, v)
+	}
+	return arr, nil
+}
+
+func (m *msgpackReader) mapOf(n uint64) (map[string]interface{}, error) {
+	obj := make(map[string]interface{}, n)
+	for i := uint64(0); i < n; i++ {
+		k, err := m.value()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("encoding: unsupported msgpack map key: %T", k)
+		}
+		if obj[key], err = m.value(); err != nil {
+			return nil, err
+		}
+	}
+	return obj, nil
+}
+
+// sized reads a length of the given size, then the string, binary, array or map of that length
+func (m *msgpackReader) sized(size int, read func(n uint64) (interface{}, error)) (interface{}, error) {
+	n, err := m.uint(size)
+	if err != nil {
+		return nil, err
+	}
+	return read(n)
+}
+
+func (m *msgpackReader) value() (interface{}, error) {
+	b, err := m.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	str := func(n uint64) (interface{}, error) {
+		bits, err := m.bytes(n)
+		return string(bits), err
+	}
+	bin := func(n uint64) (interface{}, error) {
+		return m.bytes(n)
+	}
+	arr := func(n uint64) (interface{}, error) {
+		return m.array(n)
+	}
+	obj := func(n uint64) (interface{}, error) {
+		return m.mapOf(n)
+	}
+	signed := func(size int) (interface{}, error) {
+		u, err := m.uint(size)
+		shift := 64 - 8*size
+		return int(int64(u<<shift) >> shift), err
+	}
+	switch {
+	case b <= 0x7f:
+		return int(b), nil
+	case b >= 0xe0:
+		return int(int8(b)), nil
+	case b&0xf0 == 0x80:
+		return obj(uint64(b & 0x0f))
+	case b&0xf0 == 0x90:
+		return arr(uint64(b & 0x0f))
+	case b&0xe0 == 0xa0:
+		return str(uint64(b & 0x1f))
+	}
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4:
+		return m.sized(1, bin)
+	case 0xc5:
+		return m.sized(2, bin)
+	case 0xc6:
+		return m.sized(4, bin)
+	case 0xca:
+		u, err := m.uint(4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 0xcb:
+		u, err := m.uint(8)
+		return math.Float64frombits(u), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := m.uint(1 << (b - 0xcc))
+		if u > math.MaxInt64 {
+			return u, err
+		}
+		return int(u), err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		return signed(1 << (b - 0xd0))
+	case 0xd9:
+		return m.sized(1, str)
+	case 0xda:
+		return m.sized(2, str)
+	case 0xdb:
+		return m.sized(4, str)
+	case 0xdc:
+		return m.sized(2, arr)
+	case 0xdd:
+		return m.sized(4, arr)
+	case 0xde:
+		return m.sized(2, obj)
+	case 0xdf:
+		return m.sized(4, obj)
+	default:
+		return nil, fmt.Errorf("encoding: unsupported msgpack type 0x%x", b)
+	}
+}
+
+// Decode reads a MessagePack export
+func (MsgPack) Decode(r io.Reader) (*primitive.Export, error) {
+	m := &msgpackReader{r: bufio.NewReader(r)}
+	v, err := m.value()
+	if err != nil {
+		return nil, err
+	}
+	doc, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("encoding: expected a msgpack map, got %T", v)
+	}
+	asNode := func(v interface{}) (primitive.Node, error) {
+		n, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("encoding: expected a msgpack map, got %T", v)
+		}
+		return n, nil
+	}
+	exp := &primitive.Export{}
+	nodes, _ := doc["nodes"].([]interface{})
+	for _, v := range nodes {
+		n, err := asNode(v)
+		if err != nil {
+			return nil, err
+		}
+		exp.Nodes = append(exp.Nodes, n)
+	}
+	edges, _ := doc["edges"].([]interface{})
+	for _, v := range edges {
+		obj, err := asNode(v)
+		if err != nil {
+			return nil, err
+		}
+		e := &primitive.Edge{}
+		if e.Node, err = asNode(obj["node"]); err != nil {
+			return nil, err
+		}
+		if e.From, err = asNode(obj["from"]); err != nil {
+			return nil, err
+		}
+		if e.To, err = asNode(obj["to"]); err != nil {
+			return nil, err
+		}
+		exp.Edges = append(exp.Edges, e)
+	}
+	return exp, nil
+}
@@ -0,0 +1,331 @@
+package encoding
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/autom8ter/dagger/primitive"
+	"io"
+	"math"
+	"sort"
+)
+
+// Protobuf encodes & decodes the compact, binary protocol buffers format described by dagger.proto. It is written
+// with the wire format directly, so other languages can read it with code generated from dagger.proto.
+type Protobuf struct{}
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// errTruncated is returned when a message ends in the middle of a field
+var errTruncated = errors.New("encoding: truncated protobuf message")
+
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func appendFixed64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+// protoWriter appends protocol buffer fields to a buffer
+type protoWriter []byte
+
+func (p *protoWriter) tag(field int, wire int) {
+	*p = appendVarint(*p, uint64(field)<<3|uint64(wire))
+}
+
+func (p *protoWriter) varint(field int, v uint64) {
+	p.tag(field, wireVarint)
+	*p = appendVarint(*p, v)
+}
+
+func (p *protoWriter) bytes(field int, b []byte) {
+	p.tag(field, wireBytes)
+	*p = appendVarint(*p, uint64(len(b)))
+	*p = append(*p, b...)
+}
+
+func (p *protoWriter) string(field int, s string) {
+	if s == "" {
+		return
+	}
+	p.tag(field, wireBytes)
+	*p = appendVarint(*p, uint64(len(s)))
+	*p = append(*p, s...)
+}
+
+// protoValue encodes the attribute value as a Value message
+func protoValue(v interface{}) ([]byte, error) {
+	var p protoWriter
+	switch v := v.(type) {
+	case string:
+		p.tag(1, wireBytes)
+		p = appendVarint(p, uint64(len(v)))
+		p = append(p, v...)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		var i int64
+		switch v := v.(type) {
+		case int:
+			i = int64(v)
+		case int8:
+			i = int64(v)
+		case int16:
+			i = int64(v)
+		case int32:
+			i = int64(v)
+		case int64:
+			i = v
+		case uint:
+			i = int64(v)
+		case uint8:
+			i = int64(v)
+		case uint16:
+			i = int64(v)
+		case uint32:
+			i = int64(v)
+		case uint64:
+			i = int64(v)
+		}
+		// sint64 values are zigzag encoded
+		p.varint(2, uint64(i<<1)^uint64(i>>63))
+	case float32:
+		p.tag(3, wireFixed64)
+		p = appendFixed64(p, math.Float64bits(float64(v)))
+	case float64:
+		p.tag(3, wireFixed64)
+		p = appendFixed64(p, math.Float64bits(v))
+	case bool:
+		b := uint64(0)
+		if v {
+			b = 1
+		}
+		p.varint(4, b)
+	default:
+		bits, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		p.bytes(5, bits)
+	}
+	return p, nil
+}
+
+// element appends the type, id & attribute fields shared by the Node & Edge messages
+func (p *protoWriter) element(n primitive.Node) error {
+	p.string(1, n.Type())
+	p.string(2, n.ID())
+	keys := make([]string, 0, len(n))
+	for k := range n {
+		if k != primitive.TYPE_KEY && k != primitive.ID_KEY {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		value, err := protoValue(n[k])
+		if err != nil {
+			return fmt.Errorf("encoding: attribute %s of %s: %w", k, elementID(n), err)
+		}
+		var entry protoWriter
+		entry.string(1, k)
+		entry.bytes(2, value)
+		p.bytes(3, entry)
+	}
+	return nil
+}
+
+// Encode writes the export as an Export message
+func (Protobuf) Encode(w io.Writer, exp *primitive.Export) error {
+	var p protoWriter
+	for _, n := range exp.Nodes {
+		var node protoWriter
+		if err := node.element(n); err != nil {
+			return err
+		}
+		p.bytes(1, node)
+	}
+	for _, e := range exp.Edges {
+		var edge protoWriter
+		if err := edge.element(e.Node); err != nil {
+			return err
+		}
+		edge.string(4, e.From.Type())
+		edge.string(5, e.From.ID())
+		edge.string(6, e.To.Type())
+		edge.string(7, e.To.ID())
+		p.bytes(2, edge)
+	}
+	_, err := w.Write(p)
+	return err
+}
+
+// protoField is a single field read from a message. Varint & fixed fields are held in v, length delimited fields in b.
+type protoField struct {
+	number int
+	wire   int
+	v      uint64
+	b      []byte
+}
+
+// rangeFields reads the fields of the message until fn returns an error
+func rangeFields(msg []byte, fn func(f protoField) error) error {
+	for len(msg) > 0 {
+		key, n := binary.Uvarint(msg)
+		if n <= 0 {
+			return errTruncated
+		}
+		msg = msg[n:]
+		f := protoField{number: int(key >> 3), wire: int(key & 7)}
+		switch f.wire {
+		case wireVarint:
+			f.v, n = binary.Uvarint(msg)
+			if n <= 0 {
+				return errTruncated
+			}
+			msg = msg[n:]
+		case wireFixed64:
+			if len(msg) < 8 {
+				return errTruncated
+			}
+			f.v = binary.LittleEndian.Uint64(msg)
+			msg = msg[8:]
+		case wireFixed32:
+			if len(msg) < 4 {
+				return errTruncated
+			}
+			f.v = uint64(binary.LittleEndian.Uint32(msg))
+			msg = msg[4:]
+		case wireBytes:
+			size, n := binary.Uvarint(msg)
+			if n <= 0 || uint64(len(msg)-n) < size {
+				return errTruncated
+			}
+			f.b = msg[n : n+int(size)]
+			msg = msg[n+int(size):]
+		default:
+			return fmt.Errorf("encoding: unsupported protobuf wire type %v", f.wire)
+		}
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeProtoValue reads a Value message
+func decodeProtoValue(msg []byte) (interface{}, error) {
+	var value interface{}
+	err := rangeFields(msg, func(f protoField) error {
+		switch f.number {
+		case 1:
+			value = string(f.b)
+		case 2:
+			value = int(int64(f.v>>1) ^ -int64(f.v&1))
+		case 3:
+			value = math.Float64frombits(f.v)
+		case 4:
+			value = f.v != 0
+		case 5:
+			value = nil
+			return json.Unmarshal(f.b, &value)
+		}
+		return nil
+	})
+	return value, err
+}
+
+// decodeElement reads the type, id & attribute fields shared by the Node & Edge messages, passing other fields to fn
+func decodeElement(msg []byte, fn func(f protoField)) (primitive.Node, error) {
+	n := primitive.Node{}
+	err := rangeFields(msg, func(f protoField) error {
+		switch f.number {
+		case 1:
+			n.SetType(string(f.b))
+		case 2:
+			n.SetID(string(f.b))
+		case 3:
+			var (
+				key   string
+				value interface{}
+			)
+			if err := rangeFields(f.b, func(entry protoField) error {
+				var err error
+				switch entry.number {
+				case 1:
+					key = string(entry.b)
+				case 2:
+					value, err = decodeProtoValue(entry.b)
+				}
+				return err
+			}); err != nil {
+				return err
+			}
+			n[key] = value
+		default:
+			fn(f)
+		}
+		return nil
+	})
+	return n, err
+}
+
+// Decode reads an Export message
+func (Protobuf) Decode(r io.Reader) (*primitive.Export, error) {
+	msg, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	exp := &primitive.Export{}
+	nodes := map[string]primitive.Node{}
+	err = rangeFields(msg, func(f protoField) error {
+		switch f.number {
+		case 1:
+			n, err := decodeElement(f.b, func(protoField) {})
+			if err != nil {
+				return err
+			}
+			n = decodedElement(n, "")
+			nodes[elementID(n)] = n
+			exp.Nodes = append(exp.Nodes, n)
+		case 2:
+			var fromType, fromID, toType, toID string
+			attrs, err := decodeElement(f.b, func(f protoField) {
+				switch f.number {
+				case 4:
+					fromType = string(f.b)
+				case 5:
+					fromID = string(f.b)
+				case 6:
+					toType = string(f.b)
+				case 7:
+					toID = string(f.b)
+				}
+			})
+			if err != nil {
+				return err
+			}
+			e, err := resolveEdge(attrs, "", fromType+"."+fromID, toType+"."+toID, nodes)
+			if err != nil {
+				return err
+			}
+			exp.Edges = append(exp.Edges, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return exp, nil
+}
@@ -0,0 +1,491 @@
+package encoding
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/autom8ter/dagger/primitive"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	rdfNS          = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	xsdNS          = "http://www.w3.org/2001/XMLSchema#"
+	rdfType        = rdfNS + "type"
+	rdfStatement   = rdfNS + "Statement"
+	rdfSubject     = rdfNS + "subject"
+	rdfPredicate   = rdfNS + "predicate"
+	rdfObject      = rdfNS + "object"
+	rdfJSON        = rdfNS + "JSON"
+	xsdString      = xsdNS + "string"
+	xsdInteger     = xsdNS + "integer"
+	xsdDouble      = xsdNS + "double"
+	xsdBoolean     = xsdNS + "boolean"
+	xsdDateTime    = xsdNS + "dateTime"
+	defaultRDFBase = "urn:dagger:"
+)
+
+// NTriples encodes & decodes RDF N-Triples documents. Nodes become subjects typed with rdf:type, attributes become
+// literal triples & edges become triples with the edge type as the predicate. Each edge is also reified as an
+// rdf:Statement carrying its id & attributes, so the graph survives a round trip. Attributes that are not scalars are
+// encoded as rdf:JSON literals.
+//
+// Decoding also accepts documents written by other tools: triples with a literal object become attributes, and other
+// triples become edges between their subject & object.
+type NTriples struct {
+	// Base is the IRI prefix of the graph's nodes, edges, types & attributes(default urn:dagger:)
+	Base string
+}
+
+func (t NTriples) base() string {
+	if t.Base == "" {
+		return defaultRDFBase
+	}
+	return t.Base
+}
+
+func (t NTriples) nodeIRI(id primitive.TypedID) string {
+	return t.base() + "node/" + url.PathEscape(id.Type()) + "/" + url.PathEscape(id.ID())
+}
+
+func (t NTriples) edgeIRI(id primitive.TypedID) string {
+	return t.base() + "edge/" + url.PathEscape(id.Type()) + "/" + url.PathEscape(id.ID())
+}
+
+// Encode writes the export as an N-Triples document
+func (t NTriples) Encode(w io.Writer, exp *primitive.Export) error {
+	base := t.base()
+	bw := bufio.NewWriter(w)
+	triple := func(subject, predicate, object string) {
+		fmt.Fprintf(bw, "%s %s %s .\n", subject, predicate, object)
+	}
+	attributes := func(subject string, n primitive.Node) {
+		var keys []string
+		for k, v := range n {
+			if k != primitive.ID_KEY && k != primitive.TYPE_KEY && v != nil {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			triple(subject, rdfIRI(base+"attr/"+url.PathEscape(k)), rdfLiteral(n[k]))
+		}
+	}
+	nodes := append([]primitive.Node{}, exp.Nodes...)
+	sort.Slice(nodes, func(i, j int) bool {
+		return elementID(nodes[i]) < elementID(nodes[j])
+	})
+	for _, n := range nodes {
+		subject := rdfIRI(t.nodeIRI(n))
+		triple(subject, rdfIRI(rdfType), rdfIRI(base+"type/"+url.PathEscape(n.Type())))
+		attributes(subject, n)
+	}
+	edges := append([]*primitive.Edge{}, exp.Edges...)
+	sort.Slice(edges, func(i, j int) bool {
+		return elementID(edges[i]) < elementID(edges[j])
+	})
+	for _, e := range edges {
+		from, predicate, to := rdfIRI(t.nodeIRI(e.From)), rdfIRI(base+"rel/"+url.PathEscape(e.Type())), rdfIRI(t.nodeIRI(e.To))
+		triple(from, predicate, to)
+		statement := rdfIRI(t.edgeIRI(e))
+		triple(statement, rdfIRI(rdfType), rdfIRI(rdfStatement))
+		triple(statement, rdfIRI(rdfSubject), from)
+		triple(statement, rdfIRI(rdfPredicate), predicate)
+		triple(statement, rdfIRI(rdfObject), to)
+		attributes(statement, e.Node)
+	}
+	return bw.Flush()
+}
+
+// Decode reads an N-Triples document
+func (t NTriples) Decode(r io.Reader) (*primitive.Export, error) {
+	triples, err := parseNTriples(r)
+	if err != nil {
+		return nil, err
+	}
+	base := t.base()
+	types := map[string]string{}
+	statements := map[string]bool{}
+	for _, tr := range triples {
+		if tr.predicate.value == rdfType && !tr.object.literal {
+			if tr.object.value == rdfStatement {
+				statements[tr.subject.value] = true
+			} else {
+				types[tr.subject.value] = localName(tr.object.value, base+"type/")
+			}
+		}
+	}
+	exp := &primitive.Export{}
+	nodes := map[string]primitive.Node{}
+	node := func(term string) primitive.Node {
+		if n, ok := nodes[term]; ok {
+			return n
+		}
+		n := primitive.Node{}
+		if rest := strings.TrimPrefix(term, base+"node/"); rest != term {
+			if i := strings.Index(rest, "/"); i >= 0 {
+				n.SetType(unescapePath(rest[:i]))
+				n.SetID(unescapePath(rest[i+1:]))
+			}
+		}
+		if typ, ok := types[term]; ok {
+			n.SetType(typ)
+		}
+		n = decodedElement(n, term)
+		nodes[term] = n
+		exp.Nodes = append(exp.Nodes, n)
+		return n
+	}
+	type statement struct {
+		attrs                      primitive.Node
+		subject, predicate, object string
+	}
+	reified := map[string]*statement{}
+	var order []string
+	reifiedTriples := map[[3]string]bool{}
+	var direct []ntriple
+	for _, tr := range triples {
+		if statements[tr.subject.value] {
+			s, ok := reified[tr.subject.value]
+			if !ok {
+				s = &statement{attrs: primitive.Node{}}
+				reified[tr.subject.value] = s
+				order = append(order, tr.subject.value)
+			}
+			switch {
+			case tr.predicate.value == rdfType:
+			case tr.predicate.value == rdfSubject:
+				s.subject = tr.object.value
+			case tr.predicate.value == rdfPredicate:
+				s.predicate = tr.object.value
+			case tr.predicate.value == rdfObject:
+				s.object = tr.object.value
+			case tr.object.literal:
+				s.attrs[localName(tr.predicate.value, base+"attr/")] = tr.object.decode()
+			}
+			continue
+		}
+		if tr.predicate.value == rdfType && !tr.object.literal {
+			node(tr.subject.value)
+			continue
+		}
+		if tr.object.literal {
+			node(tr.subject.value)[localName(tr.predicate.value, base+"attr/")] = tr.object.decode()
+			continue
+		}
+		direct = append(direct, tr)
+	}
+	var edges []*primitive.Edge
+	for _, term := range order {
+		s := reified[term]
+		if s.subject == "" || s.predicate == "" || s.object == "" {
+			return nil, fmt.Errorf("encoding: statement %s is missing its subject, predicate or object", term)
+		}
+		reifiedTriples[[3]string{s.subject, s.predicate, s.object}] = true
+		if rest := strings.TrimPrefix(term, base+"edge/"); rest != term {
+			if i := strings.Index(rest, "/"); i >= 0 {
+				s.attrs.SetID(unescapePath(rest[i+1:]))
+			}
+		}
+		s.attrs.SetType(localName(s.predicate, base+"rel/"))
+		node(s.subject)
+		node(s.object)
+		e, err := resolveEdge(s.attrs, term, s.subject, s.object, nodes)
+		if err != nil {
+			return nil, err
+		}
+		edges = append(edges, e)
+	}
+	for _, tr := range direct {
+		key := [3]string{tr.subject.value, tr.predicate.value, tr.object.value}
+		if reifiedTriples[key] {
+			continue
+		}
+		node(tr.subject.value)
+		node(tr.object.value)
+		attrs := primitive.Node{}
+		attrs.SetType(localName(tr.predicate.value, base+"rel/"))
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(key[:], " ")))
+		e, err := resolveEdge(attrs, strconv.FormatUint(h.Sum64(), 16), tr.subject.value, tr.object.value, nodes)
+		if err != nil {
+			return nil, err
+		}
+		edges = append(edges, e)
+	}
+	exp.Edges = edges
+	return exp, nil
+}
+
+// localName returns the part of the IRI after the prefix, or after its last # or / if it does not have the prefix
+func localName(iri, prefix string) string {
+	if rest := strings.TrimPrefix(iri, prefix); rest != iri {
+		return unescapePath(rest)
+	}
+	if i := strings.LastIndexAny(iri, "#/:"); i >= 0 && i < len(iri)-1 {
+		return iri[i+1:]
+	}
+	return iri
+}
+
+func unescapePath(s string) string {
+	if unescaped, err := url.PathUnescape(s); err == nil {
+		return unescaped
+	}
+	return s
+}
+
+func rdfIRI(iri string) string {
+	return "<" + iri + ">"
+}
+
+// rdfLiteral formats the value as a typed literal
+func rdfLiteral(v interface{}) string {
+	typed := func(text, datatype string) string {
+		return rdfQuote(text) + "^^" + rdfIRI(datatype)
+	}
+	switch v := v.(type) {
+	case string:
+		return rdfQuote(v)
+	case bool:
+		return typed(strconv.FormatBool(v), xsdBoolean)
+	case time.Time:
+		return typed(v.Format(time.RFC3339Nano), xsdDateTime)
+	case float32:
+		return typed(rdfDouble(float64(v)), xsdDouble)
+	case float64:
+		return typed(rdfDouble(v), xsdDouble)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return typed(fmt.Sprint(v), xsdInteger)
+	}
+	bits, err := json.Marshal(v)
+	if err != nil {
+		return rdfQuote(fmt.Sprint(v))
+	}
+	return typed(string(bits), rdfJSON)
+}
+
+func rdfDouble(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "INF"
+	case math.IsInf(f, -1):
+		return "-INF"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func rdfQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`).Replace(s) + `"`
+}
+
+// rdfTerm is an IRI, blank node or literal
+type rdfTerm struct {
+	value    string
+	literal  bool
+	datatype string
+}
+
+// decode converts the literal to a value of its datatype, falling back to its text
+func (t rdfTerm) decode() interface{} {
+	switch t.datatype {
+	case xsdInteger, xsdNS + "int", xsdNS + "long", xsdNS + "short":
+		if i, err := strconv.Atoi(t.value); err == nil {
+			return i
+		}
+	case xsdDouble, xsdNS + "float", xsdNS + "decimal":
+		switch t.value {
+		case "INF":
+			return math.Inf(1)
+		case "-INF":
+			return math.Inf(-1)
+		}
+		if f, err := strconv.ParseFloat(t.value, 64); err == nil {
+			return f
+		}
+	case xsdBoolean:
+		if b, err := strconv.ParseBool(t.value); err == nil {
+			return b
+		}
+	case xsdDateTime:
+		if ts, err := time.Parse(time.RFC3339Nano, t.value); err == nil {
+			return ts
+		}
+	case rdfJSON:
+		var v interface{}
+		if err := json.Unmarshal([]byte(t.value), &v); err == nil {
+			return v
+		}
+	}
+	return t.value
+}
+
+type ntriple struct {
+	subject, predicate, object rdfTerm
+}
+
+// parseNTriples parses each line of the document into a triple, skipping blank lines & comments
+func parseNTriples(r io.Reader) ([]ntriple, error) {
+	var triples []ntriple
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		p := &ntriplesParser{text: strings.TrimSpace(scanner.Text())}
+		if p.text == "" || p.text[0] == '#' {
+			continue
+		}
+		var tr ntriple
+		var err error
+		if tr.subject, err = p.term(); err == nil {
+			if tr.predicate, err = p.term(); err == nil {
+				tr.object, err = p.term()
+			}
+		}
+		if err == nil && (tr.subject.literal || tr.predicate.literal) {
+			err = fmt.Errorf("literal in subject or predicate position")
+		}
+		if err == nil {
+			p.skipSpace()
+			if !strings.HasPrefix(p.text[p.pos:], ".") {
+				err = fmt.Errorf("expected . at column %v", p.pos+1)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("encoding: n-triples line %v: %w", line, err)
+		}
+		triples = append(triples, tr)
+	}
+	return triples, scanner.Err()
+}
+
+type ntriplesParser struct {
+	text string
+	pos  int
+}
+
+func (p *ntriplesParser) skipSpace() {
+	for p.pos < len(p.text) && (p.text[p.pos] == ' ' || p.text[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *ntriplesParser) term() (rdfTerm, error) {
+	p.skipSpace()
+	if p.pos >= len(p.text) {
+		return rdfTerm{}, fmt.Errorf("unexpected end of line")
+	}
+	switch {
+	case p.text[p.pos] == '<':
+		iri, err := p.iri()
+		return rdfTerm{value: iri}, err
+	case strings.HasPrefix(p.text[p.pos:], "_:"):
+		start := p.pos
+		for p.pos < len(p.text) && p.text[p.pos] != ' ' && p.text[p.pos] != '\t' {
+			p.pos++
+		}
+		return rdfTerm{value: strings.TrimSuffix(p.text[start:p.pos], ".")}, nil
+	case p.text[p.pos] == '"':
+		return p.literal()
+	}
+	return rdfTerm{}, fmt.Errorf("unexpected %q at column %v", p.text[p.pos], p.pos+1)
+}
+
+func (p *ntriplesParser) iri() (string, error) {
+	end := strings.IndexByte(p.text[p.pos:], '>')
+	if end < 0 {
+		return "", fmt.Errorf("unterminated IRI at column %v", p.pos+1)
+	}
+	iri := p.text[p.pos+1 : p.pos+end]
+	p.pos += end + 1
+	return unescapeRDF(iri)
+}
+
+func (p *ntriplesParser) literal() (rdfTerm, error) {
+	start := p.pos
+	p.pos++
+	for ; p.pos < len(p.text); p.pos++ {
+		if p.text[p.pos] == '\\' {
+			p.pos++
+			continue
+		}
+		if p.text[p.pos] == '"' {
+			break
+		}
+	}
+	if p.pos >= len(p.text) {
+		return rdfTerm{}, fmt.Errorf("unterminated literal at column %v", start+1)
+	}
+	value, err := unescapeRDF(p.text[start+1 : p.pos])
+	if err != nil {
+		return rdfTerm{}, err
+	}
+	p.pos++
+	term := rdfTerm{value: value, literal: true, datatype: xsdString}
+	switch {
+	case strings.HasPrefix(p.text[p.pos:], "^^<"):
+		p.pos += 2
+		if term.datatype, err = p.iri(); err != nil {
+			return rdfTerm{}, err
+		}
+	case strings.HasPrefix(p.text[p.pos:], "@"):
+		// language tagged literals are decoded as plain strings
+		for p.pos < len(p.text) && p.text[p.pos] != ' ' && p.text[p.pos] != '\t' && p.text[p.pos] != '.' {
+			p.pos++
+		}
+	}
+	return term, nil
+}
+
+// unescapeRDF replaces the string & unicode escapes of an N-Triples literal or IRI
+func unescapeRDF(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'b':
+			b.WriteByte('\b')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u', 'U':
+			size := 4
+			if s[i] == 'U' {
+				size = 8
+			}
+			if i+size >= len(s) {
+				return "", fmt.Errorf("invalid unicode escape %q", s[i-1:])
+			}
+			r, err := strconv.ParseUint(s[i+1:i+1+size], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(r)) {
+				return "", fmt.Errorf("invalid unicode escape %q", s[i-1:i+1+size])
+			}
+			b.WriteRune(rune(r))
+			i += size
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,134 @@
+package dagger
+
+import (
+	"github.com/autom8ter/dagger/primitive"
+)
+
+// Graph is an independent, concurrency safe graph instance. A process may hold many graphs(ex: one per tenant).
+// The package level functions operate on the default graph.
+type Graph struct {
+	graph *primitive.Graph
+	// view is true for graphs handed out by a ReadOnlyGraph, whose nodes & edges may not be mutated
+	view bool
+	// tracer starts spans around graph operations(see WithTracerProvider)
+	tracer Tracer
+	// options are the primitive graph options collected by NewGraph's options
+	options []primitive.GraphOption
+}
+
+// Option configures a Graph
+type Option func(g *Graph)
+
+// WithPrimitive backs the graph with an existing primitive graph. The other options are applied to it, so it should not
+// be combined with WithStorage.
+func WithPrimitive(graph *primitive.Graph) Option {
+	return func(g *Graph) {
+		g.graph = graph
+	}
+}
+
+// WithStorage keeps the graph in the storage(ex: primitive.OpenDiskStorage) instead of in memory
+func WithStorage(s primitive.Storage) Option {
+	return func(g *Graph) {
+		g.options = append(g.options, primitive.WithStorage(s))
+	}
+}
+
+// WithMetricsSink reports the graph's mutation counts & traversal timings to the sink
+func WithMetricsSink(sink primitive.MetricsSink) Option {
+	return func(g *Graph) {
+		g.options = append(g.options, func(graph *primitive.Graph) {
+			graph.SetMetricsSink(sink)
+		})
+	}
+}
+
+// WithReadOnly creates the graph frozen. Call SetReadOnly(false) to allow mutations.
+func WithReadOnly() Option {
+	return func(g *Graph) {
+		g.options = append(g.options, func(graph *primitive.Graph) {
+			graph.SetReadOnly(true)
+		})
+	}
+}
+
+// UniqueEdges rejects parallel edges between the same two nodes with ErrDuplicateEdge. If perType is true, there may be
+// one edge of each type between two nodes; otherwise there may only be one edge of any type.
+func UniqueEdges(perType bool) Option {
+	return func(g *Graph) {
+		g.options = append(g.options, primitive.UniqueEdges(perType))
+	}
+}
+
+// WithHistory retains the most recent keep revisions of every node, recording a revision each time a node is added
+// or patched(see Node.History)
+func WithHistory(keep int) Option {
+	return func(g *Graph) {
+		g.options = append(g.options, primitive.WithHistory(keep))
+	}
+}
+
+// Order is the order a graph iterates over its nodes & edges in
+type Order = primitive.Order
+
+const (
+	// Unordered iterates in no particular order. It is the default & the fastest.
+	Unordered = primitive.Unordered
+	// InsertionOrder iterates in the order nodes & edges were first added
+	InsertionOrder = primitive.InsertionOrder
+	// IDOrder iterates sorted by type, then id
+	IDOrder = primitive.IDOrder
+)
+
+// WithOrder makes Range*, EdgesFrom & EdgesTo iterate in the given order, so tests & exports are deterministic.
+// Ordered iteration sorts the elements before iterating, so it is slower than the default.
+func WithOrder(order Order) Option {
+	return func(g *Graph) {
+		g.options = append(g.options, primitive.WithOrder(order))
+	}
+}
+
+// writable returns ErrReadOnly if the graph is a read only view
+func (g *Graph) writable() error {
+	if g.view {
+		return ErrReadOnly
+	}
+	return nil
+}
+
+// NewGraph creates a new, empty graph instance. The options may be passed in any order.
+func NewGraph(opts ...Option) *Graph {
+	g := &Graph{}
+	for _, o := range opts {
+		o(g)
+	}
+	if g.graph == nil {
+		g.graph = primitive.NewGraph(g.options...)
+	} else {
+		for _, o := range g.options {
+			o(g.graph)
+		}
+	}
+	g.options = nil
+	return g
+}
+
+var defaultGraph = NewGraph()
+
+// Default returns the default graph that the package level functions operate on
+func Default() *Graph {
+	return defaultGraph
+}
+
+// Primitive returns the primitive graph backing the graph
+func (g *Graph) Primitive() *primitive.Graph {
+	return g.graph
+}
+
+func (g *Graph) node(n primitive.TypedID) *Node {
+	return &Node{TypedID: n, graph: g}
+}
+
+func (g *Graph) edge(e primitive.TypedID) *Edge {
+	return &Edge{TypedID: e, graph: g}
+}
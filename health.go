@@ -0,0 +1,56 @@
+package dagger
+
+import (
+	"encoding/json"
+	"github.com/autom8ter/dagger/primitive"
+	"net/http"
+)
+
+// Health reports the node/edge counts and integrity of the graph, the state of its storage & write-ahead log and the
+// age of its last snapshot
+func (g *Graph) Health() *primitive.Health {
+	return g.graph.Health()
+}
+
+// Health calls Graph.Health on the default graph
+func Health() *primitive.Health {
+	return defaultGraph.Health()
+}
+
+// HealthHandler returns an http handler that reports the graph's health as JSON. It always responds 200 while the
+// process is able to serve the graph.
+func (g *Graph) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, g.Health(), http.StatusOK)
+	})
+}
+
+// HealthHandler calls Graph.HealthHandler on the default graph
+func HealthHandler() http.Handler {
+	return defaultGraph.HealthHandler()
+}
+
+// ReadyHandler returns an http handler that reports the graph's health as JSON. It responds 503 if the graph failed
+// any health check(including a storage or write-ahead log write failing) so orchestrators can hold traffic until the
+// graph is consistent & its changes are persisted again.
+func (g *Graph) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := g.Health()
+		status := http.StatusOK
+		if !h.Healthy() {
+			status = http.StatusServiceUnavailable
+		}
+		writeHealth(w, h, status)
+	})
+}
+
+// ReadyHandler calls Graph.ReadyHandler on the default graph
+func ReadyHandler() http.Handler {
+	return defaultGraph.ReadyHandler()
+}
+
+func writeHealth(w http.ResponseWriter, h *primitive.Health, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(h)
+}
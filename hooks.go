@@ -0,0 +1,92 @@
+package dagger
+
+import (
+	"context"
+	"github.com/autom8ter/dagger/primitive"
+)
+
+// HookAsync runs a hook in its own goroutine instead of before the mutation returns
+func HookAsync() primitive.HookOption {
+	return primitive.HookAsync()
+}
+
+// OnNodeAdded registers a hook that is passed every node added to the graph
+func (g *Graph) OnNodeAdded(fn func(n *Node), opts ...primitive.HookOption) {
+	g.graph.OnNodeAdded(func(n primitive.Node) {
+		fn(g.node(n))
+	}, opts...)
+}
+
+// OnNodeAdded calls Graph.OnNodeAdded on the default graph
+func OnNodeAdded(fn func(n *Node), opts ...primitive.HookOption) {
+	defaultGraph.OnNodeAdded(fn, opts...)
+}
+
+// OnNodePatched registers a hook that is passed every node whose attributes are changed
+func (g *Graph) OnNodePatched(fn func(n *Node), opts ...primitive.HookOption) {
+	g.graph.OnNodePatched(func(n primitive.Node) {
+		fn(g.node(n))
+	}, opts...)
+}
+
+// OnNodePatched calls Graph.OnNodePatched on the default graph
+func OnNodePatched(fn func(n *Node), opts ...primitive.HookOption) {
+	defaultGraph.OnNodePatched(fn, opts...)
+}
+
+// OnNodeDeleted registers a hook that is passed a copy of every node deleted from the graph. The node no longer exists
+// in the graph, so its attributes are passed as they were when it was deleted.
+func (g *Graph) OnNodeDeleted(fn func(n primitive.Node), opts ...primitive.HookOption) {
+	g.graph.OnNodeDeleted(fn, opts...)
+}
+
+// OnNodeDeleted calls Graph.OnNodeDeleted on the default graph
+func OnNodeDeleted(fn func(n primitive.Node), opts ...primitive.HookOption) {
+	defaultGraph.OnNodeDeleted(fn, opts...)
+}
+
+// OnEdgeAdded registers a hook that is passed every edge added to the graph
+func (g *Graph) OnEdgeAdded(fn func(e *Edge), opts ...primitive.HookOption) {
+	g.graph.OnEdgeAdded(func(e *primitive.Edge) {
+		fn(g.edge(e))
+	}, opts...)
+}
+
+// OnEdgeAdded calls Graph.OnEdgeAdded on the default graph
+func OnEdgeAdded(fn func(e *Edge), opts ...primitive.HookOption) {
+	defaultGraph.OnEdgeAdded(fn, opts...)
+}
+
+// OnEdgePatched registers a hook that is passed every edge whose attributes are changed
+func (g *Graph) OnEdgePatched(fn func(e *Edge), opts ...primitive.HookOption) {
+	g.graph.OnEdgePatched(func(e *primitive.Edge) {
+		fn(g.edge(e))
+	}, opts...)
+}
+
+// OnEdgePatched calls Graph.OnEdgePatched on the default graph
+func OnEdgePatched(fn func(e *Edge), opts ...primitive.HookOption) {
+	defaultGraph.OnEdgePatched(fn, opts...)
+}
+
+// OnEdgeDeleted registers a hook that is passed a copy of every edge deleted from the graph. The edge no longer exists
+// in the graph, so its attributes & endpoints are passed as they were when it was deleted.
+func (g *Graph) OnEdgeDeleted(fn func(e *primitive.Edge), opts ...primitive.HookOption) {
+	g.graph.OnEdgeDeleted(fn, opts...)
+}
+
+// OnEdgeDeleted calls Graph.OnEdgeDeleted on the default graph
+func OnEdgeDeleted(fn func(e *primitive.Edge), opts ...primitive.HookOption) {
+	defaultGraph.OnEdgeDeleted(fn, opts...)
+}
+
+// Subscribe returns a channel of the changes made to the graph that pass the filter. The channel is closed once the
+// context is done.
+func (g *Graph) Subscribe(ctx context.Context, filter primitive.ChangeFilter) (<-chan primitive.Change, error) {
+	return g.graph.Subscribe(ctx, filter)
+}
+
+// Subscribe calls Graph.Subscribe on the default graph
+func Subscribe(ctx context.Context, filter primitive.ChangeFilter) (<-chan primitive.Change, error) {
+	return defaultGraph.Subscribe(ctx, filter)
+}
@@ -0,0 +1,103 @@
+package loadtest
+
+import (
+	"fmt"
+	"github.com/autom8ter/dagger/primitive"
+	"math/rand"
+	"sync"
+)
+
+// GraphTarget loads an in-process graph. Writes add a node connected to a random existing node, reads get a random
+// node, and traversals walk a random node's neighborhood breadth first.
+type GraphTarget struct {
+	graph    *primitive.Graph
+	nodeType string
+	edgeType string
+	depth    int
+	mu       sync.RWMutex
+	ids      []primitive.Node
+}
+
+// NewGraphTarget creates a GraphTarget that seeds the graph with the given number of nodes of nodeType, connected by
+// edges of edgeType. Traversals are bounded to the given depth.
+func NewGraphTarget(g *primitive.Graph, nodeType, edgeType string, seed, depth int) (*GraphTarget, error) {
+	t := &GraphTarget{
+		graph:    g,
+		nodeType: nodeType,
+		edgeType: edgeType,
+		depth:    depth,
+	}
+	rng := rand.New(rand.NewSource(0))
+	for i := 0; i < seed; i++ {
+		if err := t.Write(rng); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func (t *GraphTarget) random(rng *rand.Rand) (primitive.Node, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if len(t.ids) == 0 {
+		return nil, false
+	}
+	return t.ids[rng.Intn(len(t.ids))], true
+}
+
+// Read gets a random node
+func (t *GraphTarget) Read(rng *rand.Rand) error {
+	id, ok := t.random(rng)
+	if !ok {
+		return nil
+	}
+	if _, ok := t.graph.GetNode(id); !ok {
+		return fmt.Errorf("loadtest: node %s.%s does not exist", id.Type(), id.ID())
+	}
+	return nil
+}
+
+// Write adds a node connected to a random existing node
+func (t *GraphTarget) Write(rng *rand.Rand) error {
+	n := primitive.Node{
+		primitive.TYPE_KEY: t.nodeType,
+		primitive.ID_KEY:   primitive.UUID(),
+		"value":            rng.Int(),
+	}
+	to, hasTo := t.random(rng)
+	if err := t.graph.AddNode(n); err != nil {
+		return err
+	}
+	if hasTo {
+		if err := t.graph.AddEdge(&primitive.Edge{
+			Node: primitive.Node{primitive.TYPE_KEY: t.edgeType},
+			From: n,
+			To:   to,
+		}); err != nil {
+			return err
+		}
+	}
+	key := primitive.Node{primitive.TYPE_KEY: t.nodeType, primitive.ID_KEY: n.ID()}
+	t.mu.Lock()
+	t.ids = append(t.ids, key)
+	t.mu.Unlock()
+	return nil
+}
+
+// Traverse walks a random node's neighborhood
+func (t *GraphTarget) Traverse(rng *rand.Rand) error {
+	id, ok := t.random(rng)
+	if !ok {
+		return nil
+	}
+	t.graph.BFS(id, edgeType(t.edgeType), primitive.TraversalOptions{MaxDepth: t.depth}, func(n primitive.Node, depth int) bool {
+		return true
+	})
+	return nil
+}
+
+type edgeType string
+
+func (e edgeType) Type() string {
+	return string(e)
+}
@@ -0,0 +1,191 @@
+// Package loadtest generates synthetic read/write/traversal workloads against a graph and reports throughput & latency
+package loadtest
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	OpRead     = "read"
+	OpWrite    = "write"
+	OpTraverse = "traverse"
+)
+
+// Target is the system under load. Implement it to load test a remote endpoint; see GraphTarget for an in-process graph.
+// Implementations must be concurrency safe.
+type Target interface {
+	Read(rng *rand.Rand) error
+	Write(rng *rand.Rand) error
+	Traverse(rng *rand.Rand) error
+}
+
+// Mix is the relative weight of each operation in the workload(ex: 8 reads for every 1 write & 1 traversal)
+type Mix struct {
+	Reads      int
+	Writes     int
+	Traversals int
+}
+
+// Config configures a load test. The test runs until Operations have been executed or Duration has elapsed,
+// whichever comes first. At least one of them must be set.
+type Config struct {
+	// Workers is the number of concurrent goroutines issuing operations
+	Workers int
+	// Operations is the total number of operations to execute
+	Operations int
+	// Duration is the max length of the test
+	Duration time.Duration
+	// Mix is the operation mix
+	Mix Mix
+	// Seed seeds the workers' random number generators so workloads are reproducible
+	Seed int64
+}
+
+// OpStats are the results of a single operation type
+type OpStats struct {
+	Count  int           `json:"count"`
+	Errors int           `json:"errors"`
+	P50    time.Duration `json:"p50"`
+	P90    time.Duration `json:"p90"`
+	P99    time.Duration `json:"p99"`
+	Max    time.Duration `json:"max"`
+}
+
+// Report is the result of a load test
+type Report struct {
+	Duration   time.Duration       `json:"duration"`
+	Operations int                 `json:"operations"`
+	Throughput float64             `json:"throughput"`
+	Stats      map[string]*OpStats `json:"stats"`
+}
+
+func (r *Report) String() string {
+	var ops []string
+	for op := range r.Stats {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+	lines := []string{fmt.Sprintf("%v operations in %v (%.0f ops/s)", r.Operations, r.Duration, r.Throughput)}
+	for _, op := range ops {
+		s := r.Stats[op]
+		lines = append(lines, fmt.Sprintf("%-9s count=%v errors=%v p50=%v p90=%v p99=%v max=%v", op, s.Count, s.Errors, s.P50, s.P90, s.P99, s.Max))
+	}
+	return strings.Join(lines, "\n")
+}
+
+type sample struct {
+	op      string
+	latency time.Duration
+	err     error
+}
+
+// Run executes the workload against the target and reports the results
+func Run(target Target, cfg Config) (*Report, error) {
+	if cfg.Operations <= 0 && cfg.Duration <= 0 {
+		return nil, fmt.Errorf("loadtest: one of Operations or Duration must be set")
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	total := cfg.Mix.Reads + cfg.Mix.Writes + cfg.Mix.Traversals
+	if total <= 0 {
+		return nil, fmt.Errorf("loadtest: empty operation mix")
+	}
+	var deadline time.Time
+	if cfg.Duration > 0 {
+		deadline = time.Now().Add(cfg.Duration)
+	}
+	var (
+		mu        sync.Mutex
+		remaining = cfg.Operations
+		samples   = make([][]sample, cfg.Workers)
+		wg        sync.WaitGroup
+	)
+	next := func() bool {
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return false
+		}
+		if cfg.Operations <= 0 {
+			return true
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if remaining <= 0 {
+			return false
+		}
+		remaining--
+		return true
+	}
+	start := time.Now()
+	for w := 0; w < cfg.Workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(cfg.Seed + int64(w)))
+			for next() {
+				var (
+					op   string
+					fn   func(rng *rand.Rand) error
+					pick = rng.Intn(total)
+				)
+				switch {
+				case pick < cfg.Mix.Reads:
+					op, fn = OpRead, target.Read
+				case pick < cfg.Mix.Reads+cfg.Mix.Writes:
+					op, fn = OpWrite, target.Write
+				default:
+					op, fn = OpTraverse, target.Traverse
+				}
+				began := time.Now()
+				err := fn(rng)
+				samples[w] = append(samples[w], sample{op: op, latency: time.Since(began), err: err})
+			}
+		}(w)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	latencies := map[string][]time.Duration{}
+	report := &Report{
+		Duration: elapsed,
+		Stats:    map[string]*OpStats{},
+	}
+	for _, worker := range samples {
+		for _, s := range worker {
+			stats, ok := report.Stats[s.op]
+			if !ok {
+				stats = &OpStats{}
+				report.Stats[s.op] = stats
+			}
+			stats.Count++
+			if s.err != nil {
+				stats.Errors++
+			}
+			latencies[s.op] = append(latencies[s.op], s.latency)
+			report.Operations++
+		}
+	}
+	for op, l := range latencies {
+		sort.Slice(l, func(i, j int) bool {
+			return l[i] < l[j]
+		})
+		stats := report.Stats[op]
+		stats.P50 = percentile(l, 0.50)
+		stats.P90 = percentile(l, 0.90)
+		stats.P99 = percentile(l, 0.99)
+		stats.Max = l[len(l)-1]
+	}
+	if elapsed > 0 {
+		report.Throughput = float64(report.Operations) / elapsed.Seconds()
+	}
+	return report, nil
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	i := int(float64(len(sorted)-1) * p)
+	return sorted[i]
+}
@@ -0,0 +1,43 @@
+package dagger
+
+import (
+	"github.com/autom8ter/dagger/primitive"
+)
+
+// Pattern describes a subgraph to search for with Graph.Match
+type Pattern = primitive.Pattern
+
+// PatternNode is a node variable in a Pattern
+type PatternNode = primitive.PatternNode
+
+// PatternEdge is an edge variable in a Pattern
+type PatternEdge = primitive.PatternEdge
+
+// Match is a subgraph that matched a Pattern, keyed by variable
+type Match struct {
+	Nodes map[string]*Node
+	Edges map[string]*Edge
+}
+
+// Match returns every subgraph that matches the pattern, with the nodes & edges bound to each of its variables. It is
+// the building block for rule engines over the graph.
+func (g *Graph) Match(pattern *Pattern) []Match {
+	defer g.trace("Match", nil)()
+	var matches []Match
+	for _, m := range g.graph.Match(pattern) {
+		match := Match{Nodes: map[string]*Node{}, Edges: map[string]*Edge{}}
+		for k, n := range m.Nodes {
+			match.Nodes[k] = g.node(n)
+		}
+		for k, e := range m.Edges {
+			match.Edges[k] = g.edge(e)
+		}
+		matches = append(matches, match)
+	}
+	return matches
+}
+
+// MatchPattern calls Graph.Match on the default graph
+func MatchPattern(pattern *Pattern) []Match {
+	return defaultGraph.Match(pattern)
+}
@@ -0,0 +1,176 @@
+package dagger
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/autom8ter/dagger/primitive"
+	"io"
+	"sort"
+	"strings"
+)
+
+// mermaidPalette colors the node types of a diagram in the order the types sort in
+var mermaidPalette = []string{
+	"fill:#dbeafe,stroke:#1d4ed8",
+	"fill:#dcfce7,stroke:#15803d",
+	"fill:#fef3c7,stroke:#b45309",
+	"fill:#fce7f3,stroke:#be185d",
+	"fill:#ede9fe,stroke:#6d28d9",
+	"fill:#e0f2fe,stroke:#0369a1",
+	"fill:#fee2e2,stroke:#b91c1c",
+	"fill:#f3f4f6,stroke:#374151",
+}
+
+type mermaidConfig struct {
+	labels     []string
+	direction  string
+	styles     map[string]string
+	unstyled   bool
+	layered    primitive.Type
+	transforms []primitive.Transform
+}
+
+// MermaidOption configures ExportMermaid
+type MermaidOption func(c *mermaidConfig)
+
+// MermaidTransforms applies the transforms(ex: primitive.HashAttributes) to a copy of every exported node & edge, as in
+// ExportJSON
+func MermaidTransforms(transforms ...primitive.Transform) MermaidOption {
+	return func(c *mermaidConfig) {
+		c.transforms = append(c.transforms, transforms...)
+	}
+}
+
+// MermaidNodeLabel labels each node with the first of the attributes that it has(the default is name).
+// Nodes without any of the attributes are labelled with their type & id.
+func MermaidNodeLabel(attributes ...string) MermaidOption {
+	return func(c *mermaidConfig) {
+		c.labels = attributes
+	}
+}
+
+// MermaidDirection sets the direction of the flowchart: TD(the default), LR, BT or RL
+func MermaidDirection(dir string) MermaidOption {
+	return func(c *mermaidConfig) {
+		c.direction = dir
+	}
+}
+
+// MermaidTypeStyle styles the nodes of the type with the CSS style(ex: fill:#f9f,stroke:#333), overriding the color
+// the type is given by default
+func MermaidTypeStyle(nodeType, style string) MermaidOption {
+	return func(c *mermaidConfig) {
+		c.styles[nodeType] = style
+	}
+}
+
+// MermaidUnstyled disables the default per type colors, so only the types given a MermaidTypeStyle are styled
+func MermaidUnstyled() MermaidOption {
+	return func(c *mermaidConfig) {
+		c.unstyled = true
+	}
+}
+
+// MermaidLayeredLayout declares the nodes in the order of the graph's LayeredLayout over edges of the given type, rank by
+// rank. Mermaid places nodes itself, but starts from the order they are declared in, so the diagram keeps the layout's
+// crossing minimized order.
+func MermaidLayeredLayout(edgeType primitive.Type) MermaidOption {
+	return func(c *mermaidConfig) {
+		c.layered = edgeType
+	}
+}
+
+// ExportMermaid writes the graph as a Mermaid flowchart(graph TD), which renders in markdown documents & pull request
+// descriptions when wrapped in a ```mermaid block. Nodes are labelled from their attributes, edges with their type, and
+// each node type is given its own color.
+func (g *Graph) ExportMermaid(w io.Writer, opts ...MermaidOption) error {
+	defer g.trace("ExportMermaid", nil)()
+	c := &mermaidConfig{
+		labels:    []string{"name"},
+		direction: "TD",
+		styles:    map[string]string{},
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	export := g.export(c.transforms)
+	sort.Slice(export.Nodes, func(i, j int) bool {
+		return dotID(export.Nodes[i]) < dotID(export.Nodes[j])
+	})
+	if c.layered != nil {
+		layout := g.graph.LayeredLayout(c.layered)
+		sort.SliceStable(export.Nodes, func(i, j int) bool {
+			a, aok := layout.Position(export.Nodes[i])
+			b, bok := layout.Position(export.Nodes[j])
+			if aok != bok {
+				// nodes added since the layout was computed go last
+				return aok
+			}
+			if a.Rank != b.Rank {
+				return a.Rank < b.Rank
+			}
+			return a.Order < b.Order
+		})
+	}
+	sort.Slice(export.Edges, func(i, j int) bool {
+		a, b := export.Edges[i], export.Edges[j]
+		if dotID(a.From) != dotID(b.From) {
+			return dotID(a.From) < dotID(b.From)
+		}
+		if dotID(a.To) != dotID(b.To) {
+			return dotID(a.To) < dotID(b.To)
+		}
+		return a.Type() < b.Type()
+	})
+	// mermaid ids may only contain word characters, so nodes are numbered
+	ids := map[string]string{}
+	byType := map[string][]string{}
+	var types []string
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "graph %s\n", c.direction)
+	for i, n := range export.Nodes {
+		id := fmt.Sprintf("n%v", i)
+		ids[dotID(n)] = id
+		if _, ok := byType[n.Type()]; !ok {
+			types = append(types, n.Type())
+		}
+		byType[n.Type()] = append(byType[n.Type()], id)
+		fmt.Fprintf(bw, "    %s[%s]\n", id, mermaidQuote(c.label(n)))
+	}
+	for _, e := range export.Edges {
+		fmt.Fprintf(bw, "    %s -->|%s| %s\n", ids[dotID(e.From)], mermaidQuote(e.Type()), ids[dotID(e.To)])
+	}
+	sort.Strings(types)
+	for i, typ := range types {
+		style, ok := c.styles[typ]
+		if !ok {
+			if c.unstyled {
+				continue
+			}
+			style = mermaidPalette[i%len(mermaidPalette)]
+		}
+		class := fmt.Sprintf("t%v", i)
+		fmt.Fprintf(bw, "    classDef %s %s\n", class, style)
+		fmt.Fprintf(bw, "    class %s %s\n", strings.Join(byType[typ], ","), class)
+	}
+	return bw.Flush()
+}
+
+// ExportMermaid calls Graph.ExportMermaid on the default graph
+func ExportMermaid(w io.Writer, opts ...MermaidOption) error {
+	return defaultGraph.ExportMermaid(w, opts...)
+}
+
+func (c *mermaidConfig) label(n primitive.Node) string {
+	for _, attr := range c.labels {
+		if n.Exists(attr) {
+			return fmt.Sprint(n.Get(attr))
+		}
+	}
+	return dotID(n)
+}
+
+// mermaidQuote quotes the text, replacing the characters mermaid does not allow in labels with entity codes
+func mermaidQuote(s string) string {
+	return `"` + strings.NewReplacer(`"`, "#quot;", "\n", "<br>", "|", "#124;").Replace(s) + `"`
+}
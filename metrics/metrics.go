@@ -0,0 +1,124 @@
+// Package metrics exposes a dagger graph's node & edge counts per type, mutation rates, traversal latencies & lock wait
+// times as a Prometheus collector(github.com/prometheus/client_golang), ex:
+//
+//	prometheus.MustRegister(metrics.Collector(g))
+//	http.Handle("/metrics", promhttp.Handler())
+//
+// The package is a separate module, so programs that do not export metrics to Prometheus do not depend on its client.
+package metrics
+
+import (
+	"github.com/autom8ter/dagger"
+	"github.com/autom8ter/dagger/primitive"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+// latencyBuckets are the upper bounds(in seconds) of the histogram buckets for traversal & lock wait timings
+var latencyBuckets = []float64{0.00001, 0.0001, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5}
+
+// counters maps the graph's mutation counters to the names they are exported as
+var counters = map[string]string{
+	primitive.MetricNodesAdded:   "dagger_nodes_added_total",
+	primitive.MetricNodesDeleted: "dagger_nodes_deleted_total",
+	primitive.MetricNodesPatched: "dagger_nodes_patched_total",
+	primitive.MetricEdgesAdded:   "dagger_edges_added_total",
+	primitive.MetricEdgesDeleted: "dagger_edges_deleted_total",
+}
+
+// GraphCollector is a prometheus.Collector of a graph's metrics. It is also the graph's metrics sink: it aggregates the
+// graph's mutation counters, traversal timings & lock waits as they are reported.
+type GraphCollector struct {
+	graph     *dagger.Graph
+	next      primitive.MetricsSink
+	nodes     *prometheus.Desc
+	edges     *prometheus.Desc
+	counters  map[string]*prometheus.CounterVec
+	traversal *prometheus.HistogramVec
+	lockWait  prometheus.Histogram
+}
+
+// Collector starts collecting the graph's metrics. The collector becomes the graph's metrics sink, forwarding to the
+// sink it replaces(if any). Register it with a prometheus.Registerer to export the metrics.
+func Collector(g *dagger.Graph) *GraphCollector {
+	c := &GraphCollector{
+		graph:    g,
+		next:     g.Primitive().MetricsSink(),
+		nodes:    prometheus.NewDesc("dagger_nodes", "Number of nodes by type", []string{"type"}, nil),
+		edges:    prometheus.NewDesc("dagger_edges", "Number of edges by type", []string{"type"}, nil),
+		counters: map[string]*prometheus.CounterVec{},
+		traversal: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "dagger_traversal_seconds",
+			Help:    "Latency of traversals in seconds",
+			Buckets: latencyBuckets,
+		}, []string{"kind"}),
+		lockWait: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "dagger_lock_wait_seconds",
+			Help:    "Time mutations waited to acquire the graph's write lock in seconds",
+			Buckets: latencyBuckets,
+		}),
+	}
+	for metric, name := range counters {
+		c.counters[metric] = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: name,
+			Help: "Total " + metric,
+		}, []string{"type"})
+	}
+	g.Primitive().SetMetricsSink(c)
+	return c
+}
+
+// Describe implements prometheus.Collector
+func (c *GraphCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.nodes
+	ch <- c.edges
+	for _, counter := range c.counters {
+		counter.Describe(ch)
+	}
+	c.traversal.Describe(ch)
+	c.lockWait.Describe(ch)
+}
+
+// Collect implements prometheus.Collector. The node & edge counts are read from the counts the graph maintains by type,
+// so a scrape never scans the graph.
+func (c *GraphCollector) Collect(ch chan<- prometheus.Metric) {
+	for typ, count := range c.graph.NodeTypeCounts() {
+		ch <- prometheus.MustNewConstMetric(c.nodes, prometheus.GaugeValue, float64(count), typ)
+	}
+	for typ, count := range c.graph.EdgeTypeCounts() {
+		ch <- prometheus.MustNewConstMetric(c.edges, prometheus.GaugeValue, float64(count), typ)
+	}
+	for _, counter := range c.counters {
+		counter.Collect(ch)
+	}
+	c.traversal.Collect(ch)
+	c.lockWait.Collect(ch)
+}
+
+// Count adds the value to the named mutation counter
+func (c *GraphCollector) Count(name string, value int64, tags map[string]string) {
+	if counter, ok := c.counters[name]; ok {
+		counter.WithLabelValues(tags["type"]).Add(float64(value))
+	}
+	if c.next != nil {
+		c.next.Count(name, value, tags)
+	}
+}
+
+// Timing records the duration of a traversal
+func (c *GraphCollector) Timing(name string, value time.Duration, tags map[string]string) {
+	if name == primitive.MetricTraversal {
+		c.traversal.WithLabelValues(tags["kind"]).Observe(value.Seconds())
+	}
+	if c.next != nil {
+		c.next.Timing(name, value, tags)
+	}
+}
+
+// LockWait records the time a mutation waited to acquire the graph's write lock
+func (c *GraphCollector) LockWait(value time.Duration) {
+	c.lockWait.Observe(value.Seconds())
+	if next, ok := c.next.(primitive.LockWaitSink); ok {
+		next.LockWait(value)
+	}
+}
@@ -0,0 +1,78 @@
+package metrics_test
+
+import (
+	"github.com/autom8ter/dagger"
+	"github.com/autom8ter/dagger/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCollector(t *testing.T) {
+	g := dagger.NewGraph()
+	collector := metrics.Collector(g)
+	registry := prometheus.NewPedanticRegistry()
+	if err := registry.Register(collector); err != nil {
+		t.Fatal(err)
+	}
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman"})
+	charlie := g.NewNode(map[string]interface{}{"_type": "dog", "_id": "charlie"})
+	if _, err := coleman.Connect(charlie, "pet", false); err != nil {
+		t.Fatal(err)
+	}
+	coleman.BFS(0, func(n *dagger.Node) bool { return true })
+
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP dagger_nodes Number of nodes by type
+# TYPE dagger_nodes gauge
+dagger_nodes{type="dog"} 1
+dagger_nodes{type="user"} 1
+# HELP dagger_edges Number of edges by type
+# TYPE dagger_edges gauge
+dagger_edges{type="pet"} 1
+# HELP dagger_nodes_added_total Total nodes.added
+# TYPE dagger_nodes_added_total counter
+dagger_nodes_added_total{type="dog"} 1
+dagger_nodes_added_total{type="user"} 1
+`), "dagger_nodes", "dagger_edges", "dagger_nodes_added_total"); err != nil {
+		t.Fatal(err)
+	}
+	// deleted types are no longer reported
+	if err := charlie.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP dagger_nodes Number of nodes by type
+# TYPE dagger_nodes gauge
+dagger_nodes{type="user"} 1
+`), "dagger_nodes", "dagger_edges"); err != nil {
+		t.Fatal(err)
+	}
+	if count := testutil.CollectAndCount(collector, "dagger_traversal_seconds"); count != 1 {
+		t.Fatalf("expected a bfs traversal histogram, got %v series", count)
+	}
+
+	server := httptest.NewServer(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	defer server.Close()
+	resp, err := server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`dagger_traversal_seconds_count{kind="bfs"} 1`,
+		`dagger_lock_wait_seconds_bucket{le="+Inf"}`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Fatalf("expected %q in:\n%s", want, body)
+		}
+	}
+}
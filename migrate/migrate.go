@@ -0,0 +1,94 @@
+// Package migrate applies ordered, versioned changes to the layout of a graph's data
+package migrate
+
+import (
+	"fmt"
+	"github.com/autom8ter/dagger/primitive"
+	"sort"
+)
+
+const (
+	// VersionType is the node type of the node that records the applied migration version
+	VersionType = "_migration"
+	// VersionID is the node id of the node that records the applied migration version
+	VersionID = "version"
+	// VersionKey is the attribute that records the applied migration version
+	VersionKey = "version"
+)
+
+// Migration is a versioned change to the graph
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(g *primitive.Graph) error
+}
+
+// Migrator runs migrations in version order
+type Migrator struct {
+	migrations []Migration
+}
+
+// New creates a Migrator with the given migrations
+func New(migrations ...Migration) *Migrator {
+	m := &Migrator{}
+	for _, migration := range migrations {
+		m.Register(migration.Version, migration.Name, migration.Up)
+	}
+	return m
+}
+
+// Register adds a migration to the Migrator
+func (m *Migrator) Register(version int, name string, up func(g *primitive.Graph) error) {
+	m.migrations = append(m.migrations, Migration{
+		Version: version,
+		Name:    name,
+		Up:      up,
+	})
+	sort.SliceStable(m.migrations, func(i, j int) bool {
+		return m.migrations[i].Version < m.migrations[j].Version
+	})
+}
+
+// Pending returns the migrations that have not been applied to the graph
+func (m *Migrator) Pending(g *primitive.Graph) []Migration {
+	current := Version(g)
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if migration.Version > current {
+			pending = append(pending, migration)
+		}
+	}
+	return pending
+}
+
+// Up applies the pending migrations in version order, recording the version in the graph after each one.
+// It stops at the first migration that fails.
+func (m *Migrator) Up(g *primitive.Graph) ([]Migration, error) {
+	var applied []Migration
+	for _, migration := range m.Pending(g) {
+		if err := migration.Up(g); err != nil {
+			return applied, fmt.Errorf("migration %v(%s) failed: %s", migration.Version, migration.Name, err)
+		}
+		if err := g.AddNode(primitive.Node{
+			primitive.TYPE_KEY: VersionType,
+			primitive.ID_KEY:   VersionID,
+			VersionKey:         migration.Version,
+		}); err != nil {
+			return applied, err
+		}
+		applied = append(applied, migration)
+	}
+	return applied, nil
+}
+
+// Version returns the version of the last migration applied to the graph(0 if none)
+func Version(g *primitive.Graph) int {
+	n, ok := g.GetNode(primitive.Node{
+		primitive.TYPE_KEY: VersionType,
+		primitive.ID_KEY:   VersionID,
+	})
+	if !ok {
+		return 0
+	}
+	return n.GetInt(VersionKey)
+}
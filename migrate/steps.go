@@ -0,0 +1,79 @@
+package migrate
+
+import (
+	"github.com/autom8ter/dagger/primitive"
+)
+
+// RenameAttribute returns a migration step that renames an attribute on every node of the given type
+func RenameAttribute(nodeType, from, to string) func(g *primitive.Graph) error {
+	return func(g *primitive.Graph) error {
+		// the rename happens while matching so it is applied under PatchWhere's write lock
+		_, err := g.PatchWhere(stringType(nodeType), func(n primitive.Node) bool {
+			if !n.Exists(from) {
+				return false
+			}
+			n.Set(to, n.Get(from))
+			n.Del(from)
+			return true
+		}, map[string]interface{}{})
+		return err
+	}
+}
+
+// RetypeNodes returns a migration step that changes the type of every node of one type to another, keeping node ids
+// and re-pointing their edges
+func RetypeNodes(from, to string) func(g *primitive.Graph) error {
+	return func(g *primitive.Graph) error {
+		var nodes []primitive.Node
+		g.RangeNodeTypes(stringType(from), func(n primitive.Node) bool {
+			nodes = append(nodes, n)
+			return true
+		})
+		for _, n := range nodes {
+			retyped := n.Copy()
+			retyped.SetType(to)
+			if err := g.AddNode(retyped); err != nil {
+				return err
+			}
+			if err := g.MoveEdges(n, retyped); err != nil {
+				return err
+			}
+			if err := g.DelNode(n); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// SplitEdges returns a migration step that re-types every edge of the given type to the type returned by split,
+// keeping edge ids, attributes and endpoints
+func SplitEdges(edgeType string, split func(e *primitive.Edge) string) func(g *primitive.Graph) error {
+	return func(g *primitive.Graph) error {
+		var edges []*primitive.Edge
+		g.RangeEdgeTypes(stringType(edgeType), func(e *primitive.Edge) bool {
+			edges = append(edges, e)
+			return true
+		})
+		for _, e := range edges {
+			typ := split(e)
+			if typ == edgeType {
+				continue
+			}
+			if err := g.DelEdge(e); err != nil {
+				return err
+			}
+			e.SetType(typ)
+			if err := g.AddEdge(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+type stringType string
+
+func (s stringType) Type() string {
+	return string(s)
+}
@@ -0,0 +1,195 @@
+package dagger
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/autom8ter/dagger/primitive"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type cypherConfig struct {
+	merge      bool
+	indexes    bool
+	transforms []primitive.Transform
+}
+
+// CypherOption configures ExportCypher
+type CypherOption func(c *cypherConfig)
+
+// CypherTransforms applies the transforms(ex: primitive.HashAttributes) to a copy of every exported node & edge, as in
+// ExportJSON
+func CypherTransforms(transforms ...primitive.Transform) CypherOption {
+	return func(c *cypherConfig) {
+		c.transforms = append(c.transforms, transforms...)
+	}
+}
+
+// CypherMerge emits MERGE statements keyed by each element's label & _id instead of CREATE statements, so the export
+// can be replayed against a database that already holds some of the graph without creating duplicates
+func CypherMerge() CypherOption {
+	return func(c *cypherConfig) {
+		c.merge = true
+	}
+}
+
+// CypherIndexes emits a CREATE INDEX statement on the _id of every node label before the nodes are written, which keeps
+// the endpoint lookups of large imports fast
+func CypherIndexes() CypherOption {
+	return func(c *cypherConfig) {
+		c.indexes = true
+	}
+}
+
+// ExportCypher writes the graph as Cypher statements, one per line, that recreate it in Neo4j(ex: pipe the output to
+// cypher-shell). Node & edge types become labels & relationship types and attributes become properties, including _id.
+// Nested maps are stored as JSON strings, since Neo4j properties may not be maps.
+func (g *Graph) ExportCypher(w io.Writer, opts ...CypherOption) error {
+	defer g.trace("ExportCypher", nil)()
+	c := &cypherConfig{}
+	for _, o := range opts {
+		o(c)
+	}
+	export := g.export(c.transforms)
+	sort.Slice(export.Nodes, func(i, j int) bool {
+		return dotID(export.Nodes[i]) < dotID(export.Nodes[j])
+	})
+	sort.Slice(export.Edges, func(i, j int) bool {
+		return dotID(export.Edges[i]) < dotID(export.Edges[j])
+	})
+	bw := bufio.NewWriter(w)
+	if c.indexes {
+		seen := map[string]bool{}
+		for _, n := range export.Nodes {
+			if !seen[n.Type()] {
+				seen[n.Type()] = true
+				fmt.Fprintf(bw, "CREATE INDEX IF NOT EXISTS FOR (n:%s) ON (n.%s);\n", cypherIdent(n.Type()), cypherIdent(primitive.ID_KEY))
+			}
+		}
+	}
+	for _, n := range export.Nodes {
+		if c.merge {
+			fmt.Fprintf(bw, "MERGE (n:%s {%s}) SET n += %s;\n", cypherIdent(n.Type()), cypherKey(n), cypherProperties(n))
+		} else {
+			fmt.Fprintf(bw, "CREATE (:%s %s);\n", cypherIdent(n.Type()), cypherProperties(n))
+		}
+	}
+	for _, e := range export.Edges {
+		match := fmt.Sprintf("MATCH (a:%s {%s}), (b:%s {%s})", cypherIdent(e.From.Type()), cypherKey(e.From), cypherIdent(e.To.Type()), cypherKey(e.To))
+		if c.merge {
+			fmt.Fprintf(bw, "%s MERGE (a)-[r:%s {%s}]->(b) SET r += %s;\n", match, cypherIdent(e.Type()), cypherKey(e), cypherProperties(e.Node))
+		} else {
+			fmt.Fprintf(bw, "%s CREATE (a)-[:%s %s]->(b);\n", match, cypherIdent(e.Type()), cypherProperties(e.Node))
+		}
+	}
+	return bw.Flush()
+}
+
+// ExportCypher calls Graph.ExportCypher on the default graph
+func ExportCypher(w io.Writer, opts ...CypherOption) error {
+	return defaultGraph.ExportCypher(w, opts...)
+}
+
+// cypherKey returns the property an element is matched by
+func cypherKey(id primitive.TypedID) string {
+	return fmt.Sprintf("%s: %s", cypherIdent(primitive.ID_KEY), cypherString(id.ID()))
+}
+
+// cypherProperties returns a map literal of the node's attributes, excluding its type & any nil values
+func cypherProperties(n primitive.Node) string {
+	var keys []string
+	for k, v := range n {
+		if k != primitive.TYPE_KEY && v != nil {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var props []string
+	for _, k := range keys {
+		props = append(props, fmt.Sprintf("%s: %s", cypherIdent(k), cypherValue(n[k])))
+	}
+	return "{" + strings.Join(props, ", ") + "}"
+}
+
+// cypherValue returns the Cypher literal of a property value
+func cypherValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return cypherString(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float32:
+		return cypherFloat(float64(v))
+	case float64:
+		return cypherFloat(v)
+	case time.Time:
+		return fmt.Sprintf("datetime(%s)", cypherString(v.Format(time.RFC3339Nano)))
+	case []string:
+		values := make([]string, len(v))
+		for i, s := range v {
+			values[i] = cypherString(s)
+		}
+		return "[" + strings.Join(values, ", ") + "]"
+	case []interface{}:
+		values := make([]string, len(v))
+		for i, val := range v {
+			values[i] = cypherValue(val)
+		}
+		return "[" + strings.Join(values, ", ") + "]"
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice {
+		values := make([]string, rv.Len())
+		for i := range values {
+			values[i] = cypherValue(rv.Index(i).Interface())
+		}
+		return "[" + strings.Join(values, ", ") + "]"
+	}
+	bits, err := json.Marshal(v)
+	if err != nil {
+		return cypherString(fmt.Sprint(v))
+	}
+	return cypherString(string(bits))
+}
+
+func cypherFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "0.0/0.0"
+	case math.IsInf(f, 1):
+		return "1.0/0.0"
+	case math.IsInf(f, -1):
+		return "-1.0/0.0"
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eE") {
+		s += ".0"
+	}
+	return s
+}
+
+// cypherString returns a quoted Cypher string literal. JSON escapes are all valid Cypher escapes.
+func cypherString(s string) string {
+	bits, _ := json.Marshal(s)
+	return string(bits)
+}
+
+// cypherIdent returns a backtick quoted Cypher identifier
+func cypherIdent(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
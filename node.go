@@ -4,27 +4,59 @@ import (
 	"errors"
 	"fmt"
 	"github.com/autom8ter/dagger/primitive"
+	"time"
 )
 
+// NewNode creates a new node in the graph.
+// If an id is not provided, a random uuid will be assigned.
+// Nodes that violate a registered schema are not added - use AddNode to handle the error.
+func (g *Graph) NewNode(attributes map[string]interface{}) *Node {
+	data := primitive.NewNode(attributes)
+	data.SetAll(attributes)
+	return g.nodeFrom(data)
+}
+
 // NewNode creates a new node in the global, in-memory graph.
 // If an id is not provided, a random uuid will be assigned.
 func NewNode(attributes map[string]interface{}) *Node {
+	return defaultGraph.NewNode(attributes)
+}
+
+// AddNode creates a new node in the graph, returning ErrSchemaViolation if it violates a registered schema.
+// If an id is not provided, a random uuid will be assigned.
+func (g *Graph) AddNode(attributes map[string]interface{}) (*Node, error) {
 	data := primitive.NewNode(attributes)
 	data.SetAll(attributes)
-	return nodeFrom(data)
+	if err := g.graph.AddNode(data); err != nil {
+		return nil, err
+	}
+	return g.node(data), nil
+}
+
+// AddNode calls Graph.AddNode on the default graph
+func AddNode(attributes map[string]interface{}) (*Node, error) {
+	return defaultGraph.AddNode(attributes)
 }
 
-func nodeFrom(node primitive.Node) *Node {
-	if !globalGraph.HasNode(node) || !node.HasID() {
-		globalGraph.AddNode(node)
-		return &Node{node}
+func (g *Graph) nodeFrom(node primitive.Node) *Node {
+	if !g.graph.HasNode(node) || !node.HasID() {
+		g.graph.AddNode(node)
 	}
-	return &Node{TypedID: node}
+	return g.node(node)
 }
 
 // Node is the most basic element in the graph. Node's may be connected with one another via edges to represent relationships
 type Node struct {
 	primitive.TypedID
+	graph *Graph
+}
+
+// owner returns the graph the node belongs to. Nodes created without a graph belong to the default graph.
+func (n *Node) owner() *Graph {
+	if n.graph == nil {
+		return defaultGraph
+	}
+	return n.graph
 }
 
 func (n *Node) attributes() map[string]interface{} {
@@ -35,18 +67,18 @@ func (n *Node) attributes() map[string]interface{} {
 }
 
 func (n *Node) load() primitive.Node {
-	node, ok := globalGraph.GetNode(n)
-	if !ok {
-		globalGraph.AddNode(primitive.NewNode(n.attributes()))
-		node, ok = globalGraph.GetNode(n)
+	node, ok := n.owner().graph.GetNode(n)
+	if !ok && !n.owner().view {
+		n.owner().graph.AddNode(primitive.NewNode(n.attributes()))
+		node, ok = n.owner().graph.GetNode(n)
 	}
 	return node
 }
 
 // EdgesFrom returns connections/edges that stem from the node/vertex
 func (n *Node) EdgesFrom(edgeType primitive.Type, fn func(edge *Edge) bool) {
-	globalGraph.EdgesFrom(edgeType, n, func(e *primitive.Edge) bool {
-		this, err := edgeFrom(e)
+	n.owner().graph.EdgesFrom(edgeType, n, func(e *primitive.Edge) bool {
+		this, err := n.owner().edgeFrom(e)
 		if err != nil {
 			return true
 		}
@@ -56,8 +88,8 @@ func (n *Node) EdgesFrom(edgeType primitive.Type, fn func(edge *Edge) bool) {
 
 // EdgesTo returns connections/edges that point toward the node/vertex
 func (n *Node) EdgesTo(edgeType primitive.Type, fn func(e *Edge) bool) {
-	globalGraph.EdgesTo(edgeType, n, func(e *primitive.Edge) bool {
-		this, err := edgeFrom(e)
+	n.owner().graph.EdgesTo(edgeType, n, func(e *primitive.Edge) bool {
+		this, err := n.owner().edgeFrom(e)
 		if err != nil {
 			return true
 		}
@@ -66,22 +98,30 @@ func (n *Node) EdgesTo(edgeType primitive.Type, fn func(e *Edge) bool) {
 }
 
 // Remove permenently removes the node from the graph
-func (n *Node) Remove() {
-	globalGraph.DelNode(n)
+func (n *Node) Remove() error {
+	if err := n.owner().writable(); err != nil {
+		return err
+	}
+	return n.owner().DelNode(n)
 }
 
 // Connect creates a connection/edge between the two nodes with the given relationship type
-// if mutual = true, the connection is doubly linked - (facebook is mutual, instagram is not)
+// if mutual = true, the connection is doubly linked - (facebook is mutual, instagram is not). The edge in the
+// opposite direction is returned by the edge's Reverse method.
 func (n *Node) Connect(nodeID primitive.TypedID, relationship string, mutual bool) (*Edge, error) {
+	if err := n.owner().writable(); err != nil {
+		return nil, err
+	}
 	en := primitive.NewNode(map[string]interface{}{
 		primitive.TYPE_KEY: relationship,
 	})
-	node, ok := GetNode(nodeID)
+	node, ok := n.owner().GetNode(nodeID)
 	if !ok {
 		return nil, fmt.Errorf("node: %s %s does not exist", nodeID.Type(), nodeID.ID())
 	}
+	defer n.owner().trace("AddEdge", map[string]string{AttributeEdgeType: relationship, AttributeFromType: n.Type(), AttributeToType: node.Type()})()
 	if !mutual {
-		if err := globalGraph.AddEdge(&primitive.Edge{
+		if err := n.owner().graph.AddEdge(&primitive.Edge{
 			Node: en,
 			From: n.load(),
 			To:   node.load(),
@@ -89,15 +129,23 @@ func (n *Node) Connect(nodeID primitive.TypedID, relationship string, mutual boo
 			return nil, err
 		}
 	} else {
-		if err := globalGraph.AddEdge(&primitive.Edge{
+		// the reverse edge is a separate edge: each records the other's id so patches can be synced
+		reverse := primitive.NewNode(map[string]interface{}{
+			primitive.TYPE_KEY: relationship,
+		})
+		en.SetID(primitive.UUID())
+		reverse.SetID(primitive.UUID())
+		en.Set(primitive.REVERSE_KEY, reverse.ID())
+		reverse.Set(primitive.REVERSE_KEY, en.ID())
+		if err := n.owner().graph.AddEdge(&primitive.Edge{
 			Node: en,
 			From: n.load(),
 			To:   node.load(),
 		}); err != nil {
 			return nil, err
 		}
-		if err := globalGraph.AddEdge(&primitive.Edge{
-			Node: en,
+		if err := n.owner().graph.AddEdge(&primitive.Edge{
+			Node: reverse,
 			From: node.load(),
 			To:   n.load(),
 		}); err != nil {
@@ -107,14 +155,63 @@ func (n *Node) Connect(nodeID primitive.TypedID, relationship string, mutual boo
 	if !ok {
 		return nil, errors.New("failed to created edge")
 	}
-	return &Edge{en}, nil
+	return n.owner().edge(en), nil
 }
 
 // Patch patches the node attributes with the given data
-func (n *Node) Patch(data map[string]interface{}) {
-	node := n.load()
-	node.SetAll(data)
-	globalGraph.AddNode(node)
+func (n *Node) Patch(data map[string]interface{}) error {
+	if err := n.owner().writable(); err != nil {
+		return err
+	}
+	return n.owner().graph.PatchNode(n.load(), data)
+}
+
+// PatchPath sets a nested attribute by a dotted path of keys(ex: address.city), creating missing maps along the way.
+// It returns ErrInvalidPath if the path runs through a value that is not a map or slice.
+func (n *Node) PatchPath(path string, value interface{}) error {
+	if err := n.owner().writable(); err != nil {
+		return err
+	}
+	return n.owner().graph.PatchNodePath(n, path, value)
+}
+
+// PatchPointer sets a nested attribute by a JSON Pointer(ex: /address/city), creating missing maps along the way
+func (n *Node) PatchPointer(pointer string, value interface{}) error {
+	if err := n.owner().writable(); err != nil {
+		return err
+	}
+	return n.owner().graph.PatchNodePointer(n, pointer, value)
+}
+
+// GetPath gets a nested attribute by a dotted path of keys(ex: address.city). Slice elements are addressed by
+// index(ex: phones.0). Use GetPointer for keys that contain dots.
+func (n *Node) GetPath(path string) (interface{}, bool) {
+	return n.load().GetPath(path)
+}
+
+// GetPointer gets a nested attribute by a JSON Pointer(ex: /address/city)
+func (n *Node) GetPointer(pointer string) (interface{}, bool) {
+	return n.load().GetPointer(pointer)
+}
+
+// PatchIf atomically patches the node attributes with the given data if cond returns true for a copy of the node's
+// current attributes(ex: compare-and-swap on a version attribute), returning whether the node was patched
+func (n *Node) PatchIf(cond func(current map[string]interface{}) bool, data map[string]interface{}) (bool, error) {
+	if err := n.owner().writable(); err != nil {
+		return false, err
+	}
+	return n.owner().graph.PatchNodeIf(n, func(current primitive.Node) bool {
+		return cond(current)
+	}, data)
+}
+
+// Increment atomically adds delta to the node's integer attribute, returning the new value. It is safe for concurrent
+// use without external locking.
+func (n *Node) Increment(key string, delta int64) (int64, error) {
+	if err := n.owner().writable(); err != nil {
+		return 0, err
+	}
+	return n.owner().graph.IncrementNode(n, key, delta)
 }
 
 // Range iterates over the nodes attributes until the iterator returns false
@@ -147,10 +244,109 @@ func (n *Node) Get(key string) interface{} {
 	return node.Get(key)
 }
 
+// GetStringOK gets a string value from the nodes attributes, returning false if it is missing or not a string
+func (n *Node) GetStringOK(key string) (string, bool) {
+	return n.load().GetStringOK(key)
+}
+
+// GetIntOK gets an integer value from the nodes attributes, returning false if it is missing or not an integer
+func (n *Node) GetIntOK(key string) (int, bool) {
+	return n.load().GetIntOK(key)
+}
+
+// GetFloatOK gets a numeric value from the nodes attributes, returning false if it is missing or not a number
+func (n *Node) GetFloatOK(key string) (float64, bool) {
+	return n.load().GetFloatOK(key)
+}
+
+// GetBoolOK gets a bool value from the nodes attributes, returning false if it is missing or not a bool
+func (n *Node) GetBoolOK(key string) (bool, bool) {
+	return n.load().GetBoolOK(key)
+}
+
+// GetTime gets a time value from the nodes attributes(if it exists)
+func (n *Node) GetTime(key string) time.Time {
+	return n.load().GetTime(key)
+}
+
+// GetTimeOK gets a time value from the nodes attributes, returning false if it is missing or not a time
+func (n *Node) GetTimeOK(key string) (time.Time, bool) {
+	return n.load().GetTimeOK(key)
+}
+
+// GetStringSlice gets a string slice value from the nodes attributes(if it exists)
+func (n *Node) GetStringSlice(key string) []string {
+	return n.load().GetStringSlice(key)
+}
+
+// GetStringSliceOK gets a string slice value from the nodes attributes, returning false if it is missing or not a
+// string slice
+func (n *Node) GetStringSliceOK(key string) ([]string, bool) {
+	return n.load().GetStringSliceOK(key)
+}
+
+// MustGetString gets a string value from the nodes attributes, panicking with ErrMissingAttribute or ErrAttributeType
+// if it is missing or not a string
+func (n *Node) MustGetString(key string) string {
+	return n.load().MustGetString(key)
+}
+
+// MustGetInt gets an integer value from the nodes attributes, panicking if it is missing or not an integer
+func (n *Node) MustGetInt(key string) int {
+	return n.load().MustGetInt(key)
+}
+
+// MustGetFloat gets a numeric value from the nodes attributes, panicking if it is missing or not a number
+func (n *Node) MustGetFloat(key string) float64 {
+	return n.load().MustGetFloat(key)
+}
+
+// MustGetBool gets a bool value from the nodes attributes, panicking if it is missing or not a bool
+func (n *Node) MustGetBool(key string) bool {
+	return n.load().MustGetBool(key)
+}
+
+// MustGetTime gets a time value from the nodes attributes, panicking if it is missing or not a time
+func (n *Node) MustGetTime(key string) time.Time {
+	return n.load().MustGetTime(key)
+}
+
+// MustGetStringSlice gets a string slice value from the nodes attributes, panicking if it is missing or not a string
+// slice
+func (n *Node) MustGetStringSlice(key string) []string {
+	return n.load().MustGetStringSlice(key)
+}
+
 // Del deletes the entry from the Node by key
-func (n *Node) Del(key string) {
+func (n *Node) Del(key string) error {
+	if n.owner().view || n.owner().graph.ReadOnly() {
+		return primitive.ErrReadOnly
+	}
 	node := n.load()
 	node.Del(key)
+	n.owner().graph.MarkDirty(node, key)
+	// store the node again so storage backends persist the deletion
+	return n.owner().graph.AddNode(node)
+}
+
+// DirtyFields returns the attributes that changed since the node was last marked clean with ClearDirty
+func (n *Node) DirtyFields() []string {
+	return n.owner().graph.DirtyFields(n)
+}
+
+// Copy returns a deep copy of the node's attributes that may be modified without affecting the graph
+func (n *Node) Copy() primitive.Node {
+	return n.load().Copy()
+}
+
+// History returns the node's retained revisions from oldest to newest. History must be enabled with WithHistory.
+func (n *Node) History() []primitive.Revision {
+	return n.owner().graph.History(n)
+}
+
+// AtRevision returns the node's attributes as of the given revision, if it is retained
+func (n *Node) AtRevision(revision int) (primitive.Node, bool) {
+	return n.owner().graph.NodeAtRevision(n, revision)
 }
 
 // JSON returns the node as JSON bytes
@@ -192,3 +388,96 @@ func (n *Node) FilterEdgesTo(edgeType primitive.Type, filter func(e *Edge) bool)
 	})
 	return edges
 }
+
+// RandomNeighbor picks a node this node points to over edges of the given type, with probability proportional to the
+// edge attribute weightAttr. If weightAttr is empty, every neighbor is equally likely.
+func (n *Node) RandomNeighbor(edgeType primitive.Type, weightAttr string) (*Node, bool) {
+	neighbor, ok := n.owner().graph.RandomNeighbor(n, edgeType, weightAttr)
+	if !ok {
+		return nil, false
+	}
+	return n.owner().node(neighbor), true
+}
+
+// Direction is the direction edges are followed in relative to a node
+type Direction = primitive.Direction
+
+const (
+	// Outgoing follows the edges that stem from the node
+	Outgoing = primitive.Outgoing
+	// Incoming follows the edges that point toward the node
+	Incoming = primitive.Incoming
+	// Both follows edges in either direction
+	Both = primitive.Both
+)
+
+// Neighbors returns the nodes this node points to over edges of the given type(or any type if it is "*"), sorted by
+// type & id
+func (n *Node) Neighbors(edgeType string) []*Node {
+	return n.neighbors(edgeType, Outgoing)
+}
+
+// InNeighbors returns the nodes that point to this node over edges of the given type(or any type if it is "*"),
+// sorted by type & id
+func (n *Node) InNeighbors(edgeType string) []*Node {
+	return n.neighbors(edgeType, Incoming)
+}
+
+func (n *Node) neighbors(edgeType string, direction Direction) []*Node {
+	var neighbors []*Node
+	for _, neighbor := range n.owner().graph.Neighbors(n, StringType(edgeType), direction) {
+		neighbors = append(neighbors, n.owner().node(neighbor))
+	}
+	return neighbors
+}
+
+// Degree returns the number of edges of the given type(or any type if it is "*") connected to the node in the direction
+func (n *Node) Degree(direction Direction, edgeType string) int {
+	return n.owner().graph.Degree(n, StringType(edgeType), direction)
+}
+
+// BFS walks the nodes reachable over outgoing edges level by level, up to depth hops away(0 is unlimited), passing each
+// node to fn once. The node itself is not visited. The walk stops early when fn returns false.
+func (n *Node) BFS(depth int, fn func(n *Node) bool) {
+	defer n.owner().trace("BFS", typeAttribute(AttributeNodeType, n))()
+	n.owner().graph.BFS(n, AnyType(), primitive.TraversalOptions{MaxDepth: depth}, func(node primitive.Node, d int) bool {
+		if d == 0 {
+			return true
+		}
+		return fn(n.owner().node(node))
+	})
+}
+
+// UpsertNode creates a node with the attributes, or merges them into the existing node with the same _type & _id,
+// leaving attributes that are not given untouched. It returns the node & true if it was created. If an id is not
+// provided, a random uuid will be assigned & the node is always created.
+func (g *Graph) UpsertNode(attributes map[string]interface{}) (*Node, bool, error) {
+	data := primitive.NewNode(attributes)
+	data.SetAll(attributes)
+	created, err := g.graph.UpsertNode(data)
+	if err != nil {
+		return nil, false, err
+	}
+	return g.node(data), created, nil
+}
+
+// UpsertNode calls Graph.UpsertNode on the default graph
+func UpsertNode(attributes map[string]interface{}) (*Node, bool, error) {
+	return defaultGraph.UpsertNode(attributes)
+}
+
+// FindOrCreate atomically returns the node of the given type whose attributes equal every match attribute(ex: a user
+// with an email), or creates one with the match & create attributes if there is none. It returns true if the node was
+// created. Index a match attribute with CreateIndex to avoid scanning every node of the type.
+func (g *Graph) FindOrCreate(nodeType string, matchAttrs, createAttrs map[string]interface{}) (*Node, bool, error) {
+	n, created, err := g.graph.FindOrCreate(nodeType, matchAttrs, createAttrs)
+	if err != nil {
+		return nil, false, err
+	}
+	return g.node(n), created, nil
+}
+
+// FindOrCreate calls Graph.FindOrCreate on the default graph
+func FindOrCreate(nodeType string, matchAttrs, createAttrs map[string]interface{}) (*Node, bool, error) {
+	return defaultGraph.FindOrCreate(nodeType, matchAttrs, createAttrs)
+}
@@ -0,0 +1,61 @@
+package dagger
+
+import (
+	"github.com/autom8ter/dagger/primitive"
+)
+
+// ErrNoPath is returned when no path exists between two nodes
+var ErrNoPath = primitive.ErrNoPath
+
+// ErrBudgetExhausted is returned by path searches that exhaust their traversal options before finding a path
+var ErrBudgetExhausted = primitive.ErrBudgetExhausted
+
+// ShortestPath returns the edges of a path with the fewest hops between two nodes over outgoing edges of the given
+// type(use "*" for any type). The search is bounded by the traversal options if they are given.
+func (g *Graph) ShortestPath(from, to primitive.TypedID, edgeType string, opts ...primitive.TraversalOptions) ([]*Edge, error) {
+	defer g.trace("ShortestPath", map[string]string{AttributeEdgeType: edgeType, AttributeFromType: from.Type(), AttributeToType: to.Type()})()
+	path, err := g.graph.ShortestPath(from, to, StringType(edgeType), traversalOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	return g.pathEdges(path), nil
+}
+
+// ShortestPath calls Graph.ShortestPath on the default graph
+func ShortestPath(from, to primitive.TypedID, edgeType string, opts ...primitive.TraversalOptions) ([]*Edge, error) {
+	return defaultGraph.ShortestPath(from, to, edgeType, opts...)
+}
+
+// ShortestWeightedPath returns the edges of the path with the lowest total weight between two nodes over outgoing
+// edges of the given type, reading each edge's weight from its numeric weightAttr attribute, along with the total weight.
+// If weightAttr is empty, the weight set with Edge.SetWeight is used. The search is bounded by the traversal options if
+// they are given.
+func (g *Graph) ShortestWeightedPath(from, to primitive.TypedID, edgeType string, weightAttr string, opts ...primitive.TraversalOptions) ([]*Edge, float64, error) {
+	defer g.trace("ShortestWeightedPath", map[string]string{AttributeEdgeType: edgeType, AttributeFromType: from.Type(), AttributeToType: to.Type()})()
+	path, weight, err := g.graph.ShortestWeightedPath(from, to, StringType(edgeType), weightAttr, traversalOptions(opts))
+	if err != nil {
+		return nil, 0, err
+	}
+	return g.pathEdges(path), weight, nil
+}
+
+// ShortestWeightedPath calls Graph.ShortestWeightedPath on the default graph
+func ShortestWeightedPath(from, to primitive.TypedID, edgeType string, weightAttr string, opts ...primitive.TraversalOptions) ([]*Edge, float64, error) {
+	return defaultGraph.ShortestWeightedPath(from, to, edgeType, weightAttr, opts...)
+}
+
+// traversalOptions returns the first of the optional traversal options, or no limits if none are given
+func traversalOptions(opts []primitive.TraversalOptions) primitive.TraversalOptions {
+	if len(opts) == 0 {
+		return primitive.TraversalOptions{}
+	}
+	return opts[0]
+}
+
+func (g *Graph) pathEdges(path []*primitive.Edge) []*Edge {
+	edges := []*Edge{}
+	for _, e := range path {
+		edges = append(edges, g.edge(e))
+	}
+	return edges
+}
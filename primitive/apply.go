@@ -0,0 +1,214 @@
+package primitive
+
+import (
+	"encoding/json"
+)
+
+// Filter selects nodes & edges. The zero value selects everything.
+type Filter struct {
+	// NodeTypes restricts the filter to nodes of the given types
+	NodeTypes []string `json:"node_types"`
+	// EdgeTypes restricts the filter to edges of the given types
+	EdgeTypes []string `json:"edge_types"`
+	// Node optionally restricts the filter to nodes that pass the predicate
+	Node func(n Node) bool `json:"-"`
+	// Edge optionally restricts the filter to edges that pass the predicate
+	Edge func(e *Edge) bool `json:"-"`
+}
+
+// MatchNode returns true if the node is selected by the filter
+func (f Filter) MatchNode(n Node) bool {
+	if len(f.NodeTypes) > 0 && !contains(f.NodeTypes, n.Type()) {
+		return false
+	}
+	return f.Node == nil || f.Node(n)
+}
+
+// MatchEdge returns true if the edge is selected by the filter
+func (f Filter) MatchEdge(e *Edge) bool {
+	if len(f.EdgeTypes) > 0 && !contains(f.EdgeTypes, e.Type()) {
+		return false
+	}
+	return f.Edge == nil || f.Edge(e)
+}
+
+// Plan is the set of changes that converge a graph to a desired state
+type Plan struct {
+	CreateNodes []Node  `json:"create_nodes"`
+	PatchNodes  []Node  `json:"patch_nodes"`
+	DeleteNodes []Node  `json:"delete_nodes"`
+	CreateEdges []*Edge `json:"create_edges"`
+	PatchEdges  []*Edge `json:"patch_edges"`
+	DeleteEdges []*Edge `json:"delete_edges"`
+}
+
+// Empty returns true if the plan has no changes
+func (p *Plan) Empty() bool {
+	return len(p.CreateNodes)+len(p.PatchNodes)+len(p.DeleteNodes)+len(p.CreateEdges)+len(p.PatchEdges)+len(p.DeleteEdges) == 0
+}
+
+// Plan computes the changes that would converge the nodes & edges selected by the scope to the desired state without
+// applying them. Desired elements outside of the scope are ignored.
+func (g *Graph) Plan(desired *Export, scope Filter) *Plan {
+	defer g.rlock()()
+	return g.plan(desired, scope)
+}
+
+// Apply converges the nodes & edges selected by the scope to the desired state: missing elements are created, differing
+// elements are replaced and elements absent from the desired state are deleted, and returns the changes as a plan. The
+// whole plan is checked against the graph as it will be once it is applied before anything is changed, so a plan the
+// graph would reject(a schema violation, an edge to a missing node, a duplicate edge...) changes nothing.
+func (g *Graph) Apply(desired *Export, scope Filter) (*Plan, error) {
+	defer g.lock()()
+	if g.ReadOnly() {
+		return nil, ErrReadOnly
+	}
+	plan := g.plan(desired, scope)
+	for i, e := range plan.CreateEdges {
+		if !e.HasID() {
+			// assign ids up front so the new edges can be checked against each other
+			plan.CreateEdges[i] = &Edge{Node: e.Node.Copy(), From: e.From, To: e.To}
+			plan.CreateEdges[i].SetID(UUID())
+		}
+	}
+	if err := g.checkChanges(plan.changes()); err != nil {
+		return plan, err
+	}
+	for _, e := range plan.DeleteEdges {
+		g.delEdge(e)
+	}
+	for _, n := range plan.DeleteNodes {
+		g.delNode(n)
+	}
+	// nodes are replaced with copies rather than patched in place, so readers never see a partial change
+	for _, n := range append(append([]Node{}, plan.CreateNodes...), plan.PatchNodes...) {
+		g.addNode(n.Copy())
+	}
+	for _, e := range plan.CreateEdges {
+		if err := g.addEdge(g.resolveEdge(e)); err != nil {
+			return plan, err
+		}
+	}
+	for _, e := range plan.PatchEdges {
+		g.delEdge(e)
+		if err := g.addEdge(g.resolveEdge(e)); err != nil {
+			return plan, err
+		}
+	}
+	return plan, g.walErr()
+}
+
+// changes returns the plan as the changes ApplyChanges would make, so it can be checked with checkChanges
+func (p *Plan) changes() *Changes {
+	c := &Changes{
+		Nodes: append(append([]Node{}, p.CreateNodes...), p.PatchNodes...),
+		Edges: append(append([]*Edge{}, p.CreateEdges...), p.PatchEdges...),
+	}
+	for _, n := range p.DeleteNodes {
+		c.DeletedNodes = append(c.DeletedNodes, Node{TYPE_KEY: n.Type(), ID_KEY: n.ID()})
+	}
+	for _, e := range p.DeleteEdges {
+		c.DeletedEdges = append(c.DeletedEdges, Node{TYPE_KEY: e.Type(), ID_KEY: e.ID()})
+	}
+	return c
+}
+
+// resolveEdge copies the edge with its endpoints pointing at the nodes stored in the graph
+func (g *Graph) resolveEdge(e *Edge) *Edge {
+	edge := &Edge{Node: e.Node.Copy(), From: e.From, To: e.To}
+	if from, ok := g.GetNode(e.From); ok {
+		edge.From = from
+	}
+	if to, ok := g.GetNode(e.To); ok {
+		edge.To = to
+	}
+	return edge
+}
+
+func (g *Graph) plan(desired *Export, scope Filter) *Plan {
+	plan := &Plan{}
+	wantNodes := map[string]bool{}
+	for _, n := range desired.Nodes {
+		if !scope.MatchNode(n) {
+			continue
+		}
+		wantNodes[pathOf(n)] = true
+		current, ok := g.GetNode(n)
+		if !ok {
+			plan.CreateNodes = append(plan.CreateNodes, n)
+		} else if !attributesEqual(current, n) {
+			plan.PatchNodes = append(plan.PatchNodes, n)
+		}
+	}
+	g.RangeNodes(func(n Node) bool {
+		if scope.MatchNode(n) && !wantNodes[pathOf(n)] {
+			plan.DeleteNodes = append(plan.DeleteNodes, n)
+		}
+		return true
+	})
+	wantEdges := map[string]bool{}
+	for _, e := range desired.Edges {
+		if !scope.MatchEdge(e) {
+			continue
+		}
+		current, ok := g.GetEdge(e)
+		if !e.HasID() {
+			current, ok = g.edgeBetween(e, e.From, e.To)
+			if ok {
+				// adopt the id of the matching edge so the desired state is idempotent
+				e = &Edge{Node: e.Node.Copy(), From: e.From, To: e.To}
+				e.SetID(current.ID())
+			}
+		}
+		if !ok {
+			plan.CreateEdges = append(plan.CreateEdges, e)
+			continue
+		}
+		wantEdges[pathOf(e)] = true
+		if !attributesEqual(current.Node, e.Node) || pathOf(current.From) != pathOf(e.From) || pathOf(current.To) != pathOf(e.To) {
+			plan.PatchEdges = append(plan.PatchEdges, e)
+		}
+	}
+	g.RangeEdges(func(e *Edge) bool {
+		if scope.MatchEdge(e) && !wantEdges[pathOf(e)] {
+			plan.DeleteEdges = append(plan.DeleteEdges, e)
+		}
+		return true
+	})
+	return plan
+}
+
+func (g *Graph) edgeBetween(edgeType Type, from, to TypedID) (*Edge, bool) {
+	var found *Edge
+	g.EdgesFrom(edgeType, from, func(e *Edge) bool {
+		if e.Type() == edgeType.Type() && pathOf(e.To) == pathOf(to) {
+			found = e
+			return false
+		}
+		return true
+	})
+	return found, found != nil
+}
+
+// attributesEqual compares the attributes of two nodes by their json encoding so numbers compare equally regardless
+// of whether they were decoded from json
+func attributesEqual(a, b Node) bool {
+	x, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	y, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return string(x) == string(y)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package primitive
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidPath is returned when an attribute path is malformed or runs through a value that is not a map or slice
+var ErrInvalidPath = errors.New("dagger: invalid attribute path")
+
+// splitPath splits a dotted path(ex: address.city) into its keys
+func splitPath(path string) ([]string, error) {
+	if path == "" {
+		return nil, fmt.Errorf("%w: empty path", ErrInvalidPath)
+	}
+	return strings.Split(path, "."), nil
+}
+
+// splitPointer splits a JSON Pointer(ex: /address/city, see RFC 6901) into its unescaped keys
+func splitPointer(pointer string) ([]string, error) {
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: json pointer %q must start with /", ErrInvalidPath, pointer)
+	}
+	keys := strings.Split(pointer[1:], "/")
+	for i, key := range keys {
+		keys[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(key)
+	}
+	return keys, nil
+}
+
+// GetPath gets a nested entry from the Node by a dotted path of keys(ex: address.city). Slice elements are addressed
+// by index(ex: phones.0). Use GetPointer for keys that contain dots.
+func (m Node) GetPath(path string) (interface{}, bool) {
+	keys, err := splitPath(path)
+	if err != nil {
+		return nil, false
+	}
+	return m.getKeys(keys)
+}
+
+// GetPointer gets a nested entry from the Node by a JSON Pointer(ex: /address/city)
+func (m Node) GetPointer(pointer string) (interface{}, bool) {
+	keys, err := splitPointer(pointer)
+	if err != nil {
+		return nil, false
+	}
+	return m.getKeys(keys)
+}
+
+// SetPath sets a nested entry in the Node by a dotted path of keys(ex: address.city), creating missing maps along the
+// way. It returns ErrInvalidPath if the path runs through a value that is not a map or slice.
+func (m Node) SetPath(path string, value interface{}) error {
+	keys, err := splitPath(path)
+	if err != nil {
+		return err
+	}
+	return m.setKeys(keys, value)
+}
+
+// SetPointer sets a nested entry in the Node by a JSON Pointer(ex: /address/city), creating missing maps along the way
+func (m Node) SetPointer(pointer string, value interface{}) error {
+	keys, err := splitPointer(pointer)
+	if err != nil {
+		return err
+	}
+	return m.setKeys(keys, value)
+}
+
+func (m Node) getKeys(keys []string) (interface{}, bool) {
+	var current interface{} = map[string]interface{}(m)
+	for _, key := range keys {
+		switch c := current.(type) {
+		case Node:
+			val, ok := c[key]
+			if !ok {
+				return nil, false
+			}
+			current = val
+		case map[string]interface{}:
+			val, ok := c[key]
+			if !ok {
+				return nil, false
+			}
+			current = val
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, false
+			}
+			current = c[i]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func (m Node) setKeys(keys []string, value interface{}) error {
+	var current interface{} = map[string]interface{}(m)
+	for depth, key := range keys {
+		last := depth == len(keys)-1
+		switch c := current.(type) {
+		case Node:
+			current = setChild(c, key, value, last)
+		case map[string]interface{}:
+			current = setChild(c, key, value, last)
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(c) {
+				return fmt.Errorf("%w: index %q of %s is out of range", ErrInvalidPath, key, strings.Join(keys[:depth], "."))
+			}
+			if last {
+				c[i] = value
+			}
+			current = c[i]
+		default:
+			return fmt.Errorf("%w: %s is a %T, not a map or slice", ErrInvalidPath, strings.Join(keys[:depth], "."), c)
+		}
+	}
+	return nil
+}
+
+// setChild sets the value under the key if it is the last key of the path, otherwise it returns the child under the
+// key, creating a map if there is none
+func setChild(m map[string]interface{}, key string, value interface{}, last bool) interface{} {
+	if last {
+		m[key] = value
+		return value
+	}
+	child, ok := m[key]
+	if !ok || child == nil {
+		child = map[string]interface{}{}
+		m[key] = child
+	}
+	return child
+}
+
+// PatchNodePath sets a nested attribute of the node by a dotted path of keys(ex: address.city), creating missing maps
+// along the way. The node's other attributes are left untouched.
+func (g *Graph) PatchNodePath(id TypedID, path string, value interface{}) error {
+	keys, err := splitPath(path)
+	if err != nil {
+		return err
+	}
+	return g.patchNodeKeys(id, keys, value)
+}
+
+// PatchNodePointer sets a nested attribute of the node by a JSON Pointer(ex: /address/city), creating missing maps
+// along the way
+func (g *Graph) PatchNodePointer(id TypedID, pointer string, value interface{}) error {
+	keys, err := splitPointer(pointer)
+	if err != nil {
+		return err
+	}
+	return g.patchNodeKeys(id, keys, value)
+}
+
+func (g *Graph) patchNodeKeys(id TypedID, keys []string, value interface{}) error {
+	defer g.lock()()
+	if g.ReadOnly() {
+		return ErrReadOnly
+	}
+	if keys[0] == ID_KEY || keys[0] == TYPE_KEY {
+		return fmt.Errorf("dagger: %s cannot be patched", keys[0])
+	}
+	n, ok := g.GetNode(id)
+	if !ok {
+		return fmt.Errorf("node %s.%s does not exist", id.Type(), id.ID())
+	}
+	// patch a copy so nested maps shared with readers are never modified in place
+	patched := n.Copy()
+	if err := patched.setKeys(keys, value); err != nil {
+		return err
+	}
+	if err := g.validateNode(patched); err != nil {
+		return err
+	}
+	g.addNode(patched)
+	g.count(MetricNodesPatched, n.Type())
+	return g.walErr()
+}
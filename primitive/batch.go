@@ -0,0 +1,385 @@
+package primitive
+
+import "fmt"
+
+// PatchWhere applies the changes to every node of the given type that passes the predicate, returning the number of
+// nodes patched. The nodes are matched & patched in a single pass that excludes other writers. The predicate must not
+// mutate the graph.
+func (g *Graph) PatchWhere(typ Type, pred func(n Node) bool, changes map[string]interface{}) (int, error) {
+	if err := checkPatch(changes); err != nil {
+		return 0, err
+	}
+	defer g.lock()()
+	if g.ReadOnly() {
+		return 0, ErrReadOnly
+	}
+	var matches []Node
+	g.RangeNodeTypes(typ, func(n Node) bool {
+		if pred(n) {
+			matches = append(matches, n)
+		}
+		return true
+	})
+	patched := make([]Node, len(matches))
+	for i, n := range matches {
+		patched[i] = n.Union(changes)
+		if err := g.validateNode(patched[i]); err != nil {
+			return 0, err
+		}
+	}
+	for _, n := range patched {
+		g.addNode(n)
+		g.count(MetricNodesPatched, n.Type())
+	}
+	return len(matches), g.walErr()
+}
+
+// checkPatch returns an error if the changes would patch an id or type
+func checkPatch(changes map[string]interface{}) error {
+	for _, key := range []string{ID_KEY, TYPE_KEY} {
+		if _, ok := changes[key]; ok {
+			return fmt.Errorf("dagger: %s cannot be patched", key)
+		}
+	}
+	return nil
+}
+
+// MoveEdges atomically re-points every edge from & to the node onto the target node, preserving edge ids and attributes
+func (g *Graph) MoveEdges(from TypedID, to TypedID) error {
+	defer g.lock()()
+	if g.ReadOnly() {
+		return ErrReadOnly
+	}
+	if err := g.moveEdges(from, to); err != nil {
+		return err
+	}
+	return g.walErr()
+}
+
+func (g *Graph) moveEdges(from TypedID, to TypedID) error {
+	if !g.HasNode(from) {
+		return fmt.Errorf("node %s.%s does not exist", from.Type(), from.ID())
+	}
+	target, ok := g.GetNode(to)
+	if !ok {
+		return fmt.Errorf("node %s.%s does not exist", to.Type(), to.ID())
+	}
+	m := g.newEdgeMove()
+	m.add(from, target)
+	return m.apply()
+}
+
+// edgeMove re-points the edges of nodes onto other nodes. Every re-pointed edge is checked before any edge is changed,
+// so a move that the graph would reject leaves it untouched.
+type edgeMove struct {
+	g       *Graph
+	targets map[string]Node
+	edges   []*Edge
+	seen    map[*Edge]bool
+}
+
+func (g *Graph) newEdgeMove() *edgeMove {
+	return &edgeMove{
+		g:       g,
+		targets: map[string]Node{},
+		seen:    map[*Edge]bool{},
+	}
+}
+
+// add re-points the edges from & to the node onto the target
+func (m *edgeMove) add(from TypedID, target Node) {
+	m.targets[pathOf(from)] = target
+	collect := func(e *Edge) bool {
+		if !m.seen[e] {
+			m.seen[e] = true
+			m.edges = append(m.edges, e)
+		}
+		return true
+	}
+	m.g.EdgesFrom(anyType{}, from, collect)
+	m.g.EdgesTo(anyType{}, from, collect)
+}
+
+// endpoint returns the node an edge endpoint is re-pointed onto
+func (m *edgeMove) endpoint(n Node) Node {
+	if target, ok := m.targets[pathOf(n)]; ok {
+		return target
+	}
+	return n
+}
+
+// apply replaces every edge with a copy re-pointed onto the targets, or returns the error re-pointing the first edge
+// the graph would reject without changing anything. The caller must hold the lock.
+func (m *edgeMove) apply() error {
+	moved := make([]*Edge, len(m.edges))
+	keys := map[string]*Edge{}
+	for i, e := range m.edges {
+		moved[i] = &Edge{Node: e.Node, From: m.endpoint(e.From), To: m.endpoint(e.To)}
+		if err := m.g.checkEdge(moved[i]); err != nil {
+			return err
+		}
+		// moved edges are not in the graph yet, so check them against each other too
+		if key := m.g.uniqueKey(moved[i]); key != "" {
+			if other, ok := keys[key]; ok {
+				return fmt.Errorf("%w: %s.%s & %s.%s would both connect %s to %s", ErrDuplicateEdge, other.Type(), other.ID(), e.Type(), e.ID(), pathOf(moved[i].From), pathOf(moved[i].To))
+			}
+			keys[key] = moved[i]
+		}
+	}
+	for i, e := range m.edges {
+		m.g.delEdge(e)
+		if err := m.g.addEdge(moved[i]); err != nil {
+			m.g.addEdge(e)
+			return err
+		}
+	}
+	return nil
+}
+
+// MERGED_KEY is the attribute MergeNodes records the type.id paths of merged duplicates under
+const MERGED_KEY = "_merged"
+
+// MergeStrategy returns the attributes of the survivor after merging a duplicate into it
+type MergeStrategy func(survivor, duplicate Node) map[string]interface{}
+
+// KeepSurvivor keeps the survivor's attributes, only adding attributes it is missing from the duplicate
+func KeepSurvivor(survivor, duplicate Node) map[string]interface{} {
+	return duplicate.Union(survivor)
+}
+
+// KeepDuplicate overwrites the survivor's attributes with the duplicate's attributes
+func KeepDuplicate(survivor, duplicate Node) map[string]interface{} {
+	return survivor.Union(duplicate)
+}
+
+// MergeNodes atomically merges the duplicates into the survivor: attributes are combined with the strategy, edges are
+// re-pointed onto the survivor, the duplicates' paths are appended to the survivor's MERGED_KEY attribute, and the
+// duplicates are removed. The merged node & every re-pointed edge are checked first, so a merge the graph would reject
+// changes nothing.
+func (g *Graph) MergeNodes(survivor TypedID, strategy MergeStrategy, duplicates ...TypedID) error {
+	defer g.lock()()
+	if g.ReadOnly() {
+		return ErrReadOnly
+	}
+	s, ok := g.GetNode(survivor)
+	if !ok {
+		return fmt.Errorf("node %s.%s does not exist", survivor.Type(), survivor.ID())
+	}
+	merged := s.Copy()
+	var provenance []interface{}
+	if previous, ok := s.Get(MERGED_KEY).([]interface{}); ok {
+		provenance = append(provenance, previous...)
+	}
+	var removed []Node
+	seen := map[string]bool{pathOf(s): true}
+	for _, id := range duplicates {
+		if seen[pathOf(id)] {
+			continue
+		}
+		seen[pathOf(id)] = true
+		d, ok := g.GetNode(id)
+		if !ok {
+			return fmt.Errorf("node %s.%s does not exist", id.Type(), id.ID())
+		}
+		merged = Node(strategy(merged.Copy(), d.Copy()))
+		merged.SetID(s.ID())
+		merged.SetType(s.Type())
+		if previous, ok := d.Get(MERGED_KEY).([]interface{}); ok {
+			provenance = append(provenance, previous...)
+		}
+		provenance = append(provenance, pathOf(d))
+		removed = append(removed, d)
+	}
+	merged.Set(MERGED_KEY, provenance)
+	if err := g.validateNode(merged); err != nil {
+		return err
+	}
+	m := g.newEdgeMove()
+	for _, d := range removed {
+		m.add(d, s)
+	}
+	if err := m.apply(); err != nil {
+		return err
+	}
+	for _, d := range removed {
+		g.delNode(d)
+	}
+	g.addNode(merged)
+	return g.walErr()
+}
+
+// PatchNode sets the attributes on the node. The stored node is replaced with a patched copy rather than modified in
+// place, so readers that do not take the lock(GetNode, RangeNodes...) never see a partial patch.
+func (g *Graph) PatchNode(id TypedID, data map[string]interface{}) error {
+	defer g.lockPaths(pathOf(id))()
+	if g.ReadOnly() {
+		return ErrReadOnly
+	}
+	n, ok := g.GetNode(id)
+	if !ok {
+		return fmt.Errorf("node %s.%s does not exist", id.Type(), id.ID())
+	}
+	patched := n.Union(data)
+	if err := g.validateNode(patched); err != nil {
+		return err
+	}
+	g.addNode(patched)
+	g.count(MetricNodesPatched, n.Type())
+	return g.walErr()
+}
+
+// PatchNodeIf atomically sets the attributes on the node if cond returns true for a copy of its current attributes,
+// returning whether the node was patched. No other writer can change the node between the check & the patch.
+func (g *Graph) PatchNodeIf(id TypedID, cond func(current Node) bool, data map[string]interface{}) (bool, error) {
+	defer g.lockPaths(pathOf(id))()
+	if g.ReadOnly() {
+		return false, ErrReadOnly
+	}
+	n, ok := g.GetNode(id)
+	if !ok {
+		return false, fmt.Errorf("node %s.%s does not exist", id.Type(), id.ID())
+	}
+	if !cond(n.Copy()) {
+		return false, nil
+	}
+	patched := n.Union(data)
+	if err := g.validateNode(patched); err != nil {
+		return false, err
+	}
+	g.addNode(patched)
+	g.count(MetricNodesPatched, n.Type())
+	return true, g.walErr()
+}
+
+// IncrementNode atomically adds delta to the node's integer attribute(a missing attribute counts as 0), returning the
+// new value
+func (g *Graph) IncrementNode(id TypedID, key string, delta int64) (int64, error) {
+	if err := checkPatch(map[string]interface{}{key: nil}); err != nil {
+		return 0, err
+	}
+	defer g.lockPaths(pathOf(id))()
+	if g.ReadOnly() {
+		return 0, ErrReadOnly
+	}
+	n, ok := g.GetNode(id)
+	if !ok {
+		return 0, fmt.Errorf("node %s.%s does not exist", id.Type(), id.ID())
+	}
+	value := int64(parseInt(n.Get(key))) + delta
+	patched := n.Union(map[string]interface{}{key: value})
+	if err := g.validateNode(patched); err != nil {
+		return 0, err
+	}
+	g.addNode(patched)
+	g.count(MetricNodesPatched, n.Type())
+	return value, g.walErr()
+}
+
+// IncrementEdge atomically adds delta to the edge's integer attribute(a missing attribute counts as 0), returning the
+// new value
+func (g *Graph) IncrementEdge(id TypedID, key string, delta int64) (int64, error) {
+	e, unlock := g.lockEdge(id)
+	defer unlock()
+	if g.ReadOnly() {
+		return 0, ErrReadOnly
+	}
+	if e == nil {
+		return 0, fmt.Errorf("edge %s.%s does not exist", id.Type(), id.ID())
+	}
+	value := int64(parseInt(e.Get(key))) + delta
+	if err := g.patchEdge(e, map[string]interface{}{key: value}); err != nil {
+		return 0, err
+	}
+	return value, g.walErr()
+}
+
+// PatchEdge sets the attributes on the edge
+func (g *Graph) PatchEdge(id TypedID, data map[string]interface{}) error {
+	e, unlock := g.lockEdge(id)
+	defer unlock()
+	if g.ReadOnly() {
+		return ErrReadOnly
+	}
+	if e == nil {
+		return fmt.Errorf("edge %s.%s does not exist", id.Type(), id.ID())
+	}
+	if err := g.patchEdge(e, data); err != nil {
+		return err
+	}
+	return g.walErr()
+}
+
+// checkEdgePatch returns the error setting the attributes on the edge would fail with, without changing the edge
+func (g *Graph) checkEdgePatch(e *Edge, data map[string]interface{}) error {
+	if err := checkPatch(data); err != nil {
+		return err
+	}
+	return g.checkEdge(&Edge{Node: e.Node.Union(data), From: e.From, To: e.To})
+}
+
+// patchEdge replaces the edge with a patched copy if the graph accepts it. The stored edge is never modified in place,
+// so readers holding it without the lock never see a partial patch. The caller must hold the lock.
+func (g *Graph) patchEdge(e *Edge, data map[string]interface{}) error {
+	if err := g.checkEdgePatch(e, data); err != nil {
+		return err
+	}
+	return g.addEdge(&Edge{Node: e.Node.Union(data), From: e.From, To: e.To})
+}
+
+// PatchEdgeAndReverse sets the attributes on the edge & its mutual counterpart(see Edge.Reverse) at once. If the edge
+// has no reverse edge, only the edge is patched.
+func (g *Graph) PatchEdgeAndReverse(id TypedID, data map[string]interface{}) error {
+	defer g.lock()()
+	if g.ReadOnly() {
+		return ErrReadOnly
+	}
+	e, ok := g.GetEdge(id)
+	if !ok {
+		return fmt.Errorf("edge %s.%s does not exist", id.Type(), id.ID())
+	}
+	edges := []*Edge{e}
+	if reverseID, ok := e.Reverse(); ok {
+		if reverse, ok := g.GetEdge(reverseID); ok {
+			edges = append(edges, reverse)
+		}
+	}
+	// check both edges before changing either, so the pair stays in sync
+	for _, e := range edges {
+		if err := g.checkEdgePatch(e, data); err != nil {
+			return err
+		}
+	}
+	for _, e := range edges {
+		if err := g.patchEdge(e, data); err != nil {
+			return err
+		}
+	}
+	return g.walErr()
+}
+
+// UpsertNode adds the node, or merges its attributes into the existing node with the same type & id, leaving
+// attributes the node does not set untouched. It returns true if the node was created.
+func (g *Graph) UpsertNode(n Node) (bool, error) {
+	if !n.HasID() {
+		n.SetID(UUID())
+	}
+	defer g.lockPaths(pathOf(n))()
+	if g.ReadOnly() {
+		return false, ErrReadOnly
+	}
+	existing, ok := g.GetNode(n)
+	if !ok {
+		if err := g.validateNode(n); err != nil {
+			return false, err
+		}
+		g.addNode(n)
+		return true, g.walErr()
+	}
+	patched := existing.Union(n)
+	if err := g.validateNode(patched); err != nil {
+		return false, err
+	}
+	g.addNode(patched)
+	g.count(MetricNodesPatched, existing.Type())
+	return false, g.walErr()
+}
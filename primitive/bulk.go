@@ -0,0 +1,94 @@
+package primitive
+
+import (
+	"fmt"
+)
+
+// BulkLoad adds the nodes & edges while holding the lock once. Edge endpoints are validated after every node is added,
+// once per distinct endpoint, and the edgesFrom/edgesTo indexes are rebuilt in a single pass instead of once per
+// edge. Nodes & edges that violate a registered schema or edge constraint are rejected before anything is added. If an
+// edge is otherwise invalid, none of the edges are added, but the nodes are kept.
+func (g *Graph) BulkLoad(nodes []Node, edges []*Edge) error {
+	defer g.lock()()
+	if g.ReadOnly() {
+		return ErrReadOnly
+	}
+	if err := g.validateAll(nodes, edges); err != nil {
+		return err
+	}
+	for _, n := range nodes {
+		g.addNode(n)
+	}
+	from := map[string]*edgeMap{}
+	to := map[string]*edgeMap{}
+	endpoints := map[string]Node{}
+	unique := map[string]bool{}
+	for _, e := range edges {
+		if e.ID() == "" {
+			e.SetID(UUID())
+		}
+		if err := e.Validate(); err != nil {
+			return err
+		}
+		for _, endpoint := range []Node{e.From, e.To} {
+			path := pathOf(endpoint)
+			if _, ok := endpoints[path]; ok {
+				continue
+			}
+			if !g.HasNode(endpoint) {
+				return fmt.Errorf("node %s.%s does not exist", endpoint.Type(), endpoint.ID())
+			}
+			endpoints[path] = endpoint
+		}
+		if key := g.uniqueKey(e); key != "" {
+			if unique[key] {
+				return fmt.Errorf("%w: more than one edge connects %s to %s", ErrDuplicateEdge, pathOf(e.From), pathOf(e.To))
+			}
+			unique[key] = true
+			if err := g.checkUnique(e); err != nil {
+				return err
+			}
+		}
+		if from[pathOf(e.From)] == nil {
+			from[pathOf(e.From)] = newEdgeMap()
+		}
+		from[pathOf(e.From)].AddEdge(e)
+		if to[pathOf(e.To)] == nil {
+			to[pathOf(e.To)] = newEdgeMap()
+		}
+		to[pathOf(e.To)].AddEdge(e)
+	}
+	for _, e := range edges {
+		exists := g.HasEdge(e)
+		if !exists {
+			g.counts.edge(e.Type(), 1)
+		}
+		g.edges.Set(e.Type(), e.ID(), e)
+		g.changes.setEdge(e)
+		g.log(walEntry{Op: walSetEdge, Edge: &Edge{
+			Node: e.Node,
+			From: Node{TYPE_KEY: e.From.Type(), ID_KEY: e.From.ID()},
+			To:   Node{TYPE_KEY: e.To.Type(), ID_KEY: e.To.ID()},
+		}})
+		if exists {
+			g.recordEdge(edgePatched, e)
+		} else {
+			g.recordEdge(edgeAdded, e)
+		}
+		g.count(MetricEdgesAdded, e.Type())
+	}
+	g.mergeIndex(g.edgesFrom, from, endpoints)
+	g.mergeIndex(g.edgesTo, to, endpoints)
+	return g.walErr()
+}
+
+// mergeIndex adds the edges collected per endpoint path to the index, setting each endpoint's entry once
+func (g *Graph) mergeIndex(index Storage, collected map[string]*edgeMap, endpoints map[string]Node) {
+	for path, edges := range collected {
+		n := endpoints[path]
+		edges.Range(func(e *Edge) bool {
+			g.indexEdge(index, n, e)
+			return true
+		})
+	}
+}
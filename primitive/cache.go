@@ -4,278 +4,150 @@ import (
 	"sync"
 )
 
-func newCache() *namespacedCache {
-	return &namespacedCache{
-		cacheMap:  map[string]*cache{},
-		mu:        sync.RWMutex{},
-		closeOnce: sync.Once{},
-	}
-}
+// shardCount is the number of independently locked shards a Cache spreads its keys over
+const shardCount = 64
 
-type namespacedCache struct {
-	cacheMap  map[string]*cache
-	mu        sync.RWMutex
-	closeOnce sync.Once
+func newCache() *Cache[interface{}] {
+	return NewCache[interface{}]()
 }
 
-func (n *namespacedCache) Len(namespace string) int {
-	n.mu.RLock()
-	defer n.mu.RUnlock()
-	if c, ok := n.cacheMap[namespace]; ok {
-		return c.Len()
+// NewCache returns an empty Cache of values of type V
+func NewCache[V any]() *Cache[V] {
+	c := &Cache[V]{}
+	for i := range c.shards {
+		c.shards[i] = &shard[V]{namespaces: map[string]map[string]V{}}
 	}
-	return 0
+	return c
 }
 
-func (n *namespacedCache) Namespaces() []string {
-	var namespaces []string
-	n.mu.RLock()
-	defer n.mu.RUnlock()
-	for k, _ := range n.cacheMap {
-		namespaces = append(namespaces, k)
-	}
-	return namespaces
+// Cache is a concurrency safe, namespaced cache of typed values keyed by string. Keys are sharded by a hash of their
+// namespace & key so writers to different shards never contend for the same lock. A Cache[interface{}] is the
+// default, in-memory Storage. A Graph lets writers that change different nodes & edges(AddNode, AddEdge, PatchNode...)
+// run in parallel(see Graph.lockPaths), & the shards keep them from contending with each other & with readers.
+type Cache[V any] struct {
+	shards    [shardCount]*shard[V]
+	closeOnce sync.Once
 }
 
-func (n *namespacedCache) Get(namespace string, key interface{}) (interface{}, bool) {
-	n.mu.RLock()
-	defer n.mu.RUnlock()
-	if c, ok := n.cacheMap[namespace]; ok {
-		return c.Get(key)
-	}
-	return nil, false
+// shard holds the keys of every namespace that hash to it
+type shard[V any] struct {
+	mu         sync.RWMutex
+	namespaces map[string]map[string]V
 }
 
-func (n *namespacedCache) Set(namespace string, key interface{}, value interface{}) {
-	n.mu.Lock()
-	defer n.mu.Unlock()
-	if _, ok := n.cacheMap[namespace]; !ok {
-		n.cacheMap[namespace] = &cache{
-			data: sync.Map{},
-			once: sync.Once{},
-		}
-
+// shardOf returns the shard the key belongs to using the FNV-1a hash of the namespace & key
+func (n *Cache[V]) shardOf(namespace, key string) *shard[V] {
+	hash := uint32(2166136261)
+	for i := 0; i < len(namespace); i++ {
+		hash ^= uint32(namespace[i])
+		hash *= 16777619
 	}
-	n.cacheMap[namespace].Set(key, value)
-}
-
-func (n *namespacedCache) Range(namespace string, f func(key string, value interface{}) bool) {
-	n.mu.RLock()
-	defer n.mu.RUnlock()
-	if namespace == AnyType {
-		for _, c := range n.cacheMap {
-			c.Range(f)
-		}
-	} else {
-		if c, ok := n.cacheMap[namespace]; ok {
-			c.Range(f)
-		}
+	// hash a zero byte between the namespace & key so ("ab", "c") & ("a", "bc") hash differently
+	hash *= 16777619
+	for i := 0; i < len(key); i++ {
+		hash ^= uint32(key[i])
+		hash *= 16777619
 	}
-
+	return n.shards[hash%shardCount]
 }
 
-func (n *namespacedCache) Delete(namespace string, key interface{}) {
-	n.mu.RLock()
-	defer n.mu.RUnlock()
-	if c, ok := n.cacheMap[namespace]; ok {
-		c.Delete(key)
+// Len returns the number of keys in the namespace
+func (n *Cache[V]) Len(namespace string) int {
+	count := 0
+	for _, s := range n.shards {
+		s.mu.RLock()
+		count += len(s.namespaces[namespace])
+		s.mu.RUnlock()
 	}
+	return count
 }
 
-func (n *namespacedCache) Exists(namespace string, key interface{}) bool {
-	n.mu.RLock()
-	defer n.mu.RUnlock()
-	return n.cacheMap[namespace].Exists(key)
-}
-
-func (n *namespacedCache) Copy(namespace string) Node {
-	n.mu.RLock()
-	defer n.mu.RUnlock()
-	return n.cacheMap[namespace].Copy()
-}
-
-func (n *namespacedCache) Filter(namespace string, filter func(k, v interface{}) bool) Node {
-	n.mu.RLock()
-	defer n.mu.RUnlock()
-	return n.cacheMap[namespace].Filter(filter)
-}
-
-func (n *namespacedCache) Intersection(namespace1, namespace2 string) Node {
-	n.mu.RLock()
-	defer n.mu.RUnlock()
-	return n.cacheMap[namespace1].Intersection(n.cacheMap[namespace2])
-}
-
-func (n *namespacedCache) Union(namespace1, namespace2 string) Node {
-	n.mu.RLock()
-	defer n.mu.RUnlock()
-	return n.cacheMap[namespace1].Union(n.cacheMap[namespace2])
-}
-
-func (n *namespacedCache) Map(namespace string) Node {
-	n.mu.RLock()
-	defer n.mu.RUnlock()
-	return n.cacheMap[namespace].Map()
-}
-
-func (n *namespacedCache) SetAll(namespace string, m Node) {
-	n.mu.Lock()
-	defer n.mu.Unlock()
-	if _, ok := n.cacheMap[namespace]; !ok {
-		n.cacheMap[namespace] = &cache{
-			data: sync.Map{},
-			once: sync.Once{},
+// Namespaces returns the namespaces in the cache
+func (n *Cache[V]) Namespaces() []string {
+	seen := map[string]bool{}
+	var namespaces []string
+	for _, s := range n.shards {
+		s.mu.RLock()
+		for ns := range s.namespaces {
+			if !seen[ns] {
+				seen[ns] = true
+				namespaces = append(namespaces, ns)
+			}
 		}
-
-	}
-	n.cacheMap[namespace].SetAll(m)
-}
-
-func (n *namespacedCache) Clear(namespace string) {
-	n.mu.RLock()
-	defer n.mu.RUnlock()
-	if cache, ok := n.cacheMap[namespace]; ok {
-		cache.Clear()
+		s.mu.RUnlock()
 	}
+	return namespaces
 }
 
-func (n *namespacedCache) Close() {
-	n.closeOnce.Do(func() {
-		n.mu.Lock()
-		defer n.mu.Unlock()
-		for _, c := range n.cacheMap {
-			c.Close()
+// Get returns the value stored under the key in the namespace
+func (n *Cache[V]) Get(namespace string, key string) (V, bool) {
+	s := n.shardOf(namespace, key)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, ok := s.namespaces[namespace][key]
+	return value, ok
+}
+
+// Set stores the value under the key in the namespace
+func (n *Cache[V]) Set(namespace string, key string, value V) {
+	s := n.shardOf(namespace, key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	values, ok := s.namespaces[namespace]
+	if !ok {
+		values = map[string]V{}
+		s.namespaces[namespace] = values
+	}
+	values[key] = value
+}
+
+// Range iterates over the keys in the namespace(or every namespace if it is AnyType) until f returns false. Each
+// shard's entries are copied before f is called, so f may safely modify the cache.
+func (n *Cache[V]) Range(namespace string, f func(key string, value V) bool) {
+	type entry struct {
+		key   string
+		value V
+	}
+	var entries []entry
+	for _, s := range n.shards {
+		entries = entries[:0]
+		s.mu.RLock()
+		if namespace == AnyType {
+			for _, values := range s.namespaces {
+				for k, v := range values {
+					entries = append(entries, entry{k, v})
+				}
+			}
+		} else {
+			for k, v := range s.namespaces[namespace] {
+				entries = append(entries, entry{k, v})
+			}
 		}
-		n.cacheMap = map[string]*cache{}
-	})
-}
-
-type cache struct {
-	data sync.Map
-	once sync.Once
-}
-
-func (c *cache) Get(key interface{}) (interface{}, bool) {
-	obj, exists := c.data.Load(key)
-
-	if !exists {
-		return nil, false
-	}
-	return obj, true
-}
-
-func (c *cache) Set(key interface{}, value interface{}) {
-	c.data.Store(key, value)
-}
-
-func (c *cache) Range(f func(key string, value interface{}) bool) {
-	c.data.Range(func(key interface{}, value interface{}) bool {
-		return f(key.(string), value)
-	})
-}
-
-func (c *cache) Delete(key interface{}) {
-	c.data.Delete(key)
-}
-
-func (c *cache) Len() int {
-	i := 0
-	c.data.Range(func(key, value interface{}) bool {
-		i++
-		return true
-	})
-	return i
-}
-
-func (c *cache) Exists(key interface{}) bool {
-	_, ok := c.Get(key)
-	return ok
-}
-
-func (c *cache) Close() {
-	c.once.Do(func() {
-		c.data = sync.Map{}
-	})
-}
-
-func (c *cache) Map() Node {
-	data := make(map[string]interface{})
-	c.Range(func(key string, value interface{}) bool {
-		data[key] = value
-		return true
-	})
-	return data
-}
-
-func (c *cache) SetAll(m Node) {
-	m.Range(func(k string, v interface{}) bool {
-		c.Set(k, v)
-		return true
-	})
-}
-
-func (c *cache) Intersection(other *cache) Node {
-	data := Node{}
-	if c == nil {
-		return data
-	}
-	if other != nil {
-		c.Range(func(k string, v interface{}) bool {
-			if other.Exists(v) {
-				data.Set(k, v)
+		s.mu.RUnlock()
+		for _, e := range entries {
+			if !f(e.key, e.value) {
+				return
 			}
-			return true
-		})
-	}
-	return data
-}
-
-func (c *cache) Union(other *cache) Node {
-	data := Node{}
-	if c != nil {
-		c.Range(func(k string, v interface{}) bool {
-			data.Set(k, v)
-			return true
-		})
-	}
-	if other != nil {
-		other.Range(func(k string, v interface{}) bool {
-			data.Set(k, v)
-			return true
-		})
+		}
 	}
-	return data
 }
 
-func (c *cache) Copy() Node {
-	data := Node{}
-	if c == nil {
-		return data
-	}
-	c.Range(func(k string, v interface{}) bool {
-		data.Set(k, v)
-		return true
-	})
-	return data
+// Delete removes the key from the namespace
+func (n *Cache[V]) Delete(namespace string, key string) {
+	s := n.shardOf(namespace, key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.namespaces[namespace], key)
 }
 
-func (c *cache) Filter(filter func(k, v interface{}) bool) Node {
-	data := Node{}
-	if c == nil {
-		return data
-	}
-	c.Range(func(key string, value interface{}) bool {
-		if filter(key, value) {
-			data.Set(key, value)
+// Close removes every key from the cache
+func (n *Cache[V]) Close() error {
+	n.closeOnce.Do(func() {
+		for _, s := range n.shards {
+			s.mu.Lock()
+			s.namespaces = map[string]map[string]V{}
+			s.mu.Unlock()
 		}
-		return true
-	})
-	return data
-}
-
-func (c *cache) Clear() {
-	c.Range(func(key string, value interface{}) bool {
-		c.Delete(key)
-		return true
 	})
+	return nil
 }
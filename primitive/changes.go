@@ -0,0 +1,297 @@
+package primitive
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrChangesNotTracked is returned when asking for the changes made to a graph that is not tracking them(see
+// TrackChanges)
+var ErrChangesNotTracked = errors.New("dagger: graph is not tracking changes")
+
+// ErrCheckpointPruned is returned when asking for the changes since a checkpoint whose deletions were forgotten by
+// PruneChanges
+var ErrCheckpointPruned = errors.New("dagger: checkpoint has been pruned")
+
+// Checkpoint is a position in the sequence of changes made to a graph. Every change to a node or edge advances it.
+type Checkpoint uint64
+
+// Changes are the nodes & edges added, patched or deleted after one checkpoint, up to another
+type Changes struct {
+	// Since is the checkpoint the changes were made after
+	Since Checkpoint `json:"since"`
+	// Checkpoint is the checkpoint of the last change. Pass it to ChangesSince to get the changes that follow.
+	Checkpoint Checkpoint `json:"checkpoint"`
+	// Nodes are the current attributes of the nodes added or patched since the checkpoint
+	Nodes []Node `json:"nodes"`
+	// Edges are the current attributes of the edges added or patched since the checkpoint
+	Edges []*Edge `json:"edges"`
+	// DeletedNodes are the _type & _id of the nodes deleted since the checkpoint
+	DeletedNodes []Node `json:"deleted_nodes"`
+	// DeletedEdges are the _type & _id of the edges deleted since the checkpoint
+	DeletedEdges []Node `json:"deleted_edges"`
+}
+
+// changelog records the sequence number of the last change to every node & edge, along with tombstones of deleted
+// ones, so the changes since a checkpoint can be found without a full scan of the graph
+type changelog struct {
+	mu sync.RWMutex
+	// enabled is whether changes are recorded(see TrackChanges)
+	enabled      bool
+	seq          uint64
+	pruned       uint64
+	nodes        map[string]change
+	edges        map[string]change
+	deletedNodes map[string]change
+	deletedEdges map[string]change
+}
+
+// change is the last change to a node or edge
+type change struct {
+	seq uint64
+	id  Node
+}
+
+// TrackChanges records the sequence of changes made to the graph, so the changes since a checkpoint can be read with
+// ChangesSince. A tombstone is kept for every deleted node & edge until it is pruned with PruneChanges. Changes are not
+// tracked by default.
+func TrackChanges() GraphOption {
+	return func(g *Graph) {
+		g.changes.mu.Lock()
+		defer g.changes.mu.Unlock()
+		g.changes.enabled = true
+	}
+}
+
+func (c *changelog) touch(changed, deleted map[string]change, id TypedID) map[string]change {
+	c.seq++
+	if changed == nil {
+		changed = map[string]change{}
+	}
+	changed[pathOf(id)] = change{seq: c.seq, id: Node{TYPE_KEY: id.Type(), ID_KEY: id.ID()}}
+	delete(deleted, pathOf(id))
+	return changed
+}
+
+func (c *changelog) setNode(id TypedID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.enabled {
+		return
+	}
+	c.nodes = c.touch(c.nodes, c.deletedNodes, id)
+}
+
+func (c *changelog) setEdge(id TypedID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.enabled {
+		return
+	}
+	c.edges = c.touch(c.edges, c.deletedEdges, id)
+}
+
+func (c *changelog) delNode(id TypedID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.enabled {
+		return
+	}
+	c.deletedNodes = c.touch(c.deletedNodes, c.nodes, id)
+}
+
+func (c *changelog) delEdge(id TypedID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.enabled {
+		return
+	}
+	c.deletedEdges = c.touch(c.deletedEdges, c.edges, id)
+}
+
+// since returns the ids changed after the checkpoint in the order they were changed
+func since(changed map[string]change, checkpoint Checkpoint) []Node {
+	var changes []change
+	for _, c := range changed {
+		if c.seq > uint64(checkpoint) {
+			changes = append(changes, c)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].seq < changes[j].seq
+	})
+	ids := make([]Node, 0, len(changes))
+	for _, c := range changes {
+		ids = append(ids, c.id)
+	}
+	return ids
+}
+
+// Checkpoint returns the checkpoint of the graph's latest change, or the zero checkpoint if the graph is not tracking
+// changes
+func (g *Graph) Checkpoint() Checkpoint {
+	g.changes.mu.RLock()
+	defer g.changes.mu.RUnlock()
+	return Checkpoint(g.changes.seq)
+}
+
+// ChangesSince returns copies of the nodes & edges changed after the checkpoint, in the order they were changed, along
+// with the ids of the ones deleted since. The zero checkpoint returns every node & edge. It returns
+// ErrCheckpointPruned if deletions made after the checkpoint have been forgotten by PruneChanges, and
+// ErrChangesNotTracked if the graph is not tracking changes.
+func (g *Graph) ChangesSince(checkpoint Checkpoint) (*Changes, error) {
+	defer g.rlock()()
+	c := &g.changes
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.enabled {
+		return nil, ErrChangesNotTracked
+	}
+	if uint64(checkpoint) < c.pruned {
+		return nil, fmt.Errorf("%w: changes since %v were pruned up to %v", ErrCheckpointPruned, checkpoint, c.pruned)
+	}
+	changes := &Changes{Since: checkpoint, Checkpoint: Checkpoint(c.seq)}
+	for _, id := range since(c.nodes, checkpoint) {
+		if n, ok := g.GetNode(id); ok {
+			changes.Nodes = append(changes.Nodes, n.Copy())
+		}
+	}
+	for _, id := range since(c.edges, checkpoint) {
+		if e, ok := g.GetEdge(id); ok {
+			changes.Edges = append(changes.Edges, &Edge{
+				Node: e.Node.Copy(),
+				From: Node{TYPE_KEY: e.From.Type(), ID_KEY: e.From.ID()},
+				To:   Node{TYPE_KEY: e.To.Type(), ID_KEY: e.To.ID()},
+			})
+		}
+	}
+	changes.DeletedNodes = since(c.deletedNodes, checkpoint)
+	changes.DeletedEdges = since(c.deletedEdges, checkpoint)
+	return changes, nil
+}
+
+// Transform returns a copy of the changes with the transforms applied to every node, edge and edge endpoint. The
+// deleted nodes & edges are only identified by _type & _id, so they are left as they are.
+func (c *Changes) Transform(transforms ...Transform) *Changes {
+	changes := &Changes{
+		Since:        c.Since,
+		Checkpoint:   c.Checkpoint,
+		DeletedNodes: c.DeletedNodes,
+		DeletedEdges: c.DeletedEdges,
+	}
+	for _, n := range c.Nodes {
+		changes.Nodes = append(changes.Nodes, transformed(n, transforms))
+	}
+	for _, e := range c.Edges {
+		changes.Edges = append(changes.Edges, transformedEdge(e, transforms))
+	}
+	return changes
+}
+
+// PruneChanges forgets the deletions made up to the checkpoint to free memory. ChangesSince returns
+// ErrCheckpointPruned for earlier checkpoints afterwards.
+func (g *Graph) PruneChanges(checkpoint Checkpoint) {
+	c := &g.changes
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, deleted := range []map[string]change{c.deletedNodes, c.deletedEdges} {
+		for path, change := range deleted {
+			if change.seq <= uint64(checkpoint) {
+				delete(deleted, path)
+			}
+		}
+	}
+	if uint64(checkpoint) > c.pruned {
+		c.pruned = uint64(checkpoint)
+	}
+}
+
+// ApplyChanges applies changes made to another graph(ex: an incremental backup): deleted edges & nodes are removed,
+// then changed nodes & edges are added or replaced. The changes are checked first, so changes the graph would reject
+// are not applied at all.
+func (g *Graph) ApplyChanges(changes *Changes) error {
+	defer g.lock()()
+	if g.ReadOnly() {
+		return ErrReadOnly
+	}
+	if err := g.checkChanges(changes); err != nil {
+		return err
+	}
+	for _, id := range changes.DeletedEdges {
+		g.delEdge(id)
+	}
+	for _, id := range changes.DeletedNodes {
+		g.delNode(id)
+	}
+	for _, n := range changes.Nodes {
+		g.addNode(n.Copy())
+	}
+	for _, e := range changes.Edges {
+		if err := g.addEdge(g.resolveEdge(e)); err != nil {
+			return err
+		}
+	}
+	return g.walErr()
+}
+
+// checkChanges returns the error applying the changes would fail with, without changing the graph. Edges are checked
+// against the graph as it will be once the deletions are applied & the nodes are added.
+func (g *Graph) checkChanges(changes *Changes) error {
+	deletedNodes := map[string]bool{}
+	for _, id := range changes.DeletedNodes {
+		deletedNodes[pathOf(id)] = true
+	}
+	nodes := map[string]bool{}
+	for _, n := range changes.Nodes {
+		if err := g.validateNode(n); err != nil {
+			return err
+		}
+		nodes[pathOf(n)] = true
+	}
+	exists := func(id TypedID) bool {
+		return nodes[pathOf(id)] || (!deletedNodes[pathOf(id)] && g.HasNode(id))
+	}
+	// existing edges that are deleted or replaced do not count towards UniqueEdges
+	replaced := map[string]bool{}
+	for _, id := range changes.DeletedEdges {
+		replaced[pathOf(id)] = true
+	}
+	for _, e := range changes.Edges {
+		replaced[pathOf(e)] = true
+	}
+	keys := map[string]string{}
+	for _, e := range changes.Edges {
+		if e.Node == nil || e.From == nil || e.To == nil || !e.Exists(TYPE_KEY) {
+			return fmt.Errorf("dagger: invalid edge: %v", e)
+		}
+		for _, end := range []Node{e.From, e.To} {
+			if !exists(end) {
+				return fmt.Errorf("node %s.%s does not exist", end.Type(), end.ID())
+			}
+		}
+		if err := g.validateEdge(e); err != nil {
+			return err
+		}
+		key := g.uniqueKey(e)
+		if key == "" {
+			continue
+		}
+		duplicate, ok := keys[key]
+		if !ok {
+			g.EdgesFrom(anyType{}, e.From, func(existing *Edge) bool {
+				if !replaced[pathOf(existing)] && !deletedNodes[pathOf(existing.To)] && g.uniqueKey(existing) == key {
+					duplicate, ok = pathOf(existing), true
+					return false
+				}
+				return true
+			})
+		}
+		if ok && duplicate != pathOf(e) {
+			return fmt.Errorf("%w: %s already connects %s to %s", ErrDuplicateEdge, duplicate, pathOf(e.From), pathOf(e.To))
+		}
+		keys[key] = pathOf(e)
+	}
+	return nil
+}
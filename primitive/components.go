@@ -0,0 +1,197 @@
+package primitive
+
+import "sort"
+
+// ComponentCount returns the number of weakly connected components in the graph(edge direction is ignored)
+func (g *Graph) ComponentCount() int {
+	parents := g.weakComponents()
+	count := 0
+	for key, parent := range parents {
+		if key == parent {
+			count++
+		}
+	}
+	return count
+}
+
+// ConnectedComponents returns the weakly connected components of the graph(edge direction is ignored): groups of nodes
+// joined to each other by edges in either direction. Nodes are sorted by type.id within a component & components are
+// sorted by their first node, so the result is deterministic. Isolated nodes are components of their own.
+func (g *Graph) ConnectedComponents() [][]Node {
+	parents := g.weakComponents()
+	groups := map[string][]Node{}
+	g.RangeNodes(func(n Node) bool {
+		root := parents[pathOf(n)]
+		groups[root] = append(groups[root], n)
+		return true
+	})
+	var components [][]Node
+	for _, group := range groups {
+		components = append(components, group)
+	}
+	return sortComponents(components)
+}
+
+// weakComponents returns the union-find parent of every node's type.id, where each component's root is its own parent
+func (g *Graph) weakComponents() map[string]string {
+	parents := map[string]string{}
+	var find func(key string) string
+	find = func(key string) string {
+		for parents[key] != key {
+			parents[key] = parents[parents[key]]
+			key = parents[key]
+		}
+		return key
+	}
+	g.RangeNodes(func(n Node) bool {
+		key := pathOf(n)
+		parents[key] = key
+		return true
+	})
+	g.RangeEdges(func(e *Edge) bool {
+		from, to := pathOf(e.From), pathOf(e.To)
+		if _, ok := parents[from]; !ok {
+			return true
+		}
+		if _, ok := parents[to]; !ok {
+			return true
+		}
+		parents[find(from)] = find(to)
+		return true
+	})
+	for key := range parents {
+		parents[key] = find(key)
+	}
+	return parents
+}
+
+// StronglyConnectedComponents returns the strongly connected components of the graph: groups of nodes that can each
+// reach every other node in the group over outgoing edges. Nodes that are not on a cycle are components of their own.
+// The result is sorted like ConnectedComponents.
+func (g *Graph) StronglyConnectedComponents() [][]Node {
+	// Tarjan's algorithm, with an explicit stack so deep graphs cannot overflow the goroutine stack
+	index := map[string]int{}
+	low := map[string]int{}
+	onStack := map[string]bool{}
+	var stack []Node
+	var components [][]Node
+	type frame struct {
+		node      Node
+		neighbors []Node
+		next      int
+	}
+	neighbors := func(n Node) []Node {
+		var out []Node
+		g.EdgesFrom(anyType{}, n, func(e *Edge) bool {
+			if to, ok := g.GetNode(e.To); ok {
+				out = append(out, to)
+			}
+			return true
+		})
+		return out
+	}
+	counter := 0
+	visit := func(n Node) *frame {
+		key := pathOf(n)
+		index[key] = counter
+		low[key] = counter
+		counter++
+		stack = append(stack, n)
+		onStack[key] = true
+		return &frame{node: n, neighbors: neighbors(n)}
+	}
+	g.RangeNodes(func(start Node) bool {
+		if _, ok := index[pathOf(start)]; ok {
+			return true
+		}
+		frames := []*frame{visit(start)}
+		for len(frames) > 0 {
+			f := frames[len(frames)-1]
+			key := pathOf(f.node)
+			if f.next < len(f.neighbors) {
+				to := f.neighbors[f.next]
+				f.next++
+				toKey := pathOf(to)
+				if _, ok := index[toKey]; !ok {
+					frames = append(frames, visit(to))
+				} else if onStack[toKey] && index[toKey] < low[key] {
+					low[key] = index[toKey]
+				}
+				continue
+			}
+			frames = frames[:len(frames)-1]
+			if len(frames) > 0 {
+				parent := pathOf(frames[len(frames)-1].node)
+				if low[key] < low[parent] {
+					low[parent] = low[key]
+				}
+			}
+			if low[key] == index[key] {
+				var component []Node
+				for {
+					top := stack[len(stack)-1]
+					stack = stack[:len(stack)-1]
+					onStack[pathOf(top)] = false
+					component = append(component, top)
+					if pathOf(top) == key {
+						break
+					}
+				}
+				components = append(components, component)
+			}
+		}
+		return true
+	})
+	return sortComponents(components)
+}
+
+func sortComponents(components [][]Node) [][]Node {
+	for _, c := range components {
+		sort.Slice(c, func(i, j int) bool {
+			return pathOf(c[i]) < pathOf(c[j])
+		})
+	}
+	sort.Slice(components, func(i, j int) bool {
+		return pathOf(components[i][0]) < pathOf(components[j][0])
+	})
+	return components
+}
+
+// Reachable returns true if the to node can be reached from the from node over outgoing edges of any type. A node can
+// always reach itself.
+func (g *Graph) Reachable(from, to TypedID) bool {
+	if !g.HasNode(from) || !g.HasNode(to) {
+		return false
+	}
+	target := pathOf(to)
+	if pathOf(from) == target {
+		return true
+	}
+	visited := map[string]bool{pathOf(from): true}
+	queue := []TypedID{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		found := false
+		g.EdgesFrom(anyType{}, current, func(e *Edge) bool {
+			key := pathOf(e.To)
+			if key == target {
+				found = true
+				return false
+			}
+			if !visited[key] {
+				visited[key] = true
+				queue = append(queue, e.To)
+			}
+			return true
+		})
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+func pathOf(id TypedID) string {
+	return id.Type() + "." + id.ID()
+}
@@ -0,0 +1,474 @@
+package primitive
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Replica is a conflict-free replicated copy of a graph. Each process mutates its own replica, and replicas converge to
+// the same graph by exchanging their state(see Merge & Sync) in any order, any number of times, without coordination.
+//
+// Whether a node or edge exists is an observed-remove set: deleting it removes only the additions the replica has
+// observed, so an addition made concurrently with a delete wins. Each attribute is a last-writer-wins register ordered by
+// a lamport clock, with ties broken by replica id. Deleting a node deletes the edges to & from it that the replica has
+// observed. An edge whose endpoint does not exist is hidden from the graph until the endpoint is added again.
+//
+// The replica writes the materialized graph into the graph it was created from: read the graph, but write through the
+// replica, or the write is neither replicated nor protected from being overwritten by a merge. Attribute values are
+// stored as json, so numbers are read back as float64. Deleted elements are remembered as tombstones so the deletion can
+// be replicated, which means the state only grows.
+type Replica struct {
+	mu    sync.Mutex
+	id    string
+	graph *Graph
+	clock uint64
+	nodes map[string]*CRDTElement
+	edges map[string]*CRDTElement
+}
+
+// CRDTState is the replicated state of a Replica. It is passed between replicas as json.
+type CRDTState struct {
+	Replica string                  `json:"replica"`
+	Clock   uint64                  `json:"clock"`
+	Nodes   map[string]*CRDTElement `json:"nodes"`
+	Edges   map[string]*CRDTElement `json:"edges"`
+}
+
+// CRDTElement is the replicated state of a node or edge
+type CRDTElement struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	// From & To are the _type & _id of an edge's endpoints
+	From Node `json:"from,omitempty"`
+	To   Node `json:"to,omitempty"`
+	// Adds are the tags of each time the element was added, & Removes the tags of the additions that were deleted. The
+	// element exists if it has an addition that was not deleted.
+	Adds       map[string]bool         `json:"adds"`
+	Removes    map[string]bool         `json:"removes,omitempty"`
+	Attributes map[string]CRDTRegister `json:"attributes"`
+}
+
+// CRDTRegister is a last-writer-wins attribute value
+type CRDTRegister struct {
+	Value   json.RawMessage `json:"value,omitempty"`
+	Deleted bool            `json:"deleted,omitempty"`
+	Clock   uint64          `json:"clock"`
+	Replica string          `json:"replica"`
+}
+
+// newer returns true if the register was written after the other register
+func (r CRDTRegister) newer(other CRDTRegister) bool {
+	if r.Clock != other.Clock {
+		return r.Clock > other.Clock
+	}
+	return r.Replica > other.Replica
+}
+
+func (e *CRDTElement) exists() bool {
+	for tag := range e.Adds {
+		if !e.Removes[tag] {
+			return true
+		}
+	}
+	return false
+}
+
+// remove deletes every observed addition
+func (e *CRDTElement) remove() {
+	if e.Removes == nil {
+		e.Removes = map[string]bool{}
+	}
+	for tag := range e.Adds {
+		e.Removes[tag] = true
+	}
+}
+
+// merge joins the other element into the element, returning true if the element changed
+func (e *CRDTElement) merge(other *CRDTElement) bool {
+	changed := false
+	for tag := range other.Adds {
+		if !e.Adds[tag] {
+			e.Adds[tag] = true
+			changed = true
+		}
+	}
+	for tag := range other.Removes {
+		if !e.Removes[tag] {
+			if e.Removes == nil {
+				e.Removes = map[string]bool{}
+			}
+			e.Removes[tag] = true
+			changed = true
+		}
+	}
+	for k, r := range other.Attributes {
+		if current, ok := e.Attributes[k]; !ok || r.newer(current) {
+			e.Attributes[k] = r
+			changed = true
+		}
+	}
+	return changed
+}
+
+// attributes decodes the current attribute values
+func (e *CRDTElement) attributes() (Node, error) {
+	n := e.typedID()
+	for k, r := range e.Attributes {
+		if r.Deleted {
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal(r.Value, &v); err != nil {
+			return nil, fmt.Errorf("attribute %s of %s.%s: %w", k, e.Type, e.ID, err)
+		}
+		n[k] = v
+	}
+	return n, nil
+}
+
+func (e *CRDTElement) typedID() Node {
+	return Node{TYPE_KEY: e.Type, ID_KEY: e.ID}
+}
+
+func newCRDTElement(id TypedID) *CRDTElement {
+	return &CRDTElement{
+		Type:       id.Type(),
+		ID:         id.ID(),
+		Adds:       map[string]bool{},
+		Attributes: map[string]CRDTRegister{},
+	}
+}
+
+// NewReplica returns a replica of the graph identified by the replica id, which must be unique across the replicas
+// that will be merged. The graph should start empty, since only writes made through the replica are replicated.
+func (g *Graph) NewReplica(id string) *Replica {
+	return &Replica{
+		id:    id,
+		graph: g,
+		nodes: map[string]*CRDTElement{},
+		edges: map[string]*CRDTElement{},
+	}
+}
+
+// ID returns the replica's id
+func (r *Replica) ID() string {
+	return r.id
+}
+
+// tick advances the clock, returning a tag that is unique to the replica
+func (r *Replica) tick() string {
+	r.clock++
+	return r.id + "@" + strconv.FormatUint(r.clock, 10)
+}
+
+// set writes the attributes into the element's registers. A nil value deletes the attribute.
+func (r *Replica) set(e *CRDTElement, attributes map[string]interface{}) error {
+	for k, v := range attributes {
+		if k == ID_KEY || k == TYPE_KEY {
+			continue
+		}
+		reg := CRDTRegister{Clock: r.clock, Replica: r.id, Deleted: v == nil}
+		if v != nil {
+			bits, err := json.Marshal(v)
+			if err != nil {
+				return fmt.Errorf("attribute %s: %w", k, err)
+			}
+			reg.Value = bits
+		}
+		e.Attributes[k] = reg
+	}
+	return nil
+}
+
+// AddNode adds the node, or adds its attributes to the existing node with the same _type & _id, returning the node's
+// id. Nodes without an _id are given one, and nodes without a _type are given the default type.
+func (r *Replica) AddNode(attributes map[string]interface{}) (TypedID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := Node{TYPE_KEY: DefaultType, ID_KEY: UUID()}
+	if typ, ok := attributes[TYPE_KEY].(string); ok && typ != "" {
+		id.SetType(typ)
+	}
+	if nodeID, ok := attributes[ID_KEY].(string); ok && nodeID != "" {
+		id.SetID(nodeID)
+	}
+	e, ok := r.nodes[pathOf(id)]
+	if !ok {
+		e = newCRDTElement(id)
+		r.nodes[pathOf(id)] = e
+	}
+	e.Adds[r.tick()] = true
+	if err := r.set(e, attributes); err != nil {
+		return nil, err
+	}
+	return id, r.materialize([]string{pathOf(id)}, nil)
+}
+
+// PatchNode sets the attributes of an existing node. A nil value deletes the attribute.
+func (r *Replica) PatchNode(id TypedID, attributes map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.nodeExists(id) {
+		return fmt.Errorf("node %s.%s does not exist", id.Type(), id.ID())
+	}
+	e := r.nodes[pathOf(id)]
+	r.tick()
+	if err := r.set(e, attributes); err != nil {
+		return err
+	}
+	return r.materialize([]string{pathOf(id)}, nil)
+}
+
+// DelNode deletes the node & the edges to & from it
+func (r *Replica) DelNode(id TypedID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.nodes[pathOf(id)]
+	if !ok {
+		return nil
+	}
+	r.tick()
+	e.remove()
+	var edges []string
+	for key, edge := range r.edges {
+		if pathOf(edge.From) == pathOf(id) || pathOf(edge.To) == pathOf(id) {
+			edge.remove()
+			edges = append(edges, key)
+		}
+	}
+	return r.materialize([]string{pathOf(id)}, edges)
+}
+
+// Connect adds an edge of the edge type between two existing nodes, returning the edge's id
+func (r *Replica) Connect(from, to TypedID, edgeType string, attributes map[string]interface{}) (TypedID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range []TypedID{from, to} {
+		if !r.nodeExists(id) {
+			return nil, fmt.Errorf("node %s.%s does not exist", id.Type(), id.ID())
+		}
+	}
+	id := Node{TYPE_KEY: edgeType, ID_KEY: UUID()}
+	e := newCRDTElement(id)
+	e.From = Node{TYPE_KEY: from.Type(), ID_KEY: from.ID()}
+	e.To = Node{TYPE_KEY: to.Type(), ID_KEY: to.ID()}
+	e.Adds[r.tick()] = true
+	if err := r.set(e, attributes); err != nil {
+		return nil, err
+	}
+	r.edges[pathOf(id)] = e
+	return id, r.materialize(nil, []string{pathOf(id)})
+}
+
+// PatchEdge sets the attributes of an existing edge. A nil value deletes the attribute.
+func (r *Replica) PatchEdge(id TypedID, attributes map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.edges[pathOf(id)]
+	if !ok || !e.exists() {
+		return fmt.Errorf("edge %s.%s does not exist", id.Type(), id.ID())
+	}
+	r.tick()
+	if err := r.set(e, attributes); err != nil {
+		return err
+	}
+	return r.materialize(nil, []string{pathOf(id)})
+}
+
+// DelEdge deletes the edge
+func (r *Replica) DelEdge(id TypedID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.edges[pathOf(id)]
+	if !ok {
+		return nil
+	}
+	r.tick()
+	e.remove()
+	return r.materialize(nil, []string{pathOf(id)})
+}
+
+func (r *Replica) nodeExists(id TypedID) bool {
+	e, ok := r.nodes[pathOf(id)]
+	return ok && e.exists()
+}
+
+// State returns a copy of the replica's state to send to other replicas
+func (r *Replica) State() *CRDTState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state := &CRDTState{
+		Replica: r.id,
+		Clock:   r.clock,
+		Nodes:   make(map[string]*CRDTElement, len(r.nodes)),
+		Edges:   make(map[string]*CRDTElement, len(r.edges)),
+	}
+	for key, e := range r.nodes {
+		state.Nodes[key] = copyCRDTElement(e)
+	}
+	for key, e := range r.edges {
+		state.Edges[key] = copyCRDTElement(e)
+	}
+	return state
+}
+
+func copyCRDTElement(e *CRDTElement) *CRDTElement {
+	c := newCRDTElement(e.typedID())
+	c.From = e.From
+	c.To = e.To
+	c.merge(e)
+	return c
+}
+
+// Merge joins another replica's state into the replica & writes the changes it makes into the graph. Merging is
+// commutative, associative & idempotent, so replicas that have merged each other's states hold the same graph.
+func (r *Replica) Merge(state *CRDTState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if state.Clock > r.clock {
+		r.clock = state.Clock
+	}
+	join := func(elements, into map[string]*CRDTElement) []string {
+		var changed []string
+		for key, e := range elements {
+			current, ok := into[key]
+			if !ok {
+				into[key] = copyCRDTElement(e)
+				changed = append(changed, key)
+			} else if current.merge(e) {
+				changed = append(changed, key)
+			}
+		}
+		return changed
+	}
+	return r.materialize(join(state.Nodes, r.nodes), join(state.Edges, r.edges))
+}
+
+// materialize writes the current state of the nodes & edges into the graph. Edges to & from the nodes are
+// materialized too, since they are hidden while either endpoint does not exist. The caller must hold the replica's lock.
+func (r *Replica) materialize(nodes, edges []string) error {
+	touched := map[string]bool{}
+	for _, key := range nodes {
+		touched[key] = true
+	}
+	if len(touched) > 0 {
+		for key, e := range r.edges {
+			if touched[pathOf(e.From)] || touched[pathOf(e.To)] {
+				edges = append(edges, key)
+			}
+		}
+	}
+	sort.Strings(nodes)
+	sort.Strings(edges)
+	g := r.graph
+	defer g.lock()()
+	if g.ReadOnly() {
+		return ErrReadOnly
+	}
+	for _, key := range nodes {
+		e := r.nodes[key]
+		if !e.exists() {
+			if g.HasNode(e.typedID()) {
+				g.delNode(e.typedID())
+			}
+			continue
+		}
+		n, err := e.attributes()
+		if err != nil {
+			return err
+		}
+		if current, ok := g.GetNode(n); !ok || !attributesEqual(current, n) {
+			g.addNode(n)
+		}
+	}
+	for _, key := range edges {
+		e := r.edges[key]
+		if !e.exists() || !r.nodeExists(e.From) || !r.nodeExists(e.To) {
+			if g.HasEdge(e.typedID()) {
+				g.delEdge(e.typedID())
+			}
+			continue
+		}
+		n, err := e.attributes()
+		if err != nil {
+			return err
+		}
+		if current, ok := g.GetEdge(e.typedID()); ok && attributesEqual(current.Node, n) {
+			continue
+		}
+		if err := g.addEdge(g.resolveEdge(&Edge{Node: n, From: e.From, To: e.To})); err != nil {
+			return err
+		}
+	}
+	return g.walErr()
+}
+
+// ReplicaPeer is a replica that can be synced with, in the same process or across the network
+type ReplicaPeer interface {
+	State() (*CRDTState, error)
+	Merge(state *CRDTState) error
+}
+
+// Sync merges the peer's state into the replica, then the replica's state into the peer, so both converge
+func (r *Replica) Sync(peer ReplicaPeer) error {
+	state, err := peer.State()
+	if err != nil {
+		return err
+	}
+	if err := r.Merge(state); err != nil {
+		return err
+	}
+	return peer.Merge(r.State())
+}
+
+// StartExchange syncs the replica with every peer in the background every interval until the returned stop function
+// is called(it returns once any exchange in progress completes). Errors are passed to onError if it is not nil.
+func (r *Replica) StartExchange(interval time.Duration, peers []ReplicaPeer, onError func(err error)) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				for _, peer := range peers {
+					if err := r.Sync(peer); err != nil && onError != nil {
+						onError(fmt.Errorf("dagger: crdt exchange: %w", err))
+					}
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+		<-exited
+	}
+}
+
+// Peer returns the replica as a ReplicaPeer, for syncing replicas in the same process
+func (r *Replica) Peer() ReplicaPeer {
+	return localReplica{r}
+}
+
+type localReplica struct {
+	replica *Replica
+}
+
+func (l localReplica) State() (*CRDTState, error) {
+	return l.replica.State(), nil
+}
+
+func (l localReplica) Merge(state *CRDTState) error {
+	return l.replica.Merge(state)
+}
@@ -0,0 +1,112 @@
+package primitive
+
+import "context"
+
+// canceller checks a context between iterations, recording its error once it is done
+type canceller struct {
+	ctx context.Context
+	err error
+}
+
+// done returns true once the context is cancelled or its deadline is exceeded
+func (c *canceller) done() bool {
+	if c.err == nil {
+		c.err = c.ctx.Err()
+	}
+	return c.err != nil
+}
+
+func (c *canceller) nodes(fn func(n Node) bool) func(n Node) bool {
+	return func(n Node) bool {
+		return !c.done() && fn(n)
+	}
+}
+
+func (c *canceller) edges(fn func(e *Edge) bool) func(e *Edge) bool {
+	return func(e *Edge) bool {
+		return !c.done() && fn(e)
+	}
+}
+
+func (c *canceller) depths(fn func(n Node, depth int) bool) func(n Node, depth int) bool {
+	return func(n Node, depth int) bool {
+		return !c.done() && fn(n, depth)
+	}
+}
+
+// RangeNodesCtx is like RangeNodes, but stops iterating & returns the context's error once it is done
+func (g *Graph) RangeNodesCtx(ctx context.Context, fn func(n Node) bool) error {
+	c := &canceller{ctx: ctx}
+	g.RangeNodes(c.nodes(fn))
+	return c.err
+}
+
+// RangeNodeTypesCtx is like RangeNodeTypes, but stops iterating & returns the context's error once it is done
+func (g *Graph) RangeNodeTypesCtx(ctx context.Context, typ Type, fn func(n Node) bool) error {
+	c := &canceller{ctx: ctx}
+	g.RangeNodeTypes(typ, c.nodes(fn))
+	return c.err
+}
+
+// RangeEdgesCtx is like RangeEdges, but stops iterating & returns the context's error once it is done
+func (g *Graph) RangeEdgesCtx(ctx context.Context, fn func(e *Edge) bool) error {
+	c := &canceller{ctx: ctx}
+	g.RangeEdges(c.edges(fn))
+	return c.err
+}
+
+// RangeEdgeTypesCtx is like RangeEdgeTypes, but stops iterating & returns the context's error once it is done
+func (g *Graph) RangeEdgeTypesCtx(ctx context.Context, edgeType Type, fn func(e *Edge) bool) error {
+	c := &canceller{ctx: ctx}
+	g.RangeEdgeTypes(edgeType, c.edges(fn))
+	return c.err
+}
+
+// EdgesFromCtx is like EdgesFrom, but stops iterating & returns the context's error once it is done
+func (g *Graph) EdgesFromCtx(ctx context.Context, edgeType Type, id TypedID, fn func(e *Edge) bool) error {
+	c := &canceller{ctx: ctx}
+	g.EdgesFrom(edgeType, id, c.edges(fn))
+	return c.err
+}
+
+// EdgesToCtx is like EdgesTo, but stops iterating & returns the context's error once it is done
+func (g *Graph) EdgesToCtx(ctx context.Context, edgeType Type, id TypedID, fn func(e *Edge) bool) error {
+	c := &canceller{ctx: ctx}
+	g.EdgesTo(edgeType, id, c.edges(fn))
+	return c.err
+}
+
+// BFSCtx is like BFS, but stops the traversal & returns the context's error once it is done. The result is truncated
+// if the traversal was cancelled.
+func (g *Graph) BFSCtx(ctx context.Context, start TypedID, edgeType Type, opts TraversalOptions, fn func(n Node, depth int) bool) (TraversalResult, error) {
+	c := &canceller{ctx: ctx}
+	result := g.BFS(start, edgeType, opts, c.depths(fn))
+	if c.err != nil {
+		result.Truncated = true
+	}
+	return result, c.err
+}
+
+// DFSCtx is like DFS, but stops the traversal & returns the context's error once it is done. The result is truncated
+// if the traversal was cancelled.
+func (g *Graph) DFSCtx(ctx context.Context, start TypedID, opts TraversalOptions, fn func(n Node, depth int) bool) (TraversalResult, error) {
+	c := &canceller{ctx: ctx}
+	result := g.DFS(start, opts, c.depths(fn))
+	if c.err != nil {
+		result.Truncated = true
+	}
+	return result, c.err
+}
+
+// DFSPostOrderCtx is like DFSPostOrder, but stops the traversal & returns the context's error once it is done. The
+// result is truncated if the traversal was cancelled.
+func (g *Graph) DFSPostOrderCtx(ctx context.Context, start TypedID, opts TraversalOptions, fn func(n Node, depth int) bool) (TraversalResult, error) {
+	c := &canceller{ctx: ctx}
+	result := g.dfs(start, opts, func(n Node, depth int) bool {
+		return !c.done()
+	}, c.depths(fn))
+	if c.err != nil {
+		result.Truncated = true
+	}
+	return result, c.err
+}
@@ -2,26 +2,65 @@ package primitive
 
 import (
 	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
 )
 
 // Graph is a concurrency safe, mutable, in-memory directed graph
 type Graph struct {
+	// mu is held exclusively by mutations that change many nodes & edges(see lock), & shared by the mutations that
+	// change a few(see lockPaths), which also lock the stripes of the elements they change. Reads of single nodes &
+	// edges go straight to the sharded storage without taking it.
 	mu        sync.RWMutex
-	nodes     *namespacedCache
-	edges     *namespacedCache
-	edgesFrom *namespacedCache
-	edgesTo   *namespacedCache
+	stripes   [stripeCount]stripe
+	exclusive bool
+	nodes     Storage
+	edges     Storage
+	edgesFrom Storage
+	edgesTo   Storage
+	storage   Storage
+	metrics   atomic.Value
+	readOnly  int32
+	edgeTypes *hierarchy
+	nodeTypes *hierarchy
+	dirty     *dirtyTracker
+	wal       *WAL
+	hooks     hooks
+	events    []hookEvent
+	indexes   indexes
+	schemas   schemas
+	// deletePolicies determine what DelNode does with the edges of each type
+	deletePolicies deletePolicies
+	history        history
+	ordering       ordering
+	changes        changelog
+	// uniqueEdges is whether parallel edges are allowed(see UniqueEdges)
+	uniqueEdges int
+	counts      counts
+	expiring    expiryCallbacks
 }
 
-func NewGraph() *Graph {
-	return &Graph{
+// NewGraph creates a graph. By default, the graph is kept in memory.
+func NewGraph(opts ...GraphOption) *Graph {
+	g := &Graph{
 		mu:        sync.RWMutex{},
 		nodes:     newCache(),
 		edges:     newCache(),
 		edgesFrom: newCache(),
 		edgesTo:   newCache(),
+		edgeTypes: newHierarchy(),
+		nodeTypes: newHierarchy(),
+		dirty:     newDirtyTracker(),
 	}
+	for _, o := range opts {
+		o(g)
+	}
+	if g.storage != nil {
+		// the storage may already hold a graph
+		g.recount(g.checkIntegrity())
+	}
+	return g
 }
 
 func (g *Graph) EdgeTypes() []string {
@@ -32,18 +71,67 @@ func (g *Graph) NodeTypes() []string {
 	return g.nodes.Namespaces()
 }
 
-func (g *Graph) AddNode(n Node) {
+func (g *Graph) AddNode(n Node) error {
+	if n.ID() == "" {
+		n.SetID(UUID())
+	}
+	defer g.lockPaths(pathOf(n))()
+	if g.ReadOnly() {
+		return ErrReadOnly
+	}
+	if err := g.validateNode(n); err != nil {
+		return err
+	}
+	g.addNode(n)
+	return g.walErr()
+}
+
+func (g *Graph) addNode(n Node) {
 	if n.ID() == "" {
 		n.SetID(UUID())
 	}
+	current, exists := g.GetNode(n)
+	if !exists {
+		g.counts.node(n.Type(), 1)
+		g.MarkDirty(n, changedFields(Node{}, n)...)
+	} else if !sameNode(current, n) {
+		g.MarkDirty(n, changedFields(current, n)...)
+	}
 	g.nodes.Set(n.Type(), n.ID(), n)
+	g.ordering.insert(n)
+	g.changes.setNode(n)
+	g.indexes.update(n)
+	g.history.record(n)
+	g.log(walEntry{Op: walSetNode, Node: n})
+	if exists {
+		g.recordNode(nodePatched, n)
+	} else {
+		g.recordNode(nodeAdded, n)
+	}
+	g.count(MetricNodesAdded, n.Type())
 }
 
-func (g *Graph) AddNodes(nodes ...Node) {
+func (g *Graph) AddNodes(nodes ...Node) error {
+	paths := make([]string, len(nodes))
+	for i, n := range nodes {
+		if n.ID() == "" {
+			n.SetID(UUID())
+		}
+		paths[i] = pathOf(n)
+	}
+	defer g.lockPaths(paths...)()
+	if g.ReadOnly() {
+		return ErrReadOnly
+	}
+	if err := g.validateNodes(nodes...); err != nil {
+		return err
+	}
 	for _, n := range nodes {
-		g.AddNode(n)
+		g.addNode(n)
 	}
+	return g.walErr()
 }
+
 func (g *Graph) GetNode(id TypedID) (Node, bool) {
 	val, ok := g.nodes.Get(id.Type(), id.ID())
 	if ok {
@@ -55,19 +143,43 @@ func (g *Graph) GetNode(id TypedID) (Node, bool) {
 	return nil, false
 }
 
+// RangeNodeTypes iterates over nodes of the given type & its declared subtypes until fn returns false
 func (g *Graph) RangeNodeTypes(typ Type, fn func(n Node) bool) {
-	g.nodes.Range(typ.Type(), func(key string, val interface{}) bool {
-		n, ok := val.(Node)
-		if ok {
-			if !fn(n) {
-				return false
+	if g.ordering.order != Unordered {
+		g.rangeOrderedNodes(func(fn func(n Node) bool) { g.rangeNodeTypes(typ, fn) }, fn)
+		return
+	}
+	g.rangeNodeTypes(typ, fn)
+}
+
+func (g *Graph) rangeNodeTypes(typ Type, fn func(n Node) bool) {
+	for _, t := range g.nodeTypes.expand(typ.Type()) {
+		stopped := false
+		g.nodes.Range(t, func(key string, val interface{}) bool {
+			n, ok := val.(Node)
+			if ok {
+				if !fn(n) {
+					stopped = true
+					return false
+				}
 			}
+			return true
+		})
+		if stopped {
+			return
 		}
-		return true
-	})
+	}
 }
 
 func (g *Graph) RangeNodes(fn func(n Node) bool) {
+	if g.ordering.order != Unordered {
+		g.rangeOrderedNodes(g.rangeNodes, fn)
+		return
+	}
+	g.rangeNodes(fn)
+}
+
+func (g *Graph) rangeNodes(fn func(n Node) bool) {
 	for _, namespace := range g.nodes.Namespaces() {
 		g.nodes.Range(namespace, func(key string, val interface{}) bool {
 			n, ok := val.(Node)
@@ -82,6 +194,14 @@ func (g *Graph) RangeNodes(fn func(n Node) bool) {
 }
 
 func (g *Graph) RangeEdges(fn func(e *Edge) bool) {
+	if g.ordering.order != Unordered {
+		g.rangeOrderedEdges(g.rangeEdges, fn)
+		return
+	}
+	g.rangeEdges(fn)
+}
+
+func (g *Graph) rangeEdges(fn func(e *Edge) bool) {
 	for _, namespace := range g.edges.Namespaces() {
 		g.edges.Range(namespace, func(key string, val interface{}) bool {
 			e, ok := val.(*Edge)
@@ -95,16 +215,32 @@ func (g *Graph) RangeEdges(fn func(e *Edge) bool) {
 	}
 }
 
+// RangeEdgeTypes iterates over edges of the given type & its declared subtypes until fn returns false
 func (g *Graph) RangeEdgeTypes(edgeType Type, fn func(e *Edge) bool) {
-	g.edges.Range(edgeType.Type(), func(key string, val interface{}) bool {
-		e, ok := val.(*Edge)
-		if ok {
-			if !fn(e) {
-				return false
+	if g.ordering.order != Unordered {
+		g.rangeOrderedEdges(func(fn func(e *Edge) bool) { g.rangeEdgeTypes(edgeType, fn) }, fn)
+		return
+	}
+	g.rangeEdgeTypes(edgeType, fn)
+}
+
+func (g *Graph) rangeEdgeTypes(edgeType Type, fn func(e *Edge) bool) {
+	for _, typ := range g.edgeTypes.expand(edgeType.Type()) {
+		stopped := false
+		g.edges.Range(typ, func(key string, val interface{}) bool {
+			e, ok := val.(*Edge)
+			if ok {
+				if !fn(e) {
+					stopped = true
+					return false
+				}
 			}
+			return true
+		})
+		if stopped {
+			return
 		}
-		return true
-	})
+	}
 }
 
 func (g *Graph) HasNode(id TypedID) bool {
@@ -112,23 +248,102 @@ func (g *Graph) HasNode(id TypedID) bool {
 	return ok
 }
 
-func (g *Graph) DelNode(id TypedID) {
-	if val, ok := g.edgesFrom.Get(id.Type(), id.ID()); ok {
-		if val != nil {
-			edges := val.(edgeMap)
-			edges.Range(func(e *Edge) bool {
-				g.DelEdge(e)
+func (g *Graph) DelNode(id TypedID) error {
+	defer g.lock()()
+	if g.ReadOnly() {
+		return ErrReadOnly
+	}
+	deleted, err := g.cascade(id)
+	if err != nil {
+		return err
+	}
+	if len(deleted) == 0 {
+		g.delNode(id)
+	}
+	for _, n := range deleted {
+		g.delNode(n)
+	}
+	return g.walErr()
+}
+
+// delNode removes the node along with every edge that stems from or points to it
+func (g *Graph) delNode(id TypedID) {
+	n, exists := g.GetNode(id)
+	for _, index := range []Storage{g.edgesFrom, g.edgesTo} {
+		if val, ok := index.Get(id.Type(), id.ID()); ok && val != nil {
+			var incident []*Edge
+			val.(*edgeMap).Range(func(e *Edge) bool {
+				incident = append(incident, e)
 				return true
 			})
+			for _, e := range incident {
+				g.delEdge(e)
+			}
 		}
+		index.Delete(id.Type(), id.ID())
 	}
 	g.nodes.Delete(id.Type(), id.ID())
+	g.ordering.remove(id)
+	if exists {
+		g.counts.node(id.Type(), -1)
+		g.changes.delNode(id)
+	}
+	g.indexes.remove(id)
+	g.history.remove(id)
+	g.ClearDirty(id)
+	g.log(walEntry{Op: walDelNode, Node: Node{TYPE_KEY: id.Type(), ID_KEY: id.ID()}})
+	if exists {
+		g.recordNode(nodeDeleted, n)
+	}
+	g.count(MetricNodesDeleted, id.Type())
 }
 
 func (g *Graph) AddEdge(e *Edge) error {
 	if e.ID() == "" {
 		e.SetID(UUID())
 	}
+	defer g.lockPaths(pathOf(e), pathOf(e.From), pathOf(e.To))()
+	if g.ReadOnly() {
+		return ErrReadOnly
+	}
+	if err := g.addEdge(e); err != nil {
+		return err
+	}
+	return g.walErr()
+}
+
+func (g *Graph) addEdge(e *Edge) error {
+	if e.ID() == "" {
+		e.SetID(UUID())
+	}
+	if err := g.checkEdge(e); err != nil {
+		return err
+	}
+	exists := g.HasEdge(e)
+	if !exists {
+		g.counts.edge(e.Type(), 1)
+	}
+	g.edges.Set(e.Type(), e.ID(), e)
+	g.ordering.insert(e)
+	g.changes.setEdge(e)
+	g.indexEdge(g.edgesFrom, e.From, e)
+	g.indexEdge(g.edgesTo, e.To, e)
+	g.log(walEntry{Op: walSetEdge, Edge: &Edge{
+		Node: e.Node,
+		From: Node{TYPE_KEY: e.From.Type(), ID_KEY: e.From.ID()},
+		To:   Node{TYPE_KEY: e.To.Type(), ID_KEY: e.To.ID()},
+	}})
+	if exists {
+		g.recordEdge(edgePatched, e)
+	} else {
+		g.recordEdge(edgeAdded, e)
+	}
+	g.count(MetricEdgesAdded, e.Type())
+	return nil
+}
+
+// checkEdge returns the error adding the edge to the graph would fail with, without changing the graph
+func (g *Graph) checkEdge(e *Edge) error {
 	if err := e.Validate(); err != nil {
 		return err
 	}
@@ -138,35 +353,23 @@ func (g *Graph) AddEdge(e *Edge) error {
 	if !g.HasNode(e.To) {
 		return fmt.Errorf("node %s.%s does not exist", e.To.Type(), e.To.ID())
 	}
-	g.edges.Set(e.Type(), e.ID(), e)
-	if val, ok := g.edgesFrom.Get(e.From.Type(), e.From.ID()); ok {
-		edges := val.(edgeMap)
-		edges.AddEdge(e)
-		g.edgesFrom.Set(e.From.Type(), e.From.ID(), edges)
-	} else {
-		edges := edgeMap{}
-		edges.AddEdge(e)
-		g.edgesFrom.Set(e.From.Type(), e.From.ID(), edges)
-	}
-	if val, ok := g.edgesTo.Get(e.To.Type(), e.To.ID()); ok {
-		edges := val.(edgeMap)
-		edges.AddEdge(e)
-		g.edgesTo.Set(e.To.Type(), e.To.ID(), edges)
-	} else {
-		edges := edgeMap{}
-		edges.AddEdge(e)
-		g.edgesTo.Set(e.To.Type(), e.To.ID(), edges)
+	if err := g.checkUnique(e); err != nil {
+		return err
 	}
-	return nil
+	return g.validateEdge(e)
 }
 
 func (g *Graph) AddEdges(edges ...*Edge) error {
+	defer g.lock()()
+	if g.ReadOnly() {
+		return ErrReadOnly
+	}
 	for _, e := range edges {
-		if err := g.AddEdge(e); err != nil {
+		if err := g.addEdge(e); err != nil {
 			return err
 		}
 	}
-	return nil
+	return g.walErr()
 }
 
 func (g *Graph) HasEdge(id TypedID) bool {
@@ -185,33 +388,60 @@ func (g *Graph) GetEdge(id TypedID) (*Edge, bool) {
 	return nil, false
 }
 
-func (g *Graph) DelEdge(id TypedID) {
+func (g *Graph) DelEdge(id TypedID) error {
+	_, unlock := g.lockEdge(id)
+	defer unlock()
+	if g.ReadOnly() {
+		return ErrReadOnly
+	}
+	g.delEdge(id)
+	return g.walErr()
+}
+
+// lockEdge locks the edge & the nodes on its ends with lockPaths, returning the edge as it is once they are locked(or
+// nil if it does not exist) & the function that releases them
+func (g *Graph) lockEdge(id TypedID) (*Edge, func()) {
+	for {
+		e, ok := g.GetEdge(id)
+		if !ok {
+			return nil, g.lockPaths(pathOf(id))
+		}
+		unlock := g.lockPaths(pathOf(id), pathOf(e.From), pathOf(e.To))
+		current, ok := g.GetEdge(id)
+		if !ok {
+			return nil, unlock
+		}
+		if pathOf(current.From) == pathOf(e.From) && pathOf(current.To) == pathOf(e.To) {
+			return current, unlock
+		}
+		// the edge was re-pointed(see MoveEdges) while waiting for the locks
+		unlock()
+	}
+}
+
+func (g *Graph) delEdge(id TypedID) {
 	val, ok := g.edges.Get(id.Type(), id.ID())
 	if ok && val != nil {
 		edge := val.(*Edge)
-		fromVal, ok := g.edgesFrom.Get(edge.From.Type(), edge.From.ID())
-		if ok && fromVal != nil {
-			edges := fromVal.(edgeMap)
-			edges.DelEdge(id)
-			g.edgesFrom.Set(edge.From.Type(), edge.From.ID(), edges)
-		}
-		toVal, ok := g.edgesTo.Get(edge.To.Type(), edge.To.ID())
-		if ok && toVal != nil {
-			edges := toVal.(edgeMap)
-			edges.DelEdge(id)
-			g.edgesTo.Set(edge.To.Type(), edge.To.ID(), edges)
-		}
+		g.recordEdge(edgeDeleted, edge)
+		g.unindexEdge(g.edgesFrom, edge.From, id)
+		g.unindexEdge(g.edgesTo, edge.To, id)
 	}
 	g.edges.Delete(id.Type(), id.ID())
+	g.ordering.remove(id)
+	if ok {
+		g.counts.edge(id.Type(), -1)
+		g.changes.delEdge(id)
+	}
+	g.log(walEntry{Op: walDelEdge, Node: Node{TYPE_KEY: id.Type(), ID_KEY: id.ID()}})
+	g.count(MetricEdgesDeleted, id.Type())
 }
 
 func (g *Graph) EdgesFrom(edgeType Type, id TypedID, fn func(e *Edge) bool) {
 	val, ok := g.edgesFrom.Get(id.Type(), id.ID())
 	if ok {
-		if edges, ok := val.(edgeMap); ok {
-			edges.RangeType(edgeType, func(e *Edge) bool {
-				return fn(e)
-			})
+		if edges, ok := val.(*edgeMap); ok && edges != nil {
+			g.rangeEdgeMap(edges, edgeType, fn)
 		}
 	}
 }
@@ -219,35 +449,159 @@ func (g *Graph) EdgesFrom(edgeType Type, id TypedID, fn func(e *Edge) bool) {
 func (g *Graph) EdgesTo(edgeType Type, id TypedID, fn func(e *Edge) bool) {
 	val, ok := g.edgesTo.Get(id.Type(), id.ID())
 	if ok {
-		if edges, ok := val.(edgeMap); ok {
-			edges.RangeType(edgeType, func(e *Edge) bool {
-				return fn(e)
-			})
+		if edges, ok := val.(*edgeMap); ok && edges != nil {
+			g.rangeEdgeMap(edges, edgeType, fn)
 		}
 	}
 }
 
+// DFS visits the nodes reachable from start over outgoing edges depth first in pre-order: every node is passed to fn,
+// along with its depth(the start node is depth 0), before any of the nodes reached through it. Each node is visited
+// once, so cycles are safe, and neighbors are visited in sorted type.id order so the visit order is deterministic.
+// The traversal stops when fn returns false or the options' budget is exhausted. No lock is held while fn runs, so fn
+// may mutate the graph.
+func (g *Graph) DFS(start TypedID, opts TraversalOptions, fn func(n Node, depth int) bool) TraversalResult {
+	return g.dfs(start, opts, fn, nil)
+}
+
+// DFSPostOrder is like DFS, but every node is passed to fn after all of the nodes reached through it
+func (g *Graph) DFSPostOrder(start TypedID, opts TraversalOptions, fn func(n Node, depth int) bool) TraversalResult {
+	return g.dfs(start, opts, nil, fn)
+}
+
+func (g *Graph) dfs(start TypedID, opts TraversalOptions, pre, post func(n Node, depth int) bool) TraversalResult {
+	b := NewTraversalBudget(opts)
+	n, ok := g.GetNode(start)
+	if !ok {
+		return b.Result()
+	}
+	visited := map[string]bool{}
+	var walk func(n Node, depth int) bool
+	walk = func(n Node, depth int) bool {
+		if !b.Visit() {
+			return false
+		}
+		visited[pathOf(n)] = true
+		if pre != nil && !pre(n, depth) {
+			return false
+		}
+		var neighbors []Node
+		g.EdgesFrom(anyType{}, n, func(e *Edge) bool {
+			if !visited[pathOf(e.To)] {
+				if to, ok := g.GetNode(e.To); ok {
+					neighbors = append(neighbors, to)
+				}
+			}
+			return true
+		})
+		sort.Slice(neighbors, func(i, j int) bool {
+			return pathOf(neighbors[i]) < pathOf(neighbors[j])
+		})
+		if len(neighbors) > 0 && !b.Expands(depth) {
+			// there is more of the graph beyond the max depth
+			b.Truncate()
+			neighbors = nil
+		}
+		for _, to := range neighbors {
+			if visited[pathOf(to)] {
+				continue
+			}
+			if !walk(to, depth+1) {
+				return false
+			}
+		}
+		if post != nil && !post(n, depth) {
+			return false
+		}
+		return true
+	}
+	walk(n, 0)
+	return b.Result()
+}
+
+// Export returns a point-in-time copy of the graph's nodes & edges. The export is internally consistent: edges whose
+// endpoints do not exist are left out. Writers are only blocked while references to the graph's attribute maps are
+// copied(see snapshot); the attribute maps are deep copied after the lock is released, so the export may be modified
+// without affecting the graph.
 func (g *Graph) Export() *Export {
+	nodes, edges := g.snapshot()
 	exp := &Export{}
-	g.RangeNodes(func(n Node) bool {
+	copies := make(map[string]Node, len(nodes))
+	for _, n := range nodes {
+		n = n.Copy()
+		copies[pathOf(n)] = n
 		exp.Nodes = append(exp.Nodes, n)
+	}
+	for _, e := range edges {
+		exp.Edges = append(exp.Edges, &Edge{
+			Node: e.Node.Copy(),
+			From: copies[pathOf(e.From)],
+			To:   copies[pathOf(e.To)],
+		})
+	}
+	return exp
+}
+
+// snapshot returns shallow copies of the graph's nodes & edges, leaving out edges whose endpoints do not exist. It holds
+// the read lock only while the top level of each attribute map is copied. Mutations replace nested attribute values
+// rather than modifying them in place(see PatchNodePath), so the copies do not change once the lock is released.
+func (g *Graph) snapshot() ([]Node, []*Edge) {
+	defer g.rlock()()
+	return g.copyGraph()
+}
+
+// copyGraph returns shallow copies of the graph's nodes & edges like snapshot. The caller must hold the read lock.
+func (g *Graph) copyGraph() ([]Node, []*Edge) {
+	var nodes []Node
+	g.RangeNodes(func(n Node) bool {
+		nodes = append(nodes, shallowCopy(n))
 		return true
 	})
+	var edges []*Edge
 	g.RangeEdges(func(e *Edge) bool {
-		exp.Edges = append(exp.Edges, e)
+		if !g.HasNode(e.From) || !g.HasNode(e.To) {
+			return true
+		}
+		edges = append(edges, &Edge{
+			Node: shallowCopy(e.Node),
+			From: Node{TYPE_KEY: e.From.Type(), ID_KEY: e.From.ID()},
+			To:   Node{TYPE_KEY: e.To.Type(), ID_KEY: e.To.ID()},
+		})
 		return true
 	})
-	return exp
+	return nodes, edges
 }
 
+// shallowCopy copies the top level of the attribute map
+func shallowCopy(n Node) Node {
+	copied := make(Node, len(n))
+	for k, v := range n {
+		copied[k] = v
+	}
+	return copied
+}
+
+// Import adds the export's nodes, then its edges, returning the first error. Nodes & edges that violate a registered
+// schema or edge constraint are rejected before anything is added; an edge to a node that does not exist stops the
+// import after the nodes & the edges before it are added.
 func (g *Graph) Import(exp *Export) error {
+	defer g.lock()()
+	if g.ReadOnly() {
+		return ErrReadOnly
+	}
+	if err := g.validateAll(exp.Nodes, exp.Edges); err != nil {
+		return err
+	}
+	// copy the export so later changes to it never leak into the graph
 	for _, n := range exp.Nodes {
-		g.AddNode(n)
+		g.addNode(n.Copy())
 	}
 	for _, e := range exp.Edges {
-		g.AddEdge(e)
+		if err := g.addEdge(g.resolveEdge(e)); err != nil {
+			return err
+		}
 	}
-	return nil
+	return g.walErr()
 }
 
 func (g *Graph) Close() {
@@ -255,4 +609,10 @@ func (g *Graph) Close() {
 	g.edgesTo.Close()
 	g.edgesFrom.Close()
 	g.edges.Close()
+	if g.storage != nil {
+		g.storage.Close()
+	}
+	if g.wal != nil {
+		g.wal.Close()
+	}
 }
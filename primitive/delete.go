@@ -0,0 +1,114 @@
+package primitive
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrRestricted is returned when deleting a node that has edges whose type has the Restrict delete policy
+var ErrRestricted = errors.New("dagger: node has restricted edges")
+
+// DeletePolicy determines what DelNode does with the edges of a type that are connected to the deleted node
+type DeletePolicy int
+
+const (
+	// Detach removes the edges, leaving the other endpoints in place. It is the default policy.
+	Detach DeletePolicy = iota
+	// Cascade removes the edges & also deletes the other endpoints, applying their delete policies in turn
+	Cascade
+	// Restrict refuses to delete the node while the edges exist, returning ErrRestricted
+	Restrict
+)
+
+// String returns the name of the policy
+func (p DeletePolicy) String() string {
+	switch p {
+	case Cascade:
+		return "cascade"
+	case Restrict:
+		return "restrict"
+	}
+	return "detach"
+}
+
+// deletePolicies holds the delete policies by edge type
+type deletePolicies struct {
+	mu       sync.RWMutex
+	policies map[string]DeletePolicy
+}
+
+// SetDeletePolicy sets the delete policy of edges of the given type(and its subtypes), replacing any policy previously
+// set for it
+func (g *Graph) SetDeletePolicy(edgeType string, policy DeletePolicy) {
+	g.deletePolicies.mu.Lock()
+	defer g.deletePolicies.mu.Unlock()
+	if g.deletePolicies.policies == nil {
+		g.deletePolicies.policies = map[string]DeletePolicy{}
+	}
+	g.deletePolicies.policies[edgeType] = policy
+}
+
+// DeletePolicyOf returns the delete policy of edges of the given type: the policy set for the type itself, or else the
+// policy set for the first(in sorted order) of its declared parent types, or else Detach
+func (g *Graph) DeletePolicyOf(edgeType string) DeletePolicy {
+	g.deletePolicies.mu.RLock()
+	defer g.deletePolicies.mu.RUnlock()
+	if policy, ok := g.deletePolicies.policies[edgeType]; ok {
+		return policy
+	}
+	var parents []string
+	for typ := range g.deletePolicies.policies {
+		if g.edgeTypes.isA(edgeType, typ) {
+			parents = append(parents, typ)
+		}
+	}
+	if len(parents) == 0 {
+		return Detach
+	}
+	sort.Strings(parents)
+	return g.deletePolicies.policies[parents[0]]
+}
+
+// cascade returns the node followed by every node its deletion cascades to, or ErrRestricted if any of them has an edge
+// with the Restrict policy
+func (g *Graph) cascade(id TypedID) ([]Node, error) {
+	n, ok := g.GetNode(id)
+	if !ok {
+		return nil, nil
+	}
+	deleted := []Node{n}
+	seen := map[string]bool{pathOf(n): true}
+	var err error
+	for i := 0; i < len(deleted) && err == nil; i++ {
+		current := deleted[i]
+		visit := func(e *Edge, other Node) bool {
+			switch g.DeletePolicyOf(e.Type()) {
+			case Restrict:
+				err = fmt.Errorf("%w: %s.%s is connected by %s.%s", ErrRestricted, current.Type(), current.ID(), e.Type(), e.ID())
+				return false
+			case Cascade:
+				if !seen[pathOf(other)] {
+					if o, ok := g.GetNode(other); ok {
+						seen[pathOf(o)] = true
+						deleted = append(deleted, o)
+					}
+				}
+			}
+			return true
+		}
+		g.EdgesFrom(anyType{}, current, func(e *Edge) bool {
+			return visit(e, e.To)
+		})
+		if err == nil {
+			g.EdgesTo(anyType{}, current, func(e *Edge) bool {
+				return visit(e, e.From)
+			})
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return deleted, nil
+}
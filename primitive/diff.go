@@ -0,0 +1,107 @@
+package primitive
+
+import "sort"
+
+// GraphDiff is the set of changes that turn one export into another. Modified elements hold their new attributes.
+type GraphDiff struct {
+	AddedNodes    []Node  `json:"added_nodes"`
+	RemovedNodes  []Node  `json:"removed_nodes"`
+	ModifiedNodes []Node  `json:"modified_nodes"`
+	AddedEdges    []*Edge `json:"added_edges"`
+	RemovedEdges  []*Edge `json:"removed_edges"`
+	ModifiedEdges []*Edge `json:"modified_edges"`
+}
+
+// Empty returns true if the diff has no changes
+func (d *GraphDiff) Empty() bool {
+	return len(d.AddedNodes)+len(d.RemovedNodes)+len(d.ModifiedNodes)+len(d.AddedEdges)+len(d.RemovedEdges)+len(d.ModifiedEdges) == 0
+}
+
+// Diff returns the changes that turn export a into export b. Nodes & edges are matched by type & id, and each list in
+// the diff is sorted by type.id.
+func Diff(a, b *Export) *GraphDiff {
+	d := &GraphDiff{}
+	before := map[string]Node{}
+	for _, n := range a.Nodes {
+		before[pathOf(n)] = n
+	}
+	after := map[string]bool{}
+	for _, n := range b.Nodes {
+		after[pathOf(n)] = true
+		old, ok := before[pathOf(n)]
+		if !ok {
+			d.AddedNodes = append(d.AddedNodes, n)
+		} else if !attributesEqual(old, n) {
+			d.ModifiedNodes = append(d.ModifiedNodes, n)
+		}
+	}
+	for _, n := range a.Nodes {
+		if !after[pathOf(n)] {
+			d.RemovedNodes = append(d.RemovedNodes, n)
+		}
+	}
+	beforeEdges := map[string]*Edge{}
+	for _, e := range a.Edges {
+		beforeEdges[pathOf(e)] = e
+	}
+	afterEdges := map[string]bool{}
+	for _, e := range b.Edges {
+		afterEdges[pathOf(e)] = true
+		old, ok := beforeEdges[pathOf(e)]
+		if !ok {
+			d.AddedEdges = append(d.AddedEdges, e)
+		} else if !attributesEqual(old.Node, e.Node) || pathOf(old.From) != pathOf(e.From) || pathOf(old.To) != pathOf(e.To) {
+			d.ModifiedEdges = append(d.ModifiedEdges, e)
+		}
+	}
+	for _, e := range a.Edges {
+		if !afterEdges[pathOf(e)] {
+			d.RemovedEdges = append(d.RemovedEdges, e)
+		}
+	}
+	for _, nodes := range [][]Node{d.AddedNodes, d.RemovedNodes, d.ModifiedNodes} {
+		sort.Slice(nodes, func(i, j int) bool {
+			return pathOf(nodes[i]) < pathOf(nodes[j])
+		})
+	}
+	for _, edges := range [][]*Edge{d.AddedEdges, d.RemovedEdges, d.ModifiedEdges} {
+		sort.Slice(edges, func(i, j int) bool {
+			return pathOf(edges[i]) < pathOf(edges[j])
+		})
+	}
+	return d
+}
+
+// ApplyDiff atomically applies the changes in the diff to the graph: removed elements are deleted, added elements are
+// created and modified elements have their attributes(and, for edges, endpoints) replaced. Elements the graph already
+// agrees with are left untouched, so applying a diff twice is safe.
+func (g *Graph) ApplyDiff(d *GraphDiff) error {
+	defer g.lock()()
+	if g.ReadOnly() {
+		return ErrReadOnly
+	}
+	nodes := append(append([]Node{}, d.AddedNodes...), d.ModifiedNodes...)
+	if err := g.validateAll(nodes, append(append([]*Edge{}, d.AddedEdges...), d.ModifiedEdges...)); err != nil {
+		return err
+	}
+	for _, e := range d.RemovedEdges {
+		g.delEdge(e)
+	}
+	for _, n := range d.RemovedNodes {
+		if g.HasNode(n) {
+			g.delNode(n)
+		}
+	}
+	for _, n := range append(append([]Node{}, d.AddedNodes...), d.ModifiedNodes...) {
+		g.addNode(n.Copy())
+	}
+	for _, e := range append(append([]*Edge{}, d.AddedEdges...), d.ModifiedEdges...) {
+		if g.HasEdge(e) {
+			g.delEdge(e)
+		}
+		if err := g.addEdge(g.resolveEdge(e)); err != nil {
+			return err
+		}
+	}
+	return g.walErr()
+}
@@ -0,0 +1,114 @@
+package primitive
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// dirtyTracker records which node attributes changed since they were last cleared
+type dirtyTracker struct {
+	mu     sync.Mutex
+	fields map[string]map[string]bool
+	ids    map[string]Node
+}
+
+func newDirtyTracker() *dirtyTracker {
+	return &dirtyTracker{
+		fields: map[string]map[string]bool{},
+		ids:    map[string]Node{},
+	}
+}
+
+// MarkDirty records the node's fields as changed. Call it after mutating a node's attributes in place.
+func (g *Graph) MarkDirty(id TypedID, fields ...string) {
+	if len(fields) == 0 {
+		return
+	}
+	d := g.dirty
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	path := pathOf(id)
+	if d.fields[path] == nil {
+		d.fields[path] = map[string]bool{}
+		d.ids[path] = Node{TYPE_KEY: id.Type(), ID_KEY: id.ID()}
+	}
+	for _, f := range fields {
+		d.fields[path][f] = true
+	}
+}
+
+// DirtyFields returns the sorted attributes of the node that changed(were set or deleted) since the node was last cleared
+func (g *Graph) DirtyFields(id TypedID) []string {
+	d := g.dirty
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var fields []string
+	for f := range d.fields[pathOf(id)] {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// RangeDirty iterates over the nodes with dirty fields until fn returns false, so a persistent backend can write only
+// the changed fields. Nodes are passed as they currently exist in the graph; fields that are missing from the node were deleted.
+func (g *Graph) RangeDirty(fn func(n Node, fields []string) bool) {
+	d := g.dirty
+	d.mu.Lock()
+	ids := make([]Node, 0, len(d.ids))
+	for _, id := range d.ids {
+		ids = append(ids, id)
+	}
+	d.mu.Unlock()
+	for _, id := range ids {
+		n, ok := g.GetNode(id)
+		if !ok {
+			continue
+		}
+		fields := g.DirtyFields(id)
+		if len(fields) == 0 {
+			continue
+		}
+		if !fn(n, fields) {
+			return
+		}
+	}
+}
+
+// ClearDirty marks the given nodes clean, or every node if none are given. Call it once changes have been flushed.
+func (g *Graph) ClearDirty(ids ...TypedID) {
+	d := g.dirty
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(ids) == 0 {
+		d.fields = map[string]map[string]bool{}
+		d.ids = map[string]Node{}
+		return
+	}
+	for _, id := range ids {
+		delete(d.fields, pathOf(id))
+		delete(d.ids, pathOf(id))
+	}
+}
+
+// changedFields returns the attributes that differ between two versions of a node
+func changedFields(before, after Node) []string {
+	var fields []string
+	for k, v := range after {
+		if old, ok := before[k]; !ok || !reflect.DeepEqual(old, v) {
+			fields = append(fields, k)
+		}
+	}
+	for k := range before {
+		if _, ok := after[k]; !ok {
+			fields = append(fields, k)
+		}
+	}
+	return fields
+}
+
+// sameNode returns true if both nodes are the same underlying map
+func sameNode(a, b Node) bool {
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
@@ -0,0 +1,338 @@
+package primitive
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+const (
+	diskNode  = "node"
+	diskEdge  = "edge"
+	diskEdges = "edges"
+)
+
+// diskRecord is a single line of a DiskStorage file. A record without a kind deletes its key.
+type diskRecord struct {
+	Namespace string          `json:"ns"`
+	Key       string          `json:"key"`
+	Kind      string          `json:"kind,omitempty"`
+	Value     json.RawMessage `json:"value,omitempty"`
+}
+
+type diskEntry struct {
+	offset int64
+	size   int
+}
+
+// DiskStorage is a durable Storage backed by an append-only file of json records. Only the location of each value
+// is kept in memory: values are read from disk on access, so graphs larger than memory can be held. Wrap it in a
+// CachedStorage to keep the values in use in memory.
+// Writes reach the operating system before they return, so they survive a process crash; call Sync to flush them to
+// the disk itself. Call Compact to reclaim the space taken by overwritten & deleted values.
+type DiskStorage struct {
+	mu        sync.RWMutex
+	path      string
+	file      *os.File
+	size      int64
+	index     map[string]map[string]diskEntry
+	err       error
+	closeOnce sync.Once
+}
+
+// OpenDiskStorage opens(or creates) the storage file at the path. A partially written record at the end of the file
+// (ex: from a crash mid-write) is discarded.
+func OpenDiskStorage(path string) (*DiskStorage, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	d := &DiskStorage{
+		path:  path,
+		file:  file,
+		index: map[string]map[string]diskEntry{},
+	}
+	if err := d.load(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+// load rebuilds the index from the file
+func (d *DiskStorage) load() error {
+	r := bufio.NewReader(d.file)
+	var offset int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			// an unterminated line is a torn write
+			break
+		}
+		if err != nil {
+			return err
+		}
+		var rec diskRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return fmt.Errorf("corrupt storage record at offset %v: %w", offset, err)
+		}
+		if rec.Kind == "" {
+			delete(d.index[rec.Namespace], rec.Key)
+		} else {
+			if d.index[rec.Namespace] == nil {
+				d.index[rec.Namespace] = map[string]diskEntry{}
+			}
+			d.index[rec.Namespace][rec.Key] = diskEntry{offset: offset, size: len(line)}
+		}
+		offset += int64(len(line))
+	}
+	if err := d.file.Truncate(offset); err != nil {
+		return err
+	}
+	d.size = offset
+	_, err := d.file.Seek(offset, io.SeekStart)
+	return err
+}
+
+// Err returns the first error encountered writing to the file. Storage writes cannot return errors, so check Err
+// (or the error returned by Close) to confirm that writes were persisted.
+func (d *DiskStorage) Err() error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.err
+}
+
+func (d *DiskStorage) Get(namespace string, key string) (interface{}, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	entry, ok := d.index[namespace][key]
+	if !ok {
+		return nil, false
+	}
+	value, err := d.read(entry)
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// read decodes the entry's value. The caller must hold the lock.
+func (d *DiskStorage) read(entry diskEntry) (interface{}, error) {
+	buf := make([]byte, entry.size)
+	if _, err := d.file.ReadAt(buf, entry.offset); err != nil {
+		return nil, err
+	}
+	var rec diskRecord
+	if err := json.Unmarshal(buf, &rec); err != nil {
+		return nil, err
+	}
+	return decodeValue(rec.Kind, rec.Value)
+}
+
+func (d *DiskStorage) Set(namespace string, key string, value interface{}) {
+	kind, bits, err := encodeValue(value)
+	if err != nil {
+		d.fail(err)
+		return
+	}
+	d.append(diskRecord{Namespace: namespace, Key: key, Kind: kind, Value: bits})
+}
+
+func (d *DiskStorage) Delete(namespace string, key string) {
+	d.mu.RLock()
+	_, ok := d.index[namespace][key]
+	d.mu.RUnlock()
+	if ok {
+		d.append(diskRecord{Namespace: namespace, Key: key})
+	}
+}
+
+func (d *DiskStorage) append(rec diskRecord) {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		d.fail(err)
+		return
+	}
+	line = append(line, '\n')
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, err := d.file.Write(line); err != nil {
+		if d.err == nil {
+			d.err = err
+		}
+		return
+	}
+	if rec.Kind == "" {
+		delete(d.index[rec.Namespace], rec.Key)
+	} else {
+		if d.index[rec.Namespace] == nil {
+			d.index[rec.Namespace] = map[string]diskEntry{}
+		}
+		d.index[rec.Namespace][rec.Key] = diskEntry{offset: d.size, size: len(line)}
+	}
+	d.size += int64(len(line))
+}
+
+func (d *DiskStorage) fail(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+// Range iterates over the keys & values in the namespace(or every namespace if it is AnyType) until fn returns false.
+// fn may modify the storage.
+func (d *DiskStorage) Range(namespace string, fn func(key string, value interface{}) bool) {
+	type path struct {
+		namespace, key string
+	}
+	var paths []path
+	d.mu.RLock()
+	for ns, keys := range d.index {
+		if namespace != AnyType && ns != namespace {
+			continue
+		}
+		for k := range keys {
+			paths = append(paths, path{ns, k})
+		}
+	}
+	d.mu.RUnlock()
+	for _, p := range paths {
+		value, ok := d.Get(p.namespace, p.key)
+		if !ok {
+			continue
+		}
+		if !fn(p.key, value) {
+			return
+		}
+	}
+}
+
+func (d *DiskStorage) Namespaces() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	var namespaces []string
+	for ns, keys := range d.index {
+		if len(keys) > 0 {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	return namespaces
+}
+
+// Sync flushes written records to the disk
+func (d *DiskStorage) Sync() error {
+	return d.file.Sync()
+}
+
+// Compact rewrites the file with only the current value of each key
+func (d *DiskStorage) Compact() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	tmp, err := os.Create(d.path + ".compact")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	index := map[string]map[string]diskEntry{}
+	var size int64
+	for ns, keys := range d.index {
+		for k, e := range keys {
+			buf := make([]byte, e.size)
+			if _, err := d.file.ReadAt(buf, e.offset); err != nil {
+				tmp.Close()
+				return err
+			}
+			if _, err := w.Write(buf); err != nil {
+				tmp.Close()
+				return err
+			}
+			if index[ns] == nil {
+				index[ns] = map[string]diskEntry{}
+			}
+			index[ns][k] = diskEntry{offset: size, size: e.size}
+			size += int64(e.size)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), d.path); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(d.path, os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(size, io.SeekStart); err != nil {
+		file.Close()
+		return err
+	}
+	d.file.Close()
+	d.file = file
+	d.index = index
+	d.size = size
+	return nil
+}
+
+// Close syncs & closes the file, returning the first error encountered writing to it
+func (d *DiskStorage) Close() error {
+	d.closeOnce.Do(func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if err := d.file.Sync(); err != nil && d.err == nil {
+			d.err = err
+		}
+		if err := d.file.Close(); err != nil && d.err == nil {
+			d.err = err
+		}
+	})
+	return d.Err()
+}
+
+func encodeValue(value interface{}) (string, json.RawMessage, error) {
+	var kind string
+	switch value.(type) {
+	case Node:
+		kind = diskNode
+	case *Edge:
+		kind = diskEdge
+	case *edgeMap:
+		kind = diskEdges
+	default:
+		return "", nil, fmt.Errorf("unsupported storage value: %T", value)
+	}
+	bits, err := json.Marshal(value)
+	return kind, bits, err
+}
+
+func decodeValue(kind string, bits json.RawMessage) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(bits))
+	switch kind {
+	case diskNode:
+		n := Node{}
+		return n, dec.Decode(&n)
+	case diskEdge:
+		e := &Edge{}
+		return e, dec.Decode(e)
+	case diskEdges:
+		edges := newEdgeMap()
+		return edges, dec.Decode(edges)
+	default:
+		return nil, fmt.Errorf("unsupported storage record kind: %q", kind)
+	}
+}
@@ -12,6 +12,29 @@ type Edge struct {
 	To Node `json:"to"`
 }
 
+// Weight returns the edge's WEIGHT_KEY attribute, or 1 if the edge has no weight so unweighted edges count as a hop
+func (e *Edge) Weight() float64 {
+	if !e.Exists(WEIGHT_KEY) {
+		return 1
+	}
+	return e.GetFloat(WEIGHT_KEY)
+}
+
+// Reverse returns the id of the edge's mutual counterpart: the edge of the same type that connects the same nodes in
+// the opposite direction. Only edges created as mutual pairs have one.
+func (e *Edge) Reverse() (TypedID, bool) {
+	id := e.GetString(REVERSE_KEY)
+	if id == "" {
+		return nil, false
+	}
+	return Node{TYPE_KEY: e.Type(), ID_KEY: id}, true
+}
+
+// SetWeight sets the edge's WEIGHT_KEY attribute
+func (e *Edge) SetWeight(weight float64) {
+	e.Set(WEIGHT_KEY, weight)
+}
+
 func (e *Edge) JSON() ([]byte, error) {
 	return json.Marshal(e)
 }
@@ -1,64 +1,80 @@
 package primitive
 
-// edgeMap is a map of edges. edgeMap are not concurrency safe.
-type edgeMap map[string]map[string]*Edge
+import (
+	"encoding/json"
+	"sync"
+)
 
-func (e edgeMap) Types() []string {
+// edgeMap is a concurrency safe map of edges by type & id. Storage holds a *edgeMap per node, so edges are added to &
+// removed from it in place.
+type edgeMap struct {
+	mu    sync.RWMutex
+	edges map[string]map[string]*Edge
+}
+
+func newEdgeMap() *edgeMap {
+	return &edgeMap{edges: map[string]map[string]*Edge{}}
+}
+
+// edgeBuffers recycles the slices edges are copied into while they are iterated, so iteration does not allocate
+var edgeBuffers = sync.Pool{
+	New: func() interface{} {
+		return new([]*Edge)
+	},
+}
+
+func (e *edgeMap) Types() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
 	var typs []string
-	for t, _ := range e {
+	for t := range e.edges {
 		typs = append(typs, t)
 	}
 	return typs
 }
 
 // RangeType executes the function over a list of edges with the given type. If the function returns false, the iteration stops.
-func (e edgeMap) RangeType(typ Type, fn func(e *Edge) bool) {
+// The edges are copied before the function is called, so it may modify the map.
+func (e *edgeMap) RangeType(typ Type, fn func(e *Edge) bool) {
+	buf := edgeBuffers.Get().(*[]*Edge)
+	edges := (*buf)[:0]
+	e.mu.RLock()
 	if typ.Type() == AnyType {
-		for _, edges := range e {
-			for _, edge := range edges {
-				if !fn(edge) {
-					break
-				}
+		for _, m := range e.edges {
+			for _, edge := range m {
+				edges = append(edges, edge)
 			}
 		}
 	} else {
-		if e[typ.Type()] == nil {
-			return
+		for _, edge := range e.edges[typ.Type()] {
+			edges = append(edges, edge)
 		}
-		for _, e := range e[typ.Type()] {
-			if !fn(e) {
-				break
-			}
+	}
+	e.mu.RUnlock()
+	for _, edge := range edges {
+		if !fn(edge) {
+			break
 		}
 	}
+	for i := range edges {
+		edges[i] = nil
+	}
+	*buf = edges[:0]
+	edgeBuffers.Put(buf)
 }
 
 // Range executes the function over every edge. If the function returns false, the iteration stops.
-func (e edgeMap) Range(fn func(e *Edge) bool) {
-	for _, m := range e {
-		for _, e := range m {
-			if !fn(e) {
-				break
-			}
-		}
-	}
+func (e *edgeMap) Range(fn func(e *Edge) bool) {
+	e.RangeType(typeName(AnyType), fn)
 }
 
 // Filter executes the function over every edge. If the function returns true, the edges will be added to the returned array of edges.
-func (e edgeMap) Filter(fn func(e *Edge) bool) []*Edge {
-	var edges []*Edge
-	for _, m := range e {
-		for _, e := range m {
-			if fn(e) {
-				edges = append(edges, e)
-			}
-		}
-	}
-	return edges
+func (e *edgeMap) Filter(fn func(e *Edge) bool) []*Edge {
+	return e.FilterType(typeName(AnyType), fn)
 }
 
 // FilterType executes the function over every edge of the given type. If the function returns true, the edges will be added to the returned array of edges.
-func (e edgeMap) FilterType(typ Type, fn func(e *Edge) bool) []*Edge {
+func (e *edgeMap) FilterType(typ Type, fn func(e *Edge) bool) []*Edge {
 	var edges []*Edge
 	e.RangeType(typ, func(e *Edge) bool {
 		if fn(e) {
@@ -70,45 +86,93 @@ func (e edgeMap) FilterType(typ Type, fn func(e *Edge) bool) []*Edge {
 }
 
 // DelEdge deletes the edge
-func (e edgeMap) DelEdge(id TypedID) {
-	if _, ok := e[id.Type()]; !ok {
-		return
-	}
-	delete(e[id.Type()], id.ID())
+func (e *edgeMap) DelEdge(id TypedID) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.edges[id.Type()], id.ID())
 }
 
 // AddEdge adds the edge to the map
-func (e edgeMap) AddEdge(edge *Edge) {
-	if _, ok := e[edge.Type()]; !ok {
-		e[edge.Type()] = map[string]*Edge{
+func (e *edgeMap) AddEdge(edge *Edge) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.edges[edge.Type()]; !ok {
+		e.edges[edge.Type()] = map[string]*Edge{
 			edge.ID(): edge,
 		}
 	} else {
-		e[edge.Type()][edge.ID()] = edge
+		e.edges[edge.Type()][edge.ID()] = edge
 	}
 }
 
 // HasEdge returns true if the edge exists
-func (e edgeMap) HasEdge(id TypedID) bool {
+func (e *edgeMap) HasEdge(id TypedID) bool {
 	_, ok := e.GetEdge(id)
 	return ok
 }
 
 // GetEdge gets an edge by id
-func (e edgeMap) GetEdge(id TypedID) (*Edge, bool) {
-	if _, ok := e[id.Type()]; !ok {
-		return nil, false
+func (e *edgeMap) GetEdge(id TypedID) (*Edge, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	edge, ok := e.edges[id.Type()][id.ID()]
+	return edge, ok
+}
+
+// Len returns the number of edges of the given type
+func (e *edgeMap) Len(typ Type) int {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return len(e.edges[typ.Type()])
+}
+
+func (e *edgeMap) MarshalJSON() ([]byte, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return json.Marshal(e.edges)
+}
+
+func (e *edgeMap) UnmarshalJSON(bits []byte) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.edges = map[string]map[string]*Edge{}
+	return json.Unmarshal(bits, &e.edges)
+}
+
+// keepsValues returns true if the graph's storage holds the values it is given, rather than copies of them(ex:
+// DiskStorage), so a *edgeMap that is modified in place does not need to be stored again
+func (g *Graph) keepsValues() bool {
+	if g.storage == nil {
+		return true
+	}
+	_, ok := g.storage.(*Cache[interface{}])
+	return ok
+}
+
+// indexEdge adds the edge to the index entry of the node
+func (g *Graph) indexEdge(index Storage, id TypedID, e *Edge) {
+	val, ok := index.Get(id.Type(), id.ID())
+	edges, _ := val.(*edgeMap)
+	if !ok || edges == nil {
+		edges = newEdgeMap()
+		ok = false
 	}
-	if e, ok := e[id.Type()][id.ID()]; ok {
-		return e, true
+	edges.AddEdge(e)
+	if !ok || !g.keepsValues() {
+		index.Set(id.Type(), id.ID(), edges)
 	}
-	return nil, false
 }
 
-// Len returns the number of edges of the given type
-func (e edgeMap) Len(typ Type) int {
-	if rels, ok := e[typ.Type()]; ok {
-		return len(rels)
+// unindexEdge removes the edge from the index entry of the node
+func (g *Graph) unindexEdge(index Storage, id TypedID, e TypedID) {
+	val, ok := index.Get(id.Type(), id.ID())
+	if !ok {
+		return
+	}
+	if edges, ok := val.(*edgeMap); ok && edges != nil {
+		edges.DelEdge(e)
+		if !g.keepsValues() {
+			index.Set(id.Type(), id.ID(), edges)
+		}
 	}
-	return 0
 }
@@ -1,6 +1,95 @@
 package primitive
 
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
 type Export struct {
 	Nodes []Node  `json:"nodes"`
 	Edges []*Edge `json:"edges"`
 }
+
+// Transform rewrites the attributes of an exported node. It is handed a copy so it may modify the node freely.
+type Transform func(n Node)
+
+// Transform returns a copy of the export with the transforms applied to every node, edge and edge endpoint.
+// The nodes & edges in the original export are left untouched.
+func (e *Export) Transform(transforms ...Transform) *Export {
+	apply := func(n Node) Node {
+		return transformed(n, transforms)
+	}
+	exp := &Export{}
+	for _, n := range e.Nodes {
+		exp.Nodes = append(exp.Nodes, apply(n))
+	}
+	for _, edge := range e.Edges {
+		exp.Edges = append(exp.Edges, transformedEdge(edge, transforms))
+	}
+	return exp
+}
+
+// transformed returns a copy of the node with the transforms applied
+func transformed(n Node, transforms []Transform) Node {
+	n = n.Copy()
+	for _, t := range transforms {
+		t(n)
+	}
+	return n
+}
+
+// transformedEdge returns a copy of the edge with the transforms applied to it & its endpoints
+func transformedEdge(e *Edge, transforms []Transform) *Edge {
+	return &Edge{
+		Node: transformed(e.Node, transforms),
+		From: transformed(e.From, transforms),
+		To:   transformed(e.To, transforms),
+	}
+}
+
+// HashAttributes replaces the attributes with the hex encoded sha256 hash of the salt and their value. Equal values
+// hash equally, so hashed attributes can still be joined on.
+func HashAttributes(salt string, attrs ...string) Transform {
+	return func(n Node) {
+		for _, attr := range attrs {
+			if n.Exists(attr) {
+				n.Set(attr, fmt.Sprintf("%x", sha256.Sum256([]byte(salt+n.GetString(attr)))))
+			}
+		}
+	}
+}
+
+// MaskAttributes replaces the attributes with a fixed mask
+func MaskAttributes(attrs ...string) Transform {
+	return func(n Node) {
+		for _, attr := range attrs {
+			if n.Exists(attr) {
+				n.Set(attr, "***")
+			}
+		}
+	}
+}
+
+// StripAttributes removes the attributes
+func StripAttributes(attrs ...string) Transform {
+	return func(n Node) {
+		for _, attr := range attrs {
+			n.Del(attr)
+		}
+	}
+}
+
+// Bucketize generalizes a numeric attribute into a range of the given size(ex: an age of 37 becomes "30-39")
+func Bucketize(attr string, size int) Transform {
+	return func(n Node) {
+		if !n.Exists(attr) || size <= 0 {
+			return
+		}
+		value := n.GetInt(attr)
+		low := value - value%size
+		if value < 0 && value%size != 0 {
+			low -= size
+		}
+		n.Set(attr, fmt.Sprintf("%v-%v", low, low+size-1))
+	}
+}
@@ -0,0 +1,94 @@
+package primitive
+
+type gcConfig struct {
+	edgeTypes []string
+	nodeTypes []string
+	dryRun    bool
+}
+
+// GCOption configures GCUnreachable
+type GCOption func(c *gcConfig)
+
+// GCEdgeTypes restricts reachability to edges of the given types(and their declared subtypes)
+func GCEdgeTypes(types ...string) GCOption {
+	return func(c *gcConfig) {
+		c.edgeTypes = append(c.edgeTypes, types...)
+	}
+}
+
+// GCNodeTypes restricts collection to nodes of the given types. Nodes of other types are never removed, but may still
+// connect roots to collectable nodes.
+func GCNodeTypes(types ...string) GCOption {
+	return func(c *gcConfig) {
+		c.nodeTypes = append(c.nodeTypes, types...)
+	}
+}
+
+// GCDryRun reports the unreachable nodes without removing them
+func GCDryRun() GCOption {
+	return func(c *gcConfig) {
+		c.dryRun = true
+	}
+}
+
+// GCUnreachable removes every node that cannot be reached from the roots by following outgoing edges, along with the
+// edges stemming from them, and returns the removed nodes. Removal is atomic with respect to other writers.
+func (g *Graph) GCUnreachable(roots []TypedID, opts ...GCOption) ([]Node, error) {
+	c := &gcConfig{}
+	for _, o := range opts {
+		o(c)
+	}
+	edgeTypes := []Type{anyType{}}
+	if len(c.edgeTypes) > 0 {
+		edgeTypes = nil
+		for _, t := range c.edgeTypes {
+			edgeTypes = append(edgeTypes, typeName(t))
+		}
+	}
+	if c.dryRun {
+		defer g.rlock()()
+	} else {
+		defer g.lock()()
+		if g.ReadOnly() {
+			return nil, ErrReadOnly
+		}
+	}
+	reachable := map[string]bool{}
+	var stack []Node
+	for _, id := range roots {
+		if n, ok := g.GetNode(id); ok && !reachable[pathOf(n)] {
+			reachable[pathOf(n)] = true
+			stack = append(stack, n)
+		}
+	}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, edgeType := range edgeTypes {
+			g.EdgesFrom(edgeType, n, func(e *Edge) bool {
+				if reachable[pathOf(e.To)] {
+					return true
+				}
+				if to, ok := g.GetNode(e.To); ok {
+					reachable[pathOf(to)] = true
+					stack = append(stack, to)
+				}
+				return true
+			})
+		}
+	}
+	var unreachable []Node
+	g.RangeNodes(func(n Node) bool {
+		if !reachable[pathOf(n)] && (len(c.nodeTypes) == 0 || contains(c.nodeTypes, n.Type())) {
+			unreachable = append(unreachable, n)
+		}
+		return true
+	})
+	if c.dryRun {
+		return unreachable, nil
+	}
+	for _, n := range unreachable {
+		g.delNode(n)
+	}
+	return unreachable, g.walErr()
+}
@@ -0,0 +1,69 @@
+package primitive
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+)
+
+// Hash returns a deterministic digest of every node & edge in the graph, including their attributes. Two graphs with
+// the same contents have the same hash regardless of insertion order, so processes can cheaply check whether their
+// graphs diverge before diffing or syncing them.
+func (g *Graph) Hash() string {
+	return g.HashFilter(Filter{})
+}
+
+// HashFilter returns a deterministic digest of the nodes & edges selected by the scope(ex: a single node type)
+func (g *Graph) HashFilter(scope Filter) string {
+	defer g.rlock()()
+	var hashes []string
+	g.RangeNodes(func(n Node) bool {
+		if scope.MatchNode(n) {
+			hashes = append(hashes, "n"+NodeHash(n))
+		}
+		return true
+	})
+	g.RangeEdges(func(e *Edge) bool {
+		if scope.MatchEdge(e) {
+			hashes = append(hashes, "e"+EdgeHash(e))
+		}
+		return true
+	})
+	return combineHashes(hashes)
+}
+
+// NodeHash returns a deterministic digest of the node's attributes
+func NodeHash(n Node) string {
+	return digest(n)
+}
+
+// EdgeHash returns a deterministic digest of the edge's attributes & endpoints
+func EdgeHash(e *Edge) string {
+	return digest(map[string]interface{}{
+		"node": e.Node,
+		"from": pathOf(e.From),
+		"to":   pathOf(e.To),
+	})
+}
+
+// digest hashes the value's json encoding, which sorts map keys & encodes numbers the same regardless of their Go type
+func digest(v interface{}) string {
+	bits, err := json.Marshal(v)
+	if err != nil {
+		// values that cannot be encoded still contribute a stable, if coarse, digest
+		bits = []byte(err.Error())
+	}
+	sum := sha256.Sum256(bits)
+	return hex.EncodeToString(sum[:])
+}
+
+// combineHashes hashes the sorted hashes into a single digest
+func combineHashes(hashes []string) string {
+	sort.Strings(hashes)
+	h := sha256.New()
+	for _, hash := range hashes {
+		h.Write([]byte(hash))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
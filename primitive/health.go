@@ -0,0 +1,228 @@
+package primitive
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// HealthOK indicates the graph passed all health checks
+	HealthOK = "ok"
+	// HealthDegraded indicates the graph is serving but failed one or more integrity checks
+	HealthDegraded = "degraded"
+	// HealthFailing indicates the graph can no longer persist changes: its storage or WAL failed a write
+	HealthFailing = "failing"
+)
+
+// Health is a point-in-time report of the graph's state
+type Health struct {
+	Status        string `json:"status"`
+	Nodes         int    `json:"nodes"`
+	Edges         int    `json:"edges"`
+	DanglingEdges int    `json:"dangling_edges"`
+	// Storage is the kind of storage backing the graph: memory, disk, cached or the storage's go type
+	Storage string `json:"storage"`
+	// StorageError is the first error the storage encountered persisting a write(see DiskStorage.Err)
+	StorageError string `json:"storage_error,omitempty"`
+	// WAL is the state of the graph's write-ahead log, if it has one
+	WAL *WALHealth `json:"wal,omitempty"`
+	// IntegrityErrors is the number of problems the last integrity check(see CheckIntegrity) found
+	IntegrityErrors int `json:"integrity_errors"`
+	// IntegrityCheckedAt is when the integrity of the graph was last checked. It is nil if it never was.
+	IntegrityCheckedAt *time.Time `json:"integrity_checked_at,omitempty"`
+	// LastSnapshot is when the last snapshot of the graph was written(see Snapshotted). It is nil if none was.
+	LastSnapshot *time.Time `json:"last_snapshot,omitempty"`
+	// SnapshotAge is how long ago the last snapshot was written
+	SnapshotAge time.Duration `json:"snapshot_age,omitempty"`
+	CheckedAt   time.Time     `json:"checked_at"`
+}
+
+// WALHealth is the state of a write-ahead log
+type WALHealth struct {
+	// Entries is the number of entries in the log, which recovery has to replay
+	Entries int64 `json:"entries"`
+	// Unsynced is the number of entries written since the log was last flushed to disk. They are lost on power loss.
+	Unsynced int64 `json:"unsynced"`
+	// Error is the error that stopped the log from being appended to
+	Error string `json:"error,omitempty"`
+}
+
+// Healthy returns true if the graph passed all health checks
+func (h *Health) Healthy() bool {
+	return h.Status == HealthOK
+}
+
+// counts tracks the number of nodes & edges as the graph changes so Health & metrics don't have to scan the graph
+type counts struct {
+	nodes int64
+	edges int64
+	// nodeTypes & edgeTypes map each type to a *int64 count of its nodes or edges
+	nodeTypes sync.Map
+	edgeTypes sync.Map
+	// dangling is the number of dangling edges the last scan of the graph found
+	dangling int64
+	// integrityErrors is the number of problems the last scan of the graph found
+	integrityErrors int64
+	// checkedAt & snapshotAt are the unix nanosecond times of the last scan & snapshot, or 0
+	checkedAt  int64
+	snapshotAt int64
+}
+
+// Health reports the node & edge counts the graph keeps up to date as it changes, so it is cheap enough to call from
+// readiness probes. Mutations never leave an edge without its endpoints, so dangling edges can only come from storage
+// changed outside of the graph: they are counted when the graph is opened on storage & by CheckIntegrity, and cleared
+// by Repair. The graph is degraded while the last scan found problems, & failing once its storage or WAL fails a
+// write.
+func (g *Graph) Health() *Health {
+	now := time.Now()
+	h := &Health{
+		Status:          HealthOK,
+		Nodes:           int(atomic.LoadInt64(&g.counts.nodes)),
+		Edges:           int(atomic.LoadInt64(&g.counts.edges)),
+		DanglingEdges:   int(atomic.LoadInt64(&g.counts.dangling)),
+		Storage:         storageKind(g.storage),
+		IntegrityErrors: int(atomic.LoadInt64(&g.counts.integrityErrors)),
+		CheckedAt:       now,
+	}
+	if at := atomic.LoadInt64(&g.counts.checkedAt); at != 0 {
+		checked := time.Unix(0, at)
+		h.IntegrityCheckedAt = &checked
+	}
+	if at := atomic.LoadInt64(&g.counts.snapshotAt); at != 0 {
+		snapshot := time.Unix(0, at)
+		h.LastSnapshot = &snapshot
+		h.SnapshotAge = now.Sub(snapshot)
+	}
+	if h.IntegrityErrors > 0 {
+		h.Status = HealthDegraded
+	}
+	if s, ok := g.storage.(interface{ Err() error }); ok {
+		if err := s.Err(); err != nil {
+			h.StorageError = err.Error()
+			h.Status = HealthFailing
+		}
+	}
+	g.mu.RLock()
+	w := g.wal
+	g.mu.RUnlock()
+	if w != nil {
+		h.WAL = w.health()
+		if h.WAL.Error != "" {
+			h.Status = HealthFailing
+		}
+	}
+	return h
+}
+
+// Snapshotted records that a snapshot of the graph was written at the time, so Health can report its age
+func (g *Graph) Snapshotted(at time.Time) {
+	atomic.StoreInt64(&g.counts.snapshotAt, at.UnixNano())
+}
+
+// node adds delta to the number of nodes of the type
+func (c *counts) node(typ string, delta int64) {
+	atomic.AddInt64(&c.nodes, delta)
+	addTypeCount(&c.nodeTypes, typ, delta)
+}
+
+// edge adds delta to the number of edges of the type
+func (c *counts) edge(typ string, delta int64) {
+	atomic.AddInt64(&c.edges, delta)
+	addTypeCount(&c.edgeTypes, typ, delta)
+}
+
+func addTypeCount(types *sync.Map, typ string, delta int64) {
+	count, ok := types.Load(typ)
+	if !ok {
+		count, _ = types.LoadOrStore(typ, new(int64))
+	}
+	atomic.AddInt64(count.(*int64), delta)
+}
+
+// setTypeCounts replaces the counts by type with the scanned counts
+func setTypeCounts(types *sync.Map, scanned map[string]int64) {
+	types.Range(func(typ, count interface{}) bool {
+		atomic.StoreInt64(count.(*int64), scanned[typ.(string)])
+		return true
+	})
+	for typ, count := range scanned {
+		if _, ok := types.Load(typ); !ok {
+			addTypeCount(types, typ, count)
+		}
+	}
+}
+
+// typeCounts returns the non zero counts by type
+func typeCounts(types *sync.Map) map[string]int {
+	counts := map[string]int{}
+	types.Range(func(typ, count interface{}) bool {
+		if n := atomic.LoadInt64(count.(*int64)); n > 0 {
+			counts[typ.(string)] = int(n)
+		}
+		return true
+	})
+	return counts
+}
+
+// NodeTypeCounts returns the number of nodes of each type without scanning the graph
+func (g *Graph) NodeTypeCounts() map[string]int {
+	return typeCounts(&g.counts.nodeTypes)
+}
+
+// EdgeTypeCounts returns the number of edges of each type without scanning the graph
+func (g *Graph) EdgeTypeCounts() map[string]int {
+	return typeCounts(&g.counts.edgeTypes)
+}
+
+// NodeCount returns the number of nodes in the graph without scanning it
+func (g *Graph) NodeCount() int {
+	return int(atomic.LoadInt64(&g.counts.nodes))
+}
+
+// EdgeCount returns the number of edges in the graph without scanning it
+func (g *Graph) EdgeCount() int {
+	return int(atomic.LoadInt64(&g.counts.edges))
+}
+
+func storageKind(s Storage) string {
+	switch s.(type) {
+	case nil, *Cache[interface{}]:
+		return "memory"
+	case *DiskStorage:
+		return "disk"
+	case *CachedStorage:
+		return "cached"
+	default:
+		return fmt.Sprintf("%T", s)
+	}
+}
+
+// recount scans the graph for its node & edge counts, taking the dangling edge count from the integrity check
+func (g *Graph) recount(check *integrityCheck) {
+	var nodes, edges, dangling int64
+	nodeTypes, edgeTypes := map[string]int64{}, map[string]int64{}
+	g.RangeNodes(func(n Node) bool {
+		nodes++
+		nodeTypes[n.Type()]++
+		return true
+	})
+	g.RangeEdges(func(e *Edge) bool {
+		edges++
+		edgeTypes[e.Type()]++
+		return true
+	})
+	setTypeCounts(&g.counts.nodeTypes, nodeTypes)
+	setTypeCounts(&g.counts.edgeTypes, edgeTypes)
+	for _, err := range check.errs {
+		if err.Kind == IntegrityDanglingEdge {
+			dangling++
+		}
+	}
+	atomic.StoreInt64(&g.counts.nodes, nodes)
+	atomic.StoreInt64(&g.counts.edges, edges)
+	atomic.StoreInt64(&g.counts.dangling, dangling)
+	atomic.StoreInt64(&g.counts.integrityErrors, int64(len(check.errs)))
+	atomic.StoreInt64(&g.counts.checkedAt, time.Now().UnixNano())
+}
@@ -0,0 +1,170 @@
+package primitive
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// hierarchy is a concurrency safe taxonomy of types. A type may have many subtypes & many supertypes, but may not be
+// its own ancestor.
+type hierarchy struct {
+	mu       sync.RWMutex
+	subtypes map[string]map[string]bool
+}
+
+func newHierarchy() *hierarchy {
+	return &hierarchy{subtypes: map[string]map[string]bool{}}
+}
+
+// declare makes each of the subtypes a kind of the parent
+func (h *hierarchy) declare(parent string, subtypes ...string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if parent == "" || parent == AnyType {
+		return fmt.Errorf("invalid parent type: %q", parent)
+	}
+	for _, sub := range subtypes {
+		if sub == "" || sub == AnyType {
+			return fmt.Errorf("invalid subtype: %q", sub)
+		}
+		if sub == parent || h.descends(parent, sub) {
+			return fmt.Errorf("declaring %s a kind of %s would create a cycle", sub, parent)
+		}
+	}
+	if h.subtypes[parent] == nil {
+		h.subtypes[parent] = map[string]bool{}
+	}
+	for _, sub := range subtypes {
+		h.subtypes[parent][sub] = true
+	}
+	return nil
+}
+
+// descends returns true if typ is a direct or indirect subtype of ancestor
+func (h *hierarchy) descends(typ, ancestor string) bool {
+	for sub := range h.subtypes[ancestor] {
+		if sub == typ || h.descends(typ, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// isA returns true if typ is the same type as, or a subtype of, ancestor
+func (h *hierarchy) isA(typ, ancestor string) bool {
+	if typ == ancestor || ancestor == AnyType {
+		return true
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.descends(typ, ancestor)
+}
+
+// hasSubtypes returns true if subtypes of typ have been declared
+func (h *hierarchy) hasSubtypes(typ string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subtypes[typ]) > 0
+}
+
+// expand returns the type followed by all of its direct & indirect subtypes in sorted order
+func (h *hierarchy) expand(typ string) []string {
+	if typ == AnyType {
+		return []string{typ}
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if len(h.subtypes[typ]) == 0 {
+		return []string{typ}
+	}
+	seen := map[string]bool{typ: true}
+	var subtypes []string
+	var walk func(t string)
+	walk = func(t string) {
+		for sub := range h.subtypes[t] {
+			if !seen[sub] {
+				seen[sub] = true
+				subtypes = append(subtypes, sub)
+				walk(sub)
+			}
+		}
+	}
+	walk(typ)
+	sort.Strings(subtypes)
+	return append([]string{typ}, subtypes...)
+}
+
+type typeName string
+
+func (t typeName) Type() string {
+	return string(t)
+}
+
+// DeclareEdgeType declares each of the subtypes a kind of the parent edge type(ex: friend & spouse are kinds of knows),
+// so edge lookups & traversals over the parent type include edges of every subtype. Declarations are transitive
+// and may not form a cycle.
+func (g *Graph) DeclareEdgeType(parent string, subtypes ...string) error {
+	return g.edgeTypes.declare(parent, subtypes...)
+}
+
+// IsEdgeType returns true if the edge type is the same as, or a declared subtype of, the parent edge type
+func (g *Graph) IsEdgeType(typ, parent string) bool {
+	return g.edgeTypes.isA(typ, parent)
+}
+
+// DeclareNodeType declares each of the subtypes a kind of the abstract parent node type(ex: user & employee are kinds
+// of person), so RangeNodeTypes over the parent type includes nodes of every subtype. Declarations are transitive and
+// may not form a cycle.
+func (g *Graph) DeclareNodeType(parent string, subtypes ...string) error {
+	return g.nodeTypes.declare(parent, subtypes...)
+}
+
+// IsNodeType returns true if the node type is the same as, or a declared subtype of, the parent node type.
+// Rules written against the parent type can use it to apply to every subtype.
+func (g *Graph) IsNodeType(typ, parent string) bool {
+	return g.nodeTypes.isA(typ, parent)
+}
+
+// rangeEdgeMap ranges over the edges of the given type & its subtypes until fn returns false
+func (g *Graph) rangeEdgeMap(edges *edgeMap, edgeType Type, fn func(e *Edge) bool) {
+	if g.ordering.order != Unordered {
+		g.rangeOrderedEdges(func(fn func(e *Edge) bool) { g.rangeEdgeTypeMap(edges, edgeType, fn) }, fn)
+		return
+	}
+	g.rangeEdgeTypeMap(edges, edgeType, fn)
+}
+
+func (g *Graph) rangeEdgeTypeMap(edges *edgeMap, edgeType Type, fn func(e *Edge) bool) {
+	if !g.edgeTypes.hasSubtypes(edgeType.Type()) {
+		edges.RangeType(edgeType, fn)
+		return
+	}
+	for _, typ := range g.edgeTypes.expand(edgeType.Type()) {
+		stopped := false
+		edges.RangeType(typeName(typ), func(e *Edge) bool {
+			if !fn(e) {
+				stopped = true
+				return false
+			}
+			return true
+		})
+		if stopped {
+			return
+		}
+	}
+}
+
+// copy returns an independent copy of the hierarchy
+func (h *hierarchy) copy() *hierarchy {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	c := newHierarchy()
+	for parent, subtypes := range h.subtypes {
+		c.subtypes[parent] = map[string]bool{}
+		for sub := range subtypes {
+			c.subtypes[parent][sub] = true
+		}
+	}
+	return c
+}
@@ -0,0 +1,85 @@
+package primitive
+
+import (
+	"sync"
+	"time"
+)
+
+// Revision is a past state of a node
+type Revision struct {
+	// Revision is the node's revision number, starting at 1 when it is created
+	Revision int `json:"revision"`
+	// Time is when the revision was written
+	Time time.Time `json:"time"`
+	// Node is a copy of the node's attributes as of the revision
+	Node Node `json:"node"`
+}
+
+// history retains the most recent revisions of each node
+type history struct {
+	mu sync.RWMutex
+	// keep is the number of revisions retained per node - history is disabled if it is 0
+	keep      int
+	revisions map[string][]Revision
+}
+
+// WithHistory retains the most recent keep revisions of every node, recording a revision each time a node is added
+// or patched. A node's history is discarded when it is deleted. History is disabled by default.
+func WithHistory(keep int) GraphOption {
+	return func(g *Graph) {
+		g.history.mu.Lock()
+		defer g.history.mu.Unlock()
+		g.history.keep = keep
+		g.history.revisions = map[string][]Revision{}
+	}
+}
+
+// record stores a copy of the node as its next revision, dropping the oldest revision once keep is exceeded
+func (h *history) record(n Node) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.keep <= 0 {
+		return
+	}
+	path := pathOf(n)
+	revisions := h.revisions[path]
+	next := 1
+	if len(revisions) > 0 {
+		next = revisions[len(revisions)-1].Revision + 1
+	}
+	revisions = append(revisions, Revision{Revision: next, Time: time.Now(), Node: n.Copy()})
+	if len(revisions) > h.keep {
+		revisions = append([]Revision{}, revisions[len(revisions)-h.keep:]...)
+	}
+	h.revisions[path] = revisions
+}
+
+func (h *history) remove(id TypedID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.revisions, pathOf(id))
+}
+
+// History returns the retained revisions of the node from oldest to newest
+func (g *Graph) History(id TypedID) []Revision {
+	g.history.mu.RLock()
+	defer g.history.mu.RUnlock()
+	revisions := g.history.revisions[pathOf(id)]
+	copied := make([]Revision, len(revisions))
+	for i, r := range revisions {
+		copied[i] = Revision{Revision: r.Revision, Time: r.Time, Node: r.Node.Copy()}
+	}
+	return copied
+}
+
+// NodeAtRevision returns the node as of the given revision, if it is retained
+func (g *Graph) NodeAtRevision(id TypedID, revision int) (Node, bool) {
+	g.history.mu.RLock()
+	defer g.history.mu.RUnlock()
+	for _, r := range g.history.revisions[pathOf(id)] {
+		if r.Revision == revision {
+			return r.Node.Copy(), true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,161 @@
+package primitive
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	nodeAdded = iota
+	nodePatched
+	nodeDeleted
+	edgeAdded
+	edgePatched
+	edgeDeleted
+	nodeExpired
+	edgeExpired
+)
+
+type hookConfig struct {
+	async bool
+}
+
+// HookOption configures a hook
+type HookOption func(c *hookConfig)
+
+// HookAsync runs the hook in its own goroutine instead of before the mutation returns
+func HookAsync() HookOption {
+	return func(c *hookConfig) {
+		c.async = true
+	}
+}
+
+type hook struct {
+	node  func(n Node)
+	edge  func(e *Edge)
+	async bool
+}
+
+// hookEvent is a change recorded while the graph is locked, to be passed to hooks once it is unlocked
+type hookEvent struct {
+	kind int
+	node Node
+	edge *Edge
+	seq  uint64
+}
+
+type hooks struct {
+	mu          sync.RWMutex
+	hooks       map[int][]hook
+	subscribers []*subscriber
+	count       int32
+}
+
+func (h *hooks) add(kind int, hk hook, opts []HookOption) {
+	c := &hookConfig{}
+	for _, o := range opts {
+		o(c)
+	}
+	hk.async = c.async
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.hooks == nil {
+		h.hooks = map[int][]hook{}
+	}
+	h.hooks[kind] = append(h.hooks[kind], hk)
+	atomic.AddInt32(&h.count, 1)
+}
+
+func (h *hooks) fire(events []hookEvent) {
+	for _, event := range events {
+		h.mu.RLock()
+		registered := h.hooks[event.kind]
+		subscribers := h.subscribers
+		h.mu.RUnlock()
+		for _, hk := range registered {
+			hk := hk
+			event := event
+			run := func() {
+				if hk.node != nil {
+					hk.node(event.node)
+				} else {
+					hk.edge(event.edge)
+				}
+			}
+			if hk.async {
+				go run()
+			} else {
+				run()
+			}
+		}
+		for _, s := range subscribers {
+			s.send(Change{Op: changeOps[event.kind], Node: event.node, Edge: event.edge})
+		}
+	}
+}
+
+// OnNodeAdded registers a hook that is passed a copy of every node added to the graph
+func (g *Graph) OnNodeAdded(fn func(n Node), opts ...HookOption) {
+	g.hooks.add(nodeAdded, hook{node: fn}, opts)
+}
+
+// OnNodePatched registers a hook that is passed a copy of every node whose attributes are changed
+func (g *Graph) OnNodePatched(fn func(n Node), opts ...HookOption) {
+	g.hooks.add(nodePatched, hook{node: fn}, opts)
+}
+
+// OnNodeDeleted registers a hook that is passed a copy of every node deleted from the graph
+func (g *Graph) OnNodeDeleted(fn func(n Node), opts ...HookOption) {
+	g.hooks.add(nodeDeleted, hook{node: fn}, opts)
+}
+
+// OnEdgeAdded registers a hook that is passed a copy of every edge added to the graph
+func (g *Graph) OnEdgeAdded(fn func(e *Edge), opts ...HookOption) {
+	g.hooks.add(edgeAdded, hook{edge: fn}, opts)
+}
+
+// OnEdgePatched registers a hook that is passed a copy of every edge whose attributes or endpoints are changed
+func (g *Graph) OnEdgePatched(fn func(e *Edge), opts ...HookOption) {
+	g.hooks.add(edgePatched, hook{edge: fn}, opts)
+}
+
+// OnEdgeDeleted registers a hook that is passed a copy of every edge deleted from the graph
+func (g *Graph) OnEdgeDeleted(fn func(e *Edge), opts ...HookOption) {
+	g.hooks.add(edgeDeleted, hook{edge: fn}, opts)
+}
+
+// lock acquires the write lock, excluding every other writer & the readers that take rlock. The returned function
+// releases it, then runs the hooks for the changes made while it was held, so hooks may safely mutate the graph. The
+// time spent waiting for the lock is reported to a LockWaitSink.
+func (g *Graph) lock() func() {
+	if sink, ok := g.sink().(LockWaitSink); ok {
+		start := time.Now()
+		g.mu.Lock()
+		sink.LockWait(time.Since(start))
+	} else {
+		g.mu.Lock()
+	}
+	g.exclusive = true
+	return func() {
+		events := g.events
+		g.events = nil
+		g.exclusive = false
+		g.mu.Unlock()
+		g.hooks.fire(events)
+	}
+}
+
+// recordNode records a node change for the hooks. The caller must hold lock, or lockPaths for the element.
+func (g *Graph) recordNode(kind int, n Node) {
+	if atomic.LoadInt32(&g.hooks.count) > 0 {
+		g.record(pathOf(n), hookEvent{kind: kind, node: n.Copy()})
+	}
+}
+
+// recordEdge records an edge change for the hooks. The caller must hold lock, or lockPaths for the element.
+func (g *Graph) recordEdge(kind int, e *Edge) {
+	if atomic.LoadInt32(&g.hooks.count) > 0 {
+		g.record(pathOf(e), hookEvent{kind: kind, edge: &Edge{Node: e.Node.Copy(), From: g.endpoint(e.From).Copy(), To: g.endpoint(e.To).Copy()}})
+	}
+}
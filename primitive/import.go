@@ -0,0 +1,144 @@
+package primitive
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ImportError is an error importing a single node or edge
+type ImportError struct {
+	// Index is the position of the node or edge in the import
+	Index int
+	// Edge is true if the error belongs to an edge, otherwise it belongs to a node
+	Edge bool
+	Err  error
+}
+
+func (e *ImportError) Error() string {
+	kind := "node"
+	if e.Edge {
+		kind = "edge"
+	}
+	return fmt.Sprintf("%s %v: %s", kind, e.Index, e.Err)
+}
+
+// ImportErrors are the errors from an import, ordered with node errors first and then by index
+type ImportErrors []*ImportError
+
+func (e ImportErrors) Error() string {
+	var msgs []string
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("dagger: %v import errors: %s", len(e), strings.Join(msgs, "; "))
+}
+
+type rawExport struct {
+	Nodes []json.RawMessage `json:"nodes"`
+	Edges []json.RawMessage `json:"edges"`
+}
+
+// ImportJSONParallel imports a json export(see Export) decoding nodes & edges across the given number of workers.
+// All nodes are imported before any edge. Every node & edge that fails to import is reported in the returned ImportErrors.
+func (g *Graph) ImportJSONParallel(r io.Reader, workers int) error {
+	if workers < 1 {
+		workers = 1
+	}
+	raw := &rawExport{}
+	if err := json.NewDecoder(r).Decode(raw); err != nil {
+		return err
+	}
+	if g.ReadOnly() {
+		return ErrReadOnly
+	}
+	var (
+		mu   sync.Mutex
+		errs ImportErrors
+	)
+	report := func(importErrs ...*ImportError) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, importErrs...)
+	}
+	parallel(len(raw.Nodes), workers, func(start, end int) {
+		var nodes []Node
+		for i := start; i < end; i++ {
+			n := Node{}
+			if err := json.Unmarshal(raw.Nodes[i], &n); err != nil {
+				report(&ImportError{Index: i, Err: err})
+				continue
+			}
+			if !n.Exists(TYPE_KEY) {
+				report(&ImportError{Index: i, Err: fmt.Errorf("dagger: missing node type")})
+				continue
+			}
+			nodes = append(nodes, n)
+		}
+		if err := g.AddNodes(nodes...); err != nil {
+			report(&ImportError{Index: start, Err: err})
+		}
+	})
+	parallel(len(raw.Edges), workers, func(start, end int) {
+		edges := map[int]*Edge{}
+		for i := start; i < end; i++ {
+			e := &Edge{}
+			if err := json.Unmarshal(raw.Edges[i], e); err != nil {
+				report(&ImportError{Index: i, Edge: true, Err: err})
+				continue
+			}
+			edges[i] = e
+		}
+		defer g.lock()()
+		if g.ReadOnly() {
+			report(&ImportError{Index: start, Edge: true, Err: ErrReadOnly})
+			return
+		}
+		for i := start; i < end; i++ {
+			e, ok := edges[i]
+			if !ok {
+				continue
+			}
+			if err := g.addEdge(e); err != nil {
+				report(&ImportError{Index: i, Edge: true, Err: err})
+			}
+		}
+	})
+	if err := g.walErr(); err != nil {
+		return err
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	sort.Slice(errs, func(i, j int) bool {
+		if errs[i].Edge != errs[j].Edge {
+			return !errs[i].Edge
+		}
+		return errs[i].Index < errs[j].Index
+	})
+	return errs
+}
+
+// parallel splits [0, total) into contiguous chunks and calls fn on each chunk from its own goroutine
+func parallel(total, workers int, fn func(start, end int)) {
+	if total == 0 {
+		return
+	}
+	size := (total + workers - 1) / workers
+	wg := sync.WaitGroup{}
+	for start := 0; start < total; start += size {
+		end := start + size
+		if end > total {
+			end = total
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			fn(start, end)
+		}(start, end)
+	}
+	wg.Wait()
+}
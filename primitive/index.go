@@ -0,0 +1,179 @@
+package primitive
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrNoIndex is returned when looking up an attribute that has not been indexed with CreateIndex
+var ErrNoIndex = errors.New("dagger: attribute is not indexed")
+
+// attributeIndex maps the digests of an attribute's values to the ids of the nodes holding them
+type attributeIndex struct {
+	values map[string]map[string]bool
+	// current is the digest each node is indexed under, since nodes are patched in place & their old value is lost
+	current map[string]string
+}
+
+// indexes holds the secondary attribute indexes by node type & attribute
+type indexes struct {
+	mu      sync.RWMutex
+	indexes map[string]map[string]*attributeIndex
+}
+
+func (x *indexes) update(n Node) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for attr, idx := range x.indexes[n.Type()] {
+		idx.remove(n.ID())
+		if val, ok := n[attr]; ok {
+			idx.add(n.ID(), digest(val))
+		}
+	}
+}
+
+func (x *indexes) remove(id TypedID) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for _, idx := range x.indexes[id.Type()] {
+		idx.remove(id.ID())
+	}
+}
+
+func (idx *attributeIndex) add(id string, key string) {
+	if idx.values[key] == nil {
+		idx.values[key] = map[string]bool{}
+	}
+	idx.values[key][id] = true
+	idx.current[id] = key
+}
+
+func (idx *attributeIndex) remove(id string) {
+	key, ok := idx.current[id]
+	if !ok {
+		return
+	}
+	delete(idx.values[key], id)
+	if len(idx.values[key]) == 0 {
+		delete(idx.values, key)
+	}
+	delete(idx.current, id)
+}
+
+// CreateIndex indexes the attribute of nodes of the given type so FindByIndex can look them up by value without
+// scanning every node. The index is kept up to date as nodes are added, patched & deleted. Subtypes are not included.
+func (g *Graph) CreateIndex(nodeType, attribute string) error {
+	defer g.lock()()
+	x := &g.indexes
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.indexes == nil {
+		x.indexes = map[string]map[string]*attributeIndex{}
+	}
+	if x.indexes[nodeType] == nil {
+		x.indexes[nodeType] = map[string]*attributeIndex{}
+	}
+	if _, ok := x.indexes[nodeType][attribute]; ok {
+		return nil
+	}
+	idx := &attributeIndex{
+		values:  map[string]map[string]bool{},
+		current: map[string]string{},
+	}
+	g.nodes.Range(nodeType, func(key string, val interface{}) bool {
+		if n, ok := val.(Node); ok {
+			if v, ok := n[attribute]; ok {
+				idx.add(n.ID(), digest(v))
+			}
+		}
+		return true
+	})
+	x.indexes[nodeType][attribute] = idx
+	return nil
+}
+
+// FindByIndex returns the nodes of the given type whose indexed attribute equals the value, sorted by id. Numbers are
+// equal if they encode to the same json, so an int & a float64 holding the same number match. It returns ErrNoIndex
+// if the attribute has not been indexed with CreateIndex.
+func (g *Graph) FindByIndex(nodeType, attribute string, value interface{}) ([]Node, error) {
+	x := &g.indexes
+	x.mu.RLock()
+	idx, ok := x.indexes[nodeType][attribute]
+	if !ok {
+		x.mu.RUnlock()
+		return nil, ErrNoIndex
+	}
+	var ids []string
+	for id := range idx.values[digest(value)] {
+		ids = append(ids, id)
+	}
+	x.mu.RUnlock()
+	sort.Strings(ids)
+	var nodes []Node
+	for _, id := range ids {
+		if n, ok := g.GetNode(Node{TYPE_KEY: nodeType, ID_KEY: id}); ok {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes, nil
+}
+
+// FindOrCreate atomically returns the node of the given type whose attributes equal every match attribute, or adds a
+// node with the match & create attributes if there is none, returning true if it was created. If an index covers one
+// of the match attributes it is used to find candidates instead of scanning every node of the type. When more than one
+// node matches, the one with the lowest id is returned.
+func (g *Graph) FindOrCreate(nodeType string, match, create map[string]interface{}) (Node, bool, error) {
+	defer g.lock()()
+	if found, ok := g.findMatch(nodeType, match); ok {
+		return found, false, nil
+	}
+	if g.ReadOnly() {
+		return nil, false, ErrReadOnly
+	}
+	n := Node{}
+	n.SetAll(create)
+	n.SetAll(match)
+	n.Set(TYPE_KEY, nodeType)
+	if !n.HasID() {
+		n.SetID(UUID())
+	}
+	if err := g.validateNode(n); err != nil {
+		return nil, false, err
+	}
+	g.addNode(n)
+	return n, true, g.walErr()
+}
+
+// findMatch returns the node of the given type with the lowest id whose attributes equal every match attribute
+func (g *Graph) findMatch(nodeType string, match map[string]interface{}) (Node, bool) {
+	matches := func(n Node) bool {
+		for k, v := range match {
+			current, ok := n[k]
+			if !ok || digest(current) != digest(v) {
+				return false
+			}
+		}
+		return true
+	}
+	for attr, value := range match {
+		candidates, err := g.FindByIndex(nodeType, attr, value)
+		if err != nil {
+			continue
+		}
+		for _, n := range candidates {
+			if matches(n) {
+				return n, true
+			}
+		}
+		return nil, false
+	}
+	var found Node
+	g.nodes.Range(nodeType, func(key string, val interface{}) bool {
+		if n, ok := val.(Node); ok && matches(n) && (found == nil || n.ID() < found.ID()) {
+			found = n
+		}
+		return true
+	})
+	return found, found != nil
+}
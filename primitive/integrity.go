@@ -0,0 +1,190 @@
+package primitive
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	// IntegrityDanglingEdge is an edge whose from or to node does not exist
+	IntegrityDanglingEdge = "dangling_edge"
+	// IntegrityStaleIndex is an edgesFrom/edgesTo entry for an edge that does not exist or no longer connects the node
+	IntegrityStaleIndex = "stale_index"
+	// IntegrityMissingIndex is an edge that is missing from the edgesFrom/edgesTo entry of one of its endpoints
+	IntegrityMissingIndex = "missing_index"
+	// IntegrityMisplacedNode is a node stored under a type namespace or key that does not match its type & id
+	IntegrityMisplacedNode = "misplaced_node"
+)
+
+// IntegrityError describes an inconsistency between the graph's nodes, edges & edge indexes
+type IntegrityError struct {
+	// Kind is one of the Integrity* constants
+	Kind string `json:"kind"`
+	// Type is the type of the node or edge with the inconsistency
+	Type string `json:"type"`
+	// ID is the id of the node or edge with the inconsistency
+	ID string `json:"id"`
+	// Message describes the inconsistency
+	Message string `json:"message"`
+}
+
+// Error implements the error interface
+func (e IntegrityError) Error() string {
+	return fmt.Sprintf("dagger: %s %s.%s: %s", e.Kind, e.Type, e.ID, e.Message)
+}
+
+// integrityCheck is the result of scanning the graph, along with the fix for each problem found
+type integrityCheck struct {
+	errs  []IntegrityError
+	fixes []func()
+}
+
+func (c *integrityCheck) add(err IntegrityError, fix func()) {
+	c.errs = append(c.errs, err)
+	c.fixes = append(c.fixes, fix)
+}
+
+// CheckIntegrity scans the graph for dangling edges, stale or missing edgesFrom/edgesTo entries and nodes stored under
+// the wrong type namespace. Writers are blocked during the scan. The errors are sorted by kind, type & id.
+func (g *Graph) CheckIntegrity() []IntegrityError {
+	defer g.rlock()()
+	check := g.checkIntegrity()
+	g.recount(check)
+	return check.errs
+}
+
+// Repair fixes the problems CheckIntegrity finds, returning them: dangling edges are deleted, stale index entries are
+// removed, missing index entries are added & misplaced nodes are moved under their type & id.
+func (g *Graph) Repair() ([]IntegrityError, error) {
+	defer g.lock()()
+	if g.ReadOnly() {
+		return nil, ErrReadOnly
+	}
+	check := g.checkIntegrity()
+	for _, fix := range check.fixes {
+		fix()
+	}
+	g.recount(&integrityCheck{})
+	return check.errs, g.walErr()
+}
+
+func (g *Graph) checkIntegrity() *integrityCheck {
+	check := &integrityCheck{}
+	misplaced := map[string]bool{}
+	for _, typ := range g.nodes.Namespaces() {
+		typ := typ
+		g.nodes.Range(typ, func(key string, value interface{}) bool {
+			n, ok := value.(Node)
+			if !ok || (n.Type() == typ && n.ID() == key) {
+				return true
+			}
+			misplaced[pathOf(n)] = true
+			check.add(IntegrityError{
+				Kind:    IntegrityMisplacedNode,
+				Type:    n.Type(),
+				ID:      n.ID(),
+				Message: fmt.Sprintf("stored as %s.%s", typ, key),
+			}, func() {
+				g.nodes.Delete(typ, key)
+				if !g.HasNode(n) {
+					g.nodes.Set(n.Type(), n.ID(), n)
+				}
+			})
+			return true
+		})
+	}
+	dangling := map[string]bool{}
+	g.RangeEdges(func(e *Edge) bool {
+		for _, endpoint := range []TypedID{e.From, e.To} {
+			if !g.HasNode(endpoint) && !misplaced[pathOf(endpoint)] {
+				dangling[pathOf(e)] = true
+				check.add(IntegrityError{
+					Kind:    IntegrityDanglingEdge,
+					Type:    e.Type(),
+					ID:      e.ID(),
+					Message: fmt.Sprintf("node %s does not exist", pathOf(endpoint)),
+				}, func() {
+					g.delEdge(e)
+				})
+				return true
+			}
+		}
+		return true
+	})
+	indexes := []struct {
+		name     string
+		index    Storage
+		endpoint func(e *Edge) TypedID
+	}{
+		{"edgesFrom", g.edgesFrom, func(e *Edge) TypedID { return e.From }},
+		{"edgesTo", g.edgesTo, func(e *Edge) TypedID { return e.To }},
+	}
+	for _, x := range indexes {
+		x := x
+		for _, typ := range x.index.Namespaces() {
+			typ := typ
+			x.index.Range(typ, func(key string, value interface{}) bool {
+				edges, ok := value.(*edgeMap)
+				if !ok || edges == nil {
+					return true
+				}
+				edges.Range(func(e *Edge) bool {
+					current, ok := g.GetEdge(e)
+					if ok && pathOf(x.endpoint(current)) == typ+"."+key {
+						return true
+					}
+					check.add(IntegrityError{
+						Kind:    IntegrityStaleIndex,
+						Type:    e.Type(),
+						ID:      e.ID(),
+						Message: fmt.Sprintf("%s entry of %s.%s", x.name, typ, key),
+					}, func() {
+						g.unindexEdge(x.index, Node{TYPE_KEY: typ, ID_KEY: key}, e)
+					})
+					return true
+				})
+				return true
+			})
+		}
+		g.RangeEdges(func(e *Edge) bool {
+			endpoint := x.endpoint(e)
+			if dangling[pathOf(e)] {
+				return true
+			}
+			if val, ok := x.index.Get(endpoint.Type(), endpoint.ID()); ok {
+				if edges, ok := val.(*edgeMap); ok && edges != nil && edges.HasEdge(e) {
+					return true
+				}
+			}
+			check.add(IntegrityError{
+				Kind:    IntegrityMissingIndex,
+				Type:    e.Type(),
+				ID:      e.ID(),
+				Message: fmt.Sprintf("missing from the %s entry of %s", x.name, pathOf(endpoint)),
+			}, func() {
+				g.indexEdge(x.index, endpoint, e)
+			})
+			return true
+		})
+	}
+	// report errors & apply fixes in a stable order
+	order := make([]int, len(check.errs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := check.errs[order[i]], check.errs[order[j]]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.ID < b.ID
+	})
+	sorted := &integrityCheck{}
+	for _, i := range order {
+		sorted.add(check.errs[i], check.fixes[i])
+	}
+	return sorted
+}
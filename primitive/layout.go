@@ -0,0 +1,216 @@
+package primitive
+
+import (
+	"sort"
+	"strconv"
+)
+
+// Position is the placement of a node in a layered layout
+type Position struct {
+	// Rank is the layer of the node. Edges always point from a lower rank to a higher rank unless they close a cycle.
+	Rank int `json:"rank"`
+	// Order is the position of the node within its rank
+	Order int `json:"order"`
+	// X is the horizontal coordinate of the node in unit spacing, centered on 0
+	X float64 `json:"x"`
+	// Y is the vertical coordinate of the node in unit spacing
+	Y float64 `json:"y"`
+}
+
+// Layout is a hierarchical(sugiyama) layout of the graph suitable for drawing dependency graphs
+type Layout struct {
+	// Positions maps a node's type.id path to its position
+	Positions map[string]Position `json:"positions"`
+	// Ranks holds the type.id paths of the nodes in each rank, in order
+	Ranks [][]string `json:"ranks"`
+}
+
+// Position returns the position of the node in the layout
+func (l *Layout) Position(id TypedID) (Position, bool) {
+	p, ok := l.Positions[pathOf(id)]
+	return p, ok
+}
+
+// LayeredLayout computes a hierarchical layout over edges of the given type: cycles are broken, nodes are assigned to
+// ranks by longest path, and the order within each rank is swept with the barycenter heuristic to minimize crossings.
+func (g *Graph) LayeredLayout(edgeType Type) *Layout {
+	var nodes []string
+	g.RangeNodes(func(n Node) bool {
+		nodes = append(nodes, pathOf(n))
+		return true
+	})
+	sort.Strings(nodes)
+	exists := map[string]bool{}
+	for _, n := range nodes {
+		exists[n] = true
+	}
+	succ := map[string][]string{}
+	seen := map[[2]string]bool{}
+	g.RangeEdgeTypes(edgeType, func(e *Edge) bool {
+		from, to := pathOf(e.From), pathOf(e.To)
+		if from == to || !exists[from] || !exists[to] || seen[[2]string{from, to}] {
+			return true
+		}
+		seen[[2]string{from, to}] = true
+		succ[from] = append(succ[from], to)
+		return true
+	})
+	for _, s := range succ {
+		sort.Strings(s)
+	}
+	dag := acyclic(nodes, succ)
+	ranks := longestPathRanks(nodes, dag)
+
+	// split edges spanning multiple ranks with dummy nodes so crossings are measured between adjacent ranks only
+	layers := map[int][]string{}
+	down := map[string][]string{}
+	up := map[string][]string{}
+	maxRank := 0
+	for _, n := range nodes {
+		layers[ranks[n]] = append(layers[ranks[n]], n)
+		if ranks[n] > maxRank {
+			maxRank = ranks[n]
+		}
+	}
+	dummies := 0
+	for _, from := range nodes {
+		for _, to := range dag[from] {
+			prev := from
+			for r := ranks[from] + 1; r < ranks[to]; r++ {
+				dummies++
+				dummy := "\x00" + strconv.Itoa(dummies)
+				ranks[dummy] = r
+				layers[r] = append(layers[r], dummy)
+				down[prev] = append(down[prev], dummy)
+				up[dummy] = append(up[dummy], prev)
+				prev = dummy
+			}
+			down[prev] = append(down[prev], to)
+			up[to] = append(up[to], prev)
+		}
+	}
+	order := map[string]int{}
+	for r := 0; r <= maxRank; r++ {
+		for i, n := range layers[r] {
+			order[n] = i
+		}
+	}
+	sweep := func(r int, neighbors map[string][]string) {
+		layer := layers[r]
+		centers := map[string]float64{}
+		for _, n := range layer {
+			adj := neighbors[n]
+			if len(adj) == 0 {
+				centers[n] = float64(order[n])
+				continue
+			}
+			sum := 0.0
+			for _, a := range adj {
+				sum += float64(order[a])
+			}
+			centers[n] = sum / float64(len(adj))
+		}
+		sort.SliceStable(layer, func(i, j int) bool {
+			return centers[layer[i]] < centers[layer[j]]
+		})
+		for i, n := range layer {
+			order[n] = i
+		}
+	}
+	for i := 0; i < 4; i++ {
+		for r := 1; r <= maxRank; r++ {
+			sweep(r, up)
+		}
+		for r := maxRank - 1; r >= 0; r-- {
+			sweep(r, down)
+		}
+	}
+	layout := &Layout{
+		Positions: map[string]Position{},
+	}
+	if len(nodes) == 0 {
+		return layout
+	}
+	for r := 0; r <= maxRank; r++ {
+		var rank []string
+		offset := float64(len(layers[r])-1) / 2
+		for _, n := range layers[r] {
+			if !exists[n] {
+				continue
+			}
+			rank = append(rank, n)
+			layout.Positions[n] = Position{
+				Rank:  r,
+				Order: len(rank) - 1,
+				X:     float64(order[n]) - offset,
+				Y:     float64(r),
+			}
+		}
+		layout.Ranks = append(layout.Ranks, rank)
+	}
+	return layout
+}
+
+// acyclic returns the successors with every edge that closes a cycle reversed
+func acyclic(nodes []string, succ map[string][]string) map[string][]string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	dag := map[string][]string{}
+	var visit func(n string)
+	visit = func(n string) {
+		state[n] = visiting
+		for _, s := range succ[n] {
+			switch state[s] {
+			case visiting:
+				dag[s] = append(dag[s], n)
+			case unvisited:
+				dag[n] = append(dag[n], s)
+				visit(s)
+			default:
+				dag[n] = append(dag[n], s)
+			}
+		}
+		state[n] = visited
+	}
+	for _, n := range nodes {
+		if state[n] == unvisited {
+			visit(n)
+		}
+	}
+	return dag
+}
+
+// longestPathRanks ranks each node one below its deepest predecessor
+func longestPathRanks(nodes []string, dag map[string][]string) map[string]int {
+	indegree := map[string]int{}
+	for _, n := range nodes {
+		for _, s := range dag[n] {
+			indegree[s]++
+		}
+	}
+	var queue []string
+	for _, n := range nodes {
+		if indegree[n] == 0 {
+			queue = append(queue, n)
+		}
+	}
+	ranks := map[string]int{}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, s := range dag[n] {
+			if ranks[n]+1 > ranks[s] {
+				ranks[s] = ranks[n] + 1
+			}
+			indegree[s]--
+			if indegree[s] == 0 {
+				queue = append(queue, s)
+			}
+		}
+	}
+	return ranks
+}
@@ -0,0 +1,233 @@
+package primitive
+
+import (
+	"container/list"
+	"sync"
+)
+
+// WritePolicy determines when a CachedStorage writes changes to the storage beneath it
+type WritePolicy int
+
+const (
+	// WriteThrough writes every Set & Delete to the backing storage before it returns
+	WriteThrough WritePolicy = iota
+	// WriteBehind keeps changes in the cache, writing them to the backing storage when they are evicted, on Flush and
+	// on Close. Changes that have not been written are lost if the process crashes.
+	WriteBehind
+)
+
+// CacheStats are the counters of a CachedStorage
+type CacheStats struct {
+	// Hits is the number of Gets served from the cache
+	Hits int64 `json:"hits"`
+	// Misses is the number of Gets read from the backing storage
+	Misses int64 `json:"misses"`
+	// Evictions is the number of keys dropped to make room for others
+	Evictions int64 `json:"evictions"`
+	// Len is the number of keys currently cached
+	Len int `json:"len"`
+	// Dirty is the number of cached changes not yet written to the backing storage
+	Dirty int `json:"dirty"`
+}
+
+// cachedEntry is a cached key. A deleted entry is a change that has not been written yet(WriteBehind only).
+type cachedEntry struct {
+	namespace, key string
+	value          interface{}
+	deleted        bool
+	dirty          bool
+}
+
+type cachedKey struct {
+	namespace, key string
+}
+
+// CachedStorage is a Storage that keeps the most recently used values of a slower storage(ex: DiskStorage) in memory,
+// evicting the least recently used value once it holds more than its capacity.
+type CachedStorage struct {
+	mu        sync.Mutex
+	backing   Storage
+	policy    WritePolicy
+	capacity  int
+	recent    *list.List
+	entries   map[cachedKey]*list.Element
+	hits      int64
+	misses    int64
+	evictions int64
+	closeOnce sync.Once
+}
+
+// NewCachedStorage caches up to capacity values of the backing storage, writing changes to it with the policy. The
+// CachedStorage owns the backing storage: closing it flushes & closes the backing storage.
+func NewCachedStorage(backing Storage, capacity int, policy WritePolicy) *CachedStorage {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &CachedStorage{
+		backing:  backing,
+		policy:   policy,
+		capacity: capacity,
+		recent:   list.New(),
+		entries:  map[cachedKey]*list.Element{},
+	}
+}
+
+// Stats returns the cache's counters
+func (c *CachedStorage) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := CacheStats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Len:       len(c.entries),
+	}
+	for _, elem := range c.entries {
+		if elem.Value.(*cachedEntry).dirty {
+			stats.Dirty++
+		}
+	}
+	return stats
+}
+
+// Err returns the first error the backing storage encountered persisting a write, if it reports them(see
+// DiskStorage.Err)
+func (c *CachedStorage) Err() error {
+	if s, ok := c.backing.(interface{ Err() error }); ok {
+		return s.Err()
+	}
+	return nil
+}
+
+func (c *CachedStorage) Get(namespace string, key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[cachedKey{namespace, key}]; ok {
+		c.hits++
+		c.recent.MoveToFront(elem)
+		entry := elem.Value.(*cachedEntry)
+		return entry.value, !entry.deleted
+	}
+	c.misses++
+	// the lock is held while the backing storage is read, so a concurrent Set cannot be overwritten by a stale value
+	value, ok := c.backing.Get(namespace, key)
+	if !ok {
+		return nil, false
+	}
+	c.put(&cachedEntry{namespace: namespace, key: key, value: value})
+	return value, true
+}
+
+func (c *CachedStorage) Set(namespace string, key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &cachedEntry{namespace: namespace, key: key, value: value}
+	if c.policy == WriteThrough {
+		c.backing.Set(namespace, key, value)
+	} else {
+		entry.dirty = true
+	}
+	c.put(entry)
+}
+
+func (c *CachedStorage) Delete(namespace string, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.policy == WriteThrough {
+		c.backing.Delete(namespace, key)
+		if elem, ok := c.entries[cachedKey{namespace, key}]; ok {
+			c.recent.Remove(elem)
+			delete(c.entries, cachedKey{namespace, key})
+		}
+		return
+	}
+	// the deletion is cached so Get does not fall through to the value still in the backing storage
+	c.put(&cachedEntry{namespace: namespace, key: key, deleted: true, dirty: true})
+}
+
+// put caches the entry as the most recently used, evicting the least recently used entries over capacity. The caller
+// must hold the lock.
+func (c *CachedStorage) put(entry *cachedEntry) {
+	k := cachedKey{entry.namespace, entry.key}
+	if elem, ok := c.entries[k]; ok {
+		elem.Value = entry
+		c.recent.MoveToFront(elem)
+	} else {
+		c.entries[k] = c.recent.PushFront(entry)
+	}
+	for c.recent.Len() > c.capacity {
+		oldest := c.recent.Back()
+		evicted := oldest.Value.(*cachedEntry)
+		c.write(evicted)
+		c.recent.Remove(oldest)
+		delete(c.entries, cachedKey{evicted.namespace, evicted.key})
+		c.evictions++
+	}
+}
+
+// write writes the entry to the backing storage if it has not been written. The caller must hold the lock.
+func (c *CachedStorage) write(entry *cachedEntry) {
+	if !entry.dirty {
+		return
+	}
+	if entry.deleted {
+		c.backing.Delete(entry.namespace, entry.key)
+	} else {
+		c.backing.Set(entry.namespace, entry.key, entry.value)
+	}
+	entry.dirty = false
+}
+
+// Flush writes every cached change to the backing storage. It is a no-op for WriteThrough.
+func (c *CachedStorage) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.flush()
+}
+
+// flush writes every cached change to the backing storage. The caller must hold the lock.
+func (c *CachedStorage) flush() {
+	for elem := c.recent.Back(); elem != nil; elem = elem.Prev() {
+		c.write(elem.Value.(*cachedEntry))
+	}
+}
+
+// Range iterates over the keys & values in the namespace(or every namespace if it is AnyType) of the backing storage
+// until fn returns false, flushing cached changes first. Values are not cached while they are ranged over, so a scan
+// does not evict the working set. fn may modify the storage.
+func (c *CachedStorage) Range(namespace string, fn func(key string, value interface{}) bool) {
+	c.Flush()
+	c.backing.Range(namespace, func(key string, value interface{}) bool {
+		// prefer the cached value, which callers may hold & modify in place(ex: edge indexes)
+		c.mu.Lock()
+		if elem, ok := c.entries[cachedKey{namespace, key}]; ok && namespace != AnyType {
+			entry := elem.Value.(*cachedEntry)
+			if entry.deleted {
+				c.mu.Unlock()
+				return true
+			}
+			value = entry.value
+		}
+		c.mu.Unlock()
+		return fn(key, value)
+	})
+}
+
+func (c *CachedStorage) Namespaces() []string {
+	c.Flush()
+	return c.backing.Namespaces()
+}
+
+// Close flushes cached changes & closes the backing storage
+func (c *CachedStorage) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		c.flush()
+		c.recent.Init()
+		c.entries = map[cachedKey]*list.Element{}
+		c.mu.Unlock()
+		err = c.backing.Close()
+	})
+	return err
+}
@@ -0,0 +1,209 @@
+package primitive
+
+// PatternNode is a node variable in a Pattern
+type PatternNode struct {
+	// Var names the node in a Match
+	Var string
+	// Type is the node type(or declared parent type) the node must have. Empty matches any type.
+	Type string
+	// Where is an optional predicate the node's attributes must pass
+	Where func(n Node) bool
+}
+
+// PatternEdge is an edge variable in a Pattern that connects two of its node variables
+type PatternEdge struct {
+	// Var optionally names the edge in a Match
+	Var string
+	// Type is the edge type(or declared parent type) the edge must have. Empty matches any type.
+	Type string
+	// From & To are the Vars of the nodes the edge points from & to
+	From string
+	To   string
+	// Where is an optional predicate the edge's attributes must pass
+	Where func(e *Edge) bool
+}
+
+// Pattern describes a subgraph to search for. Distinct node variables always match distinct nodes.
+type Pattern struct {
+	Nodes []PatternNode
+	Edges []PatternEdge
+}
+
+// Match is a subgraph that matched a Pattern, keyed by variable
+type Match struct {
+	Nodes map[string]Node
+	Edges map[string]*Edge
+}
+
+// Match returns every subgraph that matches the pattern. Nodes are bound in an order where each node is, if possible,
+// connected to a node bound before it, so candidates are found by following edges rather than scanning the graph.
+func (g *Graph) Match(pattern *Pattern) []Match {
+	nodes := map[string]PatternNode{}
+	for _, n := range pattern.Nodes {
+		nodes[n.Var] = n
+	}
+	order := matchOrder(pattern)
+	for _, v := range order {
+		if _, ok := nodes[v]; !ok {
+			// variables only used by edges match any node
+			nodes[v] = PatternNode{Var: v}
+		}
+	}
+	var matches []Match
+	boundNodes := map[string]Node{}
+	used := map[string]bool{}
+	boundEdges := map[string]*Edge{}
+	edgeDone := make([]bool, len(pattern.Edges))
+
+	var bindNode func(i int)
+	// bindEdges binds every pattern edge whose endpoints are both bound, starting at edge j, then binds node i
+	var bindEdges func(j int, i int)
+	bindEdges = func(j int, i int) {
+		for ; j < len(pattern.Edges); j++ {
+			pe := pattern.Edges[j]
+			from, fromOK := boundNodes[pe.From]
+			to, toOK := boundNodes[pe.To]
+			if edgeDone[j] || !fromOK || !toOK {
+				continue
+			}
+			edgeDone[j] = true
+			g.EdgesFrom(patternType(pe.Type), from, func(e *Edge) bool {
+				if pathOf(e.To) != pathOf(to) || (pe.Where != nil && !pe.Where(e)) {
+					return true
+				}
+				if pe.Var != "" {
+					boundEdges[pe.Var] = e
+				}
+				bindEdges(j+1, i)
+				return true
+			})
+			if pe.Var != "" {
+				delete(boundEdges, pe.Var)
+			}
+			edgeDone[j] = false
+			return
+		}
+		bindNode(i)
+	}
+	bindNode = func(i int) {
+		if i == len(order) {
+			m := Match{Nodes: map[string]Node{}, Edges: map[string]*Edge{}}
+			for k, v := range boundNodes {
+				m.Nodes[k] = v
+			}
+			for k, v := range boundEdges {
+				m.Edges[k] = v
+			}
+			matches = append(matches, m)
+			return
+		}
+		pn := nodes[order[i]]
+		try := func(n Node) {
+			if used[pathOf(n)] {
+				return
+			}
+			if pn.Type != "" && !g.nodeTypes.isA(n.Type(), pn.Type) {
+				return
+			}
+			if pn.Where != nil && !pn.Where(n) {
+				return
+			}
+			boundNodes[pn.Var] = n
+			used[pathOf(n)] = true
+			bindEdges(0, i+1)
+			delete(boundNodes, pn.Var)
+			delete(used, pathOf(n))
+		}
+		for _, candidate := range g.matchCandidates(pattern, pn, boundNodes) {
+			try(candidate)
+		}
+	}
+	bindNode(0)
+	return matches
+}
+
+// matchCandidates returns the nodes that may be bound to the pattern node: the neighbors of an already bound node it is
+// connected to, or every node of its type
+func (g *Graph) matchCandidates(pattern *Pattern, pn PatternNode, bound map[string]Node) []Node {
+	var candidates []Node
+	for _, pe := range pattern.Edges {
+		if pe.To == pn.Var {
+			if from, ok := bound[pe.From]; ok {
+				g.EdgesFrom(patternType(pe.Type), from, func(e *Edge) bool {
+					if n, ok := g.GetNode(e.To); ok {
+						candidates = append(candidates, n)
+					}
+					return true
+				})
+				return dedupNodes(candidates)
+			}
+		}
+		if pe.From == pn.Var {
+			if to, ok := bound[pe.To]; ok {
+				g.EdgesTo(patternType(pe.Type), to, func(e *Edge) bool {
+					if n, ok := g.GetNode(e.From); ok {
+						candidates = append(candidates, n)
+					}
+					return true
+				})
+				return dedupNodes(candidates)
+			}
+		}
+	}
+	collect := func(n Node) bool {
+		candidates = append(candidates, n)
+		return true
+	}
+	if pn.Type == "" {
+		g.RangeNodes(collect)
+	} else {
+		g.RangeNodeTypes(typeName(pn.Type), collect)
+	}
+	return candidates
+}
+
+// matchOrder orders the pattern's node variables so each is connected to an earlier one where possible
+func matchOrder(pattern *Pattern) []string {
+	var order []string
+	seen := map[string]bool{}
+	for _, start := range pattern.Nodes {
+		if seen[start.Var] {
+			continue
+		}
+		seen[start.Var] = true
+		queue := []string{start.Var}
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			order = append(order, v)
+			for _, pe := range pattern.Edges {
+				for _, next := range []string{pe.From, pe.To} {
+					if (pe.From == v || pe.To == v) && !seen[next] {
+						seen[next] = true
+						queue = append(queue, next)
+					}
+				}
+			}
+		}
+	}
+	return order
+}
+
+func dedupNodes(nodes []Node) []Node {
+	seen := map[string]bool{}
+	var unique []Node
+	for _, n := range nodes {
+		if !seen[pathOf(n)] {
+			seen[pathOf(n)] = true
+			unique = append(unique, n)
+		}
+	}
+	return unique
+}
+
+func patternType(typ string) Type {
+	if typ == "" {
+		return anyType{}
+	}
+	return typeName(typ)
+}
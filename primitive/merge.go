@@ -0,0 +1,78 @@
+package primitive
+
+// ConflictFn resolves a conflict between two versions of the same node(or edge attributes) by returning the attributes
+// to keep. ours is the version in the graph being merged into & theirs is the version in the other graph; both are
+// copies and may be modified & returned.
+type ConflictFn func(ours, theirs Node) Node
+
+// Merge unions the nodes & edges of the other graph into the graph. When the same typed id exists in both graphs with
+// differing attributes, resolve picks the attributes to keep(a nil resolver keeps theirs). Conflicting edges keep the
+// endpoints of the graph being merged into. Other is read from a consistent export, so it may be merged into itself.
+// The merged nodes & edges are resolved & checked against the graph's schemas before any is written, so a merge that
+// would violate them changes nothing.
+func (g *Graph) Merge(other *Graph, resolve ConflictFn) error {
+	if resolve == nil {
+		resolve = func(ours, theirs Node) Node {
+			return theirs
+		}
+	}
+	theirs := other.Export()
+	defer g.lock()()
+	if g.ReadOnly() {
+		return ErrReadOnly
+	}
+	var nodes []Node
+	for _, n := range theirs.Nodes {
+		current, ok := g.GetNode(n)
+		if !ok {
+			nodes = append(nodes, n.Copy())
+			continue
+		}
+		if attributesEqual(current, n) {
+			continue
+		}
+		nodes = append(nodes, resolved(resolve, current, n))
+	}
+	// replaced edges keep their endpoints in the graph being merged into
+	var added, replaced []*Edge
+	for _, e := range theirs.Edges {
+		current, ok := g.GetEdge(e)
+		if !ok {
+			added = append(added, e)
+			continue
+		}
+		if attributesEqual(current.Node, e.Node) {
+			continue
+		}
+		replaced = append(replaced, &Edge{Node: resolved(resolve, current.Node, e.Node), From: current.From, To: current.To})
+	}
+	if err := g.validateAll(nodes, append(append([]*Edge{}, added...), replaced...)); err != nil {
+		return err
+	}
+	for _, n := range nodes {
+		g.addNode(n)
+	}
+	for _, e := range added {
+		if err := g.addEdge(g.resolveEdge(e)); err != nil {
+			return err
+		}
+	}
+	for _, e := range replaced {
+		g.delEdge(e)
+		if err := g.addEdge(e); err != nil {
+			return err
+		}
+	}
+	return g.walErr()
+}
+
+// resolved calls the resolver with copies of both versions, keeping the type & id of the original
+func resolved(resolve ConflictFn, ours, theirs Node) Node {
+	n := resolve(ours.Copy(), theirs.Copy())
+	if n == nil {
+		n = Node{}
+	}
+	n.SetType(ours.Type())
+	n.SetID(ours.ID())
+	return n
+}
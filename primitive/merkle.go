@@ -0,0 +1,209 @@
+package primitive
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	nodeBucket = "node"
+	edgeBucket = "edge"
+)
+
+// MerkleTree summarizes a graph as a tree of hashes: the root covers every element, each kind/type subtree covers
+// the nodes or edges of a type, and each leaf bucket covers the elements of a type whose hashed ID shares a prefix.
+// Comparing trees top down narrows a divergence to the buckets that differ.
+type MerkleTree struct {
+	Root string `json:"root"`
+	// Types maps a subtree key(ex: node/user) to the hash of its buckets
+	Types map[string]string `json:"types"`
+	// Buckets maps a bucket key(ex: node/user/3f) to the hash of its elements
+	Buckets map[string]string `json:"buckets"`
+}
+
+// MerkleTree builds the graph's merkle tree
+func (g *Graph) MerkleTree() *MerkleTree {
+	defer g.rlock()()
+	leaves := map[string][]string{}
+	g.RangeNodes(func(n Node) bool {
+		key := bucketOf(nodeBucket, n)
+		leaves[key] = append(leaves[key], NodeHash(n))
+		return true
+	})
+	g.RangeEdges(func(e *Edge) bool {
+		key := bucketOf(edgeBucket, e)
+		leaves[key] = append(leaves[key], EdgeHash(e))
+		return true
+	})
+	tree := &MerkleTree{
+		Types:   map[string]string{},
+		Buckets: map[string]string{},
+	}
+	types := map[string][]string{}
+	for key, hashes := range leaves {
+		tree.Buckets[key] = combineHashes(hashes)
+		subtree := key[:strings.LastIndex(key, "/")]
+		types[subtree] = append(types[subtree], key+tree.Buckets[key])
+	}
+	var roots []string
+	for subtree, hashes := range types {
+		tree.Types[subtree] = combineHashes(hashes)
+		roots = append(roots, subtree+tree.Types[subtree])
+	}
+	tree.Root = combineHashes(roots)
+	return tree
+}
+
+// Diff returns the sorted keys of the buckets that differ between the trees, descending only into subtrees whose
+// hashes differ
+func (t *MerkleTree) Diff(other *MerkleTree) []string {
+	if t.Root == other.Root {
+		return nil
+	}
+	var buckets []string
+	for subtree := range union(t.Types, other.Types) {
+		if t.Types[subtree] == other.Types[subtree] {
+			continue
+		}
+		prefix := subtree + "/"
+		for key := range union(t.Buckets, other.Buckets) {
+			if strings.HasPrefix(key, prefix) && t.Buckets[key] != other.Buckets[key] {
+				buckets = append(buckets, key)
+			}
+		}
+	}
+	sort.Strings(buckets)
+	return buckets
+}
+
+// Bucket exports the nodes & edges in the bucket
+func (g *Graph) Bucket(key string) *Export {
+	defer g.rlock()()
+	exp := &Export{}
+	scope := bucketFilter(key)
+	g.RangeNodeTypes(typeName(scope.NodeTypes[0]), func(n Node) bool {
+		if scope.MatchNode(n) {
+			exp.Nodes = append(exp.Nodes, n.Copy())
+		}
+		return true
+	})
+	g.RangeEdges(func(e *Edge) bool {
+		if scope.MatchEdge(e) {
+			exp.Edges = append(exp.Edges, &Edge{Node: e.Node.Copy(), From: e.From, To: e.To})
+		}
+		return true
+	})
+	return exp
+}
+
+// SyncPeer is a remote graph that can be reconciled against
+type SyncPeer interface {
+	// MerkleTree returns the peer's merkle tree
+	MerkleTree() (*MerkleTree, error)
+	// Bucket returns the nodes & edges in one of the peer's buckets
+	Bucket(key string) (*Export, error)
+}
+
+// LocalPeer adapts a graph in the same process to a SyncPeer
+func LocalPeer(g *Graph) SyncPeer {
+	return localPeer{g}
+}
+
+type localPeer struct {
+	g *Graph
+}
+
+func (l localPeer) MerkleTree() (*MerkleTree, error) {
+	return l.g.MerkleTree(), nil
+}
+
+func (l localPeer) Bucket(key string) (*Export, error) {
+	return l.g.Bucket(key), nil
+}
+
+// SyncResult reports what a sync transferred
+type SyncResult struct {
+	// Buckets are the keys of the buckets that differed
+	Buckets []string `json:"buckets"`
+	// Nodes is the number of nodes transferred from the peer
+	Nodes int `json:"nodes"`
+	// Edges is the number of edges transferred from the peer
+	Edges int `json:"edges"`
+}
+
+// SyncFrom converges the graph to the peer's state, transferring only the buckets whose merkle hashes differ. Elements
+// in a differing bucket that the peer does not have are deleted. Node buckets are applied before edge buckets so
+// transferred edges can connect transferred nodes.
+func (g *Graph) SyncFrom(peer SyncPeer) (*SyncResult, error) {
+	remote, err := peer.MerkleTree()
+	if err != nil {
+		return nil, err
+	}
+	result := &SyncResult{Buckets: g.MerkleTree().Diff(remote)}
+	sort.SliceStable(result.Buckets, func(i, j int) bool {
+		return strings.HasPrefix(result.Buckets[i], nodeBucket) && !strings.HasPrefix(result.Buckets[j], nodeBucket)
+	})
+	for _, key := range result.Buckets {
+		exp, err := peer.Bucket(key)
+		if err != nil {
+			return result, err
+		}
+		result.Nodes += len(exp.Nodes)
+		result.Edges += len(exp.Edges)
+		if _, err := g.Apply(exp, bucketFilter(key)); err != nil {
+			return result, fmt.Errorf("failed to sync bucket %s: %w", key, err)
+		}
+	}
+	return result, nil
+}
+
+// bucketOf returns the key of the leaf bucket the element belongs to
+func bucketOf(kind string, id TypedID) string {
+	sum := sha256.Sum256([]byte(id.ID()))
+	return kind + "/" + id.Type() + "/" + hex.EncodeToString(sum[:1])
+}
+
+// bucketFilter selects the elements in the bucket
+func bucketFilter(key string) Filter {
+	parts := strings.SplitN(key, "/", 2)
+	typ := ""
+	if len(parts) == 2 && strings.Contains(parts[1], "/") {
+		typ = parts[1][:strings.LastIndex(parts[1], "/")]
+	}
+	if parts[0] == edgeBucket {
+		return Filter{
+			NodeTypes: []string{""},
+			EdgeTypes: []string{typ},
+			Node: func(n Node) bool {
+				return false
+			},
+			Edge: func(e *Edge) bool {
+				return bucketOf(edgeBucket, e) == key
+			},
+		}
+	}
+	return Filter{
+		NodeTypes: []string{typ},
+		EdgeTypes: []string{""},
+		Node: func(n Node) bool {
+			return bucketOf(nodeBucket, n) == key
+		},
+		Edge: func(e *Edge) bool {
+			return false
+		},
+	}
+}
+
+func union(a, b map[string]string) map[string]bool {
+	keys := map[string]bool{}
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	return keys
+}
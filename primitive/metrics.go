@@ -0,0 +1,61 @@
+package primitive
+
+import (
+	"time"
+)
+
+const (
+	MetricNodesAdded   = "nodes.added"
+	MetricNodesDeleted = "nodes.deleted"
+	MetricNodesPatched = "nodes.patched"
+	MetricEdgesAdded   = "edges.added"
+	MetricEdgesDeleted = "edges.deleted"
+	MetricTraversal    = "traversal"
+)
+
+// MetricsSink receives the graph's mutation counters and traversal timings. Implementations must be concurrency safe.
+type MetricsSink interface {
+	// Count adds the value to the named counter
+	Count(name string, value int64, tags map[string]string)
+	// Timing records the duration of the named operation
+	Timing(name string, value time.Duration, tags map[string]string)
+}
+
+// LockWaitSink is a MetricsSink that also records the time each mutation waited to acquire the graph's write lock.
+// Lock waits are opt-in since they are reported for every mutation.
+type LockWaitSink interface {
+	MetricsSink
+	// LockWait records the time a mutation waited to acquire the write lock
+	LockWait(value time.Duration)
+}
+
+type sinkHolder struct {
+	sink MetricsSink
+}
+
+// SetMetricsSink sets the sink the graph emits metrics to. A nil sink disables metrics.
+func (g *Graph) SetMetricsSink(sink MetricsSink) {
+	g.metrics.Store(sinkHolder{sink: sink})
+}
+
+// MetricsSink returns the sink the graph emits metrics to, or nil if metrics are disabled
+func (g *Graph) MetricsSink() MetricsSink {
+	return g.sink()
+}
+
+func (g *Graph) sink() MetricsSink {
+	holder, _ := g.metrics.Load().(sinkHolder)
+	return holder.sink
+}
+
+func (g *Graph) count(name, typ string) {
+	if sink := g.sink(); sink != nil {
+		sink.Count(name, 1, map[string]string{"type": typ})
+	}
+}
+
+func (g *Graph) timing(name, kind string, start time.Time) {
+	if sink := g.sink(); sink != nil {
+		sink.Timing(name, time.Since(start), map[string]string{"kind": kind})
+	}
+}
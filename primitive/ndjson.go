@@ -0,0 +1,77 @@
+package primitive
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ndjsonLine is a single node or edge of a newline delimited json export
+type ndjsonLine struct {
+	Node Node  `json:"node,omitempty"`
+	Edge *Edge `json:"edge,omitempty"`
+}
+
+// ExportNDJSON streams the graph to the writer as newline delimited json: one node or edge per line, nodes first.
+// Edge endpoints are written as type & id only. The export is a consistent point-in-time copy, but writers are only
+// blocked while it is taken(see Graph.Export), not while it is written, so a slow writer never holds up the graph.
+// The transforms(ex: HashAttributes) are applied to a copy of every node & edge as it is written.
+func (g *Graph) ExportNDJSON(w io.Writer, transforms ...Transform) error {
+	nodes, edges := g.snapshot()
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, n := range nodes {
+		if len(transforms) > 0 {
+			n = transformed(n, transforms)
+		}
+		if err := enc.Encode(ndjsonLine{Node: n}); err != nil {
+			return err
+		}
+	}
+	for _, e := range edges {
+		if len(transforms) > 0 {
+			e = transformedEdge(e, transforms)
+		}
+		if err := enc.Encode(ndjsonLine{Edge: e}); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ImportNDJSON imports newline delimited json written by ExportNDJSON one line at a time. Edges must follow the nodes
+// they connect.
+func (g *Graph) ImportNDJSON(r io.Reader) error {
+	defer g.lock()()
+	if g.ReadOnly() {
+		return ErrReadOnly
+	}
+	br := bufio.NewReader(r)
+	for number := 1; ; number++ {
+		bits, err := br.ReadBytes('\n')
+		if len(bits) > 0 {
+			var line ndjsonLine
+			if err := json.Unmarshal(bits, &line); err != nil {
+				return fmt.Errorf("invalid ndjson on line %v: %w", number, err)
+			}
+			switch {
+			case line.Edge != nil:
+				if err := g.addEdge(g.resolveEdge(line.Edge)); err != nil {
+					return fmt.Errorf("failed to import edge on line %v: %w", number, err)
+				}
+			case line.Node != nil:
+				if err := g.validateNode(line.Node); err != nil {
+					return fmt.Errorf("failed to import node on line %v: %w", number, err)
+				}
+				g.addNode(line.Node)
+			}
+		}
+		if err == io.EOF {
+			return g.walErr()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package primitive
+
+import "sort"
+
+// Direction is the direction edges are followed in relative to a node
+type Direction int
+
+const (
+	// Outgoing follows the edges that stem from the node
+	Outgoing Direction = iota
+	// Incoming follows the edges that point toward the node
+	Incoming
+	// Both follows edges in either direction
+	Both
+)
+
+// String returns the name of the direction
+func (d Direction) String() string {
+	switch d {
+	case Outgoing:
+		return "outgoing"
+	case Incoming:
+		return "incoming"
+	case Both:
+		return "both"
+	default:
+		return "unknown"
+	}
+}
+
+// endpoint returns the stored node on one end of an edge. Patches replace nodes rather than modifying them, so an edge's
+// From & To may hold an older revision of the node.
+func (g *Graph) endpoint(n Node) Node {
+	if current, ok := g.GetNode(n); ok {
+		return current
+	}
+	return n
+}
+
+// rangeDirection ranges over the node's edges of the given type in the direction, passing each edge & the node on its
+// other end, until fn returns false
+func (g *Graph) rangeDirection(id TypedID, edgeType Type, direction Direction, fn func(e *Edge, other Node) bool) {
+	stopped := false
+	if direction == Outgoing || direction == Both {
+		g.EdgesFrom(edgeType, id, func(e *Edge) bool {
+			if !fn(e, g.endpoint(e.To)) {
+				stopped = true
+				return false
+			}
+			return true
+		})
+	}
+	if stopped {
+		return
+	}
+	if direction == Incoming || direction == Both {
+		g.EdgesTo(edgeType, id, func(e *Edge) bool {
+			return fn(e, g.endpoint(e.From))
+		})
+	}
+}
+
+// Neighbors returns the nodes connected to the node by edges of the given type in the direction, sorted by type &
+// id. A node connected by more than one edge is returned once.
+func (g *Graph) Neighbors(id TypedID, edgeType Type, direction Direction) []Node {
+	seen := map[string]bool{}
+	var neighbors []Node
+	g.rangeDirection(id, edgeType, direction, func(e *Edge, other Node) bool {
+		if seen[pathOf(other)] {
+			return true
+		}
+		seen[pathOf(other)] = true
+		if n, ok := g.GetNode(other); ok {
+			neighbors = append(neighbors, n)
+		}
+		return true
+	})
+	sort.Slice(neighbors, func(i, j int) bool {
+		return pathOf(neighbors[i]) < pathOf(neighbors[j])
+	})
+	return neighbors
+}
+
+// Degree returns the number of edges of the given type connected to the node in the direction. With Both, a self loop
+// counts twice.
+func (g *Graph) Degree(id TypedID, edgeType Type, direction Direction) int {
+	degree := 0
+	g.rangeDirection(id, edgeType, direction, func(e *Edge, other Node) bool {
+		degree++
+		return true
+	})
+	return degree
+}
@@ -10,6 +10,10 @@ import (
 const (
 	ID_KEY   = "_id"
 	TYPE_KEY = "_type"
+	// WEIGHT_KEY is the attribute edge weights are stored under
+	WEIGHT_KEY = "_weight"
+	// REVERSE_KEY is the attribute a mutual edge stores the id of its reverse edge under
+	REVERSE_KEY = "_reverse"
 )
 
 // Node is a functional hash table for storing arbitrary data. It is not concurrency safe
@@ -108,6 +112,13 @@ func (m Node) GetInt(key string) int {
 	return parseInt(m[key])
 }
 
+func (m Node) GetFloat(key string) float64 {
+	if !m.Exists(key) {
+		return 0
+	}
+	return parseFloat(m[key])
+}
+
 // Del deletes the entry from the Node by key
 func (m Node) Del(key string) {
 	delete(m, key)
@@ -163,19 +174,79 @@ func (m Node) Union(other Node) Node {
 	return toReturn
 }
 
-// Copy creates a replica of the Node
+// Copy creates a replica of the Node. Nested maps & slices are copied too, so mutating the replica's attributes never
+// affects the original. Pointers are shared.
 func (m Node) Copy() Node {
 	copied := Node{}
 	if m == nil {
 		return copied
 	}
 	m.Range(func(k string, v interface{}) bool {
-		copied.Set(k, v)
+		copied.Set(k, deepCopy(v))
 		return true
 	})
 	return copied
 }
 
+// deepCopy recursively copies maps & slices within the value
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case Node:
+		return v.Copy()
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			copied[k] = deepCopy(val)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, val := range v {
+			copied[i] = deepCopy(val)
+		}
+		return copied
+	}
+	return deepCopyValue(reflect.ValueOf(value)).Interface()
+}
+
+func deepCopyValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			copied.SetMapIndex(iter.Key(), deepCopyElem(iter.Value(), v.Type().Elem()))
+		}
+		return copied
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(deepCopyElem(v.Index(i), v.Type().Elem()))
+		}
+		return copied
+	}
+	return v
+}
+
+// deepCopyElem copies a map or slice element, converting interface elements back to the element type
+func deepCopyElem(v reflect.Value, typ reflect.Type) reflect.Value {
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return v
+		}
+		return reflect.ValueOf(deepCopy(v.Interface())).Convert(typ)
+	}
+	return deepCopyValue(v)
+}
+
 func (v Node) Equals(other Node) bool {
 	return reflect.DeepEqual(v, other)
 }
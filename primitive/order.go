@@ -0,0 +1,132 @@
+package primitive
+
+import (
+	"sort"
+	"sync"
+)
+
+// Order is the order a graph iterates over its nodes & edges in
+type Order int
+
+const (
+	// Unordered iterates in no particular order. It is the default & the fastest.
+	Unordered Order = iota
+	// InsertionOrder iterates in the order nodes & edges were first added. Patching an element keeps its position.
+	InsertionOrder
+	// IDOrder iterates sorted by type, then id
+	IDOrder
+)
+
+// WithOrder makes RangeNodes, RangeNodeTypes, RangeEdges, RangeEdgeTypes, EdgesFrom & EdgesTo iterate in the given
+// order, so tests & exports are deterministic. Ordered iteration collects & sorts the elements before calling the
+// iterator, so it costs time & memory proportional to the number of elements iterated.
+func WithOrder(order Order) GraphOption {
+	return func(g *Graph) {
+		g.ordering.order = order
+	}
+}
+
+// ordering is the insertion order index of the graph's nodes & edges
+type ordering struct {
+	order     Order
+	mu        sync.RWMutex
+	next      uint64
+	positions map[string]uint64
+}
+
+// insert records the position of a newly added node or edge
+func (o *ordering) insert(id TypedID) {
+	if o.order != InsertionOrder {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.positions == nil {
+		o.positions = map[string]uint64{}
+	}
+	if _, ok := o.positions[pathOf(id)]; !ok {
+		o.next++
+		o.positions[pathOf(id)] = o.next
+	}
+}
+
+func (o *ordering) remove(id TypedID) {
+	if o.order != InsertionOrder {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.positions, pathOf(id))
+}
+
+// sort sorts the ids in the graph's order. Elements without a recorded position(ex: loaded from existing storage)
+// sort after those with one, by type & id.
+func (o *ordering) sort(n int, id func(i int) TypedID, swap func(i, j int)) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	sort.Sort(orderedIDs{
+		n:    n,
+		swap: swap,
+		less: func(i, j int) bool {
+			a, b := id(i), id(j)
+			if o.order == InsertionOrder {
+				x, xok := o.positions[pathOf(a)]
+				y, yok := o.positions[pathOf(b)]
+				if xok != yok {
+					return xok
+				}
+				if xok {
+					return x < y
+				}
+			}
+			if a.Type() != b.Type() {
+				return a.Type() < b.Type()
+			}
+			return a.ID() < b.ID()
+		},
+	})
+}
+
+type orderedIDs struct {
+	n    int
+	less func(i, j int) bool
+	swap func(i, j int)
+}
+
+func (o orderedIDs) Len() int           { return o.n }
+func (o orderedIDs) Less(i, j int) bool { return o.less(i, j) }
+func (o orderedIDs) Swap(i, j int)      { o.swap(i, j) }
+
+// rangeOrderedNodes calls fn with each node collected by rangeFn in the graph's order until fn returns false
+func (g *Graph) rangeOrderedNodes(rangeFn func(fn func(n Node) bool), fn func(n Node) bool) {
+	var nodes []Node
+	rangeFn(func(n Node) bool {
+		nodes = append(nodes, n)
+		return true
+	})
+	g.ordering.sort(len(nodes), func(i int) TypedID { return nodes[i] }, func(i, j int) {
+		nodes[i], nodes[j] = nodes[j], nodes[i]
+	})
+	for _, n := range nodes {
+		if !fn(n) {
+			return
+		}
+	}
+}
+
+// rangeOrderedEdges calls fn with each edge collected by rangeFn in the graph's order until fn returns false
+func (g *Graph) rangeOrderedEdges(rangeFn func(fn func(e *Edge) bool), fn func(e *Edge) bool) {
+	var edges []*Edge
+	rangeFn(func(e *Edge) bool {
+		edges = append(edges, e)
+		return true
+	})
+	g.ordering.sort(len(edges), func(i int) TypedID { return edges[i] }, func(i, j int) {
+		edges[i], edges[j] = edges[j], edges[i]
+	})
+	for _, e := range edges {
+		if !fn(e) {
+			return
+		}
+	}
+}
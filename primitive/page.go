@@ -0,0 +1,43 @@
+package primitive
+
+import "sort"
+
+// NodesPage returns up to limit nodes of the given type in id order, starting after the cursor, along with the cursor
+// of the next page. Pass an empty cursor for the first page; the next cursor is empty once there are no more nodes.
+// Subtypes are not included. If limit is not positive, every remaining node is returned.
+func (g *Graph) NodesPage(nodeType string, cursor string, limit int) ([]Node, string) {
+	var nodes []Node
+	g.nodes.Range(nodeType, func(key string, val interface{}) bool {
+		if n, ok := val.(Node); ok && key > cursor {
+			nodes = append(nodes, n)
+		}
+		return true
+	})
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ID() < nodes[j].ID()
+	})
+	if limit <= 0 || len(nodes) <= limit {
+		return nodes, ""
+	}
+	return nodes[:limit], nodes[limit-1].ID()
+}
+
+// EdgesPage returns up to limit edges of the given type in id order, starting after the cursor, along with the cursor
+// of the next page. Pass an empty cursor for the first page; the next cursor is empty once there are no more edges.
+// Subtypes are not included. If limit is not positive, every remaining edge is returned.
+func (g *Graph) EdgesPage(edgeType string, cursor string, limit int) ([]*Edge, string) {
+	var edges []*Edge
+	g.edges.Range(edgeType, func(key string, val interface{}) bool {
+		if e, ok := val.(*Edge); ok && key > cursor {
+			edges = append(edges, e)
+		}
+		return true
+	})
+	sort.Slice(edges, func(i, j int) bool {
+		return edges[i].ID() < edges[j].ID()
+	})
+	if limit <= 0 || len(edges) <= limit {
+		return edges, ""
+	}
+	return edges[:limit], edges[limit-1].ID()
+}
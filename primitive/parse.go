@@ -25,6 +25,31 @@ func parseInt(obj interface{}) int {
 	}
 }
 
+func parseFloat(obj interface{}) float64 {
+	switch obj.(type) {
+	case string:
+		val, _ := strconv.ParseFloat(obj.(string), 64)
+		return val
+	case int:
+		return float64(obj.(int))
+	case int32:
+		return float64(obj.(int32))
+	case int64:
+		return float64(obj.(int64))
+	case float32:
+		return float64(obj.(float32))
+	case float64:
+		return obj.(float64)
+	case bool:
+		if obj.(bool) {
+			return 1
+		}
+		return 0
+	default:
+		return 0
+	}
+}
+
 func parseString(obj interface{}) string {
 	switch obj.(type) {
 	case string:
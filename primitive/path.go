@@ -0,0 +1,178 @@
+package primitive
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+)
+
+// ErrNoPath is returned when no path exists between two nodes
+var ErrNoPath = errors.New("dagger: no path between nodes")
+
+// ErrBudgetExhausted is returned by path searches that exhaust their TraversalOptions before finding a path. A path
+// may still exist beyond the budget.
+var ErrBudgetExhausted = errors.New("dagger: traversal budget exhausted")
+
+// ShortestPath returns the edges of a path from one node to another with the fewest hops over outgoing edges of the
+// given type(and its subtypes). Each node dequeued counts as a visit against the options' budget, & paths longer than
+// the max depth are not followed.
+func (g *Graph) ShortestPath(from, to TypedID, edgeType Type, opts TraversalOptions) ([]*Edge, error) {
+	start, end, err := g.pathEndpoints(from, to)
+	if err != nil {
+		return nil, err
+	}
+	b := NewTraversalBudget(opts)
+	type entry struct {
+		node  Node
+		depth int
+	}
+	via := map[string]*Edge{pathOf(start): nil}
+	queue := []entry{{node: start}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if !b.Visit() {
+			return nil, ErrBudgetExhausted
+		}
+		if pathOf(current.node) == pathOf(end) {
+			return tracePath(via, end), nil
+		}
+		expand := b.Expands(current.depth)
+		g.EdgesFrom(edgeType, current.node, func(e *Edge) bool {
+			key := pathOf(e.To)
+			if _, ok := via[key]; ok {
+				return true
+			}
+			if next, ok := g.GetNode(e.To); ok {
+				if !expand {
+					b.Truncate()
+					return false
+				}
+				via[key] = e
+				queue = append(queue, entry{node: next, depth: current.depth + 1})
+			}
+			return true
+		})
+	}
+	if b.Result().Truncated {
+		return nil, ErrBudgetExhausted
+	}
+	return nil, ErrNoPath
+}
+
+// ShortestWeightedPath returns the edges of the path from one node to another over outgoing edges of the given type
+// with the lowest total weight, where each edge's weight is read from its numeric weightAttr attribute(with Dijkstra's
+// algorithm), along with the path's total weight. Edges without the attribute weigh 0; negative weights are an error.
+// If weightAttr is empty, each edge's Weight is used. Each node settled counts as a visit against the options' budget,
+// & paths with more hops than the max depth are not followed.
+func (g *Graph) ShortestWeightedPath(from, to TypedID, edgeType Type, weightAttr string, opts TraversalOptions) ([]*Edge, float64, error) {
+	start, end, err := g.pathEndpoints(from, to)
+	if err != nil {
+		return nil, 0, err
+	}
+	b := NewTraversalBudget(opts)
+	via := map[string]*Edge{pathOf(start): nil}
+	dist := map[string]float64{pathOf(start): 0}
+	done := map[string]bool{}
+	queue := &pathQueue{{node: start}}
+	for queue.Len() > 0 {
+		current := heap.Pop(queue).(pathItem)
+		key := pathOf(current.node)
+		if done[key] {
+			continue
+		}
+		if !b.Visit() {
+			return nil, 0, ErrBudgetExhausted
+		}
+		done[key] = true
+		if key == pathOf(end) {
+			return tracePath(via, end), current.dist, nil
+		}
+		expand := b.Expands(current.hops)
+		g.EdgesFrom(edgeType, current.node, func(e *Edge) bool {
+			weight := e.Weight()
+			if weightAttr != "" {
+				weight = e.GetFloat(weightAttr)
+			}
+			if weight < 0 {
+				err = fmt.Errorf("edge %s.%s has a negative weight: %v", e.Type(), e.ID(), weight)
+				return false
+			}
+			next, ok := g.GetNode(e.To)
+			if !ok || done[pathOf(next)] {
+				return true
+			}
+			if !expand {
+				b.Truncate()
+				return true
+			}
+			d := current.dist + weight
+			if best, ok := dist[pathOf(next)]; !ok || d < best {
+				dist[pathOf(next)] = d
+				via[pathOf(next)] = e
+				heap.Push(queue, pathItem{node: next, dist: d, hops: current.hops + 1})
+			}
+			return true
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	if b.Result().Truncated {
+		return nil, 0, ErrBudgetExhausted
+	}
+	return nil, 0, ErrNoPath
+}
+
+func (g *Graph) pathEndpoints(from, to TypedID) (Node, Node, error) {
+	start, ok := g.GetNode(from)
+	if !ok {
+		return nil, nil, fmt.Errorf("node %s.%s does not exist", from.Type(), from.ID())
+	}
+	end, ok := g.GetNode(to)
+	if !ok {
+		return nil, nil, fmt.Errorf("node %s.%s does not exist", to.Type(), to.ID())
+	}
+	return start, end, nil
+}
+
+// tracePath walks the edges each node was reached through back to the start
+func tracePath(via map[string]*Edge, end Node) []*Edge {
+	path := []*Edge{}
+	for e := via[pathOf(end)]; e != nil; e = via[pathOf(e.From)] {
+		path = append([]*Edge{e}, path...)
+	}
+	return path
+}
+
+type pathItem struct {
+	node Node
+	dist float64
+	hops int
+}
+
+// pathQueue is a min heap of nodes by distance
+type pathQueue []pathItem
+
+func (q pathQueue) Len() int {
+	return len(q)
+}
+
+func (q pathQueue) Less(i, j int) bool {
+	return q[i].dist < q[j].dist
+}
+
+func (q pathQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
+
+func (q *pathQueue) Push(x interface{}) {
+	*q = append(*q, x.(pathItem))
+}
+
+func (q *pathQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,26 @@
+package primitive
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ErrReadOnly is returned by mutations while the graph is read-only
+var ErrReadOnly = errors.New("dagger: graph is read-only")
+
+// SetReadOnly freezes(or unfreezes) the graph. While frozen, every mutation returns ErrReadOnly.
+// Freezing waits for in-flight mutations to complete, so the graph does not change once SetReadOnly(true) returns.
+func (g *Graph) SetReadOnly(readOnly bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var flag int32
+	if readOnly {
+		flag = 1
+	}
+	atomic.StoreInt32(&g.readOnly, flag)
+}
+
+// ReadOnly returns true if the graph is frozen
+func (g *Graph) ReadOnly() bool {
+	return atomic.LoadInt32(&g.readOnly) == 1
+}
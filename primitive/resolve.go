@@ -0,0 +1,249 @@
+package primitive
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// MatchRule scores the similarity of two nodes from 0(different) to 1(identical)
+type MatchRule func(a, b Node) float64
+
+// NormalizedMatch scores 1 if the attribute is equal in both nodes after lower-casing and stripping punctuation & whitespace
+func NormalizedMatch(attr string) MatchRule {
+	return func(a, b Node) float64 {
+		x, y := normalize(a.GetString(attr)), normalize(b.GetString(attr))
+		if x != "" && x == y {
+			return 1
+		}
+		return 0
+	}
+}
+
+// PhoneticMatch scores 1 if the attribute sounds the same in both nodes(soundex)
+func PhoneticMatch(attr string) MatchRule {
+	return func(a, b Node) float64 {
+		x, y := soundex(a.GetString(attr)), soundex(b.GetString(attr))
+		if x != "" && x == y {
+			return 1
+		}
+		return 0
+	}
+}
+
+// AttributeOverlap scores the share of the given attributes that hold equal values in both nodes.
+// If no attributes are given, every attribute except the node's id & type is compared.
+func AttributeOverlap(attrs ...string) MatchRule {
+	return func(a, b Node) float64 {
+		keys := attrs
+		if len(keys) == 0 {
+			seen := map[string]bool{}
+			for _, n := range []Node{a, b} {
+				for k := range n {
+					if k == ID_KEY || k == TYPE_KEY || k == MERGED_KEY || seen[k] {
+						continue
+					}
+					seen[k] = true
+					keys = append(keys, k)
+				}
+			}
+		}
+		if len(keys) == 0 {
+			return 0
+		}
+		equal := 0
+		for _, k := range keys {
+			if a.Exists(k) && b.Exists(k) && a.GetString(k) == b.GetString(k) {
+				equal++
+			}
+		}
+		return float64(equal) / float64(len(keys))
+	}
+}
+
+// BlockKey returns the blocking key of a node. FindDuplicates only compares nodes that share a key, so a key that
+// duplicates always share(ex: the soundex of their name) avoids comparing every pair of nodes.
+type BlockKey func(n Node) string
+
+// SoundexKey blocks nodes on the soundex of the attribute, so nodes that PhoneticMatch or NormalizedMatch the
+// attribute always share a key
+func SoundexKey(attr string) BlockKey {
+	return func(n Node) string {
+		return soundex(n.GetString(attr))
+	}
+}
+
+// PrefixKey blocks nodes on the first length letters & digits of the attribute, lower-cased, so nodes that
+// NormalizedMatch the attribute always share a key
+func PrefixKey(attr string, length int) BlockKey {
+	return func(n Node) string {
+		key := normalize(n.GetString(attr))
+		if len(key) > length {
+			key = key[:length]
+		}
+		return key
+	}
+}
+
+// MergeProposal is a group of nodes that are likely the same entity
+type MergeProposal struct {
+	// Survivor is the node the duplicates should be merged into(the node with the most attributes)
+	Survivor Node `json:"survivor"`
+	// Duplicates are the nodes that should be merged into the survivor
+	Duplicates []Node `json:"duplicates"`
+	// Score is the lowest similarity score that linked the group together
+	Score float64 `json:"score"`
+}
+
+// FindDuplicates compares the pairs of nodes of the given type that share a blocking key, averaging the rules' scores,
+// and groups the nodes whose score meets the threshold into merge proposals for MergeNodes. A nil key compares every
+// pair of nodes, which takes time quadratic in their number; a key splits them into blocks that are compared
+// separately, so pick one that duplicates always share(ex: SoundexKey for PhoneticMatch).
+func (g *Graph) FindDuplicates(typ Type, threshold float64, key BlockKey, rules ...MatchRule) []*MergeProposal {
+	if len(rules) == 0 {
+		return nil
+	}
+	var nodes []Node
+	g.RangeNodeTypes(typ, func(n Node) bool {
+		nodes = append(nodes, n)
+		return true
+	})
+	sort.Slice(nodes, func(i, j int) bool {
+		return pathOf(nodes[i]) < pathOf(nodes[j])
+	})
+	parents := make([]int, len(nodes))
+	scores := make([]float64, len(nodes))
+	for i := range parents {
+		parents[i] = i
+		scores[i] = 1
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		for parents[i] != i {
+			parents[i] = parents[parents[i]]
+			i = parents[i]
+		}
+		return i
+	}
+	blocks := map[string][]int{}
+	var keys []string
+	for i, n := range nodes {
+		k := ""
+		if key != nil {
+			k = key(n)
+		}
+		if _, ok := blocks[k]; !ok {
+			keys = append(keys, k)
+		}
+		blocks[k] = append(blocks[k], i)
+	}
+	for _, k := range keys {
+		block := blocks[k]
+		for x := 0; x < len(block); x++ {
+			for y := x + 1; y < len(block); y++ {
+				i, j := block[x], block[y]
+				total := 0.0
+				for _, rule := range rules {
+					total += rule(nodes[i], nodes[j])
+				}
+				score := total / float64(len(rules))
+				if score < threshold {
+					continue
+				}
+				a, b := find(i), find(j)
+				low := score
+				for _, s := range []float64{scores[a], scores[b]} {
+					if s < low {
+						low = s
+					}
+				}
+				parents[a] = b
+				scores[b] = low
+			}
+		}
+	}
+	groups := map[int][]Node{}
+	var roots []int
+	for i, n := range nodes {
+		root := find(i)
+		if _, ok := groups[root]; !ok {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], n)
+	}
+	var proposals []*MergeProposal
+	for _, root := range roots {
+		group := groups[root]
+		if len(group) < 2 {
+			continue
+		}
+		sort.SliceStable(group, func(i, j int) bool {
+			return len(group[i]) > len(group[j])
+		})
+		proposals = append(proposals, &MergeProposal{
+			Survivor:   group[0],
+			Duplicates: group[1:],
+			Score:      scores[root],
+		})
+	}
+	return proposals
+}
+
+// ApplyProposal merges the proposal's duplicates into its survivor with MergeNodes
+func (g *Graph) ApplyProposal(proposal *MergeProposal, strategy MergeStrategy) error {
+	var duplicates []TypedID
+	for _, d := range proposal.Duplicates {
+		duplicates = append(duplicates, d)
+	}
+	return g.MergeNodes(proposal.Survivor, strategy, duplicates...)
+}
+
+func normalize(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func soundex(s string) string {
+	codes := map[rune]byte{
+		'b': '1', 'f': '1', 'p': '1', 'v': '1',
+		'c': '2', 'g': '2', 'j': '2', 'k': '2', 'q': '2', 's': '2', 'x': '2', 'z': '2',
+		'd': '3', 't': '3',
+		'l': '4',
+		'm': '5', 'n': '5',
+		'r': '6',
+	}
+	var out []byte
+	var last byte
+	for _, r := range strings.ToLower(s) {
+		if r > unicode.MaxASCII || !unicode.IsLetter(r) {
+			continue
+		}
+		code := codes[r]
+		if len(out) == 0 {
+			out = append(out, byte(unicode.ToUpper(r)))
+			last = code
+			continue
+		}
+		if code != 0 && code != last {
+			out = append(out, code)
+		}
+		if r != 'h' && r != 'w' {
+			last = code
+		}
+		if len(out) == 4 {
+			break
+		}
+	}
+	if len(out) == 0 {
+		return ""
+	}
+	for len(out) < 4 {
+		out = append(out, '0')
+	}
+	return string(out)
+}
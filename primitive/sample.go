@@ -0,0 +1,308 @@
+package primitive
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"sort"
+)
+
+// SampleLayer holds the edges sampled for a single hop as parallel index arrays into NeighborhoodSample.Nodes.
+// Src[i] is the sampled neighbor and Dst[i] is the node it was sampled for.
+type SampleLayer struct {
+	Src []int `json:"src"`
+	Dst []int `json:"dst"`
+}
+
+// NeighborhoodSample is a mini-batch subgraph of layered neighbor samples.
+// The seed nodes always occupy the first len(seeds) positions of Nodes.
+type NeighborhoodSample struct {
+	// Nodes are the unique nodes in the batch. A node's position is its row in Features.
+	Nodes []Node `json:"nodes"`
+	// Layers holds one entry per fanout, ordered from the seeds outward
+	Layers []SampleLayer `json:"layers"`
+	// Features holds one row per node with the values of the requested feature attributes
+	Features [][]float64 `json:"features"`
+}
+
+// SampleNeighborhoods samples up to fanouts[i] outgoing neighbors of every node on hop i, starting from the seeds. A
+// negative fanout samples every neighbor. The values of the given feature attributes are collected into the sample's
+// feature matrix. The sample's nodes are copies, so they may be modified without affecting the graph.
+func (g *Graph) SampleNeighborhoods(seeds []TypedID, fanouts []int, features ...string) *NeighborhoodSample {
+	sample := &NeighborhoodSample{}
+	index := map[string]int{}
+	add := func(n Node) int {
+		key := pathOf(n)
+		if i, ok := index[key]; ok {
+			return i
+		}
+		index[key] = len(sample.Nodes)
+		sample.Nodes = append(sample.Nodes, n.Copy())
+		return index[key]
+	}
+	var frontier []int
+	for _, id := range seeds {
+		n, ok := g.GetNode(id)
+		if !ok {
+			continue
+		}
+		frontier = append(frontier, add(n))
+	}
+	for _, fanout := range fanouts {
+		layer := SampleLayer{}
+		var next []int
+		for _, dst := range frontier {
+			var neighbors []Node
+			g.EdgesFrom(anyType{}, sample.Nodes[dst], func(e *Edge) bool {
+				if n, ok := g.GetNode(e.To); ok {
+					neighbors = append(neighbors, n)
+				}
+				return true
+			})
+			rand.Shuffle(len(neighbors), func(i, j int) {
+				neighbors[i], neighbors[j] = neighbors[j], neighbors[i]
+			})
+			if fanout >= 0 && len(neighbors) > fanout {
+				neighbors = neighbors[:fanout]
+			}
+			for _, n := range neighbors {
+				before := len(sample.Nodes)
+				src := add(n)
+				if src == before {
+					next = append(next, src)
+				}
+				layer.Src = append(layer.Src, src)
+				layer.Dst = append(layer.Dst, dst)
+			}
+		}
+		sample.Layers = append(sample.Layers, layer)
+		frontier = next
+	}
+	for _, n := range sample.Nodes {
+		row := make([]float64, len(features))
+		for i, f := range features {
+			row[i] = n.GetFloat(f)
+		}
+		sample.Features = append(sample.Features, row)
+	}
+	return sample
+}
+
+// SampleNodes returns a uniform random sample of up to n nodes of the given type, gathered in a single pass with
+// reservoir sampling so the namespace is never materialized
+func (g *Graph) SampleNodes(typ Type, n int) []Node {
+	var nodes []Node
+	r := newReservoir(n)
+	g.RangeNodeTypes(typ, func(node Node) bool {
+		if i := r.offer(); i >= 0 {
+			if i == len(nodes) {
+				nodes = append(nodes, node)
+			} else {
+				nodes[i] = node
+			}
+		}
+		return true
+	})
+	return nodes
+}
+
+// reservoir implements reservoir sampling(algorithm R) over a stream of unknown length
+type reservoir struct {
+	size int
+	seen int
+}
+
+func newReservoir(size int) *reservoir {
+	return &reservoir{size: size}
+}
+
+// offer registers the next element of the stream, returning the slot it should be stored in or -1 if it is skipped
+func (r *reservoir) offer() int {
+	r.seen++
+	if r.seen <= r.size {
+		return r.seen - 1
+	}
+	if i := rand.Intn(r.seen); i < r.size {
+		return i
+	}
+	return -1
+}
+
+// EdgeSample is a labelled set of edges for link prediction.
+type EdgeSample struct {
+	// Positives are edges that exist in the graph
+	Positives []*Edge `json:"positives"`
+	// Negatives are edges that do not exist in the graph(nor as an edge of a subtype of their type) but that its schemas
+	// & edge constraints would allow. Each negative shares the edge type and endpoint node types of a positive edge.
+	Negatives []*Edge `json:"negatives"`
+}
+
+type edgeSampleConfig struct {
+	edgeType Type
+	split    string
+	ratios   map[string]float64
+}
+
+// EdgeSampleOption configures SampleEdges
+type EdgeSampleOption func(c *edgeSampleConfig)
+
+// SampleEdgeType restricts sampled edges to the given edge type & its declared subtypes
+func SampleEdgeType(typ Type) EdgeSampleOption {
+	return func(c *edgeSampleConfig) {
+		c.edgeType = typ
+	}
+}
+
+// SampleSplit restricts sampled edges to a single split of the graph. ratios maps each split name to its share of the
+// edges(ex: train: 0.8, test: 0.2). An edge is always assigned to the same split, and both directions of a
+// connection land in the same split so they never leak across splits.
+func SampleSplit(split string, ratios map[string]float64) EdgeSampleOption {
+	return func(c *edgeSampleConfig) {
+		c.split = split
+		c.ratios = ratios
+	}
+}
+
+// SampleEdges samples up to n edges uniformly from the graph in a single pass with reservoir sampling. If withNegatives is true, a negative edge is generated
+// for every positive edge by swapping its target for a node of the same type that it is not connected to. The sampled
+// edges are copies, so they may be modified without affecting the graph.
+func (g *Graph) SampleEdges(n int, withNegatives bool, opts ...EdgeSampleOption) *EdgeSample {
+	c := &edgeSampleConfig{
+		edgeType: anyType{},
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	sample := &EdgeSample{}
+	r := newReservoir(n)
+	g.rangeEdgeTypes(c.edgeType, func(e *Edge) bool {
+		if c.split != "" && edgeSplit(e, c.ratios) != c.split {
+			return true
+		}
+		if i := r.offer(); i >= 0 {
+			if i == len(sample.Positives) {
+				sample.Positives = append(sample.Positives, e)
+			} else {
+				sample.Positives[i] = e
+			}
+		}
+		return true
+	})
+	for i, e := range sample.Positives {
+		sample.Positives[i] = &Edge{Node: e.Node.Copy(), From: g.endpoint(e.From).Copy(), To: g.endpoint(e.To).Copy()}
+	}
+	if !withNegatives {
+		return sample
+	}
+	candidates := map[string][]Node{}
+	sampled := map[string]bool{}
+	for _, e := range sample.Positives {
+		typ := e.To.Type()
+		if _, ok := candidates[typ]; !ok {
+			g.RangeNodeTypes(e.To, func(n Node) bool {
+				candidates[typ] = append(candidates[typ], n)
+				return true
+			})
+		}
+		nodes := candidates[typ]
+		for attempt := 0; attempt < 10 && len(nodes) > 0; attempt++ {
+			to := nodes[rand.Intn(len(nodes))]
+			if to.ID() == e.From.ID() && to.Type() == e.From.Type() {
+				continue
+			}
+			key := e.Type() + "|" + pathOf(e.From) + "|" + pathOf(to)
+			if sampled[key] || g.connected(e, e.From, to) {
+				continue
+			}
+			negative := &Edge{
+				Node: Node{TYPE_KEY: e.Type()},
+				From: e.From.Copy(),
+				To:   to.Copy(),
+			}
+			if g.validateEdge(negative) != nil {
+				continue
+			}
+			sampled[key] = true
+			sample.Negatives = append(sample.Negatives, negative)
+			break
+		}
+	}
+	return sample
+}
+
+func (g *Graph) connected(edgeType Type, from, to TypedID) bool {
+	found := false
+	g.EdgesFrom(edgeType, from, func(e *Edge) bool {
+		if e.To.ID() == to.ID() && e.To.Type() == to.Type() {
+			found = true
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+func edgeSplit(e *Edge, ratios map[string]float64) string {
+	endpoints := []string{
+		e.From.Type() + "." + e.From.ID(),
+		e.To.Type() + "." + e.To.ID(),
+	}
+	sort.Strings(endpoints)
+	h := fnv.New64a()
+	h.Write([]byte(e.Type() + "|" + endpoints[0] + "|" + endpoints[1]))
+	point := float64(h.Sum64()%10000) / 10000
+
+	var names []string
+	total := 0.0
+	for name, ratio := range ratios {
+		names = append(names, name)
+		total += ratio
+	}
+	sort.Strings(names)
+	cumulative := 0.0
+	for _, name := range names {
+		cumulative += ratios[name] / total
+		if point < cumulative {
+			return name
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return names[len(names)-1]
+}
+
+type anyType struct{}
+
+func (anyType) Type() string {
+	return AnyType
+}
+
+// RandomNeighbor picks a node that the given node points to over edges of the given type, with probability
+// proportional to the edge's weight attribute. Edges without a positive weight are never picked.
+// If weightAttr is empty, every neighbor is equally likely.
+func (g *Graph) RandomNeighbor(id TypedID, edgeType Type, weightAttr string) (Node, bool) {
+	var (
+		picked Node
+		total  float64
+	)
+	g.EdgesFrom(edgeType, id, func(e *Edge) bool {
+		weight := 1.0
+		if weightAttr != "" {
+			weight = e.GetFloat(weightAttr)
+		}
+		if weight <= 0 {
+			return true
+		}
+		to, ok := g.GetNode(e.To)
+		if !ok {
+			return true
+		}
+		// weighted reservoir sampling: replace the pick with probability weight/total
+		total += weight
+		if rand.Float64()*total < weight {
+			picked = to
+		}
+		return true
+	})
+	return picked, picked != nil
+}
@@ -0,0 +1,171 @@
+package primitive
+
+import (
+	"sort"
+)
+
+// AttributeSchema describes an attribute observed on a node or edge type
+type AttributeSchema struct {
+	// Kinds are the value kinds observed for the attribute(string, int, float, bool, object, array)
+	Kinds []string `json:"kinds"`
+	// Required is true if every element of the type has the attribute
+	Required bool `json:"required"`
+	// Count is the number of elements of the type that have the attribute
+	Count int `json:"count"`
+}
+
+// TypeSchema describes a node type
+type TypeSchema struct {
+	Count      int                         `json:"count"`
+	Attributes map[string]*AttributeSchema `json:"attributes"`
+}
+
+// EdgeEndpoints describes a combination of endpoint node types observed for an edge type
+type EdgeEndpoints struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Count int    `json:"count"`
+	// MaxOut is the highest number of these edges stemming from a single node
+	MaxOut int `json:"max_out"`
+	// MaxIn is the highest number of these edges pointing to a single node
+	MaxIn int `json:"max_in"`
+	// Cardinality is one of one-to-one, one-to-many, many-to-one or many-to-many
+	Cardinality string `json:"cardinality"`
+}
+
+// EdgeSchema describes an edge type
+type EdgeSchema struct {
+	Count      int                         `json:"count"`
+	Attributes map[string]*AttributeSchema `json:"attributes"`
+	Endpoints  []*EdgeEndpoints            `json:"endpoints"`
+}
+
+// InferredSchema is a schema derived from the data in the graph
+type InferredSchema struct {
+	Nodes map[string]*TypeSchema `json:"nodes"`
+	Edges map[string]*EdgeSchema `json:"edges"`
+}
+
+// InferSchema scans the graph and describes every node & edge type: their attributes and value kinds, and the endpoint
+// types and cardinalities of each edge type
+func (g *Graph) InferSchema() *InferredSchema {
+	schema := &InferredSchema{
+		Nodes: map[string]*TypeSchema{},
+		Edges: map[string]*EdgeSchema{},
+	}
+	kinds := map[*AttributeSchema]map[string]bool{}
+	observe := func(attributes map[string]*AttributeSchema, n Node) {
+		for k, v := range n {
+			if k == ID_KEY || k == TYPE_KEY || v == nil {
+				continue
+			}
+			attr, ok := attributes[k]
+			if !ok {
+				attr = &AttributeSchema{}
+				attributes[k] = attr
+				kinds[attr] = map[string]bool{}
+			}
+			attr.Count++
+			kinds[attr][valueKind(v)] = true
+		}
+	}
+	g.RangeNodes(func(n Node) bool {
+		typ, ok := schema.Nodes[n.Type()]
+		if !ok {
+			typ = &TypeSchema{Attributes: map[string]*AttributeSchema{}}
+			schema.Nodes[n.Type()] = typ
+		}
+		typ.Count++
+		observe(typ.Attributes, n)
+		return true
+	})
+	type endpointKey struct {
+		edgeType, from, to string
+	}
+	endpoints := map[endpointKey]*EdgeEndpoints{}
+	out := map[endpointKey]map[string]int{}
+	in := map[endpointKey]map[string]int{}
+	g.RangeEdges(func(e *Edge) bool {
+		typ, ok := schema.Edges[e.Type()]
+		if !ok {
+			typ = &EdgeSchema{Attributes: map[string]*AttributeSchema{}}
+			schema.Edges[e.Type()] = typ
+		}
+		typ.Count++
+		observe(typ.Attributes, e.Node)
+		key := endpointKey{e.Type(), e.From.Type(), e.To.Type()}
+		ep, ok := endpoints[key]
+		if !ok {
+			ep = &EdgeEndpoints{From: key.from, To: key.to}
+			endpoints[key] = ep
+			out[key] = map[string]int{}
+			in[key] = map[string]int{}
+			typ.Endpoints = append(typ.Endpoints, ep)
+		}
+		ep.Count++
+		out[key][e.From.ID()]++
+		in[key][e.To.ID()]++
+		if out[key][e.From.ID()] > ep.MaxOut {
+			ep.MaxOut = out[key][e.From.ID()]
+		}
+		if in[key][e.To.ID()] > ep.MaxIn {
+			ep.MaxIn = in[key][e.To.ID()]
+		}
+		return true
+	})
+	for _, ep := range endpoints {
+		from, to := "one", "one"
+		if ep.MaxIn > 1 {
+			from = "many"
+		}
+		if ep.MaxOut > 1 {
+			to = "many"
+		}
+		ep.Cardinality = from + "-to-" + to
+	}
+	finish := func(count int, attributes map[string]*AttributeSchema) {
+		for _, attr := range attributes {
+			attr.Required = attr.Count == count
+			for kind := range kinds[attr] {
+				attr.Kinds = append(attr.Kinds, kind)
+			}
+			sort.Strings(attr.Kinds)
+		}
+	}
+	for _, typ := range schema.Nodes {
+		finish(typ.Count, typ.Attributes)
+	}
+	for _, typ := range schema.Edges {
+		finish(typ.Count, typ.Attributes)
+		sort.Slice(typ.Endpoints, func(i, j int) bool {
+			if typ.Endpoints[i].From != typ.Endpoints[j].From {
+				return typ.Endpoints[i].From < typ.Endpoints[j].From
+			}
+			return typ.Endpoints[i].To < typ.Endpoints[j].To
+		})
+	}
+	return schema
+}
+
+func valueKind(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return "int"
+	case float32:
+		return "float"
+	case float64:
+		// numbers decoded from JSON are always float64
+		if f := v.(float64); f == float64(int64(f)) {
+			return "int"
+		}
+		return "float"
+	case bool:
+		return "bool"
+	case []interface{}, []string, []int, []float64:
+		return "array"
+	default:
+		return "object"
+	}
+}
@@ -0,0 +1,31 @@
+package primitive
+
+import "sync/atomic"
+
+// Snapshot returns a read only, in-memory copy of the graph as of the moment it is taken. It is a full copy(see Clone),
+// not copy-on-write: taking it costs time & memory proportional to the number of nodes & edges, and every writer is
+// blocked until the copy is complete. Once taken, the snapshot never observes a half-applied mutation and may be read
+// for as long as needed without blocking writers, so take one per long running read rather than per request.
+func (g *Graph) Snapshot() *Graph {
+	snapshot := g.Clone()
+	atomic.StoreInt32(&snapshot.readOnly, 1)
+	return snapshot
+}
+
+// Clone returns an independent, in-memory deep copy of the graph's nodes, edges & type hierarchies. Attribute maps are
+// copied, so mutations on the clone never leak into the graph(or vice versa). The clone has no storage backend, WAL,
+// hooks or indexes of its own.
+func (g *Graph) Clone() *Graph {
+	exp := g.Export()
+	clone := NewGraph(WithOrder(g.ordering.order))
+	clone.edgeTypes = g.edgeTypes.copy()
+	clone.nodeTypes = g.nodeTypes.copy()
+	for _, n := range exp.Nodes {
+		clone.addNode(n)
+	}
+	for _, e := range exp.Edges {
+		clone.addEdge(e)
+	}
+	clone.ClearDirty()
+	return clone
+}
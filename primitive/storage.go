@@ -0,0 +1,96 @@
+package primitive
+
+import (
+	"strings"
+)
+
+// Storage is a namespaced key value store that holds a graph's nodes, edges & edge indexes. Values are Nodes, *Edges
+// or the graph's internal edge index maps. Implementations must be concurrency safe. The default storage is in-memory;
+// see DiskStorage for a durable alternative.
+type Storage interface {
+	// Get returns the value stored under the key in the namespace
+	Get(namespace string, key string) (interface{}, bool)
+	// Set stores the value under the key in the namespace
+	Set(namespace string, key string, value interface{})
+	// Delete removes the key from the namespace
+	Delete(namespace string, key string)
+	// Range iterates over the keys & values in the namespace until fn returns false
+	Range(namespace string, fn func(key string, value interface{}) bool)
+	// Namespaces returns the namespaces in the store
+	Namespaces() []string
+	// Close releases the store's resources
+	Close() error
+}
+
+// NewMemoryStorage returns the default, in-memory storage
+func NewMemoryStorage() Storage {
+	return newCache()
+}
+
+// GraphOption configures a Graph
+type GraphOption func(g *Graph)
+
+// WithStorage keeps the graph's nodes, edges & edge indexes in the storage. A graph opened on storage that already
+// holds a graph picks up where it left off.
+func WithStorage(s Storage) GraphOption {
+	return func(g *Graph) {
+		g.storage = s
+		g.nodes = prefixedStorage{s, "nodes/"}
+		g.edges = prefixedStorage{s, "edges/"}
+		g.edgesFrom = prefixedStorage{s, "from/"}
+		g.edgesTo = prefixedStorage{s, "to/"}
+	}
+}
+
+// prefixedStorage is a view of the namespaces of a storage that share a prefix
+type prefixedStorage struct {
+	Storage
+	prefix string
+}
+
+func (p prefixedStorage) Get(namespace string, key string) (interface{}, bool) {
+	return p.Storage.Get(p.prefix+namespace, key)
+}
+
+func (p prefixedStorage) Set(namespace string, key string, value interface{}) {
+	p.Storage.Set(p.prefix+namespace, key, value)
+}
+
+func (p prefixedStorage) Delete(namespace string, key string) {
+	p.Storage.Delete(p.prefix+namespace, key)
+}
+
+func (p prefixedStorage) Range(namespace string, fn func(key string, value interface{}) bool) {
+	if namespace == AnyType {
+		for _, ns := range p.Namespaces() {
+			stopped := false
+			p.Storage.Range(p.prefix+ns, func(key string, value interface{}) bool {
+				if !fn(key, value) {
+					stopped = true
+					return false
+				}
+				return true
+			})
+			if stopped {
+				return
+			}
+		}
+		return
+	}
+	p.Storage.Range(p.prefix+namespace, fn)
+}
+
+func (p prefixedStorage) Namespaces() []string {
+	var namespaces []string
+	for _, ns := range p.Storage.Namespaces() {
+		if strings.HasPrefix(ns, p.prefix) {
+			namespaces = append(namespaces, strings.TrimPrefix(ns, p.prefix))
+		}
+	}
+	return namespaces
+}
+
+// Close is a no-op: the graph closes the underlying storage once
+func (p prefixedStorage) Close() error {
+	return nil
+}
@@ -0,0 +1,102 @@
+package primitive
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// stripeCount is the number of locks the nodes & edges of a graph are spread over for writers that change only a few
+// of them
+const stripeCount = 64
+
+// stripe locks the nodes & edges whose type.id hashes to it, & holds the hook events recorded for them until the
+// writer holding it releases it
+type stripe struct {
+	mu     sync.RWMutex
+	events []hookEvent
+}
+
+// eventSeq orders the hook events recorded by writers holding different stripes
+var eventSeq uint64
+
+// stripeOf returns the index of the stripe the type.id path belongs to using its FNV-1a hash
+func stripeOf(path string) int {
+	hash := uint32(2166136261)
+	for i := 0; i < len(path); i++ {
+		hash ^= uint32(path[i])
+		hash *= 16777619
+	}
+	return int(hash % stripeCount)
+}
+
+// lockPaths is the lock taken by writers that only change the nodes & edges with the given type.id paths(and the edge
+// indexes of those nodes). It shares mu with other such writers & locks the paths' stripes, so writers touching
+// different stripes run in parallel. Writers that change many elements(deletes, imports, merges...) take lock
+// instead, which excludes every other writer. The returned function releases the locks, then runs the hooks for the
+// changes made while they were held.
+func (g *Graph) lockPaths(paths ...string) func() {
+	var indexes []int
+	seen := map[int]bool{}
+	for _, p := range paths {
+		if i := stripeOf(p); !seen[i] {
+			seen[i] = true
+			indexes = append(indexes, i)
+		}
+	}
+	// stripes are always locked in ascending order, so writers locking several stripes cannot deadlock
+	sort.Ints(indexes)
+	var start time.Time
+	sink, timed := g.sink().(LockWaitSink)
+	if timed {
+		start = time.Now()
+	}
+	g.mu.RLock()
+	for _, i := range indexes {
+		g.stripes[i].mu.Lock()
+	}
+	if timed {
+		sink.LockWait(time.Since(start))
+	}
+	return func() {
+		var events []hookEvent
+		for _, i := range indexes {
+			events = append(events, g.stripes[i].events...)
+			g.stripes[i].events = nil
+			g.stripes[i].mu.Unlock()
+		}
+		g.mu.RUnlock()
+		sort.Slice(events, func(i, j int) bool {
+			return events[i].seq < events[j].seq
+		})
+		g.hooks.fire(events)
+	}
+}
+
+// rlock is the lock taken by readers that need a consistent view of the graph: it excludes every writer, but not
+// other readers. The returned function releases it.
+func (g *Graph) rlock() func() {
+	g.mu.RLock()
+	for i := range g.stripes {
+		g.stripes[i].mu.RLock()
+	}
+	return func() {
+		for i := range g.stripes {
+			g.stripes[i].mu.RUnlock()
+		}
+		g.mu.RUnlock()
+	}
+}
+
+// record records a change for the hooks. Writers holding lock record it in order on the graph; writers holding
+// lockPaths record it on the stripe of the changed element, which only they hold.
+func (g *Graph) record(path string, event hookEvent) {
+	event.seq = atomic.AddUint64(&eventSeq, 1)
+	if g.exclusive {
+		g.events = append(g.events, event)
+		return
+	}
+	s := &g.stripes[stripeOf(path)]
+	s.events = append(s.events, event)
+}
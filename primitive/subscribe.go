@@ -0,0 +1,169 @@
+package primitive
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ChangeOp is the kind of mutation a Change describes
+type ChangeOp string
+
+const (
+	ChangeNodeAdded   ChangeOp = "node_added"
+	ChangeNodePatched ChangeOp = "node_patched"
+	ChangeNodeDeleted ChangeOp = "node_deleted"
+	ChangeEdgeAdded   ChangeOp = "edge_added"
+	ChangeEdgePatched ChangeOp = "edge_patched"
+	ChangeEdgeDeleted ChangeOp = "edge_deleted"
+	// ChangeNodeExpired precedes the ChangeNodeDeleted of a node removed because its TTL ran out
+	ChangeNodeExpired ChangeOp = "node_expired"
+	// ChangeEdgeExpired precedes the ChangeEdgeDeleted of an edge removed because its TTL ran out
+	ChangeEdgeExpired ChangeOp = "edge_expired"
+	// ChangeAlert is an alert fired by an alerter watching the graph. It has no type, so it is only received by
+	// subscribers that do not filter on Types.
+	ChangeAlert ChangeOp = "alert"
+)
+
+var changeOps = map[int]ChangeOp{
+	nodeAdded:   ChangeNodeAdded,
+	nodePatched: ChangeNodePatched,
+	nodeDeleted: ChangeNodeDeleted,
+	edgeAdded:   ChangeEdgeAdded,
+	edgePatched: ChangeEdgePatched,
+	edgeDeleted: ChangeEdgeDeleted,
+	nodeExpired: ChangeNodeExpired,
+	edgeExpired: ChangeEdgeExpired,
+}
+
+// Change is a mutation of the graph. Node is set for node changes & Edge is set for edge changes; both are copies taken
+// when the change was made. Alert is set for ChangeAlert.
+type Change struct {
+	Op    ChangeOp `json:"op"`
+	Node  Node     `json:"node,omitempty"`
+	Edge  *Edge    `json:"edge,omitempty"`
+	Alert *Alert   `json:"alert,omitempty"`
+}
+
+// Alert is fired when an alert rule's threshold is crossed
+type Alert struct {
+	Rule      string    `json:"rule"`
+	Value     float64   `json:"value"`
+	Threshold float64   `json:"threshold"`
+	Time      time.Time `json:"time"`
+}
+
+// PublishAlert sends the alert to the graph's subscribers as a ChangeAlert
+func (g *Graph) PublishAlert(a Alert) {
+	g.hooks.publish(Change{Op: ChangeAlert, Alert: &a})
+}
+
+// ChangeFilter selects the changes a subscriber receives. Empty fields match everything.
+type ChangeFilter struct {
+	// Ops are the kinds of changes to receive
+	Ops []ChangeOp
+	// Types are the node or edge types to receive changes for
+	Types []string
+}
+
+func (f ChangeFilter) match(c Change) bool {
+	if len(f.Ops) > 0 {
+		matched := false
+		for _, op := range f.Ops {
+			if op == c.Op {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(f.Types) > 0 {
+		typ := ""
+		if c.Edge != nil {
+			typ = c.Edge.Type()
+		} else if c.Node != nil {
+			typ = c.Node.Type()
+		}
+		return contains(f.Types, typ)
+	}
+	return true
+}
+
+type subscriber struct {
+	ctx    context.Context
+	filter ChangeFilter
+	ch     chan Change
+	mu     sync.Mutex
+	closed bool
+}
+
+func (s *subscriber) send(c Change) {
+	if !s.filter.match(c) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.ch <- c:
+	case <-s.ctx.Done():
+	}
+}
+
+// Subscribe returns a channel of the changes made to the graph that pass the filter. The
+// channel is closed once the context is done. Changes are delivered after each mutation returns its lock, but a
+// subscriber that stops receiving blocks writers until its context is done, so cancel the context when finished.
+func (g *Graph) Subscribe(ctx context.Context, filter ChangeFilter) (<-chan Change, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s := &subscriber{
+		ctx:    ctx,
+		filter: filter,
+		ch:     make(chan Change, 64),
+	}
+	g.hooks.subscribe(s)
+	go func() {
+		<-ctx.Done()
+		g.hooks.unsubscribe(s)
+		s.mu.Lock()
+		s.closed = true
+		close(s.ch)
+		s.mu.Unlock()
+	}()
+	return s.ch, nil
+}
+
+// publish sends the change to every subscriber whose filter it passes
+func (h *hooks) publish(c Change) {
+	h.mu.RLock()
+	subscribers := h.subscribers
+	h.mu.RUnlock()
+	for _, s := range subscribers {
+		s.send(c)
+	}
+}
+
+func (h *hooks) subscribe(s *subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subscribers = append(h.subscribers, s)
+	atomic.AddInt32(&h.count, 1)
+}
+
+func (h *hooks) unsubscribe(s *subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, sub := range h.subscribers {
+		if sub == s {
+			h.subscribers = append(h.subscribers[:i:i], h.subscribers[i+1:]...)
+			atomic.AddInt32(&h.count, -1)
+			return
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package primitive
+
+import (
+	"time"
+)
+
+// TraversalOptions bounds how much of the graph a traversal may expand. Zero values are unlimited.
+type TraversalOptions struct {
+	// MaxDepth is the max number of hops from the start node
+	MaxDepth int `json:"max_depth"`
+	// MaxVisited is the max number of nodes visited
+	MaxVisited int `json:"max_visited"`
+	// Deadline is the time after which no more nodes are visited
+	Deadline time.Time `json:"deadline"`
+}
+
+// TraversalResult reports how a traversal ended
+type TraversalResult struct {
+	// Visited is the number of nodes passed to the traversal's callback
+	Visited int `json:"visited"`
+	// Truncated is true if the traversal stopped early because it exhausted its budget. Results gathered by
+	// a truncated traversal are partial.
+	Truncated bool `json:"truncated"`
+}
+
+// TraversalBudget tracks a traversal's use of its TraversalOptions. The graph's traversals(BFS, DFS, ShortestPath...)
+// use one internally; traversals built on top of the graph(ex: queries) use one to honor the same options.
+type TraversalBudget struct {
+	opts   TraversalOptions
+	result TraversalResult
+}
+
+// NewTraversalBudget returns a budget enforcing the options
+func NewTraversalBudget(opts TraversalOptions) *TraversalBudget {
+	return &TraversalBudget{opts: opts}
+}
+
+// Visit reserves a visit, returning false(and truncating) if the budget is exhausted
+func (b *TraversalBudget) Visit() bool {
+	if b.opts.MaxVisited > 0 && b.result.Visited >= b.opts.MaxVisited {
+		b.result.Truncated = true
+		return false
+	}
+	if !b.opts.Deadline.IsZero() && time.Now().After(b.opts.Deadline) {
+		b.result.Truncated = true
+		return false
+	}
+	b.result.Visited++
+	return true
+}
+
+// Expands returns true if nodes at the depth may be expanded
+func (b *TraversalBudget) Expands(depth int) bool {
+	return b.opts.MaxDepth <= 0 || depth < b.opts.MaxDepth
+}
+
+// Truncate records that the traversal left out part of the graph it would otherwise have visited
+func (b *TraversalBudget) Truncate() {
+	b.result.Truncated = true
+}
+
+// Result reports how the traversal has used the budget so far
+func (b *TraversalBudget) Result() TraversalResult {
+	return b.result
+}
+
+// BFS visits the nodes reachable from start over outgoing edges of the given type level by level, passing each node &
+// its depth(the start node is depth 0) to fn. Each node is visited once. The traversal stops when fn returns false or
+// the options' budget is exhausted.
+func (g *Graph) BFS(start TypedID, edgeType Type, opts TraversalOptions, fn func(n Node, depth int) bool) TraversalResult {
+	defer g.timing(MetricTraversal, "bfs", time.Now())
+	b := NewTraversalBudget(opts)
+	n, ok := g.GetNode(start)
+	if !ok {
+		return b.result
+	}
+	type entry struct {
+		node  Node
+		depth int
+	}
+	visited := map[string]bool{pathOf(n): true}
+	queue := []entry{{node: n}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if !b.Visit() {
+			return b.result
+		}
+		if !fn(current.node, current.depth) {
+			return b.result
+		}
+		expand := b.Expands(current.depth)
+		g.EdgesFrom(edgeType, current.node, func(e *Edge) bool {
+			key := pathOf(e.To)
+			if visited[key] {
+				return true
+			}
+			to, ok := g.GetNode(e.To)
+			if !ok {
+				return true
+			}
+			if !expand {
+				// there is more of the graph beyond the max depth
+				b.result.Truncated = true
+				return false
+			}
+			visited[key] = true
+			queue = append(queue, entry{node: to, depth: current.depth + 1})
+			return true
+		})
+	}
+	return b.result
+}
@@ -0,0 +1,217 @@
+package primitive
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// EXPIRES_KEY is the attribute that holds the RFC3339 time a node or edge expires at
+const EXPIRES_KEY = "_expires"
+
+// ExpiresAt returns the time the node expires, or false if it does not expire
+func (n Node) ExpiresAt() (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339Nano, n.GetString(EXPIRES_KEY))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func (n Node) expired(now time.Time) bool {
+	t, ok := n.ExpiresAt()
+	return ok && !t.After(now)
+}
+
+// SetNodeTTL expires the node after the duration. Expired nodes are removed by Expire or a janitor.
+func (g *Graph) SetNodeTTL(id TypedID, d time.Duration) error {
+	return g.PatchNode(id, map[string]interface{}{EXPIRES_KEY: expiry(time.Now(), d)})
+}
+
+// SetEdgeTTL expires the edge after the duration. Expired edges are removed by Expire or a janitor.
+func (g *Graph) SetEdgeTTL(id TypedID, d time.Duration) error {
+	return g.PatchEdge(id, map[string]interface{}{EXPIRES_KEY: expiry(time.Now(), d)})
+}
+
+// expiryCallbacks are consulted before nodes & edges expire
+type expiryCallbacks struct {
+	mu    sync.RWMutex
+	nodes []func(n Node) time.Duration
+	edges []func(e *Edge) time.Duration
+}
+
+// node returns the longest extension the callbacks grant the node
+func (c *expiryCallbacks) node(n Node) time.Duration {
+	c.mu.RLock()
+	callbacks := c.nodes
+	c.mu.RUnlock()
+	var extend time.Duration
+	for _, fn := range callbacks {
+		if d := fn(n.Copy()); d > extend {
+			extend = d
+		}
+	}
+	return extend
+}
+
+// edge returns the longest extension the callbacks grant the edge
+func (c *expiryCallbacks) edge(e *Edge) time.Duration {
+	c.mu.RLock()
+	callbacks := c.edges
+	c.mu.RUnlock()
+	var extend time.Duration
+	for _, fn := range callbacks {
+		if d := fn(&Edge{Node: e.Node.Copy(), From: e.From.Copy(), To: e.To.Copy()}); d > extend {
+			extend = d
+		}
+	}
+	return extend
+}
+
+// OnNodeExpiring registers a callback that is passed a copy of every node about to expire. If it returns a positive
+// duration, the node is kept & expires that long after the time passed to Expire. When several callbacks are
+// registered, the longest extension wins; an extension the graph rejects(ex: a schema violation) does not save the
+// node. Callbacks run before Expire takes the lock, so they may read & mutate the graph.
+func (g *Graph) OnNodeExpiring(fn func(n Node) time.Duration) {
+	g.expiring.mu.Lock()
+	defer g.expiring.mu.Unlock()
+	g.expiring.nodes = append(g.expiring.nodes, fn)
+}
+
+// OnEdgeExpiring registers a callback that is passed a copy of every edge about to expire(see OnNodeExpiring)
+func (g *Graph) OnEdgeExpiring(fn func(e *Edge) time.Duration) {
+	g.expiring.mu.Lock()
+	defer g.expiring.mu.Unlock()
+	g.expiring.edges = append(g.expiring.edges, fn)
+}
+
+// OnNodeExpired registers a hook that is passed a copy of every node removed because its TTL ran out. It runs before
+// the OnNodeDeleted hooks for the node.
+func (g *Graph) OnNodeExpired(fn func(n Node), opts ...HookOption) {
+	g.hooks.add(nodeExpired, hook{node: fn}, opts)
+}
+
+// OnEdgeExpired registers a hook that is passed a copy of every edge removed because its TTL ran out. It runs before
+// the OnEdgeDeleted hooks for the edge. Edges removed because a node on either end expired are only deleted.
+func (g *Graph) OnEdgeExpired(fn func(e *Edge), opts ...HookOption) {
+	g.hooks.add(edgeExpired, hook{edge: fn}, opts)
+}
+
+// Expire removes the nodes & edges that expired at or before now, along with every edge to or from an expired node.
+// The OnNodeExpiring & OnEdgeExpiring callbacks are consulted first, & the nodes & edges they extend are kept. Each
+// removal is published as an expired change followed by a deleted change. It returns the number of nodes & edges
+// removed.
+func (g *Graph) Expire(now time.Time) (int, int, error) {
+	if g.ReadOnly() {
+		return 0, 0, ErrReadOnly
+	}
+	var nodes []Node
+	g.RangeNodes(func(n Node) bool {
+		if n.expired(now) {
+			nodes = append(nodes, n)
+		}
+		return true
+	})
+	var edges []*Edge
+	g.RangeEdges(func(e *Edge) bool {
+		if e.expired(now) {
+			edges = append(edges, e)
+		}
+		return true
+	})
+	extendNodes := map[string]time.Duration{}
+	for _, n := range nodes {
+		if d := g.expiring.node(n); d > 0 {
+			extendNodes[pathOf(n)] = d
+		}
+	}
+	extendEdges := map[string]time.Duration{}
+	for _, e := range edges {
+		if d := g.expiring.edge(e); d > 0 {
+			extendEdges[pathOf(e)] = d
+		}
+	}
+	defer g.lock()()
+	if g.ReadOnly() {
+		return 0, 0, ErrReadOnly
+	}
+	edgeCount := 0
+	for _, e := range edges {
+		// the edge may have been changed since it was found, so only what is still expired is removed
+		current, ok := g.GetEdge(e)
+		if !ok || !current.expired(now) {
+			continue
+		}
+		if d, ok := extendEdges[pathOf(e)]; ok {
+			extended := &Edge{Node: current.Node.Union(map[string]interface{}{EXPIRES_KEY: expiry(now, d)}), From: current.From, To: current.To}
+			if err := g.addEdge(extended); err == nil {
+				continue
+			}
+		}
+		g.recordEdge(edgeExpired, current)
+		g.delEdge(current)
+		edgeCount++
+	}
+	nodeCount := 0
+	for _, n := range nodes {
+		current, ok := g.GetNode(n)
+		if !ok || !current.expired(now) {
+			continue
+		}
+		if d, ok := extendNodes[pathOf(n)]; ok {
+			extended := current.Union(map[string]interface{}{EXPIRES_KEY: expiry(now, d)})
+			if err := g.validateNode(extended); err == nil {
+				g.addNode(extended)
+				continue
+			}
+		}
+		var incident []*Edge
+		collect := func(e *Edge) bool {
+			incident = append(incident, e)
+			return true
+		}
+		g.EdgesFrom(anyType{}, n, collect)
+		g.EdgesTo(anyType{}, n, collect)
+		for _, e := range incident {
+			if g.HasEdge(e) {
+				g.delEdge(e)
+				edgeCount++
+			}
+		}
+		g.recordNode(nodeExpired, current)
+		g.delNode(n)
+		nodeCount++
+	}
+	return nodeCount, edgeCount, g.walErr()
+}
+
+// expiry formats the time the duration after now as an EXPIRES_KEY value
+func expiry(now time.Time, d time.Duration) string {
+	return now.Add(d).UTC().Format(time.RFC3339Nano)
+}
+
+// StartJanitor removes expired nodes & edges in the background every interval until the returned stop function is
+// called(it is safe to call more than once). Errors(ex: the graph is read only) are passed to onError if it is not nil.
+func (g *Graph) StartJanitor(interval time.Duration, onError func(err error)) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				if _, _, err := g.Expire(now); err != nil && onError != nil {
+					onError(fmt.Errorf("dagger: janitor: %w", err))
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+	}
+}
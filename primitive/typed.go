@@ -0,0 +1,164 @@
+package primitive
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+var (
+	// ErrMissingAttribute is returned(or panicked with by the MustGet family) when a node has no value for an attribute
+	ErrMissingAttribute = errors.New("dagger: missing attribute")
+	// ErrAttributeType is returned(or panicked with by the MustGet family) when an attribute holds a value of another type
+	ErrAttributeType = errors.New("dagger: attribute has the wrong type")
+)
+
+// GetStringOK gets a string entry from the Node by key, returning false if it is missing or not a string
+func (m Node) GetStringOK(key string) (string, bool) {
+	val, ok := m[key].(string)
+	return val, ok
+}
+
+// GetIntOK gets an integer entry from the Node by key, returning false if it is missing or not an integer. Floats
+// without a fractional part(ex: numbers decoded from json) are integers.
+func (m Node) GetIntOK(key string) (int, bool) {
+	switch val := m[key].(type) {
+	case int:
+		return val, true
+	case int32:
+		return int(val), true
+	case int64:
+		return int(val), true
+	case float32:
+		if float64(val) == math.Trunc(float64(val)) {
+			return int(val), true
+		}
+	case float64:
+		if val == math.Trunc(val) {
+			return int(val), true
+		}
+	}
+	return 0, false
+}
+
+// GetFloatOK gets a numeric entry from the Node by key, returning false if it is missing or not a number
+func (m Node) GetFloatOK(key string) (float64, bool) {
+	switch val := m[key].(type) {
+	case int:
+		return float64(val), true
+	case int32:
+		return float64(val), true
+	case int64:
+		return float64(val), true
+	case float32:
+		return float64(val), true
+	case float64:
+		return val, true
+	}
+	return 0, false
+}
+
+// GetBoolOK gets a bool entry from the Node by key, returning false if it is missing or not a bool
+func (m Node) GetBoolOK(key string) (bool, bool) {
+	val, ok := m[key].(bool)
+	return val, ok
+}
+
+// GetTime gets a time entry from the Node by key(if it exists)
+func (m Node) GetTime(key string) time.Time {
+	val, _ := m.GetTimeOK(key)
+	return val
+}
+
+// GetTimeOK gets a time entry from the Node by key, returning false if it is missing or not a time. RFC 3339 strings
+// (ex: times decoded from json) are times.
+func (m Node) GetTimeOK(key string) (time.Time, bool) {
+	switch val := m[key].(type) {
+	case time.Time:
+		return val, true
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, val)
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
+
+// GetStringSlice gets a string slice entry from the Node by key(if it exists)
+func (m Node) GetStringSlice(key string) []string {
+	val, _ := m.GetStringSliceOK(key)
+	return val
+}
+
+// GetStringSliceOK gets a string slice entry from the Node by key, returning false if it is missing or holds anything
+// but strings. Slices of interface{} that only hold strings(ex: slices decoded from json) are string slices.
+func (m Node) GetStringSliceOK(key string) ([]string, bool) {
+	switch val := m[key].(type) {
+	case []string:
+		return val, true
+	case []interface{}:
+		strs := make([]string, 0, len(val))
+		for _, v := range val {
+			s, ok := v.(string)
+			if !ok {
+				return nil, false
+			}
+			strs = append(strs, s)
+		}
+		return strs, true
+	}
+	return nil, false
+}
+
+// mustGet panics with ErrMissingAttribute or ErrAttributeType if ok is false
+func (m Node) mustGet(key string, kind string, ok bool) {
+	if ok {
+		return
+	}
+	if !m.Exists(key) {
+		panic(fmt.Errorf("%w: %s.%s has no %s", ErrMissingAttribute, m.Type(), m.ID(), key))
+	}
+	panic(fmt.Errorf("%w: %s of %s.%s is a %T, not a %s", ErrAttributeType, key, m.Type(), m.ID(), m[key], kind))
+}
+
+// MustGetString gets a string entry from the Node by key, panicking if it is missing or not a string
+func (m Node) MustGetString(key string) string {
+	val, ok := m.GetStringOK(key)
+	m.mustGet(key, "string", ok)
+	return val
+}
+
+// MustGetInt gets an integer entry from the Node by key, panicking if it is missing or not an integer
+func (m Node) MustGetInt(key string) int {
+	val, ok := m.GetIntOK(key)
+	m.mustGet(key, "int", ok)
+	return val
+}
+
+// MustGetFloat gets a numeric entry from the Node by key, panicking if it is missing or not a number
+func (m Node) MustGetFloat(key string) float64 {
+	val, ok := m.GetFloatOK(key)
+	m.mustGet(key, "float", ok)
+	return val
+}
+
+// MustGetBool gets a bool entry from the Node by key, panicking if it is missing or not a bool
+func (m Node) MustGetBool(key string) bool {
+	val, ok := m.GetBoolOK(key)
+	m.mustGet(key, "bool", ok)
+	return val
+}
+
+// MustGetTime gets a time entry from the Node by key, panicking if it is missing or not a time
+func (m Node) MustGetTime(key string) time.Time {
+	val, ok := m.GetTimeOK(key)
+	m.mustGet(key, "time", ok)
+	return val
+}
+
+// MustGetStringSlice gets a string slice entry from the Node by key, panicking if it is missing or not a string slice
+func (m Node) MustGetStringSlice(key string) []string {
+	val, ok := m.GetStringSliceOK(key)
+	m.mustGet(key, "string slice", ok)
+	return val
+}
@@ -0,0 +1,68 @@
+package primitive
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrDuplicateEdge is returned when adding an edge that UniqueEdges does not allow
+var ErrDuplicateEdge = errors.New("dagger: duplicate edge")
+
+const (
+	multiEdges = iota
+	uniqueEdgesPerType
+	uniqueEdges
+)
+
+// UniqueEdges rejects parallel edges between the same two nodes in the same direction with ErrDuplicateEdge. If perType
+// is true, there may be one edge of each type between two nodes; otherwise there may only be one edge of any type.
+// By default, any number of edges may connect two nodes.
+func UniqueEdges(perType bool) GraphOption {
+	return func(g *Graph) {
+		if perType {
+			g.uniqueEdges = uniqueEdgesPerType
+		} else {
+			g.uniqueEdges = uniqueEdges
+		}
+	}
+}
+
+// uniqueKey returns the key an edge must not share with another edge, or "" if parallel edges are allowed
+func (g *Graph) uniqueKey(e *Edge) string {
+	switch g.uniqueEdges {
+	case uniqueEdgesPerType:
+		return pathOf(e.From) + "|" + pathOf(e.To) + "|" + e.Type()
+	case uniqueEdges:
+		return pathOf(e.From) + "|" + pathOf(e.To)
+	}
+	return ""
+}
+
+// checkUnique returns ErrDuplicateEdge if another edge in the graph shares the edge's unique key
+func (g *Graph) checkUnique(e *Edge) error {
+	key := g.uniqueKey(e)
+	if key == "" {
+		return nil
+	}
+	var err error
+	g.EdgesFrom(anyType{}, e.From, func(existing *Edge) bool {
+		if pathOf(existing) != pathOf(e) && g.uniqueKey(existing) == key {
+			err = fmt.Errorf("%w: %s.%s already connects %s to %s", ErrDuplicateEdge, existing.Type(), existing.ID(), pathOf(e.From), pathOf(e.To))
+			return false
+		}
+		return true
+	})
+	return err
+}
+
+// GetEdgesBetween returns the edges of the given type(and its subtypes) that point from one node to the other
+func (g *Graph) GetEdgesBetween(from, to TypedID, edgeType Type) []*Edge {
+	var edges []*Edge
+	g.EdgesFrom(edgeType, from, func(e *Edge) bool {
+		if pathOf(e.To) == pathOf(to) {
+			edges = append(edges, e)
+		}
+		return true
+	})
+	return edges
+}
@@ -0,0 +1,243 @@
+package primitive
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrSchemaViolation is returned when a mutation would leave a node or edge that does not conform to a registered schema
+var ErrSchemaViolation = errors.New("dagger: schema violation")
+
+// Schema declares the attributes & outgoing edge types allowed on nodes of a type
+type Schema struct {
+	// Required are the attributes every node of the type must have
+	Required []string `json:"required"`
+	// Attributes are the value kinds(string, int, float, bool, object, array) attributes must have when they are set.
+	// A float attribute accepts whole numbers.
+	Attributes map[string]string `json:"attributes"`
+	// Strict rejects attributes that are not declared in Required or Attributes
+	Strict bool `json:"strict"`
+	// EdgeTypes are the types of edges(and their subtypes) that may stem from nodes of the type. If empty, any edge type
+	// is allowed.
+	EdgeTypes []string `json:"edge_types"`
+}
+
+// EdgeConstraint restricts the node types an edge type may connect. Empty endpoint types allow any node type.
+type EdgeConstraint struct {
+	// From are the node types(and their subtypes) the edge may stem from
+	From []string `json:"from"`
+	// To are the node types(and their subtypes) the edge may point to
+	To []string `json:"to"`
+}
+
+// ConstraintOption configures an edge constraint
+type ConstraintOption func(c *EdgeConstraint)
+
+// From restricts the node types an edge may stem from
+func From(nodeTypes ...string) ConstraintOption {
+	return func(c *EdgeConstraint) {
+		c.From = append(c.From, nodeTypes...)
+	}
+}
+
+// To restricts the node types an edge may point to
+func To(nodeTypes ...string) ConstraintOption {
+	return func(c *EdgeConstraint) {
+		c.To = append(c.To, nodeTypes...)
+	}
+}
+
+// schemas holds the registered schemas by node type & the edge constraints by edge type
+type schemas struct {
+	mu          sync.RWMutex
+	schemas     map[string]Schema
+	constraints map[string]EdgeConstraint
+}
+
+// RegisterSchema registers the schema for nodes of the given type(and its declared subtypes), replacing any schema
+// previously registered for it. Adding or patching a node that violates it, or connecting it with an edge type it does
+// not allow, returns ErrSchemaViolation. Existing nodes & edges are not checked.
+func (g *Graph) RegisterSchema(nodeType string, schema Schema) {
+	g.schemas.mu.Lock()
+	defer g.schemas.mu.Unlock()
+	if g.schemas.schemas == nil {
+		g.schemas.schemas = map[string]Schema{}
+	}
+	g.schemas.schemas[nodeType] = schema
+}
+
+// Schemas returns the registered schemas by node type
+func (g *Graph) Schemas() map[string]Schema {
+	g.schemas.mu.RLock()
+	defer g.schemas.mu.RUnlock()
+	registered := map[string]Schema{}
+	for typ, schema := range g.schemas.schemas {
+		registered[typ] = schema
+	}
+	return registered
+}
+
+// ConstrainEdge restricts the node types edges of the given type(and its subtypes) may connect(ex: owner edges may only
+// point from a dog to a user), replacing any constraint previously registered for it. Adding an edge that violates it
+// returns ErrSchemaViolation. Existing edges are not checked.
+func (g *Graph) ConstrainEdge(edgeType string, opts ...ConstraintOption) {
+	c := EdgeConstraint{}
+	for _, o := range opts {
+		o(&c)
+	}
+	g.schemas.mu.Lock()
+	defer g.schemas.mu.Unlock()
+	if g.schemas.constraints == nil {
+		g.schemas.constraints = map[string]EdgeConstraint{}
+	}
+	g.schemas.constraints[edgeType] = c
+}
+
+// EdgeConstraints returns the registered edge constraints by edge type
+func (g *Graph) EdgeConstraints() map[string]EdgeConstraint {
+	g.schemas.mu.RLock()
+	defer g.schemas.mu.RUnlock()
+	registered := map[string]EdgeConstraint{}
+	for typ, c := range g.schemas.constraints {
+		registered[typ] = c
+	}
+	return registered
+}
+
+// constraintsOf returns the constraints that apply to the edge type in a stable order
+func (g *Graph) constraintsOf(edgeType string) []EdgeConstraint {
+	g.schemas.mu.RLock()
+	defer g.schemas.mu.RUnlock()
+	if len(g.schemas.constraints) == 0 {
+		return nil
+	}
+	var types []string
+	for typ := range g.schemas.constraints {
+		if g.edgeTypes.isA(edgeType, typ) {
+			types = append(types, typ)
+		}
+	}
+	sort.Strings(types)
+	var applied []EdgeConstraint
+	for _, typ := range types {
+		applied = append(applied, g.schemas.constraints[typ])
+	}
+	return applied
+}
+
+// isNodeTypeOf returns true if the node type is one of, or a subtype of one of, the types. An empty list allows any type.
+func (g *Graph) isNodeTypeOf(nodeType string, types []string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, typ := range types {
+		if g.nodeTypes.isA(nodeType, typ) {
+			return true
+		}
+	}
+	return false
+}
+
+// SchemasOf returns the schemas that apply to the node type(those registered for it & its supertypes) in a stable order
+func (g *Graph) SchemasOf(nodeType string) []Schema {
+	g.schemas.mu.RLock()
+	defer g.schemas.mu.RUnlock()
+	if len(g.schemas.schemas) == 0 {
+		return nil
+	}
+	var types []string
+	for typ := range g.schemas.schemas {
+		if g.nodeTypes.isA(nodeType, typ) {
+			types = append(types, typ)
+		}
+	}
+	sort.Strings(types)
+	var applied []Schema
+	for _, typ := range types {
+		applied = append(applied, g.schemas.schemas[typ])
+	}
+	return applied
+}
+
+// validateNode returns ErrSchemaViolation if the node does not conform to the schemas registered for its type
+func (g *Graph) validateNode(n Node) error {
+	for _, schema := range g.SchemasOf(n.Type()) {
+		for _, attr := range schema.Required {
+			if n.Get(attr) == nil {
+				return fmt.Errorf("%w: %s.%s is missing required attribute %s", ErrSchemaViolation, n.Type(), n.ID(), attr)
+			}
+		}
+		for k, v := range n {
+			if k == ID_KEY || k == TYPE_KEY || v == nil {
+				continue
+			}
+			kind, ok := schema.Attributes[k]
+			if !ok {
+				if schema.Strict && !contains(schema.Required, k) {
+					return fmt.Errorf("%w: %s.%s has undeclared attribute %s", ErrSchemaViolation, n.Type(), n.ID(), k)
+				}
+				continue
+			}
+			if actual := valueKind(v); actual != kind && !(kind == "float" && actual == "int") {
+				return fmt.Errorf("%w: %s.%s attribute %s is %s, not %s", ErrSchemaViolation, n.Type(), n.ID(), k, actual, kind)
+			}
+		}
+	}
+	return nil
+}
+
+// validateNodes validates each of the nodes, stopping at the first violation
+func (g *Graph) validateNodes(nodes ...Node) error {
+	for _, n := range nodes {
+		if err := g.validateNode(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateAll returns the first schema or edge constraint violation among the nodes & edges, so bulk writes can
+// reject them before changing anything. Errors that depend on the rest of the graph(missing endpoints, duplicate
+// edges) are left to addEdge.
+func (g *Graph) validateAll(nodes []Node, edges []*Edge) error {
+	if err := g.validateNodes(nodes...); err != nil {
+		return err
+	}
+	for _, e := range edges {
+		if err := g.validateEdge(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateEdge returns ErrSchemaViolation if the schemas registered for the edge's source node type do not allow its
+// type, or the edge connects node types its edge constraints do not allow
+func (g *Graph) validateEdge(e *Edge) error {
+	for _, c := range g.constraintsOf(e.Type()) {
+		if !g.isNodeTypeOf(e.From.Type(), c.From) {
+			return fmt.Errorf("%w: %s edges may not stem from %s nodes", ErrSchemaViolation, e.Type(), e.From.Type())
+		}
+		if !g.isNodeTypeOf(e.To.Type(), c.To) {
+			return fmt.Errorf("%w: %s edges may not point to %s nodes", ErrSchemaViolation, e.Type(), e.To.Type())
+		}
+	}
+	for _, schema := range g.SchemasOf(e.From.Type()) {
+		if len(schema.EdgeTypes) == 0 {
+			continue
+		}
+		allowed := false
+		for _, typ := range schema.EdgeTypes {
+			if g.edgeTypes.isA(e.Type(), typ) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%w: %s edges may not stem from %s.%s", ErrSchemaViolation, e.Type(), e.From.Type(), e.From.ID())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,384 @@
+package primitive
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNoWAL is returned when reading the log of a graph that is not logging changes to a WAL
+var ErrNoWAL = errors.New("dagger: graph has no wal")
+
+// ErrWALFailed is returned by mutations once appending to the graph's WAL fails. The change that could not be logged is
+// kept in memory, but the graph becomes read only so no more changes are lost(see SetWAL).
+var ErrWALFailed = errors.New("dagger: wal append failed, graph is read only")
+
+const (
+	walSetNode = "set_node"
+	walDelNode = "del_node"
+	walSetEdge = "set_edge"
+	walDelEdge = "del_edge"
+	// walCheckpoint starts a compacted log: the entries after it recreate the graph as it was at the checkpoint
+	walCheckpoint = "checkpoint"
+)
+
+// walEntry is a single line of a write-ahead log
+type walEntry struct {
+	Op   string `json:"op"`
+	Node Node   `json:"node,omitempty"`
+	Edge *Edge  `json:"edge,omitempty"`
+	// Time is when the change was made. It is zero in logs written before changes were timestamped.
+	Time time.Time `json:"time"`
+}
+
+// WAL is an append-only, on-disk log of every change made to a graph. Replaying the log with Recover restores the
+// graph after a crash.
+type WAL struct {
+	mu         sync.Mutex
+	path       string
+	file       *os.File
+	syncWrites bool
+	err        error
+	// offset is the size of the log, entries the number of entries in it & unsynced the number written since it was last
+	// flushed to disk
+	offset   int64
+	entries  int64
+	unsynced int64
+}
+
+// walMark is the position of the end of a WAL
+type walMark struct {
+	offset  int64
+	entries int64
+}
+
+// OpenWAL opens(or creates) the log at the path for appending. If syncWrites is true, every entry is flushed to disk
+// before the change it records completes, so changes survive power loss as well as process crashes. An incomplete
+// entry at the end of the log(from a crash mid-write) is truncated, so new entries are not appended to it.
+func OpenWAL(path string, syncWrites bool) (*WAL, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	offset, entries, err := truncateTornWrite(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &WAL{path: path, file: file, syncWrites: syncWrites, offset: offset, entries: entries}, nil
+}
+
+// truncateTornWrite truncates the file after its last complete line & seeks to the end, returning the size of the file
+// & the number of complete lines
+func truncateTornWrite(file *os.File) (int64, int64, error) {
+	r := bufio.NewReader(file)
+	var offset, lines int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			// an unterminated line is a torn write
+			break
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		offset += int64(len(line))
+		lines++
+	}
+	if err := file.Truncate(offset); err != nil {
+		return 0, 0, err
+	}
+	_, err := file.Seek(offset, io.SeekStart)
+	return offset, lines, err
+}
+
+// append writes the entry to the log, returning the error that stopped it from being written(or an earlier one)
+func (w *WAL) append(entry walEntry) error {
+	line, err := json.Marshal(entry)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	if err != nil {
+		w.err = err
+		return err
+	}
+	n, err := w.file.Write(append(line, '\n'))
+	w.offset += int64(n)
+	if err != nil {
+		w.err = err
+		return err
+	}
+	w.entries++
+	w.unsynced++
+	if w.syncWrites {
+		w.sync()
+	}
+	return w.err
+}
+
+func (w *WAL) mark() walMark {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return walMark{offset: w.offset, entries: w.entries}
+}
+
+// compact replaces the entries before the mark with a checkpoint that recreates the nodes & edges, keeping the entries
+// appended since the mark. The checkpoint is written to a temporary file without blocking appends, which are only
+// blocked while the entries after the mark are copied & the file is swapped.
+func (w *WAL) compact(mark walMark, at time.Time, nodes []Node, edges []*Edge) error {
+	tmp, err := os.CreateTemp(filepath.Dir(w.path), "."+filepath.Base(w.path)+"-*")
+	if err != nil {
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	swapped := false
+	defer func() {
+		if !swapped {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+	bw := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(bw)
+	entries := []walEntry{{Op: walCheckpoint, Time: at}}
+	for _, n := range nodes {
+		entries = append(entries, walEntry{Op: walSetNode, Node: n, Time: at})
+	}
+	for _, e := range edges {
+		entries = append(entries, walEntry{Op: walSetEdge, Edge: e, Time: at})
+	}
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	current, err := os.Open(w.path)
+	if err != nil {
+		return err
+	}
+	defer current.Close()
+	if _, err := current.Seek(mark.offset, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := io.CopyN(tmp, current, w.offset-mark.offset); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	offset, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), w.path); err != nil {
+		return err
+	}
+	swapped = true
+	w.file.Close()
+	w.file = tmp
+	w.offset = offset
+	w.entries = int64(len(entries)) + w.entries - mark.entries
+	w.unsynced = 0
+	return nil
+}
+
+// sync flushes the log to disk. The caller must hold the lock.
+func (w *WAL) sync() {
+	if err := w.file.Sync(); err != nil {
+		if w.err == nil {
+			w.err = err
+		}
+		return
+	}
+	w.unsynced = 0
+}
+
+func (w *WAL) health() *WALHealth {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	h := &WALHealth{Entries: w.entries, Unsynced: w.unsynced}
+	if w.err != nil {
+		h.Error = w.err.Error()
+	}
+	return h
+}
+
+// Err returns the first error encountered appending to the log. Once an append fails, no more entries are written.
+func (w *WAL) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Close flushes & closes the log, returning the first error encountered appending to it
+func (w *WAL) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.sync()
+	if err := w.file.Close(); err != nil && w.err == nil {
+		w.err = err
+	}
+	return w.err
+}
+
+// SetWAL logs every subsequent change to the graph to the WAL. Pass nil to stop logging. If appending to the WAL fails,
+// the graph becomes read only: set a new WAL & call SetReadOnly(false) to accept changes again.
+func (g *Graph) SetWAL(w *WAL) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.wal = w
+}
+
+// Recover replays a write-ahead log into the graph. An incomplete entry at the end of the log(from a crash mid-write)
+// is ignored. Recover should be called before the graph's WAL is set so the replayed changes are not logged again.
+func (g *Graph) Recover(r io.Reader) error {
+	return g.RecoverUntil(r, time.Time{})
+}
+
+// RecoverUntil replays the changes in a write-ahead log that were made at or before the given time into the graph. A
+// zero time replays the whole log.
+func (g *Graph) RecoverUntil(r io.Reader, until time.Time) error {
+	defer g.lock()()
+	if g.ReadOnly() {
+		return ErrReadOnly
+	}
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		bits, err := br.ReadBytes('\n')
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var entry walEntry
+		if err := json.Unmarshal(bits, &entry); err != nil {
+			return fmt.Errorf("corrupt wal entry on line %v: %w", line, err)
+		}
+		if entry.Op == walCheckpoint {
+			if !until.IsZero() && entry.Time.After(until) {
+				return fmt.Errorf("dagger: the wal was checkpointed at %v, after %v", entry.Time, until)
+			}
+			continue
+		}
+		if !until.IsZero() && entry.Time.After(until) {
+			// the log is in the order the changes were made, so every later entry is newer too
+			return nil
+		}
+		switch entry.Op {
+		case walSetNode:
+			if err := g.validateNode(entry.Node); err != nil {
+				return fmt.Errorf("failed to replay wal entry on line %v: %w", line, err)
+			}
+			g.addNode(entry.Node)
+		case walDelNode:
+			g.delNode(entry.Node)
+		case walSetEdge:
+			if err := g.addEdge(g.resolveEdge(entry.Edge)); err != nil {
+				return fmt.Errorf("failed to replay wal entry on line %v: %w", line, err)
+			}
+		case walDelEdge:
+			g.delEdge(entry.Node)
+		default:
+			return fmt.Errorf("unknown wal operation on line %v: %q", line, entry.Op)
+		}
+	}
+}
+
+// log appends the change to the graph's WAL if it has one, making the graph read only if it cannot. The caller must
+// hold the lock.
+func (g *Graph) log(entry walEntry) {
+	if g.wal != nil {
+		entry.Time = time.Now()
+		if err := g.wal.append(entry); err != nil {
+			atomic.StoreInt32(&g.readOnly, 1)
+		}
+	}
+}
+
+// walErr returns ErrWALFailed if the graph's WAL could not record a change. Mutations return it once they have made
+// their changes, so the caller learns the changes were not logged. The caller must hold the lock.
+func (g *Graph) walErr() error {
+	if g.wal == nil {
+		return nil
+	}
+	if err := g.wal.Err(); err != nil {
+		return fmt.Errorf("%w: %v", ErrWALFailed, err)
+	}
+	return nil
+}
+
+// CheckpointWAL compacts the graph's WAL: the entries logged before the checkpoint are replaced with an entry for each
+// node & edge in the graph, so recovery replays the graph's current size plus the changes made since rather than its
+// whole history. Writers are blocked while the graph is copied, but not while the checkpoint is written. AsOf cannot
+// reconstruct the graph as it was before the last checkpoint. It returns ErrNoWAL if the graph has no WAL.
+func (g *Graph) CheckpointWAL() error {
+	var (
+		w            *WAL
+		mark         walMark
+		nodes        []Node
+		edges        []*Edge
+		checkpointAt time.Time
+	)
+	func() {
+		defer g.rlock()()
+		if g.wal == nil {
+			return
+		}
+		w = g.wal
+		mark = w.mark()
+		checkpointAt = time.Now()
+		nodes, edges = g.copyGraph()
+	}()
+	if w == nil {
+		return ErrNoWAL
+	}
+	return w.compact(mark, checkpointAt, nodes, edges)
+}
+
+// AsOf reconstructs the graph as it was at the given time by replaying its WAL into a new, read only graph. Only
+// changes logged to the graph's current WAL are replayed, so the WAL must have been set before the changes were made.
+// It returns ErrNoWAL if the graph has no WAL.
+func (g *Graph) AsOf(t time.Time) (*Graph, error) {
+	g.mu.RLock()
+	w := g.wal
+	g.mu.RUnlock()
+	if w == nil {
+		return nil, ErrNoWAL
+	}
+	f, err := os.Open(w.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	past := NewGraph()
+	past.edgeTypes = g.edgeTypes.copy()
+	past.nodeTypes = g.nodeTypes.copy()
+	if err := past.RecoverUntil(f, t); err != nil {
+		return nil, err
+	}
+	past.ClearDirty()
+	past.SetReadOnly(true)
+	return past, nil
+}
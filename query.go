@@ -0,0 +1,428 @@
+package dagger
+
+import (
+	"fmt"
+	"github.com/autom8ter/dagger/primitive"
+	"reflect"
+)
+
+// Operator compares an attribute's value against the value passed to QueryBuilder.Where
+type Operator string
+
+const (
+	// Eq matches values equal to the argument. Numbers are compared by value regardless of their type.
+	Eq Operator = "="
+	// Neq matches values not equal to the argument
+	Neq Operator = "!="
+	// Gt matches numbers/strings greater than the argument
+	Gt Operator = ">"
+	// Gte matches numbers/strings greater than or equal to the argument
+	Gte Operator = ">="
+	// Lt matches numbers/strings less than the argument
+	Lt Operator = "<"
+	// Lte matches numbers/strings less than or equal to the argument
+	Lte Operator = "<="
+)
+
+// QueryResult holds the elements a query ended on. Only one of Nodes or Edges is set, depending on the last step.
+type QueryResult struct {
+	Nodes []*Node
+	Edges []*Edge
+	// Truncated is true if the query exhausted its budget(see QueryBuilder.Budget), so the results are partial
+	Truncated bool
+}
+
+// queryState is the set of elements flowing between the steps of a query
+type queryState struct {
+	nodes   []*Node
+	edges   []*Edge
+	onEdges bool
+	budget  *primitive.TraversalBudget
+	// depth is the number of hops(OutE/InE steps) taken so far
+	depth int
+}
+
+// hop reserves a hop from the current nodes to their edges, returning false(and truncating) if the budget's max depth
+// has been reached
+func (s *queryState) hop() bool {
+	if !s.budget.Expands(s.depth) {
+		s.budget.Truncate()
+		return false
+	}
+	s.depth++
+	return true
+}
+
+type queryStep func(s *queryState) error
+
+// QueryBuilder builds a read query as a chain of steps that is run by Execute, ex:
+// Query().Nodes("user").Where("age", Gt, 21).OutE("friend").InV().Limit(10).Execute()
+type QueryBuilder struct {
+	graph *Graph
+	steps []queryStep
+	opts  primitive.TraversalOptions
+}
+
+// NewQuery starts a query against the graph
+func (g *Graph) NewQuery() *QueryBuilder {
+	return &QueryBuilder{graph: g}
+}
+
+// Query starts a query against the default graph
+func Query() *QueryBuilder {
+	return defaultGraph.NewQuery()
+}
+
+func (q *QueryBuilder) step(s queryStep) *QueryBuilder {
+	q.steps = append(q.steps, s)
+	return q
+}
+
+// Budget bounds the whole query with the traversal options, wherever it is called in the chain: every node & edge a
+// step reaches counts as a visit, and each OutE/InE step is a hop counted against the max depth. Once the budget is
+// exhausted, steps reach no more elements & the result is marked truncated.
+func (q *QueryBuilder) Budget(opts primitive.TraversalOptions) *QueryBuilder {
+	q.opts = opts
+	return q
+}
+
+// Nodes starts from the nodes of the given types(and their subtypes), or every node if no types are given
+func (q *QueryBuilder) Nodes(types ...string) *QueryBuilder {
+	return q.step(func(s *queryState) error {
+		s.nodes, s.edges, s.onEdges = nil, nil, false
+		collect := func(n *Node) bool {
+			if !s.budget.Visit() {
+				return false
+			}
+			s.nodes = append(s.nodes, n)
+			return true
+		}
+		if len(types) == 0 {
+			q.graph.RangeNodes(collect)
+		}
+		for _, typ := range types {
+			q.graph.RangeNodeTypes(StringType(typ), collect)
+		}
+		return nil
+	})
+}
+
+// Edges starts from the edges of the given types(and their subtypes), or every edge if no types are given
+func (q *QueryBuilder) Edges(types ...string) *QueryBuilder {
+	return q.step(func(s *queryState) error {
+		s.nodes, s.edges, s.onEdges = nil, nil, true
+		collect := func(e *Edge) bool {
+			if !s.budget.Visit() {
+				return false
+			}
+			s.edges = append(s.edges, e)
+			return true
+		}
+		if len(types) == 0 {
+			q.graph.RangeEdges(collect)
+		}
+		for _, typ := range types {
+			q.graph.RangeEdgeTypes(StringType(typ), collect)
+		}
+		return nil
+	})
+}
+
+// Where keeps the nodes or edges whose attribute compares to the value with the operator. Elements missing the
+// attribute are dropped.
+func (q *QueryBuilder) Where(attribute string, op Operator, value interface{}) *QueryBuilder {
+	return q.step(func(s *queryState) error {
+		if s.onEdges {
+			var edges []*Edge
+			for _, e := range s.edges {
+				ok, err := compare(e.Get(attribute), op, value)
+				if err != nil {
+					return err
+				}
+				if ok {
+					edges = append(edges, e)
+				}
+			}
+			s.edges = edges
+			return nil
+		}
+		var nodes []*Node
+		for _, n := range s.nodes {
+			ok, err := compare(n.Get(attribute), op, value)
+			if err != nil {
+				return err
+			}
+			if ok {
+				nodes = append(nodes, n)
+			}
+		}
+		s.nodes = nodes
+		return nil
+	})
+}
+
+// Filter keeps the nodes that pass the predicate
+func (q *QueryBuilder) Filter(fn func(n *Node) bool) *QueryBuilder {
+	return q.step(func(s *queryState) error {
+		if s.onEdges {
+			return fmt.Errorf("dagger: Filter must follow a node step")
+		}
+		var nodes []*Node
+		for _, n := range s.nodes {
+			if fn(n) {
+				nodes = append(nodes, n)
+			}
+		}
+		s.nodes = nodes
+		return nil
+	})
+}
+
+// OutE moves from the current nodes to their outgoing edges of the given types, or every type if none are given
+func (q *QueryBuilder) OutE(types ...string) *QueryBuilder {
+	return q.step(func(s *queryState) error {
+		if s.onEdges {
+			return fmt.Errorf("dagger: OutE must follow a node step")
+		}
+		s.edges = nil
+		if len(s.nodes) > 0 && s.hop() {
+			for _, n := range s.nodes {
+				for _, typ := range edgeTypesOrAny(types) {
+					n.EdgesFrom(typ, func(e *Edge) bool {
+						if !s.budget.Visit() {
+							return false
+						}
+						s.edges = append(s.edges, e)
+						return true
+					})
+				}
+			}
+		}
+		s.nodes, s.onEdges = nil, true
+		return nil
+	})
+}
+
+// InE moves from the current nodes to their incoming edges of the given types, or every type if none are given
+func (q *QueryBuilder) InE(types ...string) *QueryBuilder {
+	return q.step(func(s *queryState) error {
+		if s.onEdges {
+			return fmt.Errorf("dagger: InE must follow a node step")
+		}
+		s.edges = nil
+		if len(s.nodes) > 0 && s.hop() {
+			for _, n := range s.nodes {
+				for _, typ := range edgeTypesOrAny(types) {
+					n.EdgesTo(typ, func(e *Edge) bool {
+						if !s.budget.Visit() {
+							return false
+						}
+						s.edges = append(s.edges, e)
+						return true
+					})
+				}
+			}
+		}
+		s.nodes, s.onEdges = nil, true
+		return nil
+	})
+}
+
+// InV moves from the current edges to the nodes they point to
+func (q *QueryBuilder) InV() *QueryBuilder {
+	return q.step(func(s *queryState) error {
+		if !s.onEdges {
+			return fmt.Errorf("dagger: InV must follow an edge step")
+		}
+		s.nodes = nil
+		for _, e := range s.edges {
+			if !s.budget.Visit() {
+				break
+			}
+			s.nodes = append(s.nodes, e.To())
+		}
+		s.edges, s.onEdges = nil, false
+		return nil
+	})
+}
+
+// OutV moves from the current edges to the nodes they point from
+func (q *QueryBuilder) OutV() *QueryBuilder {
+	return q.step(func(s *queryState) error {
+		if !s.onEdges {
+			return fmt.Errorf("dagger: OutV must follow an edge step")
+		}
+		s.nodes = nil
+		for _, e := range s.edges {
+			if !s.budget.Visit() {
+				break
+			}
+			s.nodes = append(s.nodes, e.From())
+		}
+		s.edges, s.onEdges = nil, false
+		return nil
+	})
+}
+
+// Out moves from the current nodes to the nodes they point to over edges of the given types. It is shorthand for
+// OutE(types...).InV().
+func (q *QueryBuilder) Out(types ...string) *QueryBuilder {
+	return q.OutE(types...).InV()
+}
+
+// In moves from the current nodes to the nodes pointing to them over edges of the given types. It is shorthand for
+// InE(types...).OutV().
+func (q *QueryBuilder) In(types ...string) *QueryBuilder {
+	return q.InE(types...).OutV()
+}
+
+// Dedup removes repeated nodes or edges, keeping the first occurrence
+func (q *QueryBuilder) Dedup() *QueryBuilder {
+	return q.step(func(s *queryState) error {
+		seen := map[string]bool{}
+		if s.onEdges {
+			var edges []*Edge
+			for _, e := range s.edges {
+				if !seen[e.Type()+"."+e.ID()] {
+					seen[e.Type()+"."+e.ID()] = true
+					edges = append(edges, e)
+				}
+			}
+			s.edges = edges
+			return nil
+		}
+		var nodes []*Node
+		for _, n := range s.nodes {
+			if !seen[n.Type()+"."+n.ID()] {
+				seen[n.Type()+"."+n.ID()] = true
+				nodes = append(nodes, n)
+			}
+		}
+		s.nodes = nodes
+		return nil
+	})
+}
+
+// Limit keeps at most n of the current nodes or edges
+func (q *QueryBuilder) Limit(n int) *QueryBuilder {
+	return q.step(func(s *queryState) error {
+		if len(s.nodes) > n {
+			s.nodes = s.nodes[:n]
+		}
+		if len(s.edges) > n {
+			s.edges = s.edges[:n]
+		}
+		return nil
+	})
+}
+
+// Execute runs the query's steps in order, returning the nodes or edges left after the last step
+func (q *QueryBuilder) Execute() (*QueryResult, error) {
+	defer q.graph.trace("ExecuteQuery", nil)()
+	s := &queryState{budget: primitive.NewTraversalBudget(q.opts)}
+	for _, step := range q.steps {
+		if err := step(s); err != nil {
+			return nil, err
+		}
+	}
+	return &QueryResult{Nodes: s.nodes, Edges: s.edges, Truncated: s.budget.Result().Truncated}, nil
+}
+
+func edgeTypesOrAny(types []string) []primitive.Type {
+	if len(types) == 0 {
+		return []primitive.Type{AnyType()}
+	}
+	var typs []primitive.Type
+	for _, t := range types {
+		typs = append(typs, StringType(t))
+	}
+	return typs
+}
+
+// compare reports whether the attribute value compares to the argument with the operator
+func compare(value interface{}, op Operator, arg interface{}) (bool, error) {
+	switch op {
+	case Eq, Neq, Gt, Gte, Lt, Lte:
+	default:
+		return false, fmt.Errorf("dagger: unsupported operator %q", op)
+	}
+	if value == nil {
+		return false, nil
+	}
+	var cmp int
+	if a, ok := toFloat(value); ok {
+		b, ok := toFloat(arg)
+		if !ok {
+			return op == Neq, nil
+		}
+		switch {
+		case a < b:
+			cmp = -1
+		case a > b:
+			cmp = 1
+		}
+	} else if a, ok := value.(string); ok {
+		b, ok := arg.(string)
+		if !ok {
+			return op == Neq, nil
+		}
+		switch {
+		case a < b:
+			cmp = -1
+		case a > b:
+			cmp = 1
+		}
+	} else {
+		switch op {
+		case Eq:
+			return reflect.DeepEqual(value, arg), nil
+		case Neq:
+			return !reflect.DeepEqual(value, arg), nil
+		}
+		return false, nil
+	}
+	switch op {
+	case Eq:
+		return cmp == 0, nil
+	case Neq:
+		return cmp != 0, nil
+	case Gt:
+		return cmp > 0, nil
+	case Gte:
+		return cmp >= 0, nil
+	case Lt:
+		return cmp < 0, nil
+	}
+	// Lte
+	return cmp <= 0, nil
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
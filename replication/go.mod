@@ -0,0 +1,20 @@
+module github.com/autom8ter/dagger/replication
+
+go 1.18
+
+require (
+	github.com/autom8ter/dagger v0.0.0
+	github.com/nats-io/nats.go v1.31.0
+	github.com/segmentio/kafka-go v0.4.47
+)
+
+require (
+	github.com/klauspost/compress v1.17.0 // indirect
+	github.com/nats-io/nkeys v0.4.5 // indirect
+	github.com/nats-io/nuid v1.0.1 // indirect
+	github.com/pierrec/lz4/v4 v4.1.15 // indirect
+	golang.org/x/crypto v0.14.0 // indirect
+	golang.org/x/sys v0.13.0 // indirect
+)
+
+replace github.com/autom8ter/dagger => ../
@@ -0,0 +1,53 @@
+package replication
+
+import (
+	"context"
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaProducer publishes messages to a Kafka topic
+type KafkaProducer struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaProducer returns a producer that publishes with the writer. The writer's topic must be set, and its balancer
+// should hash message keys(ex: kafka.Hash) so each source's messages stay in order on one partition.
+func NewKafkaProducer(writer *kafka.Writer) *KafkaProducer {
+	return &KafkaProducer{writer: writer}
+}
+
+// Produce publishes the message, keyed by its source
+func (p *KafkaProducer) Produce(ctx context.Context, key string, value []byte) error {
+	return p.writer.WriteMessages(ctx, kafka.Message{Key: []byte(key), Value: value})
+}
+
+// KafkaConsumer consumes messages from a Kafka topic
+type KafkaConsumer struct {
+	reader *kafka.Reader
+}
+
+// NewKafkaConsumer returns a consumer that reads with the reader. Give every replica its own consumer group(or no group)
+// so each replica receives every message.
+func NewKafkaConsumer(reader *kafka.Reader) *KafkaConsumer {
+	return &KafkaConsumer{reader: reader}
+}
+
+// Consume delivers the topic's messages to the handler until the context is done or the handler fails. When the reader
+// belongs to a consumer group, each message's offset is committed once the handler returns, so a restarted replica
+// resumes after the last applied message.
+func (c *KafkaConsumer) Consume(ctx context.Context, handler func(value []byte) error) error {
+	for {
+		msg, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			return err
+		}
+		if err := handler(msg.Value); err != nil {
+			return err
+		}
+		if c.reader.Config().GroupID != "" {
+			if err := c.reader.CommitMessages(ctx, msg); err != nil {
+				return err
+			}
+		}
+	}
+}
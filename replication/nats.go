@@ -0,0 +1,58 @@
+package replication
+
+import (
+	"context"
+	"github.com/nats-io/nats.go"
+)
+
+// NATSProducer publishes messages to a NATS subject
+type NATSProducer struct {
+	conn    *nats.Conn
+	subject string
+}
+
+// NewNATSProducer returns a producer that publishes to the subject over the connection
+func NewNATSProducer(conn *nats.Conn, subject string) *NATSProducer {
+	return &NATSProducer{conn: conn, subject: subject}
+}
+
+// Produce publishes the message, flushing the connection so the message is sent before it returns
+func (p *NATSProducer) Produce(ctx context.Context, key string, value []byte) error {
+	if err := p.conn.Publish(p.subject, value); err != nil {
+		return err
+	}
+	return p.conn.FlushWithContext(ctx)
+}
+
+// NATSConsumer consumes messages from a NATS subject. Core NATS does not retain messages, so a replica only receives
+// the batches published while it is subscribed: seed it from a snapshot & use Applier.SetApplied when it starts, or use
+// a JetStream stream for durable delivery.
+type NATSConsumer struct {
+	conn    *nats.Conn
+	subject string
+}
+
+// NewNATSConsumer returns a consumer of the subject over the connection
+func NewNATSConsumer(conn *nats.Conn, subject string) *NATSConsumer {
+	return &NATSConsumer{conn: conn, subject: subject}
+}
+
+// Consume delivers the subject's messages to the handler in order until the context is done or the handler fails
+func (c *NATSConsumer) Consume(ctx context.Context, handler func(value []byte) error) error {
+	msgs := make(chan *nats.Msg, 64)
+	sub, err := c.conn.ChanSubscribe(c.subject, msgs)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg := <-msgs:
+			if err := handler(msg.Data); err != nil {
+				return err
+			}
+		}
+	}
+}
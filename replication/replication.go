@@ -0,0 +1,348 @@
+// Package replication fans a graph's changes out to read replicas over a message broker(ex: NATS or Kafka).
+//
+// A Publisher on the primary polls the graph's change stream(see Graph.ExportSince) and publishes each batch of
+// changes, tagged with the checkpoints it spans, to a topic. Batches too large for a single message(ex: the first one,
+// which holds the whole graph) are split into parts. An Applier on every replica consumes the topic & applies the parts
+// in order. Parts that were already applied are skipped, so redelivered messages are harmless, and a part that does
+// not follow the last one applied from its source is reported as a gap.
+//
+// A graph's checkpoints start over when its process restarts, so each Publisher tags its messages with an epoch of its
+// own. When an Applier receives a message from a new epoch of a source, it starts following the source's checkpoints
+// from the beginning again.
+//
+// The package is a separate module, so programs that do not replicate their graph do not depend on the broker clients.
+package replication
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/autom8ter/dagger"
+	"github.com/autom8ter/dagger/primitive"
+	"sync"
+	"time"
+)
+
+// ErrSequenceGap is reported when a message is applied that does not follow the last message applied from its source,
+// which means messages were lost. The replica should be reseeded from a snapshot of the primary.
+var ErrSequenceGap = errors.New("dagger: replication sequence gap")
+
+// DefaultMaxMessageSize is the size a Publisher keeps its messages under by default. It leaves room under the 1MB
+// default message size limits of NATS & Kafka.
+const DefaultMaxMessageSize = 512 << 10
+
+// Message is a part of a batch of changes published to the broker
+type Message struct {
+	// Source identifies the publishing graph
+	Source string `json:"source"`
+	// Epoch identifies the publisher, so the checkpoints of a restarted source are not mistaken for old ones
+	Epoch string `json:"epoch"`
+	// Part is the index of the message among the parts of its batch
+	Part int `json:"part"`
+	// Parts is the number of parts the batch was split into
+	Parts int `json:"parts"`
+	// Changes are the part's share of the changes made between the batch's Since & Checkpoint
+	Changes *primitive.Changes `json:"changes"`
+}
+
+// Producer publishes messages to a broker topic. Messages are keyed by their source, so brokers that partition a
+// topic(ex: Kafka) keep each source's messages in order.
+type Producer interface {
+	Produce(ctx context.Context, key string, value []byte) error
+}
+
+// Consumer delivers the messages of a broker topic to the handler in order until the context is done. A message is
+// acknowledged once the handler returns.
+type Consumer interface {
+	Consume(ctx context.Context, handler func(value []byte) error) error
+}
+
+// Publisher publishes the changes made to a graph
+type Publisher struct {
+	mu         sync.Mutex
+	graph      *dagger.Graph
+	source     string
+	epoch      string
+	producer   Producer
+	checkpoint dagger.Checkpoint
+	maxSize    int
+	prune      bool
+}
+
+// PublisherOption configures a Publisher
+type PublisherOption func(p *Publisher)
+
+// WithMaxMessageSize splits batches of changes into messages of at most size bytes(the default is
+// DefaultMaxMessageSize). A single node or edge larger than size is still published in a message of its own.
+func WithMaxMessageSize(size int) PublisherOption {
+	return func(p *Publisher) {
+		p.maxSize = size
+	}
+}
+
+// KeepChanges leaves the graph's changelog as it is after publishing. By default, the deletions the publisher has
+// published are pruned(see Graph.PruneChanges), so use KeepChanges if the graph's changes are exported elsewhere too.
+func KeepChanges() PublisherOption {
+	return func(p *Publisher) {
+		p.prune = false
+	}
+}
+
+// NewPublisher returns a publisher of the graph's changes, identified by the source name, starting after the
+// checkpoint. Pass the zero checkpoint to publish the whole graph first. The graph must be created with
+// dagger.TrackChanges.
+func NewPublisher(g *dagger.Graph, source string, producer Producer, since dagger.Checkpoint, opts ...PublisherOption) *Publisher {
+	p := &Publisher{
+		graph:      g,
+		source:     source,
+		epoch:      primitive.UUID(),
+		producer:   producer,
+		checkpoint: since,
+		maxSize:    DefaultMaxMessageSize,
+		prune:      true,
+	}
+	for _, o := range opts {
+		o(p)
+	}
+	return p
+}
+
+// Epoch returns the epoch the publisher tags its messages with
+func (p *Publisher) Epoch() string {
+	return p.epoch
+}
+
+// Checkpoint returns the checkpoint of the last published change
+func (p *Publisher) Checkpoint() dagger.Checkpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.checkpoint
+}
+
+// Publish publishes the changes made since the last published change, if any. If publishing a part fails, the whole
+// batch is published again on the next call.
+func (p *Publisher) Publish(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	changes, err := p.graph.Primitive().ChangesSince(p.checkpoint)
+	if err != nil {
+		return err
+	}
+	if changes.Checkpoint == p.checkpoint {
+		return nil
+	}
+	parts, err := p.split(changes)
+	if err != nil {
+		return err
+	}
+	for i, part := range parts {
+		bits, err := json.Marshal(&Message{
+			Source:  p.source,
+			Epoch:   p.epoch,
+			Part:    i,
+			Parts:   len(parts),
+			Changes: part,
+		})
+		if err != nil {
+			return err
+		}
+		if err := p.producer.Produce(ctx, p.source, bits); err != nil {
+			return err
+		}
+	}
+	p.checkpoint = changes.Checkpoint
+	if p.prune {
+		p.graph.PruneChanges(changes.Checkpoint)
+	}
+	return nil
+}
+
+// split divides the changes into parts that encode to messages of at most maxSize bytes. Deleted edges come first,
+// then deleted nodes, nodes & edges, so applying the parts in order applies the changes in the order
+// Graph.ImportChanges does.
+func (p *Publisher) split(changes *primitive.Changes) ([]*primitive.Changes, error) {
+	envelope, err := json.Marshal(&Message{
+		Source: p.source,
+		Epoch:  p.epoch,
+		// leave room for the largest part numbers
+		Part:    1 << 31,
+		Parts:   1 << 31,
+		Changes: &primitive.Changes{Since: changes.Since, Checkpoint: changes.Checkpoint},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var (
+		parts []*primitive.Changes
+		part  = &primitive.Changes{Since: changes.Since, Checkpoint: changes.Checkpoint}
+		size  = len(envelope)
+	)
+	add := func(element interface{}, addTo func(part *primitive.Changes)) error {
+		bits, err := json.Marshal(element)
+		if err != nil {
+			return err
+		}
+		// every element is followed by a comma
+		if size > len(envelope) && size+len(bits)+1 > p.maxSize {
+			parts = append(parts, part)
+			part = &primitive.Changes{Since: changes.Since, Checkpoint: changes.Checkpoint}
+			size = len(envelope)
+		}
+		addTo(part)
+		size += len(bits) + 1
+		return nil
+	}
+	for _, id := range changes.DeletedEdges {
+		id := id
+		if err := add(id, func(part *primitive.Changes) { part.DeletedEdges = append(part.DeletedEdges, id) }); err != nil {
+			return nil, err
+		}
+	}
+	for _, id := range changes.DeletedNodes {
+		id := id
+		if err := add(id, func(part *primitive.Changes) { part.DeletedNodes = append(part.DeletedNodes, id) }); err != nil {
+			return nil, err
+		}
+	}
+	for _, n := range changes.Nodes {
+		n := n
+		if err := add(n, func(part *primitive.Changes) { part.Nodes = append(part.Nodes, n) }); err != nil {
+			return nil, err
+		}
+	}
+	for _, e := range changes.Edges {
+		e := e
+		if err := add(e, func(part *primitive.Changes) { part.Edges = append(part.Edges, e) }); err != nil {
+			return nil, err
+		}
+	}
+	return append(parts, part), nil
+}
+
+// Run publishes the graph's changes every interval until the context is done. Errors are passed to onError if it is
+// not nil, and the changes are published again on the next tick.
+func (p *Publisher) Run(ctx context.Context, interval time.Duration, onError func(err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.Publish(ctx); err != nil && onError != nil {
+				onError(fmt.Errorf("dagger: replication publish: %w", err))
+			}
+		}
+	}
+}
+
+// Applier applies published changes to a replica graph
+type Applier struct {
+	mu      sync.Mutex
+	graph   *dagger.Graph
+	applied map[string]position
+}
+
+// position is the last message applied from a source
+type position struct {
+	epoch      string
+	since      dagger.Checkpoint
+	checkpoint dagger.Checkpoint
+	part       int
+	parts      int
+}
+
+// completed returns the checkpoint every change from the source has been applied up to
+func (p position) completed() dagger.Checkpoint {
+	if p.part < p.parts-1 {
+		return p.since
+	}
+	return p.checkpoint
+}
+
+// NewApplier returns an applier of published changes to the graph
+func NewApplier(g *dagger.Graph) *Applier {
+	return &Applier{
+		graph:   g,
+		applied: map[string]position{},
+	}
+}
+
+// Applied returns the checkpoint every change from the source's current epoch has been applied up to
+func (a *Applier) Applied(source string) dagger.Checkpoint {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.applied[source].completed()
+}
+
+// SetApplied records that the changes from the source have been applied up to the checkpoint(ex: after seeding the
+// replica from a snapshot taken at the checkpoint), so earlier messages are skipped. The checkpoint is taken to be from
+// the epoch of the next message received from the source.
+func (a *Applier) SetApplied(source string, checkpoint dagger.Checkpoint) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.applied[source] = position{since: checkpoint, checkpoint: checkpoint}
+}
+
+// Apply applies a published message, returning false if it was already applied. If the message does not follow the
+// last one applied from its source, it is still applied, & an error wrapping ErrSequenceGap is returned.
+func (a *Applier) Apply(value []byte) (bool, error) {
+	msg := &Message{}
+	if err := json.Unmarshal(value, msg); err != nil {
+		return false, fmt.Errorf("dagger: invalid replication message: %w", err)
+	}
+	if msg.Changes == nil {
+		return false, errors.New("dagger: invalid replication message: missing changes")
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	last := a.applied[msg.Source]
+	if last.epoch != msg.Epoch && last.epoch != "" {
+		// the source restarted, so its checkpoints started over
+		last = position{}
+	}
+	var gap error
+	switch {
+	case msg.Changes.Checkpoint == last.checkpoint && last.parts > 0:
+		// the next part of the last batch
+		if msg.Part <= last.part {
+			return false, nil
+		}
+		if msg.Part > last.part+1 {
+			gap = fmt.Errorf("%w: %s skipped from part %v to %v of checkpoint %v", ErrSequenceGap, msg.Source, last.part, msg.Part, last.checkpoint)
+		}
+	case msg.Changes.Checkpoint <= last.checkpoint:
+		return false, nil
+	case msg.Part > 0:
+		gap = fmt.Errorf("%w: %s skipped to part %v of checkpoint %v", ErrSequenceGap, msg.Source, msg.Part, msg.Changes.Checkpoint)
+	case msg.Changes.Since > last.completed():
+		gap = fmt.Errorf("%w: %s skipped from checkpoint %v to %v", ErrSequenceGap, msg.Source, last.completed(), msg.Changes.Since)
+	}
+	if err := a.graph.Primitive().ApplyChanges(msg.Changes); err != nil {
+		return false, err
+	}
+	a.applied[msg.Source] = position{
+		epoch:      msg.Epoch,
+		since:      msg.Changes.Since,
+		checkpoint: msg.Changes.Checkpoint,
+		part:       msg.Part,
+		parts:      msg.Parts,
+	}
+	return true, gap
+}
+
+// Run applies the messages delivered by the consumer until the context is done. Sequence gaps are passed to onError if
+// it is not nil, but do not stop the consumer. Any other error applying a message stops it.
+func (a *Applier) Run(ctx context.Context, consumer Consumer, onError func(err error)) error {
+	return consumer.Consume(ctx, func(value []byte) error {
+		_, err := a.Apply(value)
+		if errors.Is(err, ErrSequenceGap) {
+			if onError != nil {
+				onError(err)
+			}
+			return nil
+		}
+		return err
+	})
+}
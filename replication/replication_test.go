@@ -0,0 +1,198 @@
+package replication_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/autom8ter/dagger"
+	"github.com/autom8ter/dagger/replication"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// broker is an in-memory topic that keeps every message, like a Kafka topic with a single partition
+type broker struct {
+	mu       sync.Mutex
+	messages [][]byte
+}
+
+func (b *broker) Produce(ctx context.Context, key string, value []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.messages = append(b.messages, value)
+	return nil
+}
+
+func (b *broker) Consume(ctx context.Context, handler func(value []byte) error) error {
+	for offset := 0; ; {
+		b.mu.Lock()
+		pending := b.messages[offset:]
+		b.mu.Unlock()
+		for _, msg := range pending {
+			if err := handler(msg); err != nil {
+				return err
+			}
+			offset++
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+func TestReplication(t *testing.T) {
+	ctx := context.Background()
+	primary := dagger.NewGraph(dagger.TrackChanges())
+	coleman := primary.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman", "name": "coleman"})
+	topic := &broker{}
+	pub := replication.NewPublisher(primary, "primary", topic, 0)
+	if err := pub.Publish(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := pub.Publish(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(topic.messages) != 1 {
+		t.Fatalf("expected publishing without changes to publish nothing, got %v messages", len(topic.messages))
+	}
+	tyler := primary.NewNode(map[string]interface{}{"_type": "user", "_id": "tyler"})
+	if _, err := coleman.Connect(tyler, "friend", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := coleman.Patch(map[string]interface{}{"name": "colemanword"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := pub.Publish(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if pub.Checkpoint() != primary.CurrentCheckpoint() {
+		t.Fatal("expected the publisher to be caught up")
+	}
+
+	replica := dagger.NewGraph()
+	applier := replication.NewApplier(replica)
+	for _, msg := range topic.messages {
+		if ok, err := applier.Apply(msg); err != nil || !ok {
+			t.Fatalf("expected the message to be applied, got %v %v", ok, err)
+		}
+	}
+	if replica.Hash() != primary.Hash() {
+		t.Fatal("expected the replica to match the primary")
+	}
+	if ok, err := applier.Apply(topic.messages[0]); err != nil || ok {
+		t.Fatalf("expected a redelivered message to be skipped, got %v %v", ok, err)
+	}
+	if applier.Applied("primary") != primary.CurrentCheckpoint() {
+		t.Fatal("expected the applied checkpoint to be recorded")
+	}
+
+	if _, err := primary.ExportSince(0, io.Discard); !errors.Is(err, dagger.ErrCheckpointPruned) {
+		t.Fatalf("expected the published changes to be pruned, got %v", err)
+	}
+
+	// a replica that missed the first batch applies the second, but reports the gap
+	late := replication.NewApplier(dagger.NewGraph())
+	if ok, err := late.Apply(topic.messages[1]); !ok || !errors.Is(err, replication.ErrSequenceGap) {
+		t.Fatalf("expected a sequence gap, got %v %v", ok, err)
+	}
+
+	// a restarted primary's checkpoints start over, so its changes must not be mistaken for old ones
+	restarted := dagger.NewGraph(dagger.TrackChanges())
+	restarted.NewNode(map[string]interface{}{"_type": "user", "_id": "lacee"})
+	restartedTopic := &broker{}
+	if err := replication.NewPublisher(restarted, "primary", restartedTopic, 0).Publish(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := applier.Apply(restartedTopic.messages[0]); err != nil || !ok {
+		t.Fatalf("expected the restarted primary's changes to be applied, got %v %v", ok, err)
+	}
+	if !replica.HasNode(&dagger.ForeignKey{XType: "user", XID: "lacee"}) {
+		t.Fatal("expected the restarted primary's node to be replicated")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	live := dagger.NewGraph()
+	done := make(chan error, 1)
+	go func() {
+		done <- replication.NewApplier(live).Run(ctx, topic, func(err error) {
+			t.Error(err)
+		})
+	}()
+	go pub.Run(ctx, 5*time.Millisecond, func(err error) {
+		t.Error(err)
+	})
+	if err := tyler.Del("name"); err != nil {
+		t.Fatal(err)
+	}
+	if err := primary.DelNode(coleman); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for live.Hash() != primary.Hash() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the replica to converge")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the applier to stop when the context is done, got %v", err)
+	}
+}
+
+func TestReplicationParts(t *testing.T) {
+	ctx := context.Background()
+	primary := dagger.NewGraph(dagger.TrackChanges())
+	var previous *dagger.Node
+	for i := 0; i < 20; i++ {
+		n := primary.NewNode(map[string]interface{}{"_type": "user", "_id": fmt.Sprint(i), "bio": strings.Repeat("x", 100)})
+		if previous != nil {
+			if _, err := previous.Connect(n, "friend", false); err != nil {
+				t.Fatal(err)
+			}
+		}
+		previous = n
+	}
+	topic := &broker{}
+	if err := replication.NewPublisher(primary, "primary", topic, 0, replication.WithMaxMessageSize(1024)).Publish(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(topic.messages) < 3 {
+		t.Fatalf("expected the graph to be split into parts, got %v messages", len(topic.messages))
+	}
+	for _, msg := range topic.messages {
+		if len(msg) > 1024 {
+			t.Fatalf("expected messages of at most 1024 bytes, got %v", len(msg))
+		}
+	}
+	replica := dagger.NewGraph()
+	applier := replication.NewApplier(replica)
+	for i, msg := range topic.messages {
+		if ok, err := applier.Apply(msg); err != nil || !ok {
+			t.Fatalf("expected part %v to be applied, got %v %v", i, ok, err)
+		}
+		if i < len(topic.messages)-1 && applier.Applied("primary") != 0 {
+			t.Fatal("expected a partly applied batch not to count as applied")
+		}
+		if ok, err := applier.Apply(msg); err != nil || ok {
+			t.Fatalf("expected a redelivered part to be skipped, got %v %v", ok, err)
+		}
+	}
+	if replica.Hash() != primary.Hash() || applier.Applied("primary") != primary.CurrentCheckpoint() {
+		t.Fatal("expected the replica to match the primary")
+	}
+
+	skipped := replication.NewApplier(dagger.NewGraph())
+	if _, err := skipped.Apply(topic.messages[0]); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := skipped.Apply(topic.messages[2]); !ok || !errors.Is(err, replication.ErrSequenceGap) {
+		t.Fatalf("expected a sequence gap, got %v %v", ok, err)
+	}
+}
@@ -0,0 +1,396 @@
+package dagger
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/autom8ter/dagger/primitive"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// LoadSeed loads nodes & edges into the graph from a human-editable YAML document. Nodes are declared under a name
+// that edges refer to, and edges may be mutual:
+//
+//	defaults: &user
+//	  _type: user
+//	  active: true
+//	nodes:
+//	  coleman:
+//	    <<: *user
+//	    name: coleman
+//	  charlie:
+//	    _type: dog
+//	    weight: 25
+//	edges:
+//	  - type: pet
+//	    from: coleman
+//	    to: charlie
+//	    mutual: false
+//	    attributes:
+//	      since: 2019
+//
+// Only the block subset of YAML is supported: mappings, sequences, comments, plain, quoted or [flow, list] scalars,
+// &anchors, *aliases & <<: *alias merge keys. The whole document is parsed & checked before anything is written, so
+// nothing is loaded if any node or edge is rejected.
+func (g *Graph) LoadSeed(r io.Reader) error {
+	defer g.trace("LoadSeed", nil)()
+	doc, err := parseYAML(r)
+	if err != nil {
+		return err
+	}
+	root, ok := doc.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("dagger: seed must be a mapping with nodes & edges")
+	}
+	changes := &primitive.Changes{}
+	refs := map[string]primitive.Node{}
+	if nodes, ok := root["nodes"]; ok && nodes != nil {
+		nodeMap, ok := nodes.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("dagger: seed nodes must be a mapping of name to attributes")
+		}
+		for name, attributes := range nodeMap {
+			attrs, ok := attributes.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("dagger: seed node %s must be a mapping of attributes", name)
+			}
+			node := primitive.NewNode(attrs)
+			if node.Type() == "" {
+				node.SetType(primitive.DefaultType)
+			}
+			if node.ID() == "" {
+				node.SetID(primitive.UUID())
+			}
+			changes.Nodes = append(changes.Nodes, node)
+			refs[name] = node
+		}
+	}
+	if edges, ok := root["edges"]; ok && edges != nil {
+		edgeList, ok := edges.([]interface{})
+		if !ok {
+			return fmt.Errorf("dagger: seed edges must be a sequence")
+		}
+		for i, edge := range edgeList {
+			e, ok := edge.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("dagger: seed edge %v must be a mapping", i)
+			}
+			from, ok := refs[primitive.Node(e).GetString("from")]
+			if !ok {
+				return fmt.Errorf("dagger: seed edge %v refers to unknown node: %v", i, e["from"])
+			}
+			to, ok := refs[primitive.Node(e).GetString("to")]
+			if !ok {
+				return fmt.Errorf("dagger: seed edge %v refers to unknown node: %v", i, e["to"])
+			}
+			attrs, _ := e["attributes"].(map[string]interface{})
+			attributes := primitive.Node{}
+			attributes.SetAll(attrs)
+			attributes.SetType(primitive.Node(e).GetString("type"))
+			if attributes.ID() == "" {
+				attributes.SetID(primitive.UUID())
+			}
+			changes.Edges = append(changes.Edges, &primitive.Edge{
+				Node: attributes,
+				From: from,
+				To:   to,
+			})
+			if primitive.Node(e).GetBool("mutual") {
+				reverse := attributes.Copy()
+				reverse.SetID(primitive.UUID())
+				changes.Edges = append(changes.Edges, &primitive.Edge{
+					Node: reverse,
+					From: to,
+					To:   from,
+				})
+			}
+		}
+	}
+	return g.graph.ApplyChanges(changes)
+}
+
+// LoadSeed calls Graph.LoadSeed on the default graph
+func LoadSeed(r io.Reader) error {
+	return defaultGraph.LoadSeed(r)
+}
+
+type yamlLine struct {
+	number int
+	indent int
+	text   string
+}
+
+type yamlParser struct {
+	lines   []yamlLine
+	pos     int
+	anchors map[string]interface{}
+}
+
+func parseYAML(r io.Reader) (interface{}, error) {
+	p := &yamlParser{anchors: map[string]interface{}{}}
+	scanner := bufio.NewScanner(r)
+	number := 0
+	for scanner.Scan() {
+		number++
+		line := stripComment(scanner.Text())
+		text := strings.TrimSpace(line)
+		if text == "" || text == "---" {
+			continue
+		}
+		if strings.HasPrefix(strings.TrimLeft(line, " "), "\t") {
+			return nil, fmt.Errorf("dagger: seed line %v: tabs are not allowed for indentation", number)
+		}
+		p.lines = append(p.lines, yamlLine{
+			number: number,
+			indent: len(line) - len(strings.TrimLeft(line, " ")),
+			text:   text,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(p.lines) == 0 {
+		return nil, nil
+	}
+	return p.parseBlock(p.lines[0].indent)
+}
+
+func (p *yamlParser) parseBlock(indent int) (interface{}, error) {
+	if isSequenceItem(p.lines[p.pos].text) {
+		return p.parseSequence(indent)
+	}
+	return p.parseMapping(indent)
+}
+
+func (p *yamlParser) parseSequence(indent int) (interface{}, error) {
+	var seq []interface{}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent || !isSequenceItem(line.text) {
+			break
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("dagger: seed line %v: unexpected indentation", line.number)
+		}
+		anchor, item := splitAnchor(strings.TrimSpace(strings.TrimPrefix(line.text, "-")))
+		if item == "" {
+			p.pos++
+			var value interface{}
+			if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+				var err error
+				if value, err = p.parseBlock(p.lines[p.pos].indent); err != nil {
+					return nil, err
+				}
+			}
+			seq = append(seq, p.anchor(anchor, value))
+			continue
+		}
+		if _, _, ok := splitKey(item); (ok && !isAlias(item)) || isSequenceItem(item) {
+			// the item is a nested block that starts on the same line as the dash
+			childIndent := indent + len(line.text) - len(item)
+			p.lines[p.pos] = yamlLine{number: line.number, indent: childIndent, text: item}
+			value, err := p.parseBlock(childIndent)
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, p.anchor(anchor, value))
+			continue
+		}
+		value, err := p.parseScalar(line, item)
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, p.anchor(anchor, value))
+		p.pos++
+	}
+	return seq, nil
+}
+
+func (p *yamlParser) parseMapping(indent int) (interface{}, error) {
+	m := map[string]interface{}{}
+	var merges []map[string]interface{}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("dagger: seed line %v: unexpected indentation", line.number)
+		}
+		if isSequenceItem(line.text) {
+			break
+		}
+		key, text, ok := splitKey(line.text)
+		if !ok {
+			return nil, fmt.Errorf("dagger: seed line %v: expected key: value", line.number)
+		}
+		p.pos++
+		anchor, text := splitAnchor(text)
+		var value interface{}
+		if text != "" {
+			var err error
+			if value, err = p.parseScalar(line, text); err != nil {
+				return nil, err
+			}
+		} else if p.pos < len(p.lines) {
+			next := p.lines[p.pos]
+			if next.indent > indent || (next.indent == indent && isSequenceItem(next.text)) {
+				var err error
+				if value, err = p.parseBlock(next.indent); err != nil {
+					return nil, err
+				}
+			}
+		}
+		value = p.anchor(anchor, value)
+		if key == "<<" {
+			merge, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("dagger: seed line %v: << must merge a mapping", line.number)
+			}
+			merges = append(merges, merge)
+			continue
+		}
+		m[key] = value
+	}
+	// keys set on the mapping itself override merged keys, wherever the merge key appears
+	for _, merge := range merges {
+		for k, v := range merge {
+			if _, ok := m[k]; !ok {
+				m[k] = v
+			}
+		}
+	}
+	return m, nil
+}
+
+// parseScalar parses a scalar value, resolving *aliases to a copy of the value anchored under their name
+func (p *yamlParser) parseScalar(line yamlLine, text string) (interface{}, error) {
+	if !isAlias(text) {
+		return parseScalar(text), nil
+	}
+	value, ok := p.anchors[text[1:]]
+	if !ok {
+		return nil, fmt.Errorf("dagger: seed line %v: unknown anchor: %s", line.number, text[1:])
+	}
+	return copyYAML(value), nil
+}
+
+// anchor records the value under the anchor's name, if it has one, and returns it
+func (p *yamlParser) anchor(name string, value interface{}) interface{} {
+	if name != "" {
+		p.anchors[name] = value
+	}
+	return value
+}
+
+func isSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func splitKey(text string) (string, string, bool) {
+	if strings.HasPrefix(text, `"`) || strings.HasPrefix(text, `'`) {
+		end := strings.Index(text[1:], text[:1])
+		if end < 0 || !strings.HasPrefix(text[end+2:], ":") {
+			return "", "", false
+		}
+		return text[1 : end+1], strings.TrimSpace(text[end+3:]), true
+	}
+	i := strings.Index(text, ": ")
+	if i < 0 {
+		if strings.HasSuffix(text, ":") {
+			return strings.TrimSpace(strings.TrimSuffix(text, ":")), "", true
+		}
+		return "", "", false
+	}
+	return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+2:]), true
+}
+
+// splitAnchor splits an &anchor from the start of a value
+func splitAnchor(text string) (string, string) {
+	if !strings.HasPrefix(text, "&") {
+		return "", text
+	}
+	i := strings.Index(text, " ")
+	if i < 0 {
+		return text[1:], ""
+	}
+	return text[1:i], strings.TrimSpace(text[i+1:])
+}
+
+func isAlias(text string) bool {
+	return strings.HasPrefix(text, "*") && !strings.Contains(text, " ")
+}
+
+// copyYAML deep copies a parsed value, so nodes declared with the same alias do not share attributes
+func copyYAML(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			m[k] = copyYAML(v)
+		}
+		return m
+	case []interface{}:
+		seq := make([]interface{}, len(value))
+		for i, v := range value {
+			seq[i] = copyYAML(v)
+		}
+		return seq
+	}
+	return value
+}
+
+func stripComment(line string) string {
+	quote := rune(0)
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#' && (i == 0 || line[i-1] == ' '):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func parseScalar(text string) interface{} {
+	switch {
+	case strings.HasPrefix(text, `"`):
+		if s, err := strconv.Unquote(text); err == nil {
+			return s
+		}
+		return strings.Trim(text, `"`)
+	case strings.HasPrefix(text, `'`):
+		return strings.Replace(strings.Trim(text, `'`), `''`, `'`, -1)
+	case strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]"):
+		list := []interface{}{}
+		inner := strings.TrimSpace(text[1 : len(text)-1])
+		if inner == "" {
+			return list
+		}
+		for _, item := range strings.Split(inner, ",") {
+			list = append(list, parseScalar(strings.TrimSpace(item)))
+		}
+		return list
+	}
+	switch text {
+	case "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if i, err := strconv.Atoi(text); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil {
+		return f
+	}
+	return text
+}
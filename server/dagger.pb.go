@@ -0,0 +1,617 @@
+// dagger.proto describes the gRPC service implemented by Server
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: dagger.proto
+
+package server
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// NodeRef names a node by its type & id
+type NodeRef struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NodeRef) Reset() {
+	*x = NodeRef{}
+	mi := &file_dagger_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodeRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeRef) ProtoMessage() {}
+
+func (x *NodeRef) ProtoReflect() protoreflect.Message {
+	mi := &file_dagger_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeRef.ProtoReflect.Descriptor instead.
+func (*NodeRef) Descriptor() ([]byte, []int) {
+	return file_dagger_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *NodeRef) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *NodeRef) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// Node is a node of the graph. Its attributes do not include _type & _id.
+type Node struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	Attributes    *structpb.Struct       `protobuf:"bytes,3,opt,name=attributes,proto3" json:"attributes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Node) Reset() {
+	*x = Node{}
+	mi := &file_dagger_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Node) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Node) ProtoMessage() {}
+
+func (x *Node) ProtoReflect() protoreflect.Message {
+	mi := &file_dagger_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Node.ProtoReflect.Descriptor instead.
+func (*Node) Descriptor() ([]byte, []int) {
+	return file_dagger_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Node) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Node) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Node) GetAttributes() *structpb.Struct {
+	if x != nil {
+		return x.Attributes
+	}
+	return nil
+}
+
+// Edge is an edge of the graph. Its attributes do not include _type & _id.
+type Edge struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	Attributes    *structpb.Struct       `protobuf:"bytes,3,opt,name=attributes,proto3" json:"attributes,omitempty"`
+	From          *NodeRef               `protobuf:"bytes,4,opt,name=from,proto3" json:"from,omitempty"`
+	To            *NodeRef               `protobuf:"bytes,5,opt,name=to,proto3" json:"to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Edge) Reset() {
+	*x = Edge{}
+	mi := &file_dagger_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Edge) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Edge) ProtoMessage() {}
+
+func (x *Edge) ProtoReflect() protoreflect.Message {
+	mi := &file_dagger_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Edge.ProtoReflect.Descriptor instead.
+func (*Edge) Descriptor() ([]byte, []int) {
+	return file_dagger_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Edge) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Edge) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Edge) GetAttributes() *structpb.Struct {
+	if x != nil {
+		return x.Attributes
+	}
+	return nil
+}
+
+func (x *Edge) GetFrom() *NodeRef {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *Edge) GetTo() *NodeRef {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+type ConnectRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	From  *NodeRef               `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To    *NodeRef               `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	// relationship is the type of the edge
+	Relationship string `protobuf:"bytes,3,opt,name=relationship,proto3" json:"relationship,omitempty"`
+	// mutual also connects the to node to the from node
+	Mutual bool `protobuf:"varint,4,opt,name=mutual,proto3" json:"mutual,omitempty"`
+	// attributes are set on the edge(& its reverse if mutual)
+	Attributes    *structpb.Struct `protobuf:"bytes,5,opt,name=attributes,proto3" json:"attributes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConnectRequest) Reset() {
+	*x = ConnectRequest{}
+	mi := &file_dagger_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConnectRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConnectRequest) ProtoMessage() {}
+
+func (x *ConnectRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dagger_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConnectRequest.ProtoReflect.Descriptor instead.
+func (*ConnectRequest) Descriptor() ([]byte, []int) {
+	return file_dagger_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ConnectRequest) GetFrom() *NodeRef {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *ConnectRequest) GetTo() *NodeRef {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *ConnectRequest) GetRelationship() string {
+	if x != nil {
+		return x.Relationship
+	}
+	return ""
+}
+
+func (x *ConnectRequest) GetMutual() bool {
+	if x != nil {
+		return x.Mutual
+	}
+	return false
+}
+
+func (x *ConnectRequest) GetAttributes() *structpb.Struct {
+	if x != nil {
+		return x.Attributes
+	}
+	return nil
+}
+
+type RangeNodesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// type of the nodes, or * for every node(the default)
+	Type          string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RangeNodesRequest) Reset() {
+	*x = RangeNodesRequest{}
+	mi := &file_dagger_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RangeNodesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RangeNodesRequest) ProtoMessage() {}
+
+func (x *RangeNodesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dagger_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RangeNodesRequest.ProtoReflect.Descriptor instead.
+func (*RangeNodesRequest) Descriptor() ([]byte, []int) {
+	return file_dagger_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *RangeNodesRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+type EdgesFromRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Node  *NodeRef               `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	// type of the edges, or * for every edge(the default)
+	Type          string `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EdgesFromRequest) Reset() {
+	*x = EdgesFromRequest{}
+	mi := &file_dagger_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EdgesFromRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EdgesFromRequest) ProtoMessage() {}
+
+func (x *EdgesFromRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dagger_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EdgesFromRequest.ProtoReflect.Descriptor instead.
+func (*EdgesFromRequest) Descriptor() ([]byte, []int) {
+	return file_dagger_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *EdgesFromRequest) GetNode() *NodeRef {
+	if x != nil {
+		return x.Node
+	}
+	return nil
+}
+
+func (x *EdgesFromRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+type QueryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryRequest) Reset() {
+	*x = QueryRequest{}
+	mi := &file_dagger_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryRequest) ProtoMessage() {}
+
+func (x *QueryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dagger_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryRequest.ProtoReflect.Descriptor instead.
+func (*QueryRequest) Descriptor() ([]byte, []int) {
+	return file_dagger_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *QueryRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+type QueryResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Columns []string               `protobuf:"bytes,1,rep,name=columns,proto3" json:"columns,omitempty"`
+	// rows hold one value per column. Nodes & edges are returned as structs of their attributes, including _type & _id.
+	Rows          []*structpb.ListValue `protobuf:"bytes,2,rep,name=rows,proto3" json:"rows,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryResponse) Reset() {
+	*x = QueryResponse{}
+	mi := &file_dagger_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryResponse) ProtoMessage() {}
+
+func (x *QueryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_dagger_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryResponse.ProtoReflect.Descriptor instead.
+func (*QueryResponse) Descriptor() ([]byte, []int) {
+	return file_dagger_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *QueryResponse) GetColumns() []string {
+	if x != nil {
+		return x.Columns
+	}
+	return nil
+}
+
+func (x *QueryResponse) GetRows() []*structpb.ListValue {
+	if x != nil {
+		return x.Rows
+	}
+	return nil
+}
+
+var File_dagger_proto protoreflect.FileDescriptor
+
+const file_dagger_proto_rawDesc = "" +
+	"\n" +
+	"\fdagger.proto\x12\rdagger.server\x1a\x1cgoogle/protobuf/struct.proto\"-\n" +
+	"\aNodeRef\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\"c\n" +
+	"\x04Node\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\x127\n" +
+	"\n" +
+	"attributes\x18\x03 \x01(\v2\x17.google.protobuf.StructR\n" +
+	"attributes\"\xb7\x01\n" +
+	"\x04Edge\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\x127\n" +
+	"\n" +
+	"attributes\x18\x03 \x01(\v2\x17.google.protobuf.StructR\n" +
+	"attributes\x12*\n" +
+	"\x04from\x18\x04 \x01(\v2\x16.dagger.server.NodeRefR\x04from\x12&\n" +
+	"\x02to\x18\x05 \x01(\v2\x16.dagger.server.NodeRefR\x02to\"\xd9\x01\n" +
+	"\x0eConnectRequest\x12*\n" +
+	"\x04from\x18\x01 \x01(\v2\x16.dagger.server.NodeRefR\x04from\x12&\n" +
+	"\x02to\x18\x02 \x01(\v2\x16.dagger.server.NodeRefR\x02to\x12\"\n" +
+	"\frelationship\x18\x03 \x01(\tR\frelationship\x12\x16\n" +
+	"\x06mutual\x18\x04 \x01(\bR\x06mutual\x127\n" +
+	"\n" +
+	"attributes\x18\x05 \x01(\v2\x17.google.protobuf.StructR\n" +
+	"attributes\"'\n" +
+	"\x11RangeNodesRequest\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\"R\n" +
+	"\x10EdgesFromRequest\x12*\n" +
+	"\x04node\x18\x01 \x01(\v2\x16.dagger.server.NodeRefR\x04node\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\"$\n" +
+	"\fQueryRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\"Y\n" +
+	"\rQueryResponse\x12\x18\n" +
+	"\acolumns\x18\x01 \x03(\tR\acolumns\x12.\n" +
+	"\x04rows\x18\x02 \x03(\v2\x1a.google.protobuf.ListValueR\x04rows2\x83\x03\n" +
+	"\x05Graph\x123\n" +
+	"\aAddNode\x12\x13.dagger.server.Node\x1a\x13.dagger.server.Node\x126\n" +
+	"\aGetNode\x12\x16.dagger.server.NodeRef\x1a\x13.dagger.server.Node\x12=\n" +
+	"\aConnect\x12\x1d.dagger.server.ConnectRequest\x1a\x13.dagger.server.Edge\x12E\n" +
+	"\n" +
+	"RangeNodes\x12 .dagger.server.RangeNodesRequest\x1a\x13.dagger.server.Node0\x01\x12C\n" +
+	"\tEdgesFrom\x12\x1f.dagger.server.EdgesFromRequest\x1a\x13.dagger.server.Edge0\x01\x12B\n" +
+	"\x05Query\x12\x1b.dagger.server.QueryRequest\x1a\x1c.dagger.server.QueryResponseB$Z\"github.com/autom8ter/dagger/serverb\x06proto3"
+
+var (
+	file_dagger_proto_rawDescOnce sync.Once
+	file_dagger_proto_rawDescData []byte
+)
+
+func file_dagger_proto_rawDescGZIP() []byte {
+	file_dagger_proto_rawDescOnce.Do(func() {
+		file_dagger_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_dagger_proto_rawDesc), len(file_dagger_proto_rawDesc)))
+	})
+	return file_dagger_proto_rawDescData
+}
+
+var file_dagger_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_dagger_proto_goTypes = []any{
+	(*NodeRef)(nil),            // 0: dagger.server.NodeRef
+	(*Node)(nil),               // 1: dagger.server.Node
+	(*Edge)(nil),               // 2: dagger.server.Edge
+	(*ConnectRequest)(nil),     // 3: dagger.server.ConnectRequest
+	(*RangeNodesRequest)(nil),  // 4: dagger.server.RangeNodesRequest
+	(*EdgesFromRequest)(nil),   // 5: dagger.server.EdgesFromRequest
+	(*QueryRequest)(nil),       // 6: dagger.server.QueryRequest
+	(*QueryResponse)(nil),      // 7: dagger.server.QueryResponse
+	(*structpb.Struct)(nil),    // 8: google.protobuf.Struct
+	(*structpb.ListValue)(nil), // 9: google.protobuf.ListValue
+}
+var file_dagger_proto_depIdxs = []int32{
+	8,  // 0: dagger.server.Node.attributes:type_name -> google.protobuf.Struct
+	8,  // 1: dagger.server.Edge.attributes:type_name -> google.protobuf.Struct
+	0,  // 2: dagger.server.Edge.from:type_name -> dagger.server.NodeRef
+	0,  // 3: dagger.server.Edge.to:type_name -> dagger.server.NodeRef
+	0,  // 4: dagger.server.ConnectRequest.from:type_name -> dagger.server.NodeRef
+	0,  // 5: dagger.server.ConnectRequest.to:type_name -> dagger.server.NodeRef
+	8,  // 6: dagger.server.ConnectRequest.attributes:type_name -> google.protobuf.Struct
+	0,  // 7: dagger.server.EdgesFromRequest.node:type_name -> dagger.server.NodeRef
+	9,  // 8: dagger.server.QueryResponse.rows:type_name -> google.protobuf.ListValue
+	1,  // 9: dagger.server.Graph.AddNode:input_type -> dagger.server.Node
+	0,  // 10: dagger.server.Graph.GetNode:input_type -> dagger.server.NodeRef
+	3,  // 11: dagger.server.Graph.Connect:input_type -> dagger.server.ConnectRequest
+	4,  // 12: dagger.server.Graph.RangeNodes:input_type -> dagger.server.RangeNodesRequest
+	5,  // 13: dagger.server.Graph.EdgesFrom:input_type -> dagger.server.EdgesFromRequest
+	6,  // 14: dagger.server.Graph.Query:input_type -> dagger.server.QueryRequest
+	1,  // 15: dagger.server.Graph.AddNode:output_type -> dagger.server.Node
+	1,  // 16: dagger.server.Graph.GetNode:output_type -> dagger.server.Node
+	2,  // 17: dagger.server.Graph.Connect:output_type -> dagger.server.Edge
+	1,  // 18: dagger.server.Graph.RangeNodes:output_type -> dagger.server.Node
+	2,  // 19: dagger.server.Graph.EdgesFrom:output_type -> dagger.server.Edge
+	7,  // 20: dagger.server.Graph.Query:output_type -> dagger.server.QueryResponse
+	15, // [15:21] is the sub-list for method output_type
+	9,  // [9:15] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_dagger_proto_init() }
+func file_dagger_proto_init() {
+	if File_dagger_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_dagger_proto_rawDesc), len(file_dagger_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_dagger_proto_goTypes,
+		DependencyIndexes: file_dagger_proto_depIdxs,
+		MessageInfos:      file_dagger_proto_msgTypes,
+	}.Build()
+	File_dagger_proto = out.File
+	file_dagger_proto_goTypes = nil
+	file_dagger_proto_depIdxs = nil
+}
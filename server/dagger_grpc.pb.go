@@ -0,0 +1,336 @@
+// dagger.proto describes the gRPC service implemented by Server
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.1
+// - protoc             (unknown)
+// source: dagger.proto
+
+package server
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Graph_AddNode_FullMethodName    = "/dagger.server.Graph/AddNode"
+	Graph_GetNode_FullMethodName    = "/dagger.server.Graph/GetNode"
+	Graph_Connect_FullMethodName    = "/dagger.server.Graph/Connect"
+	Graph_RangeNodes_FullMethodName = "/dagger.server.Graph/RangeNodes"
+	Graph_EdgesFrom_FullMethodName  = "/dagger.server.Graph/EdgesFrom"
+	Graph_Query_FullMethodName      = "/dagger.server.Graph/Query"
+)
+
+// GraphClient is the client API for Graph service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Graph shares a dagger graph with services written in any language
+type GraphClient interface {
+	// AddNode adds the node to the graph, or replaces the node with the same type & id. A node without an id is given one.
+	AddNode(ctx context.Context, in *Node, opts ...grpc.CallOption) (*Node, error)
+	// GetNode returns the node with the type & id, or a NOT_FOUND error
+	GetNode(ctx context.Context, in *NodeRef, opts ...grpc.CallOption) (*Node, error)
+	// Connect adds an edge from one node to another
+	Connect(ctx context.Context, in *ConnectRequest, opts ...grpc.CallOption) (*Edge, error)
+	// RangeNodes streams the nodes of a type & its subtypes
+	RangeNodes(ctx context.Context, in *RangeNodesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Node], error)
+	// EdgesFrom streams the edges of a type that stem from a node
+	EdgesFrom(ctx context.Context, in *EdgesFromRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Edge], error)
+	// Query runs a query(see Graph.Query)
+	Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QueryResponse, error)
+}
+
+type graphClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGraphClient(cc grpc.ClientConnInterface) GraphClient {
+	return &graphClient{cc}
+}
+
+func (c *graphClient) AddNode(ctx context.Context, in *Node, opts ...grpc.CallOption) (*Node, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Node)
+	err := c.cc.Invoke(ctx, Graph_AddNode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *graphClient) GetNode(ctx context.Context, in *NodeRef, opts ...grpc.CallOption) (*Node, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Node)
+	err := c.cc.Invoke(ctx, Graph_GetNode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *graphClient) Connect(ctx context.Context, in *ConnectRequest, opts ...grpc.CallOption) (*Edge, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Edge)
+	err := c.cc.Invoke(ctx, Graph_Connect_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *graphClient) RangeNodes(ctx context.Context, in *RangeNodesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Node], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Graph_ServiceDesc.Streams[0], Graph_RangeNodes_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RangeNodesRequest, Node]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Graph_RangeNodesClient = grpc.ServerStreamingClient[Node]
+
+func (c *graphClient) EdgesFrom(ctx context.Context, in *EdgesFromRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Edge], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Graph_ServiceDesc.Streams[1], Graph_EdgesFrom_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[EdgesFromRequest, Edge]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Graph_EdgesFromClient = grpc.ServerStreamingClient[Edge]
+
+func (c *graphClient) Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QueryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QueryResponse)
+	err := c.cc.Invoke(ctx, Graph_Query_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GraphServer is the server API for Graph service.
+// All implementations must embed UnimplementedGraphServer
+// for forward compatibility.
+//
+// Graph shares a dagger graph with services written in any language
+type GraphServer interface {
+	// AddNode adds the node to the graph, or replaces the node with the same type & id. A node without an id is given one.
+	AddNode(context.Context, *Node) (*Node, error)
+	// GetNode returns the node with the type & id, or a NOT_FOUND error
+	GetNode(context.Context, *NodeRef) (*Node, error)
+	// Connect adds an edge from one node to another
+	Connect(context.Context, *ConnectRequest) (*Edge, error)
+	// RangeNodes streams the nodes of a type & its subtypes
+	RangeNodes(*RangeNodesRequest, grpc.ServerStreamingServer[Node]) error
+	// EdgesFrom streams the edges of a type that stem from a node
+	EdgesFrom(*EdgesFromRequest, grpc.ServerStreamingServer[Edge]) error
+	// Query runs a query(see Graph.Query)
+	Query(context.Context, *QueryRequest) (*QueryResponse, error)
+	mustEmbedUnimplementedGraphServer()
+}
+
+// UnimplementedGraphServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedGraphServer struct{}
+
+func (UnimplementedGraphServer) AddNode(context.Context, *Node) (*Node, error) {
+	return nil, status.Error(codes.Unimplemented, "method AddNode not implemented")
+}
+func (UnimplementedGraphServer) GetNode(context.Context, *NodeRef) (*Node, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetNode not implemented")
+}
+func (UnimplementedGraphServer) Connect(context.Context, *ConnectRequest) (*Edge, error) {
+	return nil, status.Error(codes.Unimplemented, "method Connect not implemented")
+}
+func (UnimplementedGraphServer) RangeNodes(*RangeNodesRequest, grpc.ServerStreamingServer[Node]) error {
+	return status.Error(codes.Unimplemented, "method RangeNodes not implemented")
+}
+func (UnimplementedGraphServer) EdgesFrom(*EdgesFromRequest, grpc.ServerStreamingServer[Edge]) error {
+	return status.Error(codes.Unimplemented, "method EdgesFrom not implemented")
+}
+func (UnimplementedGraphServer) Query(context.Context, *QueryRequest) (*QueryResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Query not implemented")
+}
+func (UnimplementedGraphServer) mustEmbedUnimplementedGraphServer() {}
+func (UnimplementedGraphServer) testEmbeddedByValue()               {}
+
+// UnsafeGraphServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GraphServer will
+// result in compilation errors.
+type UnsafeGraphServer interface {
+	mustEmbedUnimplementedGraphServer()
+}
+
+func RegisterGraphServer(s grpc.ServiceRegistrar, srv GraphServer) {
+	// If the following call panics, it indicates UnimplementedGraphServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Graph_ServiceDesc, srv)
+}
+
+func _Graph_AddNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Node)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GraphServer).AddNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Graph_AddNode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GraphServer).AddNode(ctx, req.(*Node))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Graph_GetNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GraphServer).GetNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Graph_GetNode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GraphServer).GetNode(ctx, req.(*NodeRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Graph_Connect_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConnectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GraphServer).Connect(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Graph_Connect_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GraphServer).Connect(ctx, req.(*ConnectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Graph_RangeNodes_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RangeNodesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GraphServer).RangeNodes(m, &grpc.GenericServerStream[RangeNodesRequest, Node]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Graph_RangeNodesServer = grpc.ServerStreamingServer[Node]
+
+func _Graph_EdgesFrom_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(EdgesFromRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GraphServer).EdgesFrom(m, &grpc.GenericServerStream[EdgesFromRequest, Edge]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Graph_EdgesFromServer = grpc.ServerStreamingServer[Edge]
+
+func _Graph_Query_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GraphServer).Query(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Graph_Query_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GraphServer).Query(ctx, req.(*QueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Graph_ServiceDesc is the grpc.ServiceDesc for Graph service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Graph_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "dagger.server.Graph",
+	HandlerType: (*GraphServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddNode",
+			Handler:    _Graph_AddNode_Handler,
+		},
+		{
+			MethodName: "GetNode",
+			Handler:    _Graph_GetNode_Handler,
+		},
+		{
+			MethodName: "Connect",
+			Handler:    _Graph_Connect_Handler,
+		},
+		{
+			MethodName: "Query",
+			Handler:    _Graph_Query_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "RangeNodes",
+			Handler:       _Graph_RangeNodes_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "EdgesFrom",
+			Handler:       _Graph_EdgesFrom_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "dagger.proto",
+}
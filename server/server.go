@@ -0,0 +1,241 @@
+// Package server shares a dagger graph over gRPC, so services written in any language can read & write the same graph.
+// The service is described by dagger.proto: clients in other languages generate their stubs from it.
+//
+// The package is a separate module, so programs that do not serve their graph over gRPC do not depend on grpc.
+package server
+
+//go:generate buf generate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/autom8ter/dagger"
+	"github.com/autom8ter/dagger/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Server implements GraphServer against a graph. Register it with RegisterGraphServer.
+type Server struct {
+	UnimplementedGraphServer
+	graph *dagger.Graph
+}
+
+// NewServer returns a Server that serves the graph
+func NewServer(g *dagger.Graph) *Server {
+	return &Server{graph: g}
+}
+
+// AddNode adds the node to the graph, or replaces the node with the same type & id. A node without an id is given one.
+func (s *Server) AddNode(ctx context.Context, n *Node) (*Node, error) {
+	if n.GetType() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing node type")
+	}
+	data := n.GetAttributes().AsMap()
+	data[primitive.TYPE_KEY] = n.GetType()
+	if n.GetId() != "" {
+		data[primitive.ID_KEY] = n.GetId()
+	}
+	node, err := s.graph.AddNode(data)
+	if err != nil {
+		return nil, statusOf(err)
+	}
+	return nodeOf(node)
+}
+
+// GetNode returns the node with the type & id, or a NotFound error
+func (s *Server) GetNode(ctx context.Context, ref *NodeRef) (*Node, error) {
+	n, err := s.getNode(ref)
+	if err != nil {
+		return nil, err
+	}
+	return nodeOf(n)
+}
+
+// Connect adds an edge from one node to another, setting the attributes on it(& its reverse if mutual)
+func (s *Server) Connect(ctx context.Context, req *ConnectRequest) (*Edge, error) {
+	if req.GetRelationship() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing relationship")
+	}
+	from, err := s.getNode(req.GetFrom())
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.getNode(req.GetTo())
+	if err != nil {
+		return nil, err
+	}
+	e, err := from.Connect(to, req.GetRelationship(), req.GetMutual())
+	if err != nil {
+		return nil, statusOf(err)
+	}
+	if attributes := req.GetAttributes().AsMap(); len(attributes) > 0 {
+		var opts []dagger.PatchOption
+		if req.GetMutual() {
+			opts = append(opts, dagger.PatchReverse())
+		}
+		if err := e.Patch(attributes, opts...); err != nil {
+			return nil, statusOf(err)
+		}
+	}
+	return edgeOf(e)
+}
+
+// RangeNodes streams the nodes of the type & its subtypes. The graph is not locked while the stream is sent, so a slow
+// client does not hold up writers.
+func (s *Server) RangeNodes(req *RangeNodesRequest, stream Graph_RangeNodesServer) error {
+	var sendErr error
+	err := s.graph.RangeNodeTypesCtx(stream.Context(), typeOf(req.GetType()), func(n *dagger.Node) bool {
+		msg, err := nodeOf(n)
+		if err == nil {
+			err = stream.Send(msg)
+		}
+		sendErr = err
+		return err == nil
+	})
+	if sendErr != nil {
+		return sendErr
+	}
+	return statusOf(err)
+}
+
+// EdgesFrom streams the edges of the type that stem from the node
+func (s *Server) EdgesFrom(req *EdgesFromRequest, stream Graph_EdgesFromServer) error {
+	n, err := s.getNode(req.GetNode())
+	if err != nil {
+		return err
+	}
+	n.EdgesFrom(typeOf(req.GetType()), func(e *dagger.Edge) bool {
+		var msg *Edge
+		msg, err = edgeOf(e)
+		if err == nil {
+			err = stream.Send(msg)
+		}
+		return err == nil
+	})
+	return err
+}
+
+// Query runs the query(see Graph.Query), returning nodes & edges as structs of their attributes
+func (s *Server) Query(ctx context.Context, req *QueryRequest) (*QueryResponse, error) {
+	res, err := s.graph.Query(req.GetQuery())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	resp := &QueryResponse{Columns: res.Columns}
+	for _, row := range res.Rows {
+		values := &structpb.ListValue{}
+		for _, v := range row {
+			switch x := v.(type) {
+			case *dagger.Node:
+				v = x.Raw()
+			case *dagger.Edge:
+				v = edgeAttributes(x)
+			}
+			value := &structpb.Value{}
+			if err := convert(v, value); err != nil {
+				return nil, err
+			}
+			values.Values = append(values.Values, value)
+		}
+		resp.Rows = append(resp.Rows, values)
+	}
+	return resp, nil
+}
+
+func (s *Server) getNode(ref *NodeRef) (*dagger.Node, error) {
+	if ref.GetType() == "" || ref.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing node type or id")
+	}
+	n, ok := s.graph.GetNode(&dagger.ForeignKey{XType: ref.GetType(), XID: ref.GetId()})
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "node %s.%s does not exist", ref.GetType(), ref.GetId())
+	}
+	return n, nil
+}
+
+// typeOf returns the type, defaulting to any type
+func typeOf(typ string) primitive.Type {
+	if typ == "" {
+		return dagger.AnyType()
+	}
+	return dagger.StringType(typ)
+}
+
+// statusOf converts an error from the graph to a grpc status error
+func statusOf(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case errors.Is(err, dagger.ErrReadOnly):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, dagger.ErrDuplicateEdge):
+		return status.Error(codes.AlreadyExists, err.Error())
+	}
+	return status.Error(codes.InvalidArgument, err.Error())
+}
+
+// convert converts the value to a protobuf message through json, which every attribute value can be encoded as
+func convert(v interface{}, msg proto.Message) error {
+	bits, err := json.Marshal(v)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to encode %v: %v", v, err)
+	}
+	if err := protojson.Unmarshal(bits, msg); err != nil {
+		return status.Errorf(codes.Internal, "failed to encode %v: %v", v, err)
+	}
+	return nil
+}
+
+// attributesOf returns the attributes other than _type & _id as a struct
+func attributesOf(data map[string]interface{}) (*structpb.Struct, error) {
+	attributes := map[string]interface{}{}
+	for k, v := range data {
+		if k != primitive.TYPE_KEY && k != primitive.ID_KEY {
+			attributes[k] = v
+		}
+	}
+	msg := &structpb.Struct{}
+	if err := convert(attributes, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func nodeOf(n *dagger.Node) (*Node, error) {
+	attributes, err := attributesOf(n.Raw())
+	if err != nil {
+		return nil, err
+	}
+	return &Node{Type: n.Type(), Id: n.ID(), Attributes: attributes}, nil
+}
+
+func edgeAttributes(e *dagger.Edge) map[string]interface{} {
+	data := map[string]interface{}{}
+	e.Range(func(key string, value interface{}) bool {
+		data[key] = value
+		return true
+	})
+	return data
+}
+
+func edgeOf(e *dagger.Edge) (*Edge, error) {
+	attributes, err := attributesOf(edgeAttributes(e))
+	if err != nil {
+		return nil, err
+	}
+	from, to := e.From(), e.To()
+	return &Edge{
+		Type:       e.Type(),
+		Id:         e.ID(),
+		Attributes: attributes,
+		From:       &NodeRef{Type: from.Type(), Id: from.ID()},
+		To:         &NodeRef{Type: to.Type(), Id: to.ID()},
+	}, nil
+}
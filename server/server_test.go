@@ -0,0 +1,153 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"github.com/autom8ter/dagger"
+	"github.com/autom8ter/dagger/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+	"io"
+	"net"
+	"testing"
+)
+
+func dial(t *testing.T, g *dagger.Graph) server.GraphClient {
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	server.RegisterGraphServer(srv, server.NewServer(g))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return server.NewGraphClient(conn)
+}
+
+func attributes(t *testing.T, data map[string]interface{}) *structpb.Struct {
+	s, err := structpb.NewStruct(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+	g := dagger.NewGraph()
+	client := dial(t, g)
+
+	coleman, err := client.AddNode(ctx, &server.Node{Type: "user", Id: "coleman", Attributes: attributes(t, map[string]interface{}{"name": "coleman"})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tyler, err := client.AddNode(ctx, &server.Node{Type: "user", Attributes: attributes(t, map[string]interface{}{"name": "tyler"})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tyler.GetId() == "" {
+		t.Fatal("expected the node to be given an id")
+	}
+	if _, err := client.AddNode(ctx, &server.Node{Id: "typeless"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected an invalid argument error, got %v", err)
+	}
+
+	got, err := client.GetNode(ctx, &server.NodeRef{Type: "user", Id: "coleman"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.GetAttributes().AsMap()["name"] != "coleman" {
+		t.Fatalf("expected coleman's name, got %v", got)
+	}
+	if _, ok := g.GetNode(&dagger.ForeignKey{XType: "user", XID: tyler.GetId()}); !ok {
+		t.Fatal("expected the node to be added to the graph")
+	}
+	if _, err := client.GetNode(ctx, &server.NodeRef{Type: "user", Id: "lacee"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+
+	edge, err := client.Connect(ctx, &server.ConnectRequest{
+		From:         &server.NodeRef{Type: "user", Id: coleman.GetId()},
+		To:           &server.NodeRef{Type: "user", Id: tyler.GetId()},
+		Relationship: "friend",
+		Mutual:       true,
+		Attributes:   attributes(t, map[string]interface{}{"since": 2020}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edge.GetFrom().GetId() != "coleman" || edge.GetTo().GetId() != tyler.GetId() || edge.GetAttributes().AsMap()["since"] != float64(2020) {
+		t.Fatalf("unexpected edge: %v", edge)
+	}
+	if _, err := client.Connect(ctx, &server.ConnectRequest{
+		From:         &server.NodeRef{Type: "user", Id: "coleman"},
+		To:           &server.NodeRef{Type: "user", Id: "lacee"},
+		Relationship: "friend",
+	}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+
+	nodes, err := client.RangeNodes(ctx, &server.RangeNodesRequest{Type: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for {
+		_, err := nodes.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		count++
+	}
+	if count != 2 {
+		t.Fatalf("expected 2 users, got %v", count)
+	}
+
+	edges, err := client.EdgesFrom(ctx, &server.EdgesFromRequest{Node: &server.NodeRef{Type: "user", Id: tyler.GetId()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reverse, err := edges.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reverse.GetTo().GetId() != "coleman" || reverse.GetAttributes().AsMap()["since"] != float64(2020) {
+		t.Fatalf("expected the mutual edge with its attributes, got %v", reverse)
+	}
+	if _, err := edges.Recv(); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected a single edge, got %v", err)
+	}
+
+	res, err := client.Query(ctx, &server.QueryRequest{Query: `MATCH (a:user)-[f:friend]->(b:user) WHERE a.name = "coleman" RETURN b.name, f`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.GetRows()) != 1 || res.GetRows()[0].GetValues()[0].GetStringValue() != "tyler" {
+		t.Fatalf("unexpected query result: %v", res)
+	}
+	if f := res.GetRows()[0].GetValues()[1].GetStructValue().AsMap(); f["_type"] != "friend" || f["since"] != float64(2020) {
+		t.Fatalf("expected the edge's attributes, got %v", f)
+	}
+	if _, err := client.Query(ctx, &server.QueryRequest{Query: "MATCH"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected an invalid argument error, got %v", err)
+	}
+
+	g.SetReadOnly(true)
+	if _, err := client.AddNode(ctx, &server.Node{Type: "user"}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected a failed precondition error, got %v", err)
+	}
+}
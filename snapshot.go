@@ -0,0 +1,177 @@
+package dagger
+
+import (
+	"github.com/autom8ter/dagger/primitive"
+	"io"
+)
+
+// Reader is the read only API of a ReadOnlyGraph. Code that only reads a graph(ex: plugins & report generators) should
+// accept a Reader: neither it nor the NodeViews & EdgeViews it returns have mutation methods, so the compiler rejects
+// writes. Pass Graph.View to hand it a live graph.
+type Reader interface {
+	NodeCount() int
+	EdgeCount() int
+	NodeTypes() []string
+	EdgeTypes() []string
+	HasNode(id primitive.TypedID) bool
+	HasEdge(id primitive.TypedID) bool
+	GetNode(id primitive.TypedID) (*NodeView, bool)
+	GetEdge(id primitive.TypedID) (*EdgeView, bool)
+	RangeNodes(fn func(n *NodeView) bool)
+	RangeNodeTypes(typ primitive.Type, fn func(n *NodeView) bool)
+	RangeEdges(fn func(e *EdgeView) bool)
+	RangeEdgeTypes(edgeType primitive.Type, fn func(e *EdgeView) bool)
+	EdgesFrom(id primitive.TypedID, edgeType primitive.Type, fn func(e *EdgeView) bool)
+	EdgesTo(id primitive.TypedID, edgeType primitive.Type, fn func(e *EdgeView) bool)
+	Hash() string
+	Export() *primitive.Export
+	ExportJSON(w io.Writer, transforms ...primitive.Transform) error
+}
+
+var _ Reader = &ReadOnlyGraph{}
+
+// ReadOnlyGraph is a view of a graph that only exposes methods that read it. The nodes & edges it returns are NodeViews
+// & EdgeViews, which only expose methods that read them.
+type ReadOnlyGraph struct {
+	graph *Graph
+}
+
+// View returns a live, read only view of the graph that observes the graph's writes as they happen. Use Snapshot for
+// a copy that later writes do not change.
+func (g *Graph) View() *ReadOnlyGraph {
+	return &ReadOnlyGraph{graph: &Graph{graph: g.graph, view: true, tracer: g.tracer}}
+}
+
+// View calls Graph.View on the default graph
+func View() *ReadOnlyGraph {
+	return defaultGraph.View()
+}
+
+// Snapshot returns a read only copy of the graph as of the moment it is taken, so long running readers(ex: analytics)
+// can iterate it without blocking writers or observing a half-applied batch of mutations. The copy is not
+// copy-on-write: taking it copies every node & edge, blocking every writer for time proportional to the size of the
+// graph.
+func (g *Graph) Snapshot() *ReadOnlyGraph {
+	return &ReadOnlyGraph{graph: &Graph{graph: g.graph.Snapshot(), tracer: g.tracer}}
+}
+
+// Snapshot calls Graph.Snapshot on the default graph
+func Snapshot() *ReadOnlyGraph {
+	return defaultGraph.Snapshot()
+}
+
+// Clone returns an independent, writable deep copy of the graph. Mutations on the clone never leak into the graph(or
+// vice versa).
+func (g *Graph) Clone() *Graph {
+	return &Graph{graph: g.graph.Clone(), tracer: g.tracer}
+}
+
+// Clone calls Graph.Clone on the default graph
+func Clone() *Graph {
+	return defaultGraph.Clone()
+}
+
+// NodeCount returns the total number of nodes in the graph
+func (r *ReadOnlyGraph) NodeCount() int {
+	return r.graph.NodeCount()
+}
+
+// EdgeCount returns the total number of edges in the graph
+func (r *ReadOnlyGraph) EdgeCount() int {
+	return r.graph.EdgeCount()
+}
+
+// NodeTypes returns every node type in the graph
+func (r *ReadOnlyGraph) NodeTypes() []string {
+	return r.graph.NodeTypes()
+}
+
+// EdgeTypes returns every edge type in the graph
+func (r *ReadOnlyGraph) EdgeTypes() []string {
+	return r.graph.EdgeTypes()
+}
+
+// HasNode returns true if a node with the typed ID exists in the graph
+func (r *ReadOnlyGraph) HasNode(id primitive.TypedID) bool {
+	return r.graph.HasNode(id)
+}
+
+// HasEdge returns true if an edge with the typed ID exists in the graph
+func (r *ReadOnlyGraph) HasEdge(id primitive.TypedID) bool {
+	return r.graph.HasEdge(id)
+}
+
+// GetNode gets an existing node by its typed ID
+func (r *ReadOnlyGraph) GetNode(id primitive.TypedID) (*NodeView, bool) {
+	n, ok := r.graph.GetNode(id)
+	if !ok {
+		return nil, false
+	}
+	return nodeView(n), true
+}
+
+// GetEdge gets an existing edge by its typed ID
+func (r *ReadOnlyGraph) GetEdge(id primitive.TypedID) (*EdgeView, bool) {
+	e, ok := r.graph.GetEdge(id)
+	if !ok {
+		return nil, false
+	}
+	return edgeView(e), true
+}
+
+// RangeNodes iterates over all nodes until the iterator returns false
+func (r *ReadOnlyGraph) RangeNodes(fn func(n *NodeView) bool) {
+	r.graph.RangeNodes(func(n *Node) bool {
+		return fn(nodeView(n))
+	})
+}
+
+// RangeNodeTypes iterates over nodes of a given type until the iterator returns false
+func (r *ReadOnlyGraph) RangeNodeTypes(typ primitive.Type, fn func(n *NodeView) bool) {
+	r.graph.RangeNodeTypes(typ, func(n *Node) bool {
+		return fn(nodeView(n))
+	})
+}
+
+// RangeEdges iterates over all edges until the iterator returns false
+func (r *ReadOnlyGraph) RangeEdges(fn func(e *EdgeView) bool) {
+	r.graph.RangeEdges(func(e *Edge) bool {
+		return fn(edgeView(e))
+	})
+}
+
+// RangeEdgeTypes iterates over edges of a given type until the iterator returns false
+func (r *ReadOnlyGraph) RangeEdgeTypes(edgeType primitive.Type, fn func(e *EdgeView) bool) {
+	r.graph.RangeEdgeTypes(edgeType, func(e *Edge) bool {
+		return fn(edgeView(e))
+	})
+}
+
+// EdgesFrom iterates over the edges of the given type that stem from the node until the iterator returns false
+func (r *ReadOnlyGraph) EdgesFrom(id primitive.TypedID, edgeType primitive.Type, fn func(e *EdgeView) bool) {
+	r.graph.EdgesFrom(id, edgeType, func(e *Edge) bool {
+		return fn(edgeView(e))
+	})
+}
+
+// EdgesTo iterates over the edges of the given type that point toward the node until the iterator returns false
+func (r *ReadOnlyGraph) EdgesTo(id primitive.TypedID, edgeType primitive.Type, fn func(e *EdgeView) bool) {
+	r.graph.EdgesTo(id, edgeType, func(e *Edge) bool {
+		return fn(edgeView(e))
+	})
+}
+
+// Hash returns a digest of the graph's contents
+func (r *ReadOnlyGraph) Hash() string {
+	return r.graph.Hash()
+}
+
+// Export returns a copy of the graph's nodes & edges
+func (r *ReadOnlyGraph) Export() *primitive.Export {
+	return r.graph.Export()
+}
+
+// ExportJSON exports the graph as JSON to the io Writer
+func (r *ReadOnlyGraph) ExportJSON(w io.Writer, transforms ...primitive.Transform) error {
+	return r.graph.ExportJSON(w, transforms...)
+}
@@ -0,0 +1,260 @@
+package dagger
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/autom8ter/dagger/primitive"
+	"sort"
+	"strings"
+)
+
+type sqlConfig struct {
+	prefix      string
+	placeholder func(i int) string
+	transforms  []primitive.Transform
+}
+
+// SQLOption configures ExportSQL
+type SQLOption func(c *sqlConfig)
+
+// SQLTransforms applies the transforms(ex: primitive.HashAttributes) to a copy of every exported node & edge, as in
+// ExportJSON
+func SQLTransforms(transforms ...primitive.Transform) SQLOption {
+	return func(c *sqlConfig) {
+		c.transforms = append(c.transforms, transforms...)
+	}
+}
+
+// SQLTablePrefix prefixes every table created by ExportSQL
+func SQLTablePrefix(prefix string) SQLOption {
+	return func(c *sqlConfig) {
+		c.prefix = prefix
+	}
+}
+
+// SQLDollarPlaceholders uses $1, $2... bind parameters(postgres) instead of ?(duckdb, sqlite, mysql)
+func SQLDollarPlaceholders() SQLOption {
+	return func(c *sqlConfig) {
+		c.placeholder = func(i int) string {
+			return fmt.Sprintf("$%v", i)
+		}
+	}
+}
+
+// ExportSQL materializes the graph as relational tables using any database/sql driver(ex: duckdb).
+// Each node type becomes a node_<type> table and each edge type an edge_<type> table, with one column per attribute.
+// The columns of a node type with a registered schema are typed by the schema, and include the attributes it declares
+// even if no node has them; other columns are typed by the values they hold(TEXT if they hold several kinds).
+// Edge tables also carry the _from_type, _from_id, _to_type & _to_id of their endpoints.
+// Exporting again replaces the rows of the graph's tables, adding columns for attributes they don't have yet. Tables of
+// types that are no longer in the graph are left as they are.
+func (g *Graph) ExportSQL(db *sql.DB, opts ...SQLOption) error {
+	defer g.trace("ExportSQL", nil)()
+	c := &sqlConfig{
+		placeholder: func(i int) string {
+			return "?"
+		},
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	export := g.export(c.transforms)
+	nodes := map[string][]map[string]interface{}{}
+	for _, n := range export.Nodes {
+		nodes[n.Type()] = append(nodes[n.Type()], n)
+	}
+	edges := map[string][]map[string]interface{}{}
+	for _, e := range export.Edges {
+		row := e.Node.Copy()
+		row["_from_type"] = e.From.Type()
+		row["_from_id"] = e.From.ID()
+		row["_to_type"] = e.To.Type()
+		row["_to_id"] = e.To.ID()
+		edges[e.Type()] = append(edges[e.Type()], row)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	for typ, rows := range nodes {
+		if err := c.writeTable(tx, c.prefix+"node_"+typ, schemaColumns(g.graph.SchemasOf(typ)), rows); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	for typ, rows := range edges {
+		if err := c.writeTable(tx, c.prefix+"edge_"+typ, nil, rows); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ExportSQL calls Graph.ExportSQL on the default graph
+func ExportSQL(db *sql.DB, opts ...SQLOption) error {
+	return defaultGraph.ExportSQL(db, opts...)
+}
+
+// schemaColumns returns the column types declared by the schemas, or nil if there are none
+func schemaColumns(schemas []primitive.Schema) map[string]string {
+	if len(schemas) == 0 {
+		return nil
+	}
+	columns := map[string]string{primitive.ID_KEY: "TEXT"}
+	declare := func(name, typ string) {
+		if existing, ok := columns[name]; ok && existing != typ {
+			typ = "TEXT"
+		}
+		columns[name] = typ
+	}
+	for _, schema := range schemas {
+		for name, kind := range schema.Attributes {
+			declare(name, sqlKindType(kind))
+		}
+		for _, name := range schema.Required {
+			if _, ok := schema.Attributes[name]; !ok {
+				declare(name, "TEXT")
+			}
+		}
+	}
+	return columns
+}
+
+// writeTable replaces the rows of the table, creating it & its columns as needed. Declared columns keep their types;
+// the others are inferred from the rows.
+func (c *sqlConfig) writeTable(tx *sql.Tx, table string, declared map[string]string, rows []map[string]interface{}) error {
+	columns := map[string]string{}
+	for k, typ := range declared {
+		columns[k] = typ
+	}
+	for _, row := range rows {
+		for k, v := range row {
+			if _, ok := declared[k]; ok || k == primitive.TYPE_KEY {
+				continue
+			}
+			typ := sqlType(v)
+			if existing, ok := columns[k]; ok && existing != typ {
+				typ = "TEXT"
+			}
+			columns[k] = typ
+		}
+	}
+	var names []string
+	for k := range columns {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var defs, quoted, params []string
+	for i, name := range names {
+		def := fmt.Sprintf("%s %s", quoteIdent(name), columns[name])
+		if name == primitive.ID_KEY {
+			def += " PRIMARY KEY"
+		}
+		defs = append(defs, def)
+		quoted = append(quoted, quoteIdent(name))
+		params = append(params, c.placeholder(i+1))
+	}
+	if _, err := tx.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", quoteIdent(table), strings.Join(defs, ", "))); err != nil {
+		return fmt.Errorf("failed to create table %s: %w", table, err)
+	}
+	// the table may be left from a previous export
+	existing, err := tableColumns(tx, table)
+	if err != nil {
+		return fmt.Errorf("failed to read the columns of table %s: %w", table, err)
+	}
+	for _, name := range names {
+		if existing[name] {
+			continue
+		}
+		if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", quoteIdent(table), quoteIdent(name), columns[name])); err != nil {
+			return fmt.Errorf("failed to add column %s to table %s: %w", name, table, err)
+		}
+	}
+	if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s", quoteIdent(table))); err != nil {
+		return fmt.Errorf("failed to clear table %s: %w", table, err)
+	}
+	stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quoteIdent(table), strings.Join(quoted, ", "), strings.Join(params, ", ")))
+	if err != nil {
+		return fmt.Errorf("failed to insert into table %s: %w", table, err)
+	}
+	defer stmt.Close()
+	for _, row := range rows {
+		var values []interface{}
+		for _, name := range names {
+			values = append(values, sqlValue(row[name], columns[name]))
+		}
+		if _, err := stmt.Exec(values...); err != nil {
+			return fmt.Errorf("failed to insert %v into table %s: %w", row[primitive.ID_KEY], table, err)
+		}
+	}
+	return nil
+}
+
+// tableColumns returns the names of the table's columns
+func tableColumns(tx *sql.Tx, table string) (map[string]bool, error) {
+	rows, err := tx.Query(fmt.Sprintf("SELECT * FROM %s WHERE 1 = 0", quoteIdent(table)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]bool{}
+	for _, name := range names {
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
+func sqlType(v interface{}) string {
+	switch v.(type) {
+	case int, int32, int64:
+		return "BIGINT"
+	case float32, float64:
+		return "DOUBLE"
+	case bool:
+		return "BOOLEAN"
+	default:
+		return "TEXT"
+	}
+}
+
+// sqlKindType returns the column type of a schema value kind
+func sqlKindType(kind string) string {
+	switch kind {
+	case "int":
+		return "BIGINT"
+	case "float":
+		return "DOUBLE"
+	case "bool":
+		return "BOOLEAN"
+	default:
+		return "TEXT"
+	}
+}
+
+func sqlValue(v interface{}, columnType string) interface{} {
+	if v == nil {
+		return nil
+	}
+	if columnType != "TEXT" {
+		return v
+	}
+	switch v.(type) {
+	case string:
+		return v
+	case map[string]interface{}, primitive.Node, []interface{}:
+		bits, _ := json.Marshal(v)
+		return string(bits)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
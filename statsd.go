@@ -0,0 +1,76 @@
+package dagger
+
+import (
+	"fmt"
+	"github.com/autom8ter/dagger/primitive"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StatsD is a metrics sink that writes to a StatsD(or Datadog agent) server over UDP
+type StatsD struct {
+	mu     sync.Mutex
+	conn   net.Conn
+	prefix string
+	tags   bool
+}
+
+// NewStatsD dials the StatsD server at addr(ex: localhost:8125). Metric names are prefixed with prefix.
+// If dogstatsd is true, tags are sent in the Datadog format, otherwise they are dropped.
+func NewStatsD(addr, prefix string, dogstatsd bool) (*StatsD, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, ".") {
+		prefix += "."
+	}
+	return &StatsD{
+		conn:   conn,
+		prefix: prefix,
+		tags:   dogstatsd,
+	}, nil
+}
+
+// Count sends a counter increment
+func (s *StatsD) Count(name string, value int64, tags map[string]string) {
+	s.send(fmt.Sprintf("%s%s:%d|c", s.prefix, name, value), tags)
+}
+
+// Timing sends a timing in milliseconds
+func (s *StatsD) Timing(name string, value time.Duration, tags map[string]string) {
+	s.send(fmt.Sprintf("%s%s:%g|ms", s.prefix, name, float64(value)/float64(time.Millisecond)), tags)
+}
+
+func (s *StatsD) send(metric string, tags map[string]string) {
+	if s.tags && len(tags) > 0 {
+		var pairs []string
+		for k, v := range tags {
+			pairs = append(pairs, k+":"+v)
+		}
+		sort.Strings(pairs)
+		metric += "|#" + strings.Join(pairs, ",")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// metrics are best effort - a dropped packet must never fail a graph operation
+	s.conn.Write([]byte(metric))
+}
+
+// Close closes the connection to the StatsD server
+func (s *StatsD) Close() error {
+	return s.conn.Close()
+}
+
+// SetMetricsSink sets the sink that receives the graph's mutation counters and traversal timings(ex: NewStatsD)
+func (g *Graph) SetMetricsSink(sink primitive.MetricsSink) {
+	g.graph.SetMetricsSink(sink)
+}
+
+// SetMetricsSink calls Graph.SetMetricsSink on the default graph
+func SetMetricsSink(sink primitive.MetricsSink) {
+	defaultGraph.SetMetricsSink(sink)
+}
@@ -0,0 +1,92 @@
+package dagger
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/autom8ter/dagger/primitive"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// SyncFrom converges the graph to the peer's state, transferring only the merkle buckets that differ
+func (g *Graph) SyncFrom(peer primitive.SyncPeer) (*primitive.SyncResult, error) {
+	return g.graph.SyncFrom(peer)
+}
+
+// SyncFrom calls Graph.SyncFrom on the default graph
+func SyncFrom(peer primitive.SyncPeer) (*primitive.SyncResult, error) {
+	return defaultGraph.SyncFrom(peer)
+}
+
+// SyncHandler returns an http handler that serves the graph's merkle tree(GET /merkle) and buckets
+// (GET /bucket?key=node/user/3f) so remote instances can sync from it with an HTTPPeer
+func (g *Graph) SyncHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/merkle", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(g.graph.MerkleTree())
+	})
+	mux.HandleFunc("/bucket", func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("key")
+		if key == "" {
+			http.Error(w, "missing bucket key", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(g.graph.Bucket(key))
+	})
+	return mux
+}
+
+// SyncHandler calls Graph.SyncHandler on the default graph
+func SyncHandler() http.Handler {
+	return defaultGraph.SyncHandler()
+}
+
+// HTTPPeer is a primitive.SyncPeer served by a remote SyncHandler
+type HTTPPeer struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewHTTPPeer creates a peer for the SyncHandler mounted at the base url. If client is nil, http.DefaultClient is used.
+func NewHTTPPeer(baseURL string, client *http.Client) *HTTPPeer {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPPeer{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  client,
+	}
+}
+
+// MerkleTree fetches the peer's merkle tree
+func (p *HTTPPeer) MerkleTree() (*primitive.MerkleTree, error) {
+	tree := &primitive.MerkleTree{}
+	if err := p.get("/merkle", tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// Bucket fetches the nodes & edges in one of the peer's buckets
+func (p *HTTPPeer) Bucket(key string) (*primitive.Export, error) {
+	exp := &primitive.Export{}
+	if err := p.get("/bucket?key="+url.QueryEscape(key), exp); err != nil {
+		return nil, err
+	}
+	return exp, nil
+}
+
+func (p *HTTPPeer) get(path string, v interface{}) error {
+	resp, err := p.client.Get(p.baseURL + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("dagger: sync peer responded %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
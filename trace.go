@@ -0,0 +1,76 @@
+package dagger
+
+import (
+	"context"
+	"fmt"
+)
+
+// TracerName is the instrumentation name graphs pass to TracerProvider.Tracer
+const TracerName = "github.com/autom8ter/dagger"
+
+// Span attribute keys
+const (
+	AttributeNodeType = "dagger.node.type"
+	AttributeEdgeType = "dagger.edge.type"
+	AttributeFromType = "dagger.from.type"
+	AttributeToType   = "dagger.to.type"
+	AttributeCodec    = "dagger.codec"
+)
+
+// TracerProvider hands out the tracer a graph starts spans with. To trace with OpenTelemetry, use the
+// github.com/autom8ter/dagger/tracing module's WithTracerProvider, which adapts an OpenTelemetry TracerProvider without
+// this package depending on OpenTelemetry.
+type TracerProvider interface {
+	Tracer(name string) Tracer
+}
+
+// Tracer starts spans around graph operations(ex: by calling an OpenTelemetry tracer's Start with the attributes)
+type Tracer interface {
+	Start(ctx context.Context, spanName string, attributes map[string]string) (context.Context, Span)
+}
+
+// Span is an operation started by a Tracer
+type Span interface {
+	End()
+}
+
+// WithTracerProvider starts a span around the graph's operations using the tracer the provider returns for TracerName.
+// The traced operations are:
+//   - writes: AddEdge(Node.Connect), DelNode & DelEdge
+//   - iteration: RangeNodes, RangeNodeTypes, RangeEdges & RangeEdgeTypes(and their Ctx variants)
+//   - traversals & queries: Node.BFS, ShortestPath, ShortestWeightedPath, Match, Query & QueryBuilder.Execute
+//   - imports & exports: Export, ExportJSON(and ExportJSONGzip, snapshots), ImportJSON, ImportJSONParallel,
+//     ExportNDJSON, ImportNDJSON, ExportSince, ImportChanges, ImportNodesCSV, ImportEdgesCSV, ExportDOT, ExportMermaid,
+//     ExportCypher, ExportCytoscape, ExportSQL, LoadSeed, and Encode & Decode with the codec as an attribute(which
+//     covers ExportProto, ExportNTriples, GraphML, GEXF, Gob & MsgPack)
+//
+// Operations that take a context start their span as a child of the context's span.
+func WithTracerProvider(tp TracerProvider) Option {
+	return func(g *Graph) {
+		g.tracer = tp.Tracer(TracerName)
+	}
+}
+
+// startSpan starts a span for the operation if the graph has a tracer. The returned function ends it.
+func (g *Graph) startSpan(ctx context.Context, operation string, attributes map[string]string) (context.Context, func()) {
+	if g.tracer == nil {
+		return ctx, func() {}
+	}
+	ctx, span := g.tracer.Start(ctx, "dagger."+operation, attributes)
+	return ctx, span.End
+}
+
+// trace starts a span for an operation that does not take a context. The returned function ends it.
+func (g *Graph) trace(operation string, attributes map[string]string) func() {
+	_, end := g.startSpan(context.Background(), operation, attributes)
+	return end
+}
+
+// codecAttribute names the codec(ex: encoding.GraphML) an encode or decode is traced with
+func codecAttribute(codec interface{}) map[string]string {
+	return map[string]string{AttributeCodec: fmt.Sprintf("%T", codec)}
+}
+
+func typeAttribute(key string, typ interface{ Type() string }) map[string]string {
+	return map[string]string{key: typ.Type()}
+}
@@ -0,0 +1,53 @@
+// Package tracing traces a dagger graph's operations with OpenTelemetry, ex:
+//
+//	g := dagger.NewGraph(tracing.WithTracerProvider(otel.GetTracerProvider()))
+//
+// Spans are started around edge creation, node & edge deletion, Range* iteration, traversals, queries and imports &
+// exports(see dagger.WithTracerProvider for the full list), with the types of the nodes & edges involved or the codec
+// as attributes. Operations that take a context start their span as a child of the context's span.
+//
+// The package is a separate module, so programs that do not trace their graph do not depend on OpenTelemetry.
+package tracing
+
+import (
+	"context"
+	"github.com/autom8ter/dagger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracerProvider traces the graph with the tracer the provider returns for dagger.TracerName
+func WithTracerProvider(tp trace.TracerProvider) dagger.Option {
+	return dagger.WithTracerProvider(provider{tp})
+}
+
+// provider adapts an OpenTelemetry TracerProvider to a dagger.TracerProvider
+type provider struct {
+	tp trace.TracerProvider
+}
+
+func (p provider) Tracer(name string) dagger.Tracer {
+	return tracer{p.tp.Tracer(name)}
+}
+
+type tracer struct {
+	tracer trace.Tracer
+}
+
+func (t tracer) Start(ctx context.Context, spanName string, attributes map[string]string) (context.Context, dagger.Span) {
+	var attrs []attribute.KeyValue
+	for k, v := range attributes {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+	ctx, s := t.tracer.Start(ctx, spanName, trace.WithSpanKind(trace.SpanKindInternal), trace.WithAttributes(attrs...))
+	return ctx, span{s}
+}
+
+// span adapts an OpenTelemetry Span, whose End takes options, to a dagger.Span
+type span struct {
+	span trace.Span
+}
+
+func (s span) End() {
+	s.span.End()
+}
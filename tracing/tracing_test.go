@@ -0,0 +1,47 @@
+package tracing_test
+
+import (
+	"context"
+	"github.com/autom8ter/dagger"
+	"github.com/autom8ter/dagger/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+func TestWithTracerProvider(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	g := dagger.NewGraph(tracing.WithTracerProvider(tp))
+	coleman := g.NewNode(map[string]interface{}{"_type": "user", "_id": "coleman"})
+	charlie := g.NewNode(map[string]interface{}{"_type": "dog", "_id": "charlie"})
+	if _, err := coleman.Connect(charlie, "pet", false); err != nil {
+		t.Fatal(err)
+	}
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+	if err := g.RangeNodeTypesCtx(ctx, dagger.StringType("dog"), func(n *dagger.Node) bool { return true }); err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %v", len(spans))
+	}
+	addEdge := spans[0]
+	if addEdge.Name() != "dagger.AddEdge" || addEdge.InstrumentationScope().Name != dagger.TracerName {
+		t.Fatalf("expected an AddEdge span from the dagger tracer, got %v %v", addEdge.Name(), addEdge.InstrumentationScope())
+	}
+	attributes := map[attribute.Key]string{}
+	for _, kv := range addEdge.Attributes() {
+		attributes[kv.Key] = kv.Value.AsString()
+	}
+	if attributes[dagger.AttributeEdgeType] != "pet" || attributes[dagger.AttributeFromType] != "user" || attributes[dagger.AttributeToType] != "dog" {
+		t.Fatalf("expected the edge & node types as attributes, got %v", attributes)
+	}
+	rangeNodes := spans[1]
+	if rangeNodes.Name() != "dagger.RangeNodeTypes" || rangeNodes.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatalf("expected a RangeNodeTypes span in the request's trace, got %v", rangeNodes.Name())
+	}
+}
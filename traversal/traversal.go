@@ -0,0 +1,304 @@
+// Package traversal composes gremlin-style traversal steps that are executed lazily against a graph, ex:
+//
+//	traversal.New(g).V().Has("_type", "user").Out("friend").Dedup().Limit(10).ToList()
+//
+// Steps only describe the traversal. Nothing is read from the graph until a terminal step(Iterate, ToList, Paths) is
+// called, and nodes are pulled through the steps one at a time, so Limit stops the traversal as soon as it is satisfied.
+package traversal
+
+import (
+	"context"
+	"github.com/autom8ter/dagger/primitive"
+	"reflect"
+	"sort"
+)
+
+// Traverser is a node flowing through a traversal along with the nodes it visited to reach it. Path is only recorded
+// if the traversal includes the Path step.
+type Traverser struct {
+	Node primitive.Node
+	Path []primitive.Node
+}
+
+// iterator returns the next traverser, or false once it is exhausted
+type iterator func() (*Traverser, bool)
+
+type step func(next iterator, t *Traversal) iterator
+
+// Traversal is a chain of steps. Each step returns the traversal so they can be chained.
+type Traversal struct {
+	graph *primitive.Graph
+	start func() []primitive.Node
+	steps []step
+	paths bool
+}
+
+// Source starts traversals against a graph
+type Source struct {
+	graph *primitive.Graph
+}
+
+// New returns a traversal source for the graph
+func New(g *primitive.Graph) *Source {
+	return &Source{graph: g}
+}
+
+// V starts a traversal from the nodes with the given ids, or every node in the graph if no ids are given
+func (s *Source) V(ids ...primitive.TypedID) *Traversal {
+	return &Traversal{
+		graph: s.graph,
+		start: func() []primitive.Node {
+			var nodes []primitive.Node
+			if len(ids) == 0 {
+				s.graph.RangeNodes(func(n primitive.Node) bool {
+					nodes = append(nodes, n)
+					return true
+				})
+				return nodes
+			}
+			for _, id := range ids {
+				if n, ok := s.graph.GetNode(id); ok {
+					nodes = append(nodes, n)
+				}
+			}
+			return nodes
+		},
+	}
+}
+
+func (t *Traversal) step(s step) *Traversal {
+	t.steps = append(t.steps, s)
+	return t
+}
+
+// Out moves to the nodes each node points to over edges of the given types, or every type if none are given
+func (t *Traversal) Out(edgeTypes ...string) *Traversal {
+	return t.step(expand(func(n primitive.Node, typ primitive.Type, fn func(primitive.Node)) {
+		t.graph.EdgesFrom(typ, n, func(e *primitive.Edge) bool {
+			fn(e.To)
+			return true
+		})
+	}, edgeTypes))
+}
+
+// In moves to the nodes pointing to each node over edges of the given types, or every type if none are given
+func (t *Traversal) In(edgeTypes ...string) *Traversal {
+	return t.step(expand(func(n primitive.Node, typ primitive.Type, fn func(primitive.Node)) {
+		t.graph.EdgesTo(typ, n, func(e *primitive.Edge) bool {
+			fn(e.From)
+			return true
+		})
+	}, edgeTypes))
+}
+
+// Both moves to the nodes connected to each node in either direction over edges of the given types, or every type if
+// none are given
+func (t *Traversal) Both(edgeTypes ...string) *Traversal {
+	return t.step(expand(func(n primitive.Node, typ primitive.Type, fn func(primitive.Node)) {
+		t.graph.EdgesFrom(typ, n, func(e *primitive.Edge) bool {
+			fn(e.To)
+			return true
+		})
+		t.graph.EdgesTo(typ, n, func(e *primitive.Edge) bool {
+			fn(e.From)
+			return true
+		})
+	}, edgeTypes))
+}
+
+// Has keeps the nodes whose attribute equals the value. Numbers are compared by value regardless of their type.
+func (t *Traversal) Has(key string, value interface{}) *Traversal {
+	return t.Filter(func(n primitive.Node) bool {
+		return n.Exists(key) && equal(n.Get(key), value)
+	})
+}
+
+// Filter keeps the nodes that pass the predicate
+func (t *Traversal) Filter(fn func(n primitive.Node) bool) *Traversal {
+	return t.step(func(next iterator, _ *Traversal) iterator {
+		return func() (*Traverser, bool) {
+			for {
+				tr, ok := next()
+				if !ok {
+					return nil, false
+				}
+				if fn(tr.Node) {
+					return tr, true
+				}
+			}
+		}
+	})
+}
+
+// Dedup drops nodes that were already emitted by the step
+func (t *Traversal) Dedup() *Traversal {
+	return t.step(func(next iterator, _ *Traversal) iterator {
+		seen := map[string]bool{}
+		return func() (*Traverser, bool) {
+			for {
+				tr, ok := next()
+				if !ok {
+					return nil, false
+				}
+				key := tr.Node.Type() + "." + tr.Node.ID()
+				if !seen[key] {
+					seen[key] = true
+					return tr, true
+				}
+			}
+		}
+	})
+}
+
+// Limit stops the traversal after n nodes pass the step
+func (t *Traversal) Limit(n int) *Traversal {
+	return t.step(func(next iterator, _ *Traversal) iterator {
+		count := 0
+		return func() (*Traverser, bool) {
+			if count >= n {
+				return nil, false
+			}
+			tr, ok := next()
+			if ok {
+				count++
+			}
+			return tr, ok
+		}
+	})
+}
+
+// Path records the nodes each traverser visited, from the node it started at to its current node, so they are
+// available from Traverser.Path & Paths
+func (t *Traversal) Path() *Traversal {
+	t.paths = true
+	return t
+}
+
+// Iterate runs the traversal, passing each traverser that reaches the end to fn until fn returns false
+func (t *Traversal) Iterate(fn func(tr *Traverser) bool) {
+	t.IterateCtx(context.Background(), fn)
+}
+
+// IterateCtx is like Iterate, but stops the traversal & returns the context's error once it is done. The context is
+// checked before each node is pulled into the traversal.
+func (t *Traversal) IterateCtx(ctx context.Context, fn func(tr *Traverser) bool) error {
+	start := t.start()
+	i := 0
+	var err error
+	next := iterator(func() (*Traverser, bool) {
+		if err = ctx.Err(); err != nil || i >= len(start) {
+			return nil, false
+		}
+		n := start[i]
+		i++
+		tr := &Traverser{Node: n}
+		if t.paths {
+			tr.Path = []primitive.Node{n}
+		}
+		return tr, true
+	})
+	for _, s := range t.steps {
+		next = s(next, t)
+	}
+	for {
+		tr, ok := next()
+		if !ok || !fn(tr) {
+			return err
+		}
+	}
+}
+
+// ToList runs the traversal & returns the nodes that reach the end
+func (t *Traversal) ToList() []primitive.Node {
+	var nodes []primitive.Node
+	t.Iterate(func(tr *Traverser) bool {
+		nodes = append(nodes, tr.Node)
+		return true
+	})
+	return nodes
+}
+
+// Paths runs the traversal & returns the path of every traverser that reaches the end. It implies the Path step.
+func (t *Traversal) Paths() [][]primitive.Node {
+	t.paths = true
+	var paths [][]primitive.Node
+	t.Iterate(func(tr *Traverser) bool {
+		paths = append(paths, tr.Path)
+		return true
+	})
+	return paths
+}
+
+// expand returns a step that replaces each traverser with one per node found by the lookup over each edge type.
+// The neighbors of a traverser are only looked up once the previous ones have been pulled.
+func expand(lookup func(n primitive.Node, typ primitive.Type, fn func(primitive.Node)), edgeTypes []string) step {
+	types := []primitive.Type{stringType(primitive.AnyType)}
+	if len(edgeTypes) > 0 {
+		types = nil
+		for _, typ := range edgeTypes {
+			types = append(types, stringType(typ))
+		}
+	}
+	return func(next iterator, t *Traversal) iterator {
+		var current *Traverser
+		var pending []primitive.Node
+		return func() (*Traverser, bool) {
+			for len(pending) == 0 {
+				tr, ok := next()
+				if !ok {
+					return nil, false
+				}
+				current = tr
+				for _, typ := range types {
+					lookup(tr.Node, typ, func(n primitive.Node) {
+						pending = append(pending, n)
+					})
+				}
+				sort.Slice(pending, func(i, j int) bool {
+					return pending[i].Type()+"."+pending[i].ID() < pending[j].Type()+"."+pending[j].ID()
+				})
+			}
+			n := pending[0]
+			pending = pending[1:]
+			if neighbor, ok := t.graph.GetNode(n); ok {
+				n = neighbor
+			}
+			tr := &Traverser{Node: n}
+			if t.paths {
+				tr.Path = append(append([]primitive.Node{}, current.Path...), n)
+			}
+			return tr, true
+		}
+	}
+}
+
+// equal compares attribute values, treating numbers of different types as equal if they hold the same value
+func equal(a, b interface{}) bool {
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+type stringType string
+
+func (s stringType) Type() string {
+	return string(s)
+}
@@ -0,0 +1,92 @@
+package dagger
+
+import (
+	"github.com/autom8ter/dagger/primitive"
+	"time"
+)
+
+// SetTTL expires the node after the duration. Expired nodes, and every edge to or from them, are removed by Expire or
+// a janitor started with StartJanitor.
+func (n *Node) SetTTL(d time.Duration) error {
+	if err := n.owner().writable(); err != nil {
+		return err
+	}
+	return n.owner().graph.SetNodeTTL(n, d)
+}
+
+// SetTTL expires the edge after the duration. Expired edges are removed by Expire or a janitor started with
+// StartJanitor.
+func (e *Edge) SetTTL(d time.Duration) error {
+	if err := e.owner().writable(); err != nil {
+		return err
+	}
+	return e.owner().graph.SetEdgeTTL(e, d)
+}
+
+// Expire removes the nodes & edges that have expired, returning the number of nodes & edges removed. Nodes & edges an
+// OnNodeExpiring or OnEdgeExpiring callback extends are kept.
+func (g *Graph) Expire() (int, int, error) {
+	return g.graph.Expire(time.Now())
+}
+
+// Expire calls Graph.Expire on the default graph
+func Expire() (int, int, error) {
+	return defaultGraph.Expire()
+}
+
+// StartJanitor removes expired nodes & edges in the background every interval until the returned stop function is
+// called. Errors are passed to onError if it is not nil.
+func (g *Graph) StartJanitor(interval time.Duration, onError func(err error)) (stop func()) {
+	return g.graph.StartJanitor(interval, onError)
+}
+
+// StartJanitor calls Graph.StartJanitor on the default graph
+func StartJanitor(interval time.Duration, onError func(err error)) (stop func()) {
+	return defaultGraph.StartJanitor(interval, onError)
+}
+
+// OnNodeExpiring registers a callback that is passed every node about to expire. Returning a positive duration keeps
+// the node & extends its TTL by the duration instead. When several callbacks are registered, the longest extension wins.
+func (g *Graph) OnNodeExpiring(fn func(n *Node) time.Duration) {
+	g.graph.OnNodeExpiring(func(n primitive.Node) time.Duration {
+		return fn(g.node(n))
+	})
+}
+
+// OnNodeExpiring calls Graph.OnNodeExpiring on the default graph
+func OnNodeExpiring(fn func(n *Node) time.Duration) {
+	defaultGraph.OnNodeExpiring(fn)
+}
+
+// OnEdgeExpiring registers a callback that is passed every edge about to expire(see OnNodeExpiring)
+func (g *Graph) OnEdgeExpiring(fn func(e *Edge) time.Duration) {
+	g.graph.OnEdgeExpiring(func(e *primitive.Edge) time.Duration {
+		return fn(g.edge(e))
+	})
+}
+
+// OnEdgeExpiring calls Graph.OnEdgeExpiring on the default graph
+func OnEdgeExpiring(fn func(e *Edge) time.Duration) {
+	defaultGraph.OnEdgeExpiring(fn)
+}
+
+// OnNodeExpired registers a hook that is passed a copy of every node removed because its TTL ran out. The node no
+// longer exists in the graph, so its attributes are passed as they were when it expired.
+func (g *Graph) OnNodeExpired(fn func(n primitive.Node), opts ...primitive.HookOption) {
+	g.graph.OnNodeExpired(fn, opts...)
+}
+
+// OnNodeExpired calls Graph.OnNodeExpired on the default graph
+func OnNodeExpired(fn func(n primitive.Node), opts ...primitive.HookOption) {
+	defaultGraph.OnNodeExpired(fn, opts...)
+}
+
+// OnEdgeExpired registers a hook that is passed a copy of every edge removed because its TTL ran out
+func (g *Graph) OnEdgeExpired(fn func(e *primitive.Edge), opts ...primitive.HookOption) {
+	g.graph.OnEdgeExpired(fn, opts...)
+}
+
+// OnEdgeExpired calls Graph.OnEdgeExpired on the default graph
+func OnEdgeExpired(fn func(e *primitive.Edge), opts ...primitive.HookOption) {
+	defaultGraph.OnEdgeExpired(fn, opts...)
+}
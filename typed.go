@@ -0,0 +1,71 @@
+package dagger
+
+import (
+	"encoding/json"
+	"github.com/autom8ter/dagger/primitive"
+)
+
+// TypedNode is a node whose attributes hold the json encoded fields of a T, so they can be read & written as a struct
+// instead of one attribute at a time
+type TypedNode[T any] struct {
+	*Node
+}
+
+// NewTypedNode creates a node of the given type in the graph with the fields of data as its attributes. If data does
+// not have an _id field, a random uuid will be assigned.
+func NewTypedNode[T any](g *Graph, nodeType string, data T) (*TypedNode[T], error) {
+	attributes, err := typedAttributes(data)
+	if err != nil {
+		return nil, err
+	}
+	attributes[primitive.TYPE_KEY] = nodeType
+	return &TypedNode[T]{Node: g.NewNode(attributes)}, nil
+}
+
+// AsTyped wraps an existing node so its attributes can be read & written as a T
+func AsTyped[T any](n *Node) *TypedNode[T] {
+	return &TypedNode[T]{Node: n}
+}
+
+// GetData decodes the node's attributes into a T
+func (t *TypedNode[T]) GetData() (T, error) {
+	var data T
+	bits, err := json.Marshal(t.Raw())
+	if err != nil {
+		return data, err
+	}
+	err = json.Unmarshal(bits, &data)
+	return data, err
+}
+
+// SetData replaces the node's attributes with the fields of data, keeping the node's type & id
+func (t *TypedNode[T]) SetData(data T) error {
+	if err := t.owner().writable(); err != nil {
+		return err
+	}
+	attributes, err := typedAttributes(data)
+	if err != nil {
+		return err
+	}
+	attributes[primitive.TYPE_KEY] = t.Type()
+	attributes[primitive.ID_KEY] = t.ID()
+	return t.owner().graph.AddNode(attributes)
+}
+
+// ConnectTyped creates a connection/edge between two typed nodes with the given relationship type
+// if mutual = true, the connection is doubly linked - (facebook is mutual, instagram is not)
+func ConnectTyped[A, B any](from *TypedNode[A], to *TypedNode[B], relationship string, mutual bool) (*Edge, error) {
+	return from.Connect(to, relationship, mutual)
+}
+
+func typedAttributes(data interface{}) (primitive.Node, error) {
+	bits, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	attributes := primitive.Node{}
+	if err := json.Unmarshal(bits, &attributes); err != nil {
+		return nil, err
+	}
+	return attributes, nil
+}
@@ -0,0 +1,295 @@
+(function () {
+  "use strict";
+
+  var palette = ["#2563eb", "#16a34a", "#d97706", "#db2777", "#7c3aed", "#0891b2", "#dc2626", "#4b5563"];
+  var canvas = document.getElementById("canvas");
+  var ctx = canvas.getContext("2d");
+  var state = { nodes: [], edges: [], byKey: {}, colors: {}, filters: { nodeType: null, edgeType: null } };
+  var view = { x: 0, y: 0, scale: 1 };
+  var drag = null;
+  var selected = null;
+  var alpha = 1;
+
+  function color(type) {
+    if (!(type in state.colors)) {
+      state.colors[type] = palette[Object.keys(state.colors).length % palette.length];
+    }
+    return state.colors[type];
+  }
+
+  function query() {
+    var params = [];
+    ["nodeType", "edgeType"].forEach(function (name) {
+      var checked = state.filters[name];
+      if (checked === null) {
+        return;
+      }
+      if (checked.length === 0) {
+        // no type is named "", so an empty filter matches nothing
+        params.push(name + "=");
+      }
+      checked.forEach(function (t) {
+        params.push(name + "=" + encodeURIComponent(t));
+      });
+    });
+    return params.length ? "?" + params.join("&") : "";
+  }
+
+  function load() {
+    fetch("graph" + query()).then(function (res) {
+      if (!res.ok) {
+        throw new Error(res.status + " " + res.statusText);
+      }
+      return res.json();
+    }).then(render).catch(function (err) {
+      status("failed to load the graph: " + err.message, true);
+    });
+  }
+
+  function status(text, warn) {
+    var el = document.getElementById("status");
+    el.textContent = text;
+    el.className = warn ? "truncated" : "";
+  }
+
+  function render(doc) {
+    var previous = state.byKey;
+    state.byKey = {};
+    state.nodes = doc.nodes.map(function (n, i) {
+      var old = previous[n.key];
+      var angle = i * 2.399963;
+      var radius = 10 * Math.sqrt(i + 1);
+      n.x = old ? old.x : Math.cos(angle) * radius;
+      n.y = old ? old.y : Math.sin(angle) * radius;
+      n.vx = 0;
+      n.vy = 0;
+      state.byKey[n.key] = n;
+      return n;
+    });
+    state.edges = doc.edges.filter(function (e) {
+      e.source = state.byKey[e.from];
+      e.target = state.byKey[e.to];
+      return e.source && e.target;
+    });
+    checkboxes("nodeTypes", "nodeType", doc.nodeTypes, true);
+    checkboxes("edgeTypes", "edgeType", doc.edgeTypes, false);
+    status(state.nodes.length + " nodes, " + state.edges.length + " edges" +
+      (doc.truncated ? " (truncated)" : ""), doc.truncated);
+    alpha = 1;
+  }
+
+  function checkboxes(id, filter, types, swatches) {
+    var el = document.getElementById(id);
+    el.innerHTML = "";
+    types.forEach(function (t) {
+      var label = document.createElement("label");
+      var box = document.createElement("input");
+      box.type = "checkbox";
+      box.checked = state.filters[filter] === null || state.filters[filter].indexOf(t) >= 0;
+      box.addEventListener("change", function () {
+        var checked = [];
+        el.querySelectorAll("input").forEach(function (b) {
+          if (b.checked) {
+            checked.push(b.value);
+          }
+        });
+        state.filters[filter] = checked.length === types.length ? null : checked;
+        load();
+      });
+      box.value = t;
+      label.appendChild(box);
+      if (swatches) {
+        var swatch = document.createElement("span");
+        swatch.className = "swatch";
+        swatch.style.background = color(t);
+        label.appendChild(swatch);
+      }
+      label.appendChild(document.createTextNode(t));
+      el.appendChild(label);
+    });
+  }
+
+  // step advances the force-directed layout: nodes repel each other, edges pull their endpoints together and
+  // everything drifts towards the origin. alpha cools the simulation so the layout settles.
+  function step() {
+    var nodes = state.nodes;
+    if (alpha < 0.005 || nodes.length === 0) {
+      return;
+    }
+    var i, j, a, b, dx, dy, d2, f;
+    for (i = 0; i < nodes.length; i++) {
+      a = nodes[i];
+      for (j = i + 1; j < nodes.length; j++) {
+        b = nodes[j];
+        dx = a.x - b.x;
+        dy = a.y - b.y;
+        d2 = dx * dx + dy * dy + 0.01;
+        if (d2 > 250000) {
+          continue;
+        }
+        f = 900 / d2;
+        a.vx += dx * f;
+        a.vy += dy * f;
+        b.vx -= dx * f;
+        b.vy -= dy * f;
+      }
+    }
+    state.edges.forEach(function (e) {
+      dx = e.target.x - e.source.x;
+      dy = e.target.y - e.source.y;
+      var d = Math.sqrt(dx * dx + dy * dy) || 1;
+      f = (d - 80) * 0.02;
+      e.source.vx += dx / d * f;
+      e.source.vy += dy / d * f;
+      e.target.vx -= dx / d * f;
+      e.target.vy -= dy / d * f;
+    });
+    nodes.forEach(function (n) {
+      n.vx -= n.x * 0.002;
+      n.vy -= n.y * 0.002;
+      if (drag && drag.node === n) {
+        n.vx = 0;
+        n.vy = 0;
+        return;
+      }
+      n.x += Math.max(-20, Math.min(20, n.vx * alpha));
+      n.y += Math.max(-20, Math.min(20, n.vy * alpha));
+      n.vx *= 0.6;
+      n.vy *= 0.6;
+    });
+    alpha *= 0.995;
+  }
+
+  function draw() {
+    var ratio = window.devicePixelRatio || 1;
+    var w = canvas.clientWidth, h = canvas.clientHeight;
+    if (canvas.width !== w * ratio || canvas.height !== h * ratio) {
+      canvas.width = w * ratio;
+      canvas.height = h * ratio;
+    }
+    ctx.setTransform(ratio, 0, 0, ratio, 0, 0);
+    ctx.clearRect(0, 0, w, h);
+    ctx.translate(w / 2 + view.x, h / 2 + view.y);
+    ctx.scale(view.scale, view.scale);
+    ctx.lineWidth = 1 / view.scale;
+    state.edges.forEach(function (e) {
+      var dx = e.target.x - e.source.x, dy = e.target.y - e.source.y;
+      var d = Math.sqrt(dx * dx + dy * dy) || 1;
+      var tx = e.target.x - dx / d * 7, ty = e.target.y - dy / d * 7;
+      ctx.strokeStyle = e === selected ? "#111827" : "#9ca3af";
+      ctx.fillStyle = ctx.strokeStyle;
+      ctx.beginPath();
+      ctx.moveTo(e.source.x, e.source.y);
+      ctx.lineTo(tx, ty);
+      ctx.stroke();
+      ctx.beginPath();
+      ctx.moveTo(tx, ty);
+      ctx.lineTo(tx - dx / d * 6 - dy / d * 3, ty - dy / d * 6 + dx / d * 3);
+      ctx.lineTo(tx - dx / d * 6 + dy / d * 3, ty - dy / d * 6 - dx / d * 3);
+      ctx.fill();
+      if (view.scale > 1.2) {
+        ctx.fillStyle = "#6b7280";
+        ctx.font = 9 / view.scale * 1.2 + "px sans-serif";
+        ctx.fillText(e.type, (e.source.x + e.target.x) / 2, (e.source.y + e.target.y) / 2);
+      }
+    });
+    state.nodes.forEach(function (n) {
+      ctx.beginPath();
+      ctx.arc(n.x, n.y, 6, 0, 2 * Math.PI);
+      ctx.fillStyle = color(n.type);
+      ctx.fill();
+      if (n === selected) {
+        ctx.strokeStyle = "#111827";
+        ctx.lineWidth = 2 / view.scale;
+        ctx.stroke();
+        ctx.lineWidth = 1 / view.scale;
+      }
+      if (view.scale > 0.6) {
+        ctx.fillStyle = "#1f2937";
+        ctx.font = 11 / view.scale * Math.min(view.scale, 1.5) + "px sans-serif";
+        ctx.fillText(n.label, n.x + 8, n.y + 4);
+      }
+    });
+  }
+
+  function frame() {
+    step();
+    draw();
+    window.requestAnimationFrame(frame);
+  }
+
+  function toGraph(ev) {
+    var rect = canvas.getBoundingClientRect();
+    return {
+      x: (ev.clientX - rect.left - rect.width / 2 - view.x) / view.scale,
+      y: (ev.clientY - rect.top - rect.height / 2 - view.y) / view.scale
+    };
+  }
+
+  function hit(p) {
+    var best = null, bestDist = 100 / (view.scale * view.scale);
+    state.nodes.forEach(function (n) {
+      var d = (n.x - p.x) * (n.x - p.x) + (n.y - p.y) * (n.y - p.y);
+      if (d < bestDist) {
+        best = n;
+        bestDist = d;
+      }
+    });
+    if (best) {
+      return best;
+    }
+    state.edges.forEach(function (e) {
+      var dx = e.target.x - e.source.x, dy = e.target.y - e.source.y;
+      var len = dx * dx + dy * dy || 1;
+      var t = Math.max(0, Math.min(1, ((p.x - e.source.x) * dx + (p.y - e.source.y) * dy) / len));
+      var x = e.source.x + t * dx - p.x, y = e.source.y + t * dy - p.y;
+      if (x * x + y * y < 16 / (view.scale * view.scale)) {
+        best = e;
+      }
+    });
+    return best;
+  }
+
+  function select(item) {
+    selected = item;
+    var el = document.getElementById("selected");
+    if (!item) {
+      el.textContent = "click a node or edge";
+      return;
+    }
+    var heading = item.key ? item.key : item.type + ": " + item.from + " -> " + item.to;
+    el.textContent = heading + "\n" + JSON.stringify(item.attributes, null, 2);
+  }
+
+  canvas.addEventListener("mousedown", function (ev) {
+    var p = toGraph(ev);
+    var item = hit(p);
+    select(item);
+    drag = { node: item && item.key ? item : null, x: ev.clientX, y: ev.clientY, vx: view.x, vy: view.y };
+  });
+  window.addEventListener("mousemove", function (ev) {
+    if (!drag) {
+      return;
+    }
+    if (drag.node) {
+      var p = toGraph(ev);
+      drag.node.x = p.x;
+      drag.node.y = p.y;
+      alpha = Math.max(alpha, 0.3);
+    } else {
+      view.x = drag.vx + ev.clientX - drag.x;
+      view.y = drag.vy + ev.clientY - drag.y;
+    }
+  });
+  window.addEventListener("mouseup", function () {
+    drag = null;
+  });
+  canvas.addEventListener("wheel", function (ev) {
+    ev.preventDefault();
+    view.scale = Math.max(0.05, Math.min(8, view.scale * Math.exp(-ev.deltaY * 0.001)));
+  }, { passive: false });
+  document.getElementById("refresh").addEventListener("click", load);
+
+  load();
+  frame();
+})();
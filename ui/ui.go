@@ -0,0 +1,210 @@
+// Package ui serves a single page app that draws a live graph with a force-directed layout, for debugging graph state
+// in a browser instead of reading json exports, ex:
+//
+//	http.Handle("/ui/", http.StripPrefix("/ui", ui.Handler(g)))
+//
+// The page & its script are embedded, so the package needs no assets at runtime. The graph is read on every request,
+// a page of nodes at a time, and can be filtered by node & edge type.
+package ui
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"github.com/autom8ter/dagger"
+	"github.com/autom8ter/dagger/primitive"
+	"io/fs"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed static
+var static embed.FS
+
+type config struct {
+	limit  int
+	labels []string
+}
+
+// Option configures Handler
+type Option func(c *config)
+
+// WithLimit caps the number of nodes sent to the browser per page(the default is 2000), so a large graph does not hang
+// the page. Responses that hit the limit are flagged as truncated. A limit <= 0 disables the cap.
+func WithLimit(limit int) Option {
+	return func(c *config) {
+		c.limit = limit
+	}
+}
+
+// WithNodeLabel labels each node with the first of the attributes that it has(the default is name).
+// Nodes without any of the attributes are labelled with their type & id.
+func WithNodeLabel(attributes ...string) Option {
+	return func(c *config) {
+		c.labels = attributes
+	}
+}
+
+// Graph is the json document the page draws
+type Graph struct {
+	Nodes     []Node   `json:"nodes"`
+	Edges     []Edge   `json:"edges"`
+	NodeTypes []string `json:"nodeTypes"`
+	EdgeTypes []string `json:"edgeTypes"`
+	// Truncated is true if nodes were left out to honor the limit
+	Truncated bool `json:"truncated"`
+	// Next is the cursor of the next page of nodes if the document is truncated
+	Next string `json:"next,omitempty"`
+}
+
+// Node is a node of the graph document
+type Node struct {
+	Key        string                 `json:"key"`
+	ID         string                 `json:"id"`
+	Type       string                 `json:"type"`
+	Label      string                 `json:"label"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// Edge is an edge of the graph document. From & To are the keys of its endpoints. The from node is always in the same
+// document, but the to node may be on another page.
+type Edge struct {
+	ID         string                 `json:"id"`
+	Type       string                 `json:"type"`
+	From       string                 `json:"from"`
+	To         string                 `json:"to"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// Handler returns an http handler that serves the page at / and the graph document at /graph. The graph document may
+// be filtered with repeated nodeType & edgeType query parameters(ex: /graph?nodeType=user&edgeType=friend). Nodes are
+// served in type.id order, a page of up to limit nodes at a time: pass a truncated document's next cursor as the cursor
+// query parameter to get the following page. If g is nil, the default graph is served.
+func Handler(g *dagger.Graph, opts ...Option) http.Handler {
+	if g == nil {
+		g = dagger.Default()
+	}
+	c := &config{
+		limit:  2000,
+		labels: []string{"name"},
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	assets, _ := fs.Sub(static, "static")
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.FS(assets)))
+	mux.HandleFunc("/graph", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		limit := c.limit
+		if l := query.Get("limit"); l != "" {
+			parsed, err := strconv.Atoi(l)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid limit: %s", l), http.StatusBadRequest)
+				return
+			}
+			limit = parsed
+		}
+		doc := c.document(g, query["nodeType"], query["edgeType"], query.Get("cursor"), limit)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(doc)
+	})
+	return mux
+}
+
+// document reads a page of the nodes of the types(all types if none are given) after the cursor, along with the edges of
+// the types that stem from them to nodes of the types. Only the page's nodes & edges are copied from the graph.
+func (c *config) document(g *dagger.Graph, nodeTypes, edgeTypes []string, cursor string, limit int) *Graph {
+	doc := &Graph{
+		Nodes:     []Node{},
+		Edges:     []Edge{},
+		NodeTypes: g.NodeTypes(),
+		EdgeTypes: g.EdgeTypes(),
+	}
+	includes := func(types []string, typ string) bool {
+		if len(types) == 0 {
+			return true
+		}
+		for _, t := range types {
+			if t == typ {
+				return true
+			}
+		}
+		return false
+	}
+	// the cursor is the type.id key of the last node of the previous page
+	cursorType, cursorID := cursor, ""
+	if i := strings.Index(cursor, "."); i >= 0 {
+		cursorType, cursorID = cursor[:i], cursor[i+1:]
+	}
+	var page []primitive.Node
+	for _, typ := range doc.NodeTypes {
+		if !includes(nodeTypes, typ) || typ < cursorType {
+			continue
+		}
+		after := ""
+		if typ == cursorType {
+			after = cursorID
+		}
+		remaining := 0
+		if limit > 0 {
+			remaining = limit - len(page)
+			if remaining == 0 {
+				// the page is full: it is truncated if there are nodes left
+				if more, _ := g.Primitive().NodesPage(typ, after, 1); len(more) > 0 {
+					doc.Next = key(page[len(page)-1])
+				}
+				break
+			}
+		}
+		nodes, next := g.Primitive().NodesPage(typ, after, remaining)
+		page = append(page, nodes...)
+		if next != "" {
+			doc.Next = typ + "." + next
+			break
+		}
+	}
+	doc.Truncated = doc.Next != ""
+	for _, n := range page {
+		n := n.Copy()
+		doc.Nodes = append(doc.Nodes, Node{
+			Key:        key(n),
+			ID:         n.ID(),
+			Type:       n.Type(),
+			Label:      c.label(n),
+			Attributes: n,
+		})
+		g.Primitive().EdgesFrom(dagger.AnyType(), n, func(e *primitive.Edge) bool {
+			if includes(edgeTypes, e.Type()) && includes(nodeTypes, e.To.Type()) {
+				doc.Edges = append(doc.Edges, Edge{
+					ID:         e.ID(),
+					Type:       e.Type(),
+					From:       key(e.From),
+					To:         key(e.To),
+					Attributes: e.Node.Copy(),
+				})
+			}
+			return true
+		})
+	}
+	sort.Slice(doc.Edges, func(i, j int) bool {
+		return doc.Edges[i].Type+"."+doc.Edges[i].ID < doc.Edges[j].Type+"."+doc.Edges[j].ID
+	})
+	return doc
+}
+
+func (c *config) label(n primitive.Node) string {
+	for _, attr := range c.labels {
+		if n.Exists(attr) {
+			return fmt.Sprint(n.Get(attr))
+		}
+	}
+	return key(n)
+}
+
+func key(id primitive.TypedID) string {
+	return id.Type() + "." + id.ID()
+}
@@ -0,0 +1,189 @@
+package dagger
+
+import (
+	"github.com/autom8ter/dagger/primitive"
+	"time"
+)
+
+// NodeView is a read only view of a node, handed out by a ReadOnlyGraph. It has none of Node's mutation methods, so
+// code holding a NodeView cannot change the graph.
+type NodeView struct {
+	node *Node
+}
+
+// EdgeView is a read only view of an edge, handed out by a ReadOnlyGraph. It has none of Edge's mutation methods, so
+// code holding an EdgeView cannot change the graph.
+type EdgeView struct {
+	edge *Edge
+}
+
+func nodeView(n *Node) *NodeView {
+	return &NodeView{node: n}
+}
+
+func edgeView(e *Edge) *EdgeView {
+	return &EdgeView{edge: e}
+}
+
+// Type returns the node's type
+func (n *NodeView) Type() string {
+	return n.node.Type()
+}
+
+// ID returns the node's id
+func (n *NodeView) ID() string {
+	return n.node.ID()
+}
+
+// Get gets an empty interface value(any value type) from the node's attributes(if it exists)
+func (n *NodeView) Get(key string) interface{} {
+	return n.node.Get(key)
+}
+
+// GetString gets a string value from the node's attributes(if it exists)
+func (n *NodeView) GetString(key string) string {
+	return n.node.GetString(key)
+}
+
+// GetInt gets an int value from the node's attributes(if it exists)
+func (n *NodeView) GetInt(key string) int {
+	return n.node.GetInt(key)
+}
+
+// GetBool gets a bool value from the node's attributes(if it exists)
+func (n *NodeView) GetBool(key string) bool {
+	return n.node.GetBool(key)
+}
+
+// GetTime gets a time value from the node's attributes(if it exists)
+func (n *NodeView) GetTime(key string) time.Time {
+	return n.node.GetTime(key)
+}
+
+// GetPath gets a nested attribute by a dotted path of keys(see Node.GetPath)
+func (n *NodeView) GetPath(path string) (interface{}, bool) {
+	return n.node.GetPath(path)
+}
+
+// Range iterates over the node's attributes until the iterator returns false
+func (n *NodeView) Range(fn func(key string, value interface{}) bool) {
+	n.node.Range(fn)
+}
+
+// Copy returns a copy of the node's attributes
+func (n *NodeView) Copy() primitive.Node {
+	return n.node.Copy()
+}
+
+// JSON returns the node as JSON bytes
+func (n *NodeView) JSON() ([]byte, error) {
+	return n.node.JSON()
+}
+
+// EdgesFrom iterates over the edges of the given type that stem from the node until the iterator returns false
+func (n *NodeView) EdgesFrom(edgeType primitive.Type, fn func(e *EdgeView) bool) {
+	n.node.EdgesFrom(edgeType, func(e *Edge) bool {
+		return fn(edgeView(e))
+	})
+}
+
+// EdgesTo iterates over the edges of the given type that point toward the node until the iterator returns false
+func (n *NodeView) EdgesTo(edgeType primitive.Type, fn func(e *EdgeView) bool) {
+	n.node.EdgesTo(edgeType, func(e *Edge) bool {
+		return fn(edgeView(e))
+	})
+}
+
+// Neighbors returns the nodes this node points to over edges of the given type(or any type if it is "*")
+func (n *NodeView) Neighbors(edgeType string) []*NodeView {
+	return nodeViews(n.node.Neighbors(edgeType))
+}
+
+// InNeighbors returns the nodes that point to this node over edges of the given type(or any type if it is "*")
+func (n *NodeView) InNeighbors(edgeType string) []*NodeView {
+	return nodeViews(n.node.InNeighbors(edgeType))
+}
+
+// Degree returns the number of edges of the given type(or any type if it is "*") connected to the node in the direction
+func (n *NodeView) Degree(direction Direction, edgeType string) int {
+	return n.node.Degree(direction, edgeType)
+}
+
+// BFS walks the nodes reachable over outgoing edges level by level(see Node.BFS)
+func (n *NodeView) BFS(depth int, fn func(n *NodeView) bool) {
+	n.node.BFS(depth, func(node *Node) bool {
+		return fn(nodeView(node))
+	})
+}
+
+func nodeViews(nodes []*Node) []*NodeView {
+	views := make([]*NodeView, 0, len(nodes))
+	for _, n := range nodes {
+		views = append(views, nodeView(n))
+	}
+	return views
+}
+
+// Type returns the edge's type
+func (e *EdgeView) Type() string {
+	return e.edge.Type()
+}
+
+// ID returns the edge's id
+func (e *EdgeView) ID() string {
+	return e.edge.ID()
+}
+
+// From returns the node that points to the node returned by To()
+func (e *EdgeView) From() *NodeView {
+	return nodeView(e.edge.From())
+}
+
+// To returns the node that is being pointed to by From()
+func (e *EdgeView) To() *NodeView {
+	return nodeView(e.edge.To())
+}
+
+// Reverse returns the edge's mutual counterpart(see Edge.Reverse)
+func (e *EdgeView) Reverse() (*EdgeView, bool) {
+	reverse, ok := e.edge.Reverse()
+	if !ok {
+		return nil, false
+	}
+	return edgeView(reverse), true
+}
+
+// Weight returns the edge's weight, or 1 if the edge has no weight
+func (e *EdgeView) Weight() float64 {
+	return e.edge.Weight()
+}
+
+// Get gets an empty interface value(any value type) from the edge's attributes(if it exists)
+func (e *EdgeView) Get(key string) interface{} {
+	return e.edge.Get(key)
+}
+
+// GetString gets a string value from the edge's attributes(if it exists)
+func (e *EdgeView) GetString(key string) string {
+	return e.edge.GetString(key)
+}
+
+// GetInt gets an int value from the edge's attributes(if it exists)
+func (e *EdgeView) GetInt(key string) int {
+	return e.edge.GetInt(key)
+}
+
+// GetBool gets a bool value from the edge's attributes(if it exists)
+func (e *EdgeView) GetBool(key string) bool {
+	return e.edge.GetBool(key)
+}
+
+// Range iterates over the edge's attributes until the iterator returns false
+func (e *EdgeView) Range(fn func(key string, value interface{}) bool) {
+	e.edge.Range(fn)
+}
+
+// JSON returns the edge as JSON bytes
+func (e *EdgeView) JSON() ([]byte, error) {
+	return e.edge.JSON()
+}
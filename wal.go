@@ -0,0 +1,68 @@
+package dagger
+
+import (
+	"github.com/autom8ter/dagger/primitive"
+	"os"
+	"time"
+)
+
+// Recover replays the write-ahead log at the path into the graph, then logs every subsequent change to it so the
+// graph can be recovered again after a crash. The log is created if it does not exist. Call Recover at startup, before
+// the graph is modified.
+func (g *Graph) Recover(path string) error {
+	f, err := os.Open(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		defer f.Close()
+		if err := g.graph.Recover(f); err != nil {
+			return err
+		}
+	}
+	wal, err := primitive.OpenWAL(path, false)
+	if err != nil {
+		return err
+	}
+	g.graph.SetWAL(wal)
+	return nil
+}
+
+// Recover calls Graph.Recover on the default graph
+func Recover(path string) error {
+	return defaultGraph.Recover(path)
+}
+
+// ErrWALFailed is returned by mutations once appending to the write-ahead log fails. The graph becomes read only.
+var ErrWALFailed = primitive.ErrWALFailed
+
+// CheckpointWAL compacts the write-ahead log set up by Recover, replacing the changes logged so far with the graph's
+// current nodes & edges so recovery time is bounded by the size of the graph rather than its history. WriteSnapshot
+// checkpoints the log after every snapshot. AsOf cannot reconstruct the graph as it was before the last checkpoint. It
+// returns ErrNoWAL if Recover has not been called.
+func (g *Graph) CheckpointWAL() error {
+	return g.graph.CheckpointWAL()
+}
+
+// CheckpointWAL calls Graph.CheckpointWAL on the default graph
+func CheckpointWAL() error {
+	return defaultGraph.CheckpointWAL()
+}
+
+// ErrNoWAL is returned when reading the log of a graph that is not logging changes to a WAL
+var ErrNoWAL = primitive.ErrNoWAL
+
+// AsOf reconstructs the graph as it was at the given time by replaying the write-ahead log set up by Recover. Changes
+// made before the log was set up are not included. It returns ErrNoWAL if Recover has not been called.
+func (g *Graph) AsOf(t time.Time) (*ReadOnlyGraph, error) {
+	past, err := g.graph.AsOf(t)
+	if err != nil {
+		return nil, err
+	}
+	return &ReadOnlyGraph{graph: &Graph{graph: past}}, nil
+}
+
+// AsOf calls Graph.AsOf on the default graph
+func AsOf(t time.Time) (*ReadOnlyGraph, error) {
+	return defaultGraph.AsOf(t)
+}